@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"alfred-ai/internal/infra/config"
+	"alfred-ai/internal/security"
+)
+
+func runAudit() error {
+	if len(os.Args) < 3 {
+		printAuditUsage()
+		return nil
+	}
+
+	switch os.Args[2] {
+	case "verify":
+		path := ""
+		if len(os.Args) > 3 && !strings.HasPrefix(os.Args[3], "-") {
+			path = os.Args[3]
+		}
+		return runAuditVerify(path)
+	default:
+		return fmt.Errorf("unknown audit subcommand: %s\n\nRun 'alfred-ai audit' for usage", os.Args[2])
+	}
+}
+
+func printAuditUsage() {
+	fmt.Println(`alfred-ai audit - Audit log tools
+
+USAGE:
+    alfred-ai audit <COMMAND>
+
+COMMANDS:
+    verify [path]      Check the audit log's hash chain, HMACs and anchors
+                       (default path: security.audit.path)`)
+}
+
+// loadAuditKey returns the audit HMAC key: ALFREDAI_AUDIT_KEY if set,
+// otherwise the configured key file. Both hold the key hex-encoded, so the
+// key file's contents can be moved into the environment as they are.
+func loadAuditKey(cfg *config.Config, create bool) ([]byte, error) {
+	if v := os.Getenv("ALFREDAI_AUDIT_KEY"); v != "" {
+		key, err := hex.DecodeString(strings.TrimSpace(v))
+		if err != nil || len(key) == 0 {
+			return nil, fmt.Errorf("ALFREDAI_AUDIT_KEY: invalid hex key")
+		}
+		return key, nil
+	}
+	if cfg.Security.Audit.KeyFile == "" {
+		return nil, fmt.Errorf("security.audit.key_file is not set")
+	}
+	return security.LoadAuditKey(cfg.Security.Audit.KeyFile, create)
+}
+
+func runAuditVerify(path string) error {
+	cfg, err := loadConfigOrDefault(configPath())
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if path == "" {
+		path = cfg.Security.Audit.Path
+	}
+
+	// Without the key anyone who can rewrite the log could have rebuilt
+	// the chain, so there is nothing to vouch for.
+	key, err := loadAuditKey(cfg, false)
+	if err != nil {
+		return fmt.Errorf("audit key: %w", err)
+	}
+
+	v, err := security.VerifyAuditLog(path, cfg.Security.Audit.AnchorPath, key)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Audit log: %s\n", path)
+	fmt.Printf("  records:    %d chained", v.Records)
+	if v.Unchained > 0 {
+		fmt.Printf(", %d legacy (unverifiable)", v.Unchained)
+	}
+	fmt.Println()
+	if v.Checkpoint {
+		fmt.Printf("  checkpoint: records before seq %d removed by retention\n", v.FirstSeq)
+	}
+	fmt.Printf("  head:       seq %d %s\n", v.HeadSeq, v.HeadHash)
+	if cfg.Security.Audit.AnchorPath != "" {
+		fmt.Printf("  anchors:    %d checked (%s)\n", v.Anchors, cfg.Security.Audit.AnchorPath)
+	}
+
+	if v.Break != nil {
+		fmt.Printf("\nFAIL: %v\n", v.Break)
+		return fmt.Errorf("audit log verification failed")
+	}
+	// With no signed records and no anchor to compare against, an emptied
+	// or rewritten log looks the same as an untouched one.
+	if v.Records == 0 && cfg.Security.Audit.AnchorPath == "" {
+		fmt.Println("\nUNVERIFIED: no signed records and no anchor to check against")
+		return fmt.Errorf("audit log could not be verified")
+	}
+	fmt.Println("\nOK: audit log intact")
+	return nil
+}
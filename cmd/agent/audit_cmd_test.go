@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"alfred-ai/internal/infra/config"
+	"alfred-ai/internal/security"
+)
+
+func TestLoadAuditKey_EnvIsHex(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "audit.key")
+	fileKey, err := security.LoadAuditKey(keyFile, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The key file's contents work unchanged as the env value.
+	t.Setenv("ALFREDAI_AUDIT_KEY", string(data))
+	cfg := config.Defaults()
+	got, err := loadAuditKey(cfg, false)
+	if err != nil {
+		t.Fatalf("loadAuditKey: %v", err)
+	}
+	if !bytes.Equal(got, fileKey) {
+		t.Errorf("env key = %x, want the key file's %x", got, fileKey)
+	}
+
+	t.Setenv("ALFREDAI_AUDIT_KEY", "not hex")
+	if _, err := loadAuditKey(cfg, false); err == nil {
+		t.Error("expected error for a non-hex env key")
+	}
+}
+
+func TestRunAuditVerify_EmptyLogUnverified(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "audit.jsonl")
+	if err := writeTestFile(t, logPath, ""); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ALFREDAI_CONFIG", filepath.Join(dir, "missing.yaml"))
+	t.Setenv("ALFREDAI_AUDIT_KEY", "00112233445566778899aabbccddeeff")
+
+	if err := runAuditVerify(logPath); err == nil {
+		t.Error("expected an empty log without an anchor to fail verification")
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 
 	"alfred-ai/internal/adapter/llm"
@@ -22,6 +23,9 @@ type AgentComponents struct {
 	Compressor     *usecase.Compressor
 	Approver       domain.ToolApprover
 	ProcessManager *process.Manager // can be nil
+
+	// InteractiveApprover is set when tool_approval.interactive is on.
+	InteractiveApprover *usecase.InteractiveApprover
 }
 
 // initAgent initializes agent components (tools, context builder, agent)
@@ -38,14 +42,36 @@ func initAgent(
 ) (*AgentComponents, error) {
 	// 1. Init tool approver (if enabled)
 	var approver domain.ToolApprover
+	var interactive *usecase.InteractiveApprover
 	if cfg.Agent.ToolApproval.Enabled {
-		approver = usecase.NewConfigApprover(
+		lists := usecase.NewConfigApprover(
 			cfg.Agent.ToolApproval.AlwaysApprove,
 			cfg.Agent.ToolApproval.AlwaysDeny,
 		)
+		approver = lists
+		if cfg.Agent.ToolApproval.Interactive {
+			interactive = usecase.NewInteractiveApprover(lists, bus, cfg.Agent.ToolApproval.Timeout, log)
+			if security.AuditLogger != nil {
+				interactive.SetAuditLogger(security.AuditLogger)
+			}
+			approver = interactive
+		}
+		if len(cfg.Agent.ToolApproval.Rules) > 0 {
+			policy, err := usecase.NewApprovalPolicy(approvalRules(cfg.Agent.ToolApproval.Rules))
+			if err != nil {
+				return nil, fmt.Errorf("tool approval rules: %w", err)
+			}
+			policyApprover := usecase.NewPolicyApprover(policy, approver, log)
+			if security.AuditLogger != nil {
+				policyApprover.SetAuditLogger(security.AuditLogger)
+			}
+			approver = policyApprover
+		}
 		log.Info("tool approval enabled",
 			"always_approve", cfg.Agent.ToolApproval.AlwaysApprove,
 			"always_deny", cfg.Agent.ToolApproval.AlwaysDeny,
+			"interactive", cfg.Agent.ToolApproval.Interactive,
+			"rules", len(cfg.Agent.ToolApproval.Rules),
 		)
 	}
 
@@ -131,20 +157,44 @@ func initAgent(
 
 	// Email tool (opt-in, excluded from edge builds)
 	if cfg.Tools.EmailEnabled && !edgeBuild {
-		toolRegistry.Register(tool.NewEmailTool(nil, cfg.Tools.EmailTimeout, cfg.Tools.EmailMaxSendsPerHour, cfg.Tools.EmailAllowedDomains, log))
-		log.Info("email tool enabled", "timeout", cfg.Tools.EmailTimeout, "max_sends_per_hour", cfg.Tools.EmailMaxSendsPerHour)
+		emailBackend, err := createEmailBackend(cfg, log)
+		if err != nil {
+			log.Warn("email backend init failed, tool disabled", "error", err)
+		} else {
+			toolRegistry.Register(tool.NewEmailTool(emailBackend, cfg.Tools.EmailTimeout, cfg.Tools.EmailMaxSendsPerHour, cfg.Tools.EmailAllowedDomains, log))
+			log.Info("email tool enabled", "backend", cfg.Tools.EmailBackend, "timeout", cfg.Tools.EmailTimeout, "max_sends_per_hour", cfg.Tools.EmailMaxSendsPerHour)
+		}
 	}
 
 	// Calendar tool (opt-in, excluded from edge builds)
 	if cfg.Tools.CalendarEnabled && !edgeBuild {
-		toolRegistry.Register(tool.NewCalendarTool(nil, cfg.Tools.CalendarTimeout, log))
-		log.Info("calendar tool enabled", "timeout", cfg.Tools.CalendarTimeout)
+		calendarBackend, err := createCalendarBackend(cfg, log)
+		if err != nil {
+			log.Warn("calendar backend init failed, tool disabled", "error", err)
+		} else {
+			toolRegistry.Register(tool.NewCalendarTool(calendarBackend, cfg.Tools.CalendarTimeout, log))
+			log.Info("calendar tool enabled", "backend", cfg.Tools.CalendarBackend, "timeout", cfg.Tools.CalendarTimeout)
+		}
 	}
 
 	// Smart Home tool (opt-in, available in all builds including edge).
 	if cfg.Tools.SmartHomeEnabled {
-		toolRegistry.Register(tool.NewSmartHomeTool(nil, cfg.Tools.SmartHomeURL, cfg.Tools.SmartHomeToken, cfg.Tools.SmartHomeTimeout, cfg.Tools.SmartHomeMaxCallsPerMinute, log))
-		log.Info("smart home tool enabled", "url", cfg.Tools.SmartHomeURL, "timeout", cfg.Tools.SmartHomeTimeout)
+		policy := &tool.SmartHomeEntityPolicy{
+			Allow:    cfg.Tools.SmartHomeAllowedEntities,
+			Deny:     cfg.Tools.SmartHomeDeniedEntities,
+			Services: cfg.Tools.SmartHomeAllowedServices,
+		}
+		smartHomeBackend, err := createSmartHomeBackend(cfg, log)
+		if err != nil {
+			log.Warn("smart home backend init failed, tool disabled", "error", err)
+		} else {
+			toolRegistry.Register(tool.NewSmartHomeTool(smartHomeBackend, cfg.Tools.SmartHomeURL, cfg.Tools.SmartHomeToken, cfg.Tools.SmartHomeTimeout, cfg.Tools.SmartHomeMaxCallsPerMinute, log,
+				tool.WithSmartHomeEntityPolicy(policy)))
+			log.Info("smart home tool enabled", "backend", cfg.Tools.SmartHomeBackend, "url", cfg.Tools.SmartHomeURL, "timeout", cfg.Tools.SmartHomeTimeout)
+			if ha, ok := smartHomeBackend.(*tool.HomeAssistantBackend); ok && cfg.Tools.SmartHomeEvents {
+				go ha.WatchStateChanges(ctx, bus, policy)
+			}
+		}
 	}
 
 	// MCP bridge (opt-in, excluded from edge builds): connect to MCP servers and register discovered tools.
@@ -325,9 +375,40 @@ func initAgent(
 		Compressor:     compressor,
 		Approver:       approver,
 		ProcessManager: processManager,
+
+		InteractiveApprover: interactive,
 	}, nil
 }
 
+// approvalRules converts configured tool approval rules.
+func approvalRules(rules []config.ToolApprovalRule) []usecase.ApprovalRule {
+	out := make([]usecase.ApprovalRule, 0, len(rules))
+	for _, r := range rules {
+		rule := usecase.ApprovalRule{
+			Name:     r.Name,
+			Tool:     r.Tool,
+			Action:   usecase.PolicyAction(r.Action),
+			Agents:   r.Agents,
+			Tenants:  r.Tenants,
+			Channels: r.Channels,
+			Roles:    domain.StringsToAuthRoles(r.Roles),
+		}
+		for _, a := range r.Args {
+			rule.Args = append(rule.Args, usecase.ArgCondition{
+				Path:    a.Path,
+				Equals:  a.Equals,
+				OneOf:   a.OneOf,
+				Matches: a.Matches,
+				Under:   a.Under,
+				Absent:  a.Absent,
+				Not:     a.Not,
+			})
+		}
+		out = append(out, rule)
+	}
+	return out
+}
+
 // createSearchBackend builds the configured search backend.
 func createSearchBackend(cfg *config.Config, log *slog.Logger) tool.SearchBackend {
 	switch cfg.Tools.SearchBackend {
@@ -368,6 +449,55 @@ func createCanvasBackend(cfg *config.Config) (tool.CanvasBackend, error) {
 	}
 }
 
+// createEmailBackend builds the configured email backend.
+func createEmailBackend(cfg *config.Config, log *slog.Logger) (tool.EmailBackend, error) {
+	switch cfg.Tools.EmailBackend {
+	case "imap":
+		return tool.NewIMAPSMTPBackend(tool.IMAPSMTPConfig{
+			IMAPAddr:     cfg.Tools.EmailIMAPAddr,
+			IMAPTLS:      cfg.Tools.EmailIMAPTLS,
+			SMTPAddr:     cfg.Tools.EmailSMTPAddr,
+			SMTPTLS:      cfg.Tools.EmailSMTPTLS,
+			Username:     cfg.Tools.EmailUsername,
+			Password:     cfg.Tools.EmailPassword,
+			From:         cfg.Tools.EmailFrom,
+			DraftsFolder: cfg.Tools.EmailDraftsFolder,
+			Timeout:      cfg.Tools.EmailTimeout,
+		}, log)
+	default:
+		return tool.NewMockEmailBackend(), nil
+	}
+}
+
+// createCalendarBackend builds the configured calendar backend.
+func createCalendarBackend(cfg *config.Config, log *slog.Logger) (tool.CalendarBackend, error) {
+	switch cfg.Tools.CalendarBackend {
+	case "caldav":
+		return tool.NewCalDAVBackend(tool.CalDAVConfig{
+			URL:      cfg.Tools.CalendarURL,
+			Username: cfg.Tools.CalendarUsername,
+			Password: cfg.Tools.CalendarPassword,
+			Timeout:  cfg.Tools.CalendarTimeout,
+		}, log)
+	default:
+		return tool.NewMockCalendarBackend(), nil
+	}
+}
+
+// createSmartHomeBackend builds the configured smart home backend.
+func createSmartHomeBackend(cfg *config.Config, log *slog.Logger) (tool.SmartHomeBackend, error) {
+	switch cfg.Tools.SmartHomeBackend {
+	case "homeassistant":
+		return tool.NewHomeAssistantBackend(tool.HomeAssistantConfig{
+			URL:     cfg.Tools.SmartHomeURL,
+			Token:   cfg.Tools.SmartHomeToken,
+			Timeout: cfg.Tools.SmartHomeTimeout,
+		}, log)
+	default:
+		return tool.NewMockSmartHomeBackend(), nil
+	}
+}
+
 // createBrowserBackend builds the configured browser backend.
 func createBrowserBackend(cfg *config.Config, log *slog.Logger) (tool.BrowserBackend, error) {
 	switch cfg.Tools.BrowserBackend {
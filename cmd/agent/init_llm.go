@@ -7,12 +7,17 @@ import (
 	"alfred-ai/internal/adapter/llm"
 	"alfred-ai/internal/domain"
 	"alfred-ai/internal/infra/config"
+	"alfred-ai/internal/infra/tracer"
+	"alfred-ai/internal/usecase/usage"
 )
 
 // LLMComponents holds all LLM-related components
 type LLMComponents struct {
-	Registry   *llm.Registry
-	DefaultLLM domain.LLMProvider
+	Registry    *llm.Registry
+	DefaultLLM  domain.LLMProvider
+	Usage       *usage.Tracker    // nil when usage accounting is disabled
+	UsageLedger *usage.FileLedger // nil when usage accounting is disabled
+	Routes      llm.RouteSet      // health-aware routes, empty when none are configured
 }
 
 // initLLM initializes LLM providers, registry, and failover
@@ -21,21 +26,52 @@ func initLLM(cfg *config.Config, log *slog.Logger) (*LLMComponents, error) {
 	// 1. Create LLM registry
 	registry := llm.NewRegistry()
 
-	// 2. Register all configured providers
+	// 2. Usage accounting (optional)
+	var tracker *usage.Tracker
+	var ledger *usage.FileLedger
+	if cfg.LLM.Usage.Enabled {
+		var err error
+		tracker, ledger, err = newUsageTracker(cfg.LLM.Usage, log)
+		if err != nil {
+			return nil, fmt.Errorf("usage: %w", err)
+		}
+		log.Info("llm usage accounting enabled", "data_dir", cfg.LLM.Usage.DataDir, "budgets", len(cfg.LLM.Usage.Budgets))
+	}
+
+	// 3. Register all configured providers
 	cbCfg := cfg.LLM.CircuitBreaker
+	breakers := make(map[string]*llm.CircuitBreakerProvider)
+	var costs []*llm.CostProvider
 	for _, pc := range cfg.LLM.Providers {
 		provider, err := createLLMProvider(pc, log)
 		if err != nil {
 			return nil, fmt.Errorf("llm provider %s: %w", pc.Name, err)
 		}
 
+		// Validate and repair JSON replies to requests with a response format.
+		provider = llm.NewStructuredProvider(provider, log)
+
 		// Wrap with circuit breaker if enabled (per-provider).
 		if cbCfg.Enabled {
-			provider = llm.NewCircuitBreakerProvider(provider, llm.CircuitBreakerConfig{
+			cb := llm.NewCircuitBreakerProvider(provider, llm.CircuitBreakerConfig{
 				MaxFailures: cbCfg.MaxFailures,
 				Timeout:     cbCfg.Timeout,
 				Interval:    cbCfg.Interval,
 			}, log)
+			breakers[cb.Name()] = cb
+			provider = cb
+		}
+
+		// Record latency and token metrics, including fast-failed calls.
+		if cfg.Tracer.Metrics.Enabled {
+			provider = llm.NewMetricsProvider(provider)
+		}
+
+		// Price every call and enforce budgets before the call is made.
+		if tracker != nil {
+			cp := llm.NewCostProvider(provider, tracker, pc.Model)
+			costs = append(costs, cp)
+			provider = cp
 		}
 
 		if err := registry.Register(provider); err != nil {
@@ -49,30 +85,135 @@ func initLLM(cfg *config.Config, log *slog.Logger) (*LLMComponents, error) {
 			"timeout", cbCfg.Timeout,
 			"interval", cbCfg.Interval,
 		)
+		tracer.ObserveCircuitBreakers(func() map[string]int64 {
+			states := make(map[string]int64, len(breakers))
+			for name, cb := range breakers {
+				states[name] = int64(cb.State())
+			}
+			return states
+		})
 	}
 
-	// 3. Get default provider
+	// 4. Register health-aware routes over the providers
+	var routes llm.RouteSet
+	for _, rc := range cfg.LLM.Routes {
+		route, err := newRoute(rc.Name, rc.Providers, llm.RoutingConfig{
+			Window:       rc.Window,
+			MaxErrorRate: rc.MaxErrorRate,
+			MaxLatency:   rc.MaxLatency,
+		}, registry, breakers, log)
+		if err != nil {
+			return nil, err
+		}
+		if err := registry.Register(route); err != nil {
+			return nil, fmt.Errorf("llm route %s: %w", rc.Name, err)
+		}
+		routes = append(routes, route)
+		log.Info("llm route enabled", "route", rc.Name, "providers", len(rc.Providers))
+	}
+
+	// 5. Get default provider
 	defaultLLM, err := registry.Get(cfg.LLM.DefaultProvider)
 	if err != nil {
 		return nil, fmt.Errorf("default llm provider: %w", err)
 	}
 
-	// 4. Wrap with failover if enabled
+	// 6. Wrap with failover if enabled. The health strategy keeps the
+	// fallbacks as standbys of a route instead of trying them in order.
 	if cfg.LLM.Failover.Enabled && len(cfg.LLM.Failover.Fallbacks) > 0 {
-		var fallbacks []domain.LLMProvider
-		for _, name := range cfg.LLM.Failover.Fallbacks {
-			fb, err := registry.Get(name)
+		if cfg.LLM.Failover.Strategy == "health" {
+			members := []config.RouteMemberConfig{{Provider: cfg.LLM.DefaultProvider}}
+			for _, name := range cfg.LLM.Failover.Fallbacks {
+				members = append(members, config.RouteMemberConfig{Provider: name, Standby: true})
+			}
+			route, err := newRoute(defaultLLM.Name()+"+failover", members, llm.RoutingConfig{}, registry, breakers, log)
 			if err != nil {
-				return nil, fmt.Errorf("failover provider %s: %w", name, err)
+				return nil, err
 			}
-			fallbacks = append(fallbacks, fb)
+			defaultLLM = route
+			routes = append(routes, route)
+			log.Info("health-aware model failover enabled", "fallbacks", cfg.LLM.Failover.Fallbacks)
+		} else {
+			var fallbacks []domain.LLMProvider
+			for _, name := range cfg.LLM.Failover.Fallbacks {
+				fb, err := registry.Get(name)
+				if err != nil {
+					return nil, fmt.Errorf("failover provider %s: %w", name, err)
+				}
+				fallbacks = append(fallbacks, fb)
+			}
+			defaultLLM = llm.NewFailoverProvider(defaultLLM, fallbacks, log)
+			log.Info("model failover enabled", "fallbacks", cfg.LLM.Failover.Fallbacks)
+		}
+	}
+
+	// 7. Downgrade budgets reroute through the model preferences.
+	if len(costs) > 0 && len(cfg.LLM.ModelRouting) > 0 {
+		router := llm.NewPreferenceRouter(cfg.LLM.ModelRouting, registry, defaultLLM)
+		for _, cp := range costs {
+			cp.SetRouter(router)
 		}
-		defaultLLM = llm.NewFailoverProvider(defaultLLM, fallbacks, log)
-		log.Info("model failover enabled", "fallbacks", cfg.LLM.Failover.Fallbacks)
 	}
 
 	return &LLMComponents{
-		Registry:   registry,
-		DefaultLLM: defaultLLM,
+		Registry:    registry,
+		DefaultLLM:  defaultLLM,
+		Usage:       tracker,
+		UsageLedger: ledger,
+		Routes:      routes,
 	}, nil
 }
+
+// newRoute builds a health-aware route over registered providers. Members
+// weigh 1 unless configured otherwise, and standbys 0.
+func newRoute(name string, members []config.RouteMemberConfig, rc llm.RoutingConfig, registry *llm.Registry,
+	breakers map[string]*llm.CircuitBreakerProvider, log *slog.Logger) (*llm.RoutingProvider, error) {
+	rms := make([]llm.RouteMember, 0, len(members))
+	for _, m := range members {
+		provider, err := registry.Get(m.Provider)
+		if err != nil {
+			return nil, fmt.Errorf("llm route %s: %w", name, err)
+		}
+		weight := m.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		if m.Standby {
+			weight = 0
+		}
+		rms = append(rms, llm.RouteMember{Provider: provider, Weight: weight, Breaker: breakers[m.Provider]})
+	}
+	return llm.NewRoutingProvider(name, rms, rc, log), nil
+}
+
+// newUsageTracker opens the cost ledger and builds a tracker with the
+// configured prices and budgets.
+func newUsageTracker(cfg config.UsageConfig, log *slog.Logger) (*usage.Tracker, *usage.FileLedger, error) {
+	ledger, err := usage.NewFileLedger(cfg.DataDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	pricing := make(usage.Pricing, len(cfg.Pricing))
+	for key, p := range cfg.Pricing {
+		pricing[key] = domain.ModelPrice{
+			Input: p.Input, Output: p.Output, Cached: p.Cached, CacheWrite: p.CacheWrite, Thinking: p.Thinking,
+		}
+	}
+	budgets := make([]domain.Budget, 0, len(cfg.Budgets))
+	for _, b := range cfg.Budgets {
+		budgets = append(budgets, domain.Budget{
+			Scope:     domain.BudgetScope(b.Scope),
+			ID:        b.ID,
+			Period:    domain.BudgetPeriod(b.Period),
+			Limit:     b.Limit,
+			Action:    domain.BudgetAction(b.Action),
+			Downgrade: b.Downgrade,
+		})
+	}
+	tracker, err := usage.NewTracker(ledger, pricing, budgets, log)
+	if err != nil {
+		ledger.Close()
+		return nil, nil, err
+	}
+	return tracker, ledger, nil
+}
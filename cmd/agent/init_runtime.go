@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -23,6 +25,7 @@ import (
 	"alfred-ai/internal/usecase/cronjob"
 	"alfred-ai/internal/usecase/multiagent"
 	"alfred-ai/internal/usecase/scheduling"
+	"alfred-ai/internal/usecase/trigger"
 	"alfred-ai/internal/usecase/workflow"
 )
 
@@ -73,6 +76,66 @@ func (r *redisAdapter) Close() error {
 	return r.client.Close()
 }
 
+// singleAgentRunner runs workflow agent steps, event triggers and webhooks
+// on the only agent when multi-agent mode is off.
+type singleAgentRunner struct {
+	agent *usecase.Agent
+}
+
+func (r singleAgentRunner) RunAgent(ctx context.Context, agentID, prompt string, tools []string) (string, error) {
+	if agentID != "" {
+		return "", fmt.Errorf("agent %q: multi-agent mode is not enabled", agentID)
+	}
+	session := usecase.NewSession(fmt.Sprintf("workflow|%d", time.Now().UnixNano()))
+	return r.agent.WithTools(tools).HandleMessage(ctx, session, prompt)
+}
+
+// triggerRules converts configured event triggers to domain rules.
+func triggerRules(rules []config.TriggerRuleConfig) []domain.TriggerRule {
+	out := make([]domain.TriggerRule, len(rules))
+	for i, r := range rules {
+		out[i] = domain.TriggerRule{
+			Event:    r.Event,
+			Filter:   r.Filter,
+			Debounce: r.Debounce,
+			Throttle: r.Throttle,
+			Message:  r.Message,
+			Tools:    r.Tools,
+		}
+	}
+	return out
+}
+
+// gatewayHooks converts configured webhooks to gateway hooks.
+func gatewayHooks(hooks []config.HookConfig) []gateway.Hook {
+	out := make([]gateway.Hook, len(hooks))
+	for i, h := range hooks {
+		out[i] = gateway.Hook{
+			Name:            h.Name,
+			Secret:          h.Secret,
+			Verify:          h.Verify,
+			Header:          h.Header,
+			TimestampHeader: h.TimestampHeader,
+			DeliveryHeader:  h.DeliveryHeader,
+			Tolerance:       h.Tolerance,
+			Agent:           h.Agent,
+			Pipeline:        h.Pipeline,
+			Prompt:          h.Prompt,
+			Tools:           h.Tools,
+			Args:            h.Args,
+			Channel:         h.Channel,
+			Target:          h.Target,
+		}
+	}
+	return out
+}
+
+// approvalPrompter is implemented by channels that show tool approval
+// prompts (Telegram, Slack, TUI).
+type approvalPrompter interface {
+	SetEventBus(bus domain.EventBus)
+}
+
 // RuntimeComponents holds runtime components (channels, router, scheduler, gateway, cron, tenants, cluster)
 type RuntimeComponents struct {
 	Router        *usecase.Router
@@ -91,6 +154,8 @@ func initRuntime(
 	cfg *config.Config,
 	llmRegistry *llm.Registry,
 	llmProvider domain.LLMProvider,
+	usageReporter domain.UsageReporter, // nil when usage accounting is disabled
+	routingReporter domain.RoutingReporter, // nil without health-aware routes
 	agentComp *AgentComponents,
 	features *FeatureComponents,
 	sec *SecurityComponents,
@@ -100,11 +165,18 @@ func initRuntime(
 ) (*RuntimeComponents, func(context.Context) error, error) {
 	comp := &RuntimeComponents{}
 
+	// Session grants of the interactive approver end with their session.
+	var forgetSession func(string)
+	if agentComp.InteractiveApprover != nil {
+		forgetSession = agentComp.InteractiveApprover.ForgetSession
+		agentComp.SessionManager.SetOnRemove(forgetSession)
+	}
+
 	// 1. Init router (single-agent or multi-agent)
 	var registry *multiagent.Registry
 	if cfg.Agents != nil && len(cfg.Agents.Instances) > 0 {
 		comp.Router, registry = initMultiAgent(cfg, llmRegistry, agentComp.ToolRegistry,
-			mem, bus, nil, agentComp.Compressor, agentComp.Approver, log)
+			mem, bus, nil, agentComp.Compressor, agentComp.Approver, forgetSession, log)
 	} else {
 		comp.Router = usecase.NewRouter(agentComp.Agent, agentComp.SessionManager, bus, log)
 	}
@@ -125,6 +197,7 @@ func initRuntime(
 	}
 
 	// Set offline manager if configured
+	var offlineMgr *usecase.OfflineManager
 	if cfg.Offline != nil && cfg.Offline.Enabled {
 		// Resolve a local LLM provider (Ollama) for offline mode.
 		localLLM, err := llmRegistry.Get("ollama")
@@ -146,8 +219,8 @@ func initRuntime(
 			if queueDir == "" {
 				queueDir = "data/offline-queue"
 			}
-			offlineMgr := usecase.NewOfflineManager(localLLM, queueDir, checkURL, checkPeriod, log)
-			offlineMgr.StartMonitor(ctx)
+			offlineMgr = usecase.NewOfflineManager(localLLM, queueDir, checkURL, checkPeriod, log)
+			offlineMgr.SetReplay(comp.Router.Handle)
 			comp.Router.SetOffline(offlineMgr)
 			log.Info("offline mode enabled", "check_url", checkURL, "check_period", checkPeriod)
 		}
@@ -158,6 +231,11 @@ func initRuntime(
 		go sec.KeyRotator.Start(ctx)
 	}
 
+	// Anchor the audit chain head to a separate file if configured
+	if sec.FileAuditLogger != nil && sec.AuditAnchorPath != "" {
+		go sec.FileAuditLogger.RunAnchor(ctx, sec.AuditAnchorPath, sec.AuditAnchorInterval, log)
+	}
+
 	// 2. Build channels
 	channels, cliCh, err := buildChannels(cfg, log, features.PrivacyManager)
 	if err != nil {
@@ -165,7 +243,15 @@ func initRuntime(
 	}
 	comp.Channels = channels
 
+	// Replayed offline answers go back through the original channels, so
+	// the monitor starts once they exist.
+	if offlineMgr != nil {
+		offlineMgr.SetChannels(tool.NewChannelRegistry(comp.Channels, log))
+		offlineMgr.StartMonitor(ctx)
+	}
+
 	// Wire /clear command to actually delete the CLI session
+	interactive := agentComp.InteractiveApprover
 	if cliCh != nil {
 		cliCh.SetOnClear(func() {
 			agentComp.SessionManager.Delete("cli:" + chat.DefaultSessionID)
@@ -173,6 +259,15 @@ func initRuntime(
 		cliCh.SetEventBus(bus)
 	}
 
+	// Channels that render approval prompts natively need the bus.
+	if interactive != nil {
+		for _, ch := range channels {
+			if p, ok := ch.(approvalPrompter); ok {
+				p.SetEventBus(bus)
+			}
+		}
+	}
+
 	// 3. Init scheduler (if enabled)
 	if cfg.Scheduler.Enabled {
 		scheduler := scheduling.NewScheduler(log)
@@ -247,6 +342,9 @@ func initRuntime(
 
 		cronMgr := cronjob.NewManager(cronStore, comp.Scheduler, bus, log)
 		cronMgr.SetHandler(comp.Router)
+		cronMgr.SetTools(agentComp.ToolRegistry)
+		cronMgr.SetApprover(agentComp.Approver)
+		cronMgr.SetChannels(tool.NewChannelRegistry(comp.Channels, log))
 
 		if err := cronMgr.LoadAndSchedule(ctx); err != nil {
 			log.Warn("failed to load persisted cron jobs", "error", err)
@@ -268,13 +366,28 @@ func initRuntime(
 	}
 
 	// 3d. Init workflow tool (if enabled)
+	var workflowMgr *workflow.Manager
+	var workflowDB *workflow.SQLiteStore
 	if cfg.Tools.WorkflowEnabled {
-		workflowStore, err := workflow.NewFileStore(cfg.Tools.WorkflowDataDir)
-		if err != nil {
-			return nil, nil, fmt.Errorf("workflow store: %w", err)
+		var workflowStore domain.WorkflowStore
+		if cfg.Tools.WorkflowStore == "sqlite" {
+			if err := os.MkdirAll(cfg.Tools.WorkflowDataDir, 0700); err != nil {
+				return nil, nil, fmt.Errorf("workflow store: %w", err)
+			}
+			workflowDB, err = workflow.NewSQLiteStore(filepath.Join(cfg.Tools.WorkflowDataDir, "workflow_runs.db"))
+			if err != nil {
+				return nil, nil, fmt.Errorf("workflow store: %w", err)
+			}
+			workflowStore = workflowDB
+		} else {
+			fileStore, err := workflow.NewFileStore(cfg.Tools.WorkflowDataDir)
+			if err != nil {
+				return nil, nil, fmt.Errorf("workflow store: %w", err)
+			}
+			workflowStore = fileStore
 		}
 
-		workflowMgr := workflow.NewManager(
+		workflowMgr = workflow.NewManager(
 			workflowStore,
 			workflow.ManagerConfig{
 				PipelineDir:             cfg.Tools.WorkflowDir,
@@ -295,12 +408,29 @@ func initRuntime(
 			agentComp.ToolRegistry,
 		)
 
+		if registry != nil {
+			workflowMgr.SetAgents(registry)
+		} else {
+			workflowMgr.SetAgents(singleAgentRunner{agent: agentComp.Agent})
+		}
+		if llmRegistry != nil {
+			workflowMgr.SetModelRouter(llm.NewPreferenceRouter(cfg.LLM.ModelRouting, llmRegistry, llmProvider))
+		}
+
 		if err := workflowMgr.LoadPipelines(); err != nil {
 			log.Warn("failed to load workflow pipelines", "error", err)
 		}
+		if resumed, err := workflowMgr.RecoverRuns(ctx); err != nil {
+			log.Warn("failed to recover interrupted workflow runs", "error", err)
+		} else if resumed > 0 {
+			log.Info("resumed interrupted workflow runs", "count", resumed)
+		}
 
 		agentComp.ToolRegistry.Register(tool.NewWorkflowTool(workflowMgr, log))
-		log.Info("workflow tool enabled", "pipeline_dir", cfg.Tools.WorkflowDir)
+		if comp.CronManager != nil {
+			comp.CronManager.SetWorkflows(workflowMgr)
+		}
+		log.Info("workflow tool enabled", "pipeline_dir", cfg.Tools.WorkflowDir, "store", cfg.Tools.WorkflowStore)
 	}
 
 	// 3f. Init voice call tool (if enabled)
@@ -425,6 +555,33 @@ func initRuntime(
 		)
 	}
 
+	// 3g. Init event triggers (if any pipeline or agent declares them)
+	triggerMgr := trigger.NewManager(bus, log)
+	if workflowMgr != nil {
+		triggerMgr.SetPipelines(workflowMgr)
+		for _, p := range workflowMgr.ListPipelines() {
+			if err := triggerMgr.AddPipelineRules(p.Name, p.On); err != nil {
+				log.Warn("skipping pipeline triggers", "pipeline", p.Name, "error", err)
+			}
+		}
+	}
+	if registry != nil {
+		triggerMgr.SetAgents(registry)
+		for _, inst := range cfg.Agents.Instances {
+			if err := triggerMgr.AddAgentRules(inst.ID, triggerRules(inst.On)); err != nil {
+				return nil, nil, fmt.Errorf("agent triggers: %w", err)
+			}
+		}
+	} else {
+		triggerMgr.SetAgents(singleAgentRunner{agent: agentComp.Agent})
+	}
+	if err := triggerMgr.AddAgentRules("", triggerRules(cfg.Agent.On)); err != nil {
+		return nil, nil, fmt.Errorf("agent triggers: %w", err)
+	}
+	if triggerMgr.RuleCount() > 0 {
+		triggerMgr.Start(ctx)
+	}
+
 	// 4. Init tenant manager (if enabled)
 	if cfg.Tenants != nil && cfg.Tenants.Enabled {
 		tenantStore, err := tenant.NewSQLiteTenantStore(
@@ -522,6 +679,12 @@ func initRuntime(
 		if sec.GDPRHandler != nil {
 			gwDeps.GDPRHandler = sec.GDPRHandler
 		}
+		if usageReporter != nil {
+			gwDeps.Usage = usageReporter
+		}
+		if routingReporter != nil {
+			gwDeps.Routing = routingReporter
+		}
 		gateway.RegisterDefaultHandlers(gwServer, gwDeps)
 
 		// Register REST endpoints (status + metrics).
@@ -530,6 +693,28 @@ func initRuntime(
 			channelNames[i] = ch.Name()
 		}
 		gateway.RegisterRESTHandlers(gwServer, gwDeps, channelNames)
+		gateway.RegisterOpenAIHandlers(gwServer, gwDeps)
+
+		// Register inbound webhooks (/hooks/{name}).
+		if len(cfg.Gateway.Hooks) > 0 {
+			hookDeps := gateway.HookDeps{
+				Channels:    tool.NewChannelRegistry(comp.Channels, log),
+				AuditLogger: sec.AuditLogger,
+				Logger:      log,
+			}
+			if registry != nil {
+				hookDeps.Agents = registry
+			} else {
+				hookDeps.Agents = singleAgentRunner{agent: agentComp.Agent}
+			}
+			if workflowMgr != nil {
+				hookDeps.Pipelines = workflowMgr
+			}
+			if err := gateway.RegisterHookHandlers(gwServer, gatewayHooks(cfg.Gateway.Hooks), hookDeps); err != nil {
+				return nil, nil, fmt.Errorf("gateway hooks: %w", err)
+			}
+			log.Info("gateway webhooks enabled", "hooks", len(cfg.Gateway.Hooks))
+		}
 
 		comp.Gateway = gwServer
 		log.Info("gateway enabled", "addr", cfg.Gateway.Addr)
@@ -547,6 +732,9 @@ func initRuntime(
 			comp.Gateway.Stop(ctx)
 		}
 
+		// Stop event triggers so no new runs start during shutdown.
+		triggerMgr.Stop()
+
 		// Then stop process manager (kill all running processes).
 		if agentComp.ProcessManager != nil {
 			agentComp.ProcessManager.Stop(ctx)
@@ -574,6 +762,12 @@ func initRuntime(
 			}
 		}
 
+		if workflowDB != nil {
+			if err := workflowDB.Close(); err != nil {
+				log.Warn("workflow store close error", "error", err)
+			}
+		}
+
 		return nil
 	}
 
@@ -43,6 +43,10 @@ type SecurityComponents struct {
 	KeyRotator     *security.KeyRotator
 	Authorizer     domain.Authorizer       // RBAC authorizer; nil when RBAC is disabled
 	GDPRHandler    *security.GDPRHandler   // nil when audit or memory is unavailable
+
+	// Audit chain anchoring; AuditAnchorPath is empty when disabled.
+	AuditAnchorPath     string
+	AuditAnchorInterval time.Duration
 }
 
 // initSecurity initializes all security components (sandbox, encryption, audit logging)
@@ -99,7 +103,12 @@ func initSecurity(cfg *config.Config, log *slog.Logger) (*SecurityComponents, fu
 			return nil, nil, fmt.Errorf("create audit dir: %w", err)
 		}
 
-		fileAudit, err := security.NewFileAuditLogger(cfg.Security.Audit.Path)
+		auditKey, err := loadAuditKey(cfg, true)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("audit key: %w", err)
+		}
+		fileAudit, err := security.NewFileAuditLogger(cfg.Security.Audit.Path, security.WithAuditKey(auditKey))
 		if err != nil {
 			cleanup()
 			return nil, nil, fmt.Errorf("audit logger: %w", err)
@@ -139,7 +148,20 @@ func initSecurity(cfg *config.Config, log *slog.Logger) (*SecurityComponents, fu
 			fileAudit.Close()
 		})
 
-		log.Info("audit logging enabled", "path", cfg.Security.Audit.Path)
+		if cfg.Security.Audit.AnchorPath != "" {
+			comp.AuditAnchorPath = cfg.Security.Audit.AnchorPath
+			comp.AuditAnchorInterval = time.Hour
+			if v := cfg.Security.Audit.AnchorInterval; v != "" {
+				d, err := time.ParseDuration(v)
+				if err != nil {
+					cleanup()
+					return nil, nil, fmt.Errorf("parse audit anchor_interval: %w", err)
+				}
+				comp.AuditAnchorInterval = d
+			}
+		}
+
+		log.Info("audit logging enabled", "path", cfg.Security.Audit.Path, "anchor", cfg.Security.Audit.AnchorPath)
 	}
 
 	// 4. Initialize secret scanning (if enabled)
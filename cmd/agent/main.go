@@ -75,6 +75,21 @@ func main() {
 			fmt.Fprintf(os.Stderr, "doctor: %v\n", err)
 			os.Exit(1)
 		}
+	case "audit":
+		if err := runAudit(); err != nil {
+			fmt.Fprintf(os.Stderr, "audit: %v\n", err)
+			os.Exit(1)
+		}
+	case "memory":
+		if err := runMemory(); err != nil {
+			fmt.Fprintf(os.Stderr, "memory: %v\n", err)
+			os.Exit(1)
+		}
+	case "usage":
+		if err := runUsage(); err != nil {
+			fmt.Fprintf(os.Stderr, "usage: %v\n", err)
+			os.Exit(1)
+		}
 	default:
 		fmt.Fprintf(os.Stderr, "unknown command: %s\n\nRun 'alfred-ai --help' for usage information.\n", os.Args[1])
 		os.Exit(1)
@@ -95,6 +110,12 @@ COMMANDS:
     plugin      Plugin development tools
                 Subcommands: list, validate, init
     doctor      Run health checks on your setup
+    audit       Audit log tools
+                Subcommands: verify
+    memory      Memory store maintenance
+                Subcommands: reembed
+    usage       LLM cost accounting
+                Subcommands: report
 
     (no command) - Run bot with existing config
 
@@ -116,6 +137,7 @@ EXAMPLES:
     alfred-ai --provider openai --model gpt-4o --key sk-...  # Quick start
     alfred-ai daemon install     # Install as system service
     alfred-ai doctor             # Check system health
+    alfred-ai usage report --by model --since 7d  # LLM spend per model
 
 LEARN MORE:
     Documentation: ./docs/
@@ -256,16 +278,23 @@ func run() error {
 		return fmt.Errorf("llm: %w", err)
 	}
 
+	if llmComponents.UsageLedger != nil {
+		defer llmComponents.UsageLedger.Close()
+	}
+
 	// 5. Event bus
 	bus := eventbus.New(log)
 	defer bus.Close()
+	if llmComponents.Usage != nil {
+		llmComponents.Usage.SetBus(bus)
+	}
 
 	// 6. Memory
 	var contentEnc domain.ContentEncryptor
 	if security.Encryptor != nil {
 		contentEnc = security.Encryptor
 	}
-	mem, memCloser, err := initMemory(cfg.Memory, log, contentEnc)
+	mem, memCloser, err := initMemory(cfg.Memory, log, contentEnc, bus)
 	if err != nil {
 		return fmt.Errorf("memory: %w", err)
 	}
@@ -286,8 +315,16 @@ func run() error {
 	}
 
 	// 9. Runtime (router, channels, scheduler, gateway)
-	runtime, runtimeCleanup, err := initRuntime(ctx, cfg, llmComponents.Registry, llmComponents.DefaultLLM,
-		agentComp, features, security, mem, bus, log)
+	var usageReporter domain.UsageReporter
+	if llmComponents.Usage != nil {
+		usageReporter = llmComponents.Usage
+	}
+	var routingReporter domain.RoutingReporter
+	if len(llmComponents.Routes) > 0 {
+		routingReporter = llmComponents.Routes
+	}
+	runtime, runtimeCleanup, err := initRuntime(ctx, cfg, llmComponents.Registry, llmComponents.DefaultLLM, usageReporter,
+		routingReporter, agentComp, features, security, mem, bus, log)
 	if err != nil {
 		return fmt.Errorf("runtime: %w", err)
 	}
@@ -471,7 +508,7 @@ func runDashboard() error {
 	defer bus.Close()
 
 	var mem domain.MemoryProvider
-	mem, memCloser, err := initMemory(cfg.Memory, log, nil)
+	mem, memCloser, err := initMemory(cfg.Memory, log, nil, nil)
 	if err != nil {
 		log.Warn("memory init failed, dashboard will show limited info", "error", err)
 		mem = nil
@@ -485,6 +522,9 @@ func runDashboard() error {
 		Memory: mem,
 		Config: string(configYAML),
 	}
+	if cfg.LLM.Usage.Enabled {
+		deps.Usage = ledgerUsage{cfg: cfg.LLM.Usage}
+	}
 
 	model := dashboard.NewDashboardModel(deps)
 	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
@@ -496,7 +536,9 @@ func runDashboard() error {
 
 // initMemory creates the appropriate memory provider based on config.
 // Returns the provider, an optional closer (for vector store), and any error.
-func initMemory(cfg config.MemoryConfig, log *slog.Logger, enc domain.ContentEncryptor) (domain.MemoryProvider, func() error, error) {
+// With a bus, the vector store re-embeds stale vectors in the background and
+// reports progress on it.
+func initMemory(cfg config.MemoryConfig, log *slog.Logger, enc domain.ContentEncryptor, bus domain.EventBus) (domain.MemoryProvider, func() error, error) {
 	switch cfg.Provider {
 	case "markdown":
 		var opts []memory.MarkdownOption
@@ -506,7 +548,7 @@ func initMemory(cfg config.MemoryConfig, log *slog.Logger, enc domain.ContentEnc
 		mem, err := memory.NewMarkdownMemory(cfg.DataDir, opts...)
 		return mem, nil, err
 	case "vector":
-		return buildVectorMemory(cfg, log)
+		return buildVectorMemory(cfg, log, bus)
 	case "byterover":
 		client := memory.NewMockByteRoverClient() // TODO: replace with real client when API is ready
 		var opts []memory.ByteRoverOption
@@ -557,6 +599,7 @@ func initMultiAgent(
 	auditLogger domain.AuditLogger,
 	compressor *usecase.Compressor,
 	approver domain.ToolApprover,
+	onSessionRemove func(id string),
 	log *slog.Logger,
 ) (*usecase.Router, *multiagent.Registry) {
 	agentsCfg := cfg.Agents
@@ -597,6 +640,7 @@ func initMultiAgent(
 			continue
 		}
 		agentSessions := usecase.NewSessionManager(sessionDir)
+		agentSessions.SetOnRemove(onSessionRemove)
 
 		// Build model name.
 		agentModel := instCfg.Model
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func runMemory() error {
+	if len(os.Args) < 3 {
+		printMemoryUsage()
+		return nil
+	}
+
+	switch os.Args[2] {
+	case "reembed":
+		cfg, err := loadConfigOrDefault(configPath())
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		if cfg.Memory.Provider != "vector" {
+			return fmt.Errorf("reembed requires memory.provider: vector (got %q)", cfg.Memory.Provider)
+		}
+		return runMemoryReembed(cfg.Memory)
+	default:
+		return fmt.Errorf("unknown memory subcommand: %s\n\nRun 'alfred-ai memory' for usage", os.Args[2])
+	}
+}
+
+func printMemoryUsage() {
+	fmt.Println(`alfred-ai memory - Memory store maintenance
+
+USAGE:
+    alfred-ai memory <COMMAND>
+
+COMMANDS:
+    reembed            Re-embed vector memory entries whose vectors came from
+                       a different embedding provider, model or dimension`)
+}
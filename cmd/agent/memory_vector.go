@@ -3,9 +3,14 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"time"
 
 	"alfred-ai/internal/adapter/embedding"
 	"alfred-ai/internal/adapter/memory/vector"
@@ -13,14 +18,93 @@ import (
 	"alfred-ai/internal/infra/config"
 )
 
-func buildVectorMemory(cfg config.MemoryConfig, log *slog.Logger) (domain.MemoryProvider, func() error, error) {
+func buildVectorMemory(cfg config.MemoryConfig, log *slog.Logger, bus domain.EventBus) (domain.MemoryProvider, func() error, error) {
+	store, err := openVectorStore(cfg, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Re-embed vectors from a previous embedder in the background.
+	if bus != nil {
+		opts := reembedOptions(cfg.Embedding)
+		opts.Progress = func(p vector.ReembedProgress) {
+			publishReembed(bus, domain.EventMemoryReembedProgress, p)
+		}
+		_, err := store.StartReembed(opts, func(p vector.ReembedProgress, err error) {
+			if err == nil {
+				publishReembed(bus, domain.EventMemoryReembedCompleted, p)
+			}
+		})
+		if err != nil {
+			log.Warn("vector store: re-embed not started", "error", err)
+		}
+	}
+
+	return store, store.Close, nil
+}
+
+func reembedOptions(cfg config.EmbeddingConfig) vector.ReembedOptions {
+	return vector.ReembedOptions{
+		BatchSize: cfg.ReembedBatchSize,
+		Rate:      cfg.ReembedRate,
+	}
+}
+
+func publishReembed(bus domain.EventBus, typ domain.EventType, p vector.ReembedProgress) {
+	payload, _ := json.Marshal(p)
+	bus.Publish(context.Background(), domain.Event{
+		Type:      typ,
+		Timestamp: time.Now(),
+		Payload:   payload,
+	})
+}
+
+// runMemoryReembed re-embeds stale entries in the foreground, printing
+// progress. Interrupting it keeps the work done so far.
+func runMemoryReembed(cfg config.MemoryConfig) error {
+	if cfg.Embedding.Provider == "" {
+		return fmt.Errorf("memory.embedding.provider is not set")
+	}
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	store, err := openVectorStore(cfg, log)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	opts := reembedOptions(cfg.Embedding)
+	opts.Progress = func(p vector.ReembedProgress) {
+		fmt.Printf("\r  %d/%d re-embedded, %d failed", p.Done, p.Total, p.Failed)
+	}
+	p, err := store.Reembed(ctx, opts)
+	if p.Total == 0 && err == nil {
+		fmt.Printf("All vectors were produced by %s; nothing to do.\n", p.Model)
+		return nil
+	}
+	fmt.Println()
+	if err != nil {
+		return fmt.Errorf("re-embed stopped after %d of %d entries: %w", p.Done, p.Total, err)
+	}
+	fmt.Printf("Re-embedded %d entries with %s", p.Done, p.Model)
+	if p.Failed > 0 {
+		fmt.Printf("; %d failed (run again to retry)", p.Failed)
+	}
+	fmt.Println()
+	return nil
+}
+
+// openVectorStore opens the vector store with the configured embedder.
+func openVectorStore(cfg config.MemoryConfig, log *slog.Logger) (*vector.Store, error) {
 	var embedder domain.EmbeddingProvider
 
 	if cfg.Embedding.Provider != "" {
 		var err error
 		embedder, err = createEmbedder(cfg.Embedding)
 		if err != nil {
-			return nil, nil, fmt.Errorf("embedding provider: %w", err)
+			return nil, fmt.Errorf("embedding provider: %w", err)
 		}
 		log.Info("embedding provider initialized",
 			"provider", embedder.Name(),
@@ -36,21 +120,21 @@ func buildVectorMemory(cfg config.MemoryConfig, log *slog.Logger) (domain.Memory
 
 	// Map search config to vector store options.
 	var searchOpts []vector.SearchOpts
-	if cfg.Search.DecayHalfLife > 0 || cfg.Search.MMRDiversity > 0 || cfg.Search.MaxVectorCandidates > 0 {
+	if cfg.Search.DecayHalfLife > 0 || cfg.Search.MMRDiversity > 0 || cfg.Search.MaxVectorCandidates > 0 || cfg.Search.HNSWEfSearch > 0 {
 		searchOpts = append(searchOpts, vector.SearchOpts{
 			DecayHalfLife:       cfg.Search.DecayHalfLife,
 			MMRDiversity:        cfg.Search.MMRDiversity,
 			MaxVectorCandidates: cfg.Search.MaxVectorCandidates,
+			HNSWEfSearch:        cfg.Search.HNSWEfSearch,
 		})
 	}
 
 	dbPath := filepath.Join(cfg.DataDir, "vector.db")
 	store, err := vector.New(dbPath, embedder, log, searchOpts...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("vector store: %w", err)
+		return nil, fmt.Errorf("vector store: %w", err)
 	}
-
-	return store, store.Close, nil
+	return store, nil
 }
 
 func createEmbedder(cfg config.EmbeddingConfig) (domain.EmbeddingProvider, error) {
@@ -10,6 +10,10 @@ import (
 	"alfred-ai/internal/infra/config"
 )
 
-func buildVectorMemory(_ config.MemoryConfig, _ *slog.Logger) (domain.MemoryProvider, func() error, error) {
+func buildVectorMemory(_ config.MemoryConfig, _ *slog.Logger, _ domain.EventBus) (domain.MemoryProvider, func() error, error) {
 	return nil, nil, fmt.Errorf("vector memory requires build with -tags vector_memory")
 }
+
+func runMemoryReembed(_ config.MemoryConfig) error {
+	return fmt.Errorf("vector memory requires build with -tags vector_memory")
+}
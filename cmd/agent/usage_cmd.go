@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"alfred-ai/internal/domain"
+	"alfred-ai/internal/infra/config"
+	"alfred-ai/internal/usecase/usage"
+)
+
+func runUsage() error {
+	if len(os.Args) < 3 {
+		printUsageUsage()
+		return nil
+	}
+
+	switch os.Args[2] {
+	case "report":
+		return runUsageReport(os.Args[3:])
+	default:
+		return fmt.Errorf("unknown usage subcommand: %s\n\nRun 'alfred-ai usage' for usage", os.Args[2])
+	}
+}
+
+func printUsageUsage() {
+	fmt.Println(`alfred-ai usage - LLM cost accounting
+
+USAGE:
+    alfred-ai usage <COMMAND>
+
+COMMANDS:
+    report             Summarize the cost ledger (llm.usage.data_dir)
+        --since WHEN   Start of the report: a date (2006-01-02) or a number
+                       of days ("7d"); default: start of this month
+        --by GROUP     Group rows by day, provider, model, tenant, agent or
+                       session (default: day)`)
+}
+
+func runUsageReport(args []string) error {
+	fs := flag.NewFlagSet("usage report", flag.ContinueOnError)
+	since := fs.String("since", "", "report start")
+	by := fs.String("by", "day", "grouping")
+	fs.String("config", "", "config file path") // read by configPath
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfigOrDefault(configPath())
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	start, err := parseReportSince(*since, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ledger, err := usage.NewFileLedger(cfg.LLM.Usage.DataDir)
+	if err != nil {
+		return err
+	}
+	defer ledger.Close()
+	records, err := ledger.Records(start)
+	if err != nil {
+		return err
+	}
+	rows, err := usage.Report(records, *by)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Usage since %s (%s)\n\n", start.Format("2006-01-02"), cfg.LLM.Usage.DataDir)
+	if !cfg.LLM.Usage.Enabled {
+		fmt.Println("note: llm.usage.enabled is false; no new calls are being recorded")
+		fmt.Println()
+	}
+	printUsageRows(os.Stdout, strings.ToUpper(*by), rows)
+	return nil
+}
+
+// parseReportSince reads a --since value: a date, a day count like "7d",
+// or empty for the start of the current month.
+func parseReportSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("invalid --since %q", s)
+		}
+		y, m, d := now.AddDate(0, 0, -n).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location()), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q (want 2006-01-02 or <n>d)", s)
+	}
+	return t, nil
+}
+
+func printUsageRows(w io.Writer, group string, rows []usage.ReportRow) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tCALLS\tPROMPT\tCACHED\tCACHE WRITE\tCOMPLETION\tTHINKING\tCOST (USD)\t\n", group)
+	var total domain.UsageTotals
+	for _, r := range rows {
+		printUsageRow(tw, r.Key, r.UsageTotals)
+		total.Calls += r.Calls
+		total.Usage.PromptTokens += r.Usage.PromptTokens
+		total.Usage.CachedTokens += r.Usage.CachedTokens
+		total.Usage.CacheWriteTokens += r.Usage.CacheWriteTokens
+		total.Usage.CompletionTokens += r.Usage.CompletionTokens
+		total.Usage.ThinkingTokens += r.Usage.ThinkingTokens
+		total.CostUSD += r.CostUSD
+	}
+	printUsageRow(tw, "TOTAL", total)
+	tw.Flush()
+}
+
+func printUsageRow(w io.Writer, key string, t domain.UsageTotals) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%.4f\t\n", key, t.Calls, t.Usage.PromptTokens,
+		t.Usage.CachedTokens, t.Usage.CacheWriteTokens, t.Usage.CompletionTokens, t.Usage.ThinkingTokens, t.CostUSD)
+}
+
+// ledgerUsage reports usage straight from the ledger on disk, for
+// processes that do not make LLM calls themselves (the dashboard).
+type ledgerUsage struct {
+	cfg config.UsageConfig
+}
+
+// Summary rebuilds this month's totals from the ledger on every call so
+// it follows the agent process writing it.
+func (u ledgerUsage) Summary() domain.UsageSummary {
+	tracker, ledger, err := newUsageTracker(u.cfg, slogDiscard())
+	if err != nil {
+		return domain.UsageSummary{}
+	}
+	defer ledger.Close()
+	return tracker.Summary()
+}
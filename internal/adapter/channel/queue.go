@@ -0,0 +1,44 @@
+package channel
+
+import "sync"
+
+// chatQueue runs work off the caller's goroutine while keeping it ordered per
+// chat. Channels use it when a handler may block on input that arrives through
+// the same receive loop, such as a tool approval prompt.
+type chatQueue struct {
+	mu      sync.Mutex
+	backlog map[string][]func() // chat ID -> queued work; key present while a worker runs
+}
+
+// Go schedules fn after any earlier work for the same chat.
+func (q *chatQueue) Go(chatID string, fn func()) {
+	q.mu.Lock()
+	if q.backlog == nil {
+		q.backlog = make(map[string][]func())
+	}
+	if queued, busy := q.backlog[chatID]; busy {
+		q.backlog[chatID] = append(queued, fn)
+		q.mu.Unlock()
+		return
+	}
+	q.backlog[chatID] = nil
+	q.mu.Unlock()
+
+	go q.drain(chatID, fn)
+}
+
+func (q *chatQueue) drain(chatID string, fn func()) {
+	for fn != nil {
+		fn()
+
+		q.mu.Lock()
+		if queued := q.backlog[chatID]; len(queued) > 0 {
+			fn = queued[0]
+			q.backlog[chatID] = queued[1:]
+		} else {
+			delete(q.backlog, chatID)
+			fn = nil
+		}
+		q.mu.Unlock()
+	}
+}
@@ -43,7 +43,10 @@ type SlackChannel struct {
 	channelIDs  map[string]bool
 	mentionOnly bool
 	botUserID   string
-	userNames   sync.Map // cache: userID -> display name
+	userNames   sync.Map        // cache: userID -> display name
+	bus         domain.EventBus // optional, nil = no approval prompts
+	queue       chatQueue
+	approvals   slackApprovals
 	ctx         context.Context
 	cancel      context.CancelFunc
 }
@@ -78,6 +81,10 @@ func (s *SlackChannel) Start(ctx context.Context, handler domain.MessageHandler)
 	s.botUserID = authResp.UserID
 	s.logger.Info("slack channel started", "bot_user_id", s.botUserID)
 
+	if s.bus != nil {
+		s.subscribeApprovals(s.ctx)
+	}
+
 	go s.eventLoop()
 	go func() {
 		if err := s.socketCli.Run(); err != nil {
@@ -130,6 +137,13 @@ func (s *SlackChannel) eventLoop() {
 				case *slackevents.MessageEvent:
 					s.handleMessage(ev)
 				}
+			case socketmode.EventTypeInteractive:
+				callback, ok := evt.Data.(slack.InteractionCallback)
+				if !ok {
+					continue
+				}
+				s.socketCli.Ack(*evt.Request)
+				s.handleInteraction(callback)
 			}
 		}
 	}
@@ -200,8 +214,17 @@ func (s *SlackChannel) handleMessage(ev *slackevents.MessageEvent) {
 		msg.ThreadID = ev.ThreadTimeStamp
 	}
 
-	if err := s.handler(s.ctx, msg); err != nil {
-		s.logger.Error("slack handler error", "error", err, "channel", ev.Channel)
+	handle := func() {
+		if err := s.handler(s.ctx, msg); err != nil {
+			s.logger.Error("slack handler error", "error", err, "channel", ev.Channel)
+		}
+	}
+	// Approval button presses arrive through the event loop, so a handler
+	// waiting on one must not block it.
+	if s.bus != nil {
+		s.queue.Go(ev.Channel, handle)
+	} else {
+		handle()
 	}
 }
 
@@ -0,0 +1,173 @@
+//go:build slack
+
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"alfred-ai/internal/domain"
+	"github.com/slack-go/slack"
+)
+
+// Action IDs of the approval buttons. Button values carry the tool call ID.
+const (
+	slackActionApprove        = "tool_approve"
+	slackActionApproveSession = "tool_approve_session"
+	slackActionDeny           = "tool_deny"
+)
+
+// slackPrompt is an approval prompt posted to a channel.
+type slackPrompt struct {
+	sessionID string
+	channelID string
+	ts        string
+	tool      string
+}
+
+// slackApprovals tracks open prompts by tool call ID.
+type slackApprovals struct {
+	mu      sync.Mutex
+	prompts map[string]*slackPrompt
+}
+
+func (a *slackApprovals) add(toolCallID string, p *slackPrompt) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.prompts == nil {
+		a.prompts = make(map[string]*slackPrompt)
+	}
+	a.prompts[toolCallID] = p
+}
+
+func (a *slackApprovals) take(toolCallID string) *slackPrompt {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p := a.prompts[toolCallID]
+	delete(a.prompts, toolCallID)
+	return p
+}
+
+// SetEventBus enables tool approval prompts. Approval requests for Slack
+// sessions are posted with Approve/Deny buttons, and button presses are
+// published as decisions. Must be called before Start.
+func (s *SlackChannel) SetEventBus(bus domain.EventBus) {
+	s.bus = bus
+}
+
+// subscribeApprovals wires approval events until ctx is done.
+func (s *SlackChannel) subscribeApprovals(ctx context.Context) {
+	unsubReq := s.bus.Subscribe(domain.EventToolApprovalReq, func(_ context.Context, event domain.Event) {
+		var req domain.ApprovalRequest
+		if err := json.Unmarshal(event.Payload, &req); err != nil || req.Channel != s.Name() {
+			return
+		}
+		if err := s.promptApproval(req); err != nil {
+			s.logger.Warn("slack approval prompt failed", "error", err, "tool", req.Tool)
+		}
+	})
+	unsubResp := s.bus.Subscribe(domain.EventToolApprovalResp, func(_ context.Context, event domain.Event) {
+		var d domain.ApprovalDecision
+		if err := json.Unmarshal(event.Payload, &d); err != nil {
+			return
+		}
+		// Decided elsewhere (gateway, timeout): retire the buttons.
+		if p := s.approvals.take(d.ToolCallID); p != nil {
+			s.closePrompt(p, decisionText(d))
+		}
+	})
+	go func() {
+		<-ctx.Done()
+		unsubReq()
+		unsubResp()
+	}()
+}
+
+// promptApproval posts an approval request with buttons.
+func (s *SlackChannel) promptApproval(req domain.ApprovalRequest) error {
+	text := approvalText(req)
+	channelID, ts, err := s.api.PostMessage(req.ChatID,
+		slack.MsgOptionText(text, false),
+		slack.MsgOptionBlocks(slackApprovalBlocks(req.ToolCallID, text)...),
+	)
+	if err != nil {
+		return err
+	}
+	s.approvals.add(req.ToolCallID, &slackPrompt{sessionID: req.SessionID, channelID: channelID, ts: ts, tool: req.Tool})
+	return nil
+}
+
+// slackApprovalBlocks renders the prompt text followed by the three buttons.
+func slackApprovalBlocks(toolCallID, text string) []slack.Block {
+	button := func(actionID, label string, style slack.Style) slack.BlockElement {
+		b := slack.NewButtonBlockElement(actionID, toolCallID, slack.NewTextBlockObject(slack.PlainTextType, label, false, false))
+		if style != "" {
+			b = b.WithStyle(style)
+		}
+		return b
+	}
+	return []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.PlainTextType, text, false, false), nil, nil),
+		slack.NewActionBlock("tool_approval",
+			button(slackActionApprove, "Approve", slack.StylePrimary),
+			button(slackActionApproveSession, "Approve for session", ""),
+			button(slackActionDeny, "Deny", slack.StyleDanger),
+		),
+	}
+}
+
+// handleInteraction publishes the decision for an approval button press.
+func (s *SlackChannel) handleInteraction(cb slack.InteractionCallback) {
+	if cb.Type != slack.InteractionTypeBlockActions || s.bus == nil {
+		return
+	}
+	for _, action := range cb.ActionCallback.BlockActions {
+		d := domain.ApprovalDecision{ToolCallID: action.Value, Scope: domain.ApprovalScopeOnce, DecidedBy: "slack:" + cb.User.ID}
+		switch action.ActionID {
+		case slackActionApprove:
+			d.Approved = true
+		case slackActionApproveSession:
+			d.Approved = true
+			d.Scope = domain.ApprovalScopeSession
+		case slackActionDeny:
+		default:
+			continue
+		}
+
+		p := s.approvals.take(action.Value)
+		if p == nil {
+			continue
+		}
+		// Only presses in the channel the prompt was posted to count.
+		if cb.Channel.ID != p.channelID {
+			s.approvals.add(action.Value, p)
+			continue
+		}
+
+		payload, err := json.Marshal(d)
+		if err != nil {
+			continue
+		}
+		s.bus.Publish(s.ctx, domain.Event{
+			Type:      domain.EventToolApprovalResp,
+			Timestamp: time.Now(),
+			SessionID: p.sessionID,
+			Payload:   payload,
+		})
+		s.closePrompt(p, decisionText(d)+" by <@"+cb.User.ID+">")
+	}
+}
+
+// closePrompt replaces the buttons with the outcome.
+func (s *SlackChannel) closePrompt(p *slackPrompt, outcome string) {
+	text := fmt.Sprintf("Tool %s: %s", p.tool, outcome)
+	if _, _, _, err := s.api.UpdateMessage(p.channelID, p.ts,
+		slack.MsgOptionText(text, false),
+		slack.MsgOptionBlocks(slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)),
+	); err != nil {
+		s.logger.Debug("slack update approval prompt failed", "error", err)
+	}
+}
@@ -3,7 +3,19 @@
 package channel
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+
+	"alfred-ai/internal/domain"
+	"alfred-ai/internal/usecase/eventbus"
 )
 
 func TestSlackChannelName(t *testing.T) {
@@ -50,3 +62,80 @@ func TestSlackMultipleOptions(t *testing.T) {
 		t.Error("options not applied correctly")
 	}
 }
+
+func TestSlackApprovalPrompt(t *testing.T) {
+	var mu sync.Mutex
+	var posted, updated string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/chat.postMessage":
+			posted = r.Form.Get("blocks")
+			w.Write([]byte(`{"ok":true,"channel":"C1","ts":"111.222"}`))
+		case "/chat.update":
+			updated = r.Form.Get("text")
+			w.Write([]byte(`{"ok":true,"channel":"C1","ts":"111.222"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	bus := eventbus.New(newTelegramTestLogger())
+	defer bus.Close()
+	decisions := make(chan domain.ApprovalDecision, 1)
+	bus.Subscribe(domain.EventToolApprovalResp, func(_ context.Context, e domain.Event) {
+		var d domain.ApprovalDecision
+		json.Unmarshal(e.Payload, &d)
+		decisions <- d
+	})
+
+	ch := NewSlackChannel("bot", "app", newTelegramTestLogger())
+	ch.api = slack.New("bot", slack.OptionAPIURL(server.URL+"/"))
+	ch.ctx = context.Background()
+	ch.SetEventBus(bus)
+
+	req := domain.ApprovalRequest{ToolCallID: "call-1", Tool: "shell", SessionID: "slack:C1", Channel: "slack", ChatID: "C1"}
+	if err := ch.promptApproval(req); err != nil {
+		t.Fatalf("promptApproval: %v", err)
+	}
+	mu.Lock()
+	if !strings.Contains(posted, slackActionApproveSession) || !strings.Contains(posted, "call-1") {
+		t.Errorf("unexpected blocks: %s", posted)
+	}
+	mu.Unlock()
+
+	press := func(channelID, actionID string) {
+		var cb slack.InteractionCallback
+		cb.Type = slack.InteractionTypeBlockActions
+		cb.Channel.ID = channelID
+		cb.User.ID = "U7"
+		cb.ActionCallback.BlockActions = []*slack.BlockAction{{ActionID: actionID, Value: "call-1"}}
+		ch.handleInteraction(cb)
+	}
+
+	// A press from another channel is ignored.
+	press("C2", slackActionApprove)
+	select {
+	case d := <-decisions:
+		t.Fatalf("unexpected decision from foreign channel: %+v", d)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	press("C1", slackActionDeny)
+	select {
+	case d := <-decisions:
+		if d.ToolCallID != "call-1" || d.Approved || d.DecidedBy != "slack:U7" {
+			t.Errorf("unexpected decision: %+v", d)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no decision published")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !strings.Contains(updated, "denied") {
+		t.Errorf("prompt not retired, text = %q", updated)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -34,6 +35,9 @@ type TelegramChannel struct {
 	done        chan struct{}
 	botUsername string
 	mentionOnly bool
+	bus         domain.EventBus // optional, nil = no approval prompts
+	queue       chatQueue
+	approvals   telegramApprovals
 }
 
 // NewTelegramChannel creates a Telegram bot channel.
@@ -65,6 +69,10 @@ func (t *TelegramChannel) Start(ctx context.Context, handler domain.MessageHandl
 		t.logger.Warn("telegram getMe failed, mention detection disabled", "error", err)
 	}
 
+	if t.bus != nil {
+		t.subscribeApprovals(ctx)
+	}
+
 	go t.pollLoop(ctx)
 	t.logger.Info("telegram channel started")
 	return nil
@@ -112,6 +120,10 @@ func (t *TelegramChannel) pollLoop(ctx context.Context) {
 				if u.UpdateID >= t.offset {
 					t.offset = u.UpdateID + 1
 				}
+				if u.CallbackQuery != nil {
+					t.handleCallback(ctx, u.CallbackQuery)
+					continue
+				}
 				if u.Message == nil {
 					continue
 				}
@@ -121,7 +133,8 @@ func (t *TelegramChannel) pollLoop(ctx context.Context) {
 				if content == "" {
 					content = u.Message.Caption
 				}
-				if content == "" {
+				hasMedia := len(u.Message.Photo) > 0 || u.Message.Document != nil
+				if content == "" && !hasMedia {
 					continue
 				}
 
@@ -171,11 +184,21 @@ func (t *TelegramChannel) pollLoop(ctx context.Context) {
 					msg.ReplyToID = strconv.FormatInt(u.Message.ReplyToMessage.MessageID, 10)
 				}
 
-				// Enrich media.
+				// Enrich media, fetching file contents so they can reach the model.
 				msg.Media = extractMedia(u.Message)
+				t.downloadMedia(ctx, msg.Media)
 
-				if err := t.handler(ctx, msg); err != nil {
-					t.logger.Error("telegram handler error", "error", err, "chat_id", chatID)
+				handle := func() {
+					if err := t.handler(ctx, msg); err != nil {
+						t.logger.Error("telegram handler error", "error", err, "chat_id", chatID)
+					}
+				}
+				// Approval answers arrive through this loop, so a handler
+				// waiting on one must not block it.
+				if t.bus != nil {
+					t.queue.Go(chatID, handle)
+				} else {
+					handle()
 				}
 			}
 		}
@@ -232,9 +255,10 @@ func extractMedia(msg *telegramMessage) []domain.Media {
 	if len(msg.Photo) > 0 {
 		largest := msg.Photo[len(msg.Photo)-1]
 		media = append(media, domain.Media{
-			Type:    domain.MediaTypeImage,
-			URL:     largest.FileID,
-			Caption: msg.Caption,
+			Type:     domain.MediaTypeImage,
+			URL:      largest.FileID,
+			MIMEType: "image/jpeg", // Telegram re-encodes photos as JPEG
+			Caption:  msg.Caption,
 		})
 	}
 
@@ -245,12 +269,79 @@ func extractMedia(msg *telegramMessage) []domain.Media {
 			URL:      msg.Document.FileID,
 			MIMEType: msg.Document.MIMEType,
 			Caption:  msg.Caption,
+			Filename: msg.Document.FileName,
 		})
 	}
 
 	return media
 }
 
+// maxTelegramDownload is the Bot API's getFile size limit.
+const maxTelegramDownload = 20 * 1024 * 1024
+
+// downloadMedia resolves Telegram file IDs to inline data. Failures are
+// logged and leave the attachment without data.
+func (t *TelegramChannel) downloadMedia(ctx context.Context, media []domain.Media) {
+	for i := range media {
+		data, err := t.downloadFile(ctx, media[i].URL)
+		if err != nil {
+			t.logger.Warn("telegram file download failed", "error", err, "type", media[i].Type)
+			continue
+		}
+		media[i].Data = data
+		if media[i].MIMEType == "" {
+			media[i].MIMEType = http.DetectContentType(data)
+		}
+	}
+}
+
+// downloadFile fetches a file by ID via getFile and the file endpoint.
+func (t *TelegramChannel) downloadFile(ctx context.Context, fileID string) ([]byte, error) {
+	getURL := fmt.Sprintf("%s/bot%s/getFile?file_id=%s", t.baseURL, t.token, url.QueryEscape(fileID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, getURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+	var result telegramGetFileResponse
+	err = json.NewDecoder(io.LimitReader(resp.Body, 1*1024*1024)).Decode(&result)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal getFile: %w", err)
+	}
+	if !result.OK || result.Result.FilePath == "" {
+		return nil, fmt.Errorf("getFile returned ok=%v", result.OK)
+	}
+	if result.Result.FileSize > maxTelegramDownload {
+		return nil, fmt.Errorf("file too large (%d bytes)", result.Result.FileSize)
+	}
+
+	fileURL := fmt.Sprintf("%s/file/bot%s/%s", t.baseURL, t.token, result.Result.FilePath)
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	resp, err = t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("file download status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTelegramDownload+1))
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	if len(data) > maxTelegramDownload {
+		return nil, fmt.Errorf("file too large")
+	}
+	return data, nil
+}
+
 // --- Telegram Bot API types ---
 
 type telegramUser struct {
@@ -279,8 +370,9 @@ type telegramDocument struct {
 }
 
 type telegramUpdate struct {
-	UpdateID int64            `json:"update_id"`
-	Message  *telegramMessage `json:"message"`
+	UpdateID      int64                  `json:"update_id"`
+	Message       *telegramMessage       `json:"message"`
+	CallbackQuery *telegramCallbackQuery `json:"callback_query,omitempty"`
 }
 
 type telegramMessage struct {
@@ -311,16 +403,25 @@ type telegramUpdateResponse struct {
 }
 
 type telegramSendRequest struct {
-	ChatID          string `json:"chat_id"`
-	Text            string `json:"text"`
-	MessageThreadID int64  `json:"message_thread_id,omitempty"`
-	ReplyToMsgID    int64  `json:"reply_to_message_id,omitempty"`
+	ChatID          string                  `json:"chat_id"`
+	Text            string                  `json:"text"`
+	MessageThreadID int64                   `json:"message_thread_id,omitempty"`
+	ReplyToMsgID    int64                   `json:"reply_to_message_id,omitempty"`
+	ReplyMarkup     *telegramInlineKeyboard `json:"reply_markup,omitempty"`
 }
 
 type telegramSendResponse struct {
 	OK bool `json:"ok"`
 }
 
+type telegramGetFileResponse struct {
+	OK     bool `json:"ok"`
+	Result struct {
+		FilePath string `json:"file_path"`
+		FileSize int64  `json:"file_size"`
+	} `json:"result"`
+}
+
 type telegramGetMeResponse struct {
 	OK     bool `json:"ok"`
 	Result struct {
@@ -0,0 +1,306 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+// Callback data sent by the approval keyboard: "appr:<token>:<choice>".
+const (
+	telegramApprovalPrefix = "appr:"
+	telegramChoiceOnce     = "y"
+	telegramChoiceSession  = "s"
+	telegramChoiceDeny     = "n"
+)
+
+// maxApprovalArgs caps how much of a tool call's arguments a prompt shows.
+const maxApprovalArgs = 600
+
+// telegramPrompt is an approval prompt shown in a chat.
+type telegramPrompt struct {
+	toolCallID string
+	sessionID  string
+	chatID     string
+	tool       string
+
+	// sent is closed once sendMessage returned; messageID is set by then,
+	// and stays 0 if the send failed.
+	sent      chan struct{}
+	messageID int64
+}
+
+// telegramApprovals tracks open prompts. Tokens keep callback data within
+// Telegram's 64-byte limit regardless of tool call ID length.
+type telegramApprovals struct {
+	mu      sync.Mutex
+	next    int64
+	prompts map[string]*telegramPrompt // token -> prompt
+}
+
+func (a *telegramApprovals) add(p *telegramPrompt) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.prompts == nil {
+		a.prompts = make(map[string]*telegramPrompt)
+	}
+	a.next++
+	token := strconv.FormatInt(a.next, 36)
+	a.prompts[token] = p
+	return token
+}
+
+// take removes and returns the prompt for token.
+func (a *telegramApprovals) take(token string) *telegramPrompt {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p := a.prompts[token]
+	delete(a.prompts, token)
+	return p
+}
+
+// takeCall removes and returns the prompt for a tool call ID.
+func (a *telegramApprovals) takeCall(toolCallID string) *telegramPrompt {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for token, p := range a.prompts {
+		if p.toolCallID == toolCallID {
+			delete(a.prompts, token)
+			return p
+		}
+	}
+	return nil
+}
+
+// SetEventBus enables tool approval prompts. Approval requests for Telegram
+// sessions are shown with an inline keyboard, and button presses are
+// published as decisions. Must be called before Start.
+func (t *TelegramChannel) SetEventBus(bus domain.EventBus) {
+	t.bus = bus
+}
+
+// subscribeApprovals wires approval events until ctx is done.
+func (t *TelegramChannel) subscribeApprovals(ctx context.Context) {
+	unsubReq := t.bus.Subscribe(domain.EventToolApprovalReq, func(_ context.Context, event domain.Event) {
+		var req domain.ApprovalRequest
+		if err := json.Unmarshal(event.Payload, &req); err != nil || req.Channel != t.Name() {
+			return
+		}
+		if err := t.promptApproval(ctx, req); err != nil {
+			t.logger.Warn("telegram approval prompt failed", "error", err, "tool", req.Tool)
+		}
+	})
+	unsubResp := t.bus.Subscribe(domain.EventToolApprovalResp, func(_ context.Context, event domain.Event) {
+		var d domain.ApprovalDecision
+		if err := json.Unmarshal(event.Payload, &d); err != nil {
+			return
+		}
+		// Decided elsewhere (gateway, timeout): retire the keyboard.
+		if p := t.approvals.takeCall(d.ToolCallID); p != nil {
+			t.closePrompt(ctx, p, decisionText(d))
+		}
+	})
+	go func() {
+		<-ctx.Done()
+		unsubReq()
+		unsubResp()
+	}()
+}
+
+// promptApproval sends an approval request with an inline keyboard.
+func (t *TelegramChannel) promptApproval(ctx context.Context, req domain.ApprovalRequest) error {
+	p := &telegramPrompt{toolCallID: req.ToolCallID, sessionID: req.SessionID, chatID: req.ChatID, tool: req.Tool, sent: make(chan struct{})}
+	defer close(p.sent)
+	token := t.approvals.add(p)
+	data := func(choice string) string { return telegramApprovalPrefix + token + ":" + choice }
+
+	msg := telegramSendRequest{
+		ChatID: req.ChatID,
+		Text:   approvalText(req),
+		ReplyMarkup: &telegramInlineKeyboard{InlineKeyboard: [][]telegramInlineButton{
+			{
+				{Text: "Approve", CallbackData: data(telegramChoiceOnce)},
+				{Text: "Approve for session", CallbackData: data(telegramChoiceSession)},
+			},
+			{{Text: "Deny", CallbackData: data(telegramChoiceDeny)}},
+		}},
+	}
+	var sent struct {
+		MessageID int64 `json:"message_id"`
+	}
+	if err := t.callAPI(ctx, "sendMessage", msg, &sent); err != nil {
+		t.approvals.take(token)
+		return err
+	}
+	p.messageID = sent.MessageID
+	return nil
+}
+
+// handleCallback answers a keyboard press and publishes the decision.
+func (t *TelegramChannel) handleCallback(ctx context.Context, cb *telegramCallbackQuery) {
+	// Always answer so the client stops its loading indicator.
+	defer func() {
+		if err := t.callAPI(ctx, "answerCallbackQuery", map[string]string{"callback_query_id": cb.ID}, nil); err != nil {
+			t.logger.Debug("telegram answerCallbackQuery failed", "error", err)
+		}
+	}()
+
+	rest, ok := strings.CutPrefix(cb.Data, telegramApprovalPrefix)
+	if !ok || t.bus == nil {
+		return
+	}
+	token, choice, _ := strings.Cut(rest, ":")
+
+	t.approvals.mu.Lock()
+	p := t.approvals.prompts[token]
+	// Only presses in the chat the prompt was sent to count.
+	if p == nil || cb.Message == nil || strconv.FormatInt(cb.Message.Chat.ID, 10) != p.chatID {
+		t.approvals.mu.Unlock()
+		return
+	}
+	delete(t.approvals.prompts, token)
+	t.approvals.mu.Unlock()
+
+	d := domain.ApprovalDecision{ToolCallID: p.toolCallID, Scope: domain.ApprovalScopeOnce}
+	switch choice {
+	case telegramChoiceOnce:
+		d.Approved = true
+	case telegramChoiceSession:
+		d.Approved = true
+		d.Scope = domain.ApprovalScopeSession
+	}
+	if cb.From != nil {
+		d.DecidedBy = "telegram:" + strconv.FormatInt(cb.From.ID, 10)
+	}
+
+	payload, err := json.Marshal(d)
+	if err != nil {
+		return
+	}
+	t.bus.Publish(ctx, domain.Event{
+		Type:      domain.EventToolApprovalResp,
+		Timestamp: time.Now(),
+		SessionID: p.sessionID,
+		Payload:   payload,
+	})
+	t.closePrompt(ctx, p, decisionText(d))
+}
+
+// closePrompt replaces the keyboard with the outcome. A decision can
+// arrive while the prompt is still being sent, so it waits for the send.
+func (t *TelegramChannel) closePrompt(ctx context.Context, p *telegramPrompt, outcome string) {
+	select {
+	case <-p.sent:
+	case <-ctx.Done():
+		return
+	}
+	messageID := p.messageID
+	if messageID == 0 {
+		return
+	}
+	edit := map[string]any{
+		"chat_id":    p.chatID,
+		"message_id": messageID,
+		"text":       fmt.Sprintf("Tool %s: %s", p.tool, outcome),
+	}
+	if err := t.callAPI(ctx, "editMessageText", edit, nil); err != nil {
+		t.logger.Debug("telegram editMessageText failed", "error", err)
+	}
+}
+
+// callAPI posts a JSON request to a Bot API method and decodes its result.
+func (t *TelegramChannel) callAPI(ctx context.Context, method string, body, result any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	url := fmt.Sprintf("%s/bot%s/%s", t.baseURL, t.token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1*1024*1024)).Decode(&out); err != nil {
+		return fmt.Errorf("telegram %s: status %d: %w", method, resp.StatusCode, err)
+	}
+	if !out.OK {
+		return fmt.Errorf("telegram %s error: %s", method, out.Description)
+	}
+	if result != nil && len(out.Result) > 0 {
+		if err := json.Unmarshal(out.Result, result); err != nil {
+			return fmt.Errorf("unmarshal %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// approvalText renders an approval request as plain text for chat prompts.
+func approvalText(req domain.ApprovalRequest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Approve tool call: %s\n", req.Tool)
+	if args := strings.TrimSpace(string(req.Arguments)); args != "" && args != "{}" && args != "null" {
+		if len(args) > maxApprovalArgs {
+			args = args[:maxApprovalArgs] + "…"
+		}
+		fmt.Fprintf(&b, "Arguments: %s\n", args)
+	}
+	if !req.ExpiresAt.IsZero() {
+		fmt.Fprintf(&b, "Expires in %s.", time.Until(req.ExpiresAt).Round(time.Second))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// decisionText summarizes a decision for a retired prompt.
+func decisionText(d domain.ApprovalDecision) string {
+	switch {
+	case d.Reason == "timeout":
+		return "approval timed out, denied"
+	case d.Reason == "cancelled":
+		return "request cancelled"
+	case d.Approved && d.Scope == domain.ApprovalScopeSession:
+		return "approved for this session"
+	case d.Approved:
+		return "approved"
+	default:
+		return "denied"
+	}
+}
+
+// --- Telegram Bot API approval types ---
+
+type telegramCallbackQuery struct {
+	ID      string           `json:"id"`
+	From    *telegramUser    `json:"from,omitempty"`
+	Message *telegramMessage `json:"message,omitempty"`
+	Data    string           `json:"data"`
+}
+
+type telegramInlineKeyboard struct {
+	InlineKeyboard [][]telegramInlineButton `json:"inline_keyboard"`
+}
+
+type telegramInlineButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
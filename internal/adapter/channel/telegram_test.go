@@ -9,11 +9,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"alfred-ai/internal/domain"
+	"alfred-ai/internal/usecase/eventbus"
 )
 
 // roundTripFunc adapts a function to the http.RoundTripper interface.
@@ -800,3 +802,158 @@ func TestTelegramGetMe(t *testing.T) {
 		t.Errorf("username = %q, want testbot", username)
 	}
 }
+
+func TestTelegramDownloadFile(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getFile"):
+			if r.URL.Query().Get("file_id") == "missing" {
+				w.Write([]byte(`{"ok":false}`))
+				return
+			}
+			w.Write([]byte(`{"ok":true,"result":{"file_id":"abc","file_path":"photos/file_1.jpg","file_size":16}}`))
+		case r.URL.Path == "/file/bottest-token/photos/file_1.jpg":
+			w.Write(png)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ch := NewTelegramChannel("test-token", newTelegramTestLogger())
+	ch.baseURL = server.URL
+
+	media := []domain.Media{{Type: "photo", URL: "abc"}, {Type: "document", URL: "missing", Filename: "a.pdf"}}
+	ch.downloadMedia(context.Background(), media)
+
+	if string(media[0].Data) != string(png) {
+		t.Errorf("photo data = %q", media[0].Data)
+	}
+	if media[0].MIMEType != "image/png" {
+		t.Errorf("photo mime = %q", media[0].MIMEType)
+	}
+	if media[1].Data != nil {
+		t.Errorf("failed download should leave data empty, got %q", media[1].Data)
+	}
+}
+
+func TestTelegramApprovalPrompt(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		keyboard *telegramInlineKeyboard
+		served   bool
+	)
+	edited := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getUpdates"):
+			if keyboard == nil || served {
+				time.Sleep(20 * time.Millisecond)
+				json.NewEncoder(w).Encode(telegramUpdateResponse{OK: true})
+				return
+			}
+			served = true
+			data := keyboard.InlineKeyboard[0][1].CallbackData // "Approve for session"
+			json.NewEncoder(w).Encode(telegramUpdateResponse{OK: true, Result: []telegramUpdate{{
+				UpdateID: 1,
+				CallbackQuery: &telegramCallbackQuery{
+					ID:      "cb1",
+					From:    &telegramUser{ID: 7},
+					Message: &telegramMessage{MessageID: 55, Chat: telegramChat{ID: 42}},
+					Data:    data,
+				},
+			}}})
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			var req telegramSendRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.ChatID != "42" || !strings.Contains(req.Text, "shell") {
+				t.Errorf("unexpected prompt: %+v", req)
+			}
+			keyboard = req.ReplyMarkup
+			w.Write([]byte(`{"ok":true,"result":{"message_id":55}}`))
+		case strings.HasSuffix(r.URL.Path, "/editMessageText"):
+			var req map[string]any
+			json.NewDecoder(r.Body).Decode(&req)
+			text, _ := req["text"].(string)
+			edited <- text
+			w.Write([]byte(`{"ok":true,"result":{}}`))
+		default:
+			w.Write([]byte(`{"ok":true,"result":true}`))
+		}
+	}))
+	defer server.Close()
+
+	bus := eventbus.New(newTelegramTestLogger())
+	defer bus.Close()
+	decisions := make(chan domain.ApprovalDecision, 1)
+	bus.Subscribe(domain.EventToolApprovalResp, func(_ context.Context, e domain.Event) {
+		var d domain.ApprovalDecision
+		json.Unmarshal(e.Payload, &d)
+		decisions <- d
+	})
+
+	ch := NewTelegramChannel("test-token", newTelegramTestLogger())
+	ch.baseURL = server.URL
+	ch.SetEventBus(bus)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch.Start(ctx, func(context.Context, domain.InboundMessage) error { return nil })
+	defer ch.Stop(context.Background())
+
+	payload, _ := json.Marshal(domain.ApprovalRequest{
+		ToolCallID: "call-1", Tool: "shell", Arguments: json.RawMessage(`{"cmd":"ls"}`),
+		SessionID: "telegram:42", Channel: "telegram", ChatID: "42",
+	})
+	bus.Publish(ctx, domain.Event{Type: domain.EventToolApprovalReq, Payload: payload})
+	// Requests for other channels are ignored.
+	other, _ := json.Marshal(domain.ApprovalRequest{ToolCallID: "call-2", Channel: "slack", ChatID: "C1"})
+	bus.Publish(ctx, domain.Event{Type: domain.EventToolApprovalReq, Payload: other})
+
+	select {
+	case d := <-decisions:
+		if d.ToolCallID != "call-1" || !d.Approved || d.Scope != domain.ApprovalScopeSession || d.DecidedBy != "telegram:7" {
+			t.Errorf("unexpected decision: %+v", d)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("no decision published")
+	}
+
+	select {
+	case text := <-edited:
+		if !strings.Contains(text, "approved for this session") {
+			t.Errorf("prompt retired with %q", text)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("prompt not retired")
+	}
+}
+
+func TestChatQueueKeepsOrderPerChat(t *testing.T) {
+	var q chatQueue
+	var mu sync.Mutex
+	var got []int
+	var wg sync.WaitGroup
+	release := make(chan struct{})
+
+	wg.Add(3)
+	q.Go("a", func() { <-release; mu.Lock(); got = append(got, 1); mu.Unlock(); wg.Done() })
+	q.Go("a", func() { mu.Lock(); got = append(got, 2); mu.Unlock(); wg.Done() })
+	// Another chat is not held up by the blocked one.
+	otherDone := make(chan struct{})
+	q.Go("b", func() { close(otherDone) })
+	select {
+	case <-otherDone:
+	case <-time.After(time.Second):
+		t.Fatal("chat b blocked behind chat a")
+	}
+	q.Go("a", func() { mu.Lock(); got = append(got, 3); mu.Unlock(); wg.Done() })
+	close(release)
+	wg.Wait()
+
+	if len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Errorf("order = %v, want [1 2 3]", got)
+	}
+}
@@ -85,6 +85,14 @@ func (c *CachedEmbedder) Dimensions() int { return c.inner.Dimensions() }
 // Name implements domain.EmbeddingProvider.
 func (c *CachedEmbedder) Name() string { return c.inner.Name() }
 
+// Model implements domain.EmbeddingModeler.
+func (c *CachedEmbedder) Model() string {
+	if m, ok := c.inner.(domain.EmbeddingModeler); ok {
+		return m.Model()
+	}
+	return ""
+}
+
 // hashText returns an FNV-1a hash of the input text.
 func hashText(s string) uint64 {
 	h := fnv.New64a()
@@ -153,3 +153,6 @@ func (p *GeminiProvider) Dimensions() int { return p.dims }
 
 // Name implements domain.EmbeddingProvider.
 func (p *GeminiProvider) Name() string { return "gemini" }
+
+// Model implements domain.EmbeddingModeler.
+func (p *GeminiProvider) Model() string { return p.model }
@@ -122,5 +122,8 @@ func (p *OllamaProvider) Dimensions() int { return p.dims }
 // Name implements domain.EmbeddingProvider.
 func (p *OllamaProvider) Name() string { return "ollama" }
 
+// Model implements domain.EmbeddingModeler.
+func (p *OllamaProvider) Model() string { return p.model }
+
 // Compile-time interface check.
 var _ domain.EmbeddingProvider = (*OllamaProvider)(nil)
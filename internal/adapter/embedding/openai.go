@@ -147,3 +147,6 @@ func (p *OpenAIProvider) Dimensions() int { return p.dims }
 
 // Name implements domain.EmbeddingProvider.
 func (p *OpenAIProvider) Name() string { return "openai" }
+
+// Model implements domain.EmbeddingModeler.
+func (p *OpenAIProvider) Model() string { return p.model }
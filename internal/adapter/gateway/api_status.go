@@ -5,15 +5,19 @@ import (
 	"net/http"
 	"sync/atomic"
 	"time"
+
+	"alfred-ai/internal/domain"
 )
 
 // StatusResponse is the JSON body returned by GET /api/v1/status.
 type StatusResponse struct {
-	Agent    AgentStatus    `json:"agent"`
-	Sessions SessionStatus  `json:"sessions"`
-	Tools    ToolStatus     `json:"tools"`
-	Memory   MemoryStatus   `json:"memory"`
-	Channels []string       `json:"channels"`
+	Agent    AgentStatus          `json:"agent"`
+	Sessions SessionStatus        `json:"sessions"`
+	Tools    ToolStatus           `json:"tools"`
+	Memory   MemoryStatus         `json:"memory"`
+	Channels []string             `json:"channels"`
+	Usage    *domain.UsageSummary `json:"usage,omitempty"`   // nil when usage accounting is disabled
+	Routing  []domain.RouteStatus `json:"routing,omitempty"` // health-aware LLM routes
 }
 
 // AgentStatus holds agent overview info.
@@ -84,6 +88,13 @@ func statusHandler(deps HandlerDeps, startTime time.Time, metrics *Metrics, chan
 			},
 			Channels: channelNames,
 		}
+		if deps.Usage != nil {
+			summary := deps.Usage.Summary()
+			resp.Usage = &summary
+		}
+		if deps.Routing != nil {
+			resp.Routing = deps.Routing.RouteStatus()
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(resp)
@@ -76,6 +76,62 @@ func TestStatusHandler_Success(t *testing.T) {
 	}
 }
 
+type stubUsage struct{ summary domain.UsageSummary }
+
+func (u stubUsage) Summary() domain.UsageSummary { return u.summary }
+
+func TestStatusHandler_Usage(t *testing.T) {
+	deps := apiTestDeps(t)
+	get := func() StatusResponse {
+		w := httptest.NewRecorder()
+		statusHandler(deps, time.Now(), &Metrics{}, nil)(w, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+		var resp StatusResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	if resp := get(); resp.Usage != nil {
+		t.Errorf("Usage = %+v, want omitted without a reporter", resp.Usage)
+	}
+
+	deps.Usage = stubUsage{domain.UsageSummary{
+		Today:   domain.UsageTotals{Calls: 3, CostUSD: 0.25},
+		Budgets: []domain.BudgetStatus{{Budget: domain.Budget{Scope: domain.BudgetGlobal, Limit: 5}, Spent: 0.25}},
+	}}
+	resp := get()
+	if resp.Usage == nil || resp.Usage.Today.Calls != 3 || len(resp.Usage.Budgets) != 1 || resp.Usage.Budgets[0].Limit != 5 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+type stubRouting []domain.RouteStatus
+
+func (r stubRouting) RouteStatus() []domain.RouteStatus { return r }
+
+func TestStatusHandler_Routing(t *testing.T) {
+	deps := apiTestDeps(t)
+	deps.Routing = stubRouting{{
+		Name:     "claude",
+		Selected: "claude-key2",
+		Providers: []domain.ProviderHealth{
+			{Provider: "claude-key1", Weight: 1, State: domain.RouteDegraded, Calls: 10, ErrorRate: 0.6},
+			{Provider: "claude-key2", Weight: 1, State: domain.RouteHealthy, Calls: 8, P50Ms: 900, P95Ms: 1500},
+		},
+	}}
+
+	w := httptest.NewRecorder()
+	statusHandler(deps, time.Now(), &Metrics{}, nil)(w, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+	var resp StatusResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Routing) != 1 || resp.Routing[0].Selected != "claude-key2" || resp.Routing[0].Providers[1].P95Ms != 1500 {
+		t.Errorf("Routing = %+v", resp.Routing)
+	}
+}
+
 func TestStatusHandler_MethodNotAllowed(t *testing.T) {
 	deps := apiTestDeps(t)
 	handler := statusHandler(deps, time.Now(), &Metrics{}, nil)
@@ -36,6 +36,8 @@ type HandlerDeps struct {
 	AuditLogger    domain.AuditLogger      // can be nil
 	TenantManager  *usecase.TenantManager  // can be nil (single-tenant mode)
 	GDPRHandler    *security.GDPRHandler  // can be nil
+	Usage          domain.UsageReporter   // can be nil (usage accounting disabled)
+	Routing        domain.RoutingReporter // can be nil (no health-aware routes)
 }
 
 // requirePerm wraps an RPCHandler with RBAC enforcement.
@@ -43,34 +45,44 @@ type HandlerDeps struct {
 // Tokens without roles are treated as admin for backward compatibility.
 func requirePerm(deps HandlerDeps, perm domain.Permission, handler RPCHandler) RPCHandler {
 	return func(ctx context.Context, client *ClientInfo, payload json.RawMessage) (json.RawMessage, error) {
-		if deps.Authorizer != nil {
-			roles := domain.StringsToAuthRoles(client.Roles)
-			// Backward compat: tokens with no roles are treated as admin.
-			if len(roles) == 0 {
-				roles = []domain.AuthRole{domain.AuthRoleAdmin}
-			}
-			if err := deps.Authorizer.Authorize(ctx, roles, perm); err != nil {
-				if deps.AuditLogger != nil {
-					_ = deps.AuditLogger.Log(ctx, domain.AuditEvent{
-						Timestamp: time.Now(),
-						Type:      domain.AuditAccessDenied,
-						Actor:     client.Name,
-						Resource:  string(perm),
-						Action:    "rpc_call",
-						Outcome:   "denied",
-						Detail: map[string]string{
-							"roles":      fmt.Sprintf("%v", client.Roles),
-							"permission": string(perm),
-						},
-					})
-				}
-				return nil, domain.ErrForbidden
-			}
+		if err := authorize(ctx, deps, client, perm, "rpc_call"); err != nil {
+			return nil, err
 		}
 		return handler(ctx, client, payload)
 	}
 }
 
+// authorize checks that client holds perm, auditing denials with the given
+// action. It returns domain.ErrForbidden when the check fails.
+func authorize(ctx context.Context, deps HandlerDeps, client *ClientInfo, perm domain.Permission, action string) error {
+	if deps.Authorizer == nil {
+		return nil
+	}
+	roles := domain.StringsToAuthRoles(client.Roles)
+	// Backward compat: tokens with no roles are treated as admin.
+	if len(roles) == 0 {
+		roles = []domain.AuthRole{domain.AuthRoleAdmin}
+	}
+	if err := deps.Authorizer.Authorize(ctx, roles, perm); err != nil {
+		if deps.AuditLogger != nil {
+			_ = deps.AuditLogger.Log(ctx, domain.AuditEvent{
+				Timestamp: time.Now(),
+				Type:      domain.AuditAccessDenied,
+				Actor:     client.Name,
+				Resource:  string(perm),
+				Action:    action,
+				Outcome:   "denied",
+				Detail: map[string]string{
+					"roles":      fmt.Sprintf("%v", client.Roles),
+					"permission": string(perm),
+				},
+			})
+		}
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
 // RegisterRESTHandlers registers HTTP REST endpoints on the gateway server.
 // channelNames is the list of active channel names for the status response.
 func RegisterRESTHandlers(s *Server, deps HandlerDeps, channelNames []string) *Metrics {
@@ -380,25 +392,31 @@ func toolListHandler(deps HandlerDeps) RPCHandler {
 
 type toolApprovalRequest struct {
 	ToolCallID string `json:"tool_call_id"`
+	// Scope "session" approves the tool for the rest of the session.
+	Scope domain.ApprovalScope `json:"scope,omitempty"`
 }
 
-func publishToolApproval(deps HandlerDeps, ctx context.Context, toolCallID string, approved bool) error {
-	eventPayload, err := json.Marshal(map[string]any{
-		"approved":     approved,
-		"tool_call_id": toolCallID,
-	})
+func publishToolApproval(deps HandlerDeps, ctx context.Context, client *ClientInfo, req toolApprovalRequest, approved bool) error {
+	decision := domain.ApprovalDecision{
+		ToolCallID: req.ToolCallID,
+		Approved:   approved,
+		Scope:      req.Scope,
+		DecidedBy:  "gateway:" + client.Name,
+	}
+	eventPayload, err := json.Marshal(decision)
 	if err != nil {
 		return err
 	}
 	deps.Bus.Publish(ctx, domain.Event{
-		Type:    domain.EventToolApprovalResp,
-		Payload: eventPayload,
+		Type:      domain.EventToolApprovalResp,
+		Timestamp: time.Now(),
+		Payload:   eventPayload,
 	})
 	return nil
 }
 
-func toolApproveHandler(deps HandlerDeps) RPCHandler {
-	return func(ctx context.Context, _ *ClientInfo, payload json.RawMessage) (json.RawMessage, error) {
+func toolApprovalHandler(deps HandlerDeps, approved bool) RPCHandler {
+	return func(ctx context.Context, client *ClientInfo, payload json.RawMessage) (json.RawMessage, error) {
 		var req toolApprovalRequest
 		if err := json.Unmarshal(payload, &req); err != nil {
 			return nil, domain.ErrRPCInvalidPayload
@@ -406,48 +424,42 @@ func toolApproveHandler(deps HandlerDeps) RPCHandler {
 		if req.ToolCallID == "" {
 			return nil, domain.ErrRPCInvalidPayload
 		}
-		if err := publishToolApproval(deps, ctx, req.ToolCallID, true); err != nil {
-			return nil, err
-		}
-		return json.Marshal(map[string]bool{"ok": true})
-	}
-}
-
-func toolDenyHandler(deps HandlerDeps) RPCHandler {
-	return func(ctx context.Context, _ *ClientInfo, payload json.RawMessage) (json.RawMessage, error) {
-		var req toolApprovalRequest
-		if err := json.Unmarshal(payload, &req); err != nil {
+		switch req.Scope {
+		case "", domain.ApprovalScopeOnce, domain.ApprovalScopeSession:
+		default:
 			return nil, domain.ErrRPCInvalidPayload
 		}
-		if req.ToolCallID == "" {
-			return nil, domain.ErrRPCInvalidPayload
-		}
-		if err := publishToolApproval(deps, ctx, req.ToolCallID, false); err != nil {
+		if err := publishToolApproval(deps, ctx, client, req, approved); err != nil {
 			return nil, err
 		}
 		return json.Marshal(map[string]bool{"ok": true})
 	}
 }
 
-// --- memory ---
+func toolApproveHandler(deps HandlerDeps) RPCHandler { return toolApprovalHandler(deps, true) }
 
-type memoryQueryRequest struct {
-	Query string `json:"query"`
-	Limit int    `json:"limit"`
-}
+func toolDenyHandler(deps HandlerDeps) RPCHandler { return toolApprovalHandler(deps, false) }
 
+// --- memory ---
+
+// memoryQueryHandler accepts a domain.MemoryQuery: the query text plus
+// optional tag, metadata, source and time filters, min_score and paging.
+// Each returned entry carries its relevance score.
 func memoryQueryHandler(deps HandlerDeps) RPCHandler {
 	return func(ctx context.Context, _ *ClientInfo, payload json.RawMessage) (json.RawMessage, error) {
-		var req memoryQueryRequest
+		var req domain.MemoryQuery
 		if err := json.Unmarshal(payload, &req); err != nil {
 			return nil, domain.ErrRPCInvalidPayload
 		}
+		if req.Offset < 0 || req.Offset > domain.MaxMemoryQueryOffset {
+			return nil, domain.ErrRPCInvalidPayload
+		}
 		if req.Limit <= 0 {
 			req.Limit = 10
 		} else if req.Limit > 100 {
 			req.Limit = 100
 		}
-		entries, err := deps.Memory.Query(ctx, req.Query, req.Limit)
+		entries, err := domain.QueryMemory(ctx, deps.Memory, req)
 		if err != nil {
 			return nil, err
 		}
@@ -578,12 +590,21 @@ func cronGetHandler(deps HandlerDeps) RPCHandler {
 	}
 }
 
+// cronCreateRequest takes either a full action or the flat agent_run
+// fields.
 type cronCreateRequest struct {
-	Name     string              `json:"name"`
-	Schedule domain.CronSchedule `json:"schedule"`
-	Message  string              `json:"message"`
-	AgentID  string              `json:"agent_id"`
-	Channel  string              `json:"channel"`
+	Name     string                  `json:"name"`
+	Schedule domain.CronSchedule     `json:"schedule"`
+	Message  string                  `json:"message"`
+	AgentID  string                  `json:"agent_id"`
+	Channel  string                  `json:"channel"`
+	Action   *domain.CronAction      `json:"action,omitempty"`
+	Retry    *domain.CronRetryPolicy `json:"retry,omitempty"`
+
+	Misfire     string `json:"misfire,omitempty"`
+	MaxCatchUp  int    `json:"max_catch_up,omitempty"`
+	Concurrency string `json:"concurrency,omitempty"`
+	JitterMs    int64  `json:"jitter_ms,omitempty"`
 }
 
 func cronCreateHandler(deps HandlerDeps) RPCHandler {
@@ -592,15 +613,24 @@ func cronCreateHandler(deps HandlerDeps) RPCHandler {
 		if err := json.Unmarshal(payload, &req); err != nil {
 			return nil, domain.ErrRPCInvalidPayload
 		}
+		action := domain.CronAction{
+			Kind:    domain.CronActionAgentRun,
+			AgentID: req.AgentID,
+			Channel: req.Channel,
+			Message: req.Message,
+		}
+		if req.Action != nil {
+			action = *req.Action
+		}
 		job, err := deps.CronManager.Create(ctx, domain.CronJob{
-			Name:     req.Name,
-			Schedule: req.Schedule,
-			Action: domain.CronAction{
-				Kind:    "agent_run",
-				AgentID: req.AgentID,
-				Channel: req.Channel,
-				Message: req.Message,
-			},
+			Name:        req.Name,
+			Schedule:    req.Schedule,
+			Action:      action,
+			Retry:       req.Retry,
+			Misfire:     req.Misfire,
+			MaxCatchUp:  req.MaxCatchUp,
+			Concurrency: req.Concurrency,
+			JitterMs:    req.JitterMs,
 		})
 		if err != nil {
 			return nil, err
@@ -610,11 +640,18 @@ func cronCreateHandler(deps HandlerDeps) RPCHandler {
 }
 
 type cronUpdateRequest struct {
-	ID       string               `json:"id"`
-	Name     *string              `json:"name,omitempty"`
-	Schedule *domain.CronSchedule `json:"schedule,omitempty"`
-	Message  *string              `json:"message,omitempty"`
-	Enabled  *bool                `json:"enabled,omitempty"`
+	ID       string                  `json:"id"`
+	Name     *string                 `json:"name,omitempty"`
+	Schedule *domain.CronSchedule    `json:"schedule,omitempty"`
+	Message  *string                 `json:"message,omitempty"`
+	Action   *domain.CronAction      `json:"action,omitempty"`
+	Retry    *domain.CronRetryPolicy `json:"retry,omitempty"`
+	Enabled  *bool                   `json:"enabled,omitempty"`
+
+	Misfire     *string `json:"misfire,omitempty"`
+	MaxCatchUp  *int    `json:"max_catch_up,omitempty"`
+	Concurrency *string `json:"concurrency,omitempty"`
+	JitterMs    *int64  `json:"jitter_ms,omitempty"`
 }
 
 func cronUpdateHandler(deps HandlerDeps) RPCHandler {
@@ -627,10 +664,16 @@ func cronUpdateHandler(deps HandlerDeps) RPCHandler {
 			return nil, domain.ErrRPCInvalidPayload
 		}
 		job, err := deps.CronManager.Update(ctx, req.ID, cronjob.Patch{
-			Name:     req.Name,
-			Schedule: req.Schedule,
-			Message:  req.Message,
-			Enabled:  req.Enabled,
+			Name:        req.Name,
+			Schedule:    req.Schedule,
+			Message:     req.Message,
+			Action:      req.Action,
+			Retry:       req.Retry,
+			Enabled:     req.Enabled,
+			Misfire:     req.Misfire,
+			MaxCatchUp:  req.MaxCatchUp,
+			Concurrency: req.Concurrency,
+			JitterMs:    req.JitterMs,
 		})
 		if err != nil {
 			return nil, err
@@ -215,6 +215,40 @@ func TestHandlerMemoryQuery(t *testing.T) {
 	}
 }
 
+func TestHandlerMemoryQueryFilters(t *testing.T) {
+	deps := newHandlerDeps(t)
+	mem := deps.Memory.(*handlerStubMemory)
+	mem.entries = []domain.MemoryEntry{
+		{ID: "1", Tags: []string{"ops"}, Metadata: map[string]string{"source": "chat"}},
+		{ID: "2", Tags: []string{"ops"}, Metadata: map[string]string{"source": "auto-curate"}},
+		{ID: "3", Tags: []string{"ops", "archived"}, Metadata: map[string]string{"source": "chat"}},
+		{ID: "4", Metadata: map[string]string{"source": "chat"}},
+	}
+	h := memoryQueryHandler(deps)
+
+	result, err := callHandler(t, h, `{"tags":["ops"],"exclude_tags":["archived"],"source":"chat"}`)
+	if err != nil {
+		t.Fatalf("memoryQuery: %v", err)
+	}
+	var entries []domain.ScoredMemoryEntry
+	if err := json.Unmarshal(result, &entries); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ID != "1" || entries[0].Score <= 0 {
+		t.Errorf("entries = %+v, want [1] with a score", entries)
+	}
+
+	if _, err := callHandler(t, h, `{"created_after":"yesterday"}`); err == nil {
+		t.Error("expected error for malformed time bound")
+	}
+	if _, err := callHandler(t, h, `{"offset":-1}`); err == nil {
+		t.Error("expected error for negative offset")
+	}
+	if _, err := callHandler(t, h, `{"offset":1000000000000}`); err == nil {
+		t.Error("expected error for an offset past the cap")
+	}
+}
+
 func TestHandlerMemoryStore(t *testing.T) {
 	deps := newHandlerDeps(t)
 	h := memoryStoreHandler(deps)
@@ -286,6 +320,27 @@ func TestHandlerToolApprove(t *testing.T) {
 	}
 }
 
+func TestHandlerToolApprovePublishesDecision(t *testing.T) {
+	deps := newHandlerDeps(t)
+	var got domain.ApprovalDecision
+	deps.Bus.SubscribeAll(func(_ context.Context, e domain.Event) {
+		if e.Type == domain.EventToolApprovalResp {
+			json.Unmarshal(e.Payload, &got)
+		}
+	})
+
+	if _, err := callHandler(t, toolApproveHandler(deps), `{"tool_call_id":"c1","scope":"session"}`); err != nil {
+		t.Fatalf("toolApprove: %v", err)
+	}
+	if got.ToolCallID != "c1" || !got.Approved || got.Scope != domain.ApprovalScopeSession || got.DecidedBy != "gateway:test" {
+		t.Errorf("unexpected decision: %+v", got)
+	}
+
+	if _, err := callHandler(t, toolDenyHandler(deps), `{"tool_call_id":"c1","scope":"forever"}`); err == nil {
+		t.Error("expected error for invalid scope")
+	}
+}
+
 func TestHandlerToolDeny(t *testing.T) {
 	deps := newHandlerDeps(t)
 	h := toolDenyHandler(deps)
@@ -0,0 +1,506 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"alfred-ai/internal/domain"
+	"alfred-ai/internal/usecase/workflow"
+)
+
+// Inbound webhooks: POST /hooks/{name} lets external systems (GitHub, CI,
+// alerting, payment providers) start an agent or pipeline run. Each hook
+// verifies its own signature or token instead of a gateway token, rejects
+// replayed deliveries, runs in the background and writes one audit entry
+// per delivery. Payloads are untrusted, so agent runs get no tools unless
+// the hook lists them.
+
+// Hook verification schemes.
+const (
+	HookVerifyHMAC   = "hmac_sha256" // hex HMAC-SHA256 of the body, optionally "sha256=" prefixed
+	HookVerifyGitHub = "github"      // X-Hub-Signature-256: sha256=<hex HMAC-SHA256>
+	HookVerifyToken  = "token"       // static token, optionally as "Bearer <token>"
+)
+
+const (
+	// hookMaxBody bounds webhook request bodies.
+	hookMaxBody = 1 << 20
+	// hookDefaultTolerance is the default replay window.
+	hookDefaultTolerance = 5 * time.Minute
+	// hookDeliveryRetention is how long delivery IDs are remembered.
+	hookDeliveryRetention = 24 * time.Hour
+	// hookRunTimeout bounds a run started by a webhook.
+	hookRunTimeout = 5 * time.Minute
+	// hookMaxRunning limits concurrent webhook runs across all hooks.
+	hookMaxRunning = 16
+)
+
+// Hook configures an inbound webhook.
+type Hook struct {
+	Name   string
+	Secret string
+	Verify string // HookVerifyHMAC (default), HookVerifyGitHub or HookVerifyToken
+	Header string // signature or token header; defaults per scheme
+
+	// TimestampHeader, required for HookVerifyHMAC, names a header holding
+	// the Unix time of the delivery. The signature covers
+	// "<timestamp>.<body>" and deliveries outside Tolerance are rejected.
+	TimestampHeader string
+	// DeliveryHeader names a header carrying a unique delivery ID; defaults
+	// to X-GitHub-Delivery for HookVerifyGitHub. Deliveries must carry it,
+	// and an ID is accepted once per hookDeliveryRetention. GitHub and token
+	// deliveries sign no time, so this is what keeps old ones out.
+	DeliveryHeader string
+	// Tolerance is the replay window: identical deliveries within it are
+	// rejected. Defaults to 5 minutes.
+	Tolerance time.Duration
+
+	Agent    string            // agent ID; the default agent when Pipeline is empty
+	Pipeline string            // pipeline to run instead of an agent
+	Prompt   string            // agent prompt template; defaults to the hook name and body
+	Tools    []string          // tools the agent may use; none by default
+	Args     map[string]string // pipeline arg templates; defaults to the payload's top-level fields
+
+	Channel string // optional channel for the result
+	Target  string // chat or user ID on Channel
+}
+
+// HookAgentRunner runs a prompt on an agent in a fresh session. A non-nil
+// tools list narrows the agent's tools to those names; an empty one leaves
+// it none.
+type HookAgentRunner interface {
+	RunAgent(ctx context.Context, agentID, prompt string, tools []string) (string, error)
+}
+
+// HookPipelineRunner runs workflow pipelines.
+type HookPipelineRunner interface {
+	Run(ctx context.Context, name string, env map[string]string, opts *workflow.RunOptions) (*domain.WorkflowRun, error)
+}
+
+// HookChannelResolver looks up channels for result delivery.
+type HookChannelResolver interface {
+	Get(name string) (domain.Channel, error)
+}
+
+// HookDeps holds the runners and sinks webhook handlers use.
+type HookDeps struct {
+	Agents      HookAgentRunner     // can be nil if no hook targets an agent
+	Pipelines   HookPipelineRunner  // can be nil if no hook targets a pipeline
+	Channels    HookChannelResolver // can be nil if no hook delivers results
+	AuditLogger domain.AuditLogger  // can be nil
+	Logger      *slog.Logger
+}
+
+// compiledHook is a Hook with parsed templates.
+type compiledHook struct {
+	Hook
+	prompt *template.Template
+	args   map[string]*template.Template
+}
+
+type hookHandler struct {
+	hooks   map[string]*compiledHook
+	deps    HookDeps
+	replays *replayCache
+	running chan struct{}
+	now     func() time.Time
+}
+
+// RegisterHookHandlers validates hooks and serves them at /hooks/{name}.
+func RegisterHookHandlers(s *Server, hooks []Hook, deps HookDeps) error {
+	h, err := newHookHandler(hooks, deps)
+	if err != nil {
+		return err
+	}
+	s.RegisterHTTPRoute("/hooks/{name}", h.serveHTTP)
+	return nil
+}
+
+func newHookHandler(hooks []Hook, deps HookDeps) (*hookHandler, error) {
+	h := &hookHandler{
+		hooks:   make(map[string]*compiledHook, len(hooks)),
+		deps:    deps,
+		replays: newReplayCache(),
+		running: make(chan struct{}, hookMaxRunning),
+		now:     time.Now,
+	}
+	for _, hook := range hooks {
+		c, err := compileHook(hook, deps)
+		if err != nil {
+			return nil, fmt.Errorf("hook %q: %w", hook.Name, err)
+		}
+		if _, dup := h.hooks[hook.Name]; dup {
+			return nil, fmt.Errorf("hook %q: duplicate name", hook.Name)
+		}
+		h.hooks[hook.Name] = c
+	}
+	return h, nil
+}
+
+func compileHook(hook Hook, deps HookDeps) (*compiledHook, error) {
+	if hook.Secret == "" {
+		return nil, errors.New("secret is required")
+	}
+	switch hook.Verify {
+	case "":
+		hook.Verify = HookVerifyHMAC
+	case HookVerifyHMAC, HookVerifyGitHub, HookVerifyToken:
+	default:
+		return nil, fmt.Errorf("unknown verify scheme %q", hook.Verify)
+	}
+	if hook.Verify == HookVerifyHMAC && hook.TimestampHeader == "" {
+		return nil, errors.New("timestamp_header is required for hmac_sha256")
+	}
+	if hook.Header == "" {
+		hook.Header = map[string]string{
+			HookVerifyHMAC:   "X-Signature",
+			HookVerifyGitHub: "X-Hub-Signature-256",
+			HookVerifyToken:  "Authorization",
+		}[hook.Verify]
+	}
+	if hook.DeliveryHeader == "" && hook.Verify == HookVerifyGitHub {
+		hook.DeliveryHeader = "X-GitHub-Delivery"
+	}
+	if hook.Pipeline == "" && hook.Tools == nil {
+		hook.Tools = []string{}
+	}
+	if hook.Tolerance <= 0 {
+		hook.Tolerance = hookDefaultTolerance
+	}
+	if hook.Pipeline != "" && deps.Pipelines == nil {
+		return nil, errors.New("workflows are not enabled")
+	}
+	if hook.Pipeline == "" && deps.Agents == nil {
+		return nil, errors.New("agents are not available to hooks")
+	}
+	if hook.Channel != "" && deps.Channels == nil {
+		return nil, errors.New("no channels available for delivery")
+	}
+
+	c := &compiledHook{Hook: hook}
+	var err error
+	if hook.Prompt != "" {
+		if c.prompt, err = template.New("prompt").Parse(hook.Prompt); err != nil {
+			return nil, fmt.Errorf("parse prompt: %w", err)
+		}
+	}
+	if len(hook.Args) > 0 {
+		c.args = make(map[string]*template.Template, len(hook.Args))
+		for name, arg := range hook.Args {
+			if c.args[name], err = template.New(name).Parse(arg); err != nil {
+				return nil, fmt.Errorf("parse arg %q: %w", name, err)
+			}
+		}
+	}
+	return c, nil
+}
+
+func (h *hookHandler) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	hook, ok := h.hooks[r.PathValue("name")]
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, hookMaxBody))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	deliveryID := ulid.Make().String()
+	audit := func(outcome string, detail map[string]string) {
+		h.audit(hook, r.RemoteAddr, deliveryID, outcome, detail)
+	}
+
+	signed, err := hook.verify(r.Header, body, h.now())
+	if err != nil {
+		h.deps.Logger.Warn("webhook rejected", "hook", hook.Name, "remote", r.RemoteAddr, "error", err)
+		audit("denied", map[string]string{"error": err.Error()})
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	select {
+	case h.running <- struct{}{}:
+	default:
+		audit("throttled", nil)
+		http.Error(w, "too many webhook runs in progress", http.StatusTooManyRequests)
+		return
+	}
+	// Content is kept for twice the window, since signed timestamps may be
+	// that far apart.
+	keys := map[string]time.Duration{"body\x00" + string(signed): 2 * hook.Tolerance}
+	if hook.DeliveryHeader != "" {
+		keys["id\x00"+r.Header.Get(hook.DeliveryHeader)] = hookDeliveryRetention
+	}
+	if !h.replays.add(hook.Name, keys, h.now()) {
+		<-h.running
+		audit("duplicate", nil)
+		http.Error(w, "duplicate delivery", http.StatusConflict)
+		return
+	}
+
+	data := hookData(hook.Name, r.Header, body)
+	ctx := context.WithoutCancel(r.Context())
+	go func() {
+		defer func() { <-h.running }()
+		ctx, cancel := context.WithTimeout(ctx, hookRunTimeout)
+		defer cancel()
+
+		detail, err := h.run(ctx, hook, data, body)
+		if err != nil {
+			detail["error"] = err.Error()
+			h.deps.Logger.Warn("webhook run failed", "hook", hook.Name, "delivery_id", deliveryID, "error", err)
+			audit("failure", detail)
+			return
+		}
+		audit("success", detail)
+	}()
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "accepted", "delivery_id": deliveryID})
+}
+
+// run starts the hook's agent or pipeline and delivers the result. It
+// returns audit details even on failure.
+func (h *hookHandler) run(ctx context.Context, hook *compiledHook, data map[string]any, body []byte) (map[string]string, error) {
+	detail := make(map[string]string)
+	var output string
+	if hook.Pipeline != "" {
+		detail["pipeline"] = hook.Pipeline
+		args, err := hook.renderArgs(data, body)
+		if err != nil {
+			return detail, err
+		}
+		run, err := h.deps.Pipelines.Run(ctx, hook.Pipeline, args, nil)
+		if err != nil {
+			return detail, err
+		}
+		detail["run_id"] = run.ID
+		if run.Status == "failed" || run.Status == "denied" {
+			return detail, fmt.Errorf("workflow %s %s: %s", hook.Pipeline, run.Status, run.Error)
+		}
+		output = run.Summary()
+	} else {
+		detail["agent"] = hook.Agent
+		prompt, err := hook.renderPrompt(data, body)
+		if err != nil {
+			return detail, err
+		}
+		if output, err = h.deps.Agents.RunAgent(ctx, hook.Agent, prompt, hook.Tools); err != nil {
+			return detail, err
+		}
+	}
+
+	if hook.Channel == "" {
+		return detail, nil
+	}
+	detail["channel"] = hook.Channel
+	ch, err := h.deps.Channels.Get(hook.Channel)
+	if err != nil {
+		return detail, err
+	}
+	return detail, ch.Send(ctx, domain.OutboundMessage{
+		SessionID: hook.Target,
+		Content:   output,
+		Metadata:  map[string]string{"hook": hook.Name},
+	})
+}
+
+func (h *hookHandler) audit(hook *compiledHook, remote, deliveryID, outcome string, detail map[string]string) {
+	if h.deps.AuditLogger == nil {
+		return
+	}
+	if detail == nil {
+		detail = make(map[string]string)
+	}
+	detail["delivery_id"] = deliveryID
+	action := "agent_run"
+	if hook.Pipeline != "" {
+		action = "workflow_run"
+	}
+	_ = h.deps.AuditLogger.Log(context.Background(), domain.AuditEvent{
+		Timestamp: h.now(),
+		Type:      domain.AuditWebhook,
+		Actor:     remote,
+		Resource:  hook.Name,
+		Action:    action,
+		Outcome:   outcome,
+		Detail:    detail,
+	})
+}
+
+// verify checks a delivery's signature or token and returns the signed
+// content used for replay detection.
+func (c *compiledHook) verify(header http.Header, body []byte, now time.Time) ([]byte, error) {
+	value := header.Get(c.Header)
+	if value == "" {
+		return nil, fmt.Errorf("missing %s header", c.Header)
+	}
+	if c.DeliveryHeader != "" && header.Get(c.DeliveryHeader) == "" {
+		return nil, fmt.Errorf("missing %s header", c.DeliveryHeader)
+	}
+
+	switch c.Verify {
+	case HookVerifyToken:
+		token := strings.TrimPrefix(value, "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(c.Secret)) != 1 {
+			return nil, errors.New("invalid token")
+		}
+		return body, nil
+
+	case HookVerifyGitHub:
+		hexSig, ok := strings.CutPrefix(value, "sha256=")
+		if !ok {
+			return nil, errors.New("signature must start with sha256=")
+		}
+		return body, checkHMAC(c.Secret, body, hexSig)
+
+	default:
+		ts := header.Get(c.TimestampHeader)
+		sec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s header", c.TimestampHeader)
+		}
+		if skew := now.Sub(time.Unix(sec, 0)); skew > c.Tolerance || skew < -c.Tolerance {
+			return nil, errors.New("timestamp outside the replay window")
+		}
+		signed := append([]byte(ts+"."), body...)
+		return signed, checkHMAC(c.Secret, signed, strings.TrimPrefix(value, "sha256="))
+	}
+}
+
+// checkHMAC compares a hex HMAC-SHA256 signature of data in constant time.
+func checkHMAC(secret string, data []byte, hexSig string) error {
+	sig, err := hex.DecodeString(hexSig)
+	if err != nil {
+		return errors.New("signature is not hex")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// renderPrompt builds the agent prompt for a delivery.
+func (c *compiledHook) renderPrompt(data map[string]any, body []byte) (string, error) {
+	if c.prompt == nil {
+		return fmt.Sprintf("Webhook %s received:\n\n%s", c.Name, body), nil
+	}
+	var buf bytes.Buffer
+	if err := c.prompt.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render prompt: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// renderArgs builds pipeline args for a delivery: the configured arg
+// templates, or else the payload's top-level fields (strings as-is, other
+// values as JSON). The raw body is always passed as "payload".
+func (c *compiledHook) renderArgs(data map[string]any, body []byte) (map[string]string, error) {
+	args := make(map[string]string)
+	if c.args == nil {
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(body, &fields) == nil {
+			for k, v := range fields {
+				var s string
+				if json.Unmarshal(v, &s) == nil {
+					args[k] = s
+				} else {
+					args[k] = string(v)
+				}
+			}
+		}
+	}
+	for name, tmpl := range c.args {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("render arg %q: %w", name, err)
+		}
+		args[name] = buf.String()
+	}
+	args["payload"] = string(body)
+	return args, nil
+}
+
+// hookData is the template data for prompts and args: the hook name, the
+// decoded JSON payload (or the body as a string), the request headers
+// (first value, canonical names) and the raw body.
+func hookData(name string, header http.Header, body []byte) map[string]any {
+	var payload any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		payload = string(body)
+	}
+	headers := make(map[string]string, len(header))
+	for k, v := range header {
+		if len(v) > 0 {
+			headers[k] = v[0]
+		}
+	}
+	return map[string]any{
+		"hook":    name,
+		"payload": payload,
+		"headers": headers,
+		"body":    string(body),
+	}
+}
+
+// replayCache remembers recently accepted deliveries by hashes of their
+// signed content and delivery ID.
+type replayCache struct {
+	mu   sync.Mutex
+	seen map[[sha256.Size]byte]time.Time // hash → expiry
+}
+
+func newReplayCache() *replayCache {
+	return &replayCache{seen: make(map[[sha256.Size]byte]time.Time)}
+}
+
+// add records a delivery under each of its keys, kept for the given time,
+// and reports whether it is new: a delivery is a replay if any key was
+// seen.
+func (c *replayCache) add(hook string, keys map[string]time.Duration, now time.Time) bool {
+	hashes := make(map[[sha256.Size]byte]time.Duration, len(keys))
+	for k, ttl := range keys {
+		hashes[sha256.Sum256([]byte(hook+"\x00"+k))] = ttl
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, expiry := range c.seen {
+		if now.After(expiry) {
+			delete(c.seen, k)
+		}
+	}
+	for key := range hashes {
+		if _, dup := c.seen[key]; dup {
+			return false
+		}
+	}
+	for key, ttl := range hashes {
+		c.seen[key] = now.Add(ttl)
+	}
+	return true
+}
@@ -0,0 +1,272 @@
+package gateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"alfred-ai/internal/domain"
+	"alfred-ai/internal/usecase/workflow"
+)
+
+type hookAgents struct {
+	mu      sync.Mutex
+	prompts []string
+	tools   [][]string
+}
+
+func (a *hookAgents) RunAgent(_ context.Context, agentID, prompt string, tools []string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.prompts = append(a.prompts, agentID+": "+prompt)
+	a.tools = append(a.tools, tools)
+	return "diagnosis for " + agentID, nil
+}
+
+type hookPipelines struct {
+	mu  sync.Mutex
+	env map[string]string
+}
+
+func (p *hookPipelines) Run(_ context.Context, name string, env map[string]string, _ *workflow.RunOptions) (*domain.WorkflowRun, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.env = env
+	return &domain.WorkflowRun{ID: "run-1", PipelineName: name, Status: "completed"}, nil
+}
+
+type hookChannel struct {
+	mu   sync.Mutex
+	sent []domain.OutboundMessage
+}
+
+func (c *hookChannel) Start(context.Context, domain.MessageHandler) error { return nil }
+func (c *hookChannel) Stop(context.Context) error                         { return nil }
+func (c *hookChannel) Name() string                                       { return "slack" }
+func (c *hookChannel) Send(_ context.Context, msg domain.OutboundMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, msg)
+	return nil
+}
+
+func (c *hookChannel) Get(name string) (domain.Channel, error) {
+	if name != "slack" {
+		return nil, fmt.Errorf("channel %q not found", name)
+	}
+	return c, nil
+}
+
+type hookAudit struct {
+	mu     sync.Mutex
+	events []domain.AuditEvent
+}
+
+func (a *hookAudit) Log(_ context.Context, e domain.AuditEvent) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, e)
+	return nil
+}
+
+func (a *hookAudit) Close() error { return nil }
+
+func (a *hookAudit) outcomes() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var out []string
+	for _, e := range a.events {
+		out = append(out, e.Outcome)
+	}
+	return out
+}
+
+func sign(secret, data string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newHookServer(t *testing.T, hooks []Hook, deps HookDeps) *httptest.Server {
+	t.Helper()
+	deps.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	h, err := newHookHandler(hooks, deps)
+	require.NoError(t, err)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hooks/{name}", h.serveHTTP)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func postHook(t *testing.T, url, body string, header map[string]string) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestHookGitHubAgentDelivery(t *testing.T) {
+	agents, ch, audit := &hookAgents{}, &hookChannel{}, &hookAudit{}
+	srv := newHookServer(t, []Hook{{
+		Name:    "github",
+		Secret:  "s3cret",
+		Verify:  HookVerifyGitHub,
+		Agent:   "ops",
+		Tools:   []string{"web_search"},
+		Prompt:  `CI {{.payload.workflow_run.conclusion}} on {{index .headers "X-Github-Event"}}`,
+		Channel: "slack",
+		Target:  "C1",
+	}}, HookDeps{Agents: agents, Channels: ch, AuditLogger: audit})
+
+	body := `{"workflow_run":{"conclusion":"failure"}}`
+	url := srv.URL + "/hooks/github"
+	header := map[string]string{"X-Hub-Signature-256": "sha256=" + sign("s3cret", body), "X-GitHub-Event": "workflow_run", "X-GitHub-Delivery": "d1"}
+
+	assert.Equal(t, http.StatusUnauthorized, postHook(t, url, body, map[string]string{"X-Hub-Signature-256": "sha256=" + sign("wrong", body), "X-GitHub-Delivery": "d0"}))
+	assert.Equal(t, http.StatusAccepted, postHook(t, url, body, header))
+	assert.Equal(t, http.StatusConflict, postHook(t, url, body, header), "replayed delivery")
+	other := `{"workflow_run":{"conclusion":"success"}}`
+	assert.Equal(t, http.StatusConflict, postHook(t, url, other, map[string]string{"X-Hub-Signature-256": "sha256=" + sign("s3cret", other), "X-GitHub-Delivery": "d1"}), "replayed delivery ID")
+	assert.Equal(t, http.StatusUnauthorized, postHook(t, url, other, map[string]string{"X-Hub-Signature-256": "sha256=" + sign("s3cret", other)}), "missing delivery ID")
+
+	require.Eventually(t, func() bool {
+		ch.mu.Lock()
+		defer ch.mu.Unlock()
+		return len(ch.sent) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"ops: CI failure on workflow_run"}, agents.prompts)
+	assert.Equal(t, [][]string{{"web_search"}}, agents.tools)
+	assert.Equal(t, "C1", ch.sent[0].SessionID)
+	assert.Equal(t, "diagnosis for ops", ch.sent[0].Content)
+
+	require.Eventually(t, func() bool { return len(audit.outcomes()) == 5 }, time.Second, 5*time.Millisecond)
+	assert.ElementsMatch(t, []string{"denied", "duplicate", "duplicate", "denied", "success"}, audit.outcomes())
+	for _, e := range audit.events {
+		assert.Equal(t, domain.AuditWebhook, e.Type)
+		assert.Equal(t, "github", e.Resource)
+		assert.NotEmpty(t, e.Detail["delivery_id"])
+	}
+}
+
+func TestHookHMACTimestamp(t *testing.T) {
+	agents := &hookAgents{}
+	srv := newHookServer(t, []Hook{{
+		Name:            "alerts",
+		Secret:          "k",
+		TimestampHeader: "X-Timestamp",
+		Tolerance:       time.Minute,
+	}}, HookDeps{Agents: agents})
+	url := srv.URL + "/hooks/alerts"
+	body := `{"alert":"disk full"}`
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	assert.Equal(t, http.StatusAccepted, postHook(t, url, body, map[string]string{
+		"X-Timestamp": now, "X-Signature": sign("k", now+"."+body),
+	}))
+	assert.Equal(t, http.StatusUnauthorized, postHook(t, url, body, map[string]string{
+		"X-Timestamp": stale, "X-Signature": sign("k", stale+"."+body),
+	}), "stale timestamp")
+	assert.Equal(t, http.StatusUnauthorized, postHook(t, url, body, map[string]string{
+		"X-Timestamp": now, "X-Signature": sign("k", body),
+	}), "signature without timestamp")
+	assert.Equal(t, http.StatusUnauthorized, postHook(t, url, body, nil), "missing signature")
+
+	require.Eventually(t, func() bool {
+		agents.mu.Lock()
+		defer agents.mu.Unlock()
+		return len(agents.prompts) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, ": Webhook alerts received:\n\n"+body, agents.prompts[0])
+	assert.Equal(t, []string{}, agents.tools[0], "no tools unless the hook lists them")
+}
+
+func TestHookTokenPipeline(t *testing.T) {
+	pipelines := &hookPipelines{}
+	srv := newHookServer(t, []Hook{
+		{Name: "grafana", Secret: "tok", Verify: HookVerifyToken, Pipeline: "triage"},
+		{Name: "stripe", Secret: "tok", Verify: HookVerifyToken, Pipeline: "billing",
+			Args: map[string]string{"kind": "{{.payload.type}}"}},
+	}, HookDeps{Pipelines: pipelines})
+
+	assert.Equal(t, http.StatusUnauthorized, postHook(t, srv.URL+"/hooks/grafana", `{}`, map[string]string{"Authorization": "Bearer nope"}))
+	assert.Equal(t, http.StatusNotFound, postHook(t, srv.URL+"/hooks/missing", `{}`, nil))
+
+	body := `{"title":"High CPU","value":97}`
+	assert.Equal(t, http.StatusAccepted, postHook(t, srv.URL+"/hooks/grafana", body, map[string]string{"Authorization": "Bearer tok"}))
+	require.Eventually(t, func() bool {
+		pipelines.mu.Lock()
+		defer pipelines.mu.Unlock()
+		return pipelines.env != nil
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, map[string]string{"title": "High CPU", "value": "97", "payload": body}, pipelines.env)
+
+	pipelines.mu.Lock()
+	pipelines.env = nil
+	pipelines.mu.Unlock()
+	body = `{"type":"invoice.paid","id":"evt_1"}`
+	assert.Equal(t, http.StatusAccepted, postHook(t, srv.URL+"/hooks/stripe", body, map[string]string{"Authorization": "tok"}))
+	require.Eventually(t, func() bool {
+		pipelines.mu.Lock()
+		defer pipelines.mu.Unlock()
+		return pipelines.env != nil
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, map[string]string{"kind": "invoice.paid", "payload": body}, pipelines.env)
+}
+
+func TestNewHookHandlerValidation(t *testing.T) {
+	deps := HookDeps{Agents: &hookAgents{}}
+	tests := []struct {
+		name string
+		hook Hook
+	}{
+		{"missing secret", Hook{Name: "a"}},
+		{"hmac without timestamp", Hook{Name: "a", Secret: "s"}},
+		{"unknown scheme", Hook{Name: "a", Secret: "s", Verify: "md5"}},
+		{"pipeline without workflows", Hook{Name: "a", Secret: "s", Pipeline: "p"}},
+		{"channel without channels", Hook{Name: "a", Secret: "s", Channel: "slack", Target: "C1"}},
+		{"bad prompt", Hook{Name: "a", Secret: "s", Prompt: "{{.payload"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newHookHandler([]Hook{tt.hook}, deps)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := newHookHandler([]Hook{{Name: "a", Secret: "s", Verify: HookVerifyToken}, {Name: "a", Secret: "t", Verify: HookVerifyToken}}, deps)
+	assert.Error(t, err, "duplicate name")
+}
+
+func TestReplayCacheExpiry(t *testing.T) {
+	c := newReplayCache()
+	now := time.Now()
+	x := map[string]time.Duration{"x": 2 * time.Minute}
+	assert.True(t, c.add("h", x, now))
+	assert.False(t, c.add("h", x, now.Add(time.Minute)))
+	assert.True(t, c.add("other", x, now), "keys are per hook")
+	assert.True(t, c.add("h", x, now.Add(3*time.Minute)), "expired entry")
+	assert.False(t, c.add("h", map[string]time.Duration{"y": time.Minute, "x": time.Minute}, now.Add(3*time.Minute)), "any seen key is a replay")
+	assert.True(t, c.add("h", map[string]time.Duration{"y": time.Minute}, now.Add(3*time.Minute)), "keys of a replay are not recorded")
+}
@@ -0,0 +1,441 @@
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"alfred-ai/internal/domain"
+)
+
+// OpenAI-compatible API: /v1/chat/completions and /v1/models, so that
+// clients speaking the OpenAI protocol can talk to alfred-ai agents. Each
+// registered agent is exposed as a "model"; requests go through the Router,
+// so memory, tools, RBAC and audit apply as for any other channel.
+
+const (
+	// openAIChannel is the channel name for OpenAI API sessions.
+	openAIChannel = "openai"
+	// openAISessionHeader selects a persistent session. The request's
+	// "user" field is used when the header is absent.
+	openAISessionHeader = "X-Session-ID"
+	// openAIDefaultModel is the model ID reported in single-agent mode.
+	openAIDefaultModel = "alfred-ai"
+	// openAIMaxBody bounds request bodies, which may carry inline images.
+	openAIMaxBody = 10 << 20
+)
+
+type openAIChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	User     string          `json:"user"`
+}
+
+// openAIMessage is a chat message whose content is either a string or an
+// array of content parts.
+type openAIMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+type openAIContentPart struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	ImageURL *struct {
+		URL string `json:"url"`
+	} `json:"image_url,omitempty"`
+}
+
+type openAIChatResponse struct {
+	ID      string         `json:"id"`
+	Object  string         `json:"object"`
+	Created int64          `json:"created"`
+	Model   string         `json:"model"`
+	Choices []openAIChoice `json:"choices"`
+}
+
+type openAIChoice struct {
+	Index        int          `json:"index"`
+	Message      *openAIReply `json:"message,omitempty"`
+	Delta        *openAIReply `json:"delta,omitempty"`
+	FinishReason *string      `json:"finish_reason"`
+}
+
+type openAIReply struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+type openAIModel struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+type openAIModelList struct {
+	Object string        `json:"object"`
+	Data   []openAIModel `json:"data"`
+}
+
+type openAIErrorBody struct {
+	Error openAIError `json:"error"`
+}
+
+type openAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+}
+
+// RegisterOpenAIHandlers registers the OpenAI-compatible HTTP endpoints on
+// the gateway server. Clients authenticate with a gateway token as a
+// bearer token.
+func RegisterOpenAIHandlers(s *Server, deps HandlerDeps) {
+	startTime := time.Now()
+	s.RegisterHTTPRoute("/v1/models", openAIModelsHandler(s, deps, startTime))
+	s.RegisterHTTPRoute("/v1/chat/completions", openAIChatHandler(s, deps))
+}
+
+// openAIAuth authenticates a bearer token and returns a copy of the
+// client info with the tenant from the tenant_id query parameter, if any.
+func openAIAuth(s *Server, r *http.Request) (*ClientInfo, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	info, err := s.auth.Authenticate(token)
+	if err != nil {
+		return nil, err
+	}
+	client := *info
+	if tenantID := r.URL.Query().Get("tenant_id"); tenantID != "" {
+		client.TenantID = tenantID
+	}
+	return &client, nil
+}
+
+func openAIModelsHandler(s *Server, deps HandlerDeps, startTime time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeOpenAIError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method not allowed")
+			return
+		}
+		client, err := openAIAuth(s, r)
+		if err != nil {
+			writeOpenAIError(w, http.StatusUnauthorized, "invalid_request_error", "invalid API key")
+			return
+		}
+		if err := authorize(r.Context(), deps, client, domain.PermSessionView, "openai_models"); err != nil {
+			writeOpenAIError(w, http.StatusForbidden, "permission_error", err.Error())
+			return
+		}
+
+		list := openAIModelList{Object: "list", Data: []openAIModel{}}
+		if deps.Registry != nil {
+			for _, a := range deps.Registry.List() {
+				list.Data = append(list.Data, openAIModel{
+					ID: a.ID, Object: "model", Created: startTime.Unix(), OwnedBy: "alfred-ai",
+				})
+			}
+		} else {
+			list.Data = append(list.Data, openAIModel{
+				ID: openAIDefaultModel, Object: "model", Created: startTime.Unix(), OwnedBy: "alfred-ai",
+			})
+		}
+		writeJSON(w, http.StatusOK, list)
+	}
+}
+
+func openAIChatHandler(s *Server, deps HandlerDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeOpenAIError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method not allowed")
+			return
+		}
+		client, err := openAIAuth(s, r)
+		if err != nil {
+			writeOpenAIError(w, http.StatusUnauthorized, "invalid_request_error", "invalid API key")
+			return
+		}
+
+		ctx := r.Context()
+		if client.TenantID != "" {
+			ctx = domain.ContextWithTenantID(ctx, client.TenantID)
+		}
+		if len(client.Roles) > 0 {
+			ctx = domain.ContextWithRoles(ctx, domain.StringsToAuthRoles(client.Roles))
+		}
+		if err := authorize(ctx, deps, client, domain.PermToolExecute, "openai_chat"); err != nil {
+			writeOpenAIError(w, http.StatusForbidden, "permission_error", err.Error())
+			return
+		}
+
+		var req openAIChatRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, openAIMaxBody)).Decode(&req); err != nil {
+			writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "invalid JSON: "+err.Error())
+			return
+		}
+
+		msg, history, err := openAIInbound(req.Messages)
+		if err != nil {
+			writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+			return
+		}
+		msg.ChannelName = openAIChannel
+		msg.SenderName = client.Name
+
+		model := req.Model
+		sessions := deps.Sessions
+		if deps.Registry != nil && model != "" {
+			inst, err := deps.Registry.Get(model)
+			if err != nil {
+				writeOpenAIErrorCode(w, http.StatusNotFound, "invalid_request_error", "model_not_found",
+					fmt.Sprintf("the model %q does not exist", model))
+				return
+			}
+			msg.AgentID = inst.Identity.ID
+			sessions = inst.Sessions
+		} else if deps.Registry != nil {
+			if inst, err := deps.Registry.Default(); err == nil {
+				msg.AgentID = inst.Identity.ID
+				model = inst.Identity.ID
+				sessions = inst.Sessions
+			}
+		}
+		if model == "" {
+			model = openAIDefaultModel
+		}
+
+		// A session header or "user" selects a persistent session, whose
+		// history lives server-side. Otherwise the request's own history
+		// seeds a throwaway session.
+		msg.SessionID = r.Header.Get(openAISessionHeader)
+		if msg.SessionID == "" {
+			msg.SessionID = req.User
+		}
+		if msg.SessionID != "" {
+			if strings.ContainsAny(msg.SessionID, `/\`) || strings.Contains(msg.SessionID, "..") {
+				writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "invalid session ID")
+				return
+			}
+		} else if sessions != nil {
+			msg.SessionID = "tmp-" + ulid.Make().String()
+			key := openAIChannel + ":" + msg.SessionID
+			session := sessions.GetOrCreate(key)
+			for _, m := range history {
+				session.AddMessage(m)
+			}
+			defer sessions.Delete(key)
+		}
+
+		id := "chatcmpl-" + ulid.Make().String()
+		if req.Stream {
+			streamOpenAIChat(ctx, w, deps, msg, id, model)
+			return
+		}
+
+		out, err := deps.Router.Handle(ctx, msg)
+		if err != nil {
+			writeOpenAIError(w, openAIStatus(err), "server_error", err.Error())
+			return
+		}
+		stop := "stop"
+		writeJSON(w, http.StatusOK, openAIChatResponse{
+			ID:      id,
+			Object:  "chat.completion",
+			Created: time.Now().Unix(),
+			Model:   model,
+			Choices: []openAIChoice{{
+				Message:      &openAIReply{Role: "assistant", Content: out.Content},
+				FinishReason: &stop,
+			}},
+		})
+	}
+}
+
+// streamOpenAIChat runs msg through the router and relays the agent's
+// deltas as server-sent chat.completion.chunk events.
+func streamOpenAIChat(ctx context.Context, w http.ResponseWriter, deps HandlerDeps, msg domain.InboundMessage, id, model string) {
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	created := time.Now().Unix()
+	send := func(v any) {
+		data, _ := json.Marshal(v)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	chunk := func(delta openAIReply, finish *string) openAIChatResponse {
+		return openAIChatResponse{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   model,
+			Choices: []openAIChoice{{Delta: &delta, FinishReason: finish}},
+		}
+	}
+
+	send(chunk(openAIReply{Role: "assistant"}, nil))
+	streamed := false
+	ctx = domain.ContextWithStreamSink(ctx, func(d domain.StreamDeltaPayload) {
+		if d.Content == "" {
+			return
+		}
+		streamed = true
+		send(chunk(openAIReply{Content: d.Content}, nil))
+	})
+
+	out, err := deps.Router.HandleStream(ctx, msg)
+	if err != nil {
+		send(openAIErrorBody{Error: openAIError{Message: err.Error(), Type: "server_error"}})
+		fmt.Fprint(w, "data: [DONE]\n\n")
+		return
+	}
+	// Responses produced without the LLM (e.g. a blocked message or the
+	// offline fallback) arrive only in the final result.
+	if !streamed && out.Content != "" {
+		send(chunk(openAIReply{Content: out.Content}, nil))
+	}
+	stop := "stop"
+	send(chunk(openAIReply{}, &stop))
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// openAIInbound converts the request messages into the inbound message
+// (the final user message) and the preceding user/assistant history.
+// System and tool messages are ignored: the agent's own system prompt and
+// tools apply.
+func openAIInbound(msgs []openAIMessage) (domain.InboundMessage, []domain.Message, error) {
+	last := -1
+	for i, m := range msgs {
+		if m.Role == "user" {
+			last = i
+		}
+	}
+	if last < 0 {
+		return domain.InboundMessage{}, nil, errors.New("messages must include a user message")
+	}
+
+	var history []domain.Message
+	for _, m := range msgs[:last] {
+		if m.Role != "user" && m.Role != "assistant" {
+			continue
+		}
+		text, _, err := openAIContent(m.Content)
+		if err != nil {
+			return domain.InboundMessage{}, nil, err
+		}
+		role := domain.RoleUser
+		if m.Role == "assistant" {
+			role = domain.RoleAssistant
+		}
+		history = append(history, domain.Message{Role: role, Content: text})
+	}
+
+	text, media, err := openAIContent(msgs[last].Content)
+	if err != nil {
+		return domain.InboundMessage{}, nil, err
+	}
+	if text == "" && len(media) == 0 {
+		return domain.InboundMessage{}, nil, errors.New("the last user message is empty")
+	}
+	return domain.InboundMessage{Content: text, Media: media}, history, nil
+}
+
+// openAIContent decodes message content given as a string or as an array
+// of text and image_url parts.
+func openAIContent(raw json.RawMessage) (string, []domain.Media, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil, nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil, nil
+	}
+
+	var parts []openAIContentPart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", nil, errors.New("message content must be a string or an array of content parts")
+	}
+	var texts []string
+	var media []domain.Media
+	for _, p := range parts {
+		switch p.Type {
+		case "text":
+			texts = append(texts, p.Text)
+		case "image_url":
+			if p.ImageURL == nil {
+				return "", nil, errors.New("image_url part without a url")
+			}
+			m, err := openAIImage(p.ImageURL.URL)
+			if err != nil {
+				return "", nil, err
+			}
+			media = append(media, m)
+		default:
+			return "", nil, fmt.Errorf("unsupported content part type %q", p.Type)
+		}
+	}
+	return strings.Join(texts, "\n"), media, nil
+}
+
+// openAIImage converts an image URL, either http(s) or a base64 data URL,
+// into an image attachment.
+func openAIImage(url string) (domain.Media, error) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return domain.Media{Type: domain.MediaTypeImage, URL: url}, nil
+	}
+	meta, data, ok := strings.Cut(rest, ",")
+	mime, isBase64 := strings.CutSuffix(meta, ";base64")
+	if !ok || !isBase64 {
+		return domain.Media{}, errors.New("image data URLs must be base64-encoded")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return domain.Media{}, fmt.Errorf("decode image data URL: %w", err)
+	}
+	return domain.Media{Type: domain.MediaTypeImage, MIMEType: mime, Data: decoded}, nil
+}
+
+// openAIStatus maps router errors to HTTP status codes.
+func openAIStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, context.Canceled):
+		return http.StatusRequestTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeOpenAIError(w http.ResponseWriter, status int, typ, message string) {
+	writeOpenAIErrorCode(w, status, typ, "", message)
+}
+
+func writeOpenAIErrorCode(w http.ResponseWriter, status int, typ, code, message string) {
+	writeJSON(w, status, openAIErrorBody{Error: openAIError{Message: message, Type: typ, Code: code}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,299 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"alfred-ai/internal/domain"
+	"alfred-ai/internal/usecase"
+	"alfred-ai/internal/usecase/multiagent"
+)
+
+// recordingLLM streams a fixed reply in two chunks and records the
+// messages of each request.
+type recordingLLM struct {
+	reply string
+	mu    sync.Mutex
+	reqs  [][]domain.Message
+}
+
+func (l *recordingLLM) record(req domain.ChatRequest) {
+	l.mu.Lock()
+	l.reqs = append(l.reqs, req.Messages)
+	l.mu.Unlock()
+}
+
+func (l *recordingLLM) lastRequest() []domain.Message {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.reqs[len(l.reqs)-1]
+}
+
+func (l *recordingLLM) Chat(_ context.Context, req domain.ChatRequest) (*domain.ChatResponse, error) {
+	l.record(req)
+	return &domain.ChatResponse{
+		Message: domain.Message{Role: domain.RoleAssistant, Content: l.reply, Timestamp: time.Now()},
+	}, nil
+}
+
+func (l *recordingLLM) Name() string { return "recording" }
+
+func (l *recordingLLM) ChatStream(_ context.Context, req domain.ChatRequest) (<-chan domain.StreamDelta, error) {
+	l.record(req)
+	half := len(l.reply) / 2
+	ch := make(chan domain.StreamDelta, 3)
+	ch <- domain.StreamDelta{Content: l.reply[:half]}
+	ch <- domain.StreamDelta{Content: l.reply[half:]}
+	ch <- domain.StreamDelta{Done: true}
+	close(ch)
+	return ch, nil
+}
+
+func newOpenAIAgent(llm domain.LLMProvider, id string) *usecase.Agent {
+	return usecase.NewAgent(usecase.AgentDeps{
+		LLM:            llm,
+		Memory:         &handlerStubMemory{},
+		Tools:          handlerStubTools{},
+		ContextBuilder: usecase.NewContextBuilder("test", "model", 50),
+		Logger:         slog.Default(),
+		MaxIterations:  5,
+		Bus:            &testBus{},
+		Identity:       domain.AgentIdentity{ID: id},
+	})
+}
+
+// newOpenAIServer returns a gateway with the OpenAI routes over a
+// single-agent router, plus the agent's LLM.
+func newOpenAIServer(t *testing.T) (*httptest.Server, HandlerDeps, *recordingLLM) {
+	t.Helper()
+	llm := &recordingLLM{reply: "hello there"}
+	sessions := usecase.NewSessionManager(t.TempDir())
+	deps := HandlerDeps{
+		Router:   usecase.NewRouter(newOpenAIAgent(llm, ""), sessions, &testBus{}, slog.Default()),
+		Sessions: sessions,
+		Tools:    handlerStubTools{},
+		Memory:   &handlerStubMemory{},
+		Bus:      &testBus{},
+		Logger:   slog.Default(),
+	}
+	return startOpenAIServer(t, deps), deps, llm
+}
+
+func startOpenAIServer(t *testing.T, deps HandlerDeps) *httptest.Server {
+	t.Helper()
+	auth := NewStaticTokenAuth([]struct {
+		Token string
+		Name  string
+		Roles []string
+	}{{Token: "sk-test", Name: "tester"}})
+	s := NewServer(deps.Bus, auth, "", slog.Default())
+	RegisterOpenAIHandlers(s, deps)
+
+	mux := http.NewServeMux()
+	for _, route := range s.httpRoutes {
+		mux.HandleFunc(route.pattern, route.handler)
+	}
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func postChat(t *testing.T, srv *httptest.Server, body string, header map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/v1/chat/completions", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer sk-test")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestOpenAIChatCompletion(t *testing.T) {
+	srv, deps, llm := newOpenAIServer(t)
+
+	resp := postChat(t, srv, `{"model":"alfred-ai","messages":[
+		{"role":"system","content":"be terse"},
+		{"role":"user","content":"my name is Ann"},
+		{"role":"assistant","content":"hi Ann"},
+		{"role":"user","content":[{"type":"text","text":"what is my name?"}]}
+	]}`, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var out openAIChatResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	assert.Equal(t, "chat.completion", out.Object)
+	assert.Equal(t, "alfred-ai", out.Model)
+	require.Len(t, out.Choices, 1)
+	assert.Equal(t, "assistant", out.Choices[0].Message.Role)
+	assert.Equal(t, "hello there", out.Choices[0].Message.Content)
+	assert.Equal(t, "stop", *out.Choices[0].FinishReason)
+
+	// The prior turns seeded the session; the client system prompt did not.
+	var contents []string
+	for _, m := range llm.lastRequest() {
+		if m.Role != domain.RoleSystem {
+			contents = append(contents, m.Content)
+		}
+	}
+	assert.Equal(t, []string{"my name is Ann", "hi Ann", "what is my name?"}, contents)
+
+	// The throwaway session is gone.
+	assert.Empty(t, deps.Sessions.ListSessions())
+}
+
+func TestOpenAIChatSessionHeader(t *testing.T) {
+	srv, deps, llm := newOpenAIServer(t)
+
+	for _, content := range []string{"first", "second"} {
+		resp := postChat(t, srv, `{"messages":[{"role":"user","content":"`+content+`"}]}`,
+			map[string]string{openAISessionHeader: "ide-1"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	assert.Equal(t, []string{"openai:ide-1"}, deps.Sessions.ListSessions())
+	// The second request sees the first exchange from server-side history.
+	assert.Len(t, llm.lastRequest(), 4) // system, first, reply, second
+
+	// The "user" field also selects a session.
+	resp := postChat(t, srv, `{"user":"bob","messages":[{"role":"user","content":"hi"}]}`, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.ElementsMatch(t, []string{"openai:ide-1", "openai:bob"}, deps.Sessions.ListSessions())
+}
+
+func TestOpenAIChatStream(t *testing.T) {
+	srv, _, _ := newOpenAIServer(t)
+
+	resp := postChat(t, srv, `{"stream":true,"messages":[{"role":"user","content":"hi"}]}`, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	var content strings.Builder
+	var finish string
+	done := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+		var chunk openAIChatResponse
+		require.NoError(t, json.Unmarshal([]byte(data), &chunk))
+		assert.Equal(t, "chat.completion.chunk", chunk.Object)
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		if chunk.Choices[0].FinishReason != nil {
+			finish = *chunk.Choices[0].FinishReason
+		}
+	}
+	assert.True(t, done, "stream should end with [DONE]")
+	assert.Equal(t, "hello there", content.String())
+	assert.Equal(t, "stop", finish)
+}
+
+func TestOpenAIChatErrors(t *testing.T) {
+	srv, _, _ := newOpenAIServer(t)
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/v1/chat/completions", strings.NewReader(`{}`))
+	req.Header.Set("Authorization", "Bearer wrong")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"no user message", `{"messages":[{"role":"system","content":"x"}]}`, "must include a user message"},
+		{"bad part", `{"messages":[{"role":"user","content":[{"type":"audio"}]}]}`, `unsupported content part type "audio"`},
+		{"bad session", `{"user":"../etc","messages":[{"role":"user","content":"hi"}]}`, "invalid session ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := postChat(t, srv, tt.body, nil)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			var body openAIErrorBody
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Contains(t, body.Error.Message, tt.want)
+		})
+	}
+}
+
+func TestOpenAIMultiAgentModels(t *testing.T) {
+	registry := multiagent.NewRegistry("main", slog.Default())
+	llms := map[string]*recordingLLM{}
+	for _, id := range []string{"main", "coder"} {
+		llms[id] = &recordingLLM{reply: "from " + id}
+		require.NoError(t, registry.Register(&multiagent.AgentInstance{
+			Identity: domain.AgentIdentity{ID: id, Name: id},
+			Agent:    newOpenAIAgent(llms[id], id),
+			Sessions: usecase.NewSessionManager(t.TempDir()),
+		}))
+	}
+	deps := HandlerDeps{
+		Router:   usecase.NewMultiRouter(registry.Lookup(), multiagent.NewDefaultRouter("main"), &testBus{}, slog.Default()),
+		Registry: registry,
+		Bus:      &testBus{},
+		Logger:   slog.Default(),
+	}
+	srv := startOpenAIServer(t, deps)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer sk-test")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var models openAIModelList
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&models))
+	require.Len(t, models.Data, 2)
+	assert.Equal(t, "coder", models.Data[0].ID)
+	assert.Equal(t, "main", models.Data[1].ID)
+
+	// The model selects the agent, bypassing the default router.
+	resp = postChat(t, srv, `{"model":"coder","messages":[{"role":"user","content":"hi"}]}`, nil)
+	var out openAIChatResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	assert.Equal(t, "coder", out.Model)
+	assert.Equal(t, "from coder", out.Choices[0].Message.Content)
+
+	resp = postChat(t, srv, `{"model":"gpt-4","messages":[{"role":"user","content":"hi"}]}`, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	var errBody openAIErrorBody
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errBody))
+	assert.Equal(t, "model_not_found", errBody.Error.Code)
+}
+
+func TestOpenAIContentImage(t *testing.T) {
+	text, media, err := openAIContent(json.RawMessage(`[
+		{"type":"text","text":"what is this?"},
+		{"type":"image_url","image_url":{"url":"data:image/png;base64,aGVsbG8="}},
+		{"type":"image_url","image_url":{"url":"https://example.com/cat.jpg"}}
+	]`))
+	require.NoError(t, err)
+	assert.Equal(t, "what is this?", text)
+	require.Len(t, media, 2)
+	assert.Equal(t, domain.Media{Type: domain.MediaTypeImage, MIMEType: "image/png", Data: []byte("hello")}, media[0])
+	assert.Equal(t, "https://example.com/cat.jpg", media[1].URL)
+
+	_, _, err = openAIContent(json.RawMessage(`[{"type":"image_url","image_url":{"url":"data:image/png,raw"}}]`))
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package llm
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -27,6 +28,8 @@ type AnthropicProvider struct {
 	client  *http.Client
 	logger  *slog.Logger
 	version string
+	content contentSupport
+	cache   bool // mark the stable prompt prefix for prompt caching
 }
 
 // NewAnthropicProvider creates a provider for the Anthropic Messages API.
@@ -44,6 +47,12 @@ func NewAnthropicProvider(cfg config.ProviderConfig, logger *slog.Logger) *Anthr
 		client:  NewHTTPClient(cfg),
 		logger:  logger,
 		version: defaultAnthropicVersion,
+		content: contentSupport{
+			vision:    !cfg.DisableVision,
+			imageURLs: true,
+			fileTypes: map[string]bool{"application/pdf": true},
+		},
+		cache: !cfg.DisablePromptCache,
 	}
 }
 
@@ -60,8 +69,17 @@ func (p *AnthropicProvider) Chat(ctx context.Context, req domain.ChatRequest) (*
 	if req.Model == "" {
 		req.Model = p.model
 	}
+	if err := checkContent(p.name, p.content, req.Messages); err != nil {
+		tracer.RecordError(span, err)
+		return nil, err
+	}
+
+	antReq := toAnthropicRequest(req)
+	if p.cache {
+		antReq.setCacheBreakpoints()
+	}
 
-	body, err := json.Marshal(toAnthropicRequest(req))
+	body, err := json.Marshal(antReq)
 	if err != nil {
 		tracer.RecordError(span, err)
 		return nil, fmt.Errorf("marshal request: %w", err)
@@ -85,6 +103,9 @@ func (p *AnthropicProvider) Chat(ctx context.Context, req domain.ChatRequest) (*
 	}
 
 	result := fromAnthropicResponse(antResp)
+	if req.ResponseFormat != nil {
+		structuredToolReply(&result.Message, req.ResponseFormat)
+	}
 	setUsageAttrs(span, result.Usage)
 	tracer.SetOK(span)
 	logChatCompleted(p.logger, p.name, result)
@@ -95,16 +116,30 @@ func (p *AnthropicProvider) Chat(ctx context.Context, req domain.ChatRequest) (*
 // Name implements domain.LLMProvider.
 func (p *AnthropicProvider) Name() string { return p.name }
 
+// SupportsVision implements domain.VisionProvider.
+func (p *AnthropicProvider) SupportsVision() bool { return p.content.vision }
+
+// SupportsStructuredOutput implements domain.StructuredOutputProvider
+// through forced tool use.
+func (p *AnthropicProvider) SupportsStructuredOutput() bool { return true }
+
 // --- Anthropic API wire types ---
 
 type anthropicRequest struct {
-	Model     string              `json:"model"`
-	Messages  []anthropicMessage  `json:"messages"`
-	System    string              `json:"system,omitempty"`
-	MaxTokens int                 `json:"max_tokens"`
-	Tools     []anthropicTool     `json:"tools,omitempty"`
-	Stream    bool                `json:"stream,omitempty"`
-	Thinking  *anthropicThinking  `json:"thinking,omitempty"`
+	Model      string               `json:"model"`
+	Messages   []anthropicMessage   `json:"messages"`
+	System     []anthropicContent   `json:"system,omitempty"`
+	MaxTokens  int                  `json:"max_tokens"`
+	Tools      []anthropicTool      `json:"tools,omitempty"`
+	Stream     bool                 `json:"stream,omitempty"`
+	Thinking   *anthropicThinking   `json:"thinking,omitempty"`
+	ToolChoice *anthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+// anthropicToolChoice forces a call to the named tool.
+type anthropicToolChoice struct {
+	Type string `json:"type"` // "tool"
+	Name string `json:"name"`
 }
 
 type anthropicThinking struct {
@@ -118,20 +153,39 @@ type anthropicMessage struct {
 }
 
 type anthropicContent struct {
-	Type      string          `json:"type"`
-	Text      string          `json:"text,omitempty"`
-	Thinking  string          `json:"thinking,omitempty"`
-	ID        string          `json:"id,omitempty"`
-	Name      string          `json:"name,omitempty"`
-	Input     json.RawMessage `json:"input,omitempty"`
-	ToolUseID string          `json:"tool_use_id,omitempty"`
-	Content   string          `json:"content,omitempty"`
+	Type      string           `json:"type"`
+	Text      string           `json:"text,omitempty"`
+	Thinking  string           `json:"thinking,omitempty"`
+	ID        string           `json:"id,omitempty"`
+	Name      string           `json:"name,omitempty"`
+	Input     json.RawMessage  `json:"input,omitempty"`
+	ToolUseID string           `json:"tool_use_id,omitempty"`
+	Content   string           `json:"content,omitempty"`
+	Source    *anthropicSource `json:"source,omitempty"`
+
+	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
+}
+
+// anthropicCacheControl marks the end of a prompt prefix to cache.
+type anthropicCacheControl struct {
+	Type string `json:"type"` // "ephemeral"
+}
+
+var anthropicEphemeral = &anthropicCacheControl{Type: "ephemeral"}
+
+// anthropicSource is the payload of image and document blocks.
+type anthropicSource struct {
+	Type      string `json:"type"` // "base64" or "url"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
 }
 
 type anthropicTool struct {
-	Name        string          `json:"name"`
-	Description string          `json:"description"`
-	InputSchema json.RawMessage `json:"input_schema"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	InputSchema  json.RawMessage        `json:"input_schema"`
+	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
 }
 
 type anthropicResponse struct {
@@ -144,8 +198,24 @@ type anthropicResponse struct {
 }
 
 type anthropicUsage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+}
+
+// toDomain converts the wire usage. Anthropic reports cache reads and
+// writes apart from input_tokens, so they are added back into the prompt
+// count.
+func (u anthropicUsage) toDomain() domain.Usage {
+	prompt := u.InputTokens + u.CacheReadInputTokens + u.CacheCreationInputTokens
+	return domain.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      prompt + u.OutputTokens,
+		CachedTokens:     u.CacheReadInputTokens,
+		CacheWriteTokens: u.CacheCreationInputTokens,
+	}
 }
 
 // --- Anthropic streaming wire types ---
@@ -155,6 +225,9 @@ type anthropicStreamEvent struct {
 	Delta json.RawMessage `json:"delta,omitempty"`
 	Usage json.RawMessage `json:"usage,omitempty"`
 
+	// message_start carries the input usage; message_delta only the output.
+	Message *anthropicResponse `json:"message,omitempty"`
+
 	// content_block_start fields
 	ContentBlock *anthropicContent `json:"content_block,omitempty"`
 }
@@ -180,8 +253,15 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, req domain.ChatReque
 		req.Model = p.model
 	}
 
+	if err := checkContent(p.name, p.content, req.Messages); err != nil {
+		return nil, err
+	}
+
 	antReq := toAnthropicRequest(req)
 	antReq.Stream = true
+	if p.cache {
+		antReq.setCacheBreakpoints()
+	}
 
 	body, err := json.Marshal(antReq)
 	if err != nil {
@@ -203,6 +283,11 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, req domain.ChatReque
 	// "event:" line to know the event type. We handle this by embedding the
 	// event type dispatch inside the data parser since the data JSON contains
 	// a "type" field that maps to the SSE event type.
+	var started anthropicUsage
+	var structuredName string
+	if req.ResponseFormat != nil {
+		structuredName = req.ResponseFormat.SchemaName()
+	}
 	ch := parseSSEStream(ctx, httpResp.Body, func(data []byte) (*domain.StreamDelta, error) {
 		var evt anthropicStreamEvent
 		if err := json.Unmarshal(data, &evt); err != nil {
@@ -210,6 +295,12 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, req domain.ChatReque
 		}
 
 		switch evt.Type {
+		case "message_start":
+			if evt.Message != nil {
+				started = evt.Message.Usage
+			}
+			return nil, nil
+
 		case "content_block_delta":
 			// Try text delta first
 			var td anthropicDeltaText
@@ -229,7 +320,8 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, req domain.ChatReque
 			return nil, nil
 
 		case "content_block_start":
-			if evt.ContentBlock != nil && evt.ContentBlock.Type == "tool_use" {
+			// The forced structured output tool streams its input as content.
+			if evt.ContentBlock != nil && evt.ContentBlock.Type == "tool_use" && evt.ContentBlock.Name != structuredName {
 				return &domain.StreamDelta{
 					ToolCalls: []domain.ToolCall{{
 						ID:   evt.ContentBlock.ID,
@@ -242,13 +334,10 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, req domain.ChatReque
 		case "message_delta":
 			delta := &domain.StreamDelta{Done: true}
 			if len(evt.Usage) > 0 {
-				var u anthropicUsage
+				u := started
 				if err := json.Unmarshal(evt.Usage, &u); err == nil {
-					delta.Usage = &domain.Usage{
-						PromptTokens:     u.InputTokens,
-						CompletionTokens: u.OutputTokens,
-						TotalTokens:      u.InputTokens + u.OutputTokens,
-					}
+					usage := u.toDomain()
+					delta.Usage = &usage
 				}
 			}
 			return delta, nil
@@ -285,7 +374,7 @@ func toAnthropicRequest(req domain.ChatRequest) anthropicRequest {
 	// Extract system prompt and convert messages
 	for _, m := range req.Messages {
 		if m.Role == domain.RoleSystem {
-			antReq.System = m.Content
+			antReq.System = anthropicSystem(m.Content, req.StableSystemLen)
 			continue
 		}
 
@@ -328,6 +417,8 @@ func toAnthropicRequest(req domain.ChatRequest) anthropicRequest {
 					Input: tc.Arguments,
 				})
 			}
+		} else if len(m.Parts) > 0 {
+			antMsg.Content = append(antMsg.Content, toAnthropicParts(m.Parts)...)
 		} else {
 			antMsg.Content = append(antMsg.Content, anthropicContent{Type: "text", Text: m.Content})
 		}
@@ -344,9 +435,92 @@ func toAnthropicRequest(req domain.ChatRequest) anthropicRequest {
 		})
 	}
 
+	// Structured output: force a call to a tool whose input is the reply.
+	// Extended thinking cannot be combined with a forced tool.
+	if req.ResponseFormat != nil {
+		name, schema := structuredTool(req.ResponseFormat)
+		antReq.Tools = append(antReq.Tools, anthropicTool{
+			Name:        name,
+			Description: "Return the response as this tool's input.",
+			InputSchema: schema,
+		})
+		antReq.ToolChoice = &anthropicToolChoice{Type: "tool", Name: name}
+		antReq.Thinking = nil
+	}
+
 	return antReq
 }
 
+// anthropicSystem splits the system prompt into its stable prefix and the
+// per-turn rest so a cache breakpoint can sit between them.
+func anthropicSystem(content string, stableLen int) []anthropicContent {
+	if stableLen <= 0 || stableLen >= len(content) {
+		return []anthropicContent{{Type: "text", Text: content}}
+	}
+	return []anthropicContent{
+		{Type: "text", Text: content[:stableLen]},
+		{Type: "text", Text: content[stableLen:]},
+	}
+}
+
+// setCacheBreakpoints marks the prompt prefixes Anthropic should cache:
+// the tool definitions, the stable part of the system prompt and the
+// conversation so far. The API caches tools, system and messages in that
+// order, so each breakpoint extends the one before; the next call reads
+// the longest prefix it shares with this one. Prefixes shorter than the
+// model's minimum are not cached and cost nothing extra.
+func (r *anthropicRequest) setCacheBreakpoints() {
+	if len(r.Tools) > 0 {
+		r.Tools[len(r.Tools)-1].CacheControl = anthropicEphemeral
+	}
+	if len(r.System) > 0 {
+		r.System[0].CacheControl = anthropicEphemeral
+	}
+	if len(r.Messages) == 0 {
+		return
+	}
+	last := r.Messages[len(r.Messages)-1].Content
+	for i := len(last) - 1; i >= 0; i-- {
+		// Thinking blocks and empty text cannot carry cache_control.
+		if last[i].Type == "thinking" || (last[i].Type == "text" && last[i].Text == "") {
+			continue
+		}
+		last[i].CacheControl = anthropicEphemeral
+		return
+	}
+}
+
+// toAnthropicParts encodes content parts as text, image and document blocks.
+func toAnthropicParts(parts []domain.ContentPart) []anthropicContent {
+	out := make([]anthropicContent, 0, len(parts))
+	for _, p := range parts {
+		switch p.Type {
+		case domain.ContentPartText:
+			out = append(out, anthropicContent{Type: "text", Text: p.Text})
+		case domain.ContentPartImage:
+			out = append(out, anthropicContent{Type: "image", Source: anthropicPartSource(p)})
+		case domain.ContentPartFile:
+			if text, ok := textFile(p); ok {
+				out = append(out, anthropicContent{Type: "text", Text: text})
+				continue
+			}
+			out = append(out, anthropicContent{Type: "document", Source: anthropicPartSource(p)})
+		}
+	}
+	return out
+}
+
+func anthropicPartSource(p domain.ContentPart) *anthropicSource {
+	if len(p.Data) == 0 {
+		return &anthropicSource{Type: "url", URL: p.URL}
+	}
+	return &anthropicSource{
+		Type:      "base64",
+		MediaType: partMIME(p),
+		Data:      base64.StdEncoding.EncodeToString(p.Data),
+	}
+}
+
 func extractToolCallID(m domain.Message) string {
 	if len(m.ToolCalls) > 0 {
 		return m.ToolCalls[0].ID
@@ -356,13 +530,9 @@ func extractToolCallID(m domain.Message) string {
 
 func fromAnthropicResponse(resp anthropicResponse) *domain.ChatResponse {
 	result := &domain.ChatResponse{
-		ID:    resp.ID,
-		Model: resp.Model,
-		Usage: domain.Usage{
-			PromptTokens:     resp.Usage.InputTokens,
-			CompletionTokens: resp.Usage.OutputTokens,
-			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
-		},
+		ID:        resp.ID,
+		Model:     resp.Model,
+		Usage:     resp.Usage.toDomain(),
 		CreatedAt: time.Now(),
 	}
 
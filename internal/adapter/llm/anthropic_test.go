@@ -24,11 +24,11 @@ func TestAnthropicChatStream(t *testing.T) {
 		flusher, _ := w.(http.Flusher)
 
 		events := []string{
-			`data: {"type":"message_start"}`,
+			`data: {"type":"message_start","message":{"usage":{"input_tokens":5,"cache_read_input_tokens":3,"output_tokens":1}}}`,
 			`data: {"type":"content_block_start","content_block":{"type":"text"}}`,
 			`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"Hello"}}`,
 			`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":" world"}}`,
-			`data: {"type":"message_delta","usage":{"input_tokens":5,"output_tokens":2}}`,
+			`data: {"type":"message_delta","usage":{"output_tokens":2}}`,
 			`data: {"type":"message_stop"}`,
 		}
 		for _, e := range events {
@@ -55,11 +55,15 @@ func TestAnthropicChatStream(t *testing.T) {
 
 	var content string
 	var gotDone bool
+	var usage *domain.Usage
 	for delta := range ch {
 		content += delta.Content
 		if delta.Done {
 			gotDone = true
 		}
+		if delta.Usage != nil {
+			usage = delta.Usage
+		}
 	}
 
 	if content != "Hello world" {
@@ -68,6 +72,11 @@ func TestAnthropicChatStream(t *testing.T) {
 	if !gotDone {
 		t.Error("expected Done=true")
 	}
+	// Input usage arrives in message_start, output usage in message_delta.
+	want := domain.Usage{PromptTokens: 8, CompletionTokens: 2, TotalTokens: 10, CachedTokens: 3}
+	if usage == nil || *usage != want {
+		t.Errorf("usage = %+v, want %+v", usage, want)
+	}
 }
 
 func TestAnthropicChatStreamError(t *testing.T) {
@@ -134,8 +143,8 @@ func TestAnthropicRequestConversion(t *testing.T) {
 
 	antReq := toAnthropicRequest(req)
 
-	if antReq.System != "You are helpful." {
-		t.Errorf("System = %q, want %q", antReq.System, "You are helpful.")
+	if len(antReq.System) != 1 || antReq.System[0].Text != "You are helpful." {
+		t.Errorf("System = %+v, want one %q block", antReq.System, "You are helpful.")
 	}
 	if len(antReq.Messages) != 1 {
 		t.Fatalf("Messages len = %d, want 1 (system extracted)", len(antReq.Messages))
@@ -188,6 +197,106 @@ func TestAnthropicRequestWithToolCalls(t *testing.T) {
 	}
 }
 
+func TestAnthropicRequestResponseFormat(t *testing.T) {
+	req := domain.ChatRequest{
+		Messages:       []domain.Message{{Role: domain.RoleUser, Content: "Tags?"}},
+		ThinkingBudget: 2048,
+		ResponseFormat: &domain.ResponseFormat{Type: domain.ResponseFormatJSONSchema, Name: "tags", Schema: json.RawMessage(`{"type":"array"}`)},
+	}
+
+	antReq := toAnthropicRequest(req)
+
+	if len(antReq.Tools) != 1 || antReq.Tools[0].Name != "tags" {
+		t.Fatalf("Tools = %+v, want the structured output tool", antReq.Tools)
+	}
+	if !strings.Contains(string(antReq.Tools[0].InputSchema), `"value":{"type":"array"}`) {
+		t.Errorf("non-object schemas should be wrapped, got %s", antReq.Tools[0].InputSchema)
+	}
+	if tc := antReq.ToolChoice; tc == nil || tc.Type != "tool" || tc.Name != "tags" {
+		t.Errorf("ToolChoice = %+v, want the structured output tool forced", tc)
+	}
+	if antReq.Thinking != nil {
+		t.Error("thinking should be disabled for a forced tool")
+	}
+}
+
+func TestAnthropicRequestCacheBreakpoints(t *testing.T) {
+	req := domain.ChatRequest{
+		Messages: []domain.Message{
+			{Role: domain.RoleSystem, Content: "Persona.\n\n## Relevant Memory Context\n- likes Go"},
+			{Role: domain.RoleUser, Content: "Hello"},
+			{Role: domain.RoleAssistant, Thinking: "hmm", Content: "Hi"},
+		},
+		Tools: []domain.ToolSchema{
+			{Name: "a", Parameters: json.RawMessage(`{"type":"object"}`)},
+			{Name: "b", Parameters: json.RawMessage(`{"type":"object"}`)},
+		},
+		StableSystemLen: len("Persona."),
+	}
+
+	antReq := toAnthropicRequest(req)
+	antReq.setCacheBreakpoints()
+
+	if len(antReq.System) != 2 || antReq.System[0].Text != "Persona." {
+		t.Fatalf("System = %+v, want the stable prefix split off", antReq.System)
+	}
+	if antReq.System[0].CacheControl == nil || antReq.System[1].CacheControl != nil {
+		t.Error("only the stable system block should be cached")
+	}
+	if antReq.Tools[0].CacheControl != nil || antReq.Tools[1].CacheControl == nil {
+		t.Error("only the last tool should carry the breakpoint")
+	}
+	last := antReq.Messages[1].Content
+	if last[0].CacheControl != nil || last[1].CacheControl == nil {
+		t.Errorf("breakpoint should skip the thinking block: %+v", last)
+	}
+	if antReq.Messages[0].Content[0].CacheControl != nil {
+		t.Error("older messages need no breakpoint")
+	}
+
+	body, _ := json.Marshal(antReq)
+	if got := strings.Count(string(body), `"cache_control":{"type":"ephemeral"}`); got != 3 {
+		t.Errorf("cache_control count = %d, want 3", got)
+	}
+}
+
+func TestAnthropicProviderPromptCache(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		json.NewEncoder(w).Encode(anthropicResponse{
+			Content: []anthropicContent{{Type: "text", Text: "ok"}},
+			Usage:   anthropicUsage{InputTokens: 10, OutputTokens: 5, CacheReadInputTokens: 1500, CacheCreationInputTokens: 200},
+		})
+	}))
+	defer server.Close()
+
+	req := domain.ChatRequest{Messages: []domain.Message{
+		{Role: domain.RoleSystem, Content: "Persona."},
+		{Role: domain.RoleUser, Content: "Hello"},
+	}}
+
+	provider := NewAnthropicProvider(config.ProviderConfig{BaseURL: server.URL, Model: "claude"}, newTestLogger())
+	resp, err := provider.Chat(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	want := domain.Usage{PromptTokens: 1710, CompletionTokens: 5, TotalTokens: 1715, CachedTokens: 1500, CacheWriteTokens: 200}
+	if resp.Usage != want {
+		t.Errorf("Usage = %+v, want %+v", resp.Usage, want)
+	}
+
+	disabled := NewAnthropicProvider(config.ProviderConfig{BaseURL: server.URL, Model: "claude", DisablePromptCache: true}, newTestLogger())
+	if _, err := disabled.Chat(context.Background(), req); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	if !strings.Contains(bodies[0], "cache_control") || strings.Contains(bodies[1], "cache_control") {
+		t.Errorf("cache_control should only be sent with caching enabled:\n%s\n%s", bodies[0], bodies[1])
+	}
+}
+
 func TestAnthropicResponseConversion(t *testing.T) {
 	resp := anthropicResponse{
 		ID:    "msg_123",
@@ -1074,3 +1183,30 @@ func TestAnthropicChatStreamWithThinking(t *testing.T) {
 		t.Error("expected Done=true")
 	}
 }
+
+func TestAnthropicRequestWithImageParts(t *testing.T) {
+	req := domain.ChatRequest{Messages: imageMessage(
+		domain.ContentPart{Type: domain.ContentPartImage, Data: testPNG},
+		domain.ContentPart{Type: domain.ContentPartImage, URL: "https://example.com/cat.jpg"},
+		domain.ContentPart{Type: domain.ContentPartFile, MIMEType: "application/pdf", Data: []byte("%PDF-1.7")},
+		domain.ContentPart{Type: domain.ContentPartFile, Filename: "a.txt", MIMEType: "text/plain", Data: []byte("hello")},
+	)}
+
+	antReq := toAnthropicRequest(req)
+	blocks := antReq.Messages[0].Content
+	if len(blocks) != 5 {
+		t.Fatalf("blocks = %d, want 5", len(blocks))
+	}
+	if blocks[1].Type != "image" || blocks[1].Source.Type != "base64" || blocks[1].Source.MediaType != "image/png" {
+		t.Errorf("image block = %+v", blocks[1].Source)
+	}
+	if blocks[2].Source.Type != "url" || blocks[2].Source.URL != "https://example.com/cat.jpg" {
+		t.Errorf("url block = %+v", blocks[2].Source)
+	}
+	if blocks[3].Type != "document" || blocks[3].Source.MediaType != "application/pdf" {
+		t.Errorf("document block = %+v", blocks[3])
+	}
+	if blocks[4].Type != "text" || !strings.Contains(blocks[4].Text, "hello") {
+		t.Errorf("text file block = %+v", blocks[4])
+	}
+}
@@ -8,8 +8,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"path/filepath"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
@@ -32,10 +34,39 @@ type bedrockConverseAPI interface {
 
 // BedrockProvider implements domain.LLMProvider via the AWS Bedrock Converse API.
 type BedrockProvider struct {
-	name   string
-	model  string
-	client bedrockConverseAPI
-	logger *slog.Logger
+	name    string
+	model   string
+	client  bedrockConverseAPI
+	logger  *slog.Logger
+	content contentSupport
+	cache   bool // add cache points for models that support prompt caching
+}
+
+// bedrockImageFormats and bedrockDocumentFormats map MIME types to the
+// Converse API enums. Text-like documents are inlined as text instead.
+var (
+	bedrockImageFormats = map[string]types.ImageFormat{
+		"image/png":  types.ImageFormatPng,
+		"image/jpeg": types.ImageFormatJpeg,
+		"image/gif":  types.ImageFormatGif,
+		"image/webp": types.ImageFormatWebp,
+	}
+	bedrockDocumentFormats = map[string]types.DocumentFormat{
+		"application/pdf":    types.DocumentFormatPdf,
+		"application/msword": types.DocumentFormatDoc,
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document": types.DocumentFormatDocx,
+		"application/vnd.ms-excel": types.DocumentFormatXls,
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": types.DocumentFormatXlsx,
+	}
+)
+
+// bedrockContentSupport reports the content Bedrock accepts; media must be inline.
+func bedrockContentSupport(vision bool) contentSupport {
+	files := make(map[string]bool, len(bedrockDocumentFormats))
+	for mt := range bedrockDocumentFormats {
+		files[mt] = true
+	}
+	return contentSupport{vision: vision, fileTypes: files}
 }
 
 // NewBedrockProvider creates a Bedrock provider using the default AWS credential chain.
@@ -55,20 +86,23 @@ func NewBedrockProvider(cfg config.ProviderConfig, logger *slog.Logger) (*Bedroc
 	client := bedrockruntime.NewFromConfig(awsCfg)
 
 	return &BedrockProvider{
-		name:   cfg.Name,
-		model:  cfg.Model,
-		client: client,
-		logger: logger,
+		name:    cfg.Name,
+		model:   cfg.Model,
+		client:  client,
+		logger:  logger,
+		content: bedrockContentSupport(!cfg.DisableVision),
+		cache:   !cfg.DisablePromptCache,
 	}, nil
 }
 
 // newBedrockProviderWithClient creates a BedrockProvider with an injected client (for testing).
 func newBedrockProviderWithClient(name, model string, client bedrockConverseAPI, logger *slog.Logger) *BedrockProvider {
 	return &BedrockProvider{
-		name:   name,
-		model:  model,
-		client: client,
-		logger: logger,
+		name:    name,
+		model:   model,
+		client:  client,
+		logger:  logger,
+		content: bedrockContentSupport(true),
 	}
 }
 
@@ -85,8 +119,15 @@ func (p *BedrockProvider) Chat(ctx context.Context, req domain.ChatRequest) (*do
 	if req.Model == "" {
 		req.Model = p.model
 	}
+	if err := checkContent(p.name, p.content, req.Messages); err != nil {
+		tracer.RecordError(span, err)
+		return nil, err
+	}
 
 	input := toBedrockConverseInput(req)
+	if p.cache {
+		addBedrockCachePoints(input, req.StableSystemLen)
+	}
 
 	output, err := p.client.Converse(ctx, input)
 	if err != nil {
@@ -95,6 +136,9 @@ func (p *BedrockProvider) Chat(ctx context.Context, req domain.ChatRequest) (*do
 	}
 
 	result := fromBedrockConverseOutput(output, req.Model)
+	if req.ResponseFormat != nil {
+		structuredToolReply(&result.Message, req.ResponseFormat)
+	}
 	setUsageAttrs(span, result.Usage)
 	tracer.SetOK(span)
 	logChatCompleted(p.logger, p.name, result)
@@ -108,13 +152,23 @@ func (p *BedrockProvider) ChatStream(ctx context.Context, req domain.ChatRequest
 		req.Model = p.model
 	}
 
-	input := toBedrockConverseStreamInput(req)
+	if err := checkContent(p.name, p.content, req.Messages); err != nil {
+		return nil, err
+	}
+
+	input := toBedrockConverseStreamInput(req, p.cache)
 
 	output, err := p.client.ConverseStream(ctx, input)
 	if err != nil {
 		return nil, mapBedrockError(err)
 	}
 
+	// The forced structured output tool streams its input as content.
+	var structuredName string
+	if req.ResponseFormat != nil {
+		structuredName = req.ResponseFormat.SchemaName()
+	}
+
 	ch := make(chan domain.StreamDelta, 16)
 	go func() {
 		defer close(ch)
@@ -123,6 +177,9 @@ func (p *BedrockProvider) ChatStream(ctx context.Context, req domain.ChatRequest
 
 		for evt := range stream.Events() {
 			delta := processBedrockStreamEvent(evt)
+			if delta != nil && len(delta.ToolCalls) == 1 && delta.ToolCalls[0].Name == structuredName {
+				continue
+			}
 			if delta != nil {
 				select {
 				case ch <- *delta:
@@ -146,6 +203,13 @@ func (p *BedrockProvider) ChatStream(ctx context.Context, req domain.ChatRequest
 // Name implements domain.LLMProvider.
 func (p *BedrockProvider) Name() string { return p.name }
 
+// SupportsVision implements domain.VisionProvider.
+func (p *BedrockProvider) SupportsVision() bool { return p.content.vision }
+
+// SupportsStructuredOutput implements domain.StructuredOutputProvider
+// through forced tool use.
+func (p *BedrockProvider) SupportsStructuredOutput() bool { return true }
+
 // --- Bedrock request/response conversion ---
 
 func toBedrockConverseInput(req domain.ChatRequest) *bedrockruntime.ConverseInput {
@@ -184,11 +248,25 @@ func toBedrockConverseInput(req domain.ChatRequest) *bedrockruntime.ConverseInpu
 		input.ToolConfig = toBedrockToolConfig(req.Tools)
 	}
 
+	// Structured output: force a call to a tool whose input is the reply.
+	if req.ResponseFormat != nil {
+		name, schema := structuredTool(req.ResponseFormat)
+		tool := domain.ToolSchema{Name: name, Description: "Return the response as this tool's input.", Parameters: schema}
+		if input.ToolConfig == nil {
+			input.ToolConfig = &types.ToolConfiguration{}
+		}
+		input.ToolConfig.Tools = append(input.ToolConfig.Tools, toBedrockToolConfig([]domain.ToolSchema{tool}).Tools...)
+		input.ToolConfig.ToolChoice = &types.ToolChoiceMemberTool{Value: types.SpecificToolChoice{Name: aws.String(name)}}
+	}
+
 	return input
 }
 
-func toBedrockConverseStreamInput(req domain.ChatRequest) *bedrockruntime.ConverseStreamInput {
+func toBedrockConverseStreamInput(req domain.ChatRequest, cache bool) *bedrockruntime.ConverseStreamInput {
 	ci := toBedrockConverseInput(req)
+	if cache {
+		addBedrockCachePoints(ci, req.StableSystemLen)
+	}
 	return &bedrockruntime.ConverseStreamInput{
 		ModelId:         ci.ModelId,
 		Messages:        ci.Messages,
@@ -198,6 +276,63 @@ func toBedrockConverseStreamInput(req domain.ChatRequest) *bedrockruntime.Conver
 	}
 }
 
+// bedrockCacheModels lists the Bedrock models that support prompt caching,
+// by model ID fragment, and whether they can cache tools. Models without
+// prompt caching, Claude 3 Haiku, Claude 3 Sonnet and Claude 2.x among
+// them, reject cache points.
+var bedrockCacheModels = []struct {
+	id    string
+	tools bool
+}{
+	{"anthropic.claude-3-5-haiku", true},
+	{"anthropic.claude-3-7-sonnet", true},
+	{"anthropic.claude-haiku-4", true},
+	{"anthropic.claude-sonnet-4", true},
+	{"anthropic.claude-opus-4", true},
+	{"amazon.nova-micro", false},
+	{"amazon.nova-lite", false},
+	{"amazon.nova-pro", false},
+	{"amazon.nova-premier", false},
+}
+
+// addBedrockCachePoints marks the prompt prefixes to cache: the tool
+// definitions, the stable part of the system prompt and the conversation so
+// far. Only models in bedrockCacheModels get them.
+func addBedrockCachePoints(input *bedrockruntime.ConverseInput, stableLen int) {
+	model := aws.ToString(input.ModelId)
+	supported, cacheTools := false, false
+	for _, m := range bedrockCacheModels {
+		if strings.Contains(model, m.id) {
+			supported, cacheTools = true, m.tools
+			break
+		}
+	}
+	if !supported {
+		return
+	}
+	point := types.CachePointBlock{Type: types.CachePointTypeDefault}
+
+	if cacheTools && input.ToolConfig != nil && len(input.ToolConfig.Tools) > 0 {
+		input.ToolConfig.Tools = append(input.ToolConfig.Tools, &types.ToolMemberCachePoint{Value: point})
+	}
+	if len(input.System) == 1 {
+		text, ok := input.System[0].(*types.SystemContentBlockMemberText)
+		if ok && stableLen > 0 && stableLen < len(text.Value) {
+			input.System = []types.SystemContentBlock{
+				&types.SystemContentBlockMemberText{Value: text.Value[:stableLen]},
+				&types.SystemContentBlockMemberCachePoint{Value: point},
+				&types.SystemContentBlockMemberText{Value: text.Value[stableLen:]},
+			}
+		} else {
+			input.System = append(input.System, &types.SystemContentBlockMemberCachePoint{Value: point})
+		}
+	}
+	if n := len(input.Messages); n > 0 {
+		last := &input.Messages[n-1]
+		last.Content = append(last.Content, &types.ContentBlockMemberCachePoint{Value: point})
+	}
+}
+
 func toBedrockMessage(m domain.Message) *types.Message {
 	msg := &types.Message{}
 
@@ -242,6 +377,10 @@ func toBedrockMessage(m domain.Message) *types.Message {
 
 	case domain.RoleUser:
 		msg.Role = types.ConversationRoleUser
+		if len(m.Parts) > 0 {
+			msg.Content = toBedrockParts(m.Parts)
+			break
+		}
 		msg.Content = []types.ContentBlock{
 			&types.ContentBlockMemberText{Value: m.Content},
 		}
@@ -253,6 +392,55 @@ func toBedrockMessage(m domain.Message) *types.Message {
 	return msg
 }
 
+// toBedrockParts encodes content parts as text, image and document blocks.
+func toBedrockParts(parts []domain.ContentPart) []types.ContentBlock {
+	out := make([]types.ContentBlock, 0, len(parts))
+	for _, p := range parts {
+		switch p.Type {
+		case domain.ContentPartText:
+			out = append(out, &types.ContentBlockMemberText{Value: p.Text})
+		case domain.ContentPartImage:
+			out = append(out, &types.ContentBlockMemberImage{Value: types.ImageBlock{
+				Format: bedrockImageFormats[partMIME(p)],
+				Source: &types.ImageSourceMemberBytes{Value: p.Data},
+			}})
+		case domain.ContentPartFile:
+			if text, ok := textFile(p); ok {
+				out = append(out, &types.ContentBlockMemberText{Value: text})
+				continue
+			}
+			out = append(out, &types.ContentBlockMemberDocument{Value: types.DocumentBlock{
+				Format: bedrockDocumentFormats[partMIME(p)],
+				Name:   aws.String(bedrockDocumentName(p.Filename)),
+				Source: &types.DocumentSourceMemberBytes{Value: p.Data},
+			}})
+		}
+	}
+	return out
+}
+
+// bedrockDocumentName reduces a filename to the characters Bedrock allows
+// (letters, digits, single spaces, hyphens, parentheses and brackets).
+func bedrockDocumentName(filename string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.TrimSuffix(filename, filepath.Ext(filename)) {
+		switch {
+		case r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-()[]", r)):
+			b.WriteRune(r)
+			space = false
+		case !space && b.Len() > 0:
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	name := strings.TrimSpace(b.String())
+	if name == "" {
+		return "document"
+	}
+	return name
+}
+
 func toBedrockToolConfig(tools []domain.ToolSchema) *types.ToolConfiguration {
 	var bedrockTools []types.Tool
 	for _, t := range tools {
@@ -277,6 +465,23 @@ func toBedrockToolConfig(tools []domain.ToolSchema) *types.ToolConfiguration {
 	return &types.ToolConfiguration{Tools: bedrockTools}
 }
 
+// fromBedrockUsage converts Converse token usage. Cache reads and writes
+// are reported apart from input tokens, so they are added back into the
+// prompt count.
+func fromBedrockUsage(u *types.TokenUsage) domain.Usage {
+	cached := int(aws.ToInt32(u.CacheReadInputTokens))
+	written := int(aws.ToInt32(u.CacheWriteInputTokens))
+	prompt := int(aws.ToInt32(u.InputTokens)) + cached + written
+	completion := int(aws.ToInt32(u.OutputTokens))
+	return domain.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      prompt + completion,
+		CachedTokens:     cached,
+		CacheWriteTokens: written,
+	}
+}
+
 func fromBedrockConverseOutput(output *bedrockruntime.ConverseOutput, model string) *domain.ChatResponse {
 	now := time.Now()
 	result := &domain.ChatResponse{
@@ -285,11 +490,7 @@ func fromBedrockConverseOutput(output *bedrockruntime.ConverseOutput, model stri
 	}
 
 	if output.Usage != nil {
-		result.Usage = domain.Usage{
-			PromptTokens:     int(aws.ToInt32(output.Usage.InputTokens)),
-			CompletionTokens: int(aws.ToInt32(output.Usage.OutputTokens)),
-			TotalTokens:      int(aws.ToInt32(output.Usage.InputTokens)) + int(aws.ToInt32(output.Usage.OutputTokens)),
-		}
+		result.Usage = fromBedrockUsage(output.Usage)
 	}
 
 	msg := domain.Message{
@@ -358,11 +559,8 @@ func processBedrockStreamEvent(evt types.ConverseStreamOutput) *domain.StreamDel
 	case *types.ConverseStreamOutputMemberMetadata:
 		delta := &domain.StreamDelta{Done: true}
 		if e.Value.Usage != nil {
-			delta.Usage = &domain.Usage{
-				PromptTokens:     int(aws.ToInt32(e.Value.Usage.InputTokens)),
-				CompletionTokens: int(aws.ToInt32(e.Value.Usage.OutputTokens)),
-				TotalTokens:      int(aws.ToInt32(e.Value.Usage.InputTokens)) + int(aws.ToInt32(e.Value.Usage.OutputTokens)),
-			}
+			usage := fromBedrockUsage(e.Value.Usage)
+			delta.Usage = &usage
 		}
 		return delta
 
@@ -405,6 +405,28 @@ func TestBedrockRequestConversion(t *testing.T) {
 	}
 }
 
+func TestBedrockRequestResponseFormat(t *testing.T) {
+	req := domain.ChatRequest{
+		Model:          "anthropic.claude-3-haiku",
+		Messages:       []domain.Message{{Role: domain.RoleUser, Content: "Point?"}},
+		ResponseFormat: &domain.ResponseFormat{Type: domain.ResponseFormatJSONSchema, Name: "point", Schema: json.RawMessage(`{"type":"object"}`)},
+	}
+
+	input := toBedrockConverseInput(req)
+
+	if input.ToolConfig == nil || len(input.ToolConfig.Tools) != 1 {
+		t.Fatalf("ToolConfig = %+v, want the structured output tool", input.ToolConfig)
+	}
+	spec, ok := input.ToolConfig.Tools[0].(*types.ToolMemberToolSpec)
+	if !ok || aws.ToString(spec.Value.Name) != "point" {
+		t.Errorf("tool = %+v, want point", input.ToolConfig.Tools[0])
+	}
+	choice, ok := input.ToolConfig.ToolChoice.(*types.ToolChoiceMemberTool)
+	if !ok || aws.ToString(choice.Value.Name) != "point" {
+		t.Errorf("ToolChoice = %+v, want the structured output tool forced", input.ToolConfig.ToolChoice)
+	}
+}
+
 func TestBedrockStreamConversion(t *testing.T) {
 	// Test content_block_delta with text
 	textDelta := &types.ConverseStreamOutputMemberContentBlockDelta{
@@ -467,3 +489,65 @@ func TestBedrockStreamConversion(t *testing.T) {
 		t.Errorf("message stop: got %+v", delta)
 	}
 }
+
+func TestBedrockCachePoints(t *testing.T) {
+	req := domain.ChatRequest{
+		Model: "us.anthropic.claude-sonnet-4",
+		Messages: []domain.Message{
+			{Role: domain.RoleSystem, Content: "Persona.\n\n## Relevant Memory Context\n- likes Go"},
+			{Role: domain.RoleUser, Content: "Hello"},
+		},
+		Tools:           []domain.ToolSchema{{Name: "a", Parameters: json.RawMessage(`{"type":"object"}`)}},
+		StableSystemLen: len("Persona."),
+	}
+
+	input := toBedrockConverseInput(req)
+	addBedrockCachePoints(input, req.StableSystemLen)
+
+	if len(input.System) != 3 {
+		t.Fatalf("System len = %d, want text, cache point, text", len(input.System))
+	}
+	if _, ok := input.System[1].(*types.SystemContentBlockMemberCachePoint); !ok {
+		t.Errorf("System[1] = %T, want a cache point", input.System[1])
+	}
+	if text := input.System[0].(*types.SystemContentBlockMemberText).Value; text != "Persona." {
+		t.Errorf("stable system text = %q", text)
+	}
+	if _, ok := input.ToolConfig.Tools[1].(*types.ToolMemberCachePoint); !ok {
+		t.Error("expected a cache point after the tools")
+	}
+	content := input.Messages[0].Content
+	if _, ok := content[len(content)-1].(*types.ContentBlockMemberCachePoint); !ok {
+		t.Error("expected a cache point after the last message")
+	}
+
+	// Nova caches the prompt but not tools; models without prompt caching
+	// get no cache points.
+	req.Model = "amazon.nova-pro-v1:0"
+	input = toBedrockConverseInput(req)
+	addBedrockCachePoints(input, req.StableSystemLen)
+	if len(input.ToolConfig.Tools) != 1 || len(input.System) != 3 {
+		t.Errorf("nova: tools = %d, system = %d", len(input.ToolConfig.Tools), len(input.System))
+	}
+	for _, model := range []string{"meta.llama3-70b", "anthropic.claude-3-haiku-20240307-v1:0", "us.anthropic.claude-3-sonnet-20240229-v1:0", "anthropic.claude-v2:1"} {
+		req.Model = model
+		input = toBedrockConverseInput(req)
+		addBedrockCachePoints(input, req.StableSystemLen)
+		if len(input.ToolConfig.Tools) != 1 || len(input.System) != 1 || len(input.Messages[0].Content) != 1 {
+			t.Errorf("%s should get no cache points", model)
+		}
+	}
+}
+
+func TestBedrockCacheUsage(t *testing.T) {
+	u := fromBedrockUsage(&types.TokenUsage{
+		InputTokens:           aws.Int32(10),
+		OutputTokens:          aws.Int32(5),
+		CacheReadInputTokens:  aws.Int32(1500),
+		CacheWriteInputTokens: aws.Int32(200),
+	})
+	want := domain.Usage{PromptTokens: 1710, CompletionTokens: 5, TotalTokens: 1715, CachedTokens: 1500, CacheWriteTokens: 200}
+	if u != want {
+		t.Errorf("usage = %+v, want %+v", u, want)
+	}
+}
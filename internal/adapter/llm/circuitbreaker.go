@@ -2,6 +2,7 @@ package llm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
@@ -73,8 +74,12 @@ func NewCircuitBreakerProvider(inner domain.LLMProvider, cfg CircuitBreakerConfi
 				"to", to.String(),
 			)
 		},
+		// Rejected requests and replies that failed a response format say
+		// nothing about the provider's health.
 		IsSuccessful: func(err error) bool {
-			return err == nil
+			return err == nil ||
+				errors.Is(err, domain.ErrInvalidInput) ||
+				errors.Is(err, domain.ErrResponseFormat)
 		},
 	})
 
@@ -128,6 +133,9 @@ func (p *CircuitBreakerProvider) ChatStream(ctx context.Context, req domain.Chat
 // Name implements domain.LLMProvider.
 func (p *CircuitBreakerProvider) Name() string { return p.inner.Name() }
 
+// SupportsVision implements domain.VisionProvider.
+func (p *CircuitBreakerProvider) SupportsVision() bool { return domain.SupportsVision(p.inner) }
+
 // State returns the current circuit breaker state for monitoring.
 func (p *CircuitBreakerProvider) State() gobreaker.State {
 	return p.breaker.State()
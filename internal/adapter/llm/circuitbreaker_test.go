@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"testing"
@@ -73,6 +74,24 @@ func TestCircuitBreakerOpensAfterFailures(t *testing.T) {
 	assert.Equal(t, 3, callCount, "provider should not be called when circuit is open")
 }
 
+func TestCircuitBreakerIgnoresCallerErrors(t *testing.T) {
+	for _, want := range []error{domain.ErrInvalidInput, domain.ErrResponseFormat} {
+		inner := &mockProvider{
+			name: "strict",
+			chatFunc: func(_ context.Context, _ domain.ChatRequest) (*domain.ChatResponse, error) {
+				return nil, fmt.Errorf("structured output: %w", want)
+			},
+		}
+		cb := NewCircuitBreakerProvider(inner, CircuitBreakerConfig{MaxFailures: 2}, slog.Default())
+
+		for i := 0; i < 5; i++ {
+			_, err := cb.Chat(context.Background(), domain.ChatRequest{})
+			require.ErrorIs(t, err, want)
+		}
+		assert.Equal(t, gobreaker.StateClosed, cb.State(), "%v should not trip the breaker", want)
+	}
+}
+
 func TestCircuitBreakerClosesAfterSuccess(t *testing.T) {
 	shouldFail := true
 	inner := &mockProvider{
@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"alfred-ai/internal/domain"
+)
+
+// contentSupport describes which multimodal content parts a provider can
+// encode. Text parts are always supported.
+type contentSupport struct {
+	vision    bool            // image parts
+	imageURLs bool            // image parts given by URL instead of inline data
+	fileTypes map[string]bool // non-text file MIME types accepted as documents
+}
+
+// imageTypes are the image formats every supported vision API accepts.
+var imageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// checkContent rejects content parts the provider cannot encode, so that
+// images and files fail loudly instead of being dropped from the request.
+func checkContent(provider string, sup contentSupport, msgs []domain.Message) error {
+	for _, m := range msgs {
+		for _, p := range m.Parts {
+			switch p.Type {
+			case domain.ContentPartText:
+			case domain.ContentPartImage:
+				if !sup.vision {
+					return fmt.Errorf("%s: %w", provider, domain.ErrVisionUnsupported)
+				}
+				if len(p.Data) == 0 {
+					if !sup.imageURLs || p.URL == "" {
+						return fmt.Errorf("%s: %w: image must be sent as inline data", provider, domain.ErrContentUnsupported)
+					}
+					continue
+				}
+				if mt := partMIME(p); !imageTypes[mt] {
+					return fmt.Errorf("%s: %w: image type %s", provider, domain.ErrContentUnsupported, mt)
+				}
+			case domain.ContentPartFile:
+				if _, ok := textFile(p); ok {
+					continue
+				}
+				if mt := partMIME(p); len(p.Data) == 0 || !sup.fileTypes[mt] {
+					return fmt.Errorf("%s: %w: file %q (%s)", provider, domain.ErrContentUnsupported, p.Filename, mt)
+				}
+			default:
+				return fmt.Errorf("%s: %w: part type %q", provider, domain.ErrContentUnsupported, p.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// partMIME returns the bare media type of a part, sniffing inline data when
+// the channel did not supply one.
+func partMIME(p domain.ContentPart) string {
+	if p.MIMEType != "" {
+		if mt, _, err := mime.ParseMediaType(p.MIMEType); err == nil {
+			return mt
+		}
+		return strings.ToLower(p.MIMEType)
+	}
+	if len(p.Data) > 0 {
+		mt, _, _ := mime.ParseMediaType(http.DetectContentType(p.Data))
+		return mt
+	}
+	return "application/octet-stream"
+}
+
+// textFile renders a text-like file part as prompt text. Every provider can
+// read these, so they are inlined rather than sent as documents.
+func textFile(p domain.ContentPart) (string, bool) {
+	if p.Type != domain.ContentPartFile || len(p.Data) == 0 {
+		return "", false
+	}
+	mt := partMIME(p)
+	if !strings.HasPrefix(mt, "text/") && mt != "application/json" && mt != "application/xml" {
+		return "", false
+	}
+	name := p.Filename
+	if name == "" {
+		name = "attachment"
+	}
+	return fmt.Sprintf("<file name=%q>\n%s\n</file>", name, p.Data), true
+}
+
+// dataURL encodes inline part data as an RFC 2397 data URL.
+func dataURL(p domain.ContentPart) string {
+	return "data:" + partMIME(p) + ";base64," + base64.StdEncoding.EncodeToString(p.Data)
+}
@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"alfred-ai/internal/domain"
+	"alfred-ai/internal/infra/config"
+)
+
+var testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func testProviderConfig(name string, disableVision bool) config.ProviderConfig {
+	// The unroutable URL proves content checks run before any request.
+	return config.ProviderConfig{Name: name, Model: "m", BaseURL: "http://127.0.0.1:1", DisableVision: disableVision}
+}
+
+func imageMessage(parts ...domain.ContentPart) []domain.Message {
+	return []domain.Message{domain.NewUserMessage("describe", parts)}
+}
+
+func TestCheckContent(t *testing.T) {
+	full := contentSupport{vision: true, imageURLs: true, fileTypes: map[string]bool{"application/pdf": true}}
+	inlineOnly := contentSupport{vision: true}
+
+	tests := []struct {
+		name string
+		sup  contentSupport
+		part domain.ContentPart
+		want error
+	}{
+		{"inline image", inlineOnly, domain.ContentPart{Type: domain.ContentPartImage, Data: testPNG}, nil},
+		{"no vision", contentSupport{}, domain.ContentPart{Type: domain.ContentPartImage, Data: testPNG}, domain.ErrVisionUnsupported},
+		{"url image", full, domain.ContentPart{Type: domain.ContentPartImage, URL: "https://x/cat.png"}, nil},
+		{"url image inline only", inlineOnly, domain.ContentPart{Type: domain.ContentPartImage, URL: "https://x/cat.png"}, domain.ErrContentUnsupported},
+		{"bad image type", full, domain.ContentPart{Type: domain.ContentPartImage, MIMEType: "image/tiff", Data: []byte("x")}, domain.ErrContentUnsupported},
+		{"pdf", full, domain.ContentPart{Type: domain.ContentPartFile, MIMEType: "application/pdf", Data: []byte("%PDF")}, nil},
+		{"pdf unsupported", inlineOnly, domain.ContentPart{Type: domain.ContentPartFile, MIMEType: "application/pdf", Data: []byte("%PDF")}, domain.ErrContentUnsupported},
+		{"text file always ok", contentSupport{}, domain.ContentPart{Type: domain.ContentPartFile, MIMEType: "text/csv; charset=utf-8", Data: []byte("a,b")}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkContent("test", tt.sup, imageMessage(tt.part))
+			if tt.want == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextFileInlined(t *testing.T) {
+	text, ok := textFile(domain.ContentPart{Type: domain.ContentPartFile, Filename: "notes.md", MIMEType: "text/markdown", Data: []byte("# hi")})
+	if !ok || !strings.Contains(text, `name="notes.md"`) || !strings.Contains(text, "# hi") {
+		t.Errorf("textFile = %q, %v", text, ok)
+	}
+	if _, ok := textFile(domain.ContentPart{Type: domain.ContentPartFile, MIMEType: "application/pdf", Data: []byte("%PDF")}); ok {
+		t.Error("pdf should not be inlined as text")
+	}
+}
+
+func TestProvidersRejectImagesWhenVisionDisabled(t *testing.T) {
+	providers := []domain.LLMProvider{
+		NewOpenAIProvider(testProviderConfig("oai", true), newTestLogger()),
+		NewAnthropicProvider(testProviderConfig("ant", true), newTestLogger()),
+		NewGeminiProvider(testProviderConfig("gem", true), newTestLogger()),
+		NewOllamaProvider(testProviderConfig("oll", true), newTestLogger()),
+	}
+	req := domain.ChatRequest{Messages: imageMessage(domain.ContentPart{Type: domain.ContentPartImage, Data: testPNG})}
+	for _, p := range providers {
+		if domain.SupportsVision(p) {
+			t.Errorf("%s: SupportsVision should be false", p.Name())
+		}
+		if _, err := p.Chat(t.Context(), req); !errors.Is(err, domain.ErrVisionUnsupported) {
+			t.Errorf("%s: error = %v, want ErrVisionUnsupported", p.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"alfred-ai/internal/domain"
+	"alfred-ai/internal/usecase/usage"
+)
+
+// downgradedKey marks calls already rerouted by a downgrade budget so the
+// cheaper provider does not reroute them again.
+type downgradedKey struct{}
+
+// CostProvider wraps an LLMProvider, prices every call into the usage
+// tracker and enforces budgets before the call is made: a block budget
+// fails the call, a downgrade budget reroutes it to a cheaper model
+// preference, and a warn budget only logs.
+type CostProvider struct {
+	inner   domain.LLMProvider
+	tracker *usage.Tracker
+	model   string             // configured model, used when the call names none
+	router  domain.ModelRouter // optional; nil = downgrade budgets only warn
+}
+
+// NewCostProvider wraps inner with cost accounting. model is the provider's
+// configured model.
+func NewCostProvider(inner domain.LLMProvider, tracker *usage.Tracker, model string) *CostProvider {
+	return &CostProvider{inner: inner, tracker: tracker, model: model}
+}
+
+// SetRouter enables downgrade budgets. Call before the provider serves
+// requests.
+func (p *CostProvider) SetRouter(router domain.ModelRouter) {
+	p.router = router
+}
+
+// Chat implements domain.LLMProvider.
+func (p *CostProvider) Chat(ctx context.Context, req domain.ChatRequest) (*domain.ChatResponse, error) {
+	target, err := p.check(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if target != nil {
+		req.Model = ""
+		return target.Chat(context.WithValue(ctx, downgradedKey{}, true), req)
+	}
+
+	resp, err := p.inner.Chat(ctx, req)
+	if err == nil && resp != nil {
+		p.record(ctx, req, resp.Model, resp.Usage)
+	}
+	return resp, err
+}
+
+// ChatStream implements domain.StreamingLLMProvider if the inner provider
+// supports it. Usage is recorded when the stream reports it. A stream the
+// caller abandons is still drained and charged: with its reported usage if
+// it arrives, otherwise with an estimate.
+func (p *CostProvider) ChatStream(ctx context.Context, req domain.ChatRequest) (<-chan domain.StreamDelta, error) {
+	target, err := p.check(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if target != nil {
+		sp, ok := target.(domain.StreamingLLMProvider)
+		if !ok {
+			return nil, fmt.Errorf("provider %q does not support streaming", target.Name())
+		}
+		req.Model = ""
+		return sp.ChatStream(context.WithValue(ctx, downgradedKey{}, true), req)
+	}
+
+	sp, ok := p.inner.(domain.StreamingLLMProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %q does not support streaming", p.inner.Name())
+	}
+	ch, err := sp.ChatStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan domain.StreamDelta)
+	go func() {
+		defer close(out)
+		var u *domain.Usage
+		var completion int
+		aborted := false
+		for delta := range ch {
+			if delta.Usage != nil {
+				u = delta.Usage
+			}
+			completion += len(delta.Content)
+			if aborted {
+				continue
+			}
+			select {
+			case out <- delta:
+			case <-ctx.Done():
+				aborted = true
+			}
+		}
+		switch {
+		case u != nil:
+			p.record(ctx, req, "", *u)
+		case aborted:
+			p.record(ctx, req, "", estimateUsage(req, completion))
+		}
+	}()
+	return out, nil
+}
+
+// estimateUsage approximates the usage of a call that never reported it,
+// at about four characters per token.
+func estimateUsage(req domain.ChatRequest, completionChars int) domain.Usage {
+	var prompt int
+	for _, m := range req.Messages {
+		prompt += len(m.Content)
+	}
+	u := domain.Usage{PromptTokens: prompt / 4, CompletionTokens: completionChars / 4}
+	u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	return u
+}
+
+// check applies the budgets to a call made under ctx. It returns an error
+// for a blocked call and the provider to use instead for a downgraded one.
+func (p *CostProvider) check(ctx context.Context) (domain.LLMProvider, error) {
+	decision := p.tracker.Check(ctx)
+	switch decision.Action {
+	case domain.BudgetBlock:
+		b := decision.Budget
+		return nil, domain.NewSubSystemError("usage", "CostProvider.Chat", domain.ErrLimitReached,
+			fmt.Sprintf("%s %s budget of $%.2f spent ($%.2f)", b.Period, b.Scope, b.Limit, b.Spent))
+	case domain.BudgetDowngrade:
+		if p.router == nil || ctx.Value(downgradedKey{}) != nil {
+			return nil, nil
+		}
+		target, err := p.router.Route(decision.Budget.Downgrade)
+		if err != nil || target.Name() == p.Name() {
+			return nil, nil
+		}
+		return target, nil
+	}
+	return nil, nil
+}
+
+func (p *CostProvider) record(ctx context.Context, req domain.ChatRequest, model string, u domain.Usage) {
+	if model == "" {
+		model = req.Model
+	}
+	if model == "" {
+		model = p.model
+	}
+	// A failed ledger write is logged by the tracker and must not fail a
+	// call that already succeeded.
+	_, _ = p.tracker.Record(ctx, p.inner.Name(), model, u)
+}
+
+// Name implements domain.LLMProvider.
+func (p *CostProvider) Name() string { return p.inner.Name() }
+
+// SupportsVision implements domain.VisionProvider.
+func (p *CostProvider) SupportsVision() bool { return domain.SupportsVision(p.inner) }
+
+// Compile-time interface checks.
+var (
+	_ domain.LLMProvider          = (*CostProvider)(nil)
+	_ domain.StreamingLLMProvider = (*CostProvider)(nil)
+)
@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"alfred-ai/internal/domain"
+	"alfred-ai/internal/usecase/usage"
+)
+
+func newTestTracker(t *testing.T, budgets ...domain.Budget) *usage.Tracker {
+	t.Helper()
+	ledger, err := usage.NewFileLedger(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { ledger.Close() })
+	pricing := usage.Pricing{"big": {Input: 10, Output: 30}, "small": {Input: 1, Output: 2}}
+	tracker, err := usage.NewTracker(ledger, pricing, budgets, newTestLogger())
+	require.NoError(t, err)
+	return tracker
+}
+
+// pricedProvider answers every call with a fixed usage for its model.
+func pricedProvider(name, model string, calls *[]string) *mockStreamProvider {
+	return &mockStreamProvider{
+		mockProvider: mockProvider{
+			name: name,
+			chatFunc: func(_ context.Context, req domain.ChatRequest) (*domain.ChatResponse, error) {
+				*calls = append(*calls, name)
+				return &domain.ChatResponse{
+					Model: model,
+					Usage: domain.Usage{PromptTokens: 100_000, CompletionTokens: 10_000, TotalTokens: 110_000},
+				}, nil
+			},
+		},
+		streamFunc: func(_ context.Context, _ domain.ChatRequest) (<-chan domain.StreamDelta, error) {
+			*calls = append(*calls, name)
+			ch := make(chan domain.StreamDelta, 2)
+			ch <- domain.StreamDelta{Content: "a"}
+			ch <- domain.StreamDelta{Done: true, Usage: &domain.Usage{PromptTokens: 100_000, TotalTokens: 100_000}}
+			close(ch)
+			return ch, nil
+		},
+	}
+}
+
+type staticRouter map[string]domain.LLMProvider
+
+func (r staticRouter) Route(pref string) (domain.LLMProvider, error) { return r[pref], nil }
+
+func TestCostProvider_Records(t *testing.T) {
+	tracker := newTestTracker(t)
+	var calls []string
+	cp := NewCostProvider(pricedProvider("openai", "big", &calls), tracker, "big")
+
+	ctx := domain.ContextWithSessionID(context.Background(), "s1")
+	_, err := cp.Chat(ctx, domain.ChatRequest{})
+	require.NoError(t, err)
+
+	ch, err := cp.ChatStream(ctx, domain.ChatRequest{})
+	require.NoError(t, err)
+	for range ch {
+	}
+
+	require.Eventually(t, func() bool { return tracker.Summary().Today.Calls == 2 }, time.Second, 5*time.Millisecond)
+	// 100k in + 10k out at $10/$30, then a stream of 100k in priced by the configured model.
+	assert.InDelta(t, 1.3+1.0, tracker.Summary().Today.CostUSD, 1e-9)
+}
+
+func TestCostProvider_Budgets(t *testing.T) {
+	tracker := newTestTracker(t,
+		domain.Budget{Scope: domain.BudgetSession, Period: domain.BudgetDaily, Limit: 1, Action: domain.BudgetDowngrade, Downgrade: "cheap"},
+		domain.Budget{Scope: domain.BudgetGlobal, Period: domain.BudgetDaily, Limit: 2, Action: domain.BudgetBlock},
+	)
+	var calls []string
+	small := NewCostProvider(pricedProvider("local", "small", &calls), tracker, "small")
+	big := NewCostProvider(pricedProvider("openai", "big", &calls), tracker, "big")
+	router := staticRouter{"cheap": small}
+	big.SetRouter(router)
+	small.SetRouter(router)
+
+	ctx := domain.ContextWithSessionID(context.Background(), "s1")
+	for range 3 {
+		_, err := big.Chat(ctx, domain.ChatRequest{Model: "big"})
+		require.NoError(t, err)
+	}
+	// The first call spends $1.30 of the session budget; the rest go to the cheap model.
+	assert.Equal(t, []string{"openai", "local", "local"}, calls)
+
+	// The daily global block budget ($1.30 + 2 × $0.12 < $2) is not spent yet.
+	_, err := big.Chat(domain.ContextWithSessionID(context.Background(), "s2"), domain.ChatRequest{})
+	require.NoError(t, err)
+
+	_, err = big.Chat(ctx, domain.ChatRequest{})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, domain.ErrLimitReached)
+	assert.Equal(t, domain.CodeBudgetExceeded, domain.ErrorCodeOf(err))
+
+	_, err = small.ChatStream(ctx, domain.ChatRequest{})
+	assert.ErrorIs(t, err, domain.ErrLimitReached)
+}
+
+func TestCostProvider_ChargesAbortedStreams(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage *domain.Usage
+		want  float64
+	}{
+		{"usage after abort", &domain.Usage{PromptTokens: 100_000, TotalTokens: 100_000}, 1.0},
+		{"no usage", nil, 0.00013}, // 40 chars in, 4 out: 10 + 1 tokens at $10/$30 per million
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newTestTracker(t)
+			inner := make(chan domain.StreamDelta)
+			provider := &mockStreamProvider{
+				mockProvider: mockProvider{name: "openai"},
+				streamFunc: func(context.Context, domain.ChatRequest) (<-chan domain.StreamDelta, error) {
+					return inner, nil
+				},
+			}
+			cp := NewCostProvider(provider, tracker, "big")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			req := domain.ChatRequest{Messages: []domain.Message{{Role: domain.RoleUser, Content: strings.Repeat("x", 40)}}}
+			ch, err := cp.ChatStream(ctx, req)
+			require.NoError(t, err)
+
+			inner <- domain.StreamDelta{Content: "abcd"}
+			<-ch
+			cancel()
+			// The provider still winds the stream down after the caller left.
+			inner <- domain.StreamDelta{Usage: tt.usage}
+			close(inner)
+
+			require.Eventually(t, func() bool { return tracker.Summary().Today.Calls == 1 }, time.Second, 5*time.Millisecond)
+			assert.InDelta(t, tt.want, tracker.Summary().Today.CostUSD, 1e-9)
+		})
+	}
+}
@@ -106,3 +106,7 @@ func (f *FailoverProvider) ChatStream(ctx context.Context, req domain.ChatReques
 func (f *FailoverProvider) Name() string {
 	return f.primary.Name() + "+failover"
 }
+
+// SupportsVision implements domain.VisionProvider. It follows the primary;
+// fallbacks without vision reject image requests themselves.
+func (f *FailoverProvider) SupportsVision() bool { return domain.SupportsVision(f.primary) }
@@ -2,6 +2,7 @@ package llm
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -24,6 +25,7 @@ type GeminiProvider struct {
 	baseURL string
 	client  *http.Client
 	logger  *slog.Logger
+	content contentSupport
 }
 
 // NewGeminiProvider creates a provider for the Google Gemini API.
@@ -40,6 +42,11 @@ func NewGeminiProvider(cfg config.ProviderConfig, logger *slog.Logger) *GeminiPr
 		baseURL: baseURL,
 		client:  NewHTTPClient(cfg),
 		logger:  logger,
+		// File URIs must come from the Files API, so media is sent inline.
+		content: contentSupport{
+			vision:    !cfg.DisableVision,
+			fileTypes: map[string]bool{"application/pdf": true},
+		},
 	}
 }
 
@@ -56,6 +63,10 @@ func (p *GeminiProvider) Chat(ctx context.Context, req domain.ChatRequest) (*dom
 	if req.Model == "" {
 		req.Model = p.model
 	}
+	if err := checkContent(p.name, p.content, req.Messages); err != nil {
+		tracer.RecordError(span, err)
+		return nil, err
+	}
 
 	body, err := json.Marshal(toGeminiRequest(req))
 	if err != nil {
@@ -88,12 +99,24 @@ func (p *GeminiProvider) Chat(ctx context.Context, req domain.ChatRequest) (*dom
 // Name implements domain.LLMProvider.
 func (p *GeminiProvider) Name() string { return p.name }
 
+// SupportsVision implements domain.VisionProvider.
+func (p *GeminiProvider) SupportsVision() bool { return p.content.vision }
+
+// SupportsStructuredOutput implements domain.StructuredOutputProvider.
+func (p *GeminiProvider) SupportsStructuredOutput() bool { return true }
+
 // --- Gemini API wire types ---
 
 type geminiRequest struct {
-	Contents          []geminiContent `json:"contents"`
-	Tools             []geminiTool    `json:"tools,omitempty"`
-	SystemInstruction *geminiContent  `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent         `json:"contents"`
+	Tools             []geminiTool            `json:"tools,omitempty"`
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	ResponseMIMEType string         `json:"responseMimeType,omitempty"`
+	ResponseSchema   map[string]any `json:"responseSchema,omitempty"`
 }
 
 type geminiContent struct {
@@ -103,10 +126,16 @@ type geminiContent struct {
 
 type geminiPart struct {
 	Text             string              `json:"text,omitempty"`
+	InlineData       *geminiBlob         `json:"inlineData,omitempty"`
 	FunctionCall     *geminiFunctionCall `json:"functionCall,omitempty"`
 	FunctionResponse *geminiFuncResponse `json:"functionResponse,omitempty"`
 }
 
+type geminiBlob struct {
+	MIMEType string `json:"mimeType"`
+	Data     string `json:"data"` // base64
+}
+
 type geminiFunctionCall struct {
 	Name string          `json:"name"`
 	Args json.RawMessage `json:"args"`
@@ -137,9 +166,23 @@ type geminiCandidate struct {
 }
 
 type geminiUsage struct {
-	PromptTokenCount     int `json:"promptTokenCount"`
-	CandidatesTokenCount int `json:"candidatesTokenCount"`
-	TotalTokenCount      int `json:"totalTokenCount"`
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount,omitempty"`
+}
+
+// toDomain converts the wire usage. Gemini counts thoughts apart from
+// candidates, so they are added into the completion count.
+func (u geminiUsage) toDomain() domain.Usage {
+	return domain.Usage{
+		PromptTokens:     u.PromptTokenCount,
+		CompletionTokens: u.CandidatesTokenCount + u.ThoughtsTokenCount,
+		TotalTokens:      u.TotalTokenCount,
+		CachedTokens:     u.CachedContentTokenCount,
+		ThinkingTokens:   u.ThoughtsTokenCount,
+	}
 }
 
 // --- Gemini streaming wire types ---
@@ -152,6 +195,10 @@ func (p *GeminiProvider) ChatStream(ctx context.Context, req domain.ChatRequest)
 		req.Model = p.model
 	}
 
+	if err := checkContent(p.name, p.content, req.Messages); err != nil {
+		return nil, err
+	}
+
 	gemReq := toGeminiRequest(req)
 
 	body, err := json.Marshal(gemReq)
@@ -188,11 +235,8 @@ func (p *GeminiProvider) ChatStream(ctx context.Context, req domain.ChatRequest)
 			}
 		}
 		if chunk.UsageMetadata != nil {
-			delta.Usage = &domain.Usage{
-				PromptTokens:     chunk.UsageMetadata.PromptTokenCount,
-				CompletionTokens: chunk.UsageMetadata.CandidatesTokenCount,
-				TotalTokens:      chunk.UsageMetadata.TotalTokenCount,
-			}
+			usage := chunk.UsageMetadata.toDomain()
+			delta.Usage = &usage
 		}
 		return delta, nil
 	})
@@ -242,6 +286,8 @@ func toGeminiRequest(req domain.ChatRequest) geminiRequest {
 					},
 				})
 			}
+		} else if len(m.Parts) > 0 {
+			gc.Parts = toGeminiParts(m.Parts)
 		} else {
 			gc.Parts = []geminiPart{{Text: m.Content}}
 		}
@@ -262,20 +308,107 @@ func toGeminiRequest(req domain.ChatRequest) geminiRequest {
 		gemReq.Tools = []geminiTool{{FunctionDeclarations: decls}}
 	}
 
+	if f := req.ResponseFormat; f != nil {
+		gemReq.GenerationConfig = &geminiGenerationConfig{ResponseMIMEType: "application/json"}
+		if f.Type == domain.ResponseFormatJSONSchema {
+			var schema map[string]any
+			if err := json.Unmarshal(f.JSONSchema(), &schema); err == nil {
+				gemReq.GenerationConfig.ResponseSchema = geminiSchema(schema)
+			}
+		}
+	}
+
 	return gemReq
 }
 
+// geminiSchemaKeys are the JSON Schema keywords Gemini's responseSchema
+// (an OpenAPI subset) accepts; it rejects requests with any other.
+var geminiSchemaKeys = map[string]bool{
+	"type": true, "format": true, "title": true, "description": true, "nullable": true,
+	"enum": true, "items": true, "minItems": true, "maxItems": true, "properties": true,
+	"required": true, "minProperties": true, "maxProperties": true, "minLength": true,
+	"maxLength": true, "pattern": true, "minimum": true, "maximum": true, "anyOf": true,
+	"propertyOrdering": true, "default": true, "example": true,
+}
+
+// geminiSchema reduces a JSON Schema to the keywords responseSchema accepts
+// and maps nullable type unions like ["string", "null"] to nullable. The
+// reply is still validated against the full schema.
+func geminiSchema(schema map[string]any) map[string]any {
+	out := make(map[string]any, len(schema))
+	for k, v := range schema {
+		if !geminiSchemaKeys[k] {
+			continue
+		}
+		switch k {
+		case "type":
+			if types, ok := v.([]any); ok {
+				for _, t := range types {
+					if t == "null" {
+						out["nullable"] = true
+					} else {
+						out["type"] = t
+					}
+				}
+				continue
+			}
+		case "properties":
+			if props, ok := v.(map[string]any); ok {
+				reduced := make(map[string]any, len(props))
+				for name, p := range props {
+					if ps, ok := p.(map[string]any); ok {
+						reduced[name] = geminiSchema(ps)
+					}
+				}
+				v = reduced
+			}
+		case "items":
+			if items, ok := v.(map[string]any); ok {
+				v = geminiSchema(items)
+			}
+		case "anyOf":
+			if alts, ok := v.([]any); ok {
+				reduced := make([]any, 0, len(alts))
+				for _, a := range alts {
+					if as, ok := a.(map[string]any); ok {
+						reduced = append(reduced, geminiSchema(as))
+					}
+				}
+				v = reduced
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// toGeminiParts encodes content parts as text and inline data parts.
+func toGeminiParts(parts []domain.ContentPart) []geminiPart {
+	out := make([]geminiPart, 0, len(parts))
+	for _, p := range parts {
+		if p.Type == domain.ContentPartText {
+			out = append(out, geminiPart{Text: p.Text})
+			continue
+		}
+		if text, ok := textFile(p); ok {
+			out = append(out, geminiPart{Text: text})
+			continue
+		}
+		out = append(out, geminiPart{InlineData: &geminiBlob{
+			MIMEType: partMIME(p),
+			Data:     base64.StdEncoding.EncodeToString(p.Data),
+		}})
+	}
+	return out
+}
+
 func fromGeminiResponse(resp geminiResponse) *domain.ChatResponse {
 	result := &domain.ChatResponse{
 		CreatedAt: time.Now(),
 	}
 
 	if resp.UsageMetadata != nil {
-		result.Usage = domain.Usage{
-			PromptTokens:     resp.UsageMetadata.PromptTokenCount,
-			CompletionTokens: resp.UsageMetadata.CandidatesTokenCount,
-			TotalTokens:      resp.UsageMetadata.TotalTokenCount,
-		}
+		result.Usage = resp.UsageMetadata.toDomain()
 	}
 
 	msg := domain.Message{
@@ -2,7 +2,9 @@ package llm
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -151,6 +153,30 @@ func TestGeminiRequestConversion(t *testing.T) {
 	}
 }
 
+func TestGeminiRequestResponseFormat(t *testing.T) {
+	schema := `{"type":"object","additionalProperties":false,"required":["name"],` +
+		`"properties":{"name":{"type":["string","null"],"$comment":"x"},"tags":{"type":"array","items":{"type":"string","const":"a"}}}}`
+	gemReq := toGeminiRequest(domain.ChatRequest{
+		ResponseFormat: &domain.ResponseFormat{Type: domain.ResponseFormatJSONSchema, Schema: json.RawMessage(schema)},
+	})
+
+	cfg := gemReq.GenerationConfig
+	if cfg == nil || cfg.ResponseMIMEType != "application/json" {
+		t.Fatalf("GenerationConfig = %+v, want a JSON MIME type", cfg)
+	}
+	got, _ := json.Marshal(cfg.ResponseSchema)
+	want := `{"properties":{"name":{"nullable":true,"type":"string"},"tags":{"items":{"type":"string"},"type":"array"}},` +
+		`"required":["name"],"type":"object"}`
+	if string(got) != want {
+		t.Errorf("ResponseSchema = %s, want %s", got, want)
+	}
+
+	gemReq = toGeminiRequest(domain.ChatRequest{ResponseFormat: &domain.ResponseFormat{Type: domain.ResponseFormatJSON}})
+	if cfg := gemReq.GenerationConfig; cfg == nil || cfg.ResponseSchema != nil {
+		t.Errorf("JSON mode should set no schema, got %+v", cfg)
+	}
+}
+
 func TestGeminiRequestWithTools(t *testing.T) {
 	req := domain.ChatRequest{
 		Model: "gemini-pro",
@@ -322,6 +348,20 @@ func TestGeminiResponseNoUsage(t *testing.T) {
 	}
 }
 
+func TestGeminiResponseCachedAndThoughts(t *testing.T) {
+	var resp geminiResponse
+	data := `{"usageMetadata":{"promptTokenCount":100,"cachedContentTokenCount":60,"candidatesTokenCount":20,"thoughtsTokenCount":30,"totalTokenCount":150}}`
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	// Thoughts are counted apart from candidates and folded into completion.
+	want := domain.Usage{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150, CachedTokens: 60, ThinkingTokens: 30}
+	if got := fromGeminiResponse(resp).Usage; got != want {
+		t.Errorf("Usage = %+v, want %+v", got, want)
+	}
+}
+
 func TestGeminiChatToolUseResponse(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		resp := geminiResponse{
@@ -796,3 +836,25 @@ func TestGeminiRequestNoSystem(t *testing.T) {
 		t.Fatalf("Contents len = %d, want 1", len(gemReq.Contents))
 	}
 }
+
+func TestGeminiRequestWithImageParts(t *testing.T) {
+	req := domain.ChatRequest{Messages: imageMessage(
+		domain.ContentPart{Type: domain.ContentPartImage, MIMEType: "image/png", Data: testPNG},
+	)}
+
+	gemReq := toGeminiRequest(req)
+	parts := gemReq.Contents[0].Parts
+	if len(parts) != 2 || parts[0].Text != "describe" {
+		t.Fatalf("parts = %+v", parts)
+	}
+	if parts[1].InlineData == nil || parts[1].InlineData.MIMEType != "image/png" ||
+		parts[1].InlineData.Data != base64.StdEncoding.EncodeToString(testPNG) {
+		t.Errorf("inline data = %+v", parts[1].InlineData)
+	}
+
+	p := NewGeminiProvider(testProviderConfig("gem", false), newTestLogger())
+	urlReq := domain.ChatRequest{Messages: imageMessage(domain.ContentPart{Type: domain.ContentPartImage, URL: "https://example.com/cat.jpg"})}
+	if _, err := p.Chat(t.Context(), urlReq); !errors.Is(err, domain.ErrContentUnsupported) {
+		t.Errorf("URL image error = %v, want ErrContentUnsupported", err)
+	}
+}
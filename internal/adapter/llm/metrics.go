@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"alfred-ai/internal/domain"
+	"alfred-ai/internal/infra/tracer"
+)
+
+// MetricsProvider wraps an LLMProvider and records request latency and token
+// usage through the tracer's metrics pipeline. Recording is a no-op while
+// metrics are disabled.
+type MetricsProvider struct {
+	inner domain.LLMProvider
+}
+
+// NewMetricsProvider wraps inner with metrics recording.
+func NewMetricsProvider(inner domain.LLMProvider) *MetricsProvider {
+	return &MetricsProvider{inner: inner}
+}
+
+// Chat implements domain.LLMProvider.
+func (p *MetricsProvider) Chat(ctx context.Context, req domain.ChatRequest) (*domain.ChatResponse, error) {
+	start := time.Now()
+	resp, err := p.inner.Chat(ctx, req)
+	tracer.RecordLLMCall(ctx, p.inner.Name(), time.Since(start), err)
+	if err == nil && resp != nil {
+		p.recordUsage(ctx, req, resp.Model, resp.Usage)
+	}
+	return resp, err
+}
+
+// ChatStream implements domain.StreamingLLMProvider if the inner provider
+// supports it. Latency is measured until the stream closes.
+func (p *MetricsProvider) ChatStream(ctx context.Context, req domain.ChatRequest) (<-chan domain.StreamDelta, error) {
+	sp, ok := p.inner.(domain.StreamingLLMProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %q does not support streaming", p.inner.Name())
+	}
+
+	start := time.Now()
+	ch, err := sp.ChatStream(ctx, req)
+	if err != nil {
+		tracer.RecordLLMCall(ctx, p.inner.Name(), time.Since(start), err)
+		return nil, err
+	}
+
+	out := make(chan domain.StreamDelta)
+	go func() {
+		defer close(out)
+		var usage domain.Usage
+		for {
+			select {
+			case delta, ok := <-ch:
+				if !ok {
+					tracer.RecordLLMCall(ctx, p.inner.Name(), time.Since(start), nil)
+					p.recordUsage(ctx, req, "", usage)
+					return
+				}
+				if delta.Usage != nil {
+					usage = *delta.Usage
+				}
+				select {
+				case out <- delta:
+				case <-ctx.Done():
+					tracer.RecordLLMCall(ctx, p.inner.Name(), time.Since(start), ctx.Err())
+					return
+				}
+			case <-ctx.Done():
+				tracer.RecordLLMCall(ctx, p.inner.Name(), time.Since(start), ctx.Err())
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (p *MetricsProvider) recordUsage(ctx context.Context, req domain.ChatRequest, model string, usage domain.Usage) {
+	if model == "" {
+		model = req.Model
+	}
+	if model == "" {
+		model = p.inner.Name()
+	}
+	tenant := domain.TenantIDFromContext(ctx)
+	tracer.RecordTokens(ctx, model, tenant, usage.PromptTokens, usage.CompletionTokens)
+}
+
+// Name implements domain.LLMProvider.
+func (p *MetricsProvider) Name() string { return p.inner.Name() }
+
+// SupportsVision implements domain.VisionProvider.
+func (p *MetricsProvider) SupportsVision() bool { return domain.SupportsVision(p.inner) }
+
+// Compile-time interface checks.
+var (
+	_ domain.LLMProvider          = (*MetricsProvider)(nil)
+	_ domain.StreamingLLMProvider = (*MetricsProvider)(nil)
+)
@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"alfred-ai/internal/domain"
+)
+
+func TestMetricsProvider_Chat(t *testing.T) {
+	sentinel := errors.New("boom")
+	inner := &mockProvider{
+		name: "openai",
+		chatFunc: func(_ context.Context, req domain.ChatRequest) (*domain.ChatResponse, error) {
+			if req.Model == "fail" {
+				return nil, sentinel
+			}
+			return &domain.ChatResponse{Message: domain.Message{Content: "ok"}}, nil
+		},
+	}
+	mp := NewMetricsProvider(inner)
+	assert.Equal(t, "openai", mp.Name())
+
+	resp, err := mp.Chat(context.Background(), domain.ChatRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.Message.Content)
+
+	_, err = mp.Chat(context.Background(), domain.ChatRequest{Model: "fail"})
+	assert.ErrorIs(t, err, sentinel)
+}
+
+func TestMetricsProvider_Stream(t *testing.T) {
+	inner := &mockStreamProvider{
+		mockProvider: mockProvider{name: "stream"},
+		streamFunc: func(_ context.Context, _ domain.ChatRequest) (<-chan domain.StreamDelta, error) {
+			ch := make(chan domain.StreamDelta, 2)
+			ch <- domain.StreamDelta{Content: "a"}
+			ch <- domain.StreamDelta{Content: "b", Done: true, Usage: &domain.Usage{PromptTokens: 3}}
+			close(ch)
+			return ch, nil
+		},
+	}
+	ch, err := NewMetricsProvider(inner).ChatStream(context.Background(), domain.ChatRequest{})
+	require.NoError(t, err)
+
+	var content string
+	for delta := range ch {
+		content += delta.Content
+	}
+	assert.Equal(t, "ab", content)
+}
+
+func TestMetricsProvider_StreamCancelled(t *testing.T) {
+	inner := &mockStreamProvider{
+		mockProvider: mockProvider{name: "stream"},
+		streamFunc: func(_ context.Context, _ domain.ChatRequest) (<-chan domain.StreamDelta, error) {
+			ch := make(chan domain.StreamDelta, 1)
+			ch <- domain.StreamDelta{Content: "never read"}
+			return ch, nil // never closed
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := NewMetricsProvider(inner).ChatStream(ctx, domain.ChatRequest{})
+	require.NoError(t, err)
+	cancel()
+
+	// The wrapper stops forwarding and closes its channel.
+	for range ch {
+	}
+}
+
+func TestMetricsProvider_NonStreamingProvider(t *testing.T) {
+	_, err := NewMetricsProvider(&mockProvider{name: "no-stream"}).ChatStream(context.Background(), domain.ChatRequest{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support streaming")
+}
@@ -71,6 +71,8 @@ func NewOllamaProvider(cfg config.ProviderConfig, logger *slog.Logger) *OllamaPr
 			baseURL: baseURL + "/v1",
 			client:  client,
 			logger:  logger,
+			// Ollama's OpenAI endpoint takes base64 images only.
+			content: contentSupport{vision: !cfg.DisableVision},
 		},
 		baseURL: baseURL,
 		client:  client,
@@ -91,6 +93,12 @@ func (p *OllamaProvider) ChatStream(ctx context.Context, req domain.ChatRequest)
 // Name implements domain.LLMProvider.
 func (p *OllamaProvider) Name() string { return p.inner.Name() }
 
+// SupportsVision implements domain.VisionProvider.
+func (p *OllamaProvider) SupportsVision() bool { return p.inner.SupportsVision() }
+
+// SupportsStructuredOutput implements domain.StructuredOutputProvider.
+func (p *OllamaProvider) SupportsStructuredOutput() bool { return true }
+
 // ListModels returns the locally available Ollama models.
 func (p *OllamaProvider) ListModels(ctx context.Context) ([]OllamaModel, error) {
 	url := p.baseURL + "/api/tags"
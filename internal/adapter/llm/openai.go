@@ -24,6 +24,7 @@ type OpenAIProvider struct {
 	baseURL string
 	client  *http.Client
 	logger  *slog.Logger
+	content contentSupport
 }
 
 // NewOpenAIProvider creates a provider with configured timeouts.
@@ -40,6 +41,11 @@ func NewOpenAIProvider(cfg config.ProviderConfig, logger *slog.Logger) *OpenAIPr
 		baseURL: baseURL,
 		client:  NewHTTPClient(cfg),
 		logger:  logger,
+		content: contentSupport{
+			vision:    !cfg.DisableVision,
+			imageURLs: true,
+			fileTypes: map[string]bool{"application/pdf": true},
+		},
 	}
 }
 
@@ -56,6 +62,10 @@ func (p *OpenAIProvider) Chat(ctx context.Context, req domain.ChatRequest) (*dom
 	if req.Model == "" {
 		req.Model = p.model
 	}
+	if err := checkContent(p.name, p.content, req.Messages); err != nil {
+		tracer.RecordError(span, err)
+		return nil, err
+	}
 
 	body, err := json.Marshal(toOpenAIRequest(req))
 	if err != nil {
@@ -91,23 +101,73 @@ func (p *OpenAIProvider) Chat(ctx context.Context, req domain.ChatRequest) (*dom
 // Name implements domain.LLMProvider.
 func (p *OpenAIProvider) Name() string { return p.name }
 
+// SupportsVision implements domain.VisionProvider.
+func (p *OpenAIProvider) SupportsVision() bool { return p.content.vision }
+
+// SupportsStructuredOutput implements domain.StructuredOutputProvider.
+func (p *OpenAIProvider) SupportsStructuredOutput() bool { return true }
+
 // --- OpenAI API wire types ---
 
 type openaiRequest struct {
-	Model       string          `json:"model"`
-	Messages    []openaiMessage `json:"messages"`
-	Tools       []openaiTool    `json:"tools,omitempty"`
-	MaxTokens   int             `json:"max_tokens,omitempty"`
-	Temperature *float64        `json:"temperature,omitempty"`
-	Stream      bool            `json:"stream,omitempty"`
+	Model          string                `json:"model"`
+	Messages       []openaiMessage       `json:"messages"`
+	Tools          []openaiTool          `json:"tools,omitempty"`
+	MaxTokens      int                   `json:"max_tokens,omitempty"`
+	Temperature    *float64              `json:"temperature,omitempty"`
+	Stream         bool                  `json:"stream,omitempty"`
+	ResponseFormat *openaiResponseFormat `json:"response_format,omitempty"`
+}
+
+// openaiResponseFormat selects JSON mode ("json_object") or structured
+// outputs ("json_schema"). Ollama's OpenAI endpoint maps both onto its
+// native format parameter.
+type openaiResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *openaiJSONSchema `json:"json_schema,omitempty"`
+}
+
+type openaiJSONSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
 }
 
 type openaiMessage struct {
-	Role       string           `json:"role"`
-	Content    string           `json:"content,omitempty"`
-	Name       string           `json:"name,omitempty"`
-	ToolCalls  []openaiToolCall `json:"tool_calls,omitempty"`
-	ToolCallID string           `json:"tool_call_id,omitempty"`
+	Role       string              `json:"role"`
+	Content    string              `json:"content,omitempty"`
+	Parts      []openaiContentPart `json:"-"` // sent as "content" when set
+	Name       string              `json:"name,omitempty"`
+	ToolCalls  []openaiToolCall    `json:"tool_calls,omitempty"`
+	ToolCallID string              `json:"tool_call_id,omitempty"`
+}
+
+// MarshalJSON sends Parts as the content array when present, since the API
+// takes either a string or an array in the same field.
+func (m openaiMessage) MarshalJSON() ([]byte, error) {
+	type plain openaiMessage
+	if len(m.Parts) == 0 {
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		plain
+		Content []openaiContentPart `json:"content"`
+	}{plain(m), m.Parts})
+}
+
+type openaiContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openaiImageURL `json:"image_url,omitempty"`
+	File     *openaiFile     `json:"file,omitempty"`
+}
+
+type openaiImageURL struct {
+	URL string `json:"url"`
+}
+
+type openaiFile struct {
+	Filename string `json:"filename,omitempty"`
+	FileData string `json:"file_data"`
 }
 
 type openaiTool struct {
@@ -147,9 +207,25 @@ type openaiChoice struct {
 }
 
 type openaiUsage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+	CompletionTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
+}
+
+func (u openaiUsage) toDomain() domain.Usage {
+	return domain.Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+		CachedTokens:     u.PromptTokensDetails.CachedTokens,
+		ThinkingTokens:   u.CompletionTokensDetails.ReasoningTokens,
+	}
 }
 
 func toOpenAIRequest(req domain.ChatRequest) openaiRequest {
@@ -160,6 +236,9 @@ func toOpenAIRequest(req domain.ChatRequest) openaiRequest {
 			Content: m.Content,
 			Name:    m.Name,
 		}
+		if len(m.Parts) > 0 {
+			oaiMsg.Parts = toOpenAIParts(m.Parts)
+		}
 
 		// Handle tool result messages - map tool_call_id
 		if m.Role == domain.RoleTool && len(m.ToolCalls) > 0 {
@@ -197,6 +276,15 @@ func toOpenAIRequest(req domain.ChatRequest) openaiRequest {
 	if req.Temperature > 0 {
 		oaiReq.Temperature = &req.Temperature
 	}
+	if f := req.ResponseFormat; f != nil {
+		oaiReq.ResponseFormat = &openaiResponseFormat{Type: domain.ResponseFormatJSON}
+		if f.Type == domain.ResponseFormatJSONSchema {
+			oaiReq.ResponseFormat = &openaiResponseFormat{
+				Type:       domain.ResponseFormatJSONSchema,
+				JSONSchema: &openaiJSONSchema{Name: f.SchemaName(), Schema: f.JSONSchema()},
+			}
+		}
+	}
 
 	// Convert tools
 	if len(req.Tools) > 0 {
@@ -216,6 +304,31 @@ func toOpenAIRequest(req domain.ChatRequest) openaiRequest {
 	return oaiReq
 }
 
+// toOpenAIParts encodes content parts; checkContent has already rejected
+// anything the provider cannot take.
+func toOpenAIParts(parts []domain.ContentPart) []openaiContentPart {
+	out := make([]openaiContentPart, 0, len(parts))
+	for _, p := range parts {
+		switch p.Type {
+		case domain.ContentPartText:
+			out = append(out, openaiContentPart{Type: "text", Text: p.Text})
+		case domain.ContentPartImage:
+			url := p.URL
+			if len(p.Data) > 0 {
+				url = dataURL(p)
+			}
+			out = append(out, openaiContentPart{Type: "image_url", ImageURL: &openaiImageURL{URL: url}})
+		case domain.ContentPartFile:
+			if text, ok := textFile(p); ok {
+				out = append(out, openaiContentPart{Type: "text", Text: text})
+				continue
+			}
+			out = append(out, openaiContentPart{Type: "file", File: &openaiFile{Filename: p.Filename, FileData: dataURL(p)}})
+		}
+	}
+	return out
+}
+
 // --- OpenAI streaming wire types ---
 
 type openaiStreamChunk struct {
@@ -240,6 +353,9 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, req domain.ChatRequest)
 		req.Model = p.model
 	}
 	req.Stream = true
+	if err := checkContent(p.name, p.content, req.Messages); err != nil {
+		return nil, err
+	}
 
 	oaiReq := toOpenAIRequest(req)
 	oaiReq.Stream = true
@@ -281,11 +397,8 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, req domain.ChatRequest)
 			}
 		}
 		if chunk.Usage != nil {
-			delta.Usage = &domain.Usage{
-				PromptTokens:     chunk.Usage.PromptTokens,
-				CompletionTokens: chunk.Usage.CompletionTokens,
-				TotalTokens:      chunk.Usage.TotalTokens,
-			}
+			usage := chunk.Usage.toDomain()
+			delta.Usage = &usage
 		}
 		return delta, nil
 	})
@@ -295,13 +408,9 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, req domain.ChatRequest)
 
 func fromOpenAIResponse(resp openaiResponse) *domain.ChatResponse {
 	result := &domain.ChatResponse{
-		ID:    resp.ID,
-		Model: resp.Model,
-		Usage: domain.Usage{
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
-		},
+		ID:        resp.ID,
+		Model:     resp.Model,
+		Usage:     resp.Usage.toDomain(),
 		CreatedAt: time.Unix(resp.Created, 0),
 	}
 
@@ -392,6 +392,25 @@ func TestOpenAIRequestConversion(t *testing.T) {
 	}
 }
 
+func TestOpenAIRequestResponseFormat(t *testing.T) {
+	schema := json.RawMessage(`{"type":"object"}`)
+	oaiReq := toOpenAIRequest(domain.ChatRequest{
+		ResponseFormat: &domain.ResponseFormat{Type: domain.ResponseFormatJSONSchema, Name: "point", Schema: schema},
+	})
+	f := oaiReq.ResponseFormat
+	if f == nil || f.Type != "json_schema" || f.JSONSchema == nil || f.JSONSchema.Name != "point" {
+		t.Fatalf("ResponseFormat = %+v, want a json_schema named point", f)
+	}
+
+	oaiReq = toOpenAIRequest(domain.ChatRequest{ResponseFormat: &domain.ResponseFormat{Type: domain.ResponseFormatJSON}})
+	if f := oaiReq.ResponseFormat; f == nil || f.Type != "json_object" || f.JSONSchema != nil {
+		t.Errorf("ResponseFormat = %+v, want json_object", f)
+	}
+	if toOpenAIRequest(domain.ChatRequest{}).ResponseFormat != nil {
+		t.Error("ResponseFormat should be omitted without a format")
+	}
+}
+
 func TestOpenAIRequestWithToolCalls(t *testing.T) {
 	req := domain.ChatRequest{
 		Model: "gpt-4o",
@@ -613,6 +632,20 @@ func TestOpenAIResponseWithToolCalls(t *testing.T) {
 	}
 }
 
+func TestOpenAIResponseUsageDetails(t *testing.T) {
+	var resp openaiResponse
+	data := `{"usage":{"prompt_tokens":100,"completion_tokens":40,"total_tokens":140,
+		"prompt_tokens_details":{"cached_tokens":64},"completion_tokens_details":{"reasoning_tokens":25}}}`
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	want := domain.Usage{PromptTokens: 100, CompletionTokens: 40, TotalTokens: 140, CachedTokens: 64, ThinkingTokens: 25}
+	if got := fromOpenAIResponse(resp).Usage; got != want {
+		t.Errorf("Usage = %+v, want %+v", got, want)
+	}
+}
+
 func TestOpenAIResponseEmptyChoices(t *testing.T) {
 	resp := openaiResponse{
 		ID:      "chatcmpl-empty",
@@ -1095,3 +1128,56 @@ func TestRegistryNotFound(t *testing.T) {
 		t.Errorf("expected ErrProviderNotFound, got %v", err)
 	}
 }
+
+func TestOpenAIRequestWithImageParts(t *testing.T) {
+	req := domain.ChatRequest{Messages: imageMessage(
+		domain.ContentPart{Type: domain.ContentPartImage, MIMEType: "image/png", Data: testPNG},
+		domain.ContentPart{Type: domain.ContentPartImage, URL: "https://example.com/cat.jpg"},
+		domain.ContentPart{Type: domain.ContentPartFile, Filename: "spec.pdf", MIMEType: "application/pdf", Data: []byte("%PDF-1.7")},
+	)}
+
+	data, err := json.Marshal(toOpenAIRequest(req))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var wire struct {
+		Messages []struct {
+			Content []struct {
+				Type     string `json:"type"`
+				Text     string `json:"text"`
+				ImageURL struct {
+					URL string `json:"url"`
+				} `json:"image_url"`
+				File struct {
+					Filename string `json:"filename"`
+					FileData string `json:"file_data"`
+				} `json:"file"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, data)
+	}
+	parts := wire.Messages[0].Content
+	if len(parts) != 4 {
+		t.Fatalf("parts = %d, want 4: %s", len(parts), data)
+	}
+	if parts[0].Type != "text" || parts[0].Text != "describe" {
+		t.Errorf("part 0 = %+v", parts[0])
+	}
+	if parts[1].Type != "image_url" || !strings.HasPrefix(parts[1].ImageURL.URL, "data:image/png;base64,") {
+		t.Errorf("part 1 = %+v", parts[1])
+	}
+	if parts[2].ImageURL.URL != "https://example.com/cat.jpg" {
+		t.Errorf("part 2 = %+v", parts[2])
+	}
+	if parts[3].Type != "file" || parts[3].File.Filename != "spec.pdf" || !strings.HasPrefix(parts[3].File.FileData, "data:application/pdf;base64,") {
+		t.Errorf("part 3 = %+v", parts[3])
+	}
+
+	// Plain messages still send content as a string.
+	plain, _ := json.Marshal(toOpenAIRequest(domain.ChatRequest{Messages: []domain.Message{{Role: "user", Content: "hi"}}}))
+	if !strings.Contains(string(plain), `"content":"hi"`) {
+		t.Errorf("plain message = %s", plain)
+	}
+}
@@ -55,6 +55,11 @@ func NewOpenRouterProvider(cfg config.ProviderConfig, logger *slog.Logger) *Open
 			baseURL: baseURL,
 			client:  client,
 			logger:  logger,
+			content: contentSupport{
+				vision:    !cfg.DisableVision,
+				imageURLs: true,
+				fileTypes: map[string]bool{"application/pdf": true},
+			},
 		},
 	}
 }
@@ -71,3 +76,9 @@ func (p *OpenRouterProvider) ChatStream(ctx context.Context, req domain.ChatRequ
 
 // Name implements domain.LLMProvider.
 func (p *OpenRouterProvider) Name() string { return p.inner.Name() }
+
+// SupportsVision implements domain.VisionProvider.
+func (p *OpenRouterProvider) SupportsVision() bool { return p.inner.SupportsVision() }
+
+// SupportsStructuredOutput implements domain.StructuredOutputProvider.
+func (p *OpenRouterProvider) SupportsStructuredOutput() bool { return true }
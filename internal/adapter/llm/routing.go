@@ -0,0 +1,471 @@
+package llm
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sony/gobreaker/v2"
+	"go.opentelemetry.io/otel/trace"
+
+	"alfred-ai/internal/domain"
+	"alfred-ai/internal/infra/tracer"
+)
+
+// Default routing settings.
+const (
+	defaultRouteWindow       = 5 * time.Minute
+	defaultRouteMaxErrorRate = 0.5
+	routeMinSamples          = 5   // calls in the window before error rate and latency count
+	routeMaxSamples          = 256 // newest calls kept per provider
+	routeLatencySlack        = 1.5 // p95 within this factor of the fastest counts as equally fast
+)
+
+// errNoFirstToken fails a stream that ended before producing anything.
+var errNoFirstToken = errors.New("stream ended before the first token")
+
+// RoutingConfig tunes how a RoutingProvider judges provider health.
+type RoutingConfig struct {
+	// Window is how far back calls count towards health. Default 5m.
+	Window time.Duration
+	// MaxErrorRate degrades a provider whose error rate in the window is
+	// above it. Default 0.5.
+	MaxErrorRate float64
+	// MaxLatency degrades a provider whose p95 latency is above it. Zero
+	// sets no limit.
+	MaxLatency time.Duration
+}
+
+// RouteMember is one provider behind a RoutingProvider.
+type RouteMember struct {
+	Provider domain.LLMProvider
+	// Weight is the member's share of traffic among healthy members that
+	// are about equally fast. Zero makes it a standby, used only when no
+	// weighted member is healthy and fast.
+	Weight int
+	// Breaker is the member's circuit breaker, if it has one.
+	Breaker *CircuitBreakerProvider
+}
+
+// RoutingProvider sends each call to the healthiest of several providers,
+// e.g. the same model behind several API keys or endpoints. It tracks the
+// rolling p50/p95 latency, error rate and circuit breaker state of each,
+// balances load by weight across those that are healthy and about equally
+// fast, and fails over to the next best on error. Streams fail over only
+// until the first token arrives.
+type RoutingProvider struct {
+	name    string
+	members []*routeMember
+	cfg     RoutingConfig
+	logger  *slog.Logger
+	now     func() time.Time
+	intn    func(n int) int
+
+	mu       sync.Mutex
+	selected string
+}
+
+// routeMember is a RouteMember with its recent calls.
+type routeMember struct {
+	RouteMember
+	mu      sync.Mutex
+	samples []routeSample // oldest first
+}
+
+type routeSample struct {
+	at      time.Time
+	latency time.Duration
+	failed  bool
+}
+
+// NewRoutingProvider creates a provider named name that routes across
+// members. Zero config fields take their defaults.
+func NewRoutingProvider(name string, members []RouteMember, cfg RoutingConfig, logger *slog.Logger) *RoutingProvider {
+	if cfg.Window <= 0 {
+		cfg.Window = defaultRouteWindow
+	}
+	if cfg.MaxErrorRate <= 0 {
+		cfg.MaxErrorRate = defaultRouteMaxErrorRate
+	}
+	rms := make([]*routeMember, len(members))
+	for i, m := range members {
+		rms[i] = &routeMember{RouteMember: m}
+	}
+	return &RoutingProvider{
+		name:    name,
+		members: rms,
+		cfg:     cfg,
+		logger:  logger,
+		now:     time.Now,
+		intn:    rand.IntN,
+	}
+}
+
+// Chat implements domain.LLMProvider. Members are tried from healthiest
+// to least healthy until one succeeds.
+func (r *RoutingProvider) Chat(ctx context.Context, req domain.ChatRequest) (*domain.ChatResponse, error) {
+	ctx, span := r.startSpan(ctx)
+	defer span.End()
+
+	var allErrors []string
+	for i, m := range r.rank(span) {
+		start := r.now()
+		resp, err := m.Provider.Chat(ctx, req)
+		r.observe(ctx, m.routeMember, start, err)
+		if err == nil {
+			r.served(span, m, i+1)
+			return resp, nil
+		}
+		allErrors = append(allErrors, fmt.Sprintf("%s: %v", m.Provider.Name(), err))
+		if ctx.Err() != nil {
+			break
+		}
+		r.logger.Warn("routed LLM failed, trying the next provider",
+			"route", r.name, "provider", m.Provider.Name(), "state", m.state, "error", err)
+	}
+
+	err := fmt.Errorf("all providers failed: [%s]", joinErrors(allErrors))
+	tracer.RecordError(span, err)
+	return nil, err
+}
+
+// ChatStream implements domain.StreamingLLMProvider. It waits for the
+// first token of each stream it opens and moves on to the next member if
+// the stream fails or ends before one. Once a token has arrived the
+// stream is committed to that member.
+func (r *RoutingProvider) ChatStream(ctx context.Context, req domain.ChatRequest) (<-chan domain.StreamDelta, error) {
+	ctx, span := r.startSpan(ctx)
+	defer span.End()
+
+	var allErrors []string
+	for i, m := range r.rank(span) {
+		sp, ok := m.Provider.(domain.StreamingLLMProvider)
+		if !ok {
+			continue
+		}
+		start := r.now()
+		ch, err := sp.ChatStream(ctx, req)
+		var head []domain.StreamDelta
+		if err == nil {
+			head, err = firstToken(ctx, ch)
+		}
+		r.observe(ctx, m.routeMember, start, err)
+		if err == nil {
+			r.served(span, m, i+1)
+			return forwardStream(ctx, head, ch), nil
+		}
+		allErrors = append(allErrors, fmt.Sprintf("%s: %v", m.Provider.Name(), err))
+		if ctx.Err() != nil {
+			break
+		}
+		r.logger.Warn("routed streaming LLM failed before the first token, trying the next provider",
+			"route", r.name, "provider", m.Provider.Name(), "state", m.state, "error", err)
+	}
+
+	if len(allErrors) == 0 {
+		return nil, fmt.Errorf("no streaming-capable providers available")
+	}
+	err := fmt.Errorf("all streaming providers failed: [%s]", joinErrors(allErrors))
+	tracer.RecordError(span, err)
+	return nil, err
+}
+
+// firstToken reads ch up to and including the first delta with content,
+// thinking, tool calls or Done, and returns what it read.
+func firstToken(ctx context.Context, ch <-chan domain.StreamDelta) ([]domain.StreamDelta, error) {
+	var head []domain.StreamDelta
+	for {
+		select {
+		case delta, ok := <-ch:
+			if !ok {
+				return nil, errNoFirstToken
+			}
+			head = append(head, delta)
+			if delta.Content != "" || delta.Thinking != "" || len(delta.ToolCalls) > 0 || delta.Done {
+				return head, nil
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// forwardStream replays head and then the rest of ch.
+func forwardStream(ctx context.Context, head []domain.StreamDelta, ch <-chan domain.StreamDelta) <-chan domain.StreamDelta {
+	out := make(chan domain.StreamDelta)
+	go func() {
+		defer close(out)
+		for _, delta := range head {
+			select {
+			case out <- delta:
+			case <-ctx.Done():
+				return
+			}
+		}
+		for delta := range ch {
+			select {
+			case out <- delta:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (r *RoutingProvider) startSpan(ctx context.Context) (context.Context, trace.Span) {
+	return tracer.StartSpan(ctx, "llm.route",
+		trace.WithAttributes(tracer.StringAttr("llm.route", r.name)),
+	)
+}
+
+// served records the member that answered a call.
+func (r *RoutingProvider) served(span trace.Span, m rankedMember, attempts int) {
+	r.mu.Lock()
+	r.selected = m.Provider.Name()
+	r.mu.Unlock()
+	span.SetAttributes(
+		tracer.StringAttr("llm.route.provider", m.Provider.Name()),
+		tracer.StringAttr("llm.route.state", m.state),
+		tracer.IntAttr("llm.route.attempts", attempts),
+	)
+	tracer.SetOK(span)
+}
+
+// observe adds a call to the member's health unless its error says more
+// about the request or the caller than about the provider.
+func (r *RoutingProvider) observe(ctx context.Context, m *routeMember, start time.Time, err error) {
+	if err != nil && !providerFault(ctx, err) {
+		return
+	}
+	now := r.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.samples) == routeMaxSamples {
+		m.samples = append(m.samples[:0], m.samples[1:]...)
+	}
+	m.samples = append(m.samples, routeSample{at: now, latency: now.Sub(start), failed: err != nil})
+}
+
+// providerFault reports whether a failed call counts against the provider.
+// Rejected requests, malformed structured replies, budgets, cancellations
+// and calls an open circuit breaker refused do not.
+func providerFault(ctx context.Context, err error) bool {
+	switch {
+	case ctx.Err() != nil,
+		errors.Is(err, domain.ErrInvalidInput),
+		errors.Is(err, domain.ErrResponseFormat),
+		errors.Is(err, domain.ErrContentUnsupported),
+		errors.Is(err, domain.ErrContextOverflow),
+		errors.Is(err, domain.ErrLimitReached),
+		errors.Is(err, gobreaker.ErrOpenState),
+		errors.Is(err, gobreaker.ErrTooManyRequests):
+		return false
+	}
+	return true
+}
+
+// memberStats summarizes a member's calls in the health window.
+type memberStats struct {
+	calls, errors int
+	timed         int           // successful calls, the ones with a latency
+	p50, p95      time.Duration // over successful calls
+}
+
+func (m *routeMember) stats(since time.Time) memberStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var s memberStats
+	var latencies []time.Duration
+	for _, sample := range m.samples {
+		if sample.at.Before(since) {
+			continue
+		}
+		s.calls++
+		if sample.failed {
+			s.errors++
+			continue
+		}
+		latencies = append(latencies, sample.latency)
+	}
+	s.timed = len(latencies)
+	if s.timed > 0 {
+		slices.Sort(latencies)
+		s.p50 = percentile(latencies, 0.50)
+		s.p95 = percentile(latencies, 0.95)
+	}
+	return s
+}
+
+// percentile returns the nearest-rank percentile of sorted values.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)]
+}
+
+// state judges a member's health from its stats.
+func (r *RoutingProvider) state(m *routeMember, s memberStats) string {
+	if m.Breaker != nil && m.Breaker.State() == gobreaker.StateOpen {
+		return domain.RouteOpen
+	}
+	if s.calls >= routeMinSamples && float64(s.errors)/float64(s.calls) > r.cfg.MaxErrorRate {
+		return domain.RouteDegraded
+	}
+	if r.cfg.MaxLatency > 0 && s.timed >= routeMinSamples && s.p95 > r.cfg.MaxLatency {
+		return domain.RouteDegraded
+	}
+	return domain.RouteHealthy
+}
+
+// rankedMember is a member with its standing for one call.
+type rankedMember struct {
+	*routeMember
+	state string
+	p95   time.Duration // 0 while there are too few calls to tell
+	fast  bool          // healthy and within routeLatencySlack of the fastest
+}
+
+var routeStateOrder = map[string]int{domain.RouteHealthy: 0, domain.RouteDegraded: 1, domain.RouteOpen: 2}
+
+// rank orders the members for a call: healthy before degraded before
+// open, then fast before slow, weighted before standby, and by p95. The
+// first member is then drawn by weight from the leading fast, weighted
+// members. Providers without enough calls to judge count as fast, so they
+// are tried again once their failures leave the window.
+func (r *RoutingProvider) rank(span trace.Span) []rankedMember {
+	since := r.now().Add(-r.cfg.Window)
+	ranked := make([]rankedMember, len(r.members))
+	var fastest time.Duration
+	for i, m := range r.members {
+		s := m.stats(since)
+		rm := rankedMember{routeMember: m, state: r.state(m, s)}
+		if s.timed >= routeMinSamples {
+			rm.p95 = s.p95
+		}
+		if rm.state == domain.RouteHealthy && rm.p95 > 0 && (fastest == 0 || rm.p95 < fastest) {
+			fastest = rm.p95
+		}
+		ranked[i] = rm
+	}
+	for i := range ranked {
+		rm := &ranked[i]
+		rm.fast = rm.state == domain.RouteHealthy &&
+			(rm.p95 == 0 || float64(rm.p95) <= float64(fastest)*routeLatencySlack)
+	}
+
+	slices.SortStableFunc(ranked, func(a, b rankedMember) int {
+		return cmp.Or(
+			cmp.Compare(routeStateOrder[a.state], routeStateOrder[b.state]),
+			compareBool(a.fast, b.fast),
+			compareBool(a.Weight > 0, b.Weight > 0),
+			cmp.Compare(a.p95, b.p95),
+		)
+	})
+
+	n, total := 0, 0
+	for n < len(ranked) && ranked[n].fast && ranked[n].Weight > 0 {
+		total += ranked[n].Weight
+		n++
+	}
+	if n > 1 {
+		pick := r.intn(total)
+		for i := range n {
+			if pick < ranked[i].Weight {
+				chosen := ranked[i]
+				copy(ranked[1:i+1], ranked[:i])
+				ranked[0] = chosen
+				break
+			}
+			pick -= ranked[i].Weight
+		}
+	}
+
+	names := make([]string, len(ranked))
+	for i, m := range ranked {
+		names[i] = m.Provider.Name()
+	}
+	span.SetAttributes(tracer.StringAttr("llm.route.candidates", strings.Join(names, ",")))
+	return ranked
+}
+
+// compareBool orders true before false.
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// Status reports the route's current view of its members.
+func (r *RoutingProvider) Status() domain.RouteStatus {
+	since := r.now().Add(-r.cfg.Window)
+	r.mu.Lock()
+	status := domain.RouteStatus{Name: r.name, Selected: r.selected}
+	r.mu.Unlock()
+	for _, m := range r.members {
+		s := m.stats(since)
+		h := domain.ProviderHealth{
+			Provider: m.Provider.Name(),
+			Weight:   m.Weight,
+			State:    r.state(m, s),
+			Calls:    s.calls,
+			P50Ms:    s.p50.Milliseconds(),
+			P95Ms:    s.p95.Milliseconds(),
+		}
+		if s.calls > 0 {
+			h.ErrorRate = float64(s.errors) / float64(s.calls)
+		}
+		if m.Breaker != nil {
+			h.Breaker = m.Breaker.State().String()
+		}
+		status.Providers = append(status.Providers, h)
+	}
+	return status
+}
+
+// Name implements domain.LLMProvider.
+func (r *RoutingProvider) Name() string { return r.name }
+
+// SupportsVision implements domain.VisionProvider. It reports true if any
+// member does; members without vision reject image requests themselves
+// and the call moves on.
+func (r *RoutingProvider) SupportsVision() bool {
+	for _, m := range r.members {
+		if domain.SupportsVision(m.Provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// RouteSet reports the status of several routing providers. It implements
+// domain.RoutingReporter.
+type RouteSet []*RoutingProvider
+
+// RouteStatus implements domain.RoutingReporter.
+func (s RouteSet) RouteStatus() []domain.RouteStatus {
+	out := make([]domain.RouteStatus, len(s))
+	for i, r := range s {
+		out[i] = r.Status()
+	}
+	return out
+}
+
+// Compile-time interface checks.
+var (
+	_ domain.LLMProvider          = (*RoutingProvider)(nil)
+	_ domain.StreamingLLMProvider = (*RoutingProvider)(nil)
+	_ domain.RoutingReporter      = RouteSet(nil)
+)
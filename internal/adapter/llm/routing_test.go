@@ -0,0 +1,270 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+// routeStub answers calls after latency on the route's fake clock, or
+// fails with err.
+func routeStub(name string, clock *time.Time, latency time.Duration, err error, calls *[]string) *mockStreamProvider {
+	answer := func() error {
+		*calls = append(*calls, name)
+		*clock = clock.Add(latency)
+		return err
+	}
+	return &mockStreamProvider{
+		mockProvider: mockProvider{
+			name: name,
+			chatFunc: func(_ context.Context, _ domain.ChatRequest) (*domain.ChatResponse, error) {
+				if err := answer(); err != nil {
+					return nil, err
+				}
+				return &domain.ChatResponse{Message: domain.Message{Content: name}}, nil
+			},
+		},
+		streamFunc: func(_ context.Context, _ domain.ChatRequest) (<-chan domain.StreamDelta, error) {
+			if err := answer(); err != nil {
+				return nil, err
+			}
+			ch := make(chan domain.StreamDelta, 2)
+			ch <- domain.StreamDelta{Content: name}
+			ch <- domain.StreamDelta{Done: true}
+			close(ch)
+			return ch, nil
+		},
+	}
+}
+
+func newTestRoute(cfg RoutingConfig, clock *time.Time, members ...RouteMember) *RoutingProvider {
+	r := NewRoutingProvider("route", members, cfg, newTestLogger())
+	r.now = func() time.Time { return *clock }
+	r.intn = func(int) int { return 0 }
+	return r
+}
+
+// warm records n past calls for the member at index i.
+func warm(r *RoutingProvider, i int, n int, latency time.Duration, failed bool) {
+	m := r.members[i]
+	for range n {
+		m.samples = append(m.samples, routeSample{at: r.now(), latency: latency, failed: failed})
+	}
+}
+
+func TestRoutingProvider_WeightedBalance(t *testing.T) {
+	clock := time.Now()
+	var calls []string
+	r := newTestRoute(RoutingConfig{}, &clock,
+		RouteMember{Provider: routeStub("key1", &clock, time.Second, nil, &calls), Weight: 3},
+		RouteMember{Provider: routeStub("key2", &clock, time.Second, nil, &calls), Weight: 1},
+		RouteMember{Provider: routeStub("standby", &clock, time.Second, nil, &calls)},
+	)
+
+	for _, pick := range []int{0, 2, 3} {
+		r.intn = func(n int) int {
+			if n != 4 {
+				t.Fatalf("intn(%d), want the total weight 4", n)
+			}
+			return pick
+		}
+		if _, err := r.Chat(context.Background(), domain.ChatRequest{}); err != nil {
+			t.Fatalf("Chat: %v", err)
+		}
+	}
+	if strings.Join(calls, ",") != "key1,key1,key2" {
+		t.Errorf("calls = %v, want picks by weight", calls)
+	}
+	if r.Status().Selected != "key2" {
+		t.Errorf("Selected = %q, want key2", r.Status().Selected)
+	}
+}
+
+func TestRoutingProvider_RoutesAroundUnhealthy(t *testing.T) {
+	tests := []struct {
+		name    string
+		warm    func(r *RoutingProvider)
+		cfg     RoutingConfig
+		advance time.Duration
+		want    string
+		state   string
+	}{
+		{"slow", func(r *RoutingProvider) {
+			warm(r, 0, 10, 5*time.Second, false)
+			warm(r, 1, 10, time.Second, false)
+		}, RoutingConfig{}, 0, "b", domain.RouteHealthy},
+		{"failing", func(r *RoutingProvider) {
+			warm(r, 0, 5, time.Second, true)
+			warm(r, 0, 3, time.Second, false)
+		}, RoutingConfig{}, 0, "b", domain.RouteDegraded},
+		{"over max latency", func(r *RoutingProvider) {
+			warm(r, 0, 10, 3*time.Second, false)
+		}, RoutingConfig{MaxLatency: 2 * time.Second}, 0, "b", domain.RouteDegraded},
+		{"outside the window", func(r *RoutingProvider) {
+			warm(r, 0, 10, time.Second, true)
+		}, RoutingConfig{Window: time.Minute}, 2 * time.Minute, "a", domain.RouteHealthy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := time.Now()
+			var calls []string
+			r := newTestRoute(tt.cfg, &clock,
+				RouteMember{Provider: routeStub("a", &clock, time.Second, nil, &calls), Weight: 5},
+				RouteMember{Provider: routeStub("b", &clock, time.Second, nil, &calls), Weight: 1},
+			)
+			tt.warm(r)
+			clock = clock.Add(tt.advance)
+
+			resp, err := r.Chat(context.Background(), domain.ChatRequest{})
+			if err != nil {
+				t.Fatalf("Chat: %v", err)
+			}
+			if resp.Message.Content != tt.want {
+				t.Errorf("served by %q, want %q", resp.Message.Content, tt.want)
+			}
+			if got := r.Status().Providers[0].State; got != tt.state {
+				t.Errorf("state of a = %q, want %q", got, tt.state)
+			}
+		})
+	}
+}
+
+func TestRoutingProvider_FailsOver(t *testing.T) {
+	clock := time.Now()
+	var calls []string
+	r := newTestRoute(RoutingConfig{}, &clock,
+		RouteMember{Provider: routeStub("a", &clock, time.Second, errors.New("503"), &calls), Weight: 1},
+		RouteMember{Provider: routeStub("b", &clock, 2*time.Second, nil, &calls), Weight: 1},
+	)
+
+	resp, err := r.Chat(context.Background(), domain.ChatRequest{})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Message.Content != "b" || len(calls) != 2 {
+		t.Errorf("served by %q after %v, want b after a", resp.Message.Content, calls)
+	}
+
+	status := r.Status()
+	a, b := status.Providers[0], status.Providers[1]
+	if a.Calls != 1 || a.ErrorRate != 1 || b.Calls != 1 || b.ErrorRate != 0 || b.P95Ms != 2000 {
+		t.Errorf("status = %+v", status)
+	}
+}
+
+func TestRoutingProvider_CallerErrorsDoNotCount(t *testing.T) {
+	clock := time.Now()
+	var calls []string
+	r := newTestRoute(RoutingConfig{}, &clock,
+		RouteMember{Provider: routeStub("a", &clock, time.Second, domain.ErrInvalidInput, &calls), Weight: 1},
+	)
+
+	_, err := r.Chat(context.Background(), domain.ChatRequest{})
+	if err == nil || !strings.Contains(err.Error(), "all providers failed") {
+		t.Fatalf("err = %v", err)
+	}
+	if got := r.Status().Providers[0].Calls; got != 0 {
+		t.Errorf("Calls = %d, want rejected requests left out of health", got)
+	}
+}
+
+func TestRoutingProvider_OpenBreakerLast(t *testing.T) {
+	clock := time.Now()
+	var calls []string
+	broken := NewCircuitBreakerProvider(routeStub("a", &clock, time.Second, errors.New("down"), &calls),
+		CircuitBreakerConfig{MaxFailures: 1, Timeout: time.Hour}, newTestLogger())
+	broken.Chat(context.Background(), domain.ChatRequest{})
+	calls = nil
+
+	r := newTestRoute(RoutingConfig{}, &clock,
+		RouteMember{Provider: broken, Weight: 1, Breaker: broken},
+		RouteMember{Provider: routeStub("b", &clock, time.Second, nil, &calls)},
+	)
+
+	resp, err := r.Chat(context.Background(), domain.ChatRequest{})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Message.Content != "b" || len(calls) != 1 {
+		t.Errorf("served by %q after %v, want the standby without calling a", resp.Message.Content, calls)
+	}
+	if a := r.Status().Providers[0]; a.State != domain.RouteOpen || a.Breaker != "open" {
+		t.Errorf("a = %+v, want an open breaker", a)
+	}
+}
+
+func TestRoutingProvider_StreamFailsOverBeforeFirstToken(t *testing.T) {
+	clock := time.Now()
+	var calls []string
+	empty := routeStub("a", &clock, time.Second, nil, &calls)
+	empty.streamFunc = func(_ context.Context, _ domain.ChatRequest) (<-chan domain.StreamDelta, error) {
+		calls = append(calls, "a")
+		ch := make(chan domain.StreamDelta, 1)
+		ch <- domain.StreamDelta{Usage: &domain.Usage{PromptTokens: 1}}
+		close(ch)
+		return ch, nil
+	}
+	r := newTestRoute(RoutingConfig{}, &clock,
+		RouteMember{Provider: empty, Weight: 1},
+		RouteMember{Provider: routeStub("b", &clock, time.Second, nil, &calls), Weight: 1},
+	)
+
+	ch, err := r.ChatStream(context.Background(), domain.ChatRequest{})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	var content string
+	for delta := range ch {
+		content += delta.Content
+	}
+	if content != "b" || len(calls) != 2 {
+		t.Errorf("content = %q after %v, want b after a", content, calls)
+	}
+	if a := r.Status().Providers[0]; a.Calls != 1 || a.ErrorRate != 1 {
+		t.Errorf("a = %+v, want the empty stream counted as a failure", a)
+	}
+}
+
+func TestRoutingProvider_StreamCommitsAfterFirstToken(t *testing.T) {
+	clock := time.Now()
+	var calls []string
+	partial := routeStub("a", &clock, time.Second, nil, &calls)
+	partial.streamFunc = func(_ context.Context, _ domain.ChatRequest) (<-chan domain.StreamDelta, error) {
+		calls = append(calls, "a")
+		ch := make(chan domain.StreamDelta, 1)
+		ch <- domain.StreamDelta{Content: "par"}
+		close(ch) // dropped mid-stream
+		return ch, nil
+	}
+	r := newTestRoute(RoutingConfig{}, &clock,
+		RouteMember{Provider: partial, Weight: 1},
+		RouteMember{Provider: routeStub("b", &clock, time.Second, nil, &calls), Weight: 1},
+	)
+
+	ch, err := r.ChatStream(context.Background(), domain.ChatRequest{})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	var content string
+	for delta := range ch {
+		content += delta.Content
+	}
+	if content != "par" || len(calls) != 1 {
+		t.Errorf("content = %q after %v, want only a's tokens", content, calls)
+	}
+}
+
+func TestRouteSet(t *testing.T) {
+	clock := time.Now()
+	var calls []string
+	r := newTestRoute(RoutingConfig{}, &clock, RouteMember{Provider: routeStub("a", &clock, 0, nil, &calls), Weight: 2})
+
+	routes := RouteSet{r}.RouteStatus()
+	if len(routes) != 1 || routes[0].Name != "route" || len(routes[0].Providers) != 1 || routes[0].Providers[0].Weight != 2 {
+		t.Errorf("RouteStatus = %+v", routes)
+	}
+}
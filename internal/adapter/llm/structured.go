@@ -0,0 +1,210 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/kaptinlin/jsonschema"
+
+	"alfred-ai/internal/domain"
+)
+
+// defaultStructuredRepairs is how often an invalid structured reply is sent
+// back to the model with the validation error before the call fails.
+const defaultStructuredRepairs = 2
+
+// StructuredProvider wraps an LLMProvider so that replies to requests with
+// a ResponseFormat are JSON matching it. Providers without native support
+// are asked for JSON in the system prompt. Every reply is validated, and an
+// invalid one is returned to the model with the error to repair.
+type StructuredProvider struct {
+	inner   domain.LLMProvider
+	native  bool
+	repairs int
+	logger  *slog.Logger
+}
+
+// NewStructuredProvider wraps inner with response format enforcement.
+func NewStructuredProvider(inner domain.LLMProvider, logger *slog.Logger) *StructuredProvider {
+	return &StructuredProvider{
+		inner:   inner,
+		native:  domain.SupportsStructuredOutput(inner),
+		repairs: defaultStructuredRepairs,
+		logger:  logger,
+	}
+}
+
+// Chat implements domain.LLMProvider. For a request with a ResponseFormat
+// the reply's Content is the validated JSON and Usage covers every attempt.
+func (p *StructuredProvider) Chat(ctx context.Context, req domain.ChatRequest) (*domain.ChatResponse, error) {
+	format := req.ResponseFormat
+	if format == nil {
+		return p.inner.Chat(ctx, req)
+	}
+	schema, err := jsonschema.NewCompiler().Compile(format.JSONSchema())
+	if err != nil {
+		return nil, domain.NewSubSystemError("llm", "StructuredProvider.Chat", domain.ErrInvalidInput,
+			fmt.Sprintf("invalid response schema: %v", err))
+	}
+	req.Messages = p.withFormatPrompt(req.Messages, format)
+
+	var usage domain.Usage
+	for attempt := 0; ; attempt++ {
+		resp, err := p.inner.Chat(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		usage = addUsage(usage, resp.Usage)
+
+		raw, verr := parseStructuredReply(resp.Message.Content, schema)
+		if verr == nil {
+			resp.Message.Content = raw
+			resp.Usage = usage
+			return resp, nil
+		}
+		if attempt == p.repairs {
+			return nil, domain.NewSubSystemError("llm", "StructuredProvider.Chat", domain.ErrResponseFormat, verr.Error())
+		}
+		p.logger.Debug("structured reply invalid, asking for a repair",
+			"provider", p.inner.Name(), "attempt", attempt+1, "error", verr)
+
+		req.Messages = append(req.Messages[:len(req.Messages):len(req.Messages)],
+			domain.Message{Role: domain.RoleAssistant, Content: resp.Message.Content},
+			domain.Message{Role: domain.RoleUser, Content: fmt.Sprintf(
+				"That reply is invalid: %v\nRespond again with only the corrected JSON value.", verr)},
+		)
+	}
+}
+
+// ChatStream implements domain.StreamingLLMProvider if the inner provider
+// supports it. Streamed replies are prompted for JSON but not validated.
+func (p *StructuredProvider) ChatStream(ctx context.Context, req domain.ChatRequest) (<-chan domain.StreamDelta, error) {
+	sp, ok := p.inner.(domain.StreamingLLMProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %q does not support streaming", p.inner.Name())
+	}
+	if req.ResponseFormat != nil {
+		req.Messages = p.withFormatPrompt(req.Messages, req.ResponseFormat)
+	}
+	return sp.ChatStream(ctx, req)
+}
+
+// withFormatPrompt asks for a JSON reply in the system prompt, spelling
+// out the schema for providers that cannot enforce it themselves.
+func (p *StructuredProvider) withFormatPrompt(msgs []domain.Message, format *domain.ResponseFormat) []domain.Message {
+	instruction := "Respond with only a JSON value. Do not wrap it in markdown fences or add commentary."
+	if !p.native {
+		instruction = "Respond with only a JSON value that matches this JSON Schema. " +
+			"Do not wrap it in markdown fences or add commentary.\n" + string(format.JSONSchema())
+	}
+
+	out := make([]domain.Message, 0, len(msgs)+1)
+	if len(msgs) > 0 && msgs[0].Role == domain.RoleSystem {
+		system := msgs[0]
+		system.Content += "\n\n" + instruction
+		out = append(out, system)
+		msgs = msgs[1:]
+	} else {
+		out = append(out, domain.Message{Role: domain.RoleSystem, Content: instruction})
+	}
+	return append(out, msgs...)
+}
+
+// Name implements domain.LLMProvider.
+func (p *StructuredProvider) Name() string { return p.inner.Name() }
+
+// SupportsVision implements domain.VisionProvider.
+func (p *StructuredProvider) SupportsVision() bool { return domain.SupportsVision(p.inner) }
+
+// SupportsStructuredOutput implements domain.StructuredOutputProvider.
+func (p *StructuredProvider) SupportsStructuredOutput() bool { return true }
+
+// Compile-time interface checks.
+var (
+	_ domain.LLMProvider          = (*StructuredProvider)(nil)
+	_ domain.StreamingLLMProvider = (*StructuredProvider)(nil)
+)
+
+// jsonFenceRe matches a markdown code fence around a reply.
+var jsonFenceRe = regexp.MustCompile("(?s)^```[a-zA-Z]*\\s*(.*?)\\s*```$")
+
+// parseStructuredReply strips a surrounding code fence from a reply and
+// checks that the rest is JSON matching schema.
+func parseStructuredReply(reply string, schema *jsonschema.Schema) (string, error) {
+	raw := strings.TrimSpace(reply)
+	if m := jsonFenceRe.FindStringSubmatch(raw); m != nil {
+		raw = m[1]
+	}
+	var parsed any
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return "", fmt.Errorf("not valid JSON: %v", err)
+	}
+	if result := schema.Validate(parsed); !result.IsValid() {
+		return "", fmt.Errorf("does not match the schema: %s", result.Error())
+	}
+	return raw, nil
+}
+
+func addUsage(a, b domain.Usage) domain.Usage {
+	a.PromptTokens += b.PromptTokens
+	a.CompletionTokens += b.CompletionTokens
+	a.TotalTokens += b.TotalTokens
+	a.CachedTokens += b.CachedTokens
+	a.CacheWriteTokens += b.CacheWriteTokens
+	a.ThinkingTokens += b.ThinkingTokens
+	return a
+}
+
+// structuredTool describes the tool that providers without a JSON mode
+// (Anthropic, Bedrock) are forced to call to return structured output.
+// Tool inputs must be objects, so other schemas are wrapped in a "value"
+// property that structuredToolReply unwraps again.
+func structuredTool(format *domain.ResponseFormat) (name string, schema json.RawMessage) {
+	schema = format.JSONSchema()
+	if !isObjectSchema(schema) {
+		schema = json.RawMessage(`{"type":"object","properties":{"value":` + string(schema) + `},"required":["value"]}`)
+	}
+	return format.SchemaName(), schema
+}
+
+// structuredToolReply moves the input of the forced tool call into the
+// message content.
+func structuredToolReply(msg *domain.Message, format *domain.ResponseFormat) {
+	name := format.SchemaName()
+	for i, tc := range msg.ToolCalls {
+		if tc.Name != name {
+			continue
+		}
+		content := tc.Arguments
+		if !isObjectSchema(format.JSONSchema()) {
+			var wrapped struct {
+				Value json.RawMessage `json:"value"`
+			}
+			if json.Unmarshal(tc.Arguments, &wrapped) == nil {
+				content = wrapped.Value
+			}
+		}
+		msg.Content = string(content)
+		msg.ToolCalls = append(msg.ToolCalls[:i:i], msg.ToolCalls[i+1:]...)
+		if len(msg.ToolCalls) == 0 {
+			msg.ToolCalls = nil
+		}
+		return
+	}
+}
+
+// isObjectSchema reports whether schema describes a JSON object.
+func isObjectSchema(schema json.RawMessage) bool {
+	var s struct {
+		Type       any             `json:"type"`
+		Properties json.RawMessage `json:"properties"`
+	}
+	if json.Unmarshal(schema, &s) != nil {
+		return false
+	}
+	return s.Type == "object" || (s.Type == nil && len(s.Properties) > 0)
+}
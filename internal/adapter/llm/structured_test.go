@@ -0,0 +1,159 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"alfred-ai/internal/domain"
+)
+
+var pointSchema = json.RawMessage(`{"type":"object","required":["x"],"properties":{"x":{"type":"integer"}}}`)
+
+// nativeProvider is a mockProvider that enforces response formats itself.
+type nativeProvider struct{ mockProvider }
+
+func (p *nativeProvider) SupportsStructuredOutput() bool { return true }
+
+// replyProvider answers calls with replies in turn and records the requests.
+func replyProvider(reqs *[]domain.ChatRequest, replies ...string) *mockProvider {
+	return &mockProvider{
+		name: "mock",
+		chatFunc: func(_ context.Context, req domain.ChatRequest) (*domain.ChatResponse, error) {
+			*reqs = append(*reqs, req)
+			reply := replies[min(len(*reqs), len(replies))-1]
+			return &domain.ChatResponse{
+				Message: domain.Message{Role: domain.RoleAssistant, Content: reply},
+				Usage:   domain.Usage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12},
+			}, nil
+		},
+	}
+}
+
+func TestStructuredProviderRepairs(t *testing.T) {
+	var reqs []domain.ChatRequest
+	p := NewStructuredProvider(replyProvider(&reqs, "sure!", `{"x": "one"}`, "```json\n{\"x\": 1}\n```"), newTestLogger())
+
+	resp, err := p.Chat(context.Background(), domain.ChatRequest{
+		Messages:       []domain.Message{{Role: domain.RoleSystem, Content: "Be brief."}, {Role: domain.RoleUser, Content: "Point?"}},
+		ResponseFormat: &domain.ResponseFormat{Type: domain.ResponseFormatJSONSchema, Schema: pointSchema},
+	})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Message.Content != `{"x": 1}` {
+		t.Errorf("Content = %q, want the unfenced JSON", resp.Message.Content)
+	}
+	if len(reqs) != 3 {
+		t.Fatalf("calls = %d, want 3", len(reqs))
+	}
+	if resp.Usage.TotalTokens != 36 {
+		t.Errorf("TotalTokens = %d, want usage summed over 3 calls", resp.Usage.TotalTokens)
+	}
+
+	last := reqs[2].Messages
+	if len(last) != 6 {
+		t.Fatalf("repair request has %d messages, want 6", len(last))
+	}
+	if last[2].Content != "sure!" || !strings.Contains(last[3].Content, "not valid JSON") {
+		t.Errorf("first repair = %q / %q", last[2].Content, last[3].Content)
+	}
+	if !strings.Contains(last[5].Content, "does not match the schema") {
+		t.Errorf("second repair = %q", last[5].Content)
+	}
+	if system := last[0].Content; !strings.HasPrefix(system, "Be brief.") || !strings.Contains(system, string(pointSchema)) {
+		t.Errorf("system prompt should keep the original and add the schema, got %q", system)
+	}
+}
+
+func TestStructuredProviderGivesUp(t *testing.T) {
+	var reqs []domain.ChatRequest
+	p := NewStructuredProvider(replyProvider(&reqs, "no"), newTestLogger())
+
+	_, err := p.Chat(context.Background(), domain.ChatRequest{
+		Messages:       []domain.Message{{Role: domain.RoleUser, Content: "Point?"}},
+		ResponseFormat: &domain.ResponseFormat{Type: domain.ResponseFormatJSONSchema, Schema: pointSchema},
+	})
+	if !errors.Is(err, domain.ErrResponseFormat) {
+		t.Fatalf("err = %v, want ErrResponseFormat", err)
+	}
+	if len(reqs) != 1+defaultStructuredRepairs {
+		t.Errorf("calls = %d, want %d", len(reqs), 1+defaultStructuredRepairs)
+	}
+}
+
+func TestStructuredProviderNative(t *testing.T) {
+	var reqs []domain.ChatRequest
+	p := NewStructuredProvider(&nativeProvider{*replyProvider(&reqs, `{"x": 2}`)}, newTestLogger())
+
+	format := &domain.ResponseFormat{Type: domain.ResponseFormatJSONSchema, Schema: pointSchema}
+	if _, err := p.Chat(context.Background(), domain.ChatRequest{
+		Messages:       []domain.Message{{Role: domain.RoleUser, Content: "Point?"}},
+		ResponseFormat: format,
+	}); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	got := reqs[0]
+	if got.ResponseFormat != format {
+		t.Error("the response format should reach the inner provider")
+	}
+	if got.Messages[0].Role != domain.RoleSystem || strings.Contains(got.Messages[0].Content, `"required"`) {
+		t.Errorf("native providers get a short JSON instruction, got %+v", got.Messages[0])
+	}
+}
+
+func TestStructuredProviderPassThrough(t *testing.T) {
+	var reqs []domain.ChatRequest
+	p := NewStructuredProvider(replyProvider(&reqs, "plain text"), newTestLogger())
+
+	resp, err := p.Chat(context.Background(), domain.ChatRequest{
+		Messages: []domain.Message{{Role: domain.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Message.Content != "plain text" || len(reqs[0].Messages) != 1 {
+		t.Errorf("requests without a format should pass through, got %q / %+v", resp.Message.Content, reqs[0].Messages)
+	}
+}
+
+func TestStructuredProviderInvalidSchema(t *testing.T) {
+	var reqs []domain.ChatRequest
+	p := NewStructuredProvider(replyProvider(&reqs, "{}"), newTestLogger())
+
+	_, err := p.Chat(context.Background(), domain.ChatRequest{
+		ResponseFormat: &domain.ResponseFormat{Type: domain.ResponseFormatJSONSchema, Schema: json.RawMessage(`{"type": 5}`)},
+	})
+	if !errors.Is(err, domain.ErrInvalidInput) || len(reqs) != 0 {
+		t.Errorf("err = %v after %d calls, want ErrInvalidInput before any call", err, len(reqs))
+	}
+}
+
+func TestStructuredToolReply(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema json.RawMessage
+		args   string
+		want   string
+	}{
+		{"object", pointSchema, `{"x":1}`, `{"x":1}`},
+		{"wrapped array", json.RawMessage(`{"type":"array","items":{"type":"string"}}`), `{"value":["a","b"]}`, `["a","b"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format := &domain.ResponseFormat{Type: domain.ResponseFormatJSONSchema, Name: "out", Schema: tt.schema}
+			name, schema := structuredTool(format)
+			if name != "out" || !isObjectSchema(schema) {
+				t.Fatalf("structuredTool = %q, %s; want an object schema named out", name, schema)
+			}
+
+			msg := domain.Message{ToolCalls: []domain.ToolCall{{ID: "1", Name: "out", Arguments: json.RawMessage(tt.args)}}}
+			structuredToolReply(&msg, format)
+			if msg.Content != tt.want || msg.ToolCalls != nil {
+				t.Errorf("reply = %q with %d tool calls, want %q and none", msg.Content, len(msg.ToolCalls), tt.want)
+			}
+		})
+	}
+}
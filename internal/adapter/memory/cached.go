@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
@@ -22,6 +23,7 @@ type CachedMemory struct {
 
 type cachedResult struct {
 	entries   []domain.MemoryEntry
+	scored    []domain.ScoredMemoryEntry // structured query results
 	expiresAt time.Time
 }
 
@@ -83,6 +85,35 @@ func (c *CachedMemory) Query(ctx context.Context, query string, limit int) ([]do
 	return entries, nil
 }
 
+// QueryStructured implements domain.StructuredQuerier, caching results per
+// distinct query. Providers without native support are queried through
+// domain.QueryMemory.
+func (c *CachedMemory) QueryStructured(ctx context.Context, q domain.MemoryQuery) ([]domain.ScoredMemoryEntry, error) {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return nil, err
+	}
+	key := cacheKey("structured:"+string(data), 0)
+
+	c.mu.RLock()
+	if cached, ok := c.cache[key]; ok && time.Now().Before(cached.expiresAt) {
+		c.mu.RUnlock()
+		return cached.scored, nil
+	}
+	c.mu.RUnlock()
+
+	results, err := domain.QueryMemory(ctx, c.inner, q)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.cache[key] = cachedResult{scored: results, expiresAt: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+
+	return results, nil
+}
+
 func (c *CachedMemory) Delete(ctx context.Context, id string) error {
 	err := c.inner.Delete(ctx, id)
 	if err == nil {
@@ -103,6 +134,12 @@ func (c *CachedMemory) Sync(ctx context.Context) error        { return c.inner.S
 func (c *CachedMemory) Name() string                          { return c.inner.Name() }
 func (c *CachedMemory) IsAvailable() bool                     { return c.inner.IsAvailable() }
 
+// Compile-time interface checks.
+var (
+	_ domain.BatchStorer       = (*CachedMemory)(nil)
+	_ domain.StructuredQuerier = (*CachedMemory)(nil)
+)
+
 // invalidate clears the entire cache.
 func (c *CachedMemory) invalidate() {
 	c.mu.Lock()
@@ -249,3 +249,44 @@ func TestCachedDelegatesMethods(t *testing.T) {
 // Compile-time interface check.
 var _ domain.MemoryProvider = (*CachedMemory)(nil)
 var _ domain.BatchStorer = (*CachedMemory)(nil)
+
+func TestCachedQueryStructured(t *testing.T) {
+	inner := &trackingMemory{
+		entries: []domain.MemoryEntry{
+			{ID: "1", Content: "a", Tags: []string{"x"}},
+			{ID: "2", Content: "b"},
+		},
+	}
+	cached := NewCachedMemory(inner, time.Minute)
+	ctx := context.Background()
+	q := domain.MemoryQuery{Tags: []string{"x"}}
+
+	for range 2 {
+		results, err := cached.QueryStructured(ctx, q)
+		if err != nil {
+			t.Fatalf("QueryStructured: %v", err)
+		}
+		if len(results) != 1 || results[0].ID != "1" {
+			t.Fatalf("results = %v, want [1]", results)
+		}
+	}
+	if n := inner.queryCalls.Load(); n != 1 {
+		t.Errorf("inner queries = %d, want 1", n)
+	}
+
+	// A different filter is a different key; text queries are cached apart.
+	if _, err := cached.QueryStructured(ctx, domain.MemoryQuery{Tags: []string{"y"}}); err != nil {
+		t.Fatalf("QueryStructured: %v", err)
+	}
+	if _, err := cached.Query(ctx, "", 10); err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if n := inner.queryCalls.Load(); n != 3 {
+		t.Errorf("inner queries = %d, want 3", n)
+	}
+
+	cached.Store(ctx, domain.MemoryEntry{ID: "3", Content: "c"})
+	if cached.CacheSize() != 0 {
+		t.Error("Store should invalidate structured results")
+	}
+}
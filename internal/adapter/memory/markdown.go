@@ -102,7 +102,9 @@ func (m *MarkdownMemory) Store(_ context.Context, entry domain.MemoryEntry) erro
 		Filename:       filename,
 		Tags:           entry.Tags,
 		ContentPreview: preview,
+		Metadata:       entry.Metadata,
 		CreatedAt:      entry.CreatedAt,
+		UpdatedAt:      entry.UpdatedAt,
 	}); err != nil {
 		return domain.NewDomainError("MarkdownMemory.Store", domain.ErrMemoryIndex, err.Error())
 	}
@@ -118,15 +120,9 @@ func (m *MarkdownMemory) Query(_ context.Context, query string, limit int) ([]do
 
 	entries := make([]domain.MemoryEntry, 0, len(matches))
 	for _, match := range matches {
-		path := filepath.Join(m.entriesDir, match.Filename)
-		data, err := os.ReadFile(path)
+		entry, err := m.readEntry(match.Filename)
 		if err != nil {
-			continue // skip missing files
-		}
-
-		entry, err := m.parseEntry(data)
-		if err != nil {
-			continue // skip malformed files
+			continue // skip missing or malformed files
 		}
 		entries = append(entries, *entry)
 	}
@@ -134,6 +130,41 @@ func (m *MarkdownMemory) Query(_ context.Context, query string, limit int) ([]do
 	return entries, nil
 }
 
+// QueryStructured implements domain.StructuredQuerier. Filters are applied
+// on the index before any entry file is read.
+func (m *MarkdownMemory) QueryStructured(_ context.Context, q domain.MemoryQuery) ([]domain.ScoredMemoryEntry, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	want := max(q.Offset, 0) + limit
+
+	var results []domain.ScoredMemoryEntry
+	for _, match := range m.index.SearchQuery(q) {
+		if match.Score < q.MinScore {
+			break // sorted by score
+		}
+		entry, err := m.readEntry(match.Entry.Filename)
+		if err != nil || !q.Matches(*entry) {
+			continue
+		}
+		results = append(results, domain.ScoredMemoryEntry{MemoryEntry: *entry, Score: match.Score})
+		if len(results) == want {
+			break
+		}
+	}
+	return domain.PageMemoryResults(results, q.Offset, limit), nil
+}
+
+// readEntry loads and parses an entry file.
+func (m *MarkdownMemory) readEntry(filename string) (*domain.MemoryEntry, error) {
+	data, err := os.ReadFile(filepath.Join(m.entriesDir, filename))
+	if err != nil {
+		return nil, err
+	}
+	return m.parseEntry(data)
+}
+
 func (m *MarkdownMemory) Delete(_ context.Context, id string) error {
 	filename := m.index.GetFilename(id)
 	if filename == "" {
@@ -167,6 +198,9 @@ func (m *MarkdownMemory) Sync(_ context.Context) error {
 func (m *MarkdownMemory) Name() string      { return "markdown" }
 func (m *MarkdownMemory) IsAvailable() bool { return true }
 
+// Compile-time interface check.
+var _ domain.StructuredQuerier = (*MarkdownMemory)(nil)
+
 // renderEntry produces a markdown file with optional body encryption.
 func (m *MarkdownMemory) renderEntry(entry domain.MemoryEntry) string {
 	body := entry.Content
@@ -10,15 +10,19 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"alfred-ai/internal/domain"
 )
 
 // IndexEntry represents a single entry in the memory index.
 type IndexEntry struct {
-	ID             string    `json:"id"`
-	Filename       string    `json:"filename"`
-	Tags           []string  `json:"tags"`
-	ContentPreview string    `json:"content_preview"`
-	CreatedAt      time.Time `json:"created_at"`
+	ID             string            `json:"id"`
+	Filename       string            `json:"filename"`
+	Tags           []string          `json:"tags"`
+	ContentPreview string            `json:"content_preview"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at,omitempty"` // zero for entries indexed before it was recorded
 }
 
 // MemoryIndex is an in-memory index backed by index.json.
@@ -92,49 +96,94 @@ func (idx *MemoryIndex) All() []IndexEntry {
 // Search finds entries matching the query keywords, scored by relevance + recency.
 // An empty query returns all entries sorted by recency.
 func (idx *MemoryIndex) Search(query string, limit int) []IndexEntry {
-	idx.mu.RLock()
-	defer idx.mu.RUnlock()
-
-	keywords := tokenize(query)
+	results := idx.search(tokenize(query), nil)
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
 
-	// Empty query: return all entries sorted by recency
-	if len(keywords) == 0 {
-		entries := make([]IndexEntry, 0, len(idx.entries))
-		for _, e := range idx.entries {
-			entries = append(entries, e)
-		}
-		sort.Slice(entries, func(i, j int) bool {
-			return entries[i].CreatedAt.After(entries[j].CreatedAt)
-		})
-		if limit > 0 && len(entries) > limit {
-			entries = entries[:limit]
-		}
-		return entries
+	entries := make([]IndexEntry, len(results))
+	for i, r := range results {
+		entries[i] = r.Entry
 	}
+	return entries
+}
+
+// SearchQuery is Search with the filters of q applied, returning every
+// match with its score. Entries indexed without metadata or an update time
+// are kept when those filters cannot be checked, so callers must re-check
+// q.Matches against the full entry.
+func (idx *MemoryIndex) SearchQuery(q domain.MemoryQuery) []searchResult {
+	return idx.search(tokenize(q.Text), func(e IndexEntry) bool {
+		return q.Matches(e.filterEntry(q))
+	})
+}
+
+// search scores the entries that pass keep (nil keeps all) against
+// keywords, best first. Without keywords every entry matches and scores
+// by recency rank.
+func (idx *MemoryIndex) search(keywords []string, keep func(IndexEntry) bool) []searchResult {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
 
 	var results []searchResult
 	now := time.Now()
 
 	for _, entry := range idx.entries {
+		if keep != nil && !keep(entry) {
+			continue
+		}
+		if len(keywords) == 0 {
+			results = append(results, searchResult{Entry: entry})
+			continue
+		}
 		score := scoreEntry(entry, keywords, now)
 		if score > 0 {
 			results = append(results, searchResult{Entry: entry, Score: score})
 		}
 	}
 
+	// Empty query: rank by recency
+	if len(keywords) == 0 {
+		sort.Slice(results, func(i, j int) bool {
+			return results[i].Entry.CreatedAt.After(results[j].Entry.CreatedAt)
+		})
+		for i := range results {
+			results[i].Score = 1 / float64(i+1)
+		}
+		return results
+	}
+
 	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Entry.CreatedAt.After(results[j].Entry.CreatedAt)
 	})
+	return results
+}
 
-	if limit > 0 && len(results) > limit {
-		results = results[:limit]
+// filterEntry returns the entry as a domain.MemoryEntry for filtering by q.
+// For legacy entries, the fields the index lacks are taken from q so they
+// pass.
+func (e IndexEntry) filterEntry(q domain.MemoryQuery) domain.MemoryEntry {
+	entry := domain.MemoryEntry{
+		ID:        e.ID,
+		Tags:      e.Tags,
+		Metadata:  e.Metadata,
+		CreatedAt: e.CreatedAt,
+		UpdatedAt: e.UpdatedAt,
 	}
-
-	entries := make([]IndexEntry, len(results))
-	for i, r := range results {
-		entries[i] = r.Entry
+	if e.UpdatedAt.IsZero() {
+		entry.Metadata = q.MetadataFilter()
+		entry.UpdatedAt = e.CreatedAt
+		if !q.UpdatedAfter.IsZero() || !q.UpdatedBefore.IsZero() {
+			entry.UpdatedAt = q.UpdatedAfter
+			if entry.UpdatedAt.IsZero() {
+				entry.UpdatedAt = q.UpdatedBefore
+			}
+		}
 	}
-	return entries
+	return entry
 }
 
 // Len returns the number of entries.
@@ -755,3 +755,85 @@ func TestMarkdownMemory_StoreWithExistingID(t *testing.T) {
 		t.Errorf("ID = %q, want %q", results[0].ID, "preset-id")
 	}
 }
+
+func TestMarkdownMemory_QueryStructured(t *testing.T) {
+	mem, err := NewMarkdownMemory(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewMarkdownMemory: %v", err)
+	}
+	ctx := context.Background()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, e := range []domain.MemoryEntry{
+		{ID: "a", Content: "deploy api", Tags: []string{"ops"}, Metadata: map[string]string{"source": "chat"}},
+		{ID: "b", Content: "deploy worker", Tags: []string{"ops", "archived"}, Metadata: map[string]string{"source": "chat"}},
+		{ID: "c", Content: "deploy docs", Tags: []string{"ops"}, Metadata: map[string]string{"source": "auto-curate"}},
+		{ID: "d", Content: "lunch", Tags: []string{"personal"}},
+	} {
+		e.CreatedAt = base.Add(time.Duration(i) * 24 * time.Hour)
+		if err := mem.Store(ctx, e); err != nil {
+			t.Fatalf("Store: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		q    domain.MemoryQuery
+		want []string
+	}{
+		{"text", domain.MemoryQuery{Text: "deploy"}, []string{"c", "b", "a"}},
+		{"exclude tags", domain.MemoryQuery{Text: "deploy", ExcludeTags: []string{"archived"}}, []string{"c", "a"}},
+		{"source", domain.MemoryQuery{Text: "deploy", Source: "chat"}, []string{"b", "a"}},
+		{"created before", domain.MemoryQuery{CreatedBefore: base.Add(36 * time.Hour)}, []string{"b", "a"}},
+		{"paged", domain.MemoryQuery{Text: "deploy", Limit: 1, Offset: 1}, []string{"b"}},
+		{"offset past end", domain.MemoryQuery{Text: "deploy", Offset: 5}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := mem.QueryStructured(ctx, tt.q)
+			if err != nil {
+				t.Fatalf("QueryStructured: %v", err)
+			}
+			got := make([]string, len(results))
+			for i, r := range results {
+				got[i] = r.ID
+				if r.Score <= 0 {
+					t.Errorf("%s score = %v, want > 0", r.ID, r.Score)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownMemory_QueryStructuredLegacyIndex(t *testing.T) {
+	dir := t.TempDir()
+	mem, err := NewMarkdownMemory(dir)
+	if err != nil {
+		t.Fatalf("NewMarkdownMemory: %v", err)
+	}
+	ctx := context.Background()
+	if err := mem.Store(ctx, domain.MemoryEntry{ID: "old", Content: "legacy note", Metadata: map[string]string{"source": "chat"}}); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	// Simulate an index written before metadata was recorded.
+	entry := mem.index.entries["old"]
+	entry.Metadata = nil
+	entry.UpdatedAt = time.Time{}
+	if err := mem.index.Add(entry); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	for source, want := range map[string]int{"chat": 1, "import": 0} {
+		results, err := mem.QueryStructured(ctx, domain.MemoryQuery{Text: "legacy", Source: source})
+		if err != nil {
+			t.Fatalf("QueryStructured: %v", err)
+		}
+		if len(results) != want {
+			t.Errorf("source %q: got %d results, want %d", source, len(results), want)
+		}
+	}
+}
@@ -41,13 +41,51 @@ func (t *TenantScopedMemory) Query(ctx context.Context, query string, limit int)
 	// Filter results to only entries belonging to this tenant.
 	filtered := make([]domain.MemoryEntry, 0, len(entries))
 	for _, e := range entries {
-		if e.Metadata["tenant_id"] == t.tenantID || e.Metadata["tenant_id"] == "" {
+		if t.owns(e) {
 			filtered = append(filtered, e)
 		}
 	}
 	return filtered, nil
 }
 
+// QueryStructured implements domain.StructuredQuerier. Other tenants'
+// entries are dropped before paging, so the inner provider is asked for
+// progressively larger windows until the page fills or results run out.
+func (t *TenantScopedMemory) QueryStructured(ctx context.Context, q domain.MemoryQuery) ([]domain.ScoredMemoryEntry, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	want := min(max(q.Offset, 0), domain.MaxMemoryQueryOffset) + limit
+
+	inner := q
+	inner.Offset = 0
+	for inner.Limit = want; ; inner.Limit *= 2 {
+		results, err := domain.QueryMemory(t.scopedCtx(ctx), t.inner, inner)
+		if err != nil {
+			return nil, err
+		}
+		owned := make([]domain.ScoredMemoryEntry, 0, len(results))
+		for _, r := range results {
+			if t.owns(r.MemoryEntry) {
+				owned = append(owned, r)
+			}
+		}
+		if len(owned) >= want || len(results) < inner.Limit || inner.Limit >= maxTenantQueryWindow {
+			return domain.PageMemoryResults(owned, q.Offset, limit), nil
+		}
+	}
+}
+
+// maxTenantQueryWindow caps how many results QueryStructured requests from
+// the inner provider while filling a page.
+const maxTenantQueryWindow = 1000
+
+// owns reports whether e belongs to this tenant. Untagged entries are shared.
+func (t *TenantScopedMemory) owns(e domain.MemoryEntry) bool {
+	return e.Metadata["tenant_id"] == t.tenantID || e.Metadata["tenant_id"] == ""
+}
+
 func (t *TenantScopedMemory) Delete(ctx context.Context, id string) error {
 	return t.inner.Delete(t.scopedCtx(ctx), id)
 }
@@ -62,3 +100,6 @@ func (t *TenantScopedMemory) Sync(ctx context.Context) error {
 
 func (t *TenantScopedMemory) Name() string      { return t.inner.Name() }
 func (t *TenantScopedMemory) IsAvailable() bool  { return t.inner.IsAvailable() }
+
+// Compile-time interface check.
+var _ domain.StructuredQuerier = (*TenantScopedMemory)(nil)
@@ -2,6 +2,7 @@ package memory
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"alfred-ai/internal/domain"
@@ -83,3 +84,25 @@ func TestTenantScopedMemory_QueryFiltersByTenant(t *testing.T) {
 	assert.Contains(t, ids, "e4")
 	assert.NotContains(t, ids, "e2")
 }
+
+func TestTenantScopedMemory_QueryStructuredPages(t *testing.T) {
+	var entries []domain.MemoryEntry
+	for i := range 6 {
+		// Other tenants' entries come first so the first window underfills.
+		tenant := "tenant-b"
+		if i >= 3 {
+			tenant = "tenant-a"
+		}
+		entries = append(entries, domain.MemoryEntry{
+			ID:       fmt.Sprintf("e%d", i),
+			Metadata: map[string]string{"tenant_id": tenant},
+		})
+	}
+	scoped := NewTenantScopedMemory(&mockMemory{entries: entries}, "tenant-a")
+
+	results, err := scoped.QueryStructured(context.Background(), domain.MemoryQuery{Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "e4", results[0].ID)
+	assert.Equal(t, "e5", results[1].ID)
+}
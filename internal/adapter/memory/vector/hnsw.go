@@ -0,0 +1,491 @@
+package vector
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// HNSW tuning. M bounds the out-degree of a node on the upper layers (2*M
+// on layer 0); efConstruction and efSearch are the default candidate list
+// sizes used while inserting and querying.
+const (
+	hnswM              = 16
+	hnswEfConstruction = 200
+	hnswEfSearch       = 100
+
+	// Deleted nodes are kept as routing waypoints until they outnumber
+	// the live ones, then the graph is rebuilt without them.
+	hnswCompactMin = 64
+)
+
+// hnswGraph is a Hierarchical Navigable Small World graph (Malkov &
+// Yashunin) over unit-normalized embeddings, giving approximate nearest
+// neighbors by cosine similarity in roughly logarithmic time. It is not
+// safe for concurrent use; vecIndex serializes access.
+type hnswGraph struct {
+	dims     int
+	nodes    []hnswNode
+	ids      map[string]uint32 // live entry ID → node
+	entry    int32             // entry point node; -1 when empty
+	maxLevel int
+	deleted  int
+	rng      *rand.Rand
+}
+
+type hnswNode struct {
+	id      string
+	vec     []float32
+	friends [][]uint32 // neighbors per layer, 0..level
+	deleted bool
+}
+
+// hnswResult is a search hit with its cosine similarity to the query.
+type hnswResult struct {
+	id    string
+	score float32
+}
+
+type hnswCandidate struct {
+	node uint32
+	dist float32
+}
+
+func newHNSWGraph() *hnswGraph {
+	return &hnswGraph{
+		ids:   make(map[string]uint32),
+		entry: -1,
+		rng:   rand.New(rand.NewSource(1)),
+	}
+}
+
+// size returns the number of live entries.
+func (g *hnswGraph) size() int { return len(g.ids) }
+
+// insert adds id with the given embedding, replacing any previous vector
+// for the same id. Zero vectors cannot be compared by cosine similarity
+// and only remove the previous vector.
+func (g *hnswGraph) insert(id string, embedding []float32) error {
+	vec := normalize(embedding)
+	if vec == nil {
+		g.remove(id)
+		return nil
+	}
+	if g.dims != 0 && len(vec) != g.dims {
+		return fmt.Errorf("hnsw: embedding has %d dimensions, index has %d", len(vec), g.dims)
+	}
+	g.dims = len(vec)
+	g.remove(id)
+
+	level := g.randomLevel()
+	idx := uint32(len(g.nodes))
+	g.nodes = append(g.nodes, hnswNode{id: id, vec: vec, friends: make([][]uint32, level+1)})
+	g.ids[id] = idx
+
+	if g.entry < 0 {
+		g.entry = int32(idx)
+		g.maxLevel = level
+		return nil
+	}
+
+	ep := hnswCandidate{node: uint32(g.entry), dist: g.distance(vec, uint32(g.entry))}
+	for l := g.maxLevel; l > level; l-- {
+		ep = g.greedy(vec, ep, l)
+	}
+	for l := min(level, g.maxLevel); l >= 0; l-- {
+		found := g.searchLayer(vec, ep, hnswEfConstruction, l)
+		neighbors := g.selectNeighbors(found, hnswM)
+		links := make([]uint32, len(neighbors))
+		for i, n := range neighbors {
+			links[i] = n.node
+			g.link(n.node, idx, l)
+		}
+		g.nodes[idx].friends[l] = links
+		ep = found[0]
+	}
+
+	if level > g.maxLevel {
+		g.maxLevel = level
+		g.entry = int32(idx)
+	}
+	return nil
+}
+
+// remove drops id from search results. The node stays in the graph as a
+// routing waypoint until compaction.
+func (g *hnswGraph) remove(id string) {
+	idx, ok := g.ids[id]
+	if !ok {
+		return
+	}
+	delete(g.ids, id)
+	g.nodes[idx].deleted = true
+	g.deleted++
+
+	if g.deleted >= hnswCompactMin && g.deleted > len(g.ids) {
+		g.compact()
+	}
+}
+
+// compact rebuilds the graph from its live nodes.
+func (g *hnswGraph) compact() {
+	fresh := newHNSWGraph()
+	fresh.rng = g.rng
+	for _, n := range g.nodes {
+		if !n.deleted {
+			fresh.insert(n.id, n.vec) //nolint:errcheck // same dimensions
+		}
+	}
+	*g = *fresh
+}
+
+// search returns up to k live entries most similar to query, best first,
+// exploring ef candidates. Entries with non-positive similarity are dropped.
+func (g *hnswGraph) search(query []float32, k, ef int) []hnswResult {
+	vec := normalize(query)
+	if vec == nil || g.entry < 0 || len(vec) != g.dims || k <= 0 {
+		return nil
+	}
+
+	ep := hnswCandidate{node: uint32(g.entry), dist: g.distance(vec, uint32(g.entry))}
+	for l := g.maxLevel; l > 0; l-- {
+		ep = g.greedy(vec, ep, l)
+	}
+
+	// Widen the beam to make up for deleted nodes it will have to skip.
+	ef = max(ef, k)
+	ef += min(g.deleted, ef)
+
+	found := g.searchLayer(vec, ep, ef, 0)
+	results := make([]hnswResult, 0, k)
+	for _, c := range found {
+		if len(results) == k {
+			break
+		}
+		n := &g.nodes[c.node]
+		if n.deleted || 1-c.dist <= 0 {
+			continue
+		}
+		results = append(results, hnswResult{id: n.id, score: 1 - c.dist})
+	}
+	return results
+}
+
+// greedy walks layer l towards q, returning the closest node it reaches.
+func (g *hnswGraph) greedy(q []float32, ep hnswCandidate, l int) hnswCandidate {
+	for changed := true; changed; {
+		changed = false
+		for _, n := range g.nodes[ep.node].friends[l] {
+			if d := g.distance(q, n); d < ep.dist {
+				ep = hnswCandidate{node: n, dist: d}
+				changed = true
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayer is a beam search of width ef on layer l starting at ep. It
+// returns the closest nodes found, nearest first.
+func (g *hnswGraph) searchLayer(q []float32, ep hnswCandidate, ef, l int) []hnswCandidate {
+	visited := make([]uint64, (len(g.nodes)+63)/64)
+	visited[ep.node/64] |= 1 << (ep.node % 64)
+
+	candidates := &candidateHeap{list: []hnswCandidate{ep}}
+	best := &candidateHeap{list: []hnswCandidate{ep}, far: true}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(hnswCandidate)
+		if best.Len() >= ef && c.dist > best.list[0].dist {
+			break
+		}
+		for _, n := range g.nodes[c.node].friends[l] {
+			if visited[n/64]&(1<<(n%64)) != 0 {
+				continue
+			}
+			visited[n/64] |= 1 << (n % 64)
+
+			d := g.distance(q, n)
+			if best.Len() < ef || d < best.list[0].dist {
+				heap.Push(candidates, hnswCandidate{node: n, dist: d})
+				heap.Push(best, hnswCandidate{node: n, dist: d})
+				if best.Len() > ef {
+					heap.Pop(best)
+				}
+			}
+		}
+	}
+
+	found := best.list
+	sort.Slice(found, func(i, j int) bool { return found[i].dist < found[j].dist })
+	return found
+}
+
+// selectNeighbors picks up to m of the sorted candidates, preferring ones
+// closer to the new node than to any neighbor already picked so links
+// spread in different directions; the rest fill any remaining slots.
+func (g *hnswGraph) selectNeighbors(candidates []hnswCandidate, m int) []hnswCandidate {
+	if len(candidates) <= m {
+		return candidates
+	}
+	selected := make([]hnswCandidate, 0, m)
+	var pruned []hnswCandidate
+	for _, c := range candidates {
+		if len(selected) == m {
+			break
+		}
+		diverse := true
+		for _, s := range selected {
+			if g.distance(g.nodes[c.node].vec, s.node) < c.dist {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			selected = append(selected, c)
+		} else {
+			pruned = append(pruned, c)
+		}
+	}
+	for _, c := range pruned {
+		if len(selected) == m {
+			break
+		}
+		selected = append(selected, c)
+	}
+	return selected
+}
+
+// link adds a back-link from node to target on layer l, shrinking the
+// neighbor list if it grows past the layer's bound.
+func (g *hnswGraph) link(node, target uint32, l int) {
+	friends := append(g.nodes[node].friends[l], target)
+	limit := hnswM
+	if l == 0 {
+		limit = 2 * hnswM
+	}
+	if len(friends) > limit {
+		candidates := make([]hnswCandidate, len(friends))
+		for i, f := range friends {
+			candidates[i] = hnswCandidate{node: f, dist: g.distance(g.nodes[node].vec, f)}
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].dist < candidates[j].dist })
+		selected := g.selectNeighbors(candidates, limit)
+		friends = make([]uint32, len(selected))
+		for i, s := range selected {
+			friends[i] = s.node
+		}
+	}
+	g.nodes[node].friends[l] = friends
+}
+
+// distance is the cosine distance between q and a node; both are unit length.
+func (g *hnswGraph) distance(q []float32, node uint32) float32 {
+	v := g.nodes[node].vec
+	var dot float32
+	for i := range q {
+		dot += q[i] * v[i]
+	}
+	return 1 - dot
+}
+
+func (g *hnswGraph) randomLevel() int {
+	mult := 1 / math.Log(hnswM)
+	return int(-math.Log(1-g.rng.Float64()) * mult)
+}
+
+// normalize returns v scaled to unit length, or nil for zero or non-finite vectors.
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, f := range v {
+		norm += float64(f) * float64(f)
+	}
+	norm = math.Sqrt(norm)
+	if norm == 0 || math.IsNaN(norm) || math.IsInf(norm, 0) {
+		return nil
+	}
+	out := make([]float32, len(v))
+	for i, f := range v {
+		out[i] = float32(float64(f) / norm)
+	}
+	return out
+}
+
+// candidateHeap is a min-heap on distance, or a max-heap when far is set.
+type candidateHeap struct {
+	list []hnswCandidate
+	far  bool
+}
+
+func (h *candidateHeap) Len() int      { return len(h.list) }
+func (h *candidateHeap) Swap(i, j int) { h.list[i], h.list[j] = h.list[j], h.list[i] }
+func (h *candidateHeap) Push(x any)    { h.list = append(h.list, x.(hnswCandidate)) }
+
+func (h *candidateHeap) Less(i, j int) bool {
+	if h.far {
+		return h.list[i].dist > h.list[j].dist
+	}
+	return h.list[i].dist < h.list[j].dist
+}
+
+func (h *candidateHeap) Pop() any {
+	last := h.list[len(h.list)-1]
+	h.list = h.list[:len(h.list)-1]
+	return last
+}
+
+// --- persistence ---
+//
+// File layout, little-endian, followed by a CRC-32 of everything before it:
+//
+//	magic[8] version u32 dims u32 entry i32 maxLevel u32 fingerprint u64 nodes u32
+//	per node: idLen u16 id deleted u8 level u8 vec[dims]f32
+//	          per layer: count u16 neighbors[count]u32
+
+const hnswFileVersion = 1
+
+var hnswMagic = [8]byte{'A', 'L', 'F', 'H', 'N', 'S', 'W', 0}
+
+// maxHNSWDims bounds allocations when reading an untrusted header.
+const maxHNSWDims = 1 << 16
+
+var errHNSWCorrupt = errors.New("hnsw: corrupt index file")
+
+// writeTo serializes the graph together with a fingerprint of the data it
+// was built from.
+func (g *hnswGraph) writeTo(w io.Writer, fingerprint uint64) error {
+	crc := crc32.NewIEEE()
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+	put := func(v any) { binary.Write(bw, binary.LittleEndian, v) } //nolint:errcheck // bufio error surfaces on Flush
+
+	bw.Write(hnswMagic[:]) //nolint:errcheck
+	put(uint32(hnswFileVersion))
+	put(uint32(g.dims))
+	put(g.entry)
+	put(uint32(g.maxLevel))
+	put(fingerprint)
+	put(uint32(len(g.nodes)))
+	for _, n := range g.nodes {
+		put(uint16(len(n.id)))
+		bw.WriteString(n.id) //nolint:errcheck
+		var deleted uint8
+		if n.deleted {
+			deleted = 1
+		}
+		put(deleted)
+		put(uint8(len(n.friends) - 1))
+		put(n.vec)
+		for _, layer := range n.friends {
+			put(uint16(len(layer)))
+			put(layer)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, crc.Sum32())
+}
+
+// readHNSW decodes a graph written by writeTo and returns it with its
+// fingerprint. Any truncation, checksum mismatch or out-of-range reference
+// yields errHNSWCorrupt.
+func readHNSW(r io.Reader) (*hnswGraph, uint64, error) {
+	crc := crc32.NewIEEE()
+	br := io.TeeReader(bufio.NewReader(r), crc)
+	var err error
+	get := func(v any) {
+		if err == nil {
+			err = binary.Read(br, binary.LittleEndian, v)
+		}
+	}
+
+	var magic [8]byte
+	var version, dims, maxLevel, count uint32
+	var entry int32
+	var fingerprint uint64
+	get(&magic)
+	get(&version)
+	if err != nil || magic != hnswMagic || version != hnswFileVersion {
+		return nil, 0, errHNSWCorrupt
+	}
+	get(&dims)
+	get(&entry)
+	get(&maxLevel)
+	get(&fingerprint)
+	get(&count)
+	if err != nil || dims > maxHNSWDims || maxLevel > 64 || entry < -1 || int64(entry) >= int64(count) {
+		return nil, 0, errHNSWCorrupt
+	}
+
+	g := newHNSWGraph()
+	g.dims = int(dims)
+	g.entry = entry
+	g.maxLevel = int(maxLevel)
+	g.nodes = make([]hnswNode, 0, min(count, 1<<20))
+	for i := uint32(0); i < count && err == nil; i++ {
+		var idLen uint16
+		var deleted, level uint8
+		get(&idLen)
+		id := make([]byte, idLen)
+		get(id)
+		get(&deleted)
+		get(&level)
+		if err != nil || uint32(level) > maxLevel {
+			return nil, 0, errHNSWCorrupt
+		}
+		n := hnswNode{
+			id:      string(id),
+			vec:     make([]float32, dims),
+			friends: make([][]uint32, level+1),
+			deleted: deleted == 1,
+		}
+		get(n.vec)
+		for l := range n.friends {
+			var nc uint16
+			get(&nc)
+			n.friends[l] = make([]uint32, nc)
+			get(n.friends[l])
+		}
+		g.nodes = append(g.nodes, n)
+	}
+	if err != nil {
+		return nil, 0, errHNSWCorrupt
+	}
+
+	sum := crc.Sum32()
+	var stored uint32
+	if binary.Read(br, binary.LittleEndian, &stored) != nil || stored != sum {
+		return nil, 0, errHNSWCorrupt
+	}
+
+	// Every link must point at a node that exists on that layer, and the
+	// entry point must sit on the top layer, or searches would panic.
+	if entry >= 0 && len(g.nodes[entry].friends)-1 != g.maxLevel {
+		return nil, 0, errHNSWCorrupt
+	}
+	for i, n := range g.nodes {
+		for l, layer := range n.friends {
+			for _, f := range layer {
+				if f >= count || len(g.nodes[f].friends) <= l {
+					return nil, 0, errHNSWCorrupt
+				}
+			}
+		}
+		if n.deleted {
+			g.deleted++
+			continue
+		}
+		if _, dup := g.ids[n.id]; dup {
+			return nil, 0, errHNSWCorrupt
+		}
+		g.ids[n.id] = uint32(i)
+	}
+	return g, fingerprint, nil
+}
@@ -0,0 +1,273 @@
+package vector
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"alfred-ai/internal/domain"
+)
+
+// randomVectors returns n deterministic random vectors.
+func randomVectors(n, dims int, seed int64) [][]float32 {
+	rng := rand.New(rand.NewSource(seed))
+	out := make([][]float32, n)
+	for i := range out {
+		v := make([]float32, dims)
+		for j := range v {
+			v[j] = float32(rng.NormFloat64())
+		}
+		out[i] = v
+	}
+	return out
+}
+
+// bruteForceTopK returns the indices of the k vectors most similar to q.
+func bruteForceTopK(vecs [][]float32, q []float32, k int) []int {
+	idx := make([]int, len(vecs))
+	scores := make([]float32, len(vecs))
+	for i, v := range vecs {
+		idx[i] = i
+		scores[i] = cosineSimilarity(q, v)
+	}
+	sort.Slice(idx, func(a, b int) bool { return scores[idx[a]] > scores[idx[b]] })
+	return idx[:k]
+}
+
+// recallAtK measures the fraction of the exact top-k the graph returns.
+func recallAtK(g *hnswGraph, vecs, queries [][]float32, k, ef int) float64 {
+	hits, total := 0, 0
+	for _, q := range queries {
+		want := make(map[string]bool, k)
+		for _, i := range bruteForceTopK(vecs, q, k) {
+			if cosineSimilarity(q, vecs[i]) > 0 {
+				want[fmt.Sprintf("v%d", i)] = true
+			}
+		}
+		for _, r := range g.search(q, k, ef) {
+			if want[r.id] {
+				hits++
+			}
+		}
+		total += len(want)
+	}
+	return float64(hits) / float64(total)
+}
+
+func buildGraph(t testing.TB, vecs [][]float32) *hnswGraph {
+	t.Helper()
+	g := newHNSWGraph()
+	for i, v := range vecs {
+		if err := g.insert(fmt.Sprintf("v%d", i), v); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	return g
+}
+
+func TestHNSWRecall(t *testing.T) {
+	vecs := randomVectors(3000, 32, 1)
+	g := buildGraph(t, vecs)
+
+	if recall := recallAtK(g, vecs, randomVectors(50, 32, 2), 10, hnswEfSearch); recall < 0.9 {
+		t.Errorf("recall@10 = %.3f, want >= 0.9", recall)
+	}
+}
+
+func TestHNSWUpdateAndRemove(t *testing.T) {
+	g := newHNSWGraph()
+	g.insert("a", []float32{1, 0, 0})
+	g.insert("b", []float32{0, 1, 0})
+
+	// Re-inserting moves the vector.
+	g.insert("a", []float32{0, 0, 1})
+	res := g.search([]float32{0, 0, 1}, 5, hnswEfSearch)
+	if len(res) != 1 || res[0].id != "a" {
+		t.Fatalf("search after update = %v, want [a]", res)
+	}
+	if g.size() != 2 {
+		t.Errorf("size = %d, want 2", g.size())
+	}
+
+	g.remove("a")
+	if res := g.search([]float32{0, 0, 1}, 5, hnswEfSearch); len(res) != 0 {
+		t.Errorf("search after remove = %v, want none", res)
+	}
+
+	if err := g.insert("c", []float32{1, 0}); err == nil {
+		t.Error("expected error for mismatched dimensions")
+	}
+}
+
+func TestHNSWCompaction(t *testing.T) {
+	vecs := randomVectors(200, 16, 3)
+	g := buildGraph(t, vecs)
+	for i := 0; i < 150; i++ {
+		g.remove(fmt.Sprintf("v%d", i))
+	}
+
+	if g.deleted >= hnswCompactMin {
+		t.Errorf("deleted = %d, expected compaction", g.deleted)
+	}
+	if g.size() != 50 {
+		t.Fatalf("size = %d, want 50", g.size())
+	}
+	for _, r := range g.search(vecs[199], 50, hnswEfSearch) {
+		var i int
+		fmt.Sscanf(r.id, "v%d", &i)
+		if i < 150 {
+			t.Fatalf("removed entry %q returned", r.id)
+		}
+	}
+}
+
+func TestHNSWPersistRoundTrip(t *testing.T) {
+	vecs := randomVectors(500, 16, 4)
+	g := buildGraph(t, vecs)
+	g.remove("v3")
+
+	var buf bytes.Buffer
+	if err := g.writeTo(&buf, 42); err != nil {
+		t.Fatalf("writeTo: %v", err)
+	}
+	data := buf.Bytes()
+
+	loaded, fp, err := readHNSW(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("readHNSW: %v", err)
+	}
+	if fp != 42 {
+		t.Errorf("fingerprint = %d, want 42", fp)
+	}
+	if loaded.size() != g.size() {
+		t.Errorf("size = %d, want %d", loaded.size(), g.size())
+	}
+	q := vecs[10]
+	got, want := loaded.search(q, 5, hnswEfSearch), g.search(q, 5, hnswEfSearch)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("loaded search = %v, want %v", got, want)
+	}
+
+	// Flipped bytes and truncation are detected.
+	corrupt := append([]byte(nil), data...)
+	corrupt[len(corrupt)/2] ^= 0xff
+	if _, _, err := readHNSW(bytes.NewReader(corrupt)); err == nil {
+		t.Error("expected error for corrupted file")
+	}
+	if _, _, err := readHNSW(bytes.NewReader(data[:len(data)-10])); err == nil {
+		t.Error("expected error for truncated file")
+	}
+}
+
+func TestVecIndexPersistedAcrossReopen(t *testing.T) {
+	emb := &mockEmbedder{dims: 3}
+	dbPath := filepath.Join(t.TempDir(), "persist.db")
+	ctx := context.Background()
+
+	s, err := New(dbPath, emb, slog.Default())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	emb.vecs = [][]float32{{1, 0, 0}}
+	s.Store(ctx, domain.MemoryEntry{ID: "p1", Content: "first"})
+	emb.vecs = [][]float32{{0, 1, 0}}
+	s.Store(ctx, domain.MemoryEntry{ID: "p2", Content: "second"})
+	s.Close()
+
+	info, err := os.Stat(dbPath + ".hnsw")
+	if err != nil {
+		t.Fatalf("index file not written: %v", err)
+	}
+
+	s, err = New(dbPath, emb, slog.Default())
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if !s.vecIdx.isLoaded() || s.vecIdx.size() != 2 {
+		t.Fatalf("index loaded=%v size=%d, want loaded with 2", s.vecIdx.isLoaded(), s.vecIdx.size())
+	}
+	if s.vecIdx.dirty {
+		t.Error("index read from disk should not be rebuilt")
+	}
+	emb.vecs = [][]float32{{0, 1, 0}}
+	results, _ := s.vectorSearch(ctx, "q", 1)
+	if len(results) != 1 || results[0].ID != "p2" || results[0].Content != "second" {
+		t.Errorf("results = %+v, want p2", results)
+	}
+	s.Close()
+
+	// Unchanged data leaves the file alone.
+	after, _ := os.Stat(dbPath + ".hnsw")
+	if !after.ModTime().Equal(info.ModTime()) {
+		t.Error("clean index should not be rewritten on close")
+	}
+}
+
+func TestVecIndexRebuildsCorruptOrStale(t *testing.T) {
+	emb := &mockEmbedder{dims: 3}
+	dbPath := filepath.Join(t.TempDir(), "rebuild.db")
+	ctx := context.Background()
+
+	s, err := New(dbPath, emb, slog.Default())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	emb.vecs = [][]float32{{1, 0, 0}}
+	s.Store(ctx, domain.MemoryEntry{ID: "r1", Content: "one"})
+	s.Close()
+
+	// Corrupt file.
+	if err := os.WriteFile(dbPath+".hnsw", []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err = New(dbPath, emb, slog.Default())
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if s.vecIdx.size() != 1 {
+		t.Errorf("size after corrupt rebuild = %d, want 1", s.vecIdx.size())
+	}
+
+	// Simulate a crash: the database gains a row the saved index never saw.
+	emb.vecs = [][]float32{{0, 1, 0}}
+	s.vecIdx.loaded = false // skip the incremental update
+	s.Store(ctx, domain.MemoryEntry{ID: "r2", Content: "two"})
+	s.db.Close()
+
+	s, err = New(dbPath, emb, slog.Default())
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	if s.vecIdx.size() != 2 {
+		t.Errorf("size after stale rebuild = %d, want 2", s.vecIdx.size())
+	}
+}
+
+func TestVecIndexStoreWithoutEmbeddingClearsVector(t *testing.T) {
+	emb := &mockEmbedder{dims: 3}
+	s, err := New(filepath.Join(t.TempDir(), "clear.db"), emb, slog.Default())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+
+	emb.vecs = [][]float32{{1, 0, 0}}
+	s.Store(ctx, domain.MemoryEntry{ID: "c1", Content: "text"})
+	if s.vecIdx.size() != 1 {
+		t.Fatalf("size = %d, want 1", s.vecIdx.size())
+	}
+
+	// Empty content is stored without an embedding.
+	s.Store(ctx, domain.MemoryEntry{ID: "c1", Content: ""})
+	if s.vecIdx.size() != 0 {
+		t.Errorf("size = %d, want 0 after the embedding was cleared", s.vecIdx.size())
+	}
+}
@@ -1,10 +1,17 @@
 package vector
 
-import "database/sql"
+import (
+	"database/sql"
+	"fmt"
+)
 
-// migrate creates the schema if it doesn't exist.
-func migrate(db *sql.DB) error {
-	const schema = `
+// migrations are the schema changes in order. migrations[i] brings the
+// database to version i+1, tracked in PRAGMA user_version. Append new
+// migrations; never edit applied ones.
+var migrations = []string{
+	// 1: entries with FTS5 full-text index. Databases created before
+	// versioning have these objects already, hence IF NOT EXISTS.
+	`
 		CREATE TABLE IF NOT EXISTS entries (
 			id         TEXT PRIMARY KEY,
 			content    TEXT NOT NULL,
@@ -32,7 +39,45 @@ func migrate(db *sql.DB) error {
 			INSERT INTO entries_fts(entries_fts, rowid, content, tags) VALUES ('delete', old.rowid, old.content, old.tags);
 			INSERT INTO entries_fts(rowid, content, tags) VALUES (new.rowid, new.content, new.tags);
 		END;
+	`,
+
+	// 2: record which embedder produced each vector. Existing vectors have
+	// no recorded model and are re-embedded.
 	`
-	_, err := db.Exec(schema)
-	return err
+		ALTER TABLE entries ADD COLUMN embedding_model TEXT;
+		ALTER TABLE entries ADD COLUMN embedding_dims INTEGER;
+		CREATE INDEX entries_embedding_model ON entries(embedding_model);
+	`,
+}
+
+// migrate applies the migrations the database has not seen yet, each in
+// its own transaction.
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than supported version %d", version, len(migrations))
+	}
+
+	for v := version; v < len(migrations); v++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[v]); err != nil {
+			tx.Rollback() //nolint:errcheck
+			return fmt.Errorf("migration %d: %w", v+1, err)
+		}
+		// PRAGMA arguments cannot be bound; v is an int.
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", v+1)); err != nil {
+			tx.Rollback() //nolint:errcheck
+			return fmt.Errorf("migration %d: %w", v+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", v+1, err)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,104 @@
+package vector
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+// Filtered vector searches scan matching rows exactly when at most
+// filteredScanMax entries pass the filter. Above that, the index is asked
+// for filteredOversample times the wanted results before filtering.
+const (
+	filteredScanMax    = 5000
+	filteredOversample = 8
+)
+
+// sqlFilter is a WHERE fragment over the entries table (aliased e) and its
+// arguments. An empty clause matches every row.
+type sqlFilter struct {
+	clause string // "" or " AND ..."
+	args   []any
+}
+
+// with returns the filter arguments followed by extra.
+func (f sqlFilter) with(extra ...any) []any {
+	return append(append([]any(nil), f.args...), extra...)
+}
+
+// buildFilter translates the tag, metadata and time filters of q to SQL.
+func buildFilter(q domain.MemoryQuery) sqlFilter {
+	var f sqlFilter
+	add := func(cond string, args ...any) {
+		f.clause += " AND " + cond
+		f.args = append(f.args, args...)
+	}
+
+	for _, tag := range q.Tags {
+		add("EXISTS (SELECT 1 FROM json_each(e.tags) WHERE value = ?)", tag)
+	}
+	for _, tag := range q.ExcludeTags {
+		add("NOT EXISTS (SELECT 1 FROM json_each(e.tags) WHERE value = ?)", tag)
+	}
+
+	meta := q.MetadataFilter()
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		add("EXISTS (SELECT 1 FROM json_each(e.metadata) WHERE key = ? AND value = ?)", k, meta[k])
+	}
+
+	bound := func(column, op string, t time.Time) {
+		if !t.IsZero() {
+			add("julianday(e."+column+") "+op+" julianday(?)", t.UTC().Format(time.RFC3339))
+		}
+	}
+	bound("created_at", ">=", q.CreatedAfter)
+	bound("created_at", "<=", q.CreatedBefore)
+	bound("updated_at", ">=", q.UpdatedAfter)
+	bound("updated_at", "<=", q.UpdatedBefore)
+	return f
+}
+
+// QueryStructured implements domain.StructuredQuerier. Filters are pushed
+// down into both the FTS and the vector query. Scores are fused RRF scores
+// in (0, 1], after temporal decay.
+func (s *Store) QueryStructured(ctx context.Context, q domain.MemoryQuery) ([]domain.ScoredMemoryEntry, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	want := min(max(q.Offset, 0), domain.MaxMemoryQueryOffset) + limit
+	f := buildFilter(q)
+
+	var scored []scoredEntry
+	if q.Text == "" {
+		entries, err := s.keywordSearchFiltered(ctx, "", f, want)
+		if err != nil {
+			return nil, err
+		}
+		scored = entriesToScored(entries)
+	} else {
+		var err error
+		if scored, err = s.rankedSearch(ctx, q.Text, f, want); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]domain.ScoredMemoryEntry, 0, len(scored))
+	for _, se := range scored {
+		if se.score < q.MinScore {
+			continue
+		}
+		results = append(results, domain.ScoredMemoryEntry{MemoryEntry: se.entry, Score: se.score})
+	}
+	return domain.PageMemoryResults(results, q.Offset, limit), nil
+}
+
+// Compile-time interface check.
+var _ domain.StructuredQuerier = (*Store)(nil)
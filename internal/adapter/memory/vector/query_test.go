@@ -0,0 +1,141 @@
+package vector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+func seedQueryStore(t *testing.T, s *Store) {
+	t.Helper()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []domain.MemoryEntry{
+		{ID: "a", Content: "deploy notes for the api", Tags: []string{"ops", "api"},
+			Metadata: map[string]string{"source": "chat", "project": "x"}, CreatedAt: base},
+		{ID: "b", Content: "deploy checklist", Tags: []string{"ops"},
+			Metadata: map[string]string{"source": "auto-curate"}, CreatedAt: base.Add(24 * time.Hour)},
+		{ID: "c", Content: "deploy rollback plan", Tags: []string{"ops", "archived"},
+			Metadata: map[string]string{"source": "chat", "project": "y"}, CreatedAt: base.Add(48 * time.Hour)},
+		{ID: "d", Content: "lunch order", Tags: []string{"personal"}, CreatedAt: base.Add(72 * time.Hour)},
+	}
+	for _, e := range entries {
+		if err := s.Store(context.Background(), e); err != nil {
+			t.Fatalf("Store %s: %v", e.ID, err)
+		}
+	}
+}
+
+func resultIDs(results []domain.ScoredMemoryEntry) map[string]bool {
+	ids := make(map[string]bool, len(results))
+	for _, r := range results {
+		ids[r.ID] = true
+	}
+	return ids
+}
+
+func TestQueryStructuredFilters(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		q    domain.MemoryQuery
+		want []string
+	}{
+		{"tags", domain.MemoryQuery{Text: "deploy", Tags: []string{"ops", "api"}}, []string{"a"}},
+		{"exclude tags", domain.MemoryQuery{Text: "deploy", ExcludeTags: []string{"archived"}}, []string{"a", "b"}},
+		{"metadata", domain.MemoryQuery{Text: "deploy", Metadata: map[string]string{"project": "y"}}, []string{"c"}},
+		{"source", domain.MemoryQuery{Text: "deploy", Source: "chat"}, []string{"a", "c"}},
+		{"created window", domain.MemoryQuery{Text: "deploy", CreatedAfter: base.Add(time.Hour), CreatedBefore: base.Add(25 * time.Hour)}, []string{"b"}},
+		{"no text", domain.MemoryQuery{Tags: []string{"personal"}}, []string{"d"}},
+	}
+
+	for _, withEmbedder := range []bool{false, true} {
+		var emb domain.EmbeddingProvider
+		if withEmbedder {
+			emb = &mockEmbedder{dims: 3}
+		}
+		s := newTestStore(t, emb)
+		seedQueryStore(t, s)
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				results, err := s.QueryStructured(context.Background(), tt.q)
+				if err != nil {
+					t.Fatalf("QueryStructured: %v", err)
+				}
+				got := resultIDs(results)
+				// Vector search also surfaces entries without the keyword,
+				// so with an embedder only the filter is checked exactly.
+				if !withEmbedder && len(got) != len(tt.want) {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+				for _, id := range tt.want {
+					if !got[id] {
+						t.Errorf("embedder=%v: missing %s in %v", withEmbedder, id, got)
+					}
+				}
+				for _, r := range results {
+					if !tt.q.Matches(r.MemoryEntry) {
+						t.Errorf("embedder=%v: %s does not match the filter", withEmbedder, r.ID)
+					}
+					if r.Score <= 0 || r.Score > 1 {
+						t.Errorf("score for %s = %v, want (0, 1]", r.ID, r.Score)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestQueryStructuredPaging(t *testing.T) {
+	s := newTestStore(t, &mockEmbedder{dims: 3})
+	seedQueryStore(t, s)
+	ctx := context.Background()
+
+	all, err := s.QueryStructured(ctx, domain.MemoryQuery{Text: "deploy", Tags: []string{"ops"}, Limit: 10})
+	if err != nil {
+		t.Fatalf("QueryStructured: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("len = %d, want 3", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Score > all[i-1].Score {
+			t.Errorf("results not sorted by score: %v", all)
+		}
+	}
+
+	page, err := s.QueryStructured(ctx, domain.MemoryQuery{Text: "deploy", Tags: []string{"ops"}, Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("QueryStructured: %v", err)
+	}
+	if len(page) != 1 || page[0].ID != all[1].ID {
+		t.Errorf("page = %v, want [%s]", page, all[1].ID)
+	}
+
+	// Offsets past the cap are not searched for and return an empty page.
+	deep, err := s.QueryStructured(ctx, domain.MemoryQuery{Text: "deploy", Offset: 1 << 40})
+	if err != nil || len(deep) != 0 {
+		t.Errorf("deep page = %v, %v; want empty", deep, err)
+	}
+
+	high, err := s.QueryStructured(ctx, domain.MemoryQuery{Text: "deploy", Tags: []string{"ops"}, MinScore: all[0].Score})
+	if err != nil {
+		t.Fatalf("QueryStructured: %v", err)
+	}
+	for _, r := range high {
+		if r.Score < all[0].Score {
+			t.Errorf("min_score kept %s with score %v", r.ID, r.Score)
+		}
+	}
+	if len(high) == 0 {
+		t.Error("min_score dropped the top result")
+	}
+}
+
+func TestBuildFilterEmpty(t *testing.T) {
+	if f := buildFilter(domain.MemoryQuery{Text: "x", Limit: 5}); f.clause != "" || len(f.args) != 0 {
+		t.Errorf("buildFilter = %+v, want empty", f)
+	}
+}
@@ -0,0 +1,255 @@
+package vector
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/time/rate"
+
+	"alfred-ai/internal/domain"
+)
+
+// Re-embed defaults.
+const (
+	defaultReembedBatchSize = 64
+	defaultReembedRate      = 2.0 // Embed calls per second
+)
+
+// ReembedOptions tunes a re-embed run. Zero values select defaults.
+type ReembedOptions struct {
+	BatchSize int                   // entries per Embed call; 0 = 64
+	Rate      float64               // max Embed calls per second; 0 = 2, negative = unlimited
+	Progress  func(ReembedProgress) // called after each batch; may be nil
+}
+
+// ReembedProgress reports how far a re-embed run has got.
+type ReembedProgress struct {
+	Model     string `json:"model"`
+	Total     int    `json:"total"`     // stale entries when the run started
+	Done      int    `json:"done"`      // entries re-embedded so far
+	Failed    int    `json:"failed"`    // entries whose batch failed to embed
+	Remaining int    `json:"remaining"` // entries still to visit in this run
+}
+
+// StaleEmbeddings counts entries whose vector was not produced by the
+// current embedder, including entries that have none. Those are left out
+// of vector search until re-embedded.
+func (s *Store) StaleEmbeddings(ctx context.Context) (int, error) {
+	if s.embedder == nil {
+		return 0, nil
+	}
+	var n int
+	err := s.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM entries WHERE content != '' AND embedding_model IS NOT ?", s.model,
+	).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("%w: count stale embeddings: %v", domain.ErrVectorStore, err)
+	}
+	return n, nil
+}
+
+// Reembed re-embeds every stale entry in batches with the current
+// embedder. Each entry is visited at most once per run; a batch that fails
+// to embed is skipped and counted in Failed. Progress is written row by
+// row, so a cancelled run resumes where it stopped when started again.
+func (s *Store) Reembed(ctx context.Context, opts ReembedOptions) (ReembedProgress, error) {
+	progress := ReembedProgress{Model: s.model}
+	if s.embedder == nil {
+		return progress, fmt.Errorf("%w: re-embed requires an embedding provider", domain.ErrVectorStore)
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultReembedBatchSize
+	}
+	limit := rate.Inf
+	switch {
+	case opts.Rate == 0:
+		limit = rate.Limit(defaultReembedRate)
+	case opts.Rate > 0:
+		limit = rate.Limit(opts.Rate)
+	}
+	limiter := rate.NewLimiter(limit, 1)
+
+	total, err := s.StaleEmbeddings(ctx)
+	if err != nil {
+		return progress, cmp.Or(ctx.Err(), err)
+	}
+	progress.Total, progress.Remaining = total, total
+
+	var lastRowID int64
+	for progress.Remaining > 0 {
+		batch, err := s.staleBatch(ctx, lastRowID, batchSize)
+		if err != nil {
+			return progress, cmp.Or(ctx.Err(), err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		lastRowID = batch[len(batch)-1].rowID
+
+		if err := limiter.Wait(ctx); err != nil {
+			return progress, err
+		}
+		texts := make([]string, len(batch))
+		for i, r := range batch {
+			texts[i] = r.content
+		}
+		vecs, err := s.embedder.Embed(ctx, texts)
+		if err == nil && len(vecs) != len(batch) {
+			err = fmt.Errorf("got %d embeddings for %d texts", len(vecs), len(batch))
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return progress, ctx.Err()
+			}
+			s.logger.Warn("vector store: re-embed batch failed", "entries", len(batch), "error", err)
+			progress.Failed += len(batch)
+		} else {
+			n, err := s.saveReembedded(ctx, batch, vecs)
+			if err != nil {
+				return progress, cmp.Or(ctx.Err(), err)
+			}
+			progress.Done += n
+		}
+
+		progress.Remaining = max(progress.Remaining-len(batch), 0)
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+	}
+	return progress, nil
+}
+
+// staleRow is an entry waiting to be re-embedded.
+type staleRow struct {
+	rowID     int64
+	id        string
+	content   string
+	updatedAt string
+}
+
+// staleBatch returns up to limit stale entries after rowid afterRowID.
+func (s *Store) staleBatch(ctx context.Context, afterRowID int64, limit int) ([]staleRow, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT rowid, id, content, updated_at FROM entries
+		 WHERE rowid > ? AND content != '' AND embedding_model IS NOT ?
+		 ORDER BY rowid LIMIT ?`,
+		afterRowID, s.model, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: select stale embeddings: %v", domain.ErrVectorStore, err)
+	}
+	defer rows.Close()
+
+	var batch []staleRow
+	for rows.Next() {
+		var r staleRow
+		if err := rows.Scan(&r.rowID, &r.id, &r.content, &r.updatedAt); err != nil {
+			return nil, fmt.Errorf("%w: scan stale embedding: %v", domain.ErrVectorStore, err)
+		}
+		batch = append(batch, r)
+	}
+	return batch, rows.Err()
+}
+
+// saveReembedded writes new vectors for batch and indexes them. Entries
+// rewritten since they were read are left alone: that write embedded them
+// already. Returns the number of entries updated.
+func (s *Store) saveReembedded(ctx context.Context, batch []staleRow, vecs [][]float32) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: begin tx: %v", domain.ErrVectorStore, err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	blobs := make([][]byte, len(batch))
+	updated := make([]bool, len(batch))
+	n := 0
+	for i, r := range batch {
+		blobs[i] = float32ToBytes(vecs[i])
+		model, dims := s.embeddingColumns(blobs[i])
+		res, err := tx.ExecContext(ctx,
+			`UPDATE entries SET embedding = ?, embedding_model = ?, embedding_dims = ?
+			 WHERE id = ? AND updated_at = ? AND content = ?`,
+			blobs[i], model, dims, r.id, r.updatedAt, r.content,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("%w: update embedding %q: %v", domain.ErrVectorStore, r.id, err)
+		}
+		if rows, _ := res.RowsAffected(); rows > 0 {
+			updated[i] = true
+			n++
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%w: commit: %v", domain.ErrVectorStore, err)
+	}
+
+	if s.vecIdx.isLoaded() {
+		for i, r := range batch {
+			if updated[i] {
+				s.indexEmbedding(r.id, blobs[i])
+			}
+		}
+	}
+	return n, nil
+}
+
+// StartReembed runs Reembed in the background if any entry is stale and no
+// job is running. The job stops on StopReembed or Close; it is restarted
+// from the remaining stale entries on the next StartReembed. done, if not
+// nil, is called with the final progress when the job ends.
+func (s *Store) StartReembed(opts ReembedOptions, done func(ReembedProgress, error)) (bool, error) {
+	stale, err := s.StaleEmbeddings(context.Background())
+	if err != nil || stale == 0 {
+		return false, err
+	}
+
+	s.reembedMu.Lock()
+	defer s.reembedMu.Unlock()
+	if s.reembedDone != nil {
+		select {
+		case <-s.reembedDone:
+		default:
+			return false, nil // already running
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+	s.reembedCancel, s.reembedDone = cancel, finished
+
+	s.logger.Info("vector store: re-embedding entries", "stale", stale, "model", s.model)
+	go func() {
+		defer close(finished)
+		defer cancel()
+		progress, err := s.Reembed(ctx, opts)
+		if errors.Is(err, context.Canceled) {
+			s.logger.Info("vector store: re-embed stopped", "done", progress.Done, "remaining", progress.Remaining)
+		} else if err != nil {
+			s.logger.Warn("vector store: re-embed failed", "error", err)
+		} else {
+			s.logger.Info("vector store: re-embed finished", "done", progress.Done, "failed", progress.Failed)
+		}
+		if done != nil {
+			done(progress, err)
+		}
+	}()
+	return true, nil
+}
+
+// StopReembed cancels the background re-embed job, if any, and waits for
+// it to exit.
+func (s *Store) StopReembed() {
+	s.reembedMu.Lock()
+	cancel, done := s.reembedCancel, s.reembedDone
+	s.reembedMu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
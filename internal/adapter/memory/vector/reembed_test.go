@@ -0,0 +1,267 @@
+package vector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+// modelEmbedder produces vectors that depend on the model name, so
+// vectors from two models never agree.
+type modelEmbedder struct {
+	model string
+	dims  int
+	fail  bool
+}
+
+func (m *modelEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	if m.fail {
+		return nil, errors.New("embedder down")
+	}
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		v := make([]float32, m.dims)
+		for j := range v {
+			v[j] = float32(len(t)+len(m.model)+j) / 100
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func (m *modelEmbedder) Dimensions() int { return m.dims }
+func (m *modelEmbedder) Name() string    { return "test" }
+func (m *modelEmbedder) Model() string   { return m.model }
+
+// openWithModel opens the store at path with a modelEmbedder.
+func openWithModel(t *testing.T, path string, emb *modelEmbedder) *Store {
+	t.Helper()
+	s, err := New(path, emb, slog.Default())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func seedModelStore(t *testing.T, path string, n int) {
+	t.Helper()
+	s := openWithModel(t, path, &modelEmbedder{model: "old", dims: 3})
+	for i := range n {
+		if err := s.Store(context.Background(), domain.MemoryEntry{Content: "note " + string(rune('a'+i))}); err != nil {
+			t.Fatalf("Store: %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestEmbeddingModelRecorded(t *testing.T) {
+	s := newTestStore(t, &modelEmbedder{model: "m1", dims: 4})
+	if err := s.Store(context.Background(), domain.MemoryEntry{ID: "e1", Content: "hello"}); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	var model string
+	var dims int
+	err := s.db.QueryRow("SELECT embedding_model, embedding_dims FROM entries WHERE id = 'e1'").Scan(&model, &dims)
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if model != "test/m1" || dims != 4 {
+		t.Errorf("recorded %q/%d, want test/m1/4", model, dims)
+	}
+}
+
+func TestReembedAfterModelChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reembed.db")
+	seedModelStore(t, path, 5)
+
+	s := openWithModel(t, path, &modelEmbedder{model: "new", dims: 5})
+	ctx := context.Background()
+
+	if n, _ := s.StaleEmbeddings(ctx); n != 5 {
+		t.Fatalf("stale = %d, want 5", n)
+	}
+	// Old vectors are not compared against new queries.
+	if got, err := s.vectorSearch(ctx, "note", 10); err != nil || len(got) != 0 {
+		t.Fatalf("vectorSearch before re-embed = %d results, err %v; want none", len(got), err)
+	}
+
+	var calls []ReembedProgress
+	progress, err := s.Reembed(ctx, ReembedOptions{
+		BatchSize: 2,
+		Rate:      -1,
+		Progress:  func(p ReembedProgress) { calls = append(calls, p) },
+	})
+	if err != nil {
+		t.Fatalf("Reembed: %v", err)
+	}
+	if progress.Done != 5 || progress.Remaining != 0 || progress.Total != 5 || progress.Model != "test/new" {
+		t.Errorf("progress = %+v", progress)
+	}
+	if len(calls) != 3 || calls[0].Done != 2 || calls[0].Remaining != 3 {
+		t.Errorf("progress calls = %+v", calls)
+	}
+
+	if n, _ := s.StaleEmbeddings(ctx); n != 0 {
+		t.Errorf("stale after re-embed = %d", n)
+	}
+	if got, err := s.vectorSearch(ctx, "note", 10); err != nil || len(got) != 5 {
+		t.Errorf("vectorSearch after re-embed = %d results, err %v; want 5", len(got), err)
+	}
+}
+
+func TestReembedResumes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resume.db")
+	seedModelStore(t, path, 5)
+	s := openWithModel(t, path, &modelEmbedder{model: "new", dims: 3})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := s.Reembed(ctx, ReembedOptions{
+		BatchSize: 2,
+		Rate:      -1,
+		Progress:  func(ReembedProgress) { cancel() },
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Reembed error = %v, want context.Canceled", err)
+	}
+	if n, _ := s.StaleEmbeddings(context.Background()); n != 3 {
+		t.Fatalf("stale after cancel = %d, want 3", n)
+	}
+
+	progress, err := s.Reembed(context.Background(), ReembedOptions{Rate: -1})
+	if err != nil {
+		t.Fatalf("Reembed: %v", err)
+	}
+	if progress.Total != 3 || progress.Done != 3 {
+		t.Errorf("resumed progress = %+v, want 3 of 3", progress)
+	}
+}
+
+func TestReembedEmbedFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fail.db")
+	seedModelStore(t, path, 3)
+	s := openWithModel(t, path, &modelEmbedder{model: "new", dims: 3, fail: true})
+
+	progress, err := s.Reembed(context.Background(), ReembedOptions{BatchSize: 2, Rate: -1})
+	if err != nil {
+		t.Fatalf("Reembed: %v", err)
+	}
+	if progress.Failed != 3 || progress.Done != 0 {
+		t.Errorf("progress = %+v, want 3 failed", progress)
+	}
+	if n, _ := s.StaleEmbeddings(context.Background()); n != 3 {
+		t.Errorf("stale = %d, want 3", n)
+	}
+}
+
+func TestReembedSkipsRewrittenEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "race.db")
+	seedModelStore(t, path, 1)
+	s := openWithModel(t, path, &modelEmbedder{model: "new", dims: 3})
+	ctx := context.Background()
+
+	batch, err := s.staleBatch(ctx, 0, 10)
+	if err != nil || len(batch) != 1 {
+		t.Fatalf("staleBatch = %v, %v", batch, err)
+	}
+	// The entry changes between reading and saving the batch.
+	if _, err := s.db.Exec("UPDATE entries SET content = 'rewritten'"); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	n, err := s.saveReembedded(ctx, batch, [][]float32{{1, 2, 3}})
+	if err != nil {
+		t.Fatalf("saveReembedded: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("updated %d entries, want 0", n)
+	}
+}
+
+func TestStartReembedStopsOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bg.db")
+	seedModelStore(t, path, 4)
+	s, err := New(path, &modelEmbedder{model: "new", dims: 3}, slog.Default())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	done := make(chan error, 1)
+	started, err := s.StartReembed(ReembedOptions{BatchSize: 1, Rate: 0.5}, func(_ ReembedProgress, err error) {
+		done <- err
+	})
+	if err != nil || !started {
+		t.Fatalf("StartReembed = %v, %v", started, err)
+	}
+	if again, _ := s.StartReembed(ReembedOptions{}, nil); again {
+		t.Error("second StartReembed should not start another job")
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not stop the re-embed job")
+	}
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("job error = %v, want context.Canceled", err)
+	}
+}
+
+func TestMigrateLegacySchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	// Unversioned schema as created before numbered migrations.
+	if _, err := db.Exec(migrations[0]); err != nil {
+		t.Fatalf("legacy schema: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO entries (id, content, embedding, created_at, updated_at)
+		VALUES ('old', 'legacy note', ?, '2025-01-01T00:00:00Z', '2025-01-01T00:00:00Z')`,
+		float32ToBytes([]float32{1, 0, 0}))
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	db.Close()
+
+	s := openWithModel(t, path, &modelEmbedder{model: "m", dims: 3})
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil || version != len(migrations) {
+		t.Fatalf("user_version = %d, %v; want %d", version, err, len(migrations))
+	}
+	if n, _ := s.StaleEmbeddings(context.Background()); n != 1 {
+		t.Errorf("legacy vectors stale = %d, want 1", n)
+	}
+	results, err := s.Query(context.Background(), "legacy", 10)
+	if err != nil || len(results) != 1 {
+		t.Errorf("keyword search over legacy entry = %v, %v", results, err)
+	}
+}
+
+func TestMigrateRejectsNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "future.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	db.Exec("PRAGMA user_version = 99")
+	db.Close()
+
+	if _, err := New(path, nil, slog.Default()); err == nil {
+		t.Fatal("expected error for a schema newer than supported")
+	}
+}
@@ -22,10 +22,23 @@ type scoredEntry struct {
 // hybridSearch combines keyword (FTS5) and vector (cosine) search using
 // Reciprocal Rank Fusion, then optionally applies temporal decay and MMR.
 func (s *Store) hybridSearch(ctx context.Context, query string, limit int) ([]domain.MemoryEntry, error) {
+	scored, err := s.rankedSearch(ctx, query, sqlFilter{}, limit)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]domain.MemoryEntry, len(scored))
+	for i, se := range scored {
+		result[i] = se.entry
+	}
+	return result, nil
+}
+
+// rankedSearch is hybridSearch restricted by f, returning scored entries.
+func (s *Store) rankedSearch(ctx context.Context, query string, f sqlFilter, limit int) ([]scoredEntry, error) {
 	fetchLimit := limit * 2
 
-	kwResults, kwErr := s.keywordSearch(ctx, query, fetchLimit)
-	vecResults, vecErr := s.vectorSearch(ctx, query, fetchLimit)
+	kwResults, kwErr := s.keywordSearchFiltered(ctx, query, f, fetchLimit)
+	vecResults, vecErr := s.vectorSearchFiltered(ctx, query, f, fetchLimit)
 
 	// If both fail, return the first error.
 	if kwErr != nil && vecErr != nil {
@@ -62,21 +75,23 @@ func (s *Store) hybridSearch(ctx context.Context, query string, limit int) ([]do
 	if len(scored) > limit {
 		scored = scored[:limit]
 	}
-
-	result := make([]domain.MemoryEntry, len(scored))
-	for i, se := range scored {
-		result[i] = se.entry
-	}
-	return result, nil
+	return scored, nil
 }
 
 // keywordSearch performs FTS5 full-text search. If the query contains FTS5
 // syntax errors, it falls back to a LIKE-based search.
 func (s *Store) keywordSearch(ctx context.Context, query string, limit int) ([]domain.MemoryEntry, error) {
+	return s.keywordSearchFiltered(ctx, query, sqlFilter{}, limit)
+}
+
+// keywordSearchFiltered is keywordSearch restricted by f. An empty query
+// returns the most recently updated matching entries.
+func (s *Store) keywordSearchFiltered(ctx context.Context, query string, f sqlFilter, limit int) ([]domain.MemoryEntry, error) {
 	if query == "" {
 		rows, err := s.db.QueryContext(ctx,
-			"SELECT id, content, tags, metadata, created_at, updated_at FROM entries ORDER BY updated_at DESC LIMIT ?",
-			limit,
+			"SELECT e.id, e.content, e.tags, e.metadata, e.created_at, e.updated_at FROM entries e WHERE 1=1"+
+				f.clause+" ORDER BY e.updated_at DESC LIMIT ?",
+			f.with(limit)...,
 		)
 		if err != nil {
 			return nil, err
@@ -89,24 +104,25 @@ func (s *Store) keywordSearch(ctx context.Context, query string, limit int) ([]d
 		`SELECT e.id, e.content, e.tags, e.metadata, e.created_at, e.updated_at
 		 FROM entries_fts f
 		 JOIN entries e ON e.rowid = f.rowid
-		 WHERE entries_fts MATCH ?
+		 WHERE entries_fts MATCH ?`+f.clause+`
 		 ORDER BY bm25(entries_fts)
 		 LIMIT ?`,
-		query, limit,
+		append([]any{query}, f.with(limit)...)...,
 	)
 	if err != nil {
 		// FTS5 syntax error — fall back to LIKE search.
-		return s.likeSearch(ctx, query, limit)
+		return s.likeSearch(ctx, query, f, limit)
 	}
 	defer rows.Close()
 	return scanRows(rows)
 }
 
 // likeSearch is a fallback when FTS5 MATCH fails due to special characters.
-func (s *Store) likeSearch(ctx context.Context, query string, limit int) ([]domain.MemoryEntry, error) {
+func (s *Store) likeSearch(ctx context.Context, query string, f sqlFilter, limit int) ([]domain.MemoryEntry, error) {
 	rows, err := s.db.QueryContext(ctx,
-		"SELECT id, content, tags, metadata, created_at, updated_at FROM entries WHERE content LIKE ? ORDER BY updated_at DESC LIMIT ?",
-		"%"+query+"%", limit,
+		"SELECT e.id, e.content, e.tags, e.metadata, e.created_at, e.updated_at FROM entries e WHERE e.content LIKE ?"+
+			f.clause+" ORDER BY e.updated_at DESC LIMIT ?",
+		append([]any{"%" + query + "%"}, f.with(limit)...)...,
 	)
 	if err != nil {
 		return nil, err
@@ -116,9 +132,16 @@ func (s *Store) likeSearch(ctx context.Context, query string, limit int) ([]doma
 }
 
 // vectorSearch embeds the query and finds the most similar entries by cosine similarity.
-// It uses an in-memory vector index when available (avoiding SQLite I/O), and falls
-// back to a database scan on first call to populate the index.
+// It uses the HNSW vector index when available (avoiding a full scan), and falls
+// back to a database scan if the index cannot be loaded.
 func (s *Store) vectorSearch(ctx context.Context, query string, limit int) ([]domain.MemoryEntry, error) {
+	return s.vectorSearchFiltered(ctx, query, sqlFilter{}, limit)
+}
+
+// vectorSearchFiltered is vectorSearch restricted by f. When few entries
+// pass the filter they are scanned exactly; otherwise the index is asked
+// for extra candidates that are then filtered in SQL.
+func (s *Store) vectorSearchFiltered(ctx context.Context, query string, f sqlFilter, limit int) ([]domain.MemoryEntry, error) {
 	if s.embedder == nil {
 		return nil, nil
 	}
@@ -132,34 +155,109 @@ func (s *Store) vectorSearch(ctx context.Context, query string, limit int) ([]do
 	}
 	queryVec := vecs[0]
 
-	// Try in-memory index first. If not loaded yet, load it from the DB.
+	k := limit
+	if f.clause != "" {
+		var matching int
+		err := s.db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM entries e WHERE e.embedding_model = ?"+f.clause,
+			append([]any{s.model}, f.args...)...,
+		).Scan(&matching)
+		if err != nil {
+			return nil, err
+		}
+		if matching <= filteredScanMax {
+			return s.vectorSearchDBFiltered(ctx, queryVec, f, limit, matching)
+		}
+		k = limit * filteredOversample
+	}
+
+	// Try the index first. If not loaded yet, load it.
 	if !s.vecIdx.isLoaded() {
-		if err := s.vecIdx.loadFromDB(ctx, s); err != nil {
+		if err := s.vecIdx.load(ctx, s); err != nil {
 			s.logger.Warn("vector store: failed to load vec index, falling back to DB scan", "error", err)
-			return s.vectorSearchDB(ctx, queryVec, limit)
+			return s.vectorSearchDBFiltered(ctx, queryVec, f, limit, s.maxVectorCandidates())
 		}
 	}
 
-	results := s.vecIdx.search(queryVec, limit)
+	results := s.vecIdx.search(queryVec, k)
 	if results != nil {
-		return results, nil
+		ids := make([]string, len(results))
+		for i, r := range results {
+			ids[i] = r.id
+		}
+		entries, err := s.fetchEntries(ctx, ids, f)
+		if err != nil {
+			return nil, err
+		}
+		return truncate(entries, limit), nil
 	}
 
 	// Fallback to DB scan (shouldn't happen after successful load, but defensive).
-	return s.vectorSearchDB(ctx, queryVec, limit)
+	return s.vectorSearchDBFiltered(ctx, queryVec, f, limit, s.maxVectorCandidates())
+}
+
+// fetchEntries loads the entries with the given IDs that pass f, in the
+// order given. IDs no longer in the database are skipped.
+func (s *Store) fetchEntries(ctx context.Context, ids []string, f sqlFilter) ([]domain.MemoryEntry, error) {
+	if len(ids) == 0 {
+		return []domain.MemoryEntry{}, nil
+	}
+
+	args := make([]any, len(ids), len(ids)+len(f.args))
+	placeholders := make([]string, len(ids))
+	for i, id := range ids {
+		args[i] = id
+		placeholders[i] = "?"
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT e.id, e.content, e.tags, e.metadata, e.created_at, e.updated_at FROM entries e WHERE e.id IN ("+
+			strings.Join(placeholders, ",")+")"+f.clause,
+		append(args, f.args...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found, err := scanRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]domain.MemoryEntry, len(found))
+	for _, e := range found {
+		byID[e.ID] = e
+	}
+
+	result := make([]domain.MemoryEntry, 0, len(ids))
+	for _, id := range ids {
+		if e, ok := byID[id]; ok {
+			result = append(result, e)
+		}
+	}
+	return result, nil
 }
 
 // vectorSearchDB is the original database-scan based vector search, used as a
 // fallback when the in-memory index is unavailable.
 func (s *Store) vectorSearchDB(ctx context.Context, queryVec []float32, limit int) ([]domain.MemoryEntry, error) {
-	maxCandidates := s.opts.MaxVectorCandidates
-	if maxCandidates <= 0 {
-		maxCandidates = defaultMaxVectorCandidates
+	return s.vectorSearchDBFiltered(ctx, queryVec, sqlFilter{}, limit, s.maxVectorCandidates())
+}
+
+func (s *Store) maxVectorCandidates() int {
+	if s.opts.MaxVectorCandidates > 0 {
+		return s.opts.MaxVectorCandidates
 	}
+	return defaultMaxVectorCandidates
+}
 
+// vectorSearchDBFiltered scans up to maxCandidates of the most recently
+// updated entries passing f and ranks them by cosine similarity.
+func (s *Store) vectorSearchDBFiltered(ctx context.Context, queryVec []float32, f sqlFilter, limit, maxCandidates int) ([]domain.MemoryEntry, error) {
 	rows, err := s.db.QueryContext(ctx,
-		"SELECT id, content, tags, metadata, embedding, created_at, updated_at FROM entries WHERE embedding IS NOT NULL ORDER BY updated_at DESC LIMIT ?",
-		maxCandidates,
+		"SELECT e.id, e.content, e.tags, e.metadata, e.embedding, e.created_at, e.updated_at FROM entries e WHERE e.embedding_model = ?"+
+			f.clause+" ORDER BY e.updated_at DESC LIMIT ?",
+		append([]any{s.model}, f.with(maxCandidates)...)...,
 	)
 	if err != nil {
 		return nil, err
@@ -241,9 +339,11 @@ func entriesToScored(entries []domain.MemoryEntry) []scoredEntry {
 	return scored
 }
 
-// reciprocalRankFusion merges two ranked lists using RRF (k=60).
+// reciprocalRankFusion merges two ranked lists using RRF (k=60). Scores
+// are scaled so an entry ranked first in both lists scores 1.
 func reciprocalRankFusion(list1, list2 []domain.MemoryEntry) []scoredEntry {
 	const k = 60
+	const best = 2.0 / (k + 1)
 
 	scores := make(map[string]float64)
 	entries := make(map[string]domain.MemoryEntry)
@@ -259,7 +359,7 @@ func reciprocalRankFusion(list1, list2 []domain.MemoryEntry) []scoredEntry {
 
 	result := make([]scoredEntry, 0, len(scores))
 	for id, s := range scores {
-		result = append(result, scoredEntry{entry: entries[id], score: s})
+		result = append(result, scoredEntry{entry: entries[id], score: s / best})
 	}
 	sort.Slice(result, func(i, j int) bool {
 		return result[i].score > result[j].score
@@ -374,9 +474,9 @@ func (s *Store) fetchEmbeddings(ctx context.Context, candidates []scoredEntry) m
 
 	query := "SELECT id, embedding FROM entries WHERE id IN (" +
 		strings.Join(placeholders, ",") +
-		") AND embedding IS NOT NULL"
+		") AND embedding_model = ?"
 
-	rows, err := s.db.QueryContext(ctx, query, ids...)
+	rows, err := s.db.QueryContext(ctx, query, append(ids, s.model)...)
 	if err != nil {
 		return nil
 	}
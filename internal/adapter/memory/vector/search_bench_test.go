@@ -309,3 +309,32 @@ func BenchmarkCosineSimilarity_Dims(b *testing.B) {
 		})
 	}
 }
+
+// --- HNSW vs Brute Force ---
+
+// BenchmarkHNSWRecall reports recall@10 of the HNSW index against an exact
+// brute-force scan at several search beam widths, alongside per-query
+// latency of each.
+func BenchmarkHNSWRecall(b *testing.B) {
+	const dims, k = 128, 10
+	for _, n := range []int{1000, 10000} {
+		vecs := randomVectors(n, dims, 1)
+		queries := randomVectors(100, dims, 2)
+		g := buildGraph(b, vecs)
+
+		for _, ef := range []int{50, 100, 200} {
+			b.Run(fmt.Sprintf("hnsw_%d/ef_%d", n, ef), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					g.search(queries[i%len(queries)], k, ef)
+				}
+				b.StopTimer()
+				b.ReportMetric(recallAtK(g, vecs, queries, k, ef), "recall@10")
+			})
+		}
+		b.Run(fmt.Sprintf("bruteforce_%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bruteForceTopK(vecs, queries[i%len(queries)], k)
+			}
+		})
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"strings"
+	"sync"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -20,7 +22,8 @@ import (
 type SearchOpts struct {
 	DecayHalfLife      time.Duration // exponential recency decay; 0 = disabled
 	MMRDiversity       float64       // 0-1; 0 = disabled
-	MaxVectorCandidates int          // max entries to load for vector search; 0 = default (10000)
+	MaxVectorCandidates int          // max entries scanned when the vector index is unavailable; 0 = default (10000)
+	HNSWEfSearch        int          // HNSW search beam width; higher = better recall, slower; 0 = default (100)
 }
 
 const defaultMaxVectorCandidates = 10000
@@ -29,9 +32,9 @@ const defaultMaxVectorCandidates = 10000
 // vector embeddings. When an EmbeddingProvider is supplied, Store generates
 // embeddings on write and supports hybrid (BM25 + cosine) search.
 //
-// An in-memory vecIndex caches embeddings to avoid SQLite I/O on every vector
-// search. The index is lazily loaded on the first search and incrementally
-// updated on Store/Delete operations.
+// An HNSW vecIndex answers vector searches without SQLite I/O. It is persisted
+// to dbPath+".hnsw", loaded (or rebuilt if missing, corrupt or stale) when the
+// Store opens, and incrementally updated on Store/Delete operations.
 type Store struct {
 	db       *sql.DB
 	embedder domain.EmbeddingProvider
@@ -39,6 +42,11 @@ type Store struct {
 	dbPath   string
 	opts     SearchOpts
 	vecIdx   *vecIndex
+	model    string // domain.EmbeddingModelID of embedder; "" without one
+
+	reembedMu     sync.Mutex
+	reembedCancel context.CancelFunc // stops the background re-embed job
+	reembedDone   chan struct{}      // closed when the job exits
 }
 
 // New opens (or creates) a SQLite database at dbPath, runs migrations, and
@@ -75,18 +83,42 @@ func New(dbPath string, embedder domain.EmbeddingProvider, logger *slog.Logger,
 		so = opts[0]
 	}
 
-	return &Store{
+	s := &Store{
 		db:       db,
 		embedder: embedder,
 		logger:   logger,
 		dbPath:   dbPath,
 		opts:     so,
-		vecIdx:   newVecIndex(),
-	}, nil
+		vecIdx:   newVecIndex(indexPath(dbPath), so.HNSWEfSearch),
+	}
+	if embedder != nil {
+		s.model = domain.EmbeddingModelID(embedder)
+	}
+
+	if embedder != nil {
+		if err := s.vecIdx.load(context.Background(), s); err != nil {
+			logger.Warn("vector store: failed to load vec index, will retry on first search", "error", err)
+		}
+	}
+	return s, nil
+}
+
+// indexPath returns where the HNSW index for dbPath is persisted, or "" for
+// in-memory databases.
+func indexPath(dbPath string) string {
+	if dbPath == "" || strings.HasPrefix(dbPath, ":memory:") || strings.HasPrefix(dbPath, "file:") {
+		return ""
+	}
+	return dbPath + ".hnsw"
 }
 
-// Close closes the underlying database connection.
+// Close stops any background re-embed job, persists the vector index and
+// closes the underlying database connection.
 func (s *Store) Close() error {
+	s.StopReembed()
+	if err := s.vecIdx.persist(context.Background(), s); err != nil {
+		s.logger.Warn("vector store: failed to persist vec index", "error", err)
+	}
 	return s.db.Close()
 }
 
@@ -125,24 +157,16 @@ func (s *Store) Store(ctx context.Context, entry domain.MemoryEntry) error {
 			embeddingBlob = float32ToBytes(vecs[0])
 		}
 	}
+	model, dims := s.embeddingColumns(embeddingBlob)
 
-	const upsert = `
-		INSERT INTO entries (id, content, tags, metadata, embedding, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(id) DO UPDATE SET
-			content    = excluded.content,
-			tags       = excluded.tags,
-			metadata   = excluded.metadata,
-			embedding  = excluded.embedding,
-			updated_at = excluded.updated_at
-	`
-
-	_, err = s.db.ExecContext(ctx, upsert,
+	_, err = s.db.ExecContext(ctx, upsertEntry,
 		entry.ID,
 		entry.Content,
 		string(tags),
 		string(meta),
 		embeddingBlob,
+		model,
+		dims,
 		entry.CreatedAt.Format(time.RFC3339),
 		entry.UpdatedAt.Format(time.RFC3339),
 	)
@@ -150,10 +174,14 @@ func (s *Store) Store(ctx context.Context, entry domain.MemoryEntry) error {
 		return fmt.Errorf("%w: upsert: %v", domain.ErrVectorStore, err)
 	}
 
-	// Update in-memory vector index if loaded and embedding was generated.
-	if embeddingBlob != nil && s.vecIdx.isLoaded() {
-		emb := bytesToFloat32(embeddingBlob)
-		s.vecIdx.put(entry, emb)
+	// Update the vector index if loaded. An upsert without an embedding
+	// clears the entry's previous vector.
+	if s.vecIdx.isLoaded() {
+		if embeddingBlob == nil {
+			s.vecIdx.remove(entry.ID)
+		} else {
+			s.indexEmbedding(entry.ID, embeddingBlob)
+		}
 	}
 
 	return nil
@@ -219,18 +247,7 @@ func (s *Store) StoreBatch(ctx context.Context, entries []domain.MemoryEntry) er
 	}
 	defer tx.Rollback() //nolint:errcheck
 
-	const upsert = `
-		INSERT INTO entries (id, content, tags, metadata, embedding, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(id) DO UPDATE SET
-			content    = excluded.content,
-			tags       = excluded.tags,
-			metadata   = excluded.metadata,
-			embedding  = excluded.embedding,
-			updated_at = excluded.updated_at
-	`
-
-	stmt, err := tx.PrepareContext(ctx, upsert)
+	stmt, err := tx.PrepareContext(ctx, upsertEntry)
 	if err != nil {
 		return fmt.Errorf("%w: prepare: %v", domain.ErrVectorStore, err)
 	}
@@ -250,6 +267,7 @@ func (s *Store) StoreBatch(ctx context.Context, entries []domain.MemoryEntry) er
 		if embeddings != nil {
 			emb = embeddings[i]
 		}
+		model, dims := s.embeddingColumns(emb)
 
 		_, err = stmt.ExecContext(ctx,
 			entry.ID,
@@ -257,6 +275,8 @@ func (s *Store) StoreBatch(ctx context.Context, entries []domain.MemoryEntry) er
 			string(tags),
 			string(meta),
 			emb,
+			model,
+			dims,
 			entry.CreatedAt.Format(time.RFC3339),
 			entry.UpdatedAt.Format(time.RFC3339),
 		)
@@ -269,12 +289,13 @@ func (s *Store) StoreBatch(ctx context.Context, entries []domain.MemoryEntry) er
 		return fmt.Errorf("%w: commit: %v", domain.ErrVectorStore, err)
 	}
 
-	// Update in-memory vector index for entries that got embeddings.
-	if s.vecIdx.isLoaded() && embeddings != nil {
+	// Update the vector index if loaded.
+	if s.vecIdx.isLoaded() {
 		for i, entry := range entries {
-			if embeddings[i] != nil {
-				emb := bytesToFloat32(embeddings[i])
-				s.vecIdx.put(entry, emb)
+			if embeddings != nil && embeddings[i] != nil {
+				s.indexEmbedding(entry.ID, embeddings[i])
+			} else {
+				s.vecIdx.remove(entry.ID)
 			}
 		}
 	}
@@ -282,6 +303,38 @@ func (s *Store) StoreBatch(ctx context.Context, entries []domain.MemoryEntry) er
 	return nil
 }
 
+// upsertEntry inserts an entry or replaces an existing one, keeping its
+// creation time.
+const upsertEntry = `
+	INSERT INTO entries (id, content, tags, metadata, embedding, embedding_model, embedding_dims, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(id) DO UPDATE SET
+		content         = excluded.content,
+		tags            = excluded.tags,
+		metadata        = excluded.metadata,
+		embedding       = excluded.embedding,
+		embedding_model = excluded.embedding_model,
+		embedding_dims  = excluded.embedding_dims,
+		updated_at      = excluded.updated_at
+`
+
+// embeddingColumns returns the embedding_model and embedding_dims values
+// recorded with blob: the store's model and the vector length, or NULLs
+// when there is no embedding.
+func (s *Store) embeddingColumns(blob []byte) (model, dims any) {
+	if blob == nil {
+		return nil, nil
+	}
+	return s.model, len(blob) / 4
+}
+
+// indexEmbedding adds a stored embedding to the vector index.
+func (s *Store) indexEmbedding(id string, blob []byte) {
+	if err := s.vecIdx.put(id, bytesToFloat32(blob)); err != nil {
+		s.logger.Warn("vector store: embedding not indexed", "id", id, "error", err)
+	}
+}
+
 // Query implements domain.MemoryProvider.
 func (s *Store) Query(ctx context.Context, query string, limit int) ([]domain.MemoryEntry, error) {
 	if limit <= 0 {
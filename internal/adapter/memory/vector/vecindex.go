@@ -2,82 +2,69 @@ package vector
 
 import (
 	"context"
-	"sort"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
 	"sync"
-
-	"alfred-ai/internal/domain"
 )
 
-// vecIndex is an in-memory index of embedding vectors that avoids SQLite I/O
-// on every vector search. Entries are loaded lazily on the first search and
-// updated incrementally on Store/Delete operations.
+// vecIndex is an HNSW index over the stored embeddings that answers vector
+// searches without touching SQLite. It is loaded when the Store opens (or
+// lazily on the first search), updated incrementally on Store/Delete, and
+// persisted next to the database so restarts skip the rebuild.
 type vecIndex struct {
-	mu      sync.RWMutex
-	entries map[string]vecEntry // id → entry with embedding
-	loaded  bool
-}
-
-type vecEntry struct {
-	entry     domain.MemoryEntry
-	embedding []float32
+	mu     sync.RWMutex
+	graph  *hnswGraph
+	path   string // persisted index file; empty = in-memory only
+	ef     int    // search beam width
+	loaded bool
+	dirty  bool // graph changed since it was last persisted
 }
 
-func newVecIndex() *vecIndex {
+func newVecIndex(path string, ef int) *vecIndex {
+	if ef <= 0 {
+		ef = hnswEfSearch
+	}
 	return &vecIndex{
-		entries: make(map[string]vecEntry),
+		graph: newHNSWGraph(),
+		path:  path,
+		ef:    ef,
 	}
 }
 
-// search performs in-memory cosine similarity search against all cached embeddings.
-// Returns nil if the index has not been loaded yet.
-func (idx *vecIndex) search(queryVec []float32, limit int) []domain.MemoryEntry {
+// search returns the IDs of the cached embeddings most similar to queryVec.
+// Returns nil if the index has not been loaded yet or is empty.
+func (idx *vecIndex) search(queryVec []float32, limit int) []hnswResult {
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
 
-	if !idx.loaded || len(idx.entries) == 0 {
+	if !idx.loaded || idx.graph.size() == 0 {
 		return nil
 	}
-
-	type scored struct {
-		entry domain.MemoryEntry
-		score float32
-	}
-
-	candidates := make([]scored, 0, len(idx.entries))
-	for _, ve := range idx.entries {
-		sim := cosineSimilarity(queryVec, ve.embedding)
-		if sim <= 0 {
-			continue
-		}
-		candidates = append(candidates, scored{entry: ve.entry, score: sim})
+	results := idx.graph.search(queryVec, limit, idx.ef)
+	if results == nil {
+		results = []hnswResult{}
 	}
-
-	sort.Slice(candidates, func(i, j int) bool {
-		return candidates[i].score > candidates[j].score
-	})
-
-	n := min(limit, len(candidates))
-	result := make([]domain.MemoryEntry, n)
-	for i := 0; i < n; i++ {
-		result[i] = candidates[i].entry
-	}
-	return result
+	return results
 }
 
 // put adds or updates an entry in the index.
-func (idx *vecIndex) put(entry domain.MemoryEntry, embedding []float32) {
+func (idx *vecIndex) put(id string, embedding []float32) error {
 	if embedding == nil {
-		return
+		return nil
 	}
 	idx.mu.Lock()
-	idx.entries[entry.ID] = vecEntry{entry: entry, embedding: embedding}
-	idx.mu.Unlock()
+	defer idx.mu.Unlock()
+	idx.dirty = true
+	return idx.graph.insert(id, embedding)
 }
 
 // remove deletes an entry from the index.
 func (idx *vecIndex) remove(id string) {
 	idx.mu.Lock()
-	delete(idx.entries, id)
+	idx.graph.remove(id)
+	idx.dirty = true
 	idx.mu.Unlock()
 }
 
@@ -92,54 +79,149 @@ func (idx *vecIndex) isLoaded() bool {
 func (idx *vecIndex) size() int {
 	idx.mu.RLock()
 	defer idx.mu.RUnlock()
-	return len(idx.entries)
+	return idx.graph.size()
 }
 
-// loadFromDB populates the index from the database. This is called once
-// on the first vector search. Subsequent calls are no-ops.
-func (idx *vecIndex) loadFromDB(ctx context.Context, s *Store) error {
+// load populates the index, preferring the persisted file when its
+// fingerprint matches the database. A missing, corrupt or stale file is
+// replaced by a rebuild from the stored embeddings. Subsequent calls are
+// no-ops.
+func (idx *vecIndex) load(ctx context.Context, s *Store) error {
 	idx.mu.Lock()
+	defer idx.mu.Unlock()
 	if idx.loaded {
-		idx.mu.Unlock()
 		return nil
 	}
-	idx.mu.Unlock()
 
-	rows, err := s.db.QueryContext(ctx,
-		"SELECT id, content, tags, metadata, embedding, created_at, updated_at FROM entries WHERE embedding IS NOT NULL",
-	)
+	fingerprint, err := s.indexFingerprint(ctx)
 	if err != nil {
 		return err
 	}
+
+	if idx.path != "" {
+		g, stored, err := readHNSWFile(idx.path)
+		switch {
+		case err == nil && stored == fingerprint:
+			idx.graph = g
+			idx.loaded = true
+			return nil
+		case err == nil:
+			s.logger.Info("vector store: index is stale, rebuilding", "path", idx.path)
+		case !errors.Is(err, os.ErrNotExist):
+			s.logger.Warn("vector store: unreadable index, rebuilding", "path", idx.path, "error", err)
+		}
+	}
+
+	g, err := buildHNSWFromDB(ctx, s)
+	if err != nil {
+		return err
+	}
+	idx.graph = g
+	idx.loaded = true
+	idx.dirty = true
+	return idx.persistLocked(fingerprint)
+}
+
+// persist writes the index next to the database if it changed since the
+// last write.
+func (idx *vecIndex) persist(ctx context.Context, s *Store) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if !idx.loaded || !idx.dirty || idx.path == "" {
+		return nil
+	}
+	fingerprint, err := s.indexFingerprint(ctx)
+	if err != nil {
+		return err
+	}
+	return idx.persistLocked(fingerprint)
+}
+
+func (idx *vecIndex) persistLocked(fingerprint uint64) error {
+	if idx.path == "" {
+		return nil
+	}
+	tmp := idx.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("create index file: %w", err)
+	}
+	if err := idx.graph.writeTo(f, fingerprint); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("write index file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("close index file: %w", err)
+	}
+	if err := os.Rename(tmp, idx.path); err != nil {
+		return fmt.Errorf("rename index file: %w", err)
+	}
+	idx.dirty = false
+	return nil
+}
+
+func readHNSWFile(path string) (*hnswGraph, uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	return readHNSW(f)
+}
+
+// buildHNSWFromDB inserts every stored embedding into a fresh graph.
+func buildHNSWFromDB(ctx context.Context, s *Store) (*hnswGraph, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, embedding FROM entries WHERE embedding_model = ? ORDER BY rowid", s.model)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
-	entries := make(map[string]vecEntry)
+	g := newHNSWGraph()
 	for rows.Next() {
 		var (
-			entry        domain.MemoryEntry
-			tagsJSON     string
-			metaJSON     string
-			embBlob      []byte
-			createdAtStr string
-			updatedAtStr string
+			id      string
+			embBlob []byte
 		)
-		if err := rows.Scan(&entry.ID, &entry.Content, &tagsJSON, &metaJSON, &embBlob, &createdAtStr, &updatedAtStr); err != nil {
+		if err := rows.Scan(&id, &embBlob); err != nil {
 			continue
 		}
-
 		emb := bytesToFloat32(embBlob)
 		if emb == nil {
 			continue
 		}
-
-		unmarshalEntryFields(&entry, tagsJSON, metaJSON, createdAtStr, updatedAtStr)
-		entries[entry.ID] = vecEntry{entry: entry, embedding: emb}
+		if err := g.insert(id, emb); err != nil {
+			s.logger.Warn("vector store: skipping embedding", "id", id, "error", err)
+		}
 	}
+	return g, rows.Err()
+}
 
-	idx.mu.Lock()
-	idx.entries = entries
-	idx.loaded = true
-	idx.mu.Unlock()
+// indexFingerprint hashes the embedding model and the ID, update time and
+// dimension of every entry embedded by it, so a persisted index can be
+// checked against the database it was built from.
+func (s *Store) indexFingerprint(ctx context.Context) (uint64, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, updated_at, embedding_dims FROM entries WHERE embedding_model = ? ORDER BY id", s.model)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
 
-	return rows.Err()
+	h := fnv.New64a()
+	h.Write([]byte(s.model))
+	h.Write([]byte{0})
+	for rows.Next() {
+		var (
+			id, updatedAt string
+			dims          int
+		)
+		if err := rows.Scan(&id, &updatedAt, &dims); err != nil {
+			return 0, err
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00%d\x00", id, updatedAt, dims)
+	}
+	return h.Sum64(), rows.Err()
 }
@@ -5,7 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net/mail"
+	"regexp"
+	"strings"
 	"time"
+	"unicode"
 
 	"alfred-ai/internal/domain"
 )
@@ -38,6 +42,9 @@ type CalendarEvent struct {
 	End         string   `json:"end"`   // ISO 8601
 	Attendees   []string `json:"attendees,omitempty"`
 	AllDay      bool     `json:"all_day,omitempty"`
+	Recurrence  string   `json:"recurrence,omitempty"` // RFC 5545 RRULE value, e.g. "FREQ=WEEKLY;BYDAY=MO"
+	TimeZone    string   `json:"timezone,omitempty"`   // IANA zone the event is anchored to
+	ETag        string   `json:"etag,omitempty"`       // server version tag for conflict detection
 }
 
 // CreateEventInput is the input for creating an event.
@@ -49,6 +56,8 @@ type CreateEventInput struct {
 	End         string   `json:"end"`
 	Attendees   []string `json:"attendees,omitempty"`
 	AllDay      bool     `json:"all_day,omitempty"`
+	Recurrence  string   `json:"recurrence,omitempty"`
+	TimeZone    string   `json:"timezone,omitempty"`
 }
 
 // UpdateEventInput is the input for updating an event.
@@ -59,6 +68,8 @@ type UpdateEventInput struct {
 	Start       *string  `json:"start,omitempty"`
 	End         *string  `json:"end,omitempty"`
 	Attendees   []string `json:"attendees,omitempty"`
+	Recurrence  *string  `json:"recurrence,omitempty"`
+	ETag        string   `json:"etag,omitempty"` // if set, the update fails when the event changed since it was read
 }
 
 // CalendarBackend abstracts calendar operations.
@@ -115,6 +126,8 @@ func (m *MockCalendarBackend) CreateEvent(_ context.Context, calendarID string,
 		End:         input.End,
 		Attendees:   input.Attendees,
 		AllDay:      input.AllDay,
+		Recurrence:  input.Recurrence,
+		TimeZone:    input.TimeZone,
 	}
 	m.nextID++
 	m.events[calendarID] = append(m.events[calendarID], ev)
@@ -143,6 +156,9 @@ func (m *MockCalendarBackend) UpdateEvent(_ context.Context, calendarID, eventID
 			if update.Attendees != nil {
 				events[i].Attendees = update.Attendees
 			}
+			if update.Recurrence != nil {
+				events[i].Recurrence = *update.Recurrence
+			}
 			m.events[calendarID] = events
 			return &events[i], nil
 		}
@@ -230,6 +246,18 @@ func (t *CalendarTool) Schema() domain.ToolSchema {
 					"type": "boolean",
 					"description": "Whether this is an all-day event"
 				},
+				"recurrence": {
+					"type": "string",
+					"description": "Recurrence rule in iCalendar RRULE syntax (e.g. FREQ=WEEKLY;BYDAY=MO,WE); an empty string removes it on update"
+				},
+				"timezone": {
+					"type": "string",
+					"description": "IANA time zone for the event (e.g. Europe/Berlin); defaults to UTC"
+				},
+				"etag": {
+					"type": "string",
+					"description": "ETag from a previous read; update fails if the event changed since"
+				},
 				"time_min": {
 					"type": "string",
 					"description": "Filter events starting after this time (ISO 8601)"
@@ -263,6 +291,9 @@ type calendarParams struct {
 	End         string   `json:"end,omitempty"`
 	Attendees   []string `json:"attendees,omitempty"`
 	AllDay      bool     `json:"all_day,omitempty"`
+	Recurrence  *string  `json:"recurrence,omitempty"` // "" clears it on update
+	TimeZone    string   `json:"timezone,omitempty"`
+	ETag        string   `json:"etag,omitempty"`
 	TimeMin     string   `json:"time_min,omitempty"`
 	TimeMax     string   `json:"time_max,omitempty"`
 	Page        int      `json:"page,omitempty"`
@@ -292,6 +323,44 @@ func validateISO8601(name, value string) error {
 	return nil
 }
 
+func validateTimeZone(tz string) error {
+	if tz == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return fmt.Errorf("'timezone' must be an IANA time zone name (e.g. Europe/Berlin): %v", err)
+	}
+	return nil
+}
+
+// rruleRe matches an RRULE value: NAME=VALUE parts separated by semicolons,
+// optionally prefixed with "RRULE:". Line breaks can never match, so a rule
+// cannot smuggle further properties into the event.
+var rruleRe = regexp.MustCompile(`^(RRULE:)?[A-Z]+=[A-Za-z0-9,+\-]+(;[A-Z]+=[A-Za-z0-9,+\-]+)*$`)
+
+func validateRecurrence(rule string) error {
+	if rule == "" {
+		return nil
+	}
+	if !rruleRe.MatchString(rule) {
+		return fmt.Errorf("'recurrence' must be an RRULE value (e.g. FREQ=WEEKLY;BYDAY=MO,WE)")
+	}
+	return nil
+}
+
+func validateAttendees(attendees []string) error {
+	for _, a := range attendees {
+		if strings.IndexFunc(a, unicode.IsControl) >= 0 {
+			return fmt.Errorf("attendee %q contains control characters", a)
+		}
+		addr, err := mail.ParseAddress(a)
+		if err != nil || addr.Name != "" || addr.Address != a {
+			return fmt.Errorf("attendee %q must be a plain email address", a)
+		}
+	}
+	return nil
+}
+
 func (t *CalendarTool) handleListCalendars(ctx context.Context, _ calendarParams) (any, error) {
 	cals, err := t.backend.ListCalendars(ctx)
 	if err != nil {
@@ -342,6 +411,19 @@ func (t *CalendarTool) handleCreateEvent(ctx context.Context, p calendarParams)
 	if err := validateISO8601("end", p.End); err != nil {
 		return nil, err
 	}
+	if err := validateTimeZone(p.TimeZone); err != nil {
+		return nil, err
+	}
+	var recurrence string
+	if p.Recurrence != nil {
+		recurrence = *p.Recurrence
+	}
+	if err := validateRecurrence(recurrence); err != nil {
+		return nil, err
+	}
+	if err := validateAttendees(p.Attendees); err != nil {
+		return nil, err
+	}
 	return t.backend.CreateEvent(ctx, p.CalendarID, CreateEventInput{
 		Title:       p.Title,
 		Description: p.Description,
@@ -350,6 +432,8 @@ func (t *CalendarTool) handleCreateEvent(ctx context.Context, p calendarParams)
 		End:         p.End,
 		Attendees:   p.Attendees,
 		AllDay:      p.AllDay,
+		Recurrence:  recurrence,
+		TimeZone:    p.TimeZone,
 	})
 }
 
@@ -363,7 +447,15 @@ func (t *CalendarTool) handleUpdateEvent(ctx context.Context, p calendarParams)
 	if err := validateISO8601("end", p.End); err != nil {
 		return nil, err
 	}
-	update := UpdateEventInput{Attendees: p.Attendees}
+	if p.Recurrence != nil {
+		if err := validateRecurrence(*p.Recurrence); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateAttendees(p.Attendees); err != nil {
+		return nil, err
+	}
+	update := UpdateEventInput{Attendees: p.Attendees, ETag: p.ETag}
 	if p.Title != "" {
 		update.Title = &p.Title
 	}
@@ -379,6 +471,7 @@ func (t *CalendarTool) handleUpdateEvent(ctx context.Context, p calendarParams)
 	if p.End != "" {
 		update.End = &p.End
 	}
+	update.Recurrence = p.Recurrence // an empty rule removes the recurrence
 	return t.backend.UpdateEvent(ctx, p.CalendarID, p.EventID, update)
 }
 
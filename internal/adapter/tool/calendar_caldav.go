@@ -0,0 +1,610 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	maxCalDAVBodySize = 4 * 1024 * 1024 // 4MB
+	caldavProdID      = "-//alfred-ai//calendar//EN"
+)
+
+// ErrCalendarConflict is returned when an event changed on the server since
+// it was read (HTTP 412 on an If-Match/If-None-Match precondition).
+var ErrCalendarConflict = errors.New("calendar event was modified by someone else; re-read it and retry")
+
+// CalDAVConfig configures the CalDAV calendar backend.
+type CalDAVConfig struct {
+	URL      string        // calendar home collection, e.g. https://cloud.example.com/remote.php/dav/calendars/alice/
+	Username string        // HTTP basic auth user
+	Password string        // HTTP basic auth password or app token
+	Timeout  time.Duration // per-request timeout
+}
+
+// CalDAVBackend implements CalendarBackend against a CalDAV server
+// (Nextcloud, Radicale, Baïkal, ...). Calendar IDs are collection names under
+// the home URL and event IDs are resource names inside a calendar.
+type CalDAVBackend struct {
+	client   *http.Client
+	home     *url.URL
+	username string
+	password string
+	logger   *slog.Logger
+}
+
+// NewCalDAVBackend creates a calendar backend backed by a CalDAV server.
+func NewCalDAVBackend(cfg CalDAVConfig, logger *slog.Logger) (*CalDAVBackend, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("caldav url is required")
+	}
+	home, err := url.Parse(cfg.URL)
+	if err != nil || home.Scheme == "" || home.Host == "" {
+		return nil, fmt.Errorf("invalid caldav url %q", cfg.URL)
+	}
+	if !strings.HasSuffix(home.Path, "/") {
+		home.Path += "/"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 15 * time.Second
+	}
+	return &CalDAVBackend{
+		client:   &http.Client{Timeout: cfg.Timeout},
+		home:     home,
+		username: cfg.Username,
+		password: cfg.Password,
+		logger:   logger,
+	}, nil
+}
+
+// --- WebDAV multistatus ---
+
+type davMultistatus struct {
+	XMLName   xml.Name      `xml:"DAV: multistatus"`
+	Responses []davResponse `xml:"response"`
+}
+
+type davResponse struct {
+	Href      string        `xml:"href"`
+	Propstats []davPropstat `xml:"propstat"`
+}
+
+type davPropstat struct {
+	Status string  `xml:"status"`
+	Prop   davProp `xml:"prop"`
+}
+
+type davProp struct {
+	DisplayName  string `xml:"displayname"`
+	ResourceType struct {
+		Calendar *struct{} `xml:"urn:ietf:params:xml:ns:caldav calendar"`
+	} `xml:"resourcetype"`
+	ETag         string `xml:"getetag"`
+	CalendarData string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+}
+
+// okProp returns the first propstat reported with a 2xx status.
+func (r davResponse) okProp() (davProp, bool) {
+	for _, ps := range r.Propstats {
+		fields := strings.Fields(ps.Status)
+		if len(fields) >= 2 && strings.HasPrefix(fields[1], "2") {
+			return ps.Prop, true
+		}
+	}
+	return davProp{}, false
+}
+
+const caldavPropfindCalendars = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:displayname/><d:resourcetype/></d:prop>
+</d:propfind>`
+
+// ListCalendars discovers calendar collections under the home URL.
+func (b *CalDAVBackend) ListCalendars(ctx context.Context) ([]CalendarInfo, error) {
+	ms, err := b.multistatus(ctx, "PROPFIND", b.home, "1", caldavPropfindCalendars)
+	if err != nil {
+		return nil, err
+	}
+	var cals []CalendarInfo
+	for _, r := range ms.Responses {
+		prop, ok := r.okProp()
+		if !ok || prop.ResourceType.Calendar == nil {
+			continue
+		}
+		id := path.Base(strings.TrimSuffix(hrefPath(r.Href), "/"))
+		name := prop.DisplayName
+		if name == "" {
+			name = id
+		}
+		cals = append(cals, CalendarInfo{ID: id, Name: name})
+	}
+	sort.Slice(cals, func(i, j int) bool { return cals[i].ID < cals[j].ID })
+	return cals, nil
+}
+
+// ListEvents runs a calendar-query REPORT, optionally bounded by a time range.
+// Recurring events match when any occurrence falls inside the range.
+func (b *CalDAVBackend) ListEvents(ctx context.Context, calendarID string, opts ListEventsOpts) ([]CalendarEvent, error) {
+	calURL, err := b.calendarURL(calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeRange := ""
+	if opts.TimeMin != "" || opts.TimeMax != "" {
+		var attrs []string
+		for _, v := range []struct{ name, value string }{{"start", opts.TimeMin}, {"end", opts.TimeMax}} {
+			if v.value == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, v.value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid time range: %w", err)
+			}
+			attrs = append(attrs, fmt.Sprintf(`%s="%s"`, v.name, t.UTC().Format(icalUTCLayout)))
+		}
+		timeRange = `<c:time-range ` + strings.Join(attrs, " ") + `/>`
+	}
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/><c:calendar-data/></d:prop>
+  <c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VEVENT">` + timeRange + `</c:comp-filter></c:comp-filter></c:filter>
+</c:calendar-query>`
+
+	ms, err := b.multistatus(ctx, "REPORT", calURL, "1", body)
+	if err != nil {
+		return nil, err
+	}
+	var events []CalendarEvent
+	for _, r := range ms.Responses {
+		prop, ok := r.okProp()
+		if !ok || prop.CalendarData == "" {
+			continue
+		}
+		cal, err := parseICal(prop.CalendarData)
+		if err != nil {
+			b.logger.Warn("skipping unparseable calendar object", "href", r.Href, "error", err)
+			continue
+		}
+		ev, err := eventFromICal(cal, calendarID, path.Base(hrefPath(r.Href)), prop.ETag)
+		if err != nil {
+			b.logger.Warn("skipping calendar object", "href", r.Href, "error", err)
+			continue
+		}
+		events = append(events, *ev)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Start < events[j].Start })
+	return paginateEvents(events, opts.Page, opts.PerPage), nil
+}
+
+func paginateEvents(events []CalendarEvent, page, perPage int) []CalendarEvent {
+	if perPage <= 0 {
+		return events
+	}
+	if page <= 0 {
+		page = 1
+	}
+	start := (page - 1) * perPage
+	if start >= len(events) {
+		return nil
+	}
+	return events[start:min(start+perPage, len(events))]
+}
+
+// GetEvent fetches a single event resource.
+func (b *CalDAVBackend) GetEvent(ctx context.Context, calendarID, eventID string) (*CalendarEvent, error) {
+	cal, etag, err := b.getObject(ctx, calendarID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return eventFromICal(cal, calendarID, eventID, etag)
+}
+
+// CreateEvent stores a new VEVENT. If-None-Match guards against overwriting
+// an existing resource with the same name.
+func (b *CalDAVBackend) CreateEvent(ctx context.Context, calendarID string, input CreateEventInput) (*CalendarEvent, error) {
+	start, err := time.Parse(time.RFC3339, input.Start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start: %w", err)
+	}
+	end, err := time.Parse(time.RFC3339, input.End)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end: %w", err)
+	}
+
+	if err := validateRecurrence(input.Recurrence); err != nil {
+		return nil, err
+	}
+	if err := validateAttendees(input.Attendees); err != nil {
+		return nil, err
+	}
+
+	uid, err := newEventUID()
+	if err != nil {
+		return nil, err
+	}
+	vevent := &icalComponent{Name: "VEVENT", Props: []*icalProp{
+		{Name: "UID", Value: uid},
+		{Name: "DTSTAMP", Value: time.Now().UTC().Format(icalUTCLayout)},
+		icalTimeProp("DTSTART", start, input.AllDay, input.TimeZone),
+		icalTimeProp("DTEND", end, input.AllDay, input.TimeZone),
+		{Name: "SUMMARY", Value: icalEscape(input.Title)},
+	}}
+	if input.Description != "" {
+		vevent.set(&icalProp{Name: "DESCRIPTION", Value: icalEscape(input.Description)})
+	}
+	if input.Location != "" {
+		vevent.set(&icalProp{Name: "LOCATION", Value: icalEscape(input.Location)})
+	}
+	if input.Recurrence != "" {
+		vevent.set(&icalProp{Name: "RRULE", Value: strings.TrimPrefix(input.Recurrence, "RRULE:")})
+	}
+	setAttendees(vevent, input.Attendees)
+
+	cal := &icalComponent{Name: "VCALENDAR", Props: []*icalProp{
+		{Name: "VERSION", Value: "2.0"},
+		{Name: "PRODID", Value: caldavProdID},
+	}}
+	if input.TimeZone != "" && !input.AllDay {
+		vtz, err := icalTimezone(input.TimeZone, start.Year())
+		if err != nil {
+			return nil, err
+		}
+		cal.Children = append(cal.Children, vtz)
+	}
+	cal.Children = append(cal.Children, vevent)
+
+	eventID := uid + ".ics"
+	etag, err := b.putObject(ctx, calendarID, eventID, cal, "If-None-Match", "*")
+	if err != nil {
+		return nil, err
+	}
+	return eventFromICal(cal, calendarID, eventID, etag)
+}
+
+// UpdateEvent edits only the supplied fields of the master VEVENT and writes
+// it back with If-Match, so concurrent edits are reported as ErrCalendarConflict
+// instead of being silently overwritten.
+func (b *CalDAVBackend) UpdateEvent(ctx context.Context, calendarID, eventID string, update UpdateEventInput) (*CalendarEvent, error) {
+	cal, etag, err := b.getObject(ctx, calendarID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if update.ETag != "" && update.ETag != etag {
+		return nil, ErrCalendarConflict
+	}
+	if update.Recurrence != nil {
+		if err := validateRecurrence(*update.Recurrence); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateAttendees(update.Attendees); err != nil {
+		return nil, err
+	}
+	vevent := masterEvent(cal)
+	if vevent == nil {
+		return nil, fmt.Errorf("event %q has no VEVENT", eventID)
+	}
+
+	if update.Title != nil {
+		vevent.set(&icalProp{Name: "SUMMARY", Value: icalEscape(*update.Title)})
+	}
+	if update.Description != nil {
+		vevent.set(&icalProp{Name: "DESCRIPTION", Value: icalEscape(*update.Description)})
+	}
+	if update.Location != nil {
+		vevent.set(&icalProp{Name: "LOCATION", Value: icalEscape(*update.Location)})
+	}
+	if update.Recurrence != nil {
+		if *update.Recurrence == "" {
+			vevent.remove("RRULE")
+		} else {
+			vevent.set(&icalProp{Name: "RRULE", Value: strings.TrimPrefix(*update.Recurrence, "RRULE:")})
+		}
+	}
+	for _, f := range []struct {
+		name  string
+		value *string
+	}{{"DTSTART", update.Start}, {"DTEND", update.End}} {
+		if f.value == nil {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, *f.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", strings.ToLower(f.name[2:]), err)
+		}
+		// Keep the original value type and time zone of the property.
+		allDay, tzid := false, ""
+		if old := vevent.prop(f.name); old != nil {
+			_, allDay, _ = parseICalTime(old)
+			tzid = old.param("TZID")
+		} else if ds := vevent.prop("DTSTART"); ds != nil {
+			_, allDay, _ = parseICalTime(ds)
+			tzid = ds.param("TZID")
+		}
+		if f.name == "DTEND" {
+			vevent.remove("DURATION")
+		}
+		vevent.set(icalTimeProp(f.name, t, allDay, tzid))
+	}
+	if update.Attendees != nil {
+		setAttendees(vevent, update.Attendees)
+	}
+	bumpSequence(vevent)
+
+	newETag, err := b.putObject(ctx, calendarID, eventID, cal, "If-Match", etag)
+	if err != nil {
+		return nil, err
+	}
+	return eventFromICal(cal, calendarID, eventID, newETag)
+}
+
+// DeleteEvent removes an event resource.
+func (b *CalDAVBackend) DeleteEvent(ctx context.Context, calendarID, eventID string) error {
+	u, err := b.objectURL(calendarID, eventID)
+	if err != nil {
+		return err
+	}
+	resp, err := b.do(ctx, http.MethodDelete, u, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusAccepted:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("event %q not found", eventID)
+	case http.StatusPreconditionFailed:
+		return ErrCalendarConflict
+	default:
+		return caldavStatusError("delete event", resp)
+	}
+}
+
+// --- HTTP helpers ---
+
+func (b *CalDAVBackend) calendarURL(calendarID string) (*url.URL, error) {
+	if !validDAVSegment(calendarID) {
+		return nil, fmt.Errorf("invalid calendar_id %q", calendarID)
+	}
+	return b.home.JoinPath(calendarID + "/"), nil
+}
+
+func (b *CalDAVBackend) objectURL(calendarID, eventID string) (*url.URL, error) {
+	calURL, err := b.calendarURL(calendarID)
+	if err != nil {
+		return nil, err
+	}
+	if !validDAVSegment(eventID) {
+		return nil, fmt.Errorf("invalid event_id %q", eventID)
+	}
+	return calURL.JoinPath(eventID), nil
+}
+
+// validDAVSegment rejects IDs that would escape the calendar home.
+func validDAVSegment(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, "/\\?#")
+}
+
+func hrefPath(href string) string {
+	if u, err := url.Parse(href); err == nil {
+		if p, err := url.PathUnescape(u.EscapedPath()); err == nil {
+			return p
+		}
+	}
+	return href
+}
+
+func (b *CalDAVBackend) do(ctx context.Context, method string, u *url.URL, body []byte, headers map[string]string) (*http.Response, error) {
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), rd)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if b.username != "" || b.password != "" {
+		req.SetBasicAuth(b.username, b.password)
+	}
+	req.Header.Set("User-Agent", "alfred-ai/1.0")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("caldav %s: %w", method, err)
+	}
+	return resp, nil
+}
+
+func (b *CalDAVBackend) multistatus(ctx context.Context, method string, u *url.URL, depth, body string) (*davMultistatus, error) {
+	resp, err := b.do(ctx, method, u, []byte(body), map[string]string{
+		"Depth":        depth,
+		"Content-Type": "application/xml; charset=utf-8",
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, caldavStatusError(strings.ToLower(method), resp)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCalDAVBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	var ms davMultistatus
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		return nil, fmt.Errorf("parse multistatus: %w", err)
+	}
+	return &ms, nil
+}
+
+func (b *CalDAVBackend) getObject(ctx context.Context, calendarID, eventID string) (*icalComponent, string, error) {
+	u, err := b.objectURL(calendarID, eventID)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := b.do(ctx, http.MethodGet, u, nil, map[string]string{"Accept": "text/calendar"})
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", fmt.Errorf("event %q not found", eventID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", caldavStatusError("get event", resp)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCalDAVBodySize))
+	if err != nil {
+		return nil, "", fmt.Errorf("read response: %w", err)
+	}
+	cal, err := parseICal(string(data))
+	if err != nil {
+		return nil, "", err
+	}
+	return cal, resp.Header.Get("ETag"), nil
+}
+
+// putObject uploads cal and returns the new ETag (empty if the server does
+// not report one on PUT).
+func (b *CalDAVBackend) putObject(ctx context.Context, calendarID, eventID string, cal *icalComponent, condHeader, condValue string) (string, error) {
+	u, err := b.objectURL(calendarID, eventID)
+	if err != nil {
+		return "", err
+	}
+	headers := map[string]string{"Content-Type": "text/calendar; charset=utf-8"}
+	if condValue != "" {
+		headers[condHeader] = condValue
+	}
+	resp, err := b.do(ctx, http.MethodPut, u, []byte(cal.encode()), headers)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return resp.Header.Get("ETag"), nil
+	case http.StatusPreconditionFailed:
+		return "", ErrCalendarConflict
+	default:
+		return "", caldavStatusError("put event", resp)
+	}
+}
+
+func caldavStatusError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("%s failed (HTTP %d): %s", op, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// --- iCalendar <-> CalendarEvent ---
+
+// masterEvent returns the VEVENT without RECURRENCE-ID (the series master),
+// falling back to the first VEVENT.
+func masterEvent(cal *icalComponent) *icalComponent {
+	events := cal.children("VEVENT")
+	for _, ev := range events {
+		if ev.prop("RECURRENCE-ID") == nil {
+			return ev
+		}
+	}
+	if len(events) > 0 {
+		return events[0]
+	}
+	return nil
+}
+
+func eventFromICal(cal *icalComponent, calendarID, eventID, etag string) (*CalendarEvent, error) {
+	vevent := masterEvent(cal)
+	if vevent == nil {
+		return nil, fmt.Errorf("event %q has no VEVENT", eventID)
+	}
+	ev := &CalendarEvent{
+		ID:          eventID,
+		CalendarID:  calendarID,
+		Title:       icalUnescape(vevent.value("SUMMARY")),
+		Description: icalUnescape(vevent.value("DESCRIPTION")),
+		Location:    icalUnescape(vevent.value("LOCATION")),
+		Recurrence:  vevent.value("RRULE"),
+		ETag:        etag,
+	}
+
+	dtstart := vevent.prop("DTSTART")
+	if dtstart == nil {
+		return nil, fmt.Errorf("event %q has no DTSTART", eventID)
+	}
+	start, allDay, err := parseICalTime(dtstart)
+	if err != nil {
+		return nil, fmt.Errorf("event %q: invalid DTSTART: %w", eventID, err)
+	}
+	ev.AllDay = allDay
+	ev.TimeZone = dtstart.param("TZID")
+
+	end := start
+	if dtend := vevent.prop("DTEND"); dtend != nil {
+		if end, _, err = parseICalTime(dtend); err != nil {
+			return nil, fmt.Errorf("event %q: invalid DTEND: %w", eventID, err)
+		}
+	} else if dur := vevent.value("DURATION"); dur != "" {
+		d, err := parseICalDuration(dur)
+		if err != nil {
+			return nil, fmt.Errorf("event %q: %w", eventID, err)
+		}
+		end = start.Add(d)
+	} else if allDay {
+		end = start.AddDate(0, 0, 1)
+	}
+	ev.Start = start.Format(time.RFC3339)
+	ev.End = end.Format(time.RFC3339)
+
+	for _, a := range vevent.propsNamed("ATTENDEE") {
+		addr := a.Value
+		if len(addr) > 7 && strings.EqualFold(addr[:7], "mailto:") {
+			addr = addr[7:]
+		}
+		ev.Attendees = append(ev.Attendees, addr)
+	}
+	return ev, nil
+}
+
+func setAttendees(vevent *icalComponent, attendees []string) {
+	vevent.remove("ATTENDEE")
+	for _, a := range attendees {
+		vevent.Props = append(vevent.Props, &icalProp{
+			Name:   "ATTENDEE",
+			Params: []icalParam{{Name: "RSVP", Value: "TRUE"}},
+			Value:  "mailto:" + a,
+		})
+	}
+}
+
+func bumpSequence(vevent *icalComponent) {
+	seq := 0
+	fmt.Sscanf(vevent.value("SEQUENCE"), "%d", &seq)
+	vevent.set(&icalProp{Name: "SEQUENCE", Value: fmt.Sprint(seq + 1)})
+	vevent.set(&icalProp{Name: "DTSTAMP", Value: time.Now().UTC().Format(icalUTCLayout)})
+}
+
+func newEventUID() (string, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", fmt.Errorf("generate event uid: %w", err)
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
@@ -0,0 +1,475 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// --- local CalDAV stand-in ---
+
+type fakeCalDAVObject struct {
+	data string
+	etag string
+}
+
+type fakeCalDAVServer struct {
+	srv       *httptest.Server
+	mu        sync.Mutex
+	calendars map[string]string                      // id -> display name
+	objects   map[string]map[string]fakeCalDAVObject // calendar -> resource -> object
+	version   int
+}
+
+const fakeCalDAVHome = "/dav/calendars/alice/"
+
+func newFakeCalDAVServer(t *testing.T) *fakeCalDAVServer {
+	t.Helper()
+	f := &fakeCalDAVServer{
+		calendars: map[string]string{"personal": "Personal", "work": "Work"},
+		objects:   map[string]map[string]fakeCalDAVObject{"personal": {}, "work": {}},
+	}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeCalDAVServer) homeURL() string { return f.srv.URL + fakeCalDAVHome }
+
+func (f *fakeCalDAVServer) put(cal, name, data string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.version++
+	etag := fmt.Sprintf(`"v%d"`, f.version)
+	f.objects[cal][name] = fakeCalDAVObject{data: strings.ReplaceAll(data, "\n", "\r\n"), etag: etag}
+	return etag
+}
+
+func (f *fakeCalDAVServer) get(cal, name string) fakeCalDAVObject {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.objects[cal][name]
+}
+
+func (f *fakeCalDAVServer) handle(w http.ResponseWriter, r *http.Request) {
+	if user, pass, ok := r.BasicAuth(); !ok || user != "alice" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	rest, ok := strings.CutPrefix(r.URL.Path, fakeCalDAVHome)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	cal, name, _ := strings.Cut(rest, "/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == "PROPFIND" && rest == "":
+		var b strings.Builder
+		b.WriteString(`<?xml version="1.0"?><d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">`)
+		b.WriteString(`<d:response><d:href>` + fakeCalDAVHome + `</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`)
+		for id, display := range f.calendars {
+			b.WriteString(`<d:response><d:href>` + fakeCalDAVHome + id + `/</d:href><d:propstat><d:prop><d:displayname>` + display +
+				`</d:displayname><d:resourcetype><d:collection/><cal:calendar/></d:resourcetype></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`)
+		}
+		b.WriteString(`</d:multistatus>`)
+		w.WriteHeader(http.StatusMultiStatus)
+		io.WriteString(w, b.String())
+	case r.Method == "REPORT" && name == "":
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "calendar-query") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var b strings.Builder
+		b.WriteString(`<?xml version="1.0"?><d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">`)
+		for res, obj := range f.objects[cal] {
+			b.WriteString(`<d:response><d:href>` + fakeCalDAVHome + cal + "/" + res + `</d:href><d:propstat><d:prop><d:getetag>` +
+				strings.ReplaceAll(obj.etag, `"`, "&quot;") + `</d:getetag><cal:calendar-data>` + xmlEscapeText(obj.data) +
+				`</cal:calendar-data></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`)
+		}
+		b.WriteString(`</d:multistatus>`)
+		w.WriteHeader(http.StatusMultiStatus)
+		io.WriteString(w, b.String())
+	case r.Method == http.MethodGet:
+		obj, ok := f.objects[cal][name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", obj.etag)
+		io.WriteString(w, obj.data)
+	case r.Method == http.MethodPut:
+		objs, ok := f.objects[cal]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		existing, exists := objs[name]
+		if r.Header.Get("If-None-Match") == "*" && exists {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if m := r.Header.Get("If-Match"); m != "" && (!exists || m != existing.etag) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if _, err := parseICal(string(body)); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.version++
+		etag := fmt.Sprintf(`"v%d"`, f.version)
+		objs[name] = fakeCalDAVObject{data: string(body), etag: etag}
+		w.Header().Set("ETag", etag)
+		if exists {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+		}
+	case r.Method == http.MethodDelete:
+		if _, ok := f.objects[cal][name]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		delete(f.objects[cal], name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func xmlEscapeText(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func newTestCalDAVBackend(t *testing.T) (*CalDAVBackend, *fakeCalDAVServer) {
+	t.Helper()
+	srv := newFakeCalDAVServer(t)
+	b, err := NewCalDAVBackend(CalDAVConfig{
+		URL:      srv.homeURL(),
+		Username: "alice",
+		Password: "secret",
+		Timeout:  5 * time.Second,
+	}, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, srv
+}
+
+const testRecurringICS = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Example//Test//EN
+BEGIN:VTIMEZONE
+TZID:Europe/Berlin
+BEGIN:STANDARD
+DTSTART:19701025T030000
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:standup-1
+DTSTAMP:20250101T000000Z
+DTSTART;TZID=Europe/Berlin:20250106T093000
+DTEND;TZID=Europe/Berlin:20250106T094500
+RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR
+EXDATE;TZID=Europe/Berlin:20250108T093000
+SUMMARY:Team standup\, daily
+DESCRIPTION:Line one\nLine two
+ATTENDEE;CN=Bob:mailto:bob@example.com
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:-PT10M
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+UID:standup-1
+RECURRENCE-ID;TZID=Europe/Berlin:20250110T093000
+DTSTART;TZID=Europe/Berlin:20250110T100000
+DTEND;TZID=Europe/Berlin:20250110T101500
+SUMMARY:Team standup (moved)
+END:VEVENT
+END:VCALENDAR
+`
+
+// --- tests ---
+
+func TestCalDAVListCalendars(t *testing.T) {
+	b, _ := newTestCalDAVBackend(t)
+	cals, err := b.ListCalendars(context.Background())
+	if err != nil {
+		t.Fatalf("ListCalendars: %v", err)
+	}
+	if len(cals) != 2 || cals[0].ID != "personal" || cals[1].ID != "work" || cals[1].Name != "Work" {
+		t.Errorf("calendars = %+v", cals)
+	}
+}
+
+func TestCalDAVBadCredentials(t *testing.T) {
+	b, _ := newTestCalDAVBackend(t)
+	b.password = "wrong"
+	_, err := b.ListCalendars(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Fatalf("expected 401 error, got %v", err)
+	}
+}
+
+func TestCalDAVGetRecurringEvent(t *testing.T) {
+	b, srv := newTestCalDAVBackend(t)
+	etag := srv.put("work", "standup-1.ics", testRecurringICS)
+
+	ev, err := b.GetEvent(context.Background(), "work", "standup-1.ics")
+	if err != nil {
+		t.Fatalf("GetEvent: %v", err)
+	}
+	if ev.Title != "Team standup, daily" {
+		t.Errorf("title = %q", ev.Title)
+	}
+	if ev.Description != "Line one\nLine two" {
+		t.Errorf("description = %q", ev.Description)
+	}
+	if ev.Start != "2025-01-06T09:30:00+01:00" || ev.End != "2025-01-06T09:45:00+01:00" {
+		t.Errorf("start/end = %s / %s", ev.Start, ev.End)
+	}
+	if ev.Recurrence != "FREQ=WEEKLY;BYDAY=MO,WE,FR" || ev.TimeZone != "Europe/Berlin" {
+		t.Errorf("recurrence/timezone = %q / %q", ev.Recurrence, ev.TimeZone)
+	}
+	if ev.ETag != etag {
+		t.Errorf("etag = %q, want %q", ev.ETag, etag)
+	}
+	if len(ev.Attendees) != 1 || ev.Attendees[0] != "bob@example.com" {
+		t.Errorf("attendees = %v", ev.Attendees)
+	}
+}
+
+func TestCalDAVListEvents(t *testing.T) {
+	b, srv := newTestCalDAVBackend(t)
+	srv.put("work", "standup-1.ics", testRecurringICS)
+
+	events, err := b.ListEvents(context.Background(), "work", ListEventsOpts{
+		TimeMin: "2025-01-01T00:00:00Z",
+		TimeMax: "2025-02-01T00:00:00Z",
+	})
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	if len(events) != 1 || events[0].ID != "standup-1.ics" || events[0].CalendarID != "work" {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+func TestCalDAVUpdatePreservesRecurrenceAndTimeZone(t *testing.T) {
+	b, srv := newTestCalDAVBackend(t)
+	srv.put("work", "standup-1.ics", testRecurringICS)
+
+	title := "Standup"
+	start := "2025-01-06T08:00:00Z" // 09:00 in Berlin
+	ev, err := b.UpdateEvent(context.Background(), "work", "standup-1.ics", UpdateEventInput{Title: &title, Start: &start})
+	if err != nil {
+		t.Fatalf("UpdateEvent: %v", err)
+	}
+	if ev.Title != "Standup" || ev.Start != "2025-01-06T09:00:00+01:00" {
+		t.Errorf("updated event = %+v", ev)
+	}
+
+	stored := srv.get("work", "standup-1.ics").data
+	for _, want := range []string{
+		"DTSTART;TZID=Europe/Berlin:20250106T090000",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR",
+		"EXDATE;TZID=Europe/Berlin:20250108T093000",
+		"RECURRENCE-ID;TZID=Europe/Berlin:20250110T093000",
+		"SUMMARY:Team standup (moved)",
+		"BEGIN:VTIMEZONE",
+		"BEGIN:VALARM",
+		"SEQUENCE:1",
+		"ATTENDEE;CN=Bob:mailto:bob@example.com",
+	} {
+		if !strings.Contains(stored, want) {
+			t.Errorf("stored object missing %q:\n%s", want, stored)
+		}
+	}
+}
+
+func TestCalDAVUpdateConflict(t *testing.T) {
+	b, srv := newTestCalDAVBackend(t)
+	staleETag := srv.put("work", "standup-1.ics", testRecurringICS)
+	srv.put("work", "standup-1.ics", testRecurringICS) // someone else edits it
+
+	title := "Mine"
+	_, err := b.UpdateEvent(context.Background(), "work", "standup-1.ics", UpdateEventInput{Title: &title, ETag: staleETag})
+	if !errors.Is(err, ErrCalendarConflict) {
+		t.Fatalf("expected ErrCalendarConflict, got %v", err)
+	}
+}
+
+func TestCalDAVPutPreconditionFailed(t *testing.T) {
+	b, srv := newTestCalDAVBackend(t)
+	srv.put("work", "standup-1.ics", testRecurringICS)
+	cal, _, err := b.getObject(context.Background(), "work", "standup-1.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = b.putObject(context.Background(), "work", "standup-1.ics", cal, "If-Match", `"stale"`)
+	if !errors.Is(err, ErrCalendarConflict) {
+		t.Fatalf("expected ErrCalendarConflict, got %v", err)
+	}
+}
+
+func TestCalDAVCreateRoundTrip(t *testing.T) {
+	b, srv := newTestCalDAVBackend(t)
+
+	created, err := b.CreateEvent(context.Background(), "personal", CreateEventInput{
+		Title:      "Yoga; evening, relaxed",
+		Start:      "2025-07-01T16:00:00Z",
+		End:        "2025-07-01T17:00:00Z",
+		Attendees:  []string{"carol@example.com"},
+		Recurrence: "FREQ=WEEKLY;BYDAY=TU",
+		TimeZone:   "Europe/Berlin",
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	if !strings.HasSuffix(created.ID, ".ics") || created.ETag == "" {
+		t.Errorf("created = %+v", created)
+	}
+
+	stored := srv.get("personal", created.ID).data
+	for _, want := range []string{
+		"DTSTART;TZID=Europe/Berlin:20250701T180000",
+		"RRULE:FREQ=WEEKLY;BYDAY=TU",
+		"TZID:Europe/Berlin",
+		"TZOFFSETTO:+0200",
+		`SUMMARY:Yoga\; evening\, relaxed`,
+	} {
+		if !strings.Contains(stored, want) {
+			t.Errorf("stored object missing %q:\n%s", want, stored)
+		}
+	}
+
+	got, err := b.GetEvent(context.Background(), "personal", created.ID)
+	if err != nil {
+		t.Fatalf("GetEvent: %v", err)
+	}
+	if got.Title != "Yoga; evening, relaxed" || got.Start != "2025-07-01T18:00:00+02:00" ||
+		got.Recurrence != "FREQ=WEEKLY;BYDAY=TU" || got.TimeZone != "Europe/Berlin" {
+		t.Errorf("round-tripped event = %+v", got)
+	}
+}
+
+func TestCalDAVCreateAllDay(t *testing.T) {
+	b, srv := newTestCalDAVBackend(t)
+	created, err := b.CreateEvent(context.Background(), "personal", CreateEventInput{
+		Title: "Holiday", Start: "2025-12-25T00:00:00Z", End: "2025-12-26T00:00:00Z", AllDay: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	if !created.AllDay {
+		t.Error("expected all-day event")
+	}
+	if stored := srv.get("personal", created.ID).data; !strings.Contains(stored, "DTSTART;VALUE=DATE:20251225") {
+		t.Errorf("stored object:\n%s", stored)
+	}
+}
+
+func TestCalDAVDeleteEvent(t *testing.T) {
+	b, srv := newTestCalDAVBackend(t)
+	srv.put("work", "standup-1.ics", testRecurringICS)
+
+	if err := b.DeleteEvent(context.Background(), "work", "standup-1.ics"); err != nil {
+		t.Fatalf("DeleteEvent: %v", err)
+	}
+	if err := b.DeleteEvent(context.Background(), "work", "standup-1.ics"); err == nil {
+		t.Fatal("expected not found on second delete")
+	}
+}
+
+func TestCalDAVRejectsPathTraversal(t *testing.T) {
+	b, _ := newTestCalDAVBackend(t)
+	if _, err := b.GetEvent(context.Background(), "..", "x.ics"); err == nil {
+		t.Error("expected error for calendar_id ..")
+	}
+	if _, err := b.GetEvent(context.Background(), "work", "../../other/x.ics"); err == nil {
+		t.Error("expected error for event_id with slashes")
+	}
+}
+
+func TestCalDAVThroughCalendarTool(t *testing.T) {
+	b, srv := newTestCalDAVBackend(t)
+	srv.put("work", "standup-1.ics", testRecurringICS)
+	tool := NewCalendarTool(b, time.Second, slog.Default())
+
+	result := execCalendarTool(t, tool, map[string]any{
+		"action": "get_event", "calendar_id": "work", "event_id": "standup-1.ics",
+	})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.Content)
+	}
+	if !strings.Contains(result.Content, "FREQ=WEEKLY") {
+		t.Errorf("content = %s", result.Content)
+	}
+}
+
+func TestNewCalDAVBackendValidation(t *testing.T) {
+	if _, err := NewCalDAVBackend(CalDAVConfig{}, slog.Default()); err == nil {
+		t.Error("expected error for empty url")
+	}
+	if _, err := NewCalDAVBackend(CalDAVConfig{URL: "not a url"}, slog.Default()); err == nil {
+		t.Error("expected error for invalid url")
+	}
+}
+
+func TestICalRoundTripFolding(t *testing.T) {
+	long := strings.Repeat("Überlänge ", 20)
+	cal := &icalComponent{Name: "VCALENDAR", Children: []*icalComponent{{
+		Name:  "VEVENT",
+		Props: []*icalProp{{Name: "SUMMARY", Value: icalEscape(long)}},
+	}}}
+	encoded := cal.encode()
+	for _, line := range strings.Split(strings.TrimSuffix(encoded, "\r\n"), "\r\n") {
+		if len(line) > icalMaxLineOctets {
+			t.Errorf("line exceeds %d octets: %q", icalMaxLineOctets, line)
+		}
+	}
+	parsed, err := parseICal(encoded)
+	if err != nil {
+		t.Fatalf("parseICal: %v", err)
+	}
+	if got := icalUnescape(parsed.Children[0].value("SUMMARY")); got != long {
+		t.Errorf("summary = %q", got)
+	}
+}
+
+func TestParseICalDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"PT1H30M": 90 * time.Minute,
+		"P1D":     24 * time.Hour,
+		"P1W":     7 * 24 * time.Hour,
+		"-PT15M":  -15 * time.Minute,
+		"P1DT2H":  26 * time.Hour,
+	}
+	for in, want := range tests {
+		got, err := parseICalDuration(in)
+		if err != nil || got != want {
+			t.Errorf("parseICalDuration(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseICalDuration("1H"); err == nil {
+		t.Error("expected error for invalid duration")
+	}
+}
@@ -0,0 +1,392 @@
+package tool
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Minimal iCalendar (RFC 5545) model. Components keep every property they
+// were parsed with, in order, so that edits touch only the fields the agent
+// changed and RRULE, EXDATE, VTIMEZONE, VALARM etc. survive round-trips.
+
+const (
+	icalDateLayout     = "20060102"
+	icalDateTimeLayout = "20060102T150405"
+	icalUTCLayout      = "20060102T150405Z"
+	icalMaxLineOctets  = 75
+)
+
+type icalParam struct {
+	Name  string
+	Value string
+}
+
+type icalProp struct {
+	Name   string
+	Params []icalParam
+	Value  string
+}
+
+type icalComponent struct {
+	Name     string
+	Props    []*icalProp
+	Children []*icalComponent
+}
+
+func (p *icalProp) param(name string) string {
+	for _, prm := range p.Params {
+		if strings.EqualFold(prm.Name, name) {
+			return prm.Value
+		}
+	}
+	return ""
+}
+
+func (c *icalComponent) prop(name string) *icalProp {
+	for _, p := range c.Props {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+func (c *icalComponent) propsNamed(name string) []*icalProp {
+	var out []*icalProp
+	for _, p := range c.Props {
+		if p.Name == name {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func (c *icalComponent) value(name string) string {
+	if p := c.prop(name); p != nil {
+		return p.Value
+	}
+	return ""
+}
+
+// set replaces the first property called name (keeping its position) and
+// drops any duplicates, or appends it if absent.
+func (c *icalComponent) set(p *icalProp) {
+	out := c.Props[:0]
+	replaced := false
+	for _, old := range c.Props {
+		if old.Name != p.Name {
+			out = append(out, old)
+			continue
+		}
+		if !replaced {
+			out = append(out, p)
+			replaced = true
+		}
+	}
+	c.Props = out
+	if !replaced {
+		c.Props = append(c.Props, p)
+	}
+}
+
+func (c *icalComponent) remove(name string) {
+	out := c.Props[:0]
+	for _, p := range c.Props {
+		if p.Name != name {
+			out = append(out, p)
+		}
+	}
+	c.Props = out
+}
+
+func (c *icalComponent) children(name string) []*icalComponent {
+	var out []*icalComponent
+	for _, ch := range c.Children {
+		if ch.Name == name {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+// parseICal parses an iCalendar stream and returns its VCALENDAR component.
+func parseICal(data string) (*icalComponent, error) {
+	var stack []*icalComponent
+	var root *icalComponent
+	for _, line := range unfoldICal(data) {
+		if line == "" {
+			continue
+		}
+		p, err := parseICalLine(line)
+		if err != nil {
+			return nil, err
+		}
+		switch p.Name {
+		case "BEGIN":
+			comp := &icalComponent{Name: strings.ToUpper(p.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, comp)
+			}
+			stack = append(stack, comp)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return nil, fmt.Errorf("ical: unexpected END:%s", p.Value)
+			}
+			done := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				root = done
+			}
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("ical: property %s outside component", p.Name)
+			}
+			cur := stack[len(stack)-1]
+			cur.Props = append(cur.Props, p)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("ical: unterminated %s", stack[len(stack)-1].Name)
+	}
+	if root == nil || root.Name != "VCALENDAR" {
+		return nil, fmt.Errorf("ical: missing VCALENDAR")
+	}
+	return root, nil
+}
+
+func unfoldICal(data string) []string {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	var lines []string
+	for _, l := range strings.Split(data, "\n") {
+		if (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+// parseICalLine splits "NAME;P1=V1;P2="V:2":value" honouring quoted params.
+func parseICalLine(line string) (*icalProp, error) {
+	inQuote := false
+	var fields []string
+	start := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			inQuote = !inQuote
+		case ';':
+			if !inQuote {
+				fields = append(fields, line[start:i])
+				start = i + 1
+			}
+		case ':':
+			if !inQuote {
+				fields = append(fields, line[start:i])
+				p := &icalProp{Name: strings.ToUpper(fields[0]), Value: line[i+1:]}
+				for _, f := range fields[1:] {
+					k, v, _ := strings.Cut(f, "=")
+					p.Params = append(p.Params, icalParam{Name: strings.ToUpper(k), Value: strings.Trim(v, `"`)})
+				}
+				return p, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("ical: malformed line %q", line)
+}
+
+func (c *icalComponent) encode() string {
+	var b strings.Builder
+	c.encodeTo(&b)
+	return b.String()
+}
+
+func (c *icalComponent) encodeTo(b *strings.Builder) {
+	writeICalLine(b, "BEGIN:"+c.Name)
+	for _, p := range c.Props {
+		var line strings.Builder
+		line.WriteString(p.Name)
+		for _, prm := range p.Params {
+			line.WriteString(";" + prm.Name + "=")
+			if strings.ContainsAny(prm.Value, ":;,") {
+				line.WriteString(`"` + prm.Value + `"`)
+			} else {
+				line.WriteString(prm.Value)
+			}
+		}
+		line.WriteString(":" + p.Value)
+		writeICalLine(b, line.String())
+	}
+	for _, ch := range c.Children {
+		ch.encodeTo(b)
+	}
+	writeICalLine(b, "END:"+c.Name)
+}
+
+// writeICalLine folds long lines at 75 octets without splitting UTF-8 runes.
+func writeICalLine(b *strings.Builder, line string) {
+	limit := icalMaxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = icalMaxLineOctets - 1
+	}
+	b.WriteString(line + "\r\n")
+}
+
+var icalTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+var icalTextUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func icalEscape(s string) string   { return icalTextEscaper.Replace(s) }
+func icalUnescape(s string) string { return icalTextUnescaper.Replace(s) }
+
+// parseICalTime interprets a DTSTART/DTEND style property.
+func parseICalTime(p *icalProp) (t time.Time, allDay bool, err error) {
+	v := p.Value
+	if strings.EqualFold(p.param("VALUE"), "DATE") || len(v) == len(icalDateLayout) {
+		t, err = time.Parse(icalDateLayout, v)
+		return t, true, err
+	}
+	if strings.HasSuffix(v, "Z") {
+		t, err = time.Parse(icalUTCLayout, v)
+		return t, false, err
+	}
+	loc := time.UTC
+	if tzid := p.param("TZID"); tzid != "" {
+		if l, lerr := time.LoadLocation(tzid); lerr == nil {
+			loc = l
+		}
+	}
+	t, err = time.ParseInLocation(icalDateTimeLayout, v, loc)
+	return t, false, err
+}
+
+// icalTimeProp renders t as a property. Timed values are written in tzid's
+// local time when it is a known zone, or in UTC otherwise.
+func icalTimeProp(name string, t time.Time, allDay bool, tzid string) *icalProp {
+	if allDay {
+		return &icalProp{Name: name, Params: []icalParam{{Name: "VALUE", Value: "DATE"}}, Value: t.Format(icalDateLayout)}
+	}
+	if tzid != "" {
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			return &icalProp{Name: name, Params: []icalParam{{Name: "TZID", Value: tzid}}, Value: t.In(loc).Format(icalDateTimeLayout)}
+		}
+	}
+	return &icalProp{Name: name, Value: t.UTC().Format(icalUTCLayout)}
+}
+
+// parseICalDuration parses RFC 5545 durations such as "PT1H30M" or "P1D".
+func parseICalDuration(s string) (time.Duration, error) {
+	orig := s
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg, s = true, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("ical: invalid duration %q", orig)
+	}
+	s = s[1:]
+	var d time.Duration
+	inTime := false
+	num := ""
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			num += string(r)
+		case r == 'T':
+			inTime = true
+		default:
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return 0, fmt.Errorf("ical: invalid duration %q", orig)
+			}
+			num = ""
+			switch {
+			case r == 'W' && !inTime:
+				d += time.Duration(n) * 7 * 24 * time.Hour
+			case r == 'D' && !inTime:
+				d += time.Duration(n) * 24 * time.Hour
+			case r == 'H' && inTime:
+				d += time.Duration(n) * time.Hour
+			case r == 'M' && inTime:
+				d += time.Duration(n) * time.Minute
+			case r == 'S' && inTime:
+				d += time.Duration(n) * time.Second
+			default:
+				return 0, fmt.Errorf("ical: invalid duration %q", orig)
+			}
+		}
+	}
+	if num != "" {
+		return 0, fmt.Errorf("ical: invalid duration %q", orig)
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+// icalTimezone builds a VTIMEZONE for tzid covering the offset changes in
+// the given year, derived from the Go time zone database.
+func icalTimezone(tzid string, year int) (*icalComponent, error) {
+	loc, err := time.LoadLocation(tzid)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", tzid)
+	}
+	vtz := &icalComponent{Name: "VTIMEZONE", Props: []*icalProp{{Name: "TZID", Value: tzid}}}
+
+	start := time.Date(year, 1, 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(1, 0, 0)
+	_, prevOff := start.Zone()
+	for t := start; t.Before(end); t = t.Add(time.Hour) {
+		name, off := t.Zone()
+		if off == prevOff {
+			continue
+		}
+		// Transitions land on the hour in practice; t is the first instant
+		// observed with the new offset.
+		kind := "STANDARD"
+		if t.IsDST() {
+			kind = "DAYLIGHT"
+		}
+		// DTSTART is the wall-clock time of the change under the old offset.
+		local := t.UTC().Add(time.Duration(prevOff) * time.Second)
+		vtz.Children = append(vtz.Children, &icalComponent{Name: kind, Props: []*icalProp{
+			{Name: "DTSTART", Value: local.Format(icalDateTimeLayout)},
+			{Name: "TZOFFSETFROM", Value: formatICalOffset(prevOff)},
+			{Name: "TZOFFSETTO", Value: formatICalOffset(off)},
+			{Name: "TZNAME", Value: name},
+		}})
+		prevOff = off
+	}
+	if len(vtz.Children) == 0 {
+		name, off := start.Zone()
+		vtz.Children = append(vtz.Children, &icalComponent{Name: "STANDARD", Props: []*icalProp{
+			{Name: "DTSTART", Value: "19700101T000000"},
+			{Name: "TZOFFSETFROM", Value: formatICalOffset(off)},
+			{Name: "TZOFFSETTO", Value: formatICalOffset(off)},
+			{Name: "TZNAME", Value: name},
+		}})
+	}
+	return vtz, nil
+}
+
+func formatICalOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, (seconds%3600)/60)
+}
@@ -14,9 +14,10 @@ import (
 // --- test backend ---
 
 type testCalendarBackend struct {
-	calendars []CalendarInfo
-	events    map[string][]CalendarEvent
-	nextID    int
+	calendars  []CalendarInfo
+	events     map[string][]CalendarEvent
+	nextID     int
+	lastUpdate UpdateEventInput
 
 	listCalendarsErr error
 	listEventsErr    error
@@ -75,6 +76,7 @@ func (b *testCalendarBackend) CreateEvent(_ context.Context, calID string, input
 }
 
 func (b *testCalendarBackend) UpdateEvent(_ context.Context, calID, evtID string, update UpdateEventInput) (*CalendarEvent, error) {
+	b.lastUpdate = update
 	if b.updateEventErr != nil {
 		return nil, b.updateEventErr
 	}
@@ -292,6 +294,53 @@ func TestCalendarToolCreateEventMissingFields(t *testing.T) {
 	}
 }
 
+func TestCalendarToolUpdateEventClearsRecurrence(t *testing.T) {
+	tool, backend := newTestCalendarTool(t)
+	backend.events["cal-1"] = []CalendarEvent{{ID: "e1", Title: "Standup", Recurrence: "FREQ=DAILY"}}
+	result := execCalendarTool(t, tool, map[string]any{
+		"action": "update_event", "calendar_id": "cal-1", "event_id": "e1",
+		"recurrence": "",
+	})
+	if result.IsError {
+		t.Fatalf("expected success: %s", result.Content)
+	}
+	if r := backend.lastUpdate.Recurrence; r == nil || *r != "" {
+		t.Errorf("Recurrence = %v, want an empty rule that removes it", r)
+	}
+}
+
+func TestCalendarToolRejectsICalInjection(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]any
+		want   string
+	}{
+		{"recurrence line break", map[string]any{"recurrence": "FREQ=DAILY\r\nATTENDEE:mailto:x@evil.example"}, "RRULE"},
+		{"recurrence property", map[string]any{"recurrence": "FREQ=DAILY:ATTENDEE"}, "RRULE"},
+		{"attendee line break", map[string]any{"attendees": []string{"a@example.com\r\nORGANIZER:mailto:x@evil.example"}}, "control characters"},
+		{"attendee not an address", map[string]any{"attendees": []string{"Alice <a@example.com>"}}, "plain email address"},
+	}
+	for _, tt := range tests {
+		for _, action := range []string{"create_event", "update_event"} {
+			t.Run(tt.name+"/"+action, func(t *testing.T) {
+				tool, backend := newTestCalendarTool(t)
+				backend.events["cal-1"] = []CalendarEvent{{ID: "e1", Title: "Old"}}
+				params := map[string]any{
+					"action": action, "calendar_id": "cal-1", "event_id": "e1",
+					"title": "t", "start": "2025-01-01T10:00:00Z", "end": "2025-01-01T11:00:00Z",
+				}
+				for k, v := range tt.params {
+					params[k] = v
+				}
+				result := execCalendarTool(t, tool, params)
+				if !result.IsError || !strings.Contains(result.Content, tt.want) {
+					t.Errorf("expected %q error, got %q", tt.want, result.Content)
+				}
+			})
+		}
+	}
+}
+
 func TestCalendarToolCreateEventInvalidTime(t *testing.T) {
 	tool, _ := newTestCalendarTool(t)
 	result := execCalendarTool(t, tool, map[string]any{
@@ -23,7 +23,7 @@ func NewCronTool(manager *cronjob.Manager, logger *slog.Logger) *CronTool {
 
 func (t *CronTool) Name() string { return "cron" }
 func (t *CronTool) Description() string {
-	return "Create, list, update, and delete scheduled cron jobs. Jobs execute agent messages on a schedule (one-shot, interval, or cron expression)."
+	return "Create, list, update, and delete scheduled cron jobs. On a schedule (one-shot, interval, or cron expression) a job runs an agent message, a workflow, a tool, or sends a notification, and can post the result to a channel."
 }
 
 func (t *CronTool) Schema() domain.ToolSchema {
@@ -65,20 +65,77 @@ func (t *CronTool) Schema() domain.ToolSchema {
 						"expression": {
 							"type": "string",
 							"description": "Cron expression (e.g. '*/5 * * * *' for every 5 minutes)"
+						},
+						"time_zone": {
+							"type": "string",
+							"description": "IANA time zone for the cron expression (e.g. 'America/New_York'); default server local time"
 						}
 					}
 				},
+				"kind": {
+					"type": "string",
+					"enum": ["agent_run", "workflow_run", "tool_call", "notify"],
+					"description": "What the job does (default agent_run). On update, replaces the whole action."
+				},
 				"message": {
 					"type": "string",
-					"description": "Message to send to the agent when the job fires"
+					"description": "agent_run: message to send to the agent. notify: Go text/template for the notification, e.g. 'Standup at {{.Now.Format \"15:04\"}}' ({{.Job.Name}} is also available)"
 				},
 				"agent_id": {
 					"type": "string",
 					"description": "Target agent ID (optional, defaults to main agent)"
 				},
+				"workflow": {
+					"type": "string",
+					"description": "Pipeline name (workflow_run)"
+				},
+				"args": {
+					"type": "object",
+					"additionalProperties": {"type": "string"},
+					"description": "Pipeline arguments (workflow_run)"
+				},
+				"tool": {
+					"type": "string",
+					"description": "Tool name (tool_call)"
+				},
+				"params": {
+					"type": "object",
+					"description": "Tool parameters (tool_call)"
+				},
 				"channel": {
 					"type": "string",
-					"description": "Target channel (optional)"
+					"description": "Channel that receives the result, e.g. 'slack' or 'telegram' (required for notify)"
+				},
+				"target": {
+					"type": "string",
+					"description": "Chat, channel or user ID on the channel"
+				},
+				"retry": {
+					"type": "object",
+					"properties": {
+						"max_attempts": {"type": "integer", "description": "Attempts including the first (max 10)"},
+						"backoff_ms": {"type": "integer", "description": "Delay before the first retry, doubled after each (default 1000)"},
+						"max_backoff_ms": {"type": "integer", "description": "Cap on the delay (default 60000)"}
+					},
+					"description": "Retry a failed run with exponential backoff"
+				},
+				"misfire": {
+					"type": "string",
+					"enum": ["skip", "run_once", "run_all"],
+					"description": "Runs missed while the server was down: skip them (default), run once, or run each up to max_catch_up"
+				},
+				"max_catch_up": {
+					"type": "integer",
+					"description": "Most missed runs to make up with misfire 'run_all' (default 10, max 100)"
+				},
+				"concurrency": {
+					"type": "string",
+					"enum": ["allow", "forbid", "replace"],
+					"description": "When the previous run is still going: run anyway (default), skip the new run, or cancel the previous one"
+				},
+				"jitter_ms": {
+					"type": "integer",
+					"description": "Random delay of up to this many milliseconds added to each run"
 				},
 				"enabled": {
 					"type": "boolean",
@@ -95,15 +152,46 @@ func (t *CronTool) Schema() domain.ToolSchema {
 }
 
 type cronParams struct {
-	Action   string               `json:"action"`
-	JobID    string               `json:"job_id"`
-	Name     string               `json:"name"`
-	Schedule *domain.CronSchedule `json:"schedule,omitempty"`
-	Message  string               `json:"message"`
-	AgentID  string               `json:"agent_id"`
-	Channel  string               `json:"channel"`
-	Enabled  *bool                `json:"enabled,omitempty"`
-	Limit    int                  `json:"limit"`
+	Action   string                  `json:"action"`
+	JobID    string                  `json:"job_id"`
+	Name     string                  `json:"name"`
+	Schedule *domain.CronSchedule    `json:"schedule,omitempty"`
+	Kind     string                  `json:"kind"`
+	Message  string                  `json:"message"`
+	AgentID  string                  `json:"agent_id"`
+	Workflow string                  `json:"workflow"`
+	Args     map[string]string       `json:"args,omitempty"`
+	Tool     string                  `json:"tool"`
+	Params   json.RawMessage         `json:"params,omitempty"`
+	Channel  string                  `json:"channel"`
+	Target   string                  `json:"target"`
+	Retry    *domain.CronRetryPolicy `json:"retry,omitempty"`
+	Enabled  *bool                   `json:"enabled,omitempty"`
+	Limit    int                     `json:"limit"`
+
+	Misfire     string `json:"misfire"`
+	MaxCatchUp  *int   `json:"max_catch_up,omitempty"`
+	Concurrency string `json:"concurrency"`
+	JitterMs    *int64 `json:"jitter_ms,omitempty"`
+}
+
+// cronAction builds the job action from the flat tool parameters.
+func (p cronParams) cronAction() domain.CronAction {
+	kind := p.Kind
+	if kind == "" {
+		kind = domain.CronActionAgentRun
+	}
+	return domain.CronAction{
+		Kind:     kind,
+		AgentID:  p.AgentID,
+		Channel:  p.Channel,
+		Target:   p.Target,
+		Message:  p.Message,
+		Workflow: p.Workflow,
+		Args:     p.Args,
+		Tool:     p.Tool,
+		Params:   p.Params,
+	}
 }
 
 func (t *CronTool) Execute(ctx context.Context, params json.RawMessage) (*domain.ToolResult, error) {
@@ -123,19 +211,20 @@ func (t *CronTool) handleCreate(ctx context.Context, p cronParams) (any, error)
 	if p.Schedule == nil {
 		return nil, fmt.Errorf("'schedule' is required for create action")
 	}
-	if err := RequireField("message", p.Message); err != nil {
-		return nil, err
-	}
 
 	job := domain.CronJob{
 		Name:     p.Name,
 		Schedule: *p.Schedule,
-		Action: domain.CronAction{
-			Kind:    "agent_run",
-			AgentID: p.AgentID,
-			Channel: p.Channel,
-			Message: p.Message,
-		},
+		Action:   p.cronAction(),
+		Retry:       p.Retry,
+		Misfire:     p.Misfire,
+		Concurrency: p.Concurrency,
+	}
+	if p.MaxCatchUp != nil {
+		job.MaxCatchUp = *p.MaxCatchUp
+	}
+	if p.JitterMs != nil {
+		job.JitterMs = *p.JitterMs
 	}
 
 	return t.manager.Create(ctx, job)
@@ -158,8 +247,21 @@ func (t *CronTool) handleUpdate(ctx context.Context, p cronParams) (any, error)
 	}
 
 	patch := cronjob.Patch{
-		Schedule: p.Schedule,
-		Enabled:  p.Enabled,
+		Schedule:   p.Schedule,
+		Retry:      p.Retry,
+		Enabled:    p.Enabled,
+		MaxCatchUp: p.MaxCatchUp,
+		JitterMs:   p.JitterMs,
+	}
+	if p.Misfire != "" {
+		patch.Misfire = &p.Misfire
+	}
+	if p.Concurrency != "" {
+		patch.Concurrency = &p.Concurrency
+	}
+	if p.Kind != "" {
+		action := p.cronAction()
+		patch.Action = &action
 	}
 	if p.Name != "" {
 		patch.Name = &p.Name
@@ -219,3 +219,43 @@ func TestCronToolInvalidParams(t *testing.T) {
 		t.Error("expected error for invalid JSON")
 	}
 }
+
+func TestCronToolCreateNotify(t *testing.T) {
+	ct := newTestCronTool(t)
+
+	result := execCronTool(t, ct, map[string]any{
+		"action":   "create",
+		"name":     "standup",
+		"kind":     "notify",
+		"message":  "Standup in 5 minutes",
+		"channel":  "slack",
+		"target":   "C123",
+		"schedule": map[string]any{"kind": "cron", "expression": "55 8 * * 1-5"},
+		"retry":    map[string]any{"max_attempts": 3, "backoff_ms": 5000},
+	})
+	if result.IsError {
+		t.Fatalf("create failed: %s", result.Content)
+	}
+
+	var job domain.CronJob
+	if err := json.Unmarshal([]byte(result.Content), &job); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if job.Action.Kind != domain.CronActionNotify || job.Action.Target != "C123" {
+		t.Errorf("got action %+v", job.Action)
+	}
+	if job.Retry == nil || job.Retry.MaxAttempts != 3 {
+		t.Errorf("got retry %+v", job.Retry)
+	}
+
+	// notify needs a channel.
+	result = execCronTool(t, ct, map[string]any{
+		"action":   "create",
+		"kind":     "notify",
+		"message":  "hi",
+		"schedule": map[string]any{"kind": "every", "every_ms": 60000},
+	})
+	if !result.IsError {
+		t.Error("expected error for notify without channel")
+	}
+}
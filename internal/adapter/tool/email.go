@@ -32,13 +32,22 @@ type EmailSummary struct {
 
 // EmailMessage is a full email with body.
 type EmailMessage struct {
-	ID      string   `json:"id"`
-	From    string   `json:"from"`
-	To      []string `json:"to"`
-	CC      []string `json:"cc,omitempty"`
-	Subject string   `json:"subject"`
-	Body    string   `json:"body"`
-	Date    string   `json:"date"`
+	ID          string            `json:"id"`
+	MessageID   string            `json:"message_id,omitempty"` // RFC 5322 Message-ID header
+	From        string            `json:"from"`
+	To          []string          `json:"to"`
+	CC          []string          `json:"cc,omitempty"`
+	Subject     string            `json:"subject"`
+	Body        string            `json:"body"`
+	Date        string            `json:"date"`
+	Attachments []EmailAttachment `json:"attachments,omitempty"`
+}
+
+// EmailAttachment describes a file attached to an email (content is not returned).
+type EmailAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
 }
 
 // EmailDraft represents a draft email.
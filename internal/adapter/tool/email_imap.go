@@ -0,0 +1,621 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Email transport security modes.
+const (
+	EmailTLSImplicit = "tls"      // TLS from the first byte (IMAPS 993, SMTPS 465)
+	EmailTLSStartTLS = "starttls" // plaintext upgraded via STARTTLS (IMAP 143, submission 587)
+	EmailTLSNone     = "none"     // plaintext; only for local/testing servers
+)
+
+const (
+	defaultEmailFolder       = "INBOX"
+	defaultEmailDraftsFolder = "Drafts"
+	defaultEmailPerPage      = 20
+	maxEmailPerPage          = 100
+	maxIMAPLiteralSize       = 25 * 1024 * 1024 // 25MB
+)
+
+// IMAPSMTPConfig configures the IMAP/SMTP email backend.
+type IMAPSMTPConfig struct {
+	IMAPAddr     string        // host:port of the IMAP server
+	IMAPTLS      string        // "tls" (default), "starttls", or "none"
+	SMTPAddr     string        // host:port of the SMTP submission server
+	SMTPTLS      string        // "starttls" (default), "tls", or "none"
+	Username     string        // login for both IMAP and SMTP
+	Password     string        // password or app-specific password
+	From         string        // sender address; defaults to Username
+	DraftsFolder string        // IMAP folder for drafts; defaults to "Drafts"
+	Timeout      time.Duration // per-operation deadline
+	TLSConfig    *tls.Config   // optional; overrides the default TLS config
+}
+
+// IMAPSMTPBackend implements EmailBackend using IMAP for reading and SMTP for sending.
+// Each operation opens its own short-lived connection, so the backend is safe
+// for concurrent use and never holds an idle session open.
+type IMAPSMTPBackend struct {
+	cfg    IMAPSMTPConfig
+	logger *slog.Logger
+}
+
+// NewIMAPSMTPBackend creates an email backend that talks to real mail servers.
+func NewIMAPSMTPBackend(cfg IMAPSMTPConfig, logger *slog.Logger) (*IMAPSMTPBackend, error) {
+	if cfg.IMAPAddr == "" {
+		return nil, fmt.Errorf("imap address is required")
+	}
+	if cfg.SMTPAddr == "" {
+		return nil, fmt.Errorf("smtp address is required")
+	}
+	if cfg.Username == "" {
+		return nil, fmt.Errorf("email username is required")
+	}
+	if cfg.IMAPTLS == "" {
+		cfg.IMAPTLS = EmailTLSImplicit
+	}
+	if cfg.SMTPTLS == "" {
+		cfg.SMTPTLS = EmailTLSStartTLS
+	}
+	for _, mode := range []string{cfg.IMAPTLS, cfg.SMTPTLS} {
+		switch mode {
+		case EmailTLSImplicit, EmailTLSStartTLS, EmailTLSNone:
+		default:
+			return nil, fmt.Errorf("unknown email tls mode %q", mode)
+		}
+	}
+	if cfg.From == "" {
+		cfg.From = cfg.Username
+	}
+	if cfg.DraftsFolder == "" {
+		cfg.DraftsFolder = defaultEmailDraftsFolder
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &IMAPSMTPBackend{cfg: cfg, logger: logger}, nil
+}
+
+// List returns the newest messages in a folder, one page at a time.
+func (b *IMAPSMTPBackend) List(ctx context.Context, opts ListEmailsOpts) ([]EmailSummary, error) {
+	folder := imapFolderName(opts.Folder)
+	perPage := opts.PerPage
+	if perPage <= 0 {
+		perPage = defaultEmailPerPage
+	}
+	if perPage > maxEmailPerPage {
+		perPage = maxEmailPerPage
+	}
+	page := opts.Page
+	if page <= 0 {
+		page = 1
+	}
+
+	var out []EmailSummary
+	err := b.withIMAP(ctx, func(c *imapConn) error {
+		if err := c.selectFolder(folder, true); err != nil {
+			return err
+		}
+		uids, err := c.uidSearch("ALL")
+		if err != nil {
+			return err
+		}
+		start := (page - 1) * perPage
+		if start >= len(uids) {
+			return nil
+		}
+		end := min(start+perPage, len(uids))
+		out, err = c.fetchSummaries(folder, uids[start:end])
+		return err
+	})
+	return out, err
+}
+
+// Read fetches a full message including its text body and attachment list.
+func (b *IMAPSMTPBackend) Read(ctx context.Context, id string) (*EmailMessage, error) {
+	folder, uid, err := parseEmailID(id)
+	if err != nil {
+		return nil, err
+	}
+	var msg *EmailMessage
+	err = b.withIMAP(ctx, func(c *imapConn) error {
+		if err := c.selectFolder(folder, true); err != nil {
+			return err
+		}
+		raw, err := c.fetchSection(uid, "BODY.PEEK[]", "BODY[]")
+		if err != nil {
+			return err
+		}
+		msg, err = parseEmailMessage(emailID(folder, uid), raw)
+		return err
+	})
+	return msg, err
+}
+
+// Search runs a full-text IMAP search in the inbox, newest first.
+func (b *IMAPSMTPBackend) Search(ctx context.Context, query string, limit int) ([]EmailSummary, error) {
+	q, err := imapQuote(query)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultEmailPerPage
+	}
+	if limit > maxEmailPerPage {
+		limit = maxEmailPerPage
+	}
+
+	var out []EmailSummary
+	err = b.withIMAP(ctx, func(c *imapConn) error {
+		if err := c.selectFolder(defaultEmailFolder, true); err != nil {
+			return err
+		}
+		uids, err := c.uidSearch("CHARSET UTF-8 TEXT " + q)
+		if err != nil {
+			return err
+		}
+		if len(uids) > limit {
+			uids = uids[:limit]
+		}
+		out, err = c.fetchSummaries(defaultEmailFolder, uids)
+		return err
+	})
+	return out, err
+}
+
+// Draft stores a draft in the drafts folder via IMAP APPEND.
+func (b *IMAPSMTPBackend) Draft(ctx context.Context, to, subject, body string, cc []string) (*EmailDraft, error) {
+	out := outgoingEmail{From: b.cfg.From, To: []string{to}, CC: cc, Subject: subject, Body: body}
+	raw, messageID, err := out.build(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	draft := &EmailDraft{ID: messageID, To: []string{to}, CC: cc, Subject: subject, Body: body}
+	err = b.withIMAP(ctx, func(c *imapConn) error {
+		uid, err := c.appendMessage(b.cfg.DraftsFolder, `(\Draft)`, raw)
+		if err != nil {
+			return err
+		}
+		if uid > 0 {
+			draft.ID = emailID(b.cfg.DraftsFolder, uid)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// Send delivers a new message over SMTP.
+func (b *IMAPSMTPBackend) Send(ctx context.Context, to, subject, body string, cc []string) (*EmailSendResult, error) {
+	out := outgoingEmail{From: b.cfg.From, To: []string{to}, CC: cc, Subject: subject, Body: body}
+	return b.deliver(ctx, out)
+}
+
+// Reply answers a message, keeping it in the same thread through the
+// In-Reply-To and References headers.
+func (b *IMAPSMTPBackend) Reply(ctx context.Context, messageID, body string) (*EmailSendResult, error) {
+	folder, uid, err := parseEmailID(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	var orig *emailHeaders
+	err = b.withIMAP(ctx, func(c *imapConn) error {
+		if err := c.selectFolder(folder, true); err != nil {
+			return err
+		}
+		raw, err := c.fetchSection(uid, "BODY.PEEK[HEADER]", "BODY[HEADER]")
+		if err != nil {
+			return err
+		}
+		orig, err = parseEmailHeaders(raw)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return b.deliver(ctx, orig.reply(b.cfg.From, body))
+}
+
+func (b *IMAPSMTPBackend) deliver(ctx context.Context, out outgoingEmail) (*EmailSendResult, error) {
+	raw, id, err := out.build(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	rcpts := append(append([]string{}, out.To...), out.CC...)
+	if err := b.sendSMTP(ctx, rcpts, raw); err != nil {
+		return nil, err
+	}
+	b.logger.Debug("email delivered", "message_id", id, "recipients", len(rcpts))
+	return &EmailSendResult{MessageID: id, Status: "sent"}, nil
+}
+
+func (b *IMAPSMTPBackend) tlsConfig(addr string) *tls.Config {
+	if b.cfg.TLSConfig != nil {
+		return b.cfg.TLSConfig.Clone()
+	}
+	host, _, _ := net.SplitHostPort(addr)
+	return &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+}
+
+// dial opens a TCP (or implicit TLS) connection bounded by the context and
+// the configured timeout.
+func (b *IMAPSMTPBackend) dial(ctx context.Context, addr, mode string) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.cfg.Timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", addr, err)
+	}
+	if mode == EmailTLSImplicit {
+		tc := tls.Client(conn, b.tlsConfig(addr))
+		if err := tc.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("tls handshake %s: %w", addr, err)
+		}
+		conn = tc
+	}
+	return conn, nil
+}
+
+// withIMAP runs fn on an authenticated IMAP session and logs out afterwards.
+func (b *IMAPSMTPBackend) withIMAP(ctx context.Context, fn func(*imapConn) error) error {
+	conn, err := b.dial(ctx, b.cfg.IMAPAddr, b.cfg.IMAPTLS)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(b.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	// Unblock pending reads if the caller cancels mid-session.
+	var once sync.Once
+	closeConn := func() { once.Do(func() { conn.Close() }) }
+	stop := context.AfterFunc(ctx, closeConn)
+	defer stop()
+	defer closeConn()
+
+	c := newIMAPConn(conn)
+	if _, err := c.readLine(); err != nil {
+		return fmt.Errorf("imap greeting: %w", err)
+	}
+	if b.cfg.IMAPTLS == EmailTLSStartTLS {
+		if _, err := c.command("STARTTLS"); err != nil {
+			return fmt.Errorf("imap starttls: %w", err)
+		}
+		tc := tls.Client(conn, b.tlsConfig(b.cfg.IMAPAddr))
+		if err := tc.HandshakeContext(ctx); err != nil {
+			return fmt.Errorf("imap tls handshake: %w", err)
+		}
+		c.reset(tc)
+	}
+
+	user, err := imapQuote(b.cfg.Username)
+	if err != nil {
+		return err
+	}
+	pass, err := imapQuote(b.cfg.Password)
+	if err != nil {
+		return err
+	}
+	if _, err := c.command("LOGIN " + user + " " + pass); err != nil {
+		return fmt.Errorf("imap login: %w", err)
+	}
+
+	if err := fn(c); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	c.command("LOGOUT") //nolint:errcheck // best-effort
+	return nil
+}
+
+// --- minimal IMAP4rev1 client ---
+
+// imapConn speaks the subset of IMAP4rev1 needed by the email tool.
+type imapConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// imapResponse is one untagged server response with its literals pulled out.
+// Literals are keyed by the item name that precedes them, e.g. "BODY[HEADER]".
+type imapResponse struct {
+	Text     string
+	Literals map[string][]byte
+}
+
+func newIMAPConn(conn net.Conn) *imapConn {
+	return &imapConn{conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *imapConn) reset(conn net.Conn) {
+	c.conn = conn
+	c.r = bufio.NewReader(conn)
+}
+
+func (c *imapConn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+var imapLiteralRe = regexp.MustCompile(`\{(\d+)\}$`)
+
+// readResponse reads one logical response, following any {n} literals.
+func (c *imapConn) readResponse() (*imapResponse, error) {
+	resp := &imapResponse{}
+	var text strings.Builder
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		m := imapLiteralRe.FindStringSubmatchIndex(line)
+		if m == nil {
+			text.WriteString(line)
+			resp.Text = text.String()
+			return resp, nil
+		}
+		n, err := strconv.Atoi(line[m[2]:m[3]])
+		if err != nil || n > maxIMAPLiteralSize {
+			return nil, fmt.Errorf("imap literal too large")
+		}
+		prefix := strings.TrimRight(line[:m[0]], " ")
+		text.WriteString(prefix)
+		text.WriteString(" ")
+
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		key := prefix
+		if i := strings.LastIndexAny(prefix, " ("); i >= 0 {
+			key = prefix[i+1:]
+		}
+		if resp.Literals == nil {
+			resp.Literals = make(map[string][]byte)
+		}
+		resp.Literals[strings.ToUpper(key)] = buf
+	}
+}
+
+// command sends a tagged command and collects untagged responses until the
+// tagged completion. A NO or BAD completion becomes an error.
+func (c *imapConn) command(cmd string) ([]*imapResponse, error) {
+	tag, err := c.send(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return c.collect(tag)
+}
+
+func (c *imapConn) send(cmd string) (string, error) {
+	c.tag++
+	tag := "a" + strconv.Itoa(c.tag)
+	if _, err := io.WriteString(c.conn, tag+" "+cmd+"\r\n"); err != nil {
+		return "", err
+	}
+	return tag, nil
+}
+
+func (c *imapConn) collect(tag string) ([]*imapResponse, error) {
+	var out []*imapResponse
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if rest, ok := strings.CutPrefix(resp.Text, tag+" "); ok {
+			status, info, _ := strings.Cut(rest, " ")
+			if !strings.EqualFold(status, "OK") {
+				return nil, fmt.Errorf("imap %s: %s", strings.ToUpper(status), info)
+			}
+			out = append(out, &imapResponse{Text: rest})
+			return out, nil
+		}
+		out = append(out, resp)
+	}
+}
+
+func (c *imapConn) selectFolder(folder string, readOnly bool) error {
+	name, err := imapQuote(folder)
+	if err != nil {
+		return err
+	}
+	verb := "SELECT "
+	if readOnly {
+		verb = "EXAMINE "
+	}
+	if _, err := c.command(verb + name); err != nil {
+		return fmt.Errorf("select folder %q: %w", folder, err)
+	}
+	return nil
+}
+
+// uidSearch returns matching UIDs, newest (highest UID) first.
+func (c *imapConn) uidSearch(criteria string) ([]uint32, error) {
+	resps, err := c.command("UID SEARCH " + criteria)
+	if err != nil {
+		return nil, fmt.Errorf("imap search: %w", err)
+	}
+	var uids []uint32
+	for _, r := range resps {
+		rest, ok := strings.CutPrefix(r.Text, "* SEARCH")
+		if !ok {
+			continue
+		}
+		for _, f := range strings.Fields(rest) {
+			if n, err := strconv.ParseUint(f, 10, 32); err == nil {
+				uids = append(uids, uint32(n))
+			}
+		}
+	}
+	sort.Slice(uids, func(i, j int) bool { return uids[i] > uids[j] })
+	return uids, nil
+}
+
+var imapUIDRe = regexp.MustCompile(`\bUID (\d+)`)
+
+func fetchUID(r *imapResponse) uint32 {
+	m := imapUIDRe.FindStringSubmatch(r.Text)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.ParseUint(m[1], 10, 32)
+	return uint32(n)
+}
+
+// fetchSection fetches one body section of a single message.
+func (c *imapConn) fetchSection(uid uint32, item, key string) ([]byte, error) {
+	resps, err := c.command(fmt.Sprintf("UID FETCH %d (UID %s)", uid, item))
+	if err != nil {
+		return nil, fmt.Errorf("imap fetch: %w", err)
+	}
+	for _, r := range resps {
+		if fetchUID(r) != uid {
+			continue
+		}
+		if data, ok := r.Literals[key]; ok {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("message %d not found", uid)
+}
+
+// fetchSummaries fetches headers for uids and returns them in the same order.
+func (c *imapConn) fetchSummaries(folder string, uids []uint32) ([]EmailSummary, error) {
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	set := make([]string, len(uids))
+	for i, u := range uids {
+		set[i] = strconv.FormatUint(uint64(u), 10)
+	}
+	resps, err := c.command("UID FETCH " + strings.Join(set, ",") + " (UID BODY.PEEK[HEADER])")
+	if err != nil {
+		return nil, fmt.Errorf("imap fetch: %w", err)
+	}
+
+	byUID := make(map[uint32]EmailSummary, len(uids))
+	for _, r := range resps {
+		uid := fetchUID(r)
+		raw, ok := r.Literals["BODY[HEADER]"]
+		if uid == 0 || !ok {
+			continue
+		}
+		h, err := parseEmailHeaders(raw)
+		if err != nil {
+			continue
+		}
+		byUID[uid] = h.summary(emailID(folder, uid))
+	}
+
+	out := make([]EmailSummary, 0, len(byUID))
+	for _, u := range uids {
+		if s, ok := byUID[u]; ok {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+var imapAppendUIDRe = regexp.MustCompile(`\[APPENDUID \d+ (\d+)\]`)
+
+// appendMessage uploads raw into folder. It returns the new UID when the
+// server supports UIDPLUS, or 0 otherwise.
+func (c *imapConn) appendMessage(folder, flags string, raw []byte) (uint32, error) {
+	name, err := imapQuote(folder)
+	if err != nil {
+		return 0, err
+	}
+	tag, err := c.send(fmt.Sprintf("APPEND %s %s {%d}", name, flags, len(raw)))
+	if err != nil {
+		return 0, err
+	}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return 0, err
+		}
+		if strings.HasPrefix(line, "+") {
+			break
+		}
+		if rest, ok := strings.CutPrefix(line, tag+" "); ok {
+			return 0, fmt.Errorf("imap append: %s", rest)
+		}
+	}
+	if _, err := c.conn.Write(append(raw, '\r', '\n')); err != nil {
+		return 0, err
+	}
+	resps, err := c.collect(tag)
+	if err != nil {
+		return 0, fmt.Errorf("imap append: %w", err)
+	}
+	if m := imapAppendUIDRe.FindStringSubmatch(resps[len(resps)-1].Text); m != nil {
+		n, _ := strconv.ParseUint(m[1], 10, 32)
+		return uint32(n), nil
+	}
+	return 0, nil
+}
+
+// imapQuote renders s as an IMAP quoted string.
+func imapQuote(s string) (string, error) {
+	if strings.ContainsAny(s, "\r\n") {
+		return "", fmt.Errorf("value must not contain line breaks")
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`, nil
+}
+
+// imapFolderName maps friendly folder names to IMAP mailbox names.
+func imapFolderName(folder string) string {
+	if folder == "" || strings.EqualFold(folder, "inbox") {
+		return defaultEmailFolder
+	}
+	return folder
+}
+
+// emailID encodes a folder and UID into an opaque message ID ("INBOX:42").
+func emailID(folder string, uid uint32) string {
+	return folder + ":" + strconv.FormatUint(uint64(uid), 10)
+}
+
+func parseEmailID(id string) (string, uint32, error) {
+	folder, uidStr := defaultEmailFolder, id
+	if i := strings.LastIndex(id, ":"); i >= 0 {
+		folder, uidStr = imapFolderName(id[:i]), id[i+1:]
+	}
+	uid, err := strconv.ParseUint(uidStr, 10, 32)
+	if err != nil || uid == 0 {
+		return "", 0, fmt.Errorf("invalid message id %q (expected folder:uid)", id)
+	}
+	return folder, uint32(uid), nil
+}
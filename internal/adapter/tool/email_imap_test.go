@@ -0,0 +1,607 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// --- in-process IMAP stand-in ---
+
+type fakeIMAPMessage struct {
+	uid uint32
+	raw string
+}
+
+type fakeIMAPServer struct {
+	ln       net.Listener
+	user     string
+	pass     string
+	mu       sync.Mutex
+	folders  map[string][]fakeIMAPMessage
+	nextUID  uint32
+	commands []string
+}
+
+func newFakeIMAPServer(t *testing.T, user, pass string) *fakeIMAPServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeIMAPServer{ln: ln, user: user, pass: pass, folders: map[string][]fakeIMAPMessage{}, nextUID: 100}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeIMAPServer) addr() string { return s.ln.Addr().String() }
+
+func (s *fakeIMAPServer) add(folder, raw string) uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextUID++
+	s.folders[folder] = append(s.folders[folder], fakeIMAPMessage{uid: s.nextUID, raw: strings.ReplaceAll(raw, "\n", "\r\n")})
+	return s.nextUID
+}
+
+func (s *fakeIMAPServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+var fakeIMAPAppendRe = regexp.MustCompile(`^APPEND "([^"]+)" \S+ \{(\d+)\}$`)
+
+func (s *fakeIMAPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	reply := func(format string, args ...any) {
+		fmt.Fprintf(w, format+"\r\n", args...)
+		w.Flush()
+	}
+	reply("* OK fake IMAP ready")
+
+	authed := false
+	folder := ""
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		tag, cmd, _ := strings.Cut(line, " ")
+		s.mu.Lock()
+		s.commands = append(s.commands, cmd)
+		s.mu.Unlock()
+
+		verb := strings.ToUpper(strings.Fields(cmd)[0])
+		if verb == "UID" {
+			verb += " " + strings.ToUpper(strings.Fields(cmd)[1])
+		}
+		if !authed && verb != "LOGIN" && verb != "LOGOUT" {
+			reply("%s BAD not authenticated", tag)
+			continue
+		}
+
+		switch verb {
+		case "LOGIN":
+			if cmd == fmt.Sprintf("LOGIN %q %q", s.user, s.pass) {
+				authed = true
+				reply("%s OK logged in", tag)
+			} else {
+				reply("%s NO [AUTHENTICATIONFAILED] invalid credentials", tag)
+			}
+		case "SELECT", "EXAMINE":
+			name := strings.Trim(strings.TrimSpace(cmd[len(verb):]), `"`)
+			s.mu.Lock()
+			_, ok := s.folders[name]
+			s.mu.Unlock()
+			if !ok {
+				reply("%s NO no such mailbox", tag)
+				continue
+			}
+			folder = name
+			reply("%s OK [READ-ONLY] selected", tag)
+		case "UID SEARCH":
+			criteria := strings.TrimSpace(cmd[len("UID SEARCH"):])
+			var needle string
+			if i := strings.Index(criteria, `TEXT "`); i >= 0 {
+				needle = strings.ToLower(strings.TrimSuffix(criteria[i+len(`TEXT "`):], `"`))
+			}
+			var uids []string
+			s.mu.Lock()
+			for _, m := range s.folders[folder] {
+				if needle == "" || strings.Contains(strings.ToLower(m.raw), needle) {
+					uids = append(uids, strconv.FormatUint(uint64(m.uid), 10))
+				}
+			}
+			s.mu.Unlock()
+			reply("* SEARCH %s", strings.Join(uids, " "))
+			reply("%s OK search done", tag)
+		case "UID FETCH":
+			fields := strings.Fields(cmd)
+			want := map[string]bool{}
+			for _, u := range strings.Split(fields[2], ",") {
+				want[u] = true
+			}
+			headerOnly := strings.Contains(cmd, "BODY.PEEK[HEADER]")
+			s.mu.Lock()
+			for i, m := range s.folders[folder] {
+				if !want[strconv.FormatUint(uint64(m.uid), 10)] {
+					continue
+				}
+				data, item := m.raw, "BODY[]"
+				if headerOnly {
+					hdr, _, _ := strings.Cut(m.raw, "\r\n\r\n")
+					data, item = hdr+"\r\n\r\n", "BODY[HEADER]"
+				}
+				fmt.Fprintf(w, "* %d FETCH (UID %d %s {%d}\r\n%s)\r\n", i+1, m.uid, item, len(data), data)
+			}
+			s.mu.Unlock()
+			reply("%s OK fetch done", tag)
+		case "APPEND":
+			m := fakeIMAPAppendRe.FindStringSubmatch(cmd)
+			if m == nil {
+				reply("%s BAD bad append", tag)
+				continue
+			}
+			n, _ := strconv.Atoi(m[2])
+			reply("+ Ready for literal data")
+			buf := make([]byte, n)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return
+			}
+			r.ReadString('\n')
+			s.mu.Lock()
+			if _, ok := s.folders[m[1]]; !ok {
+				s.mu.Unlock()
+				reply("%s NO [TRYCREATE] no such mailbox", tag)
+				continue
+			}
+			s.nextUID++
+			uid := s.nextUID
+			s.folders[m[1]] = append(s.folders[m[1]], fakeIMAPMessage{uid: uid, raw: string(buf)})
+			s.mu.Unlock()
+			reply("%s OK [APPENDUID 1 %d] append done", tag, uid)
+		case "LOGOUT":
+			reply("* BYE logging out")
+			reply("%s OK logout done", tag)
+			return
+		default:
+			reply("%s BAD unknown command", tag)
+		}
+	}
+}
+
+// --- in-process SMTP stand-in ---
+
+type fakeSMTPMessage struct {
+	from  string
+	rcpts []string
+	data  string
+}
+
+type fakeSMTPServer struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	msgs []fakeSMTPMessage
+	auth []string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTPServer{ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) addr() string { return s.ln.Addr().String() }
+
+func (s *fakeSMTPServer) messages() []fakeSMTPMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeSMTPMessage(nil), s.msgs...)
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 fake SMTP ready")
+
+	var cur fakeSMTPMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		upper := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(upper, "EHLO"):
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(upper, "AUTH PLAIN"):
+			s.mu.Lock()
+			s.auth = append(s.auth, strings.TrimSpace(line[len("AUTH PLAIN"):]))
+			s.mu.Unlock()
+			reply("235 authenticated")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			cur = fakeSMTPMessage{from: strings.Trim(line[len("MAIL FROM:"):], "<>")}
+			reply("250 ok")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			cur.rcpts = append(cur.rcpts, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			reply("250 ok")
+		case upper == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			cur.data = data.String()
+			s.mu.Lock()
+			s.msgs = append(s.msgs, cur)
+			s.mu.Unlock()
+			reply("250 queued")
+		case upper == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unsupported")
+		}
+	}
+}
+
+// --- helpers ---
+
+func newTestIMAPSMTPBackend(t *testing.T) (*IMAPSMTPBackend, *fakeIMAPServer, *fakeSMTPServer) {
+	t.Helper()
+	imapSrv := newFakeIMAPServer(t, "alfred@example.com", "s3cret")
+	imapSrv.folders["INBOX"] = nil
+	imapSrv.folders["Drafts"] = nil
+	smtpSrv := newFakeSMTPServer(t)
+
+	b, err := NewIMAPSMTPBackend(IMAPSMTPConfig{
+		IMAPAddr: imapSrv.addr(),
+		IMAPTLS:  EmailTLSNone,
+		SMTPAddr: smtpSrv.addr(),
+		SMTPTLS:  EmailTLSNone,
+		Username: "alfred@example.com",
+		Password: "s3cret",
+		Timeout:  5 * time.Second,
+	}, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, imapSrv, smtpSrv
+}
+
+const testPlainEmail = `From: Bob <bob@example.com>
+To: alfred@example.com
+Subject: Lunch?
+Date: Mon, 02 Jan 2006 15:04:05 +0000
+Message-ID: <lunch-1@example.com>
+
+Are you free for lunch tomorrow?
+`
+
+const testMultipartEmail = `From: Carol <carol@example.com>
+Reply-To: carol-replies@example.com
+To: alfred@example.com
+Cc: dave@example.com
+Subject: =?utf-8?q?Quarterly_report_=E2=9C=93?=
+Date: Tue, 03 Jan 2006 10:00:00 +0000
+Message-ID: <report-2@example.com>
+References: <thread-0@example.com>
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="outer"
+
+--outer
+Content-Type: multipart/alternative; boundary="inner"
+
+--inner
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+Numbers look good =E2=80=94 see attached.
+--inner
+Content-Type: text/html; charset=utf-8
+
+<p>Numbers look good</p>
+--inner--
+--outer
+Content-Type: application/pdf; name="report.pdf"
+Content-Disposition: attachment; filename="report.pdf"
+Content-Transfer-Encoding: base64
+
+JVBERi0xLjQK
+--outer--
+`
+
+// --- tests ---
+
+func TestIMAPSMTPBackendList(t *testing.T) {
+	b, srv, _ := newTestIMAPSMTPBackend(t)
+	first := srv.add("INBOX", testPlainEmail)
+	second := srv.add("INBOX", testMultipartEmail)
+
+	emails, err := b.List(context.Background(), ListEmailsOpts{Folder: "inbox"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(emails) != 2 {
+		t.Fatalf("got %d emails, want 2", len(emails))
+	}
+	if emails[0].ID != emailID("INBOX", second) || emails[1].ID != emailID("INBOX", first) {
+		t.Errorf("expected newest first, got %s, %s", emails[0].ID, emails[1].ID)
+	}
+	if emails[0].Subject != "Quarterly report ✓" {
+		t.Errorf("subject not decoded: %q", emails[0].Subject)
+	}
+	if emails[1].Date != "2006-01-02T15:04:05Z" {
+		t.Errorf("date = %q", emails[1].Date)
+	}
+
+	page2, err := b.List(context.Background(), ListEmailsOpts{Page: 2, PerPage: 1})
+	if err != nil {
+		t.Fatalf("List page 2: %v", err)
+	}
+	if len(page2) != 1 || page2[0].ID != emailID("INBOX", first) {
+		t.Errorf("page 2 = %+v", page2)
+	}
+}
+
+func TestIMAPSMTPBackendListUnknownFolder(t *testing.T) {
+	b, _, _ := newTestIMAPSMTPBackend(t)
+	if _, err := b.List(context.Background(), ListEmailsOpts{Folder: "Archive"}); err == nil {
+		t.Fatal("expected error for unknown folder")
+	}
+}
+
+func TestIMAPSMTPBackendRead(t *testing.T) {
+	b, srv, _ := newTestIMAPSMTPBackend(t)
+	uid := srv.add("INBOX", testMultipartEmail)
+
+	msg, err := b.Read(context.Background(), emailID("INBOX", uid))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if msg.MessageID != "<report-2@example.com>" {
+		t.Errorf("message_id = %q", msg.MessageID)
+	}
+	if !strings.Contains(msg.Body, "Numbers look good — see attached.") {
+		t.Errorf("body = %q", msg.Body)
+	}
+	if len(msg.CC) != 1 || !strings.Contains(msg.CC[0], "dave@example.com") {
+		t.Errorf("cc = %v", msg.CC)
+	}
+	if len(msg.Attachments) != 1 {
+		t.Fatalf("attachments = %+v", msg.Attachments)
+	}
+	att := msg.Attachments[0]
+	if att.Filename != "report.pdf" || att.ContentType != "application/pdf" || att.Size != 9 {
+		t.Errorf("attachment = %+v", att)
+	}
+}
+
+func TestIMAPSMTPBackendReadInvalidID(t *testing.T) {
+	b, _, _ := newTestIMAPSMTPBackend(t)
+	if _, err := b.Read(context.Background(), "INBOX:abc"); err == nil {
+		t.Fatal("expected error for invalid id")
+	}
+}
+
+func TestIMAPSMTPBackendReadMissing(t *testing.T) {
+	b, _, _ := newTestIMAPSMTPBackend(t)
+	if _, err := b.Read(context.Background(), "INBOX:999"); err == nil {
+		t.Fatal("expected error for missing message")
+	}
+}
+
+func TestIMAPSMTPBackendSearch(t *testing.T) {
+	b, srv, _ := newTestIMAPSMTPBackend(t)
+	srv.add("INBOX", testPlainEmail)
+	uid := srv.add("INBOX", testMultipartEmail)
+
+	results, err := b.Search(context.Background(), "numbers", 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != emailID("INBOX", uid) {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+func TestIMAPSMTPBackendSearchRejectsNewlines(t *testing.T) {
+	b, _, _ := newTestIMAPSMTPBackend(t)
+	if _, err := b.Search(context.Background(), "x\r\na2 DELETE INBOX", 10); err == nil {
+		t.Fatal("expected error for injected newline")
+	}
+}
+
+func TestIMAPSMTPBackendDraft(t *testing.T) {
+	b, srv, _ := newTestIMAPSMTPBackend(t)
+
+	draft, err := b.Draft(context.Background(), "bob@example.com", "Hello", "Draft body", []string{"carol@example.com"})
+	if err != nil {
+		t.Fatalf("Draft: %v", err)
+	}
+	if !strings.HasPrefix(draft.ID, "Drafts:") {
+		t.Errorf("draft id = %q", draft.ID)
+	}
+
+	srv.mu.Lock()
+	stored := srv.folders["Drafts"]
+	srv.mu.Unlock()
+	if len(stored) != 1 {
+		t.Fatalf("drafts folder has %d messages", len(stored))
+	}
+	for _, want := range []string{"To: bob@example.com", "Cc: carol@example.com", "Subject: Hello", "Draft body"} {
+		if !strings.Contains(stored[0].raw, want) {
+			t.Errorf("draft missing %q:\n%s", want, stored[0].raw)
+		}
+	}
+}
+
+func TestIMAPSMTPBackendSend(t *testing.T) {
+	b, _, smtpSrv := newTestIMAPSMTPBackend(t)
+
+	res, err := b.Send(context.Background(), "bob@example.com", "Hi", "Line one\nLine two", []string{"carol@example.com"})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if res.Status != "sent" || !strings.HasSuffix(res.MessageID, "@example.com>") {
+		t.Errorf("result = %+v", res)
+	}
+
+	msgs := smtpSrv.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("smtp received %d messages", len(msgs))
+	}
+	m := msgs[0]
+	if m.from != "alfred@example.com" {
+		t.Errorf("from = %q", m.from)
+	}
+	if strings.Join(m.rcpts, ",") != "bob@example.com,carol@example.com" {
+		t.Errorf("rcpts = %v", m.rcpts)
+	}
+	for _, want := range []string{"Message-ID: " + res.MessageID, "Subject: Hi", "Line one\r\nLine two\r\n"} {
+		if !strings.Contains(m.data, want) {
+			t.Errorf("message missing %q:\n%s", want, m.data)
+		}
+	}
+	if len(smtpSrv.auth) != 1 {
+		t.Errorf("expected one AUTH PLAIN, got %d", len(smtpSrv.auth))
+	}
+}
+
+func TestIMAPSMTPBackendReplyThreads(t *testing.T) {
+	b, srv, smtpSrv := newTestIMAPSMTPBackend(t)
+	uid := srv.add("INBOX", testMultipartEmail)
+
+	if _, err := b.Reply(context.Background(), emailID("INBOX", uid), "Thanks!"); err != nil {
+		t.Fatalf("Reply: %v", err)
+	}
+
+	msgs := smtpSrv.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("smtp received %d messages", len(msgs))
+	}
+	m := msgs[0]
+	if len(m.rcpts) != 1 || m.rcpts[0] != "carol-replies@example.com" {
+		t.Errorf("reply should go to Reply-To, got %v", m.rcpts)
+	}
+	for _, want := range []string{
+		"In-Reply-To: <report-2@example.com>",
+		"References: <thread-0@example.com> <report-2@example.com>",
+		"Subject: =?utf-8?q?Re:_Quarterly_report_=E2=9C=93?=",
+	} {
+		if !strings.Contains(m.data, want) {
+			t.Errorf("reply missing %q:\n%s", want, m.data)
+		}
+	}
+}
+
+func TestIMAPSMTPBackendLoginFailure(t *testing.T) {
+	b, _, _ := newTestIMAPSMTPBackend(t)
+	b.cfg.Password = "wrong"
+	_, err := b.List(context.Background(), ListEmailsOpts{})
+	if err == nil || !strings.Contains(err.Error(), "imap login") {
+		t.Fatalf("expected login error, got %v", err)
+	}
+}
+
+func TestIMAPSMTPBackendThroughEmailTool(t *testing.T) {
+	b, srv, _ := newTestIMAPSMTPBackend(t)
+	uid := srv.add("INBOX", testPlainEmail)
+	tool := NewEmailTool(b, time.Second, 10, nil, slog.Default())
+
+	result := execEmailTool(t, tool, map[string]any{"action": "read", "id": emailID("INBOX", uid)})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.Content)
+	}
+	if !strings.Contains(result.Content, "Are you free for lunch tomorrow?") {
+		t.Errorf("content = %s", result.Content)
+	}
+}
+
+func TestNewIMAPSMTPBackendValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  IMAPSMTPConfig
+	}{
+		{"missing imap", IMAPSMTPConfig{SMTPAddr: "s:587", Username: "u"}},
+		{"missing smtp", IMAPSMTPConfig{IMAPAddr: "i:993", Username: "u"}},
+		{"missing username", IMAPSMTPConfig{IMAPAddr: "i:993", SMTPAddr: "s:587"}},
+		{"bad tls mode", IMAPSMTPConfig{IMAPAddr: "i:993", SMTPAddr: "s:587", Username: "u", IMAPTLS: "ssl"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewIMAPSMTPBackend(tt.cfg, slog.Default()); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestParseEmailID(t *testing.T) {
+	tests := []struct {
+		id     string
+		folder string
+		uid    uint32
+		ok     bool
+	}{
+		{"INBOX:42", "INBOX", 42, true},
+		{"inbox:42", "INBOX", 42, true},
+		{"42", "INBOX", 42, true},
+		{"Work:Projects:7", "Work:Projects", 7, true},
+		{"INBOX:0", "", 0, false},
+		{"INBOX:", "", 0, false},
+		{"draft-1", "", 0, false},
+	}
+	for _, tt := range tests {
+		folder, uid, err := parseEmailID(tt.id)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseEmailID(%q) err = %v, want ok=%v", tt.id, err, tt.ok)
+			continue
+		}
+		if tt.ok && (folder != tt.folder || uid != tt.uid) {
+			t.Errorf("parseEmailID(%q) = %q, %d", tt.id, folder, uid)
+		}
+	}
+}
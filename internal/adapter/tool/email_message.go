@@ -0,0 +1,222 @@
+package tool
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+const (
+	maxEmailBodySize  = 256 * 1024 // text returned to the LLM
+	maxEmailMIMEDepth = 8
+)
+
+var emailWordDecoder = &mime.WordDecoder{}
+
+// emailHeaders holds the parsed headers of a stored message.
+type emailHeaders struct {
+	From       string
+	ReplyTo    string
+	To         []string
+	CC         []string
+	Subject    string
+	Date       string
+	MessageID  string
+	References string
+}
+
+func parseEmailHeaders(raw []byte) (*emailHeaders, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(ensureHeaderTerminator(raw)))
+	if err != nil {
+		return nil, fmt.Errorf("parse message headers: %w", err)
+	}
+	return headersFromMail(msg.Header), nil
+}
+
+// ensureHeaderTerminator appends the blank line that a header-only fetch may omit.
+func ensureHeaderTerminator(raw []byte) []byte {
+	if bytes.Contains(raw, []byte("\r\n\r\n")) || bytes.Contains(raw, []byte("\n\n")) {
+		return raw
+	}
+	return append(append([]byte{}, raw...), "\r\n\r\n"...)
+}
+
+func headersFromMail(h mail.Header) *emailHeaders {
+	out := &emailHeaders{
+		From:       decodeEmailHeader(h.Get("From")),
+		ReplyTo:    decodeEmailHeader(h.Get("Reply-To")),
+		To:         emailAddressList(h, "To"),
+		CC:         emailAddressList(h, "Cc"),
+		Subject:    decodeEmailHeader(h.Get("Subject")),
+		Date:       h.Get("Date"),
+		MessageID:  strings.TrimSpace(h.Get("Message-Id")),
+		References: strings.Join(strings.Fields(h.Get("References")), " "),
+	}
+	if t, err := h.Date(); err == nil {
+		out.Date = t.UTC().Format(time.RFC3339)
+	}
+	return out
+}
+
+func decodeEmailHeader(v string) string {
+	if dec, err := emailWordDecoder.DecodeHeader(v); err == nil {
+		return dec
+	}
+	return v
+}
+
+func emailAddressList(h mail.Header, key string) []string {
+	addrs, err := h.AddressList(key)
+	if err != nil {
+		if v := h.Get(key); v != "" {
+			return []string{decodeEmailHeader(v)}
+		}
+		return nil
+	}
+	out := make([]string, len(addrs))
+	for i, a := range addrs {
+		out[i] = a.String()
+	}
+	return out
+}
+
+func (h *emailHeaders) summary(id string) EmailSummary {
+	return EmailSummary{ID: id, From: h.From, To: h.To, Subject: h.Subject, Date: h.Date}
+}
+
+// reply builds a threaded reply addressed to the original sender.
+func (h *emailHeaders) reply(from, body string) outgoingEmail {
+	to := h.ReplyTo
+	if to == "" {
+		to = h.From
+	}
+	subject := h.Subject
+	if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+	refs := h.References
+	if h.MessageID != "" {
+		refs = strings.TrimSpace(refs + " " + h.MessageID)
+	}
+	return outgoingEmail{
+		From:       from,
+		To:         []string{to},
+		Subject:    subject,
+		Body:       body,
+		InReplyTo:  h.MessageID,
+		References: refs,
+	}
+}
+
+// parseEmailMessage turns a raw RFC 5322 message into an EmailMessage with
+// its first text/plain body and a list of attachments.
+func parseEmailMessage(id string, raw []byte) (*EmailMessage, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("parse message: %w", err)
+	}
+	h := headersFromMail(msg.Header)
+	out := &EmailMessage{
+		ID:        id,
+		MessageID: h.MessageID,
+		From:      h.From,
+		To:        h.To,
+		CC:        h.CC,
+		Subject:   h.Subject,
+		Date:      h.Date,
+	}
+
+	var html string
+	err = walkEmailPart(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"),
+		msg.Header.Get("Content-Disposition"), msg.Body, 0, func(p emailPart) {
+			switch {
+			case p.filename != "" || p.disposition == "attachment":
+				out.Attachments = append(out.Attachments, EmailAttachment{
+					Filename:    p.filename,
+					ContentType: p.mediaType,
+					Size:        len(p.data),
+				})
+			case p.mediaType == "text/plain" && out.Body == "":
+				out.Body = string(p.data)
+			case p.mediaType == "text/html" && html == "":
+				html = string(p.data)
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+	if out.Body == "" && html != "" {
+		out.Body = html
+	}
+	if len(out.Body) > maxEmailBodySize {
+		out.Body = out.Body[:maxEmailBodySize] + "\n[truncated]"
+	}
+	return out, nil
+}
+
+// emailPart is a decoded leaf of a MIME tree.
+type emailPart struct {
+	mediaType   string
+	disposition string
+	filename    string
+	data        []byte
+}
+
+func walkEmailPart(contentType, encoding, disposition string, body io.Reader, depth int, visit func(emailPart)) error {
+	if depth > maxEmailMIMEDepth {
+		return nil
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("parse mime part: %w", err)
+			}
+			if err := walkEmailPart(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"),
+				part.Header.Get("Content-Disposition"), part, depth+1, visit); err != nil {
+				return err
+			}
+		}
+	}
+
+	p := emailPart{mediaType: mediaType, filename: decodeEmailHeader(params["name"])}
+	if disp, dparams, err := mime.ParseMediaType(disposition); err == nil {
+		p.disposition = disp
+		if fn := dparams["filename"]; fn != "" {
+			p.filename = decodeEmailHeader(fn)
+		}
+	}
+	p.data, err = io.ReadAll(io.LimitReader(decodeTransferEncoding(encoding, body), maxIMAPLiteralSize))
+	if err != nil {
+		return fmt.Errorf("decode mime part: %w", err)
+	}
+	visit(p)
+	return nil
+}
+
+func decodeTransferEncoding(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
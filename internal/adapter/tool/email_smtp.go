@@ -0,0 +1,156 @@
+package tool
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// outgoingEmail is a plain-text message about to be sent or saved as a draft.
+type outgoingEmail struct {
+	From       string
+	To         []string
+	CC         []string
+	Subject    string
+	Body       string
+	InReplyTo  string
+	References string
+}
+
+// build renders the message as RFC 5322 bytes and returns its Message-ID.
+func (e outgoingEmail) build(now time.Time) ([]byte, string, error) {
+	from, err := mail.ParseAddress(e.From)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid from address %q: %w", e.From, err)
+	}
+	for _, addr := range append(append([]string{}, e.To...), e.CC...) {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return nil, "", fmt.Errorf("invalid recipient %q: %w", addr, err)
+		}
+	}
+	for _, v := range []string{e.Subject, e.InReplyTo, e.References} {
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, "", fmt.Errorf("header values must not contain line breaks")
+		}
+	}
+
+	messageID, err := newMessageID(from.Address)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var b strings.Builder
+	writeHeader := func(k, v string) { b.WriteString(k + ": " + v + "\r\n") }
+	writeHeader("From", from.String())
+	writeHeader("To", strings.Join(e.To, ", "))
+	if len(e.CC) > 0 {
+		writeHeader("Cc", strings.Join(e.CC, ", "))
+	}
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", e.Subject))
+	writeHeader("Date", now.Format(time.RFC1123Z))
+	writeHeader("Message-ID", messageID)
+	if e.InReplyTo != "" {
+		writeHeader("In-Reply-To", e.InReplyTo)
+	}
+	if e.References != "" {
+		writeHeader("References", e.References)
+	}
+	writeHeader("MIME-Version", "1.0")
+	writeHeader("Content-Type", `text/plain; charset="utf-8"`)
+	writeHeader("Content-Transfer-Encoding", "8bit")
+	b.WriteString("\r\n")
+
+	// Normalise line endings; dot-stuffing is handled by smtp.Data.
+	body := strings.ReplaceAll(e.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	if !strings.HasSuffix(body, "\n") {
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String()), messageID, nil
+}
+
+func newMessageID(from string) (string, error) {
+	var buf [12]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", fmt.Errorf("generate message id: %w", err)
+	}
+	host := "alfred-ai.local"
+	if i := strings.LastIndex(from, "@"); i >= 0 && i < len(from)-1 {
+		host = from[i+1:]
+	}
+	return "<" + hex.EncodeToString(buf[:]) + "@" + host + ">", nil
+}
+
+// sendSMTP submits raw to the configured SMTP server.
+func (b *IMAPSMTPBackend) sendSMTP(ctx context.Context, rcpts []string, raw []byte) error {
+	conn, err := b.dial(ctx, b.cfg.SMTPAddr, b.cfg.SMTPTLS)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(b.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(b.cfg.SMTPAddr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp greeting: %w", err)
+	}
+	defer c.Close()
+
+	if b.cfg.SMTPTLS == EmailTLSStartTLS {
+		if err := c.StartTLS(b.tlsConfig(b.cfg.SMTPAddr)); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if b.cfg.Password != "" {
+		if ok, _ := c.Extension("AUTH"); ok {
+			if err := c.Auth(smtp.PlainAuth("", b.cfg.Username, b.cfg.Password, host)); err != nil {
+				return fmt.Errorf("smtp auth: %w", err)
+			}
+		}
+	}
+
+	from, err := mail.ParseAddress(b.cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address %q: %w", b.cfg.From, err)
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	for _, r := range rcpts {
+		addr, err := mail.ParseAddress(r)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", r, err)
+		}
+		if err := c.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("smtp rcpt to %s: %w", addr.Address, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		return fmt.Errorf("smtp write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if err := c.Quit(); err != nil {
+		b.logger.Debug("smtp quit failed", "error", err)
+	}
+	return nil
+}
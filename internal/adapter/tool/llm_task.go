@@ -187,6 +187,17 @@ func (t *LLMTaskTool) Execute(ctx context.Context, params json.RawMessage) (*dom
 				userContent.WriteString("\n")
 			}
 
+			// Compile the schema before the call, so a bad one is reported
+			// as the caller's mistake rather than as a failed LLM call.
+			var schema *jsonschema.Schema
+			if len(p.Schema) > 0 && !bytes.Equal(p.Schema, []byte("null")) {
+				compiled, err := jsonschema.NewCompiler().Compile([]byte(p.Schema))
+				if err != nil {
+					return nil, fmt.Errorf("invalid schema: %v", err)
+				}
+				schema = compiled
+			}
+
 			// Build ChatRequest (no tools = JSON-only mode)
 			maxTokens := t.config.MaxTokens
 			if p.MaxTokens != nil && *p.MaxTokens > 0 {
@@ -216,6 +227,15 @@ func (t *LLMTaskTool) Execute(ctx context.Context, params json.RawMessage) (*dom
 				MaxTokens:   maxTokens,
 				Temperature: temperature,
 			}
+			// With a schema the provider constrains the reply itself. Without
+			// one any JSON value is allowed, which JSON modes do not offer.
+			if schema != nil {
+				chatReq.ResponseFormat = &domain.ResponseFormat{
+					Type:   domain.ResponseFormatJSONSchema,
+					Name:   "llm_task",
+					Schema: p.Schema,
+				}
+			}
 
 			// Apply timeout (capped at config timeout)
 			timeout := t.config.Timeout
@@ -254,10 +274,11 @@ func (t *LLMTaskTool) Execute(ctx context.Context, params json.RawMessage) (*dom
 				return nil, fmt.Errorf("LLM returned invalid JSON: %v\nRaw output: %s", err, truncate(raw, 500))
 			}
 
-			// Optional JSON Schema validation
-			if len(p.Schema) > 0 && !bytes.Equal(p.Schema, []byte("null")) {
-				if err := validateJSONSchema(p.Schema, parsed); err != nil {
-					return nil, fmt.Errorf("LLM JSON did not match schema: %v", err)
+			// Optional JSON Schema validation, for providers used without
+			// response format enforcement
+			if schema != nil {
+				if result := schema.Validate(parsed); !result.IsValid() {
+					return nil, fmt.Errorf("LLM JSON did not match schema: %s", result.Error())
 				}
 			}
 
@@ -282,20 +303,6 @@ func (t *LLMTaskTool) isModelAllowed(modelKey string) bool {
 	return false
 }
 
-// validateJSONSchema validates parsed JSON against a JSON Schema.
-func validateJSONSchema(schemaBytes json.RawMessage, data any) error {
-	compiler := jsonschema.NewCompiler()
-	schema, err := compiler.Compile([]byte(schemaBytes))
-	if err != nil {
-		return fmt.Errorf("invalid schema: %w", err)
-	}
-	result := schema.Validate(data)
-	if !result.IsValid() {
-		return fmt.Errorf("%s", result.Error())
-	}
-	return nil
-}
-
 // codeFenceRe matches markdown code fences wrapping JSON.
 var codeFenceRe = regexp.MustCompile(`(?si)^` + "```" + `(?:json)?\s*(.*?)\s*` + "```" + `$`)
 
@@ -334,6 +334,10 @@ func TestLLMTaskToolSchemaValidationSuccess(t *testing.T) {
 	if result.IsError {
 		t.Fatalf("expected success, got error: %s", result.Content)
 	}
+	f := mock.lastReq.ResponseFormat
+	if f == nil || f.Type != domain.ResponseFormatJSONSchema || !strings.Contains(string(f.Schema), `"age"`) {
+		t.Errorf("ResponseFormat = %+v, want the task schema", f)
+	}
 }
 
 func TestLLMTaskToolSchemaValidationFailure(t *testing.T) {
@@ -367,8 +371,11 @@ func TestLLMTaskToolInvalidSchema(t *testing.T) {
 		"schema": map[string]any{"type": 123},
 	})
 
-	if !result.IsError {
-		t.Error("expected error for invalid schema")
+	if !result.IsError || !strings.Contains(result.Content, "invalid schema") {
+		t.Errorf("expected error for invalid schema, got %q", result.Content)
+	}
+	if mock.lastReq.Messages != nil {
+		t.Error("invalid schema should be rejected before calling the LLM")
 	}
 }
 
@@ -388,6 +395,9 @@ func TestLLMTaskToolNullSchemaSkipped(t *testing.T) {
 	if result.IsError {
 		t.Fatalf("expected success with null schema, got error: %s", result.Content)
 	}
+	if mock.lastReq.ResponseFormat != nil {
+		t.Error("no response format expected without a schema")
+	}
 }
 
 // --- Provider override ---
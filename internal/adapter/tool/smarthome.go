@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"path"
+	"strings"
 	"sync"
 	"time"
 
@@ -107,11 +109,60 @@ func (m *MockSmartHomeBackend) TriggerAutomation(_ context.Context, automationID
 	return fmt.Errorf("automation %q not found", automationID)
 }
 
+// SmartHomeEntityPolicy restricts which entities the agent may act on.
+// Patterns are globs such as "light.*" or "switch.garden_*". Deny wins over
+// allow; an empty allow list permits everything not denied. Services are
+// globs over "domain.service", such as "*.turn_on" or "climate.set_*"; an
+// empty list permits every service.
+type SmartHomeEntityPolicy struct {
+	Allow    []string
+	Deny     []string
+	Services []string
+}
+
+// Permits reports whether entityID may be controlled.
+func (p *SmartHomeEntityPolicy) Permits(entityID string) bool {
+	if p == nil {
+		return true
+	}
+	if matchEntityPattern(p.Deny, entityID) {
+		return false
+	}
+	return len(p.Allow) == 0 || matchEntityPattern(p.Allow, entityID)
+}
+
+// PermitsService reports whether the service domain.service may be called.
+func (p *SmartHomeEntityPolicy) PermitsService(dom, service string) bool {
+	if p == nil || len(p.Services) == 0 {
+		return true
+	}
+	return matchEntityPattern(p.Services, dom+"."+service)
+}
+
+func matchEntityPattern(patterns []string, entityID string) bool {
+	for _, pat := range patterns {
+		if ok, _ := path.Match(pat, entityID); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SmartHomeOption configures a SmartHomeTool.
+type SmartHomeOption func(*SmartHomeTool)
+
+// WithSmartHomeEntityPolicy limits call_service and trigger_automation to
+// entities permitted by policy.
+func WithSmartHomeEntityPolicy(policy *SmartHomeEntityPolicy) SmartHomeOption {
+	return func(t *SmartHomeTool) { t.policy = policy }
+}
+
 // SmartHomeTool provides smart home control to the LLM.
 type SmartHomeTool struct {
 	backend     SmartHomeBackend
 	logger      *slog.Logger
 	rateLimiter *RateLimiter
+	policy      *SmartHomeEntityPolicy
 
 	// TTL cache for list_entities.
 	mu            sync.Mutex
@@ -128,16 +179,21 @@ func NewSmartHomeTool(
 	timeout time.Duration,
 	maxCallsPerMin int,
 	logger *slog.Logger,
+	opts ...SmartHomeOption,
 ) *SmartHomeTool {
 	if backend == nil {
 		backend = NewMockSmartHomeBackend()
 	}
-	return &SmartHomeTool{
+	t := &SmartHomeTool{
 		backend:     backend,
 		logger:      logger,
 		rateLimiter: NewRateLimiter(maxCallsPerMin, time.Minute),
 		cacheTTL:    time.Minute,
 	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
 }
 
 func (t *SmartHomeTool) Name() string { return "smart_home" }
@@ -164,7 +220,7 @@ func (t *SmartHomeTool) Schema() domain.ToolSchema {
 				},
 				"domain": {
 					"type": "string",
-					"description": "Service domain (e.g. light, switch, climate)"
+					"description": "Service domain; must match the entity's domain (e.g. light for light.kitchen)"
 				},
 				"service": {
 					"type": "string",
@@ -223,6 +279,18 @@ func (t *SmartHomeTool) checkRateLimit() error {
 	return nil
 }
 
+// checkEntity rejects entities outside the configured policy.
+func (t *SmartHomeTool) checkEntity(entityID string) error {
+	if !t.policy.Permits(entityID) {
+		return fmt.Errorf("entity %q is not permitted by the smart home policy", entityID)
+	}
+	return nil
+}
+
+// targetKeys are service_data fields that would retarget a service call
+// past the policy check on entity_id.
+var targetKeys = []string{"entity_id", "device_id", "area_id", "floor_id", "label_id", "target"}
+
 func (t *SmartHomeTool) handleListEntities(ctx context.Context, _ smartHomeParams) (any, error) {
 	if err := t.checkRateLimit(); err != nil {
 		return nil, err
@@ -270,6 +338,23 @@ func (t *SmartHomeTool) handleCallService(ctx context.Context, p smartHomeParams
 	if err := RequireFields("domain", p.Domain, "service", p.Service, "entity_id", p.EntityID); err != nil {
 		return nil, err
 	}
+	if err := t.checkEntity(p.EntityID); err != nil {
+		return nil, err
+	}
+	// The policy judges the call by its entity, so the service must act on
+	// that entity's own domain: light.kitchen must not be a way to reach
+	// lock.unlock or script.*.
+	if entityDomain, _, _ := strings.Cut(p.EntityID, "."); p.Domain != entityDomain {
+		return nil, fmt.Errorf("domain %q does not match entity %q", p.Domain, p.EntityID)
+	}
+	if !t.policy.PermitsService(p.Domain, p.Service) {
+		return nil, fmt.Errorf("service %s.%s is not permitted by the smart home policy", p.Domain, p.Service)
+	}
+	for _, k := range targetKeys {
+		if _, ok := p.ServiceData[k]; ok {
+			return nil, fmt.Errorf("service_data must not contain %q; use entity_id", k)
+		}
+	}
 	if err := t.backend.CallService(ctx, p.Domain, p.Service, p.EntityID, p.ServiceData); err != nil {
 		return nil, err
 	}
@@ -317,6 +402,9 @@ func (t *SmartHomeTool) handleTriggerAutomation(ctx context.Context, p smartHome
 	if err := RequireField("automation_id", p.AutomationID); err != nil {
 		return nil, err
 	}
+	if err := t.checkEntity(automationEntityID(p.AutomationID)); err != nil {
+		return nil, err
+	}
 	if err := t.backend.TriggerAutomation(ctx, p.AutomationID); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,436 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+
+	"alfred-ai/internal/domain"
+)
+
+const maxHassBodySize = 4 * 1024 * 1024 // 4MB
+
+var (
+	hassEntityIDRe = regexp.MustCompile(`^[a-z0-9_]+\.[a-z0-9_]+$`)
+	hassNameRe     = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+// HomeAssistantConfig configures the Home Assistant backend.
+type HomeAssistantConfig struct {
+	URL     string        // base URL, e.g. http://homeassistant.local:8123
+	Token   string        // long-lived access token
+	Timeout time.Duration // per-request timeout
+}
+
+// HomeAssistantBackend implements SmartHomeBackend using the Home Assistant
+// REST API for states, services and history, and its WebSocket API for
+// automations and state-change subscriptions.
+type HomeAssistantBackend struct {
+	client  *http.Client
+	baseURL string
+	token   string
+	timeout time.Duration
+	logger  *slog.Logger
+}
+
+// NewHomeAssistantBackend creates a smart home backend for a Home Assistant instance.
+func NewHomeAssistantBackend(cfg HomeAssistantConfig, logger *slog.Logger) (*HomeAssistantBackend, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid home assistant url %q", cfg.URL)
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("home assistant token is required")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &HomeAssistantBackend{
+		client:  &http.Client{Timeout: cfg.Timeout},
+		baseURL: strings.TrimRight(cfg.URL, "/"),
+		token:   cfg.Token,
+		timeout: cfg.Timeout,
+		logger:  logger,
+	}, nil
+}
+
+// hassState is the Home Assistant state object.
+type hassState struct {
+	EntityID    string         `json:"entity_id"`
+	State       string         `json:"state"`
+	Attributes  map[string]any `json:"attributes"`
+	LastChanged string         `json:"last_changed"`
+}
+
+func (s hassState) entity() SmartHomeEntity {
+	return SmartHomeEntity{EntityID: s.EntityID, State: s.State, Attributes: s.Attributes, LastChanged: s.LastChanged}
+}
+
+// --- REST API ---
+
+func (b *HomeAssistantBackend) ListEntities(ctx context.Context) ([]SmartHomeEntity, error) {
+	var states []hassState
+	if err := b.rest(ctx, http.MethodGet, "/api/states", nil, &states); err != nil {
+		return nil, err
+	}
+	out := make([]SmartHomeEntity, len(states))
+	for i, s := range states {
+		out[i] = s.entity()
+	}
+	return out, nil
+}
+
+func (b *HomeAssistantBackend) GetEntity(ctx context.Context, entityID string) (*SmartHomeEntity, error) {
+	if !hassEntityIDRe.MatchString(entityID) {
+		return nil, fmt.Errorf("invalid entity_id %q", entityID)
+	}
+	var s hassState
+	if err := b.rest(ctx, http.MethodGet, "/api/states/"+entityID, nil, &s); err != nil {
+		return nil, err
+	}
+	e := s.entity()
+	return &e, nil
+}
+
+func (b *HomeAssistantBackend) CallService(ctx context.Context, dom, service, entityID string, data map[string]any) error {
+	if !hassNameRe.MatchString(dom) || !hassNameRe.MatchString(service) {
+		return fmt.Errorf("invalid service %s.%s", dom, service)
+	}
+	if !hassEntityIDRe.MatchString(entityID) {
+		return fmt.Errorf("invalid entity_id %q", entityID)
+	}
+	body := make(map[string]any, len(data)+1)
+	for k, v := range data {
+		body[k] = v
+	}
+	// The target is always the single checked entity.
+	body["entity_id"] = entityID
+	return b.rest(ctx, http.MethodPost, "/api/services/"+dom+"/"+service, body, nil)
+}
+
+func (b *HomeAssistantBackend) GetHistory(ctx context.Context, entityID string, opts HistoryOpts) ([]SmartHomeState, error) {
+	if !hassEntityIDRe.MatchString(entityID) {
+		return nil, fmt.Errorf("invalid entity_id %q", entityID)
+	}
+	p := "/api/history/period"
+	if opts.StartTime != "" {
+		t, err := time.Parse(time.RFC3339, opts.StartTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start_time: %w", err)
+		}
+		p += "/" + url.PathEscape(t.UTC().Format(time.RFC3339))
+	}
+	q := url.Values{}
+	q.Set("filter_entity_id", entityID)
+	q.Set("minimal_response", "")
+	q.Set("no_attributes", "")
+	if opts.EndTime != "" {
+		t, err := time.Parse(time.RFC3339, opts.EndTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end_time: %w", err)
+		}
+		q.Set("end_time", t.UTC().Format(time.RFC3339))
+	}
+
+	var series [][]SmartHomeState
+	if err := b.rest(ctx, http.MethodGet, p+"?"+q.Encode(), nil, &series); err != nil {
+		return nil, err
+	}
+	var out []SmartHomeState
+	for _, s := range series {
+		out = append(out, s...)
+	}
+	return out, nil
+}
+
+func (b *HomeAssistantBackend) rest(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+b.token)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("home assistant request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHassBodySize))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("home assistant: not found (%s)", strings.SplitN(path, "?", 2)[0])
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("home assistant: unauthorized (check token)")
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("home assistant request failed (HTTP %d): %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	return nil
+}
+
+// --- WebSocket API ---
+
+// hassMessage covers the WebSocket message shapes we read.
+type hassMessage struct {
+	ID      int64           `json:"id"`
+	Type    string          `json:"type"`
+	Success bool            `json:"success"`
+	Result  json.RawMessage `json:"result"`
+	Error   *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Event   json.RawMessage `json:"event"`
+	Message string          `json:"message"`
+}
+
+// hassWS is an authenticated WebSocket session.
+type hassWS struct {
+	conn   *websocket.Conn
+	nextID atomic.Int64
+}
+
+func (b *HomeAssistantBackend) wsURL() string {
+	u := b.baseURL + "/api/websocket"
+	if rest, ok := strings.CutPrefix(u, "https://"); ok {
+		return "wss://" + rest
+	}
+	return "ws://" + strings.TrimPrefix(u, "http://")
+}
+
+func (b *HomeAssistantBackend) dialWS(ctx context.Context) (*hassWS, error) {
+	conn, _, err := websocket.Dial(ctx, b.wsURL(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("home assistant websocket connect: %w", err)
+	}
+	conn.SetReadLimit(maxHassBodySize)
+	ws := &hassWS{conn: conn}
+
+	var msg hassMessage
+	if err := wsjson.Read(ctx, conn, &msg); err != nil || msg.Type != "auth_required" {
+		conn.Close(websocket.StatusProtocolError, "")
+		return nil, fmt.Errorf("home assistant websocket: expected auth_required (got %q): %v", msg.Type, err)
+	}
+	if err := wsjson.Write(ctx, conn, map[string]string{"type": "auth", "access_token": b.token}); err != nil {
+		conn.Close(websocket.StatusInternalError, "")
+		return nil, fmt.Errorf("home assistant websocket auth: %w", err)
+	}
+	if err := wsjson.Read(ctx, conn, &msg); err != nil {
+		conn.Close(websocket.StatusInternalError, "")
+		return nil, fmt.Errorf("home assistant websocket auth: %w", err)
+	}
+	if msg.Type != "auth_ok" {
+		conn.Close(websocket.StatusPolicyViolation, "")
+		return nil, fmt.Errorf("home assistant websocket auth failed: %s", msg.Message)
+	}
+	return ws, nil
+}
+
+func (ws *hassWS) close() { ws.conn.Close(websocket.StatusNormalClosure, "") }
+
+// command sends a request and waits for its result, skipping unrelated messages.
+func (ws *hassWS) command(ctx context.Context, msg map[string]any) (json.RawMessage, error) {
+	id := ws.nextID.Add(1)
+	msg["id"] = id
+	if err := wsjson.Write(ctx, ws.conn, msg); err != nil {
+		return nil, fmt.Errorf("home assistant websocket write: %w", err)
+	}
+	for {
+		var resp hassMessage
+		if err := wsjson.Read(ctx, ws.conn, &resp); err != nil {
+			return nil, fmt.Errorf("home assistant websocket read: %w", err)
+		}
+		if resp.ID != id || resp.Type != "result" {
+			continue
+		}
+		if !resp.Success {
+			if resp.Error != nil {
+				return nil, fmt.Errorf("home assistant: %s: %s", resp.Error.Code, resp.Error.Message)
+			}
+			return nil, fmt.Errorf("home assistant: command %v failed", msg["type"])
+		}
+		return resp.Result, nil
+	}
+}
+
+// withWS runs fn on a short-lived authenticated WebSocket session.
+func (b *HomeAssistantBackend) withWS(ctx context.Context, fn func(*hassWS) error) error {
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+	ws, err := b.dialWS(ctx)
+	if err != nil {
+		return err
+	}
+	defer ws.close()
+	return fn(ws)
+}
+
+func (b *HomeAssistantBackend) ListAutomations(ctx context.Context) ([]SmartHomeAutomation, error) {
+	var out []SmartHomeAutomation
+	err := b.withWS(ctx, func(ws *hassWS) error {
+		raw, err := ws.command(ctx, map[string]any{"type": "get_states"})
+		if err != nil {
+			return err
+		}
+		var states []hassState
+		if err := json.Unmarshal(raw, &states); err != nil {
+			return fmt.Errorf("parse states: %w", err)
+		}
+		for _, s := range states {
+			if !strings.HasPrefix(s.EntityID, "automation.") {
+				continue
+			}
+			name, _ := s.Attributes["friendly_name"].(string)
+			if name == "" {
+				name = s.EntityID
+			}
+			out = append(out, SmartHomeAutomation{ID: s.EntityID, Name: name, Enabled: s.State == "on"})
+		}
+		return nil
+	})
+	return out, err
+}
+
+func (b *HomeAssistantBackend) TriggerAutomation(ctx context.Context, automationID string) error {
+	entityID := automationEntityID(automationID)
+	if !hassEntityIDRe.MatchString(entityID) {
+		return fmt.Errorf("invalid automation_id %q", automationID)
+	}
+	return b.withWS(ctx, func(ws *hassWS) error {
+		_, err := ws.command(ctx, map[string]any{
+			"type":         "call_service",
+			"domain":       "automation",
+			"service":      "trigger",
+			"service_data": map[string]any{"entity_id": entityID},
+		})
+		return err
+	})
+}
+
+// automationEntityID accepts "morning" or "automation.morning".
+func automationEntityID(id string) string {
+	if strings.HasPrefix(id, "automation.") {
+		return id
+	}
+	return "automation." + id
+}
+
+// SmartHomeStateChange is the payload of domain.EventSmartHomeStateChanged.
+type SmartHomeStateChange struct {
+	EntityID   string         `json:"entity_id"`
+	OldState   string         `json:"old_state,omitempty"`
+	NewState   string         `json:"new_state,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	ChangedAt  string         `json:"changed_at,omitempty"`
+}
+
+type hassStateChangedEvent struct {
+	Data struct {
+		EntityID string     `json:"entity_id"`
+		OldState *hassState `json:"old_state"`
+		NewState *hassState `json:"new_state"`
+	} `json:"data"`
+}
+
+// WatchStateChanges subscribes to state_changed events and republishes those
+// permitted by policy on bus as domain.EventSmartHomeStateChanged. It
+// reconnects with backoff and returns when ctx is cancelled.
+func (b *HomeAssistantBackend) WatchStateChanges(ctx context.Context, bus domain.EventBus, policy *SmartHomeEntityPolicy) {
+	backoff := time.Second
+	for {
+		started := time.Now()
+		err := b.watchOnce(ctx, bus, policy)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > time.Minute {
+			backoff = time.Second
+		}
+		b.logger.Warn("home assistant event stream disconnected", "error", err, "retry_in", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+func (b *HomeAssistantBackend) watchOnce(ctx context.Context, bus domain.EventBus, policy *SmartHomeEntityPolicy) error {
+	dialCtx, cancel := context.WithTimeout(ctx, b.timeout)
+	ws, err := b.dialWS(dialCtx)
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer ws.close()
+
+	subCtx, cancel := context.WithTimeout(ctx, b.timeout)
+	_, err = ws.command(subCtx, map[string]any{"type": "subscribe_events", "event_type": "state_changed"})
+	cancel()
+	if err != nil {
+		return err
+	}
+	b.logger.Info("home assistant event stream connected")
+
+	for {
+		var msg hassMessage
+		if err := wsjson.Read(ctx, ws.conn, &msg); err != nil {
+			return err
+		}
+		if msg.Type != "event" {
+			continue
+		}
+		var ev hassStateChangedEvent
+		if err := json.Unmarshal(msg.Event, &ev); err != nil {
+			b.logger.Debug("skipping malformed home assistant event", "error", err)
+			continue
+		}
+		if ev.Data.EntityID == "" || !policy.Permits(ev.Data.EntityID) {
+			continue
+		}
+		change := SmartHomeStateChange{EntityID: ev.Data.EntityID}
+		if ev.Data.OldState != nil {
+			change.OldState = ev.Data.OldState.State
+		}
+		if ev.Data.NewState != nil {
+			change.NewState = ev.Data.NewState.State
+			change.Attributes = ev.Data.NewState.Attributes
+			change.ChangedAt = ev.Data.NewState.LastChanged
+		}
+		PublishToolEvent(ctx, bus, domain.EventSmartHomeStateChanged, change)
+	}
+}
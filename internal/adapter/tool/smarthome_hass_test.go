@@ -0,0 +1,301 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+
+	"alfred-ai/internal/domain"
+)
+
+// --- local Home Assistant stand-in ---
+
+const fakeHassToken = "hass-token"
+
+type fakeHass struct {
+	srv    *httptest.Server
+	mu     sync.Mutex
+	states []hassState
+	calls  []map[string]any // service payloads, REST and WebSocket
+	paths  []string         // REST service paths
+	query  string           // last history query
+}
+
+func newFakeHass(t *testing.T) *fakeHass {
+	t.Helper()
+	f := &fakeHass{states: []hassState{
+		{EntityID: "light.kitchen", State: "on", Attributes: map[string]any{"brightness": 200.0}, LastChanged: "2026-01-01T10:00:00Z"},
+		{EntityID: "lock.front_door", State: "locked"},
+		{EntityID: "automation.night_mode", State: "on", Attributes: map[string]any{"friendly_name": "Night Mode"}},
+	}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/websocket", f.handleWS)
+	mux.HandleFunc("/api/", f.handleREST)
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeHass) handleREST(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+fakeHassToken {
+		http.Error(w, "401: Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/states":
+		json.NewEncoder(w).Encode(f.states)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/states/"):
+		id := strings.TrimPrefix(r.URL.Path, "/api/states/")
+		for _, s := range f.states {
+			if s.EntityID == id {
+				json.NewEncoder(w).Encode(s)
+				return
+			}
+		}
+		http.Error(w, `{"message": "Entity not found."}`, http.StatusNotFound)
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/api/services/"):
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		f.calls = append(f.calls, body)
+		f.paths = append(f.paths, r.URL.Path)
+		w.Write([]byte("[]"))
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/history/period"):
+		f.query = r.URL.RawQuery
+		w.Write([]byte(`[[{"state":"off","last_changed":"2026-01-01T09:00:00Z"},{"state":"on","last_changed":"2026-01-01T10:00:00Z"}]]`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeHass) handleWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	ctx := r.Context()
+
+	wsjson.Write(ctx, conn, map[string]any{"type": "auth_required"})
+	var auth map[string]any
+	if err := wsjson.Read(ctx, conn, &auth); err != nil {
+		return
+	}
+	if auth["access_token"] != fakeHassToken {
+		wsjson.Write(ctx, conn, map[string]any{"type": "auth_invalid", "message": "Invalid access token"})
+		return
+	}
+	wsjson.Write(ctx, conn, map[string]any{"type": "auth_ok"})
+
+	for {
+		var msg map[string]any
+		if err := wsjson.Read(ctx, conn, &msg); err != nil {
+			return
+		}
+		id := msg["id"]
+		switch msg["type"] {
+		case "get_states":
+			f.mu.Lock()
+			states := f.states
+			f.mu.Unlock()
+			wsjson.Write(ctx, conn, map[string]any{"id": id, "type": "result", "success": true, "result": states})
+		case "call_service":
+			f.mu.Lock()
+			f.calls = append(f.calls, msg)
+			f.mu.Unlock()
+			wsjson.Write(ctx, conn, map[string]any{"id": id, "type": "result", "success": true, "result": map[string]any{}})
+		case "subscribe_events":
+			wsjson.Write(ctx, conn, map[string]any{"id": id, "type": "result", "success": true, "result": nil})
+			for _, entity := range []string{"lock.front_door", "light.kitchen"} {
+				wsjson.Write(ctx, conn, map[string]any{"id": id, "type": "event", "event": map[string]any{
+					"event_type": "state_changed",
+					"data": map[string]any{
+						"entity_id": entity,
+						"old_state": map[string]any{"entity_id": entity, "state": "off"},
+						"new_state": map[string]any{"entity_id": entity, "state": "on", "last_changed": "2026-01-01T11:00:00Z"},
+					},
+				}})
+			}
+		default:
+			wsjson.Write(ctx, conn, map[string]any{"id": id, "type": "result", "success": false,
+				"error": map[string]any{"code": "unknown_command", "message": "Unknown command."}})
+		}
+	}
+}
+
+func newTestHassBackend(t *testing.T, f *fakeHass) *HomeAssistantBackend {
+	t.Helper()
+	b, err := NewHomeAssistantBackend(HomeAssistantConfig{URL: f.srv.URL, Token: fakeHassToken, Timeout: 5 * time.Second}, newTestLogger())
+	if err != nil {
+		t.Fatalf("NewHomeAssistantBackend: %v", err)
+	}
+	return b
+}
+
+// --- tests ---
+
+func TestNewHomeAssistantBackendValidation(t *testing.T) {
+	if _, err := NewHomeAssistantBackend(HomeAssistantConfig{URL: "ftp://ha", Token: "x"}, newTestLogger()); err == nil {
+		t.Error("expected error for non-http url")
+	}
+	if _, err := NewHomeAssistantBackend(HomeAssistantConfig{URL: "http://ha:8123"}, newTestLogger()); err == nil {
+		t.Error("expected error for missing token")
+	}
+}
+
+func TestHomeAssistantListAndGetEntity(t *testing.T) {
+	f := newFakeHass(t)
+	b := newTestHassBackend(t, f)
+	ctx := context.Background()
+
+	entities, err := b.ListEntities(ctx)
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(entities) != 3 || entities[0].EntityID != "light.kitchen" {
+		t.Errorf("unexpected entities: %+v", entities)
+	}
+
+	e, err := b.GetEntity(ctx, "light.kitchen")
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if e.State != "on" || e.Attributes["brightness"] != 200.0 {
+		t.Errorf("unexpected entity: %+v", e)
+	}
+
+	if _, err := b.GetEntity(ctx, "light.garage"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found, got %v", err)
+	}
+	if _, err := b.GetEntity(ctx, "../config"); err == nil {
+		t.Error("expected invalid entity_id error")
+	}
+}
+
+func TestHomeAssistantUnauthorized(t *testing.T) {
+	f := newFakeHass(t)
+	b, _ := NewHomeAssistantBackend(HomeAssistantConfig{URL: f.srv.URL, Token: "wrong"}, newTestLogger())
+	if _, err := b.ListEntities(context.Background()); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("expected unauthorized, got %v", err)
+	}
+	if _, err := b.ListAutomations(context.Background()); err == nil || !strings.Contains(err.Error(), "auth failed") {
+		t.Errorf("expected websocket auth failure, got %v", err)
+	}
+}
+
+func TestHomeAssistantCallServiceForcesEntity(t *testing.T) {
+	f := newFakeHass(t)
+	b := newTestHassBackend(t, f)
+
+	err := b.CallService(context.Background(), "light", "turn_on", "light.kitchen",
+		map[string]any{"brightness": 128, "entity_id": "light.other"})
+	if err != nil {
+		t.Fatalf("CallService: %v", err)
+	}
+	if len(f.calls) != 1 || f.paths[0] != "/api/services/light/turn_on" {
+		t.Fatalf("unexpected calls: %v %v", f.paths, f.calls)
+	}
+	if f.calls[0]["entity_id"] != "light.kitchen" || f.calls[0]["brightness"] != 128.0 {
+		t.Errorf("unexpected payload: %v", f.calls[0])
+	}
+
+	if err := b.CallService(context.Background(), "light/../x", "turn_on", "light.kitchen", nil); err == nil {
+		t.Error("expected invalid service error")
+	}
+}
+
+func TestHomeAssistantGetHistory(t *testing.T) {
+	f := newFakeHass(t)
+	b := newTestHassBackend(t, f)
+
+	history, err := b.GetHistory(context.Background(), "light.kitchen", HistoryOpts{
+		StartTime: "2026-01-01T00:00:00Z", EndTime: "2026-01-02T00:00:00Z",
+	})
+	if err != nil {
+		t.Fatalf("GetHistory: %v", err)
+	}
+	if len(history) != 2 || history[1].State != "on" {
+		t.Errorf("unexpected history: %+v", history)
+	}
+	if !strings.Contains(f.query, "filter_entity_id=light.kitchen") || !strings.Contains(f.query, "end_time=2026-01-02") {
+		t.Errorf("unexpected query: %s", f.query)
+	}
+
+	if _, err := b.GetHistory(context.Background(), "light.kitchen", HistoryOpts{StartTime: "yesterday"}); err == nil {
+		t.Error("expected invalid start_time error")
+	}
+}
+
+func TestHomeAssistantAutomations(t *testing.T) {
+	f := newFakeHass(t)
+	b := newTestHassBackend(t, f)
+	ctx := context.Background()
+
+	autos, err := b.ListAutomations(ctx)
+	if err != nil {
+		t.Fatalf("ListAutomations: %v", err)
+	}
+	if len(autos) != 1 || autos[0].ID != "automation.night_mode" || autos[0].Name != "Night Mode" || !autos[0].Enabled {
+		t.Errorf("unexpected automations: %+v", autos)
+	}
+
+	if err := b.TriggerAutomation(ctx, "night_mode"); err != nil {
+		t.Fatalf("TriggerAutomation: %v", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.calls) != 1 || f.calls[0]["service"] != "trigger" {
+		t.Fatalf("unexpected calls: %v", f.calls)
+	}
+	data := f.calls[0]["service_data"].(map[string]any)
+	if data["entity_id"] != "automation.night_mode" {
+		t.Errorf("unexpected service_data: %v", data)
+	}
+}
+
+func TestHomeAssistantWatchStateChanges(t *testing.T) {
+	f := newFakeHass(t)
+	b := newTestHassBackend(t, f)
+	bus := &recordingEventBus{}
+	policy := &SmartHomeEntityPolicy{Deny: []string{"lock.*"}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		b.WatchStateChanges(ctx, bus, policy)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(bus.Events()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	events := bus.Events()
+	if len(events) == 0 {
+		t.Fatal("expected a state change event")
+	}
+	for _, e := range events {
+		if e.Type != domain.EventSmartHomeStateChanged {
+			t.Errorf("unexpected event type %s", e.Type)
+		}
+		var change SmartHomeStateChange
+		if err := json.Unmarshal(e.Payload, &change); err != nil {
+			t.Fatalf("unmarshal payload: %v", err)
+		}
+		if change.EntityID != "light.kitchen" || change.OldState != "off" || change.NewState != "on" {
+			t.Errorf("unexpected change: %+v", change)
+		}
+	}
+}
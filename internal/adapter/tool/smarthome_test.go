@@ -368,9 +368,9 @@ func TestSmartHomeToolBackendCallServiceError(t *testing.T) {
 	tool, backend := newTestSmartHomeTool(t)
 	backend.callServiceErr = fmt.Errorf("ha error")
 	result := execSmartHomeTool(t, tool, map[string]any{
-		"action": "call_service", "domain": "light", "service": "turn_on", "entity_id": "x",
+		"action": "call_service", "domain": "light", "service": "turn_on", "entity_id": "light.x",
 	})
-	if !result.IsError {
+	if !result.IsError || !strings.Contains(result.Content, "ha error") {
 		t.Error("expected error from backend")
 	}
 }
@@ -430,3 +430,99 @@ func FuzzSmartHomeTool_Execute(f *testing.F) {
 		tool.Execute(context.Background(), data)
 	})
 }
+
+// --- entity policy ---
+
+func TestSmartHomeEntityPolicyPermits(t *testing.T) {
+	p := &SmartHomeEntityPolicy{Allow: []string{"light.*", "lock.shed"}, Deny: []string{"lock.*"}}
+	cases := map[string]bool{
+		"light.kitchen":   true,
+		"switch.fan":      false,
+		"lock.front_door": false,
+		"lock.shed":       false, // deny wins
+	}
+	for id, want := range cases {
+		if got := p.Permits(id); got != want {
+			t.Errorf("Permits(%q) = %v, want %v", id, got, want)
+		}
+	}
+	var nilPolicy *SmartHomeEntityPolicy
+	if !nilPolicy.Permits("lock.front_door") {
+		t.Error("nil policy should permit everything")
+	}
+}
+
+func TestSmartHomeToolPolicyBlocksCallService(t *testing.T) {
+	b := newTestSmartHomeBackend()
+	tool := NewSmartHomeTool(b, "http://ha:8123", "token", 10*time.Second, 1000, newTestLogger(),
+		WithSmartHomeEntityPolicy(&SmartHomeEntityPolicy{Deny: []string{"lock.*"}}))
+
+	result := execSmartHomeTool(t, tool, map[string]any{
+		"action": "call_service", "domain": "lock", "service": "unlock", "entity_id": "lock.front_door",
+	})
+	if !result.IsError || !strings.Contains(result.Content, "not permitted") {
+		t.Errorf("expected policy error, got %s", result.Content)
+	}
+	if len(b.calls) != 0 {
+		t.Errorf("backend should not be called, got %d calls", len(b.calls))
+	}
+}
+
+func TestSmartHomeToolPolicyRejectsRetargeting(t *testing.T) {
+	b := newTestSmartHomeBackend()
+	tool := NewSmartHomeTool(b, "http://ha:8123", "token", 10*time.Second, 1000, newTestLogger(),
+		WithSmartHomeEntityPolicy(&SmartHomeEntityPolicy{Allow: []string{"light.*"}}))
+
+	result := execSmartHomeTool(t, tool, map[string]any{
+		"action": "call_service", "domain": "light", "service": "turn_off", "entity_id": "light.desk",
+		"service_data": map[string]any{"area_id": "house"},
+	})
+	if !result.IsError || !strings.Contains(result.Content, "area_id") {
+		t.Errorf("expected retargeting error, got %s", result.Content)
+	}
+	if len(b.calls) != 0 {
+		t.Errorf("backend should not be called, got %d calls", len(b.calls))
+	}
+}
+
+func TestSmartHomeToolPolicyChecksService(t *testing.T) {
+	tests := []struct {
+		name    string
+		domain  string
+		service string
+		want    string
+	}{
+		{"other domain", "lock", "unlock", "does not match"},
+		{"generic domain", "homeassistant", "turn_off", "does not match"},
+		{"service not allowed", "light", "flash", "not permitted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestSmartHomeBackend()
+			tool := NewSmartHomeTool(b, "http://ha:8123", "token", 10*time.Second, 1000, newTestLogger(),
+				WithSmartHomeEntityPolicy(&SmartHomeEntityPolicy{Allow: []string{"light.*"}, Services: []string{"*.turn_on", "*.turn_off"}}))
+
+			result := execSmartHomeTool(t, tool, map[string]any{
+				"action": "call_service", "domain": tt.domain, "service": tt.service, "entity_id": "light.desk",
+			})
+			if !result.IsError || !strings.Contains(result.Content, tt.want) {
+				t.Errorf("expected %q error, got %s", tt.want, result.Content)
+			}
+			if len(b.calls) != 0 {
+				t.Errorf("backend should not be called, got %d calls", len(b.calls))
+			}
+		})
+	}
+}
+
+func TestSmartHomeToolPolicyBlocksAutomation(t *testing.T) {
+	b := newTestSmartHomeBackend()
+	b.automations = []SmartHomeAutomation{{ID: "automation.disarm", Name: "Disarm"}}
+	tool := NewSmartHomeTool(b, "http://ha:8123", "token", 10*time.Second, 1000, newTestLogger(),
+		WithSmartHomeEntityPolicy(&SmartHomeEntityPolicy{Deny: []string{"automation.disarm"}}))
+
+	result := execSmartHomeTool(t, tool, map[string]any{"action": "trigger_automation", "automation_id": "disarm"})
+	if !result.IsError || !strings.Contains(result.Content, "not permitted") {
+		t.Errorf("expected policy error, got %s", result.Content)
+	}
+}
@@ -14,15 +14,17 @@ import (
 // --- compact response envelopes ---
 
 type workflowRunEnvelope struct {
-	OK       bool                  `json:"ok"`
-	Status   string                `json:"status"`
-	RunID    string                `json:"run_id"`
-	Output   json.RawMessage       `json:"output,omitempty"`
-	Approval *workflowApprovalInfo `json:"approval,omitempty"`
-	Error    *string               `json:"error,omitempty"`
+	OK        bool                   `json:"ok"`
+	Status    string                 `json:"status"`
+	RunID     string                 `json:"run_id"`
+	Output    json.RawMessage        `json:"output,omitempty"`
+	Approval  *workflowApprovalInfo  `json:"approval,omitempty"`
+	Approvals []workflowApprovalInfo `json:"approvals,omitempty"` // set when several branches await approval
+	Error     *string                `json:"error,omitempty"`
 }
 
 type workflowApprovalInfo struct {
+	StepID      string `json:"step_id,omitempty"`
 	Message     string `json:"message"`
 	ResumeToken string `json:"resume_token"`
 }
@@ -49,6 +51,7 @@ type workflowStatusEnvelope struct {
 	PipelineName string              `json:"pipeline_name"`
 	Status       string              `json:"status"`
 	Steps        []domain.StepResult `json:"steps"`
+	StepStates   map[string]string   `json:"step_states,omitempty"`
 	Error        string              `json:"error,omitempty"`
 	CreatedAt    time.Time           `json:"created_at"`
 	UpdatedAt    time.Time           `json:"updated_at"`
@@ -69,7 +72,7 @@ func NewWorkflowTool(manager *workflow.Manager, logger *slog.Logger) *WorkflowTo
 
 func (t *WorkflowTool) Name() string { return "workflow" }
 func (t *WorkflowTool) Description() string {
-	return "Run, resume, list, and inspect pipeline workflows. Supports multi-step pipelines with exec, HTTP, transform, approval, and tool_call steps."
+	return "Run, resume, list, and inspect pipeline workflows. Supports multi-step pipelines with exec, HTTP, transform, approval, tool_call, agent (LLM), foreach, and nested pipeline steps, with per-step retries and error handlers."
 }
 
 func (t *WorkflowTool) Schema() domain.ToolSchema {
@@ -86,7 +89,7 @@ func (t *WorkflowTool) Schema() domain.ToolSchema {
 				},
 				"pipeline": {
 					"type": "string",
-					"description": "Pipeline name to run (for 'run' action)"
+					"description": "Pipeline name to run (for 'run' action) or to filter runs by (for 'list' action)"
 				},
 				"steps": {
 					"type": "array",
@@ -95,7 +98,7 @@ func (t *WorkflowTool) Schema() domain.ToolSchema {
 						"type": "object",
 						"properties": {
 							"id": {"type": "string"},
-							"type": {"type": "string", "enum": ["exec", "http", "transform", "approval", "tool_call"]},
+							"type": {"type": "string", "enum": ["exec", "http", "transform", "approval", "tool_call", "agent", "foreach", "pipeline"]},
 							"name": {"type": "string"},
 							"command": {"type": "string"},
 							"args": {"type": "array", "items": {"type": "string"}},
@@ -108,8 +111,28 @@ func (t *WorkflowTool) Schema() domain.ToolSchema {
 							"template": {"type": "string"},
 							"message": {"type": "string"},
 							"condition": {"type": "string"},
+							"depends_on": {"type": "array", "items": {"type": "string"}, "description": "Step IDs to wait for; steps without a dependency path between them run in parallel"},
 							"tool_name": {"type": "string"},
-							"tool_params": {"type": "object"}
+							"tool_params": {"type": "object"},
+							"prompt": {"type": "string", "description": "agent: prompt template sent to the agent or model"},
+							"agent": {"type": "string", "description": "agent: agent ID (default agent when empty)"},
+							"model": {"type": "string", "description": "agent: model preference (e.g. 'fast') to use instead of an agent"},
+							"tools": {"type": "array", "items": {"type": "string"}, "description": "agent: tools the agent may use"},
+							"output_schema": {"type": "object", "description": "agent: JSON Schema the reply must match; later steps see the parsed JSON"},
+							"retry": {
+								"type": "object",
+								"description": "Retry policy: attempts (total), backoff (nanoseconds, doubled per retry), on (timeout, 4xx, 5xx or error substrings)",
+								"properties": {
+									"attempts": {"type": "integer"},
+									"backoff": {"type": "integer"},
+									"on": {"type": "array", "items": {"type": "string"}}
+								}
+							},
+							"on_error": {"type": "string", "description": "fail (default), continue, or the ID of a compensation step to run instead"},
+							"items": {"type": "string", "description": "foreach: dotted path to a list, e.g. 'list.output'"},
+							"do": {"type": "object", "description": "foreach: step run once per item, with {{.item}} and {{.index}}"},
+							"pipeline": {"type": "string", "description": "pipeline: name of the pipeline to run"},
+							"pipeline_args": {"type": "object", "additionalProperties": {"type": "string"}}
 						},
 						"required": ["id", "type"]
 					}
@@ -131,10 +154,19 @@ func (t *WorkflowTool) Schema() domain.ToolSchema {
 					"type": "string",
 					"description": "Workflow run ID (for 'status' action)"
 				},
+				"status": {
+					"type": "string",
+					"enum": ["running", "paused", "completed", "failed", "denied"],
+					"description": "Only list runs with this status (for 'list' action)"
+				},
 				"limit": {
 					"type": "integer",
 					"description": "Max results to return (for 'list' action, default 10)"
 				},
+				"offset": {
+					"type": "integer",
+					"description": "Number of runs to skip, for paging (for 'list' action)"
+				},
 				"timeout": {
 					"type": "string",
 					"description": "Per-call timeout override (e.g. '30s', '2m')"
@@ -157,7 +189,9 @@ type workflowParams struct {
 	ResumeToken string            `json:"resume_token"`
 	Approve     *bool             `json:"approve,omitempty"`
 	RunID       string            `json:"run_id"`
+	Status      string            `json:"status"`
 	Limit       int               `json:"limit"`
+	Offset      int               `json:"offset"`
 	Timeout     string            `json:"timeout,omitempty"`
 	MaxOutput   int               `json:"max_output,omitempty"`
 }
@@ -218,7 +252,12 @@ func (t *WorkflowTool) handleList(ctx context.Context, p workflowParams) (any, e
 	}
 
 	pipelines := t.manager.ListPipelines()
-	runs, err := t.manager.ListRuns(ctx, limit)
+	runs, err := t.manager.ListRuns(ctx, domain.WorkflowRunFilter{
+		Status:   p.Status,
+		Pipeline: p.Pipeline,
+		Limit:    limit,
+		Offset:   p.Offset,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -260,6 +299,7 @@ func (t *WorkflowTool) handleStatus(ctx context.Context, p workflowParams) (any,
 		PipelineName: run.PipelineName,
 		Status:       run.Status,
 		Steps:        run.Steps,
+		StepStates:   run.StepStates,
 		Error:        run.Error,
 		CreatedAt:    run.CreatedAt,
 		UpdatedAt:    run.UpdatedAt,
@@ -287,6 +327,18 @@ func toRunEnvelope(run *domain.WorkflowRun) workflowRunEnvelope {
 			Message:     run.ApprovalMessage,
 			ResumeToken: run.ResumeToken,
 		}
+		if len(run.Approvals) > 0 {
+			env.Approval.StepID = run.Approvals[0].StepID
+		}
+		if len(run.Approvals) > 1 {
+			for _, a := range run.Approvals {
+				env.Approvals = append(env.Approvals, workflowApprovalInfo{
+					StepID:      a.StepID,
+					Message:     a.Message,
+					ResumeToken: a.ResumeToken,
+				})
+			}
+		}
 	}
 
 	// Attach error if failed.
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"sync/atomic"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
@@ -20,7 +21,7 @@ type TUIChannel struct {
 	agentName string
 	modelName string
 	gen       atomic.Uint64   // current request generation, set by ChatModel via SetGen
-	bus       domain.EventBus // optional, nil = no tool event forwarding
+	bus       domain.EventBus // optional, nil = no tool events or approval prompts
 }
 
 // NewTUIChannel creates a new TUI-based CLI channel.
@@ -46,14 +47,15 @@ func (c *TUIChannel) SetAgentInfo(agent, model string) {
 	c.modelName = model
 }
 
-// SetEventBus enables forwarding tool events from the EventBus to the TUI.
+// SetEventBus enables forwarding tool events from the EventBus to the TUI
+// and answering tool approval requests for CLI sessions.
 func (c *TUIChannel) SetEventBus(bus domain.EventBus) {
 	c.bus = bus
 }
 
 // Start creates the Bubble Tea program and blocks until it exits.
 func (c *TUIChannel) Start(ctx context.Context, handler domain.MessageHandler) error {
-	model := NewChatModel(ChatModelDeps{
+	deps := ChatModelDeps{
 		Handler:   handler,
 		Privacy:   c.privacy,
 		OnClear:   c.onClear,
@@ -61,7 +63,11 @@ func (c *TUIChannel) Start(ctx context.Context, handler domain.MessageHandler) e
 		Logger:    c.logger,
 		AgentName: c.agentName,
 		ModelName: c.modelName,
-	})
+	}
+	if c.bus != nil {
+		deps.OnApproval = func(d domain.ApprovalDecision) { c.publishDecision(ctx, d) }
+	}
+	model := NewChatModel(deps)
 
 	c.program = tea.NewProgram(
 		model,
@@ -82,8 +88,22 @@ func (c *TUIChannel) Start(ctx context.Context, handler domain.MessageHandler) e
 				IsError: payload["success"] == "false",
 			})
 		})
+		unsub3 := c.bus.Subscribe(domain.EventToolApprovalReq, func(_ context.Context, event domain.Event) {
+			var req domain.ApprovalRequest
+			if json.Unmarshal(event.Payload, &req) == nil && req.Channel == c.Name() {
+				c.program.Send(ApprovalRequestMsg{Request: req})
+			}
+		})
+		unsub4 := c.bus.Subscribe(domain.EventToolApprovalResp, func(_ context.Context, event domain.Event) {
+			var d domain.ApprovalDecision
+			if json.Unmarshal(event.Payload, &d) == nil && d.ToolCallID != "" {
+				c.program.Send(ApprovalResolvedMsg{Decision: d})
+			}
+		})
 		defer unsub1()
 		defer unsub2()
+		defer unsub3()
+		defer unsub4()
 	}
 
 	// Monitor context cancellation to quit the program.
@@ -127,6 +147,21 @@ func (c *TUIChannel) Send(_ context.Context, msg domain.OutboundMessage) error {
 // with session key format (cli:cli-default) and config.yaml channel type.
 func (c *TUIChannel) Name() string { return "cli" }
 
+// publishDecision answers a tool approval request from the TUI.
+func (c *TUIChannel) publishDecision(ctx context.Context, d domain.ApprovalDecision) {
+	d.DecidedBy = c.Name()
+	payload, err := json.Marshal(d)
+	if err != nil {
+		return
+	}
+	c.bus.Publish(ctx, domain.Event{
+		Type:      domain.EventToolApprovalResp,
+		Timestamp: time.Now(),
+		SessionID: c.Name() + ":" + DefaultSessionID,
+		Payload:   payload,
+	})
+}
+
 // extractToolPayload unmarshals a JSON payload into a string map.
 func extractToolPayload(raw json.RawMessage) map[string]string {
 	var m map[string]string
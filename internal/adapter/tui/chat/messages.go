@@ -40,3 +40,14 @@ type ToolExpandMsg struct {
 	Name   string
 	Result string
 }
+
+// ApprovalRequestMsg asks the user to approve a tool call.
+type ApprovalRequestMsg struct {
+	Request domain.ApprovalRequest
+}
+
+// ApprovalResolvedMsg reports that an approval request was decided elsewhere
+// (gateway client, timeout) and its prompt should be dropped.
+type ApprovalResolvedMsg struct {
+	Decision domain.ApprovalDecision
+}
@@ -29,6 +29,9 @@ type ChatModelDeps struct {
 	Logger    *slog.Logger
 	AgentName string
 	ModelName string
+
+	// OnApproval publishes the user's answer to a tool approval prompt.
+	OnApproval func(decision domain.ApprovalDecision)
 }
 
 // ChatModel is the root Bubble Tea model for the chat TUI.
@@ -45,6 +48,7 @@ type ChatModel struct {
 	spinner   spinner.Model
 	searchBar components.SearchBarModel
 	modal     components.ModalModel
+	approval  components.ApprovalDialogModel
 
 	// State
 	waiting   bool   // true while waiting for handler response
@@ -64,6 +68,9 @@ type ChatModel struct {
 	gen      uint64
 	cancelFn context.CancelFunc // cancels the in-flight handler goroutine
 
+	// Pending tool approval prompts, oldest first. The first is shown.
+	approvals []domain.ApprovalRequest
+
 	// Tool tracking for current response (accumulated between ToolStarted/Completed msgs).
 	pendingTools   []components.ToolCallSummary
 	toolStartTimes map[string]time.Time
@@ -117,6 +124,7 @@ func NewChatModel(deps ChatModelDeps) ChatModel {
 		spinner:        s,
 		searchBar:      components.NewSearchBar(),
 		modal:          components.NewModal(),
+		approval:       components.NewApprovalDialog(),
 		streamCfg:      DefaultStreamConfig(),
 		toolStartTimes: make(map[string]time.Time),
 	}
@@ -205,6 +213,27 @@ func (m ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case ApprovalRequestMsg:
+		m.approvals = append(m.approvals, msg.Request)
+		m.syncApproval()
+		return m, nil
+
+	case ApprovalResolvedMsg:
+		for i, req := range m.approvals {
+			if req.ToolCallID == msg.Decision.ToolCallID {
+				m.approvals = append(m.approvals[:i], m.approvals[i+1:]...)
+				if msg.Decision.Reason == "timeout" {
+					m.chatView.AddMessage(components.ChatMessage{
+						Role:    components.RoleSystem,
+						Content: "Approval for " + req.Tool + " timed out; the call was denied.",
+					})
+				}
+				break
+			}
+		}
+		m.syncApproval()
+		return m, nil
+
 	case ToolExpandMsg:
 		m.modal.SetSize(m.width, m.height)
 		m.modal.Open(msg.Name, msg.Result)
@@ -272,9 +301,11 @@ func (m ChatModel) View() string {
 	// Search bar (shown below content when active).
 	searchView := m.searchBar.View()
 
-	// Input area with optional spinner.
+	// Input area with optional spinner; a pending approval takes its place.
 	inputView := m.input.View()
-	if m.waiting {
+	if len(m.approvals) > 0 {
+		inputView = m.approval.View()
+	} else if m.waiting {
 		spinnerStr := m.spinner.View() + " " + m.statusBar.Extra
 		inputView = lipgloss.NewStyle().Faint(true).Render("> waiting for response...") +
 			"\n" + spinnerStr
@@ -310,6 +341,7 @@ func (m *ChatModel) layout() {
 
 	m.tabBar.SetWidth(m.width)
 	m.statusBar.SetWidth(m.width)
+	m.approval.SetWidth(m.width)
 	m.split.SetSize(m.width, contentH)
 
 	leftW := m.split.LeftWidth()
@@ -384,6 +416,11 @@ func (m ChatModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, cmd
 	}
 
+	// A pending approval prompt captures keys until answered.
+	if len(m.approvals) > 0 && msg.Type != tea.KeyCtrlC {
+		return m.handleApprovalKey(msg)
+	}
+
 	// If search input is active, route keys to search bar.
 	if m.searchBar.Mode == components.SearchInput {
 		var cmd tea.Cmd
@@ -515,6 +552,46 @@ func (m ChatModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// handleApprovalKey answers the oldest pending approval prompt.
+func (m ChatModel) handleApprovalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	req := m.approvals[0]
+	decision := domain.ApprovalDecision{ToolCallID: req.ToolCallID, Scope: domain.ApprovalScopeOnce}
+	switch msg.String() {
+	case "y", "Y":
+		decision.Approved = true
+	case "s", "S":
+		decision.Approved = true
+		decision.Scope = domain.ApprovalScopeSession
+	case "n", "N", "esc":
+	default:
+		return m, nil
+	}
+
+	m.approvals = m.approvals[1:]
+	m.syncApproval()
+
+	onApproval := m.deps.OnApproval
+	if onApproval == nil {
+		return m, nil
+	}
+	return m, func() tea.Msg {
+		onApproval(decision)
+		return nil
+	}
+}
+
+// syncApproval points the approval dialog at the oldest pending request.
+func (m *ChatModel) syncApproval() {
+	if len(m.approvals) == 0 {
+		return
+	}
+	req := m.approvals[0]
+	m.approval.Tool = req.Tool
+	m.approval.Arguments = string(req.Arguments)
+	m.approval.Queued = len(m.approvals) - 1
+	m.approval.SetWidth(m.width)
+}
+
 func vimHints() []components.KeyHint {
 	return []components.KeyHint{
 		{Key: "j/k", Desc: "Scroll"},
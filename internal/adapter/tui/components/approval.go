@@ -0,0 +1,62 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"alfred-ai/internal/adapter/tui/theme"
+)
+
+// maxDialogArgs caps how much of a tool call's arguments the dialog shows.
+const maxDialogArgs = 400
+
+// ApprovalDialogModel is a bordered prompt asking the user to approve a tool
+// call. It replaces the input area while a request is pending.
+type ApprovalDialogModel struct {
+	Tool      string
+	Arguments string
+	Queued    int // further requests waiting behind this one
+	width     int
+}
+
+// NewApprovalDialog creates an approval dialog.
+func NewApprovalDialog() ApprovalDialogModel {
+	return ApprovalDialogModel{}
+}
+
+// SetWidth updates the available width.
+func (m *ApprovalDialogModel) SetWidth(w int) {
+	m.width = w
+}
+
+// View renders the dialog.
+func (m ApprovalDialogModel) View() string {
+	title := theme.TextWarning.Render(theme.SymbolWarning + " Approve tool call: " + m.Tool)
+
+	lines := []string{title}
+	if args := strings.TrimSpace(m.Arguments); args != "" && args != "{}" && args != "null" {
+		if len(args) > maxDialogArgs {
+			args = args[:maxDialogArgs] + theme.SymbolEllipsis
+		}
+		lines = append(lines, theme.TextMuted.Render(args))
+	}
+
+	keys := theme.Bold.Render("y") + " approve  " +
+		theme.Bold.Render("s") + " approve for session  " +
+		theme.Bold.Render("n") + "/" + theme.Bold.Render("Esc") + " deny"
+	if m.Queued > 0 {
+		keys += theme.Dim.Render(fmt.Sprintf("  (%d more waiting)", m.Queued))
+	}
+	lines = append(lines, keys)
+
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.ColorWarning).
+		Padding(0, 1)
+	if m.width > 4 {
+		style = style.Width(m.width - 2)
+	}
+	return style.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -2,7 +2,9 @@ package components
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -15,14 +17,20 @@ import (
 
 // MemoryQueryMsg triggers a memory query.
 type MemoryQueryMsg struct {
-	Query string
+	Query domain.MemoryQuery
 }
 
+// MemoryPageSize is the number of results per memory viewer page.
+const MemoryPageSize = 50
+
 // MemoryViewerModel provides a query input + results table for memory browsing.
+// Besides free text, the input accepts filter terms (see ParseMemoryQuery);
+// n and p page through results while the table has focus.
 type MemoryViewerModel struct {
 	QueryInput textinput.Model
 	Table      table.Model
-	entries    []domain.MemoryEntry
+	entries    []domain.ScoredMemoryEntry
+	query      domain.MemoryQuery // last query sent, for paging
 	selected   *domain.MemoryEntry
 	err        string
 	ready      bool
@@ -30,6 +38,72 @@ type MemoryViewerModel struct {
 	height     int
 }
 
+// ParseMemoryQuery builds a query from viewer input. Terms of the form
+// tag:x, -tag:x, source:x, key=value, after:T, before:T and min:score are
+// filters; everything else is search text. T is a date (2006-01-02), an
+// RFC 3339 time, or an age such as 36h or 7d. Bounds apply to creation time.
+func ParseMemoryQuery(input string, now time.Time) (domain.MemoryQuery, error) {
+	q := domain.MemoryQuery{Limit: MemoryPageSize}
+	var text []string
+	for _, term := range strings.Fields(input) {
+		key, value, ok := strings.Cut(term, ":")
+		switch {
+		case ok && key == "tag":
+			q.Tags = append(q.Tags, value)
+		case ok && key == "-tag":
+			q.ExcludeTags = append(q.ExcludeTags, value)
+		case ok && key == "source":
+			q.Source = value
+		case ok && (key == "after" || key == "before"):
+			t, err := parseMemoryTime(value, now)
+			if err != nil {
+				return q, fmt.Errorf("%s: %w", key, err)
+			}
+			if key == "after" {
+				q.CreatedAfter = t
+			} else {
+				q.CreatedBefore = t
+			}
+		case ok && key == "min":
+			score, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return q, fmt.Errorf("min: invalid score %q", value)
+			}
+			q.MinScore = score
+		default:
+			if k, v, isMeta := strings.Cut(term, "="); isMeta && k != "" {
+				if q.Metadata == nil {
+					q.Metadata = make(map[string]string)
+				}
+				q.Metadata[k] = v
+				continue
+			}
+			text = append(text, term)
+		}
+	}
+	q.Text = strings.Join(text, " ")
+	return q, nil
+}
+
+// parseMemoryTime accepts a date, an RFC 3339 time, or an age before now.
+func parseMemoryTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
 // NewMemoryViewer creates a memory viewer.
 func NewMemoryViewer() MemoryViewerModel {
 	qi := textinput.New()
@@ -54,7 +128,7 @@ func (m *MemoryViewerModel) SetSize(w, h int) {
 }
 
 // SetResults updates the displayed entries.
-func (m *MemoryViewerModel) SetResults(entries []domain.MemoryEntry) {
+func (m *MemoryViewerModel) SetResults(entries []domain.ScoredMemoryEntry) {
 	m.entries = entries
 	m.err = ""
 	m.selected = nil
@@ -77,12 +151,16 @@ func (m MemoryViewerModel) Update(msg tea.Msg) (MemoryViewerModel, tea.Cmd) {
 		case tea.KeyEnter:
 			// If query input has focus, fire a query.
 			if m.QueryInput.Focused() {
-				query := strings.TrimSpace(m.QueryInput.Value())
-				if query != "" {
-					return m, func() tea.Msg {
-						return MemoryQueryMsg{Query: query}
-					}
+				input := strings.TrimSpace(m.QueryInput.Value())
+				if input == "" {
+					break
+				}
+				q, err := ParseMemoryQuery(input, time.Now())
+				if err != nil {
+					m.err = err.Error()
+					return m, nil
 				}
+				return m, m.runQuery(q)
 			}
 		case tea.KeyTab:
 			// Switch focus between query input and table.
@@ -94,6 +172,26 @@ func (m MemoryViewerModel) Update(msg tea.Msg) (MemoryViewerModel, tea.Cmd) {
 				m.QueryInput.Focus()
 			}
 			return m, nil
+		case tea.KeyRunes:
+			if m.QueryInput.Focused() || m.query.Limit == 0 {
+				break
+			}
+			switch keyMsg.String() {
+			case "n":
+				if len(m.entries) == m.query.Limit {
+					q := m.query
+					q.Offset += q.Limit
+					return m, m.runQuery(q)
+				}
+				return m, nil
+			case "p":
+				if m.query.Offset > 0 {
+					q := m.query
+					q.Offset = max(q.Offset-q.Limit, 0)
+					return m, m.runQuery(q)
+				}
+				return m, nil
+			}
 		}
 	}
 
@@ -112,6 +210,14 @@ func (m MemoryViewerModel) Update(msg tea.Msg) (MemoryViewerModel, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// runQuery records q as the current query and returns a command to run it.
+func (m *MemoryViewerModel) runQuery(q domain.MemoryQuery) tea.Cmd {
+	m.query = q
+	return func() tea.Msg {
+		return MemoryQueryMsg{Query: q}
+	}
+}
+
 // View renders the memory viewer.
 func (m MemoryViewerModel) View() string {
 	if !m.ready {
@@ -126,9 +232,11 @@ func (m MemoryViewerModel) View() string {
 	if m.err != "" {
 		resultsView = theme.TextError.Render("  " + theme.SymbolError + " " + m.err)
 	} else if len(m.entries) == 0 {
-		resultsView = theme.TextMuted.Render("  No results. Enter a query to search memory.")
+		resultsView = theme.TextMuted.Render("  No results. Enter a query to search memory (filters: tag:x -tag:x source:x key=value after:7d before:2006-01-02 min:0.5).")
 	} else {
-		header := theme.TextMuted.Render(fmt.Sprintf("  Results: %d entries", len(m.entries)))
+		first := m.query.Offset + 1
+		header := theme.TextMuted.Render(fmt.Sprintf("  Results %d-%d  (n/p: next/previous page)",
+			first, first+len(m.entries)-1))
 		tableView := lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
 			BorderForeground(theme.ColorBorder).
@@ -157,7 +265,7 @@ func (m *MemoryViewerModel) rebuildTable() {
 	}
 
 	idW := 10
-	contentW := m.width - idW - 20 - 10
+	contentW := m.width - idW - 20 - 10 - 8
 	if contentW < 20 {
 		contentW = 20
 	}
@@ -167,6 +275,7 @@ func (m *MemoryViewerModel) rebuildTable() {
 		{Title: "Content", Width: contentW},
 		{Title: "Tags", Width: 15},
 		{Title: "Age", Width: 8},
+		{Title: "Score", Width: 6},
 	}
 
 	var rows []table.Row
@@ -177,7 +286,7 @@ func (m *MemoryViewerModel) rebuildTable() {
 		}
 		tags := strings.Join(e.Tags, ", ")
 		age := RelativeTime(e.CreatedAt)
-		rows = append(rows, table.Row{e.ID, content, tags, age})
+		rows = append(rows, table.Row{e.ID, content, tags, age, fmt.Sprintf("%.2f", e.Score)})
 	}
 
 	tableH := m.height - 8
@@ -2,16 +2,27 @@ package dashboard
 
 import (
 	"context"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"alfred-ai/internal/domain"
 )
 
-// queryMemoryCmd runs a memory query asynchronously.
-func queryMemoryCmd(mem domain.MemoryProvider, query string) tea.Cmd {
+// queryMemoryCmd runs a structured memory query asynchronously.
+func queryMemoryCmd(mem domain.MemoryProvider, query domain.MemoryQuery) tea.Cmd {
 	return func() tea.Msg {
-		entries, err := mem.Query(context.Background(), query, 50)
+		entries, err := domain.QueryMemory(context.Background(), mem, query)
 		return MemoryQueryResultMsg{Entries: entries, Err: err}
 	}
 }
+
+// usageRefreshInterval is how often the overview re-reads LLM usage.
+const usageRefreshInterval = 5 * time.Second
+
+// loadUsageCmd reads the usage snapshot asynchronously.
+func loadUsageCmd(usage domain.UsageReporter) tea.Cmd {
+	return func() tea.Msg {
+		return UsageMsg{Summary: usage.Summary()}
+	}
+}
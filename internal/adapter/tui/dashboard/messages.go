@@ -10,6 +10,14 @@ type EventBusMsg struct {
 
 // MemoryQueryResultMsg carries the result of a memory query.
 type MemoryQueryResultMsg struct {
-	Entries []domain.MemoryEntry
+	Entries []domain.ScoredMemoryEntry
 	Err     error
 }
+
+// UsageMsg carries a fresh LLM usage snapshot for the overview tab.
+type UsageMsg struct {
+	Summary domain.UsageSummary
+}
+
+// usageTickMsg triggers the next usage refresh.
+type usageTickMsg struct{}
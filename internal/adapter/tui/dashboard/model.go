@@ -3,6 +3,7 @@ package dashboard
 import (
 	"context"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -34,6 +35,7 @@ type DashboardDeps struct {
 	AgentName    string
 	ModelName    string
 	ProviderName string
+	Usage        domain.UsageReporter // can be nil (usage accounting disabled)
 }
 
 // DashboardModel is the root Bubble Tea model for the monitoring dashboard.
@@ -102,13 +104,16 @@ func (m *DashboardModel) SetProgramSender(send func(tea.Msg)) {
 	m.programSend = send
 }
 
-// Init subscribes to the EventBus.
+// Init subscribes to the EventBus and starts polling LLM usage.
 func (m *DashboardModel) Init() tea.Cmd {
 	if m.deps.Bus != nil && m.programSend != nil {
 		m.unsubscribe = m.deps.Bus.SubscribeAll(func(_ context.Context, event domain.Event) {
 			m.programSend(EventBusMsg{Event: event})
 		})
 	}
+	if m.deps.Usage != nil {
+		return loadUsageCmd(m.deps.Usage)
+	}
 	return nil
 }
 
@@ -177,6 +182,13 @@ func (m *DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case UsageMsg:
+		m.overview.SetUsage(msg.Summary)
+		return m, tea.Tick(usageRefreshInterval, func(time.Time) tea.Msg { return usageTickMsg{} })
+
+	case usageTickMsg:
+		return m, loadUsageCmd(m.deps.Usage)
+
 	case components.MemoryQueryMsg:
 		if m.deps.Memory != nil {
 			return m, queryMemoryCmd(m.deps.Memory, msg.Query)
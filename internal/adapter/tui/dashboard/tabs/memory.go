@@ -25,7 +25,7 @@ func (m *MemoryModel) SetSize(w, h int) {
 }
 
 // SetResults updates displayed entries.
-func (m *MemoryModel) SetResults(entries []domain.MemoryEntry) {
+func (m *MemoryModel) SetResults(entries []domain.ScoredMemoryEntry) {
 	m.Viewer.SetResults(entries)
 }
 
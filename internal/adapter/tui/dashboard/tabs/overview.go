@@ -10,6 +10,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"alfred-ai/internal/adapter/tui/theme"
+	"alfred-ai/internal/domain"
 )
 
 // AgentStat represents an agent's status for display.
@@ -29,6 +30,7 @@ type OverviewModel struct {
 	MemoryCount  int
 	ErrorCount   int
 	StartedAt    time.Time
+	Usage        *domain.UsageSummary // nil until usage accounting reports
 	width        int
 	height       int
 }
@@ -69,6 +71,9 @@ func (m *OverviewModel) IncrementMemory() { m.MemoryCount++ }
 // IncrementErrors increments the error counter.
 func (m *OverviewModel) IncrementErrors() { m.ErrorCount++ }
 
+// SetUsage replaces the LLM usage snapshot.
+func (m *OverviewModel) SetUsage(summary domain.UsageSummary) { m.Usage = &summary }
+
 // Update is a no-op for the overview tab.
 func (m OverviewModel) Update(_ tea.Msg) (OverviewModel, tea.Cmd) {
 	return m, nil
@@ -137,5 +142,42 @@ func (m OverviewModel) View() string {
 
 	sb.WriteString("  " + strings.Join(statParts, "  "+lipgloss.NewStyle().Foreground(theme.ColorBorder).Render("|")+"  ") + "\n")
 
+	if m.Usage != nil {
+		sb.WriteString("\n")
+		m.viewUsage(&sb)
+	}
+
 	return sb.String()
 }
+
+// viewUsage renders LLM spend and budget status.
+func (m OverviewModel) viewUsage(sb *strings.Builder) {
+	sb.WriteString(theme.Bold.Render("  LLM Cost") + "\n")
+
+	totals := func(label string, t domain.UsageTotals) string {
+		return fmt.Sprintf("%s: %s %s", theme.TextMuted.Render(label),
+			theme.StatValue.Render(fmt.Sprintf("$%.2f", t.CostUSD)),
+			theme.TextMuted.Render(fmt.Sprintf("(%d calls, %d tokens)", t.Calls, t.Usage.TotalTokens)))
+	}
+	sep := "  " + lipgloss.NewStyle().Foreground(theme.ColorBorder).Render("|") + "  "
+	sb.WriteString("  " + totals("Today", m.Usage.Today) + sep + totals("Month", m.Usage.Month) + "\n")
+
+	for _, b := range m.Usage.Budgets {
+		scope := string(b.Scope)
+		if b.Key != "" {
+			scope += " " + b.Key
+		}
+		line := fmt.Sprintf("  %-28s %-6s $%.2f / $%.2f  %s", scope, b.Period, b.Spent, b.Limit, b.Action)
+		switch {
+		case b.Exceeded():
+			sb.WriteString(theme.TextError.Render(line+" "+theme.SymbolWarning) + "\n")
+		case b.Spent >= 0.8*b.Limit:
+			sb.WriteString(theme.TextWarning.Render(line) + "\n")
+		default:
+			sb.WriteString(line + "\n")
+		}
+	}
+	if len(m.Usage.Unpriced) > 0 {
+		sb.WriteString(theme.TextMuted.Render("  No price for: "+strings.Join(m.Usage.Unpriced, ", ")) + "\n")
+	}
+}
@@ -11,6 +11,7 @@ type AuditEventType string
 const (
 	AuditLLMCall      AuditEventType = "llm_call"
 	AuditToolExec     AuditEventType = "tool_exec"
+	AuditToolApproval AuditEventType = "tool_approval"
 	AuditMemorySync   AuditEventType = "memory_sync"
 	AuditMemoryStore  AuditEventType = "memory_store"
 	AuditMemoryDelete AuditEventType = "memory_delete"
@@ -43,6 +44,13 @@ const (
 	AuditGDPRDelete    AuditEventType = "gdpr_delete"
 	AuditGDPRAnonymize AuditEventType = "gdpr_anonymize"
 	AuditRBACDenied    AuditEventType = "rbac_denied"
+
+	// AuditWebhook records each inbound webhook delivery and its outcome.
+	AuditWebhook AuditEventType = "webhook"
+
+	// AuditCheckpoint is written by log retention in place of the removed
+	// records, so the remaining hash chain stays verifiable.
+	AuditCheckpoint AuditEventType = "audit_checkpoint"
 )
 
 // AuditEvent represents a single auditable action.
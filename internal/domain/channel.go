@@ -1,6 +1,9 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"strings"
+)
 
 // MediaType identifies the kind of media attached to a message.
 type MediaType string
@@ -20,6 +23,32 @@ type Media struct {
 	MIMEType string    `json:"mime_type,omitempty"`
 	Data     []byte    `json:"data,omitempty"`
 	Caption  string    `json:"caption,omitempty"`
+	Filename string    `json:"filename,omitempty"`
+}
+
+// ContentPart converts an image or file attachment into a message content
+// part. It returns false for other media types and for attachments with
+// neither inline data nor an http(s) URL (e.g. an unresolved platform file ID).
+func (m Media) ContentPart() (ContentPart, bool) {
+	var typ ContentPartType
+	switch m.Type {
+	case MediaTypeImage:
+		typ = ContentPartImage
+	case MediaTypeFile:
+		typ = ContentPartFile
+	default:
+		return ContentPart{}, false
+	}
+	part := ContentPart{Type: typ, MIMEType: m.MIMEType, Filename: m.Filename}
+	switch {
+	case len(m.Data) > 0:
+		part.Data = m.Data
+	case strings.HasPrefix(m.URL, "https://") || strings.HasPrefix(m.URL, "http://"):
+		part.URL = m.URL
+	default:
+		return ContentPart{}, false
+	}
+	return part, true
 }
 
 // InboundMessage is a message received from a channel (user input).
@@ -37,6 +66,10 @@ type InboundMessage struct {
 	Media      []Media           `json:"media,omitempty"`
 	Metadata   map[string]string `json:"metadata,omitempty"`
 	IsMention  bool              `json:"is_mention,omitempty"`
+
+	// AgentID pins the message to a specific agent in multi-agent mode,
+	// bypassing the agent router. Ignored in single-agent mode.
+	AgentID string `json:"agent_id,omitempty"`
 }
 
 // OutboundMessage is a message sent to a channel (agent response).
@@ -19,3 +19,58 @@ func SessionIDFromContext(ctx context.Context) string {
 	}
 	return ""
 }
+
+const sessionKeyCtxKey ctxKey = "session_key"
+
+// ContextWithSessionKey returns a new context carrying the session's router
+// key ("channel:chatID").
+func ContextWithSessionKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, sessionKeyCtxKey, key)
+}
+
+// SessionKeyFromContext extracts the session's router key from the context.
+// Returns empty string if not set.
+func SessionKeyFromContext(ctx context.Context) string {
+	if v, ok := ctx.Value(sessionKeyCtxKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+const agentCtxKey ctxKey = "agent_id"
+
+// ContextWithAgentID returns a new context carrying the ID of the agent
+// handling the request (multi-agent mode).
+func ContextWithAgentID(ctx context.Context, agentID string) context.Context {
+	return context.WithValue(ctx, agentCtxKey, agentID)
+}
+
+// AgentIDFromContext extracts the agent ID from the context.
+// Returns empty string if not set.
+func AgentIDFromContext(ctx context.Context) string {
+	if v, ok := ctx.Value(agentCtxKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+const streamSinkCtxKey ctxKey = "stream_sink"
+
+// StreamSink receives streaming deltas synchronously and in order, unlike
+// EventStreamDelta subscribers on the asynchronous event bus.
+type StreamSink func(StreamDeltaPayload)
+
+// ContextWithStreamSink returns a new context carrying a stream sink for
+// the agent to feed while it streams a response.
+func ContextWithStreamSink(ctx context.Context, sink StreamSink) context.Context {
+	return context.WithValue(ctx, streamSinkCtxKey, sink)
+}
+
+// StreamSinkFromContext extracts the stream sink from the context.
+// Returns nil if not set.
+func StreamSinkFromContext(ctx context.Context) StreamSink {
+	if v, ok := ctx.Value(streamSinkCtxKey).(StreamSink); ok {
+		return v
+	}
+	return nil
+}
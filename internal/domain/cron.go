@@ -2,22 +2,28 @@ package domain
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 )
 
 // CronJob represents a runtime-created scheduled job managed by the LLM.
 type CronJob struct {
-	ID        string            `json:"id"`
-	Name      string            `json:"name"`
-	Schedule  CronSchedule      `json:"schedule"`
-	Action    CronAction        `json:"action"`
-	Enabled   bool              `json:"enabled"`
-	CreatedAt time.Time         `json:"created_at"`
-	UpdatedAt time.Time         `json:"updated_at"`
-	LastRunAt *time.Time        `json:"last_run_at,omitempty"`
-	NextRunAt *time.Time        `json:"next_run_at,omitempty"`
-	RunCount  int               `json:"run_count"`
-	Metadata  map[string]string `json:"metadata,omitempty"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Schedule    CronSchedule      `json:"schedule"`
+	Action      CronAction        `json:"action"`
+	Retry       *CronRetryPolicy  `json:"retry,omitempty"`
+	Misfire     string            `json:"misfire,omitempty"`      // see CronMisfire* constants; "" = skip
+	MaxCatchUp  int               `json:"max_catch_up,omitempty"` // run_all cap; 0 = 10
+	Concurrency string            `json:"concurrency,omitempty"`  // see CronConcurrency* constants; "" = allow
+	JitterMs    int64             `json:"jitter_ms,omitempty"`    // random delay of up to this much added to each run time
+	Enabled     bool              `json:"enabled"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	LastRunAt   *time.Time        `json:"last_run_at,omitempty"`
+	NextRunAt   *time.Time        `json:"next_run_at,omitempty"`
+	RunCount    int               `json:"run_count"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
 }
 
 // CronSchedule supports three kinds: "at" (one-shot), "every" (interval), "cron" (expression).
@@ -26,14 +32,54 @@ type CronSchedule struct {
 	At         string `json:"at,omitempty"`         // ISO 8601 timestamp for one-shot
 	EveryMs    int64  `json:"every_ms,omitempty"`   // interval in milliseconds
 	Expression string `json:"expression,omitempty"` // cron expression e.g. "*/5 * * * *"
+	TimeZone   string `json:"time_zone,omitempty"`  // IANA zone for Expression, e.g. "Europe/Berlin"; default local
 }
 
-// CronAction defines what a job does when triggered.
+// Cron misfire policies decide what happens to runs missed while the
+// process was down.
+const (
+	CronMisfireSkip    = "skip"     // drop missed runs
+	CronMisfireRunOnce = "run_once" // run once for all missed runs
+	CronMisfireRunAll  = "run_all"  // run each missed run, up to MaxCatchUp
+)
+
+// Cron concurrency policies decide what happens when a job fires while its
+// previous run is still going.
+const (
+	CronConcurrencyAllow   = "allow"   // run both
+	CronConcurrencyForbid  = "forbid"  // skip the new run
+	CronConcurrencyReplace = "replace" // cancel the previous run
+)
+
+// Cron action kinds.
+const (
+	CronActionAgentRun    = "agent_run"    // send Message to an agent
+	CronActionWorkflowRun = "workflow_run" // run the Workflow pipeline with Args
+	CronActionToolCall    = "tool_call"    // call Tool with Params, no LLM involved
+	CronActionNotify      = "notify"       // send the Message template to Channel
+)
+
+// CronAction defines what a job does when triggered. When Channel is set,
+// the result (agent reply, workflow output, tool output or notification)
+// is sent to Target on that channel.
 type CronAction struct {
-	Kind    string `json:"kind"` // "agent_run"
-	AgentID string `json:"agent_id,omitempty"`
-	Channel string `json:"channel,omitempty"`
-	Message string `json:"message"`
+	Kind     string            `json:"kind"` // see CronAction* constants
+	AgentID  string            `json:"agent_id,omitempty"`
+	Channel  string            `json:"channel,omitempty"`
+	Target   string            `json:"target,omitempty"` // chat, channel or user ID on Channel
+	Message  string            `json:"message"`          // agent prompt, or text/template for notify
+	Workflow string            `json:"workflow,omitempty"`
+	Args     map[string]string `json:"args,omitempty"` // workflow arguments
+	Tool     string            `json:"tool,omitempty"`
+	Params   json.RawMessage   `json:"params,omitempty"` // tool parameters
+}
+
+// CronRetryPolicy retries a failed run with exponential backoff. All
+// attempts of one run share the scheduler's per-run timeout.
+type CronRetryPolicy struct {
+	MaxAttempts  int   `json:"max_attempts"`             // including the first; 0 or 1 = no retry
+	BackoffMs    int64 `json:"backoff_ms,omitempty"`     // delay before the first retry, doubled after each; 0 = 1s
+	MaxBackoffMs int64 `json:"max_backoff_ms,omitempty"` // cap on the delay; 0 = 1m
 }
 
 // CronRun records one execution of a cron job.
@@ -43,6 +89,10 @@ type CronRun struct {
 	Duration  string    `json:"duration"`
 	Success   bool      `json:"success"`
 	Error     string    `json:"error,omitempty"`
+	Attempts  int       `json:"attempts,omitempty"`
+	Output    string    `json:"output,omitempty"` // truncated result
+	Delivered bool      `json:"delivered,omitempty"`
+	CatchUp   bool      `json:"catch_up,omitempty"` // run for a time missed while down
 }
 
 // CronStore provides persistent storage for cron jobs and their execution history.
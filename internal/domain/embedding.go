@@ -11,3 +11,19 @@ type EmbeddingProvider interface {
 	// Name returns the provider's identifier (e.g., "openai", "gemini").
 	Name() string
 }
+
+// EmbeddingModeler is an optional interface for EmbeddingProviders that can
+// report which model produces their vectors.
+type EmbeddingModeler interface {
+	Model() string
+}
+
+// EmbeddingModelID identifies the embedder behind p's vectors, such as
+// "openai/text-embedding-3-small". Vectors from different IDs are not
+// comparable.
+func EmbeddingModelID(p EmbeddingProvider) string {
+	if m, ok := p.(EmbeddingModeler); ok && m.Model() != "" {
+		return p.Name() + "/" + m.Model()
+	}
+	return p.Name()
+}
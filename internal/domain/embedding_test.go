@@ -2,6 +2,7 @@ package domain_test
 
 import (
 	"context"
+	"testing"
 
 	"alfred-ai/internal/domain"
 )
@@ -21,3 +22,16 @@ func (s *stubEmbedder) Embed(_ context.Context, texts []string) ([][]float32, er
 
 func (s *stubEmbedder) Dimensions() int { return 3 }
 func (s *stubEmbedder) Name() string    { return "stub" }
+
+type modelStubEmbedder struct{ stubEmbedder }
+
+func (modelStubEmbedder) Model() string { return "small" }
+
+func TestEmbeddingModelID(t *testing.T) {
+	if got := domain.EmbeddingModelID(&stubEmbedder{}); got != "stub" {
+		t.Errorf("without model = %q, want stub", got)
+	}
+	if got := domain.EmbeddingModelID(&modelStubEmbedder{}); got != "stub/small" {
+		t.Errorf("with model = %q, want stub/small", got)
+	}
+}
@@ -41,6 +41,9 @@ var (
 	ErrMemoryDelete        = fmt.Errorf("memory delete failed")
 	ErrToolApprovalDenied  = fmt.Errorf("tool approval denied")
 	ErrToolApprovalTimeout = fmt.Errorf("tool approval timed out")
+	ErrVisionUnsupported   = fmt.Errorf("provider does not support image input")
+	ErrContentUnsupported  = fmt.Errorf("provider does not support this content type")
+	ErrResponseFormat      = fmt.Errorf("reply does not match the response format")
 
 	// Gateway / RPC errors.
 	ErrGatewayAuthFailed = fmt.Errorf("gateway: %w", ErrAuthInvalid)
@@ -214,6 +217,7 @@ const (
 	CodeVoiceCallProvider  ErrorCode = "VOICE_CALL_PROVIDER"
 	CodeVoiceCallPhone     ErrorCode = "VOICE_CALL_INVALID_PHONE"
 	CodeVoiceCallWebhook   ErrorCode = "VOICE_CALL_WEBHOOK"
+	CodeBudgetExceeded     ErrorCode = "BUDGET_EXCEEDED"
 
 	// Category error codes — fallback codes when no subsystem-specific code matches.
 	CodeNotFound         ErrorCode = "NOT_FOUND"
@@ -317,6 +321,7 @@ var subSystemCodeMap = map[error]map[string]ErrorCode{
 		"canvas":    CodeCanvasContentSize,
 		"camera":    CodeCameraPayload,
 		"tenant":    CodeTenantLimitHit,
+		"usage":     CodeBudgetExceeded,
 	},
 	ErrPermissionDenied: {
 		"plugin":   CodePluginPermission,
@@ -68,6 +68,19 @@ const (
 	EventWorkflowFailed    EventType = "workflow.failed"
 	EventWorkflowPaused    EventType = "workflow.paused"
 	EventWorkflowResumed   EventType = "workflow.resumed"
+
+	// Vector memory re-embedding events.
+	EventMemoryReembedProgress  EventType = "memory.reembed.progress"
+	EventMemoryReembedCompleted EventType = "memory.reembed.completed"
+
+	// Event trigger events.
+	EventTriggerFired EventType = "trigger.fired"
+
+	// Usage and budget events.
+	EventBudgetExceeded EventType = "usage.budget.exceeded"
+
+	// Smart home events.
+	EventSmartHomeStateChanged EventType = "smarthome.state_changed"
 )
 
 // Event is the envelope published on the event bus.
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"slices"
 	"time"
 )
 
@@ -39,3 +40,130 @@ type MemoryProvider interface {
 type BatchStorer interface {
 	StoreBatch(ctx context.Context, entries []MemoryEntry) error
 }
+
+// MemoryQuery is a structured memory search. Zero-valued fields do not
+// filter; an empty Text matches every entry, newest first.
+type MemoryQuery struct {
+	Text          string            `json:"query,omitempty"`
+	Tags          []string          `json:"tags,omitempty"`         // entry must carry all of these
+	ExcludeTags   []string          `json:"exclude_tags,omitempty"` // entry must carry none of these
+	Metadata      map[string]string `json:"metadata,omitempty"`     // exact match on every key
+	Source        string            `json:"source,omitempty"`       // shorthand for Metadata["source"]
+	CreatedAfter  time.Time         `json:"created_after,omitzero"`
+	CreatedBefore time.Time         `json:"created_before,omitzero"`
+	UpdatedAfter  time.Time         `json:"updated_after,omitzero"`
+	UpdatedBefore time.Time         `json:"updated_before,omitzero"`
+	MinScore      float64           `json:"min_score,omitempty"`
+	Limit         int               `json:"limit,omitempty"` // 0 = provider default
+	Offset        int               `json:"offset,omitempty"`
+}
+
+// MaxMemoryQueryOffset bounds how deep a MemoryQuery may page. Providers
+// search for Offset+Limit entries, so pages past it come back empty.
+const MaxMemoryQueryOffset = 1000
+
+// MetadataFilter returns the metadata equality filter, with Source folded in.
+func (q MemoryQuery) MetadataFilter() map[string]string {
+	if q.Source == "" {
+		return q.Metadata
+	}
+	m := make(map[string]string, len(q.Metadata)+1)
+	for k, v := range q.Metadata {
+		m[k] = v
+	}
+	m["source"] = q.Source
+	return m
+}
+
+// HasFilters reports whether the query restricts results beyond Text.
+func (q MemoryQuery) HasFilters() bool {
+	return len(q.Tags) > 0 || len(q.ExcludeTags) > 0 || len(q.Metadata) > 0 || q.Source != "" ||
+		!q.CreatedAfter.IsZero() || !q.CreatedBefore.IsZero() ||
+		!q.UpdatedAfter.IsZero() || !q.UpdatedBefore.IsZero()
+}
+
+// Matches reports whether entry passes the tag, metadata and time filters.
+// Text and MinScore are not considered. Time bounds are inclusive.
+func (q MemoryQuery) Matches(entry MemoryEntry) bool {
+	for _, tag := range q.Tags {
+		if !slices.Contains(entry.Tags, tag) {
+			return false
+		}
+	}
+	for _, tag := range q.ExcludeTags {
+		if slices.Contains(entry.Tags, tag) {
+			return false
+		}
+	}
+	for k, v := range q.MetadataFilter() {
+		if got, ok := entry.Metadata[k]; !ok || got != v {
+			return false
+		}
+	}
+	switch {
+	case !q.CreatedAfter.IsZero() && entry.CreatedAt.Before(q.CreatedAfter),
+		!q.CreatedBefore.IsZero() && entry.CreatedAt.After(q.CreatedBefore),
+		!q.UpdatedAfter.IsZero() && entry.UpdatedAt.Before(q.UpdatedAfter),
+		!q.UpdatedBefore.IsZero() && entry.UpdatedAt.After(q.UpdatedBefore):
+		return false
+	}
+	return true
+}
+
+// ScoredMemoryEntry is a query result with its relevance score. Higher is
+// more relevant; the scale depends on the provider.
+type ScoredMemoryEntry struct {
+	MemoryEntry
+	Score float64 `json:"score"`
+}
+
+// StructuredQuerier is an optional interface that MemoryProvider
+// implementations can support to filter, page and score results natively.
+type StructuredQuerier interface {
+	QueryStructured(ctx context.Context, q MemoryQuery) ([]ScoredMemoryEntry, error)
+}
+
+// QueryMemory runs a structured query against p. Providers that do not
+// implement StructuredQuerier are queried by text and filtered afterwards,
+// with scores derived from result rank.
+func QueryMemory(ctx context.Context, p MemoryProvider, q MemoryQuery) ([]ScoredMemoryEntry, error) {
+	if sq, ok := p.(StructuredQuerier); ok {
+		return sq.QueryStructured(ctx, q)
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	fetch := min(max(q.Offset, 0), MaxMemoryQueryOffset) + limit
+	if q.HasFilters() {
+		fetch *= 4 // over-fetch so filtering still fills the page
+	}
+	entries, err := p.Query(ctx, q.Text, fetch)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []ScoredMemoryEntry
+	for i, e := range entries {
+		score := 1 / float64(i+1)
+		if !q.Matches(e) || score < q.MinScore {
+			continue
+		}
+		results = append(results, ScoredMemoryEntry{MemoryEntry: e, Score: score})
+	}
+	return PageMemoryResults(results, q.Offset, limit), nil
+}
+
+// PageMemoryResults applies offset and limit to ranked results.
+func PageMemoryResults(results []ScoredMemoryEntry, offset, limit int) []ScoredMemoryEntry {
+	offset = max(offset, 0)
+	if offset >= len(results) {
+		return []ScoredMemoryEntry{}
+	}
+	results = results[offset:]
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
@@ -0,0 +1,102 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+// listMemory returns its entries in order, ignoring the query text.
+type listMemory struct {
+	entries []domain.MemoryEntry
+}
+
+func (m *listMemory) Store(context.Context, domain.MemoryEntry) error { return nil }
+func (m *listMemory) Query(_ context.Context, _ string, limit int) ([]domain.MemoryEntry, error) {
+	return m.entries[:min(limit, len(m.entries))], nil
+}
+func (m *listMemory) Delete(context.Context, string) error { return nil }
+func (m *listMemory) Curate(context.Context, []domain.Message) (*domain.CurateResult, error) {
+	return &domain.CurateResult{}, nil
+}
+func (m *listMemory) Sync(context.Context) error { return nil }
+func (m *listMemory) Name() string               { return "list" }
+func (m *listMemory) IsAvailable() bool          { return true }
+
+func TestMemoryQueryMatches(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entry := domain.MemoryEntry{
+		Tags:      []string{"ops", "api"},
+		Metadata:  map[string]string{"source": "chat", "project": "x"},
+		CreatedAt: now,
+		UpdatedAt: now.Add(time.Hour),
+	}
+
+	tests := []struct {
+		name string
+		q    domain.MemoryQuery
+		want bool
+	}{
+		{"empty", domain.MemoryQuery{}, true},
+		{"all tags", domain.MemoryQuery{Tags: []string{"ops", "api"}}, true},
+		{"missing tag", domain.MemoryQuery{Tags: []string{"ops", "db"}}, false},
+		{"excluded tag", domain.MemoryQuery{ExcludeTags: []string{"api"}}, false},
+		{"metadata", domain.MemoryQuery{Metadata: map[string]string{"project": "x"}}, true},
+		{"metadata mismatch", domain.MemoryQuery{Metadata: map[string]string{"project": "y"}}, false},
+		{"source", domain.MemoryQuery{Source: "chat"}, true},
+		{"source mismatch", domain.MemoryQuery{Source: "auto-curate"}, false},
+		{"created inclusive", domain.MemoryQuery{CreatedAfter: now, CreatedBefore: now}, true},
+		{"created after", domain.MemoryQuery{CreatedAfter: now.Add(time.Minute)}, false},
+		{"updated before", domain.MemoryQuery{UpdatedBefore: now}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.Matches(entry); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryMemoryFallback(t *testing.T) {
+	p := &listMemory{entries: []domain.MemoryEntry{
+		{ID: "1", Tags: []string{"keep"}},
+		{ID: "2"},
+		{ID: "3", Tags: []string{"keep"}},
+		{ID: "4", Tags: []string{"keep"}},
+	}}
+
+	results, err := domain.QueryMemory(context.Background(), p, domain.MemoryQuery{Tags: []string{"keep"}, Limit: 2, Offset: 1})
+	if err != nil {
+		t.Fatalf("QueryMemory: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "3" || results[1].ID != "4" {
+		t.Fatalf("results = %+v, want [3 4]", results)
+	}
+	if results[0].Score != 1.0/3 {
+		t.Errorf("score = %v, want rank-based 1/3", results[0].Score)
+	}
+
+	results, err = domain.QueryMemory(context.Background(), p, domain.MemoryQuery{MinScore: 0.5})
+	if err != nil {
+		t.Fatalf("QueryMemory: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("min_score kept %d results, want 2", len(results))
+	}
+}
+
+func TestPageMemoryResults(t *testing.T) {
+	results := make([]domain.ScoredMemoryEntry, 5)
+	if got := domain.PageMemoryResults(results, 3, 10); len(got) != 2 {
+		t.Errorf("offset 3: len = %d, want 2", len(got))
+	}
+	if got := domain.PageMemoryResults(results, -1, 2); len(got) != 2 {
+		t.Errorf("negative offset: len = %d, want 2", len(got))
+	}
+	if got := domain.PageMemoryResults(results, 9, 2); got == nil || len(got) != 0 {
+		t.Errorf("offset past end = %v, want empty", got)
+	}
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Role constants for message roles.
 const (
@@ -10,14 +13,75 @@ const (
 	RoleTool      = "tool"
 )
 
+// ContentPartType identifies the kind of a message content part.
+type ContentPartType string
+
+const (
+	ContentPartText  ContentPartType = "text"
+	ContentPartImage ContentPartType = "image"
+	ContentPartFile  ContentPartType = "file"
+)
+
+// ContentPart is one typed piece of multimodal message content.
+// Image and file parts carry either inline Data or a URL the provider can fetch.
+type ContentPart struct {
+	Type     ContentPartType `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	MIMEType string          `json:"mime_type,omitempty"`
+	Data     []byte          `json:"data,omitempty"`
+	URL      string          `json:"url,omitempty"`
+	Filename string          `json:"filename,omitempty"`
+}
+
 // Message represents a single message in a conversation.
+//
+// When Parts is set it is the message's full content, in order, and Content
+// holds its text for consumers that only understand plain text (memory,
+// token counting, logs).
 type Message struct {
-	Role      string     `json:"role"`
-	Content   string     `json:"content"`
-	Name      string     `json:"name,omitempty"`
-	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
-	Thinking  string     `json:"thinking,omitempty"`
-	Timestamp time.Time  `json:"timestamp"`
+	Role      string        `json:"role"`
+	Content   string        `json:"content"`
+	Parts     []ContentPart `json:"parts,omitempty"`
+	Name      string        `json:"name,omitempty"`
+	ToolCalls []ToolCall    `json:"tool_calls,omitempty"`
+	Thinking  string        `json:"thinking,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// ContentParts returns m's content as parts, wrapping plain Content in a
+// single text part when Parts is empty.
+func (m Message) ContentParts() []ContentPart {
+	if len(m.Parts) > 0 {
+		return m.Parts
+	}
+	if m.Content == "" {
+		return nil
+	}
+	return []ContentPart{{Type: ContentPartText, Text: m.Content}}
+}
+
+// HasImages reports whether m carries any image parts.
+func (m Message) HasImages() bool {
+	for _, p := range m.Parts {
+		if p.Type == ContentPartImage {
+			return true
+		}
+	}
+	return false
+}
+
+// NewUserMessage builds a user message from text and attachments. The text,
+// if any, becomes the leading part.
+func NewUserMessage(text string, attachments []ContentPart) Message {
+	msg := Message{Role: RoleUser, Content: text, Timestamp: time.Now()}
+	if len(attachments) == 0 {
+		return msg
+	}
+	if text != "" {
+		msg.Parts = append(msg.Parts, ContentPart{Type: ContentPartText, Text: text})
+	}
+	msg.Parts = append(msg.Parts, attachments...)
+	return msg
 }
 
 // ChatRequest is sent to an LLM provider.
@@ -29,6 +93,46 @@ type ChatRequest struct {
 	Temperature    float64      `json:"temperature,omitempty"`
 	Stream         bool         `json:"stream,omitempty"`
 	ThinkingBudget int          `json:"thinking_budget,omitempty"`
+
+	// StableSystemLen is the length in bytes of the leading part of the
+	// system prompt that stays the same between turns. Providers with
+	// prompt caching cache the system prompt up to it; zero treats the
+	// whole system prompt as stable.
+	StableSystemLen int `json:"stable_system_len,omitempty"`
+
+	// ResponseFormat constrains the reply to JSON. The reply's Content is
+	// then the JSON value; nil leaves the reply free-form.
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// Response format types.
+const (
+	ResponseFormatJSON       = "json_object" // any JSON object
+	ResponseFormatJSONSchema = "json_schema" // JSON matching Schema
+)
+
+// ResponseFormat asks a provider for a JSON reply.
+type ResponseFormat struct {
+	Type   string          `json:"type"`
+	Name   string          `json:"name,omitempty"`   // schema name; "response" when empty
+	Schema json.RawMessage `json:"schema,omitempty"` // JSON Schema, for ResponseFormatJSONSchema
+}
+
+// SchemaName returns the name providers label the schema with.
+func (f *ResponseFormat) SchemaName() string {
+	if f.Name == "" {
+		return "response"
+	}
+	return f.Name
+}
+
+// JSONSchema returns the schema the reply must match; an object schema
+// for ResponseFormatJSON.
+func (f *ResponseFormat) JSONSchema() json.RawMessage {
+	if f.Type == ResponseFormatJSONSchema && len(f.Schema) > 0 {
+		return f.Schema
+	}
+	return json.RawMessage(`{"type":"object"}`)
 }
 
 // ChatResponse is returned from an LLM provider.
@@ -40,11 +144,17 @@ type ChatResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
-// Usage tracks token consumption.
+// Usage tracks token consumption. CachedTokens is the part of PromptTokens
+// served from the provider's prompt cache, CacheWriteTokens the part written
+// to it and ThinkingTokens the part of CompletionTokens spent on reasoning;
+// all are zero when the provider does not report them.
 type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	CachedTokens     int `json:"cached_tokens,omitempty"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+	ThinkingTokens   int `json:"thinking_tokens,omitempty"`
 }
 
 // Conversation holds an ordered sequence of messages.
@@ -25,3 +25,30 @@ type StreamingLLMProvider interface {
 	// ChatStream sends a request and returns a channel of incremental deltas.
 	ChatStream(ctx context.Context, req ChatRequest) (<-chan StreamDelta, error)
 }
+
+// VisionProvider is implemented by providers that accept image content parts.
+// Requests carrying images are rejected with ErrVisionUnsupported for
+// providers that do not implement it.
+type VisionProvider interface {
+	SupportsVision() bool
+}
+
+// SupportsVision reports whether p accepts image content parts.
+func SupportsVision(p LLMProvider) bool {
+	v, ok := p.(VisionProvider)
+	return ok && v.SupportsVision()
+}
+
+// StructuredOutputProvider is implemented by providers that honor
+// ChatRequest.ResponseFormat natively. Other providers are asked for JSON
+// in the prompt instead.
+type StructuredOutputProvider interface {
+	SupportsStructuredOutput() bool
+}
+
+// SupportsStructuredOutput reports whether p constrains replies to a
+// ResponseFormat natively.
+func SupportsStructuredOutput(p LLMProvider) bool {
+	s, ok := p.(StructuredOutputProvider)
+	return ok && s.SupportsStructuredOutput()
+}
@@ -0,0 +1,33 @@
+package domain
+
+// Provider states as seen by a health-aware route.
+const (
+	RouteHealthy  = "healthy"
+	RouteDegraded = "degraded" // error rate or latency above the route's limits
+	RouteOpen     = "open"     // circuit breaker open
+)
+
+// RouteStatus is a health-aware route's current view of its providers.
+type RouteStatus struct {
+	Name      string           `json:"name"`
+	Selected  string           `json:"selected,omitempty"` // provider that served the last call
+	Providers []ProviderHealth `json:"providers"`
+}
+
+// ProviderHealth is the rolling health of one provider behind a route,
+// computed over the route's health window.
+type ProviderHealth struct {
+	Provider  string  `json:"provider"`
+	Weight    int     `json:"weight"` // 0 = standby
+	State     string  `json:"state"`
+	Breaker   string  `json:"breaker,omitempty"` // circuit breaker state, if any
+	Calls     int     `json:"calls"`
+	ErrorRate float64 `json:"error_rate"`
+	P50Ms     int64   `json:"p50_ms"`
+	P95Ms     int64   `json:"p95_ms"`
+}
+
+// RoutingReporter provides the status of the health-aware routes.
+type RoutingReporter interface {
+	RouteStatus() []RouteStatus
+}
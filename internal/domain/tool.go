@@ -3,6 +3,8 @@ package domain
 import (
 	"context"
 	"encoding/json"
+	"strings"
+	"time"
 )
 
 // ToolSchema describes a tool for the LLM function-calling protocol.
@@ -48,3 +50,47 @@ type ToolApprover interface {
 	// RequestApproval blocks until the call is approved or denied.
 	RequestApproval(ctx context.Context, call ToolCall) (bool, error)
 }
+
+// ApprovalScope controls how long an approval decision applies.
+type ApprovalScope string
+
+const (
+	// ApprovalScopeOnce applies the decision to a single tool call.
+	ApprovalScopeOnce ApprovalScope = "once"
+	// ApprovalScopeSession approves the tool for the rest of the session.
+	ApprovalScopeSession ApprovalScope = "session"
+)
+
+// ApprovalRequest is the payload of EventToolApprovalReq. Channels render it
+// as a native prompt in the chat identified by Channel and ChatID.
+type ApprovalRequest struct {
+	ToolCallID string          `json:"tool_call_id"`
+	Tool       string          `json:"tool"`
+	Arguments  json.RawMessage `json:"arguments,omitempty"`
+	SessionID  string          `json:"session_id"`
+	Channel    string          `json:"channel,omitempty"`
+	ChatID     string          `json:"chat_id,omitempty"`
+	ExpiresAt  time.Time       `json:"expires_at"`
+	Rule       string          `json:"rule,omitempty"` // policy rule that asked for the prompt
+}
+
+// ApprovalDecision is the payload of EventToolApprovalResp. Channels and the
+// gateway publish it to answer an ApprovalRequest; the approver publishes it
+// itself when a request times out or is cancelled.
+type ApprovalDecision struct {
+	ToolCallID string        `json:"tool_call_id"`
+	Approved   bool          `json:"approved"`
+	Scope      ApprovalScope `json:"scope,omitempty"`
+	DecidedBy  string        `json:"decided_by,omitempty"`
+	Reason     string        `json:"reason,omitempty"`
+}
+
+// SplitSessionKey splits a router session key ("channel:chatID") into its
+// channel name and chat ID. Keys without a channel prefix return an empty
+// channel.
+func SplitSessionKey(key string) (channel, chatID string) {
+	if ch, id, ok := strings.Cut(key, ":"); ok {
+		return ch, id
+	}
+	return "", key
+}
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// TriggerRule starts a run of the pipeline or agent that declares it when
+// a matching event is published on the event bus.
+type TriggerRule struct {
+	// Event is the event type to react to, e.g. "process.completed". A
+	// trailing "*" matches every type with that prefix ("node.*").
+	Event string `json:"event" yaml:"event"`
+
+	// Filter is a Go text/template bool expression over the event, with
+	// .type, .payload (decoded JSON), .session_id and .timestamp, e.g.
+	// `{{ne .payload.exit_code 0.0}}`. Empty matches every event.
+	Filter string `json:"filter,omitempty" yaml:"filter,omitempty"`
+
+	// Debounce waits until no matching event arrived for this long, then
+	// starts one run with the last event.
+	Debounce time.Duration `json:"debounce,omitempty" yaml:"debounce,omitempty"`
+
+	// Throttle starts at most one run per interval; matching events in
+	// between are dropped.
+	Throttle time.Duration `json:"throttle,omitempty" yaml:"throttle,omitempty"`
+
+	// Message is the prompt template for agent runs, executed with the
+	// same data as Filter. Defaults to the event type and payload.
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+
+	// Tools lists the tools an agent run may use. Event payloads can carry
+	// untrusted content, so agent runs get no tools unless listed.
+	Tools []string `json:"tools,omitempty" yaml:"tools,omitempty"`
+}
@@ -0,0 +1,135 @@
+package domain
+
+import "time"
+
+// ModelPrice is the price of a model in USD per million tokens. A zero
+// Cached or CacheWrite price bills those prompt tokens at the Input price
+// and a zero Thinking price bills reasoning tokens at the Output price.
+type ModelPrice struct {
+	Input      float64 `json:"input" yaml:"input"`
+	Output     float64 `json:"output" yaml:"output"`
+	Cached     float64 `json:"cached,omitempty" yaml:"cached,omitempty"`
+	CacheWrite float64 `json:"cache_write,omitempty" yaml:"cache_write,omitempty"`
+	Thinking   float64 `json:"thinking,omitempty" yaml:"thinking,omitempty"`
+}
+
+// Cost returns the USD cost of u at this price.
+func (p ModelPrice) Cost(u Usage) float64 {
+	cached, write, thinking := p.Cached, p.CacheWrite, p.Thinking
+	if cached == 0 {
+		cached = p.Input
+	}
+	if write == 0 {
+		write = p.Input
+	}
+	if thinking == 0 {
+		thinking = p.Output
+	}
+	uncached := u.PromptTokens - u.CachedTokens - u.CacheWriteTokens
+	prompt := float64(uncached)*p.Input + float64(u.CachedTokens)*cached + float64(u.CacheWriteTokens)*write
+	completion := float64(u.CompletionTokens-u.ThinkingTokens)*p.Output + float64(u.ThinkingTokens)*thinking
+	return (prompt + completion) / 1_000_000
+}
+
+// CostRecord is one priced LLM call in the usage ledger.
+type CostRecord struct {
+	Time      time.Time `json:"time"`
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	SessionID string    `json:"session_id,omitempty"`
+	AgentID   string    `json:"agent_id,omitempty"`
+	TenantID  string    `json:"tenant_id,omitempty"`
+	Usage     Usage     `json:"usage"`
+	CostUSD   float64   `json:"cost_usd"`
+}
+
+// BudgetScope selects which calls count against a budget.
+type BudgetScope string
+
+const (
+	BudgetGlobal  BudgetScope = "global"
+	BudgetTenant  BudgetScope = "tenant"
+	BudgetAgent   BudgetScope = "agent"
+	BudgetSession BudgetScope = "session"
+)
+
+// BudgetPeriod is the window a budget's spend is summed over.
+type BudgetPeriod string
+
+const (
+	BudgetDaily   BudgetPeriod = "day"
+	BudgetMonthly BudgetPeriod = "month"
+)
+
+// BudgetAction is what happens to calls once a budget is spent. Block is a
+// hard limit; downgrade and warn are soft and let the call through.
+type BudgetAction string
+
+const (
+	BudgetBlock     BudgetAction = "block"
+	BudgetDowngrade BudgetAction = "downgrade"
+	BudgetWarn      BudgetAction = "warn"
+)
+
+// Budget caps LLM spend in USD for a scope over a period.
+type Budget struct {
+	Scope BudgetScope `json:"scope" yaml:"scope"`
+	// ID names the tenant, agent or session the budget applies to. Empty
+	// applies the limit to each of them separately. Ignored for global.
+	ID     string       `json:"id,omitempty" yaml:"id,omitempty"`
+	Period BudgetPeriod `json:"period" yaml:"period"`
+	Limit  float64      `json:"limit" yaml:"limit"`
+	Action BudgetAction `json:"action" yaml:"action"`
+	// Downgrade is the model preference (see ModelRouter) calls are routed
+	// to once a downgrade budget is spent, e.g. "cheap".
+	Downgrade string `json:"downgrade,omitempty" yaml:"downgrade,omitempty"`
+}
+
+// BudgetStatus reports the current spend against a budget.
+type BudgetStatus struct {
+	Budget
+	Key   string  `json:"key"` // scope ID the spend was summed for
+	Spent float64 `json:"spent"`
+}
+
+// Exceeded reports whether the budget's limit has been reached.
+func (s BudgetStatus) Exceeded() bool { return s.Limit > 0 && s.Spent >= s.Limit }
+
+// UsageTotals aggregates token usage and cost over a set of calls.
+type UsageTotals struct {
+	Calls   int     `json:"calls"`
+	Usage   Usage   `json:"usage"`
+	CostUSD float64 `json:"cost_usd"`
+}
+
+// Add folds one record into the totals.
+func (t *UsageTotals) Add(r CostRecord) {
+	t.Calls++
+	t.Usage.PromptTokens += r.Usage.PromptTokens
+	t.Usage.CompletionTokens += r.Usage.CompletionTokens
+	t.Usage.TotalTokens += r.Usage.TotalTokens
+	t.Usage.CachedTokens += r.Usage.CachedTokens
+	t.Usage.CacheWriteTokens += r.Usage.CacheWriteTokens
+	t.Usage.ThinkingTokens += r.Usage.ThinkingTokens
+	t.CostUSD += r.CostUSD
+}
+
+// UsageSummary is the spend snapshot shown in status views.
+type UsageSummary struct {
+	Today    UsageTotals    `json:"today"`
+	Month    UsageTotals    `json:"month"`
+	Budgets  []BudgetStatus `json:"budgets,omitempty"`
+	Unpriced []string       `json:"unpriced,omitempty"` // models used without a price
+}
+
+// UsageReporter provides the current spend snapshot.
+type UsageReporter interface {
+	Summary() UsageSummary
+}
+
+// UsageLedger persists cost records.
+type UsageLedger interface {
+	Append(rec CostRecord) error
+	// Records returns the records with Time at or after since, oldest first.
+	Records(since time.Time) ([]CostRecord, error)
+}
@@ -3,6 +3,7 @@ package domain
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -21,16 +22,49 @@ type Pipeline struct {
 	Timeout     time.Duration          `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 	Env         map[string]string      `json:"env,omitempty" yaml:"env,omitempty"`
 	Args        map[string]PipelineArg `json:"args,omitempty" yaml:"args,omitempty"`
+
+	// On starts the pipeline when matching events are published, with the
+	// event payload as args.
+	On []TriggerRule `json:"on,omitempty" yaml:"on,omitempty"`
+
+	// OnInterrupt decides what happens on startup to runs a crash or
+	// restart interrupted: PipelineInterruptFail (default) or
+	// PipelineInterruptResume.
+	OnInterrupt string `json:"on_interrupt,omitempty" yaml:"on_interrupt,omitempty"`
 }
 
+// Interrupted run policies.
+const (
+	// PipelineInterruptFail marks interrupted runs failed.
+	PipelineInterruptFail = "fail"
+	// PipelineInterruptResume continues interrupted runs from their last
+	// checkpoint; steps that were running start over.
+	PipelineInterruptResume = "resume"
+)
+
 // Step is a single unit of work inside a Pipeline.
 type Step struct {
 	ID        string        `json:"id" yaml:"id"`
-	Type      string        `json:"type" yaml:"type"` // "exec", "http", "transform", "approval", "tool_call"
+	Type      string        `json:"type" yaml:"type"` // "exec", "http", "transform", "approval", "tool_call", "agent", "foreach", "pipeline"
 	Name      string        `json:"name,omitempty" yaml:"name,omitempty"`
 	Timeout   time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 	Condition string        `json:"condition,omitempty" yaml:"condition,omitempty"` // Go text/template bool expression
 
+	// DependsOn lists the step IDs that must finish (completed or skipped)
+	// before this step starts. If no step in a pipeline declares it, steps
+	// run one after another in declaration order.
+	DependsOn []string `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+
+	// Retry re-runs the step when it fails. Nil means a single attempt.
+	Retry *StepRetry `json:"retry,omitempty" yaml:"retry,omitempty"`
+
+	// OnError decides what a failure that survives all retries does:
+	// "fail" (default) ends the run, "continue" records the failure and
+	// lets dependent steps run, and any other value names a compensation
+	// step to run instead. Compensation steps only run when routed to and
+	// must not declare depends_on.
+	OnError string `json:"on_error,omitempty" yaml:"on_error,omitempty"`
+
 	// exec step fields
 	Command string   `json:"command,omitempty" yaml:"command,omitempty"`
 	Args    []string `json:"args,omitempty" yaml:"args,omitempty"`
@@ -52,6 +86,39 @@ type Step struct {
 	// tool_call step fields
 	ToolName   string          `json:"tool_name,omitempty" yaml:"tool_name,omitempty"`
 	ToolParams json.RawMessage `json:"tool_params,omitempty" yaml:"tool_params,omitempty"`
+
+	// agent step fields: Prompt (a template) goes to the agent named by
+	// Agent (the default agent when empty), or to the model the Model
+	// preference routes to ("fast", "powerful", ...). Tools limits the
+	// agent to the named tools. With OutputSchema the reply must be JSON
+	// matching that JSON Schema, and later steps see the parsed value.
+	Prompt       string         `json:"prompt,omitempty" yaml:"prompt,omitempty"`
+	Agent        string         `json:"agent,omitempty" yaml:"agent,omitempty"`
+	Model        string         `json:"model,omitempty" yaml:"model,omitempty"`
+	Tools        []string       `json:"tools,omitempty" yaml:"tools,omitempty"`
+	OutputSchema map[string]any `json:"output_schema,omitempty" yaml:"output_schema,omitempty"`
+
+	// foreach step fields: Items is a dotted path into the template data
+	// (e.g. "list.output" or "args.files") resolving to a JSON array or to
+	// text with one item per line. Do runs once per item, in order, with
+	// {{.item}} and {{.index}} set.
+	Items string `json:"items,omitempty" yaml:"items,omitempty"`
+	Do    *Step  `json:"do,omitempty" yaml:"do,omitempty"`
+
+	// pipeline step fields: runs another named pipeline with the given
+	// args (templates allowed) and returns its step outputs.
+	Pipeline     string            `json:"pipeline,omitempty" yaml:"pipeline,omitempty"`
+	PipelineArgs map[string]string `json:"pipeline_args,omitempty" yaml:"pipeline_args,omitempty"`
+}
+
+// StepRetry is a step's retry policy.
+type StepRetry struct {
+	Attempts int           `json:"attempts" yaml:"attempts"`                   // total attempts, including the first
+	Backoff  time.Duration `json:"backoff,omitempty" yaml:"backoff,omitempty"` // wait before the first retry, doubled after each; default 1s
+
+	// On limits retries to matching failures: "timeout", "4xx", "5xx" or
+	// a case-insensitive substring of the error. Empty retries any failure.
+	On []string `json:"on,omitempty" yaml:"on,omitempty"`
 }
 
 // WorkflowRun tracks the runtime state of a single pipeline execution.
@@ -67,13 +134,62 @@ type WorkflowRun struct {
 	Pipeline     Pipeline          `json:"pipeline"`
 	Env          map[string]string `json:"env,omitempty"`
 
+	// ParentRunID is set on runs started by a pipeline step.
+	ParentRunID string `json:"parent_run_id,omitempty"`
+
 	// Effective per-run overrides (clamped to config max).
 	EffectiveTimeout   time.Duration `json:"effective_timeout,omitempty"`
 	EffectiveMaxOutput int           `json:"effective_max_output,omitempty"`
 
-	// Approval state (populated when Status == "paused")
-	ApprovalMessage string `json:"approval_message,omitempty"`
-	ResumeToken     string `json:"resume_token,omitempty"`
+	// Per-step state keyed by step ID: "pending", "running",
+	// "awaiting_approval", "completed", "failed", "skipped", "denied", or
+	// "standby" for compensation steps no failure has routed to yet.
+	StepStates map[string]string `json:"step_states,omitempty"`
+
+	// Approval state (populated when Status == "paused"). ApprovalMessage
+	// and ResumeToken mirror the first entry of Approvals.
+	ApprovalMessage string            `json:"approval_message,omitempty"`
+	ResumeToken     string            `json:"resume_token,omitempty"`
+	Approvals       []PendingApproval `json:"approvals,omitempty"`
+}
+
+// PendingApproval is an approval step waiting for a decision. Parallel
+// branches can each pause on their own approval, so a run may hold several.
+type PendingApproval struct {
+	StepID      string `json:"step_id"`
+	Message     string `json:"message"`
+	ResumeToken string `json:"resume_token"`
+}
+
+// FindApproval returns the index of the pending approval with the given
+// resume token, or -1.
+func (r *WorkflowRun) FindApproval(token string) int {
+	for i, a := range r.Approvals {
+		if a.ResumeToken == token {
+			return i
+		}
+	}
+	return -1
+}
+
+// Summary describes a finished or paused run: the output of its last
+// completed step, or its status when no step produced output.
+func (r *WorkflowRun) Summary() string {
+	if r.Status == "paused" {
+		return fmt.Sprintf("Workflow %s is waiting for approval: %s", r.PipelineName, r.ApprovalMessage)
+	}
+	for i := len(r.Steps) - 1; i >= 0; i-- {
+		step := r.Steps[i]
+		if step.Status != "completed" || len(step.Output) == 0 || string(step.Output) == "null" {
+			continue
+		}
+		var s string
+		if json.Unmarshal(step.Output, &s) == nil {
+			return s
+		}
+		return string(step.Output)
+	}
+	return fmt.Sprintf("Workflow %s %s.", r.PipelineName, r.Status)
 }
 
 // StepResult records the outcome of executing a single step.
@@ -83,13 +199,36 @@ type StepResult struct {
 	Output   json.RawMessage `json:"output"`
 	Error    string          `json:"error,omitempty"`
 	Duration time.Duration   `json:"duration"`
+
+	// Attempt and Iteration mark the extra results a step records: one per
+	// failed attempt before a retry, and one per foreach item. The step's
+	// own result has neither set.
+	Attempt   int `json:"attempt,omitempty"`
+	Iteration int `json:"iteration,omitempty"`
+}
+
+// IsDetail reports whether r records a single attempt or iteration rather
+// than the step's outcome.
+func (r StepResult) IsDetail() bool {
+	return r.Attempt > 0 || r.Iteration > 0
+}
+
+// WorkflowRunFilter selects runs for WorkflowStore.ListRuns. Empty fields
+// match every run; Limit 0 means no limit.
+type WorkflowRunFilter struct {
+	Status   string
+	Pipeline string
+	Limit    int
+	Offset   int
 }
 
-// WorkflowStore persists workflow runs for resumability.
+// WorkflowStore persists workflow runs for resumability. SaveRun is called
+// when a run starts, after every step (a checkpoint) and when it ends.
 type WorkflowStore interface {
 	SaveRun(ctx context.Context, run WorkflowRun) error
 	GetRun(ctx context.Context, id string) (*WorkflowRun, error)
-	ListRuns(ctx context.Context, limit int) ([]WorkflowRun, error)
+	// ListRuns returns matching runs, newest first.
+	ListRuns(ctx context.Context, filter WorkflowRunFilter) ([]WorkflowRun, error)
 	DeleteRun(ctx context.Context, id string) error
 	GetRunByToken(ctx context.Context, token string) (*WorkflowRun, error)
 }
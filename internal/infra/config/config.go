@@ -91,16 +91,27 @@ type RoutingRuleConfig struct {
 
 // AgentInstanceConfig defines a single agent instance.
 type AgentInstanceConfig struct {
-	ID           string            `yaml:"id"`
-	Name         string            `yaml:"name"`
-	Description  string            `yaml:"description"`
-	SystemPrompt string            `yaml:"system_prompt"`
-	Model        string            `yaml:"model"`
-	Provider     string            `yaml:"provider"`
-	Tools        []string          `yaml:"tools,omitempty"`
-	Skills       []string          `yaml:"skills,omitempty"`
-	MaxIter      int               `yaml:"max_iter,omitempty"`
-	Metadata     map[string]string `yaml:"metadata,omitempty"`
+	ID           string              `yaml:"id"`
+	Name         string              `yaml:"name"`
+	Description  string              `yaml:"description"`
+	SystemPrompt string              `yaml:"system_prompt"`
+	Model        string              `yaml:"model"`
+	Provider     string              `yaml:"provider"`
+	Tools        []string            `yaml:"tools,omitempty"`
+	Skills       []string            `yaml:"skills,omitempty"`
+	MaxIter      int                 `yaml:"max_iter,omitempty"`
+	Metadata     map[string]string   `yaml:"metadata,omitempty"`
+	On           []TriggerRuleConfig `yaml:"on,omitempty"`
+}
+
+// TriggerRuleConfig starts an agent run when a matching event is published.
+type TriggerRuleConfig struct {
+	Event    string        `yaml:"event"`              // event type; a trailing "*" matches a prefix
+	Filter   string        `yaml:"filter,omitempty"`   // text/template bool over .type, .payload, .session_id
+	Debounce time.Duration `yaml:"debounce,omitempty"` // run once after events stop arriving for this long
+	Throttle time.Duration `yaml:"throttle,omitempty"` // at most one run per interval
+	Message  string        `yaml:"message,omitempty"`  // prompt template; defaults to the event type and payload
+	Tools    []string      `yaml:"tools,omitempty"`    // tools the agent may use; none by default
 }
 
 // NodesConfig holds remote node system settings (Phase 5).
@@ -146,9 +157,29 @@ type ScheduledTaskConfig struct {
 
 // GatewayConfig holds WebSocket gateway settings.
 type GatewayConfig struct {
-	Enabled bool       `yaml:"enabled"`
-	Addr    string     `yaml:"addr"`
-	Auth    AuthConfig `yaml:"auth"`
+	Enabled bool         `yaml:"enabled"`
+	Addr    string       `yaml:"addr"`
+	Auth    AuthConfig   `yaml:"auth"`
+	Hooks   []HookConfig `yaml:"hooks,omitempty"`
+}
+
+// HookConfig defines an inbound webhook served at /hooks/{name} that runs
+// an agent or a pipeline.
+type HookConfig struct {
+	Name            string            `yaml:"name"`
+	Secret          string            `yaml:"secret"`
+	Verify          string            `yaml:"verify,omitempty"`           // "hmac_sha256" (default), "github" or "token"
+	Header          string            `yaml:"header,omitempty"`           // signature or token header; defaults per scheme
+	TimestampHeader string            `yaml:"timestamp_header,omitempty"` // required for hmac_sha256: signs "<timestamp>.<body>"
+	DeliveryHeader  string            `yaml:"delivery_header,omitempty"`  // unique delivery ID, accepted once; X-GitHub-Delivery for github
+	Tolerance       time.Duration     `yaml:"tolerance,omitempty"`        // replay window, default 5m
+	Agent           string            `yaml:"agent,omitempty"`            // agent ID; default agent when neither is set
+	Pipeline        string            `yaml:"pipeline,omitempty"`
+	Prompt          string            `yaml:"prompt,omitempty"` // agent prompt template
+	Tools           []string          `yaml:"tools,omitempty"`  // tools the agent may use; none by default
+	Args            map[string]string `yaml:"args,omitempty"`   // pipeline arg templates
+	Channel         string            `yaml:"channel,omitempty"`
+	Target          string            `yaml:"target,omitempty"`
 }
 
 // AuthConfig holds gateway authentication settings.
@@ -297,6 +328,15 @@ type AuditConfig struct {
 	Enabled   bool            `yaml:"enabled"`
 	Path      string          `yaml:"path"`
 	Retention RetentionConfig `yaml:"retention"`
+
+	// KeyFile holds the hex HMAC key for signing records, created on first
+	// start. The default, ~/.alfredai/keys/audit.key, keeps it out of the log
+	// directory, so whoever can rewrite the log cannot re-sign it too.
+	// ALFREDAI_AUDIT_KEY overrides it.
+	KeyFile string `yaml:"key_file"`
+	// AnchorPath receives the chain head every AnchorInterval; empty disables.
+	AnchorPath     string `yaml:"anchor_path"`
+	AnchorInterval string `yaml:"anchor_interval"` // duration string; default "1h"
 }
 
 // RetentionConfig holds audit log retention policy settings.
@@ -310,6 +350,35 @@ type ToolApprovalConfig struct {
 	Enabled       bool     `yaml:"enabled"`
 	AlwaysApprove []string `yaml:"always_approve"`
 	AlwaysDeny    []string `yaml:"always_deny"`
+	// Interactive prompts the user for unlisted tools instead of denying them.
+	Interactive bool          `yaml:"interactive"`
+	Timeout     time.Duration `yaml:"timeout"` // default: 2m; unanswered prompts are denied
+
+	// Rules are evaluated in order before the lists; the first match decides.
+	Rules []ToolApprovalRule `yaml:"rules"`
+}
+
+// ToolApprovalRule allows, denies or prompts for matching tool calls.
+type ToolApprovalRule struct {
+	Name     string            `yaml:"name"`
+	Tool     string            `yaml:"tool"`   // tool name or glob, e.g. "*"
+	Action   string            `yaml:"action"` // allow, deny, prompt
+	Agents   []string          `yaml:"agents"`
+	Tenants  []string          `yaml:"tenants"`
+	Channels []string          `yaml:"channels"`
+	Roles    []string          `yaml:"roles"`
+	Args     []ToolApprovalArg `yaml:"args"`
+}
+
+// ToolApprovalArg is a predicate over a value in the tool call arguments.
+type ToolApprovalArg struct {
+	Path    string   `yaml:"path"` // e.g. "action", "files[0].path", "args[*]"
+	Equals  string   `yaml:"equals"`
+	OneOf   []string `yaml:"one_of"`
+	Matches string   `yaml:"matches"` // regular expression
+	Under   string   `yaml:"under"`   // directory the path must be inside
+	Absent  bool     `yaml:"absent"`
+	Not     bool     `yaml:"not"`
 }
 
 // AgentConfig holds agent behavior settings.
@@ -321,6 +390,7 @@ type AgentConfig struct {
 	SubAgent      SubAgentConfig       `yaml:"sub_agent"`
 	ToolApproval  ToolApprovalConfig   `yaml:"tool_approval"`
 	ContextGuard  ContextGuardConfig   `yaml:"context_guard"`
+	On            []TriggerRuleConfig  `yaml:"on,omitempty"` // event triggers for the default agent
 }
 
 // ContextGuardConfig controls proactive context window overflow prevention.
@@ -350,15 +420,64 @@ type SubAgentConfig struct {
 type FailoverConfig struct {
 	Enabled   bool     `yaml:"enabled"`
 	Fallbacks []string `yaml:"fallbacks"`
+	Strategy  string   `yaml:"strategy,omitempty"` // "ordered" (default) or "health"
 }
 
 // LLMConfig holds LLM provider settings.
 type LLMConfig struct {
 	DefaultProvider string               `yaml:"default_provider"`
 	Providers       []ProviderConfig     `yaml:"providers"`
+	Routes          []RouteConfig        `yaml:"routes,omitempty"`
 	Failover        FailoverConfig       `yaml:"failover"`
 	CircuitBreaker  CircuitBreakerConfig `yaml:"circuit_breaker"`
 	ModelRouting    map[string]string    `yaml:"model_routing,omitempty"` // preference → provider name, e.g. "fast" → "groq"
+	Usage           UsageConfig          `yaml:"usage"`
+}
+
+// RouteConfig defines a routed provider: a named provider that sends each
+// call to the healthiest of its member providers. It can be used wherever
+// a provider name is, e.g. as default_provider or in model_routing.
+type RouteConfig struct {
+	Name         string              `yaml:"name"`
+	Providers    []RouteMemberConfig `yaml:"providers"`
+	Window       time.Duration       `yaml:"window,omitempty"`         // rolling health window (default 5m)
+	MaxErrorRate float64             `yaml:"max_error_rate,omitempty"` // error rate that degrades a provider (default 0.5)
+	MaxLatency   time.Duration       `yaml:"max_latency,omitempty"`    // p95 latency that degrades a provider (0 = none)
+}
+
+// RouteMemberConfig is one provider behind a route.
+type RouteMemberConfig struct {
+	Provider string `yaml:"provider"`
+	Weight   int    `yaml:"weight,omitempty"`  // traffic share among equally healthy providers (default 1)
+	Standby  bool   `yaml:"standby,omitempty"` // only used when no other provider is healthy
+}
+
+// UsageConfig holds LLM cost accounting and budget settings.
+type UsageConfig struct {
+	Enabled bool                        `yaml:"enabled"`
+	DataDir string                      `yaml:"data_dir"`          // monthly JSONL cost ledgers
+	Pricing map[string]ModelPriceConfig `yaml:"pricing,omitempty"` // "provider/model", "model" or "provider" → price
+	Budgets []BudgetConfig              `yaml:"budgets,omitempty"`
+}
+
+// ModelPriceConfig is a model price in USD per million tokens. Cache reads
+// and writes default to the input price, thinking tokens to the output price.
+type ModelPriceConfig struct {
+	Input      float64 `yaml:"input"`
+	Output     float64 `yaml:"output"`
+	Cached     float64 `yaml:"cached,omitempty"`
+	CacheWrite float64 `yaml:"cache_write,omitempty"`
+	Thinking   float64 `yaml:"thinking,omitempty"`
+}
+
+// BudgetConfig caps LLM spend in USD for a scope over a period.
+type BudgetConfig struct {
+	Scope     string  `yaml:"scope"`               // "global" | "tenant" | "agent" | "session"
+	ID        string  `yaml:"id,omitempty"`        // tenant/agent/session ID; empty = each one separately
+	Period    string  `yaml:"period"`              // "day" | "month"
+	Limit     float64 `yaml:"limit"`               // USD
+	Action    string  `yaml:"action"`              // "block" | "downgrade" | "warn"
+	Downgrade string  `yaml:"downgrade,omitempty"` // model_routing preference for downgrade budgets
 }
 
 // CircuitBreakerConfig holds circuit breaker settings for LLM providers.
@@ -379,16 +498,18 @@ type PoolConfig struct {
 
 // ProviderConfig holds settings for a single LLM provider.
 type ProviderConfig struct {
-	Name           string        `yaml:"name"`
-	Type           string        `yaml:"type"`
-	BaseURL        string        `yaml:"base_url"`
-	APIKey         string        `yaml:"api_key"`
-	Model          string        `yaml:"model"`
-	Region         string        `yaml:"region,omitempty"`
-	ConnTimeout    time.Duration `yaml:"conn_timeout"`
-	RespTimeout    time.Duration `yaml:"resp_timeout"`
-	Pool           PoolConfig    `yaml:"pool"`
-	ThinkingBudget int           `yaml:"thinking_budget,omitempty"`
+	Name               string        `yaml:"name"`
+	Type               string        `yaml:"type"`
+	BaseURL            string        `yaml:"base_url"`
+	APIKey             string        `yaml:"api_key"`
+	Model              string        `yaml:"model"`
+	Region             string        `yaml:"region,omitempty"`
+	ConnTimeout        time.Duration `yaml:"conn_timeout"`
+	RespTimeout        time.Duration `yaml:"resp_timeout"`
+	Pool               PoolConfig    `yaml:"pool"`
+	ThinkingBudget     int           `yaml:"thinking_budget,omitempty"`
+	DisableVision      bool          `yaml:"disable_vision,omitempty"`       // reject image input (text-only model)
+	DisablePromptCache bool          `yaml:"disable_prompt_cache,omitempty"` // anthropic/bedrock: send no cache breakpoints
 }
 
 // MemoryConfig holds memory provider settings.
@@ -408,13 +529,16 @@ type SearchConfig struct {
 	MMRDiversity        float64       `yaml:"mmr_diversity"`          // 0-1, 0 = disabled
 	EmbeddingCacheSize  int           `yaml:"embedding_cache_size"`   // 0 = disabled
 	MaxVectorCandidates int           `yaml:"max_vector_candidates"`  // 0 = default (10000)
+	HNSWEfSearch        int           `yaml:"hnsw_ef_search"`         // 0 = default (100)
 }
 
 // EmbeddingConfig holds text embedding provider settings.
 type EmbeddingConfig struct {
-	Provider string `yaml:"provider"` // "openai", "gemini", ""
-	Model    string `yaml:"model"`
-	APIKey   string `yaml:"api_key,omitempty"`
+	Provider         string  `yaml:"provider"` // "openai", "gemini", ""
+	Model            string  `yaml:"model"`
+	APIKey           string  `yaml:"api_key,omitempty"`
+	ReembedBatchSize int     `yaml:"reembed_batch_size"` // 0 = default (64)
+	ReembedRate      float64 `yaml:"reembed_rate"`       // embed calls/sec; 0 = default (2), < 0 = unlimited
 }
 
 // ByteRoverConfig holds ByteRover API settings.
@@ -486,6 +610,7 @@ type ToolsConfig struct {
 	WorkflowEnabled         bool          `yaml:"workflow_enabled"`
 	WorkflowDir             string        `yaml:"workflow_dir"`
 	WorkflowDataDir         string        `yaml:"workflow_data_dir"`
+	WorkflowStore           string        `yaml:"workflow_store"` // "file" | "sqlite"
 	WorkflowTimeout         time.Duration `yaml:"workflow_timeout"`
 	WorkflowMaxOutput       int           `yaml:"workflow_max_output"`
 	WorkflowMaxRunning      int           `yaml:"workflow_max_running"`
@@ -520,10 +645,23 @@ type ToolsConfig struct {
 	EmailTimeout         time.Duration `yaml:"email_timeout"`
 	EmailMaxSendsPerHour int           `yaml:"email_max_sends_per_hour"`
 	EmailAllowedDomains  []string      `yaml:"email_allowed_domains"`
+	EmailBackend         string        `yaml:"email_backend"`   // "mock" | "imap"
+	EmailIMAPAddr        string        `yaml:"email_imap_addr"` // host:port, e.g. "imap.example.com:993"
+	EmailIMAPTLS         string        `yaml:"email_imap_tls"`  // "tls" | "starttls" | "none"
+	EmailSMTPAddr        string        `yaml:"email_smtp_addr"` // host:port, e.g. "smtp.example.com:587"
+	EmailSMTPTLS         string        `yaml:"email_smtp_tls"`  // "starttls" | "tls" | "none"
+	EmailUsername        string        `yaml:"email_username"`
+	EmailPassword        string        `yaml:"email_password"`
+	EmailFrom            string        `yaml:"email_from"` // defaults to email_username
+	EmailDraftsFolder    string        `yaml:"email_drafts_folder"`
 
 	// Calendar tool.
-	CalendarEnabled bool          `yaml:"calendar_enabled"`
-	CalendarTimeout time.Duration `yaml:"calendar_timeout"`
+	CalendarEnabled  bool          `yaml:"calendar_enabled"`
+	CalendarTimeout  time.Duration `yaml:"calendar_timeout"`
+	CalendarBackend  string        `yaml:"calendar_backend"` // "mock" | "caldav"
+	CalendarURL      string        `yaml:"calendar_url"`     // CalDAV calendar home collection
+	CalendarUsername string        `yaml:"calendar_username"`
+	CalendarPassword string        `yaml:"calendar_password"`
 
 	// Smart Home tool.
 	SmartHomeEnabled           bool          `yaml:"smarthome_enabled"`
@@ -531,6 +669,11 @@ type ToolsConfig struct {
 	SmartHomeToken             string        `yaml:"smarthome_token"`
 	SmartHomeTimeout           time.Duration `yaml:"smarthome_timeout"`
 	SmartHomeMaxCallsPerMinute int           `yaml:"smarthome_max_calls_per_minute"`
+	SmartHomeBackend           string        `yaml:"smarthome_backend"`                    // "mock" | "homeassistant"
+	SmartHomeAllowedEntities   []string      `yaml:"smarthome_allowed_entities,omitempty"` // glob patterns; empty = all
+	SmartHomeDeniedEntities    []string      `yaml:"smarthome_denied_entities,omitempty"`  // glob patterns; deny wins
+	SmartHomeAllowedServices   []string      `yaml:"smarthome_allowed_services,omitempty"` // glob patterns over domain.service; empty = all
+	SmartHomeEvents            bool          `yaml:"smarthome_events"`                     // publish state changes on the event bus
 
 	// MCP (Model Context Protocol) bridge.
 	MCPEnabled bool        `yaml:"mcp_enabled"`
@@ -573,8 +716,35 @@ type LoggerConfig struct {
 // TracerConfig holds tracing settings.
 type TracerConfig struct {
 	Enabled  bool   `yaml:"enabled"`
-	Exporter string `yaml:"exporter"`
-	Endpoint string `yaml:"endpoint"`
+	Exporter string `yaml:"exporter"` // stdout, otlp-grpc, otlp-http, noop
+	Endpoint string `yaml:"endpoint"` // OTLP collector, e.g. "localhost:4317"; default: OTEL_EXPORTER_OTLP_ENDPOINT
+
+	// OTLP settings, shared by the metrics exporter.
+	Insecure           bool              `yaml:"insecure"` // plain HTTP / gRPC without TLS
+	Headers            map[string]string `yaml:"headers"`
+	SampleRatio        float64           `yaml:"sample_ratio"` // 0 < ratio <= 1; default: 1
+	ServiceName        string            `yaml:"service_name"` // default: "alfred-ai"
+	ResourceAttributes map[string]string `yaml:"resource_attributes"`
+
+	Metrics MetricsConfig `yaml:"metrics"`
+}
+
+// MetricsConfig holds OpenTelemetry metrics settings.
+type MetricsConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Exporter string        `yaml:"exporter"` // otlp-grpc, otlp-http, stdout
+	Endpoint string        `yaml:"endpoint"` // default: tracer.endpoint
+	Interval time.Duration `yaml:"interval"` // export interval; default: 60s
+}
+
+// defaultKeyDir returns the directory for signing keys, $HOME/.alfredai/keys,
+// kept apart from the data they protect. Falls back to "./keys".
+func defaultKeyDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "./keys"
+	}
+	return filepath.Join(home, ".alfredai", "keys")
 }
 
 // defaultDataDir returns the persistent data directory under $HOME/.alfredai/data.
@@ -615,6 +785,9 @@ func Defaults() *Config {
 		},
 		LLM: LLMConfig{
 			DefaultProvider: "openai",
+			Usage: UsageConfig{
+				DataDir: filepath.Join(dataDir, "usage"),
+			},
 		},
 		Memory: MemoryConfig{
 			Provider:   "noop",
@@ -651,6 +824,7 @@ func Defaults() *Config {
 			WorkflowEnabled:    false,
 			WorkflowDir:        "./workflows",
 			WorkflowDataDir:    filepath.Join(dataDir, "workflows"),
+			WorkflowStore:      "file",
 			WorkflowTimeout:    120 * time.Second,
 			WorkflowMaxOutput:  1024 * 1024, // 1 MiB
 			WorkflowMaxRunning: 5,
@@ -691,12 +865,20 @@ func Defaults() *Config {
 			EmailEnabled:               false,
 			EmailTimeout:               30 * time.Second,
 			EmailMaxSendsPerHour:       10,
+			EmailBackend:               "mock",
+			EmailIMAPTLS:               "tls",
+			EmailSMTPTLS:               "starttls",
+			EmailDraftsFolder:          "Drafts",
 			CalendarEnabled:            false,
 			CalendarTimeout:            15 * time.Second,
+			CalendarBackend:            "mock",
 			SmartHomeEnabled:           false,
 			SmartHomeURL:               "",
 			SmartHomeTimeout:           10 * time.Second,
 			SmartHomeMaxCallsPerMinute: 60,
+			SmartHomeBackend:           "mock",
+			SmartHomeDeniedEntities:    []string{"lock.*", "alarm_control_panel.*"},
+			SmartHomeAllowedServices:   []string{"*.turn_on", "*.turn_off", "*.toggle", "climate.set_*", "cover.*", "media_player.*"},
 		},
 		Logger: LoggerConfig{
 			Level:  "info",
@@ -712,6 +894,7 @@ func Defaults() *Config {
 			Audit: AuditConfig{
 				Enabled: true,
 				Path:    filepath.Join(dataDir, "audit.jsonl"),
+				KeyFile: filepath.Join(defaultKeyDir(), "audit.key"),
 			},
 			ConsentDir: dataDir,
 		},
@@ -808,6 +991,12 @@ func ApplyEnvOverrides(cfg *Config) {
 	if v := os.Getenv("ALFREDAI_LLM_DEFAULT_PROVIDER"); v != "" {
 		cfg.LLM.DefaultProvider = v
 	}
+	if v := os.Getenv("ALFREDAI_LLM_USAGE_ENABLED"); v == "true" {
+		cfg.LLM.Usage.Enabled = true
+	}
+	if v := os.Getenv("ALFREDAI_LLM_USAGE_DATA_DIR"); v != "" {
+		cfg.LLM.Usage.DataDir = v
+	}
 	if v := os.Getenv("ALFREDAI_LOGGER_LEVEL"); v != "" {
 		cfg.Logger.Level = v
 	}
@@ -817,6 +1006,9 @@ func ApplyEnvOverrides(cfg *Config) {
 	if v := os.Getenv("ALFREDAI_TRACER_EXPORTER"); v != "" {
 		cfg.Tracer.Exporter = v
 	}
+	if v := os.Getenv("ALFREDAI_TRACER_ENDPOINT"); v != "" {
+		cfg.Tracer.Endpoint = v
+	}
 	if v := os.Getenv("ALFREDAI_TOOLS_SANDBOX_ROOT"); v != "" {
 		cfg.Tools.SandboxRoot = v
 	}
@@ -899,6 +1091,9 @@ func ApplyEnvOverrides(cfg *Config) {
 	if v := os.Getenv("ALFREDAI_TOOLS_WORKFLOW_DATA_DIR"); v != "" {
 		cfg.Tools.WorkflowDataDir = v
 	}
+	if v := os.Getenv("ALFREDAI_TOOLS_WORKFLOW_STORE"); v != "" {
+		cfg.Tools.WorkflowStore = v
+	}
 	if v := os.Getenv("ALFREDAI_TOOLS_WORKFLOW_TIMEOUT"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil && d > 0 {
 			cfg.Tools.WorkflowTimeout = d
@@ -1080,6 +1275,30 @@ func ApplyEnvOverrides(cfg *Config) {
 	if v := os.Getenv("ALFREDAI_TOOLS_EMAIL_ALLOWED_DOMAINS"); v != "" {
 		cfg.Tools.EmailAllowedDomains = splitAndTrim(v, ",")
 	}
+	if v := os.Getenv("ALFREDAI_TOOLS_EMAIL_BACKEND"); v != "" {
+		cfg.Tools.EmailBackend = v
+	}
+	if v := os.Getenv("ALFREDAI_TOOLS_EMAIL_IMAP_ADDR"); v != "" {
+		cfg.Tools.EmailIMAPAddr = v
+	}
+	if v := os.Getenv("ALFREDAI_TOOLS_EMAIL_IMAP_TLS"); v != "" {
+		cfg.Tools.EmailIMAPTLS = v
+	}
+	if v := os.Getenv("ALFREDAI_TOOLS_EMAIL_SMTP_ADDR"); v != "" {
+		cfg.Tools.EmailSMTPAddr = v
+	}
+	if v := os.Getenv("ALFREDAI_TOOLS_EMAIL_SMTP_TLS"); v != "" {
+		cfg.Tools.EmailSMTPTLS = v
+	}
+	if v := os.Getenv("ALFREDAI_TOOLS_EMAIL_USERNAME"); v != "" {
+		cfg.Tools.EmailUsername = v
+	}
+	if v := os.Getenv("ALFREDAI_TOOLS_EMAIL_PASSWORD"); v != "" {
+		cfg.Tools.EmailPassword = v
+	}
+	if v := os.Getenv("ALFREDAI_TOOLS_EMAIL_FROM"); v != "" {
+		cfg.Tools.EmailFrom = v
+	}
 
 	// Calendar tool overrides.
 	if v := os.Getenv("ALFREDAI_TOOLS_CALENDAR_ENABLED"); v == "true" {
@@ -1090,6 +1309,18 @@ func ApplyEnvOverrides(cfg *Config) {
 			cfg.Tools.CalendarTimeout = d
 		}
 	}
+	if v := os.Getenv("ALFREDAI_TOOLS_CALENDAR_BACKEND"); v != "" {
+		cfg.Tools.CalendarBackend = v
+	}
+	if v := os.Getenv("ALFREDAI_TOOLS_CALENDAR_URL"); v != "" {
+		cfg.Tools.CalendarURL = v
+	}
+	if v := os.Getenv("ALFREDAI_TOOLS_CALENDAR_USERNAME"); v != "" {
+		cfg.Tools.CalendarUsername = v
+	}
+	if v := os.Getenv("ALFREDAI_TOOLS_CALENDAR_PASSWORD"); v != "" {
+		cfg.Tools.CalendarPassword = v
+	}
 
 	// Smart Home tool overrides.
 	if v := os.Getenv("ALFREDAI_TOOLS_SMARTHOME_ENABLED"); v == "true" {
@@ -1111,6 +1342,21 @@ func ApplyEnvOverrides(cfg *Config) {
 			cfg.Tools.SmartHomeMaxCallsPerMinute = n
 		}
 	}
+	if v := os.Getenv("ALFREDAI_TOOLS_SMARTHOME_BACKEND"); v != "" {
+		cfg.Tools.SmartHomeBackend = v
+	}
+	if v := os.Getenv("ALFREDAI_TOOLS_SMARTHOME_ALLOWED_ENTITIES"); v != "" {
+		cfg.Tools.SmartHomeAllowedEntities = splitAndTrim(v, ",")
+	}
+	if v := os.Getenv("ALFREDAI_TOOLS_SMARTHOME_DENIED_ENTITIES"); v != "" {
+		cfg.Tools.SmartHomeDeniedEntities = splitAndTrim(v, ",")
+	}
+	if v := os.Getenv("ALFREDAI_TOOLS_SMARTHOME_ALLOWED_SERVICES"); v != "" {
+		cfg.Tools.SmartHomeAllowedServices = splitAndTrim(v, ",")
+	}
+	if v := os.Getenv("ALFREDAI_TOOLS_SMARTHOME_EVENTS"); v == "true" {
+		cfg.Tools.SmartHomeEvents = true
+	}
 
 	// Edge IoT tool overrides.
 	if v := os.Getenv("ALFREDAI_TOOLS_GPIO_ENABLED"); v == "true" {
@@ -1485,6 +1731,18 @@ func decryptSecrets(cfg *Config, passphrase string) error {
 		}
 	}
 
+	// Decrypt webhook secrets.
+	for i := range cfg.Gateway.Hooks {
+		secret := cfg.Gateway.Hooks[i].Secret
+		if strings.HasPrefix(secret, "enc:") {
+			decrypted, err := DecryptValue(strings.TrimPrefix(secret, "enc:"), passphrase)
+			if err != nil {
+				return fmt.Errorf("gateway hook %s secret: %w", cfg.Gateway.Hooks[i].Name, err)
+			}
+			cfg.Gateway.Hooks[i].Secret = decrypted
+		}
+	}
+
 	// Decrypt gateway auth tokens.
 	for i := range cfg.Gateway.Auth.Tokens {
 		tok := cfg.Gateway.Auth.Tokens[i].Token
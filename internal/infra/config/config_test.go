@@ -18,6 +18,9 @@ func TestDefaults(t *testing.T) {
 	if cfg.Logger.Level != "info" {
 		t.Errorf("Logger.Level = %q, want %q", cfg.Logger.Level, "info")
 	}
+	if audit := cfg.Security.Audit; filepath.Dir(audit.KeyFile) == filepath.Dir(audit.Path) {
+		t.Errorf("Audit.KeyFile = %q, want it outside the log directory", audit.KeyFile)
+	}
 }
 
 func TestLoadNonExistentReturnsDefaults(t *testing.T) {
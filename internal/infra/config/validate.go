@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"net"
+	"path"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -44,6 +46,7 @@ func Validate(cfg *Config) error {
 	validateTenants(cfg, ve)
 	validateOffline(cfg, ve)
 	validateCluster(cfg, ve)
+	validateTracer(cfg, ve)
 	if ve.HasErrors() {
 		return ve
 	}
@@ -68,6 +71,51 @@ func validateAgent(cfg *Config, ve *ValidationError) {
 			ve.Add("agent.compression.keep_recent must be > 0 when compression is enabled")
 		}
 	}
+	for i, r := range cfg.Agent.ToolApproval.Rules {
+		validateToolApprovalRule(fmt.Sprintf("agent.tool_approval.rules[%d]", i), r, ve)
+	}
+}
+
+var validApprovalActions = map[string]bool{
+	"allow":  true,
+	"deny":   true,
+	"prompt": true,
+}
+
+var validApprovalRoles = map[string]bool{
+	"admin":    true,
+	"operator": true,
+	"user":     true,
+	"viewer":   true,
+}
+
+func validateToolApprovalRule(prefix string, r ToolApprovalRule, ve *ValidationError) {
+	if r.Tool == "" {
+		ve.Add("%s.tool must not be empty", prefix)
+	} else if _, err := path.Match(r.Tool, ""); err != nil {
+		ve.Add("%s.tool %q is not a valid pattern", prefix, r.Tool)
+	}
+	if !validApprovalActions[r.Action] {
+		ve.Add("%s.action %q is invalid (want: allow, deny, prompt)", prefix, r.Action)
+	}
+	for _, role := range r.Roles {
+		if !validApprovalRoles[role] {
+			ve.Add("%s.roles: unknown role %q", prefix, role)
+		}
+	}
+	for j, a := range r.Args {
+		if a.Path == "" {
+			ve.Add("%s.args[%d].path must not be empty", prefix, j)
+		}
+		if a.Matches != "" {
+			if _, err := regexp.Compile(a.Matches); err != nil {
+				ve.Add("%s.args[%d].matches: %v", prefix, j, err)
+			}
+		}
+		if !a.Absent && a.Equals == "" && len(a.OneOf) == 0 && a.Matches == "" && a.Under == "" {
+			ve.Add("%s.args[%d] needs one of equals, one_of, matches, under or absent", prefix, j)
+		}
+	}
 }
 
 var validProviderTypes = map[string]bool{
@@ -83,6 +131,7 @@ func validateLLM(cfg *Config, ve *ValidationError) {
 	if cfg.LLM.DefaultProvider == "" {
 		ve.Add("llm.default_provider must not be empty")
 	}
+	validateUsage(cfg, ve)
 
 	if len(cfg.LLM.Providers) == 0 {
 		return
@@ -115,9 +164,85 @@ func validateLLM(cfg *Config, ve *ValidationError) {
 		}
 	}
 
+	for i, r := range cfg.LLM.Routes {
+		if r.Name == "" {
+			ve.Add("llm.routes[%d].name must not be empty", i)
+			continue
+		}
+		if seen[r.Name] {
+			ve.Add("llm.routes[%d]: name %q is already used by a provider or route", i, r.Name)
+		}
+		if len(r.Providers) == 0 {
+			ve.Add("llm.routes[%d] (%s): providers must not be empty", i, r.Name)
+		}
+		for j, m := range r.Providers {
+			if !seen[m.Provider] {
+				ve.Add("llm.routes[%d].providers[%d]: provider %q is not configured", i, j, m.Provider)
+			}
+			if m.Weight < 0 {
+				ve.Add("llm.routes[%d].providers[%d].weight must not be negative", i, j)
+			}
+		}
+		if r.Window < 0 || r.MaxLatency < 0 {
+			ve.Add("llm.routes[%d] (%s): window and max_latency must not be negative", i, r.Name)
+		}
+		if r.MaxErrorRate < 0 || r.MaxErrorRate > 1 {
+			ve.Add("llm.routes[%d] (%s): max_error_rate must be between 0 and 1", i, r.Name)
+		}
+		seen[r.Name] = true
+		if r.Name == cfg.LLM.DefaultProvider {
+			foundDefault = true
+		}
+	}
+
 	if !foundDefault && cfg.LLM.DefaultProvider != "" {
 		ve.Add("llm.default_provider %q does not match any configured provider", cfg.LLM.DefaultProvider)
 	}
+	if s := cfg.LLM.Failover.Strategy; s != "" && s != "ordered" && s != "health" {
+		ve.Add("llm.failover.strategy %q is invalid (want: ordered, health)", s)
+	}
+}
+
+var (
+	validBudgetScopes  = map[string]bool{"global": true, "tenant": true, "agent": true, "session": true}
+	validBudgetPeriods = map[string]bool{"day": true, "month": true}
+	validBudgetActions = map[string]bool{"block": true, "downgrade": true, "warn": true}
+)
+
+func validateUsage(cfg *Config, ve *ValidationError) {
+	u := cfg.LLM.Usage
+	if !u.Enabled {
+		return
+	}
+	if u.DataDir == "" {
+		ve.Add("llm.usage.data_dir must not be empty when usage accounting is enabled")
+	}
+	for key, p := range u.Pricing {
+		if p.Input < 0 || p.Output < 0 || p.Cached < 0 || p.CacheWrite < 0 || p.Thinking < 0 {
+			ve.Add("llm.usage.pricing[%s]: prices must not be negative", key)
+		}
+	}
+	for i, b := range u.Budgets {
+		if !validBudgetScopes[b.Scope] {
+			ve.Add("llm.usage.budgets[%d].scope %q is invalid (want: global, tenant, agent, session)", i, b.Scope)
+		}
+		if !validBudgetPeriods[b.Period] {
+			ve.Add("llm.usage.budgets[%d].period %q is invalid (want: day, month)", i, b.Period)
+		}
+		if b.Limit <= 0 {
+			ve.Add("llm.usage.budgets[%d].limit must be positive", i)
+		}
+		if !validBudgetActions[b.Action] {
+			ve.Add("llm.usage.budgets[%d].action %q is invalid (want: block, downgrade, warn)", i, b.Action)
+		}
+		if b.Action == "downgrade" {
+			if b.Downgrade == "" {
+				ve.Add("llm.usage.budgets[%d]: downgrade budgets need a downgrade preference", i)
+			} else if _, ok := cfg.LLM.ModelRouting[b.Downgrade]; !ok {
+				ve.Add("llm.usage.budgets[%d]: downgrade preference %q is not in llm.model_routing", i, b.Downgrade)
+			}
+		}
+	}
 }
 
 var validMemoryProviders = map[string]bool{
@@ -153,6 +278,12 @@ func validateSearch(cfg *Config, ve *ValidationError) {
 	if s.EmbeddingCacheSize < 0 {
 		ve.Add("memory.search.embedding_cache_size must be >= 0")
 	}
+	if s.HNSWEfSearch < 0 {
+		ve.Add("memory.search.hnsw_ef_search must be >= 0")
+	}
+	if cfg.Memory.Embedding.ReembedBatchSize < 0 {
+		ve.Add("memory.embedding.reembed_batch_size must be >= 0")
+	}
 }
 
 var validSearchBackends = map[string]bool{
@@ -175,6 +306,32 @@ var validCanvasBackends = map[string]bool{
 	"local": true,
 }
 
+var validEmailBackends = map[string]bool{
+	"mock": true,
+	"imap": true,
+}
+
+var validCalendarBackends = map[string]bool{
+	"mock":   true,
+	"caldav": true,
+}
+
+var validSmartHomeBackends = map[string]bool{
+	"mock":          true,
+	"homeassistant": true,
+}
+
+var validWorkflowStores = map[string]bool{
+	"file":   true,
+	"sqlite": true,
+}
+
+var validEmailTLSModes = map[string]bool{
+	"tls":      true,
+	"starttls": true,
+	"none":     true,
+}
+
 func validateTools(cfg *Config, ve *ValidationError) {
 	if cfg.Tools.SandboxRoot == "" {
 		ve.Add("tools.sandbox_root must not be empty")
@@ -237,6 +394,9 @@ func validateTools(cfg *Config, ve *ValidationError) {
 		if cfg.Tools.WorkflowMaxRunning <= 0 {
 			ve.Add("tools.workflow_max_running must be > 0 when workflow is enabled")
 		}
+		if !validWorkflowStores[cfg.Tools.WorkflowStore] {
+			ve.Add("tools.workflow_store %q is invalid (want: file, sqlite)", cfg.Tools.WorkflowStore)
+		}
 	}
 	if cfg.Tools.LLMTaskEnabled {
 		if cfg.Tools.LLMTaskTimeout <= 0 {
@@ -298,11 +458,37 @@ func validateTools(cfg *Config, ve *ValidationError) {
 		if cfg.Tools.EmailMaxSendsPerHour <= 0 {
 			ve.Add("tools.email_max_sends_per_hour must be > 0 when email is enabled")
 		}
+		if !validEmailBackends[cfg.Tools.EmailBackend] {
+			ve.Add("tools.email_backend %q is invalid (want: mock, imap)", cfg.Tools.EmailBackend)
+		}
+		if cfg.Tools.EmailBackend == "imap" {
+			if cfg.Tools.EmailIMAPAddr == "" {
+				ve.Add("tools.email_imap_addr is required when email_backend is imap")
+			}
+			if cfg.Tools.EmailSMTPAddr == "" {
+				ve.Add("tools.email_smtp_addr is required when email_backend is imap")
+			}
+			if cfg.Tools.EmailUsername == "" {
+				ve.Add("tools.email_username is required when email_backend is imap")
+			}
+			if !validEmailTLSModes[cfg.Tools.EmailIMAPTLS] {
+				ve.Add("tools.email_imap_tls %q is invalid (want: tls, starttls, none)", cfg.Tools.EmailIMAPTLS)
+			}
+			if !validEmailTLSModes[cfg.Tools.EmailSMTPTLS] {
+				ve.Add("tools.email_smtp_tls %q is invalid (want: tls, starttls, none)", cfg.Tools.EmailSMTPTLS)
+			}
+		}
 	}
 	if cfg.Tools.CalendarEnabled {
 		if cfg.Tools.CalendarTimeout <= 0 {
 			ve.Add("tools.calendar_timeout must be > 0 when calendar is enabled")
 		}
+		if !validCalendarBackends[cfg.Tools.CalendarBackend] {
+			ve.Add("tools.calendar_backend %q is invalid (want: mock, caldav)", cfg.Tools.CalendarBackend)
+		}
+		if cfg.Tools.CalendarBackend == "caldav" && cfg.Tools.CalendarURL == "" {
+			ve.Add("tools.calendar_url is required when calendar_backend is caldav")
+		}
 	}
 	if cfg.Tools.SmartHomeEnabled {
 		if cfg.Tools.SmartHomeURL == "" {
@@ -314,6 +500,19 @@ func validateTools(cfg *Config, ve *ValidationError) {
 		if cfg.Tools.SmartHomeMaxCallsPerMinute <= 0 {
 			ve.Add("tools.smarthome_max_calls_per_minute must be > 0 when smart_home is enabled")
 		}
+		if !validSmartHomeBackends[cfg.Tools.SmartHomeBackend] {
+			ve.Add("tools.smarthome_backend %q is invalid (want: mock, homeassistant)", cfg.Tools.SmartHomeBackend)
+		}
+		if cfg.Tools.SmartHomeBackend == "homeassistant" && cfg.Tools.SmartHomeToken == "" {
+			ve.Add("tools.smarthome_token is required when smarthome_backend is homeassistant")
+		}
+		for _, pats := range [][]string{cfg.Tools.SmartHomeAllowedEntities, cfg.Tools.SmartHomeDeniedEntities, cfg.Tools.SmartHomeAllowedServices} {
+			for _, pat := range pats {
+				if _, err := path.Match(pat, ""); err != nil {
+					ve.Add("tools.smarthome pattern %q is invalid: %v", pat, err)
+				}
+			}
+		}
 	}
 	if cfg.Tools.MCPEnabled {
 		if len(cfg.Tools.MCPServers) == 0 {
@@ -540,6 +739,11 @@ func validateSecurity(cfg *Config, ve *ValidationError) {
 	if cfg.Security.Audit.Enabled && cfg.Security.Audit.Path == "" {
 		ve.Add("security.audit.path is required when audit is enabled")
 	}
+	if v := cfg.Security.Audit.AnchorInterval; v != "" {
+		if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+			ve.Add("security.audit.anchor_interval %q must be a positive duration", v)
+		}
+	}
 }
 
 func validateScheduler(cfg *Config, ve *ValidationError) {
@@ -570,8 +774,56 @@ func validateGateway(cfg *Config, ve *ValidationError) {
 	if _, _, err := net.SplitHostPort(cfg.Gateway.Addr); err != nil {
 		ve.Add("gateway.addr %q is not a valid host:port", cfg.Gateway.Addr)
 	}
+
+	validVerify := map[string]bool{"": true, "hmac_sha256": true, "github": true, "token": true}
+	seen := make(map[string]bool)
+	for i, h := range cfg.Gateway.Hooks {
+		prefix := fmt.Sprintf("gateway.hooks[%d]", i)
+		switch {
+		case h.Name == "":
+			ve.Add("%s.name is required", prefix)
+		case !hookNameRe.MatchString(h.Name):
+			ve.Add("%s.name %q may only contain letters, digits, '-' and '_'", prefix, h.Name)
+		case seen[h.Name]:
+			ve.Add("%s: duplicate hook name %q", prefix, h.Name)
+		}
+		seen[h.Name] = true
+		if h.Secret == "" {
+			ve.Add("%s.secret is required", prefix)
+		}
+		if !validVerify[h.Verify] {
+			ve.Add("%s.verify %q is invalid (want: hmac_sha256, github, token)", prefix, h.Verify)
+		}
+		if h.TimestampHeader != "" && h.Verify != "" && h.Verify != "hmac_sha256" {
+			ve.Add("%s.timestamp_header requires verify hmac_sha256", prefix)
+		}
+		if h.TimestampHeader == "" && (h.Verify == "" || h.Verify == "hmac_sha256") {
+			ve.Add("%s.timestamp_header is required for hmac_sha256", prefix)
+		}
+		if h.Tolerance < 0 {
+			ve.Add("%s.tolerance must not be negative", prefix)
+		}
+		if h.Agent != "" && h.Pipeline != "" {
+			ve.Add("%s: set agent or pipeline, not both", prefix)
+		}
+		if h.Pipeline != "" && h.Prompt != "" {
+			ve.Add("%s.prompt applies to agent hooks only", prefix)
+		}
+		if h.Pipeline == "" && len(h.Args) > 0 {
+			ve.Add("%s.args applies to pipeline hooks only", prefix)
+		}
+		if h.Pipeline != "" && len(h.Tools) > 0 {
+			ve.Add("%s.tools applies to agent hooks only", prefix)
+		}
+		if (h.Channel == "") != (h.Target == "") {
+			ve.Add("%s: channel and target must be set together", prefix)
+		}
+	}
 }
 
+// hookNameRe matches webhook names, which appear in the /hooks/{name} path.
+var hookNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
 func validateAgents(cfg *Config, ve *ValidationError) {
 	if cfg.Agents == nil {
 		return
@@ -676,3 +928,35 @@ func validateCluster(cfg *Config, ve *ValidationError) {
 		}
 	}
 }
+
+var validTraceExporters = map[string]bool{
+	"":          true,
+	"noop":      true,
+	"stdout":    true,
+	"otlp-grpc": true,
+	"otlp-http": true,
+}
+
+var validMetricsExporters = map[string]bool{
+	"stdout":    true,
+	"otlp-grpc": true,
+	"otlp-http": true,
+}
+
+func validateTracer(cfg *Config, ve *ValidationError) {
+	t := cfg.Tracer
+	if t.Enabled && !validTraceExporters[t.Exporter] {
+		ve.Add("tracer.exporter %q is invalid (want: stdout, otlp-grpc, otlp-http, noop)", t.Exporter)
+	}
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		ve.Add("tracer.sample_ratio must be between 0 and 1")
+	}
+	if t.Metrics.Enabled {
+		if !validMetricsExporters[t.Metrics.Exporter] {
+			ve.Add("tracer.metrics.exporter %q is invalid (want: stdout, otlp-grpc, otlp-http)", t.Metrics.Exporter)
+		}
+		if t.Metrics.Interval < 0 {
+			ve.Add("tracer.metrics.interval must be >= 0")
+		}
+	}
+}
@@ -56,6 +56,26 @@ func TestValidateAgentCompressionThreshold(t *testing.T) {
 	assertContains(t, err.Error(), "agent.compression.keep_recent must be > 0")
 }
 
+func TestValidateAgentToolApprovalRules(t *testing.T) {
+	cfg := Defaults()
+	cfg.Agent.ToolApproval.Rules = []ToolApprovalRule{
+		{Tool: "shell", Action: "allow", Args: []ToolApprovalArg{{Path: "command", Matches: "^(ls|cat)$"}}},
+		{Tool: "github", Action: "block", Roles: []string{"guest"}},
+		{Tool: "filesystem", Action: "deny", Args: []ToolApprovalArg{{Path: "path"}, {Path: "x", Matches: "("}}},
+	}
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	assertContains(t, err.Error(), `agent.tool_approval.rules[1].action "block" is invalid`)
+	assertContains(t, err.Error(), `agent.tool_approval.rules[1].roles: unknown role "guest"`)
+	assertContains(t, err.Error(), "agent.tool_approval.rules[2].args[0] needs one of")
+	assertContains(t, err.Error(), "agent.tool_approval.rules[2].args[1].matches")
+	if strings.Contains(err.Error(), "rules[0]") {
+		t.Errorf("valid rule reported: %v", err)
+	}
+}
+
 func TestValidateLLMDefaultProviderEmpty(t *testing.T) {
 	cfg := Defaults()
 	cfg.LLM.DefaultProvider = ""
@@ -117,6 +137,75 @@ func TestValidateLLMAPIKeyEmpty(t *testing.T) {
 	assertContains(t, err.Error(), "ALFREDAI_LLM_PROVIDER_OPENAI_API_KEY")
 }
 
+func TestValidateLLMUsageBudgets(t *testing.T) {
+	cfg := Defaults()
+	cfg.LLM.Usage.Enabled = true
+	cfg.LLM.Usage.Pricing = map[string]ModelPriceConfig{"gpt-4o": {Input: -1}}
+	cfg.LLM.Usage.Budgets = []BudgetConfig{
+		{Scope: "team", Period: "week", Limit: 0, Action: "panic"},
+		{Scope: "session", Period: "day", Limit: 1, Action: "downgrade", Downgrade: "cheap"},
+	}
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{
+		"pricing[gpt-4o]: prices must not be negative",
+		`budgets[0].scope "team" is invalid`,
+		`budgets[0].period "week" is invalid`,
+		"budgets[0].limit must be positive",
+		`budgets[0].action "panic" is invalid`,
+		`downgrade preference "cheap" is not in llm.model_routing`,
+	} {
+		assertContains(t, err.Error(), want)
+	}
+
+	cfg.LLM.Usage.Pricing = nil
+	cfg.LLM.Usage.Budgets = cfg.LLM.Usage.Budgets[1:]
+	cfg.LLM.ModelRouting = map[string]string{"cheap": "openai"}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("expected valid: %v", err)
+	}
+}
+
+func TestValidateLLMRoutes(t *testing.T) {
+	cfg := Defaults()
+	cfg.LLM.DefaultProvider = "claude"
+	cfg.LLM.Providers = []ProviderConfig{
+		{Name: "claude-key1", Type: "anthropic", APIKey: "sk-1"},
+		{Name: "claude-key2", Type: "anthropic", APIKey: "sk-2"},
+	}
+	cfg.LLM.Routes = []RouteConfig{
+		{Name: "claude-key1", Providers: []RouteMemberConfig{{Provider: "missing", Weight: -1}}, MaxErrorRate: 2},
+		{Name: "empty"},
+	}
+	cfg.LLM.Failover.Strategy = "random"
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{
+		`llm.routes[0]: name "claude-key1" is already used`,
+		`llm.routes[0].providers[0]: provider "missing" is not configured`,
+		"llm.routes[0].providers[0].weight must not be negative",
+		"llm.routes[0] (claude-key1): max_error_rate must be between 0 and 1",
+		"llm.routes[1] (empty): providers must not be empty",
+		`default_provider "claude" does not match`,
+		`llm.failover.strategy "random" is invalid`,
+	} {
+		assertContains(t, err.Error(), want)
+	}
+
+	cfg.LLM.Routes = []RouteConfig{{Name: "claude", Providers: []RouteMemberConfig{
+		{Provider: "claude-key1", Weight: 2},
+		{Provider: "claude-key2", Standby: true},
+	}}}
+	cfg.LLM.Failover.Strategy = "health"
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("expected valid: %v", err)
+	}
+}
+
 func TestValidateMemoryInvalidProvider(t *testing.T) {
 	cfg := Defaults()
 	cfg.Memory.Provider = "unknown"
@@ -150,6 +239,16 @@ func TestValidateToolsSandboxEmpty(t *testing.T) {
 	assertContains(t, err.Error(), "tools.sandbox_root must not be empty")
 }
 
+func TestValidateSecurityAuditAnchorInterval(t *testing.T) {
+	cfg := Defaults()
+	cfg.Security.Audit.AnchorInterval = "soon"
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	assertContains(t, err.Error(), `security.audit.anchor_interval "soon" must be a positive duration`)
+}
+
 func TestValidateChannelsInvalidType(t *testing.T) {
 	cfg := Defaults()
 	cfg.Channels = []ChannelConfig{{Type: "unknown"}}
@@ -582,6 +681,22 @@ func TestValidateNotesDisabledNoValidation(t *testing.T) {
 	}
 }
 
+func TestValidateWorkflowStore(t *testing.T) {
+	cfg := Defaults()
+	cfg.Tools.WorkflowEnabled = true
+	cfg.Tools.WorkflowStore = "postgres"
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	assertContains(t, err.Error(), `tools.workflow_store "postgres" is invalid`)
+
+	cfg.Tools.WorkflowStore = "sqlite"
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("expected valid: %v", err)
+	}
+}
+
 // --- GitHub tool validation ---
 
 func TestValidateGitHubEnabledBadTimeout(t *testing.T) {
@@ -646,6 +761,57 @@ func TestValidateEmailEnabledValid(t *testing.T) {
 	}
 }
 
+func TestValidateEmailBadBackend(t *testing.T) {
+	cfg := Defaults()
+	cfg.Tools.EmailEnabled = true
+	cfg.Tools.EmailBackend = "pop3"
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	assertContains(t, err.Error(), "tools.email_backend")
+}
+
+func TestValidateEmailIMAPRequiresServers(t *testing.T) {
+	cfg := Defaults()
+	cfg.Tools.EmailEnabled = true
+	cfg.Tools.EmailBackend = "imap"
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	assertContains(t, err.Error(), "tools.email_imap_addr is required")
+	assertContains(t, err.Error(), "tools.email_smtp_addr is required")
+	assertContains(t, err.Error(), "tools.email_username is required")
+}
+
+func TestValidateEmailIMAPBadTLSMode(t *testing.T) {
+	cfg := Defaults()
+	cfg.Tools.EmailEnabled = true
+	cfg.Tools.EmailBackend = "imap"
+	cfg.Tools.EmailIMAPAddr = "imap.example.com:993"
+	cfg.Tools.EmailSMTPAddr = "smtp.example.com:587"
+	cfg.Tools.EmailUsername = "me@example.com"
+	cfg.Tools.EmailSMTPTLS = "ssl"
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	assertContains(t, err.Error(), "tools.email_smtp_tls")
+}
+
+func TestValidateEmailIMAPValid(t *testing.T) {
+	cfg := Defaults()
+	cfg.Tools.EmailEnabled = true
+	cfg.Tools.EmailBackend = "imap"
+	cfg.Tools.EmailIMAPAddr = "imap.example.com:993"
+	cfg.Tools.EmailSMTPAddr = "smtp.example.com:587"
+	cfg.Tools.EmailUsername = "me@example.com"
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("expected valid: %v", err)
+	}
+}
+
 // --- Calendar tool validation ---
 
 func TestValidateCalendarEnabledBadTimeout(t *testing.T) {
@@ -667,6 +833,28 @@ func TestValidateCalendarEnabledValid(t *testing.T) {
 	}
 }
 
+func TestValidateCalendarCalDAVRequiresURL(t *testing.T) {
+	cfg := Defaults()
+	cfg.Tools.CalendarEnabled = true
+	cfg.Tools.CalendarBackend = "caldav"
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	assertContains(t, err.Error(), "tools.calendar_url is required")
+}
+
+func TestValidateCalendarBadBackend(t *testing.T) {
+	cfg := Defaults()
+	cfg.Tools.CalendarEnabled = true
+	cfg.Tools.CalendarBackend = "google"
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	assertContains(t, err.Error(), "tools.calendar_backend")
+}
+
 // --- Smart Home tool validation ---
 
 func TestValidateSmartHomeEnabledMissingURL(t *testing.T) {
@@ -713,6 +901,37 @@ func TestValidateSmartHomeEnabledValid(t *testing.T) {
 	}
 }
 
+func TestValidateSmartHomeHomeAssistantRequiresToken(t *testing.T) {
+	cfg := Defaults()
+	cfg.Tools.SmartHomeEnabled = true
+	cfg.Tools.SmartHomeURL = "http://ha:8123"
+	cfg.Tools.SmartHomeBackend = "homeassistant"
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	assertContains(t, err.Error(), "tools.smarthome_token is required")
+
+	cfg.Tools.SmartHomeToken = "tok"
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("expected valid: %v", err)
+	}
+}
+
+func TestValidateSmartHomeBadBackendAndPattern(t *testing.T) {
+	cfg := Defaults()
+	cfg.Tools.SmartHomeEnabled = true
+	cfg.Tools.SmartHomeURL = "http://ha:8123"
+	cfg.Tools.SmartHomeBackend = "openhab"
+	cfg.Tools.SmartHomeAllowedEntities = []string{"light.[a"}
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	assertContains(t, err.Error(), "tools.smarthome_backend")
+	assertContains(t, err.Error(), "light.[a")
+}
+
 func TestValidateSmartHomeDisabledNoValidation(t *testing.T) {
 	cfg := Defaults()
 	cfg.Tools.SmartHomeEnabled = false
@@ -820,3 +1039,74 @@ func assertContains(t *testing.T, s, substr string) {
 		t.Errorf("expected %q to contain %q", s, substr)
 	}
 }
+
+func TestValidateTracer(t *testing.T) {
+	cfg := Defaults()
+	cfg.Tracer.Enabled = true
+	cfg.Tracer.Exporter = "jaeger"
+	cfg.Tracer.SampleRatio = 1.5
+	cfg.Tracer.Metrics = MetricsConfig{Enabled: true, Exporter: "prometheus"}
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	assertContains(t, err.Error(), `tracer.exporter "jaeger" is invalid`)
+	assertContains(t, err.Error(), "tracer.sample_ratio must be between 0 and 1")
+	assertContains(t, err.Error(), `tracer.metrics.exporter "prometheus" is invalid`)
+
+	cfg.Tracer.Exporter = "otlp-grpc"
+	cfg.Tracer.SampleRatio = 0.5
+	cfg.Tracer.Metrics.Exporter = "otlp-http"
+	if err := Validate(cfg); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateSearchHNSWEfSearchNegative(t *testing.T) {
+	cfg := Defaults()
+	cfg.Memory.Search.HNSWEfSearch = -1
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	assertContains(t, err.Error(), "memory.search.hnsw_ef_search must be >= 0")
+}
+
+func TestValidateEmbeddingReembedBatchSizeNegative(t *testing.T) {
+	cfg := Defaults()
+	cfg.Memory.Embedding.ReembedBatchSize = -1
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	assertContains(t, err.Error(), "memory.embedding.reembed_batch_size must be >= 0")
+}
+
+func TestValidateGatewayHooks(t *testing.T) {
+	cfg := Defaults()
+	cfg.Gateway.Enabled = true
+	cfg.Gateway.Hooks = []HookConfig{
+		{Name: "github", Secret: "s", Verify: "github", Agent: "ops"},
+		{Name: "github", Secret: "s"},
+		{Name: "bad/name", Verify: "md5"},
+		{Name: "both", Secret: "s", Agent: "ops", Pipeline: "p", Channel: "slack"},
+		{Name: "ts", Secret: "s", Verify: "token", TimestampHeader: "X-Timestamp"},
+		{Name: "tools", Secret: "s", TimestampHeader: "X-Timestamp", Pipeline: "p", Tools: []string{"web_search"}},
+	}
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	assertContains(t, err.Error(), `gateway.hooks[1]: duplicate hook name "github"`)
+	assertContains(t, err.Error(), `gateway.hooks[2].name "bad/name" may only contain`)
+	assertContains(t, err.Error(), "gateway.hooks[2].secret is required")
+	assertContains(t, err.Error(), `gateway.hooks[2].verify "md5" is invalid`)
+	assertContains(t, err.Error(), "gateway.hooks[3]: set agent or pipeline, not both")
+	assertContains(t, err.Error(), "gateway.hooks[3]: channel and target must be set together")
+	assertContains(t, err.Error(), "gateway.hooks[4].timestamp_header requires verify hmac_sha256")
+	assertContains(t, err.Error(), "gateway.hooks[1].timestamp_header is required for hmac_sha256")
+	assertContains(t, err.Error(), "gateway.hooks[5].tools applies to agent hooks only")
+	if strings.Contains(err.Error(), "gateway.hooks[0]") {
+		t.Errorf("valid hook reported: %v", err)
+	}
+}
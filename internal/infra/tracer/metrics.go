@@ -0,0 +1,231 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+
+	"alfred-ai/internal/infra/config"
+)
+
+const defaultMetricsInterval = 60 * time.Second
+
+// Metric names exported by the metrics pipeline.
+const (
+	MetricLLMDuration  = "alfredai.llm.duration"
+	MetricLLMTokens    = "alfredai.llm.tokens"
+	MetricToolDuration = "alfredai.tool.duration"
+	MetricCircuitState = "alfredai.llm.circuit_state"
+)
+
+// instruments holds the metric instruments of the active MeterProvider.
+// It is nil when metrics are disabled, making the Record helpers no-ops.
+type instruments struct {
+	llmDuration  metric.Float64Histogram
+	llmTokens    metric.Int64Counter
+	toolDuration metric.Float64Histogram
+}
+
+var active atomic.Pointer[instruments]
+
+var (
+	circuitMu     sync.RWMutex
+	circuitSource func() map[string]int64
+)
+
+// ObserveCircuitBreakers registers the source of the circuit-breaker state
+// gauge: a map of provider name to state (0 closed, 1 half-open, 2 open).
+// It may be called before or after Setup.
+func ObserveCircuitBreakers(states func() map[string]int64) {
+	circuitMu.Lock()
+	circuitSource = states
+	circuitMu.Unlock()
+}
+
+func setupMetrics(ctx context.Context, cfg config.TracerConfig, res *resource.Resource) (func(context.Context) error, error) {
+	active.Store(nil)
+	if !cfg.Metrics.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newMetricExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	interval := cfg.Metrics.Interval
+	if interval <= 0 {
+		interval = defaultMetricsInterval
+	}
+
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(interval))),
+		sdkmetric.WithResource(res),
+	)
+	inst, err := newInstruments(mp.Meter(tracerName))
+	if err != nil {
+		mp.Shutdown(ctx)
+		return nil, fmt.Errorf("create metric instruments: %w", err)
+	}
+	otel.SetMeterProvider(mp)
+	active.Store(inst)
+
+	return func(ctx context.Context) error {
+		active.CompareAndSwap(inst, nil)
+		return mp.Shutdown(ctx)
+	}, nil
+}
+
+func newMetricExporter(ctx context.Context, cfg config.TracerConfig) (sdkmetric.Exporter, error) {
+	endpoint := cfg.Metrics.Endpoint
+	if endpoint == "" {
+		endpoint = cfg.Endpoint
+	}
+
+	switch cfg.Metrics.Exporter {
+	case "stdout":
+		exp, err := stdoutmetric.New()
+		if err != nil {
+			return nil, fmt.Errorf("create stdout metric exporter: %w", err)
+		}
+		return exp, nil
+	case "otlp-grpc":
+		var opts []otlpmetricgrpc.Option
+		switch {
+		case hasScheme(endpoint):
+			opts = append(opts, otlpmetricgrpc.WithEndpointURL(endpoint))
+		case endpoint != "":
+			opts = append(opts, otlpmetricgrpc.WithEndpoint(endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetricgrpc.WithHeaders(cfg.Headers))
+		}
+		exp, err := otlpmetricgrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("create otlp-grpc metric exporter: %w", err)
+		}
+		return exp, nil
+	case "otlp-http":
+		var opts []otlpmetrichttp.Option
+		switch {
+		case hasScheme(endpoint):
+			opts = append(opts, otlpmetrichttp.WithEndpointURL(endpoint))
+		case endpoint != "":
+			opts = append(opts, otlpmetrichttp.WithEndpoint(endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(cfg.Headers))
+		}
+		exp, err := otlpmetrichttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("create otlp-http metric exporter: %w", err)
+		}
+		return exp, nil
+	default:
+		return nil, fmt.Errorf("unsupported metrics exporter: %s", cfg.Metrics.Exporter)
+	}
+}
+
+func newInstruments(m metric.Meter) (*instruments, error) {
+	var inst instruments
+	var err error
+	if inst.llmDuration, err = m.Float64Histogram(MetricLLMDuration,
+		metric.WithDescription("LLM request latency per provider"),
+		metric.WithUnit("s"),
+	); err != nil {
+		return nil, err
+	}
+	if inst.llmTokens, err = m.Int64Counter(MetricLLMTokens,
+		metric.WithDescription("LLM tokens used per model and tenant"),
+		metric.WithUnit("{token}"),
+	); err != nil {
+		return nil, err
+	}
+	if inst.toolDuration, err = m.Float64Histogram(MetricToolDuration,
+		metric.WithDescription("Tool execution time per tool"),
+		metric.WithUnit("s"),
+	); err != nil {
+		return nil, err
+	}
+	_, err = m.Int64ObservableGauge(MetricCircuitState,
+		metric.WithDescription("LLM circuit breaker state (0 closed, 1 half-open, 2 open)"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			circuitMu.RLock()
+			src := circuitSource
+			circuitMu.RUnlock()
+			if src == nil {
+				return nil
+			}
+			for provider, state := range src() {
+				o.Observe(state, metric.WithAttributes(attribute.String("provider", provider)))
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &inst, nil
+}
+
+// RecordLLMCall records the latency of one LLM request.
+func RecordLLMCall(ctx context.Context, provider string, d time.Duration, err error) {
+	inst := active.Load()
+	if inst == nil {
+		return
+	}
+	inst.llmDuration.Record(ctx, d.Seconds(), metric.WithAttributes(
+		attribute.String("provider", provider),
+		attribute.Bool("error", err != nil),
+	))
+}
+
+// RecordTokens adds prompt and completion token counts for a model and tenant.
+func RecordTokens(ctx context.Context, model, tenant string, prompt, completion int) {
+	inst := active.Load()
+	if inst == nil {
+		return
+	}
+	if prompt > 0 {
+		inst.llmTokens.Add(ctx, int64(prompt), metric.WithAttributes(
+			attribute.String("model", model),
+			attribute.String("tenant", tenant),
+			attribute.String("type", "prompt"),
+		))
+	}
+	if completion > 0 {
+		inst.llmTokens.Add(ctx, int64(completion), metric.WithAttributes(
+			attribute.String("model", model),
+			attribute.String("tenant", tenant),
+			attribute.String("type", "completion"),
+		))
+	}
+}
+
+// RecordToolCall records the execution time of one tool call.
+func RecordToolCall(ctx context.Context, tool string, d time.Duration, err error) {
+	inst := active.Load()
+	if inst == nil {
+		return
+	}
+	inst.toolDuration.Record(ctx, d.Seconds(), metric.WithAttributes(
+		attribute.String("tool", tool),
+		attribute.Bool("error", err != nil),
+	))
+}
@@ -0,0 +1,354 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	collectormetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"alfred-ai/internal/infra/config"
+)
+
+// receiver collects OTLP exports from either transport.
+type receiver struct {
+	mu      sync.Mutex
+	traces  []*collectortrace.ExportTraceServiceRequest
+	metrics []*collectormetrics.ExportMetricsServiceRequest
+	headers []string // values of the "x-api-key" header
+}
+
+func (r *receiver) addTraces(req *collectortrace.ExportTraceServiceRequest, key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.traces = append(r.traces, req)
+	r.headers = append(r.headers, key)
+}
+
+func (r *receiver) addMetrics(req *collectormetrics.ExportMetricsServiceRequest, key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, req)
+	r.headers = append(r.headers, key)
+}
+
+// spanNames returns the names of all received spans.
+func (r *receiver) spanNames() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var names []string
+	for _, req := range r.traces {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					names = append(names, s.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// resourceAttr returns a resource attribute of the first received trace.
+func (r *receiver) resourceAttr(key string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.traces) == 0 || len(r.traces[0].ResourceSpans) == 0 {
+		return ""
+	}
+	return attrValue(r.traces[0].ResourceSpans[0].Resource.Attributes, key)
+}
+
+// metricsByName returns all received metrics keyed by name.
+func (r *receiver) metricsByName() map[string]*metricspb.Metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string]*metricspb.Metric)
+	for _, req := range r.metrics {
+		for _, rm := range req.ResourceMetrics {
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					out[m.Name] = m
+				}
+			}
+		}
+	}
+	return out
+}
+
+func attrValue(attrs []*commonpb.KeyValue, key string) string {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value.GetStringValue()
+		}
+	}
+	return ""
+}
+
+// newHTTPReceiver starts an OTLP/HTTP receiver and returns its URL.
+func newHTTPReceiver(t *testing.T) (*receiver, string) {
+	t.Helper()
+	r := &receiver{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/traces", func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		var msg collectortrace.ExportTraceServiceRequest
+		if err := proto.Unmarshal(body, &msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.addTraces(&msg, req.Header.Get("X-Api-Key"))
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	})
+	mux.HandleFunc("/v1/metrics", func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		var msg collectormetrics.ExportMetricsServiceRequest
+		if err := proto.Unmarshal(body, &msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.addMetrics(&msg, req.Header.Get("X-Api-Key"))
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return r, srv.URL
+}
+
+type grpcTraceService struct {
+	collectortrace.UnimplementedTraceServiceServer
+	r *receiver
+}
+
+func (s *grpcTraceService) Export(ctx context.Context, req *collectortrace.ExportTraceServiceRequest) (*collectortrace.ExportTraceServiceResponse, error) {
+	s.r.addTraces(req, firstMetadata(ctx, "x-api-key"))
+	return &collectortrace.ExportTraceServiceResponse{}, nil
+}
+
+type grpcMetricsService struct {
+	collectormetrics.UnimplementedMetricsServiceServer
+	r *receiver
+}
+
+func (s *grpcMetricsService) Export(ctx context.Context, req *collectormetrics.ExportMetricsServiceRequest) (*collectormetrics.ExportMetricsServiceResponse, error) {
+	s.r.addMetrics(req, firstMetadata(ctx, "x-api-key"))
+	return &collectormetrics.ExportMetricsServiceResponse{}, nil
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// newGRPCReceiver starts an OTLP/gRPC receiver and returns its host:port.
+func newGRPCReceiver(t *testing.T) (*receiver, string) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	r := &receiver{}
+	srv := grpc.NewServer()
+	collectortrace.RegisterTraceServiceServer(srv, &grpcTraceService{r: r})
+	collectormetrics.RegisterMetricsServiceServer(srv, &grpcMetricsService{r: r})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return r, lis.Addr().String()
+}
+
+func TestSetupOTLPHTTPTraces(t *testing.T) {
+	r, url := newHTTPReceiver(t)
+	shutdown, err := Setup(context.Background(), config.TracerConfig{
+		Enabled:            true,
+		Exporter:           "otlp-http",
+		Endpoint:           url,
+		Headers:            map[string]string{"X-Api-Key": "secret"},
+		ServiceName:        "alfred-test",
+		ResourceAttributes: map[string]string{"deployment.environment": "test"},
+	})
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+
+	_, span := StartSpan(context.Background(), "http-span")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	if names := r.spanNames(); len(names) != 1 || names[0] != "http-span" {
+		t.Fatalf("spans = %v, want [http-span]", names)
+	}
+	if got := r.resourceAttr("service.name"); got != "alfred-test" {
+		t.Errorf("service.name = %q", got)
+	}
+	if got := r.resourceAttr("deployment.environment"); got != "test" {
+		t.Errorf("deployment.environment = %q", got)
+	}
+	if r.headers[0] != "secret" {
+		t.Errorf("header = %q, want secret", r.headers[0])
+	}
+}
+
+func TestSetupOTLPGRPCTraces(t *testing.T) {
+	r, addr := newGRPCReceiver(t)
+	shutdown, err := Setup(context.Background(), config.TracerConfig{
+		Enabled:  true,
+		Exporter: "otlp-grpc",
+		Endpoint: addr,
+		Insecure: true,
+		Headers:  map[string]string{"x-api-key": "secret"},
+	})
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+
+	_, span := StartSpan(context.Background(), "grpc-span")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	if names := r.spanNames(); len(names) != 1 || names[0] != "grpc-span" {
+		t.Fatalf("spans = %v, want [grpc-span]", names)
+	}
+	if got := r.resourceAttr("service.name"); got != defaultServiceName {
+		t.Errorf("service.name = %q, want %q", got, defaultServiceName)
+	}
+	if r.headers[0] != "secret" {
+		t.Errorf("header = %q, want secret", r.headers[0])
+	}
+}
+
+func TestSetupSampleRatio(t *testing.T) {
+	r, url := newHTTPReceiver(t)
+	shutdown, err := Setup(context.Background(), config.TracerConfig{
+		Enabled:     true,
+		Exporter:    "otlp-http",
+		Endpoint:    url,
+		SampleRatio: 0.000001,
+	})
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		_, span := StartSpan(context.Background(), "sampled-out")
+		span.End()
+	}
+	shutdown(context.Background())
+
+	if names := r.spanNames(); len(names) != 0 {
+		t.Errorf("expected no sampled spans, got %d", len(names))
+	}
+}
+
+func TestSetupMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter string
+		start    func(*testing.T) (*receiver, string)
+	}{
+		{"http", "otlp-http", newHTTPReceiver},
+		{"grpc", "otlp-grpc", newGRPCReceiver},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, endpoint := tt.start(t)
+			ObserveCircuitBreakers(func() map[string]int64 {
+				return map[string]int64{"openai": 2}
+			})
+			t.Cleanup(func() { ObserveCircuitBreakers(nil) })
+
+			shutdown, err := Setup(context.Background(), config.TracerConfig{
+				Endpoint: endpoint,
+				Insecure: true,
+				Metrics: config.MetricsConfig{
+					Enabled:  true,
+					Exporter: tt.exporter,
+					Interval: time.Hour,
+				},
+			})
+			if err != nil {
+				t.Fatalf("Setup: %v", err)
+			}
+
+			ctx := context.Background()
+			RecordLLMCall(ctx, "openai", 250*time.Millisecond, nil)
+			RecordLLMCall(ctx, "openai", time.Second, errors.New("boom"))
+			RecordTokens(ctx, "gpt-4o", "acme", 100, 20)
+			RecordToolCall(ctx, "filesystem", 10*time.Millisecond, nil)
+			if err := shutdown(ctx); err != nil {
+				t.Fatalf("shutdown: %v", err)
+			}
+
+			got := r.metricsByName()
+			llm := got[MetricLLMDuration]
+			if llm == nil || len(llm.GetHistogram().GetDataPoints()) != 2 {
+				t.Fatalf("%s: %v", MetricLLMDuration, llm)
+			}
+			for _, dp := range llm.GetHistogram().GetDataPoints() {
+				if attrValue(dp.Attributes, "provider") != "openai" || dp.GetCount() != 1 {
+					t.Errorf("llm duration point: %v", dp)
+				}
+			}
+
+			tokens := map[string]int64{}
+			for _, dp := range got[MetricLLMTokens].GetSum().GetDataPoints() {
+				if attrValue(dp.Attributes, "model") != "gpt-4o" || attrValue(dp.Attributes, "tenant") != "acme" {
+					t.Errorf("token point attrs: %v", dp.Attributes)
+				}
+				tokens[attrValue(dp.Attributes, "type")] = dp.GetAsInt()
+			}
+			if tokens["prompt"] != 100 || tokens["completion"] != 20 {
+				t.Errorf("tokens = %v", tokens)
+			}
+
+			tool := got[MetricToolDuration].GetHistogram().GetDataPoints()
+			if len(tool) != 1 || attrValue(tool[0].Attributes, "tool") != "filesystem" {
+				t.Errorf("tool duration: %v", tool)
+			}
+
+			cb := got[MetricCircuitState].GetGauge().GetDataPoints()
+			if len(cb) != 1 || cb[0].GetAsInt() != 2 || attrValue(cb[0].Attributes, "provider") != "openai" {
+				t.Errorf("circuit state: %v", cb)
+			}
+		})
+	}
+}
+
+func TestRecordWithoutMetrics(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.TracerConfig{})
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	defer shutdown(context.Background())
+
+	// No pipeline: these must not panic.
+	RecordLLMCall(context.Background(), "openai", time.Second, nil)
+	RecordTokens(context.Background(), "gpt-4o", "", 1, 1)
+	RecordToolCall(context.Background(), "shell", time.Second, nil)
+}
+
+func TestSetupUnsupportedMetricsExporter(t *testing.T) {
+	_, err := Setup(context.Background(), config.TracerConfig{
+		Metrics: config.MetricsConfig{Enabled: true, Exporter: "invalid"},
+	})
+	if err == nil {
+		t.Error("expected error for unsupported metrics exporter")
+	}
+}
@@ -2,12 +2,18 @@ package tracer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/trace/noop"
@@ -15,11 +21,36 @@ import (
 	"alfred-ai/internal/infra/config"
 )
 
-const tracerName = "alfred-ai"
+const (
+	tracerName         = "alfred-ai"
+	defaultServiceName = "alfred-ai"
+)
 
-// Setup initializes OpenTelemetry tracing and returns a shutdown function.
-// When cfg.Enabled is false, a noop TracerProvider is used (zero overhead).
+// Setup initializes OpenTelemetry tracing and metrics and returns a shutdown
+// function. When cfg.Enabled is false, a noop TracerProvider is used (zero
+// overhead); metrics are set up independently when cfg.Metrics.Enabled.
 func Setup(ctx context.Context, cfg config.TracerConfig) (func(context.Context) error, error) {
+	res, err := newResource(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("create resource: %w", err)
+	}
+
+	traceShutdown, err := setupTraces(ctx, cfg, res)
+	if err != nil {
+		return nil, err
+	}
+	metricsShutdown, err := setupMetrics(ctx, cfg, res)
+	if err != nil {
+		traceShutdown(ctx)
+		return nil, err
+	}
+
+	return func(ctx context.Context) error {
+		return errors.Join(traceShutdown(ctx), metricsShutdown(ctx))
+	}, nil
+}
+
+func setupTraces(ctx context.Context, cfg config.TracerConfig, res *resource.Resource) (func(context.Context) error, error) {
 	noopShutdown := func(context.Context) error { return nil }
 
 	if !cfg.Enabled {
@@ -36,6 +67,16 @@ func Setup(ctx context.Context, cfg config.TracerConfig) (func(context.Context)
 		if err != nil {
 			return nil, fmt.Errorf("create stdout exporter: %w", err)
 		}
+	case "otlp-grpc":
+		exporter, err = otlptracegrpc.New(ctx, otlpTraceGRPCOptions(cfg)...)
+		if err != nil {
+			return nil, fmt.Errorf("create otlp-grpc exporter: %w", err)
+		}
+	case "otlp-http":
+		exporter, err = otlptracehttp.New(ctx, otlpTraceHTTPOptions(cfg)...)
+		if err != nil {
+			return nil, fmt.Errorf("create otlp-http exporter: %w", err)
+		}
 	case "noop", "":
 		otel.SetTracerProvider(noop.NewTracerProvider())
 		return noopShutdown, nil
@@ -45,13 +86,82 @@ func Setup(ctx context.Context, cfg config.TracerConfig) (func(context.Context)
 
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithBatcher(exporter),
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSampler(sampler(cfg.SampleRatio)),
+		sdktrace.WithResource(res),
 	)
 	otel.SetTracerProvider(tp)
 
 	return tp.Shutdown, nil
 }
 
+// sampler samples all traces unless ratio is strictly between 0 and 1.
+// Child spans follow their parent's decision.
+func sampler(ratio float64) sdktrace.Sampler {
+	if ratio <= 0 || ratio >= 1 {
+		return sdktrace.AlwaysSample()
+	}
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+}
+
+// newResource describes this process: the SDK defaults (including
+// OTEL_RESOURCE_ATTRIBUTES) overlaid with the configured service name and
+// attributes.
+func newResource(ctx context.Context, cfg config.TracerConfig) (*resource.Resource, error) {
+	name := cfg.ServiceName
+	if name == "" {
+		name = defaultServiceName
+	}
+	attrs := []attribute.KeyValue{attribute.String("service.name", name)}
+	keys := make([]string, 0, len(cfg.ResourceAttributes))
+	for k := range cfg.ResourceAttributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs = append(attrs, attribute.String(k, cfg.ResourceAttributes[k]))
+	}
+	return resource.Merge(resource.Default(), resource.NewSchemaless(attrs...))
+}
+
+// hasScheme reports whether endpoint is a full URL rather than host:port.
+func hasScheme(endpoint string) bool {
+	return strings.Contains(endpoint, "://")
+}
+
+func otlpTraceGRPCOptions(cfg config.TracerConfig) []otlptracegrpc.Option {
+	var opts []otlptracegrpc.Option
+	switch {
+	case hasScheme(cfg.Endpoint):
+		opts = append(opts, otlptracegrpc.WithEndpointURL(cfg.Endpoint))
+	case cfg.Endpoint != "":
+		opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+	}
+	return opts
+}
+
+func otlpTraceHTTPOptions(cfg config.TracerConfig) []otlptracehttp.Option {
+	var opts []otlptracehttp.Option
+	switch {
+	case hasScheme(cfg.Endpoint):
+		opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	case cfg.Endpoint != "":
+		opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	return opts
+}
+
 // StartSpan is a convenience helper to start a named span.
 func StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
 	return otel.Tracer(tracerName).Start(ctx, name, opts...)
@@ -25,21 +25,45 @@ type RetentionPolicy struct {
 }
 
 // FileAuditLogger implements domain.AuditLogger by writing JSONL to a file.
+// Records are hash-chained and, with WithAuditKey, signed; see
+// VerifyAuditLog.
 type FileAuditLogger struct {
 	mu        sync.Mutex
 	file      *os.File
 	path      string
 	retention *RetentionPolicy
+	key       []byte    // HMAC key; nil = records are chained but unsigned
+	head      chainHead // last record written
+
+	anchorMu sync.Mutex
+	anchored chainHead // head at the last anchor
+}
+
+// AuditOption configures a FileAuditLogger.
+type AuditOption func(*FileAuditLogger)
+
+// WithAuditKey signs every record with HMAC-SHA256 under key.
+func WithAuditKey(key []byte) AuditOption {
+	return func(a *FileAuditLogger) { a.key = key }
 }
 
 // NewFileAuditLogger creates an audit logger that appends to the given path.
-// The file is created with 0600 permissions if it does not exist.
-func NewFileAuditLogger(path string) (*FileAuditLogger, error) {
+// The file is created with 0600 permissions if it does not exist. An existing
+// file's chain is continued.
+func NewFileAuditLogger(path string, opts ...AuditOption) (*FileAuditLogger, error) {
+	head, err := readChainHead(path)
+	if err != nil {
+		return nil, fmt.Errorf("read audit chain: %w", err)
+	}
 	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 	if err != nil {
 		return nil, fmt.Errorf("open audit log: %w", err)
 	}
-	return &FileAuditLogger{file: f, path: path}, nil
+	a := &FileAuditLogger{file: f, path: path, head: head}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a, nil
 }
 
 // SetRetention configures the retention policy for log cleanup.
@@ -55,17 +79,19 @@ func (a *FileAuditLogger) Log(ctx context.Context, event domain.AuditEvent) erro
 		event.Timestamp = time.Now().UTC()
 	}
 
-	data, err := json.Marshal(event)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	data, err := json.Marshal(auditRecord{AuditEvent: event, Seq: a.head.seq + 1, Prev: a.head.hash})
 	if err != nil {
 		return domain.NewDomainError("FileAuditLogger.Log", domain.ErrAuditWrite, err.Error())
 	}
+	line := signLine(data, a.key)
 
-	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	if _, err := a.file.Write(append(data, '\n')); err != nil {
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
 		return domain.NewDomainError("FileAuditLogger.Log", domain.ErrAuditWrite, err.Error())
 	}
+	a.head = chainHead{seq: a.head.seq + 1, hash: lineHash(line)}
 
 	// Also emit as OTel span event if a span is active
 	span := trace.SpanFromContext(ctx)
@@ -111,8 +137,9 @@ func (a *FileAuditLogger) Close() error {
 }
 
 // EnforceRetention removes old entries based on the configured retention policy.
-// It rewrites the log file, keeping only entries that satisfy the policy.
-// This is safe to call while the logger is active.
+// Only a prefix of the log is removed, so the remaining records still form a
+// chain; a signed checkpoint record at the top of the file names the last
+// removed record. This is safe to call while the logger is active.
 func (a *FileAuditLogger) EnforceRetention(ctx context.Context) (removed int, err error) {
 	a.mu.Lock()
 	policy := a.retention
@@ -139,16 +166,10 @@ func (a *FileAuditLogger) EnforceRetention(ctx context.Context) (removed int, er
 		cutoff = time.Now().Add(-policy.MaxAge)
 	}
 
-	// Read all lines, filter, write back.
+	// Read all lines, drop the expired prefix, write back.
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	// Close current file handle.
-	if err := a.file.Close(); err != nil {
-		return 0, fmt.Errorf("close for retention: %w", err)
-	}
-
-	// Read existing entries.
 	readFile, err := os.Open(path)
 	if err != nil {
 		return 0, fmt.Errorf("open for reading: %w", err)
@@ -164,20 +185,6 @@ func (a *FileAuditLogger) EnforceRetention(ctx context.Context) (removed int, er
 		if len(line) == 0 {
 			continue
 		}
-
-		// Check age if policy has MaxAge.
-		if !cutoff.IsZero() {
-			var entry struct {
-				Timestamp time.Time `json:"timestamp"`
-			}
-			if json.Unmarshal(line, &entry) == nil && !entry.Timestamp.IsZero() {
-				if entry.Timestamp.Before(cutoff) {
-					removed++
-					continue
-				}
-			}
-		}
-
 		lineCopy := make([]byte, len(line))
 		copy(lineCopy, line)
 		kept = append(kept, lineCopy)
@@ -186,34 +193,88 @@ func (a *FileAuditLogger) EnforceRetention(ctx context.Context) (removed int, er
 	readFile.Close()
 
 	if err := scanner.Err(); err != nil {
-		// Reopen file handle before returning.
-		a.file, _ = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 		return 0, fmt.Errorf("scan audit log: %w", err)
 	}
 
-	// If MaxSize is set and we're still over, trim oldest entries.
-	if policy.MaxSize > 0 && keptSize > policy.MaxSize {
-		for len(kept) > 0 && keptSize > policy.MaxSize {
+	// base is the chain head just before the first kept record. A previous
+	// checkpoint is replaced by the new one.
+	var base chainHead
+	if len(kept) > 0 {
+		if rec, err := parseRecord(kept[0]); err == nil && rec.isCheckpoint() {
+			if b, err := rec.checkpointBase(); err == nil {
+				base = b
+			}
 			keptSize -= int64(len(kept[0])) + 1
 			kept = kept[1:]
-			removed++
 		}
 	}
+	drop := func() {
+		base = base.advance(kept[0])
+		keptSize -= int64(len(kept[0])) + 1
+		kept = kept[1:]
+		removed++
+	}
 
-	// Write back filtered entries.
+	// Drop entries older than MaxAge, stopping at the first recent one.
+	if !cutoff.IsZero() {
+		for len(kept) > 0 {
+			var entry struct {
+				Timestamp time.Time `json:"timestamp"`
+			}
+			if json.Unmarshal(kept[0], &entry) != nil || entry.Timestamp.IsZero() || !entry.Timestamp.Before(cutoff) {
+				break
+			}
+			drop()
+		}
+	}
+
+	// checkpoint is needed once chained or legacy records have been removed.
+	checkpoint := func() ([]byte, error) {
+		if base == (chainHead{}) {
+			return nil, nil
+		}
+		return checkpointLine(base, removed, a.key)
+	}
+	cp, err := checkpoint()
+	if err != nil {
+		return 0, fmt.Errorf("checkpoint: %w", err)
+	}
+
+	// If MaxSize is set and we're still over, trim oldest entries.
+	for policy.MaxSize > 0 && len(kept) > 0 && keptSize+int64(len(cp))+1 > policy.MaxSize {
+		drop()
+		if cp, err = checkpoint(); err != nil {
+			return 0, fmt.Errorf("checkpoint: %w", err)
+		}
+	}
+
+	if removed == 0 {
+		return 0, nil
+	}
+
+	// Write back the checkpoint and the remaining entries.
 	tmpPath := path + ".tmp"
 	tmpFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
 	if err != nil {
-		a.file, _ = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 		return 0, fmt.Errorf("create temp file: %w", err)
 	}
 
+	if cp != nil {
+		tmpFile.Write(cp)
+		tmpFile.Write([]byte{'\n'})
+	}
 	for _, line := range kept {
 		tmpFile.Write(line)
 		tmpFile.Write([]byte{'\n'})
 	}
 	tmpFile.Close()
 
+	// Close current file handle.
+	if err := a.file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("close for retention: %w", err)
+	}
+
 	if err := os.Rename(tmpPath, path); err != nil {
 		os.Remove(tmpPath)
 		a.file, _ = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
@@ -0,0 +1,451 @@
+package security
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+// Audit log integrity
+//
+// Every record written by FileAuditLogger carries a sequence number, the
+// SHA-256 of the previous record's line ("prev") and, when a key is set, an
+// HMAC-SHA256 of the record itself. The HMAC is the last field of the line:
+//
+//	{...event fields...,"seq":7,"prev":"<hex>","hmac":"<hex>"}
+//
+// and is computed over the line with that field removed, so verification
+// does not depend on re-encoding. Retention drops a prefix of the log and
+// writes a signed checkpoint record in its place, naming the sequence number
+// and hash of the last dropped record, so the remaining chain still verifies.
+// Anchors copy the chain head to a separate file so that truncating the tail
+// of the log can be detected too. Legacy records written before chaining are
+// folded into the base of the chain, so the first chained record commits to
+// them and none can be added or edited later.
+
+// auditRecord is the on-disk form of an audit event.
+type auditRecord struct {
+	domain.AuditEvent
+	Seq  uint64 `json:"seq,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// auditAnchor is a line of the anchor file.
+type auditAnchor struct {
+	Timestamp time.Time `json:"timestamp"`
+	Seq       uint64    `json:"seq"`
+	Hash      string    `json:"hash"`
+}
+
+// Detail keys of checkpoint records.
+const (
+	checkpointSeq     = "seq"
+	checkpointHash    = "hash"
+	checkpointRemoved = "removed"
+)
+
+const hmacSuffixPrefix = `,"hmac":"`
+
+// chainHead identifies the last record of a chain. The zero value is the
+// head of an empty chain.
+type chainHead struct {
+	seq  uint64
+	hash string
+}
+
+// lineHash returns the chain hash of a written line.
+func lineHash(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+// signLine appends the HMAC of a JSON object line as its last field. Without
+// a key the line is returned unchanged.
+func signLine(body, key []byte) []byte {
+	if len(key) == 0 || len(body) < 2 || body[len(body)-1] != '}' {
+		return body
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	sig := hex.EncodeToString(mac.Sum(nil))
+
+	line := make([]byte, 0, len(body)+len(hmacSuffixPrefix)+len(sig)+2)
+	line = append(line, body[:len(body)-1]...)
+	line = append(line, hmacSuffixPrefix...)
+	line = append(line, sig...)
+	line = append(line, '"', '}')
+	return line
+}
+
+// splitSignature separates a signed line into the signed body and the HMAC.
+// ok is false if the line carries no HMAC.
+func splitSignature(line []byte) (body []byte, sig string, ok bool) {
+	const sigLen = sha256.Size * 2
+	n := len(hmacSuffixPrefix) + sigLen + 2
+	if len(line) < n+1 || !bytes.HasSuffix(line, []byte(`"}`)) {
+		return line, "", false
+	}
+	tail := line[len(line)-n:]
+	if !bytes.HasPrefix(tail, []byte(hmacSuffixPrefix)) {
+		return line, "", false
+	}
+	sig = string(tail[len(hmacSuffixPrefix) : len(hmacSuffixPrefix)+sigLen])
+	body = append(append([]byte{}, line[:len(line)-n]...), '}')
+	return body, sig, true
+}
+
+func validSignature(body []byte, sig string, key []byte) bool {
+	want, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), want)
+}
+
+// parseRecord decodes a log line.
+func parseRecord(line []byte) (auditRecord, error) {
+	var rec auditRecord
+	err := json.Unmarshal(line, &rec)
+	return rec, err
+}
+
+// isCheckpoint reports whether rec is a retention checkpoint.
+func (r auditRecord) isCheckpoint() bool {
+	return r.Type == domain.AuditCheckpoint
+}
+
+// checkpointBase returns the chain head a checkpoint stands in for.
+func (r auditRecord) checkpointBase() (chainHead, error) {
+	seq, err := strconv.ParseUint(r.Detail[checkpointSeq], 10, 64)
+	if err != nil {
+		return chainHead{}, fmt.Errorf("checkpoint seq: %w", err)
+	}
+	return chainHead{seq: seq, hash: r.Detail[checkpointHash]}, nil
+}
+
+// checkpointLine builds a signed checkpoint for the given base.
+func checkpointLine(base chainHead, removed int, key []byte) ([]byte, error) {
+	body, err := json.Marshal(auditRecord{AuditEvent: domain.AuditEvent{
+		Timestamp: time.Now().UTC(),
+		Type:      domain.AuditCheckpoint,
+		Actor:     "audit",
+		Action:    "retention",
+		Detail: map[string]string{
+			checkpointSeq:     strconv.FormatUint(base.seq, 10),
+			checkpointHash:    base.hash,
+			checkpointRemoved: strconv.Itoa(removed),
+		},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return signLine(body, key), nil
+}
+
+// advance returns the chain head after line. Legacy records before the first
+// chained record are hashed into the head; unreadable lines and anything else
+// outside the chain leave it unchanged. A checkpoint resets it to the
+// checkpoint's base.
+func (h chainHead) advance(line []byte) chainHead {
+	rec, err := parseRecord(line)
+	if err != nil {
+		return h
+	}
+	if rec.isCheckpoint() {
+		if base, err := rec.checkpointBase(); err == nil {
+			return base
+		}
+		return h
+	}
+	if rec.Seq == 0 {
+		if h.seq == 0 {
+			return chainHead{hash: lineHash(append([]byte(h.hash), line...))}
+		}
+		return h
+	}
+	return chainHead{seq: rec.Seq, hash: lineHash(line)}
+}
+
+// readChainHead returns the head of the chain stored at path.
+func readChainHead(path string) (chainHead, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return chainHead{}, nil
+	}
+	if err != nil {
+		return chainHead{}, err
+	}
+	defer f.Close()
+
+	var head chainHead
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Bytes(); len(line) > 0 {
+			head = head.advance(line)
+		}
+	}
+	return head, scanner.Err()
+}
+
+// AuditChainBreak describes the first integrity violation found.
+type AuditChainBreak struct {
+	Line   int    // 1-based line in the audit log; 0 for anchor problems
+	Seq    uint64 // sequence number of the offending record, if known
+	Reason string
+}
+
+func (b *AuditChainBreak) Error() string {
+	if b.Line == 0 {
+		return b.Reason
+	}
+	return fmt.Sprintf("line %d (seq %d): %s", b.Line, b.Seq, b.Reason)
+}
+
+// AuditVerification is the result of VerifyAuditLog.
+type AuditVerification struct {
+	Records    int    // chained records verified
+	Unchained  int    // legacy records written before chaining was enabled
+	Checkpoint bool   // the log starts at a retention checkpoint
+	FirstSeq   uint64 // sequence number of the first record still present
+	HeadSeq    uint64
+	HeadHash   string
+	Signed     bool // HMACs were checked
+	Anchors    int  // anchors checked
+	Break      *AuditChainBreak
+}
+
+// VerifyAuditLog checks the hash chain and HMACs of the audit log at path and
+// stops at the first broken link. With a nil key only the chain is checked.
+// If anchorPath is set, every anchor must match the log, and the log must not
+// end before the newest anchor.
+func VerifyAuditLog(path, anchorPath string, key []byte) (*AuditVerification, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	defer f.Close()
+
+	v := &AuditVerification{Signed: len(key) > 0}
+	fail := func(line int, seq uint64, format string, args ...any) (*AuditVerification, error) {
+		v.Break = &AuditChainBreak{Line: line, Seq: seq, Reason: fmt.Sprintf(format, args...)}
+		return v, nil
+	}
+
+	var head chainHead
+	hashes := make(map[uint64]string)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		rec, err := parseRecord(line)
+		if err != nil {
+			return fail(n, 0, "malformed record: %v", err)
+		}
+
+		body, sig, signed := splitSignature(line)
+		if rec.Seq == 0 && !rec.isCheckpoint() {
+			// Legacy records may only precede the chain, which commits to
+			// them through the first record's prev hash.
+			if signed || v.Records > 0 {
+				return fail(n, 0, "record without sequence number inside the chain")
+			}
+			head = head.advance(line)
+			v.Unchained++
+			continue
+		}
+		if v.Signed {
+			if !signed {
+				return fail(n, rec.Seq, "missing hmac")
+			}
+			if !validSignature(body, sig, key) {
+				return fail(n, rec.Seq, "hmac mismatch")
+			}
+		}
+
+		if rec.isCheckpoint() {
+			if v.Records > 0 || v.Unchained > 0 || v.Checkpoint {
+				return fail(n, 0, "checkpoint inside the chain")
+			}
+			base, err := rec.checkpointBase()
+			if err != nil {
+				return fail(n, 0, "%v", err)
+			}
+			head = base
+			v.Checkpoint = true
+			continue
+		}
+
+		if rec.Seq != head.seq+1 {
+			if v.Records == 0 && !v.Checkpoint {
+				return fail(n, rec.Seq, "chain starts at seq %d without a checkpoint (records removed)", rec.Seq)
+			}
+			return fail(n, rec.Seq, "expected seq %d (records removed or reordered)", head.seq+1)
+		}
+		if rec.Prev != head.hash {
+			if v.Records == 0 && !v.Checkpoint {
+				return fail(n, rec.Seq, "prev hash does not match the legacy records before the chain (records added or changed)")
+			}
+			return fail(n, rec.Seq, "prev hash does not match the preceding record")
+		}
+		if v.Records == 0 {
+			v.FirstSeq = rec.Seq
+		}
+		head = chainHead{seq: rec.Seq, hash: lineHash(line)}
+		hashes[head.seq] = head.hash
+		v.Records++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan audit log: %w", err)
+	}
+	v.HeadSeq, v.HeadHash = head.seq, head.hash
+
+	if anchorPath != "" {
+		if err := verifyAnchors(v, anchorPath, key, hashes); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// verifyAnchors checks anchors against the verified chain.
+func verifyAnchors(v *AuditVerification, anchorPath string, key []byte, hashes map[uint64]string) error {
+	f, err := os.Open(anchorPath)
+	if err != nil {
+		return fmt.Errorf("open anchor file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		brk := func(format string, args ...any) {
+			v.Break = &AuditChainBreak{Reason: fmt.Sprintf("anchor line %d: ", n) + fmt.Sprintf(format, args...)}
+		}
+		body, sig, signed := splitSignature(line)
+		if v.Signed && (!signed || !validSignature(body, sig, key)) {
+			brk("hmac mismatch")
+			return nil
+		}
+		var a auditAnchor
+		if err := json.Unmarshal(line, &a); err != nil {
+			brk("malformed anchor: %v", err)
+			return nil
+		}
+		v.Anchors++
+		switch {
+		case a.Seq > v.HeadSeq:
+			brk("anchored seq %d is beyond the log head %d (log truncated)", a.Seq, v.HeadSeq)
+			return nil
+		case a.Seq < v.FirstSeq || v.Records == 0:
+			// Removed by retention; the checkpoint covers it.
+		case hashes[a.Seq] != a.Hash:
+			brk("hash of seq %d does not match the log", a.Seq)
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// LoadAuditKey reads the audit HMAC key from a key file holding hex-encoded
+// bytes. With create set, a missing file is created with a random 32-byte
+// key and 0600 permissions, in a 0700 directory if that is missing too.
+func LoadAuditKey(path string, create bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) == 0 {
+			return nil, fmt.Errorf("audit key %s: invalid hex key", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) || !create {
+		return nil, fmt.Errorf("read audit key: %w", err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generate audit key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("create audit key dir: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("write audit key: %w", err)
+	}
+	return key, nil
+}
+
+// Anchor appends the current chain head to the anchor file at path, unless it
+// was already anchored.
+func (a *FileAuditLogger) Anchor(path string) error {
+	a.anchorMu.Lock()
+	defer a.anchorMu.Unlock()
+
+	a.mu.Lock()
+	head := a.head
+	key := a.key
+	a.mu.Unlock()
+
+	if head.seq == 0 || head == a.anchored {
+		return nil
+	}
+	body, err := json.Marshal(auditAnchor{Timestamp: time.Now().UTC(), Seq: head.seq, Hash: head.hash})
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open anchor file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(signLine(body, key), '\n')); err != nil {
+		return fmt.Errorf("write anchor: %w", err)
+	}
+	a.anchored = head
+	return nil
+}
+
+// RunAnchor anchors the chain head to path every interval until ctx is done,
+// then anchors once more.
+func (a *FileAuditLogger) RunAnchor(ctx context.Context, path string, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := a.Anchor(path); err != nil {
+				logger.Warn("audit anchor failed", "error", err)
+			}
+			return
+		case <-ticker.C:
+			if err := a.Anchor(path); err != nil {
+				logger.Warn("audit anchor failed", "error", err)
+			}
+		}
+	}
+}
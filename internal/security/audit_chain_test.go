@@ -0,0 +1,286 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+var testAuditKey = []byte("0123456789abcdef0123456789abcdef")
+
+// writeChainedLog logs n events to a new signed log and returns its path.
+func writeChainedLog(t *testing.T, n int) (string, *FileAuditLogger) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	logger, err := NewFileAuditLogger(path, WithAuditKey(testAuditKey))
+	if err != nil {
+		t.Fatalf("NewFileAuditLogger: %v", err)
+	}
+	t.Cleanup(func() { logger.Close() })
+	for i := 0; i < n; i++ {
+		if err := logger.Log(context.Background(), domain.AuditEvent{
+			Type:   domain.AuditToolExec,
+			Detail: map[string]string{"index": fmt.Sprint(i)},
+		}); err != nil {
+			t.Fatalf("Log: %v", err)
+		}
+	}
+	return path, logger
+}
+
+func readLines(t *testing.T, path string) [][]byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	return bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+}
+
+func writeLines(t *testing.T, path string, lines [][]byte) {
+	t.Helper()
+	if err := os.WriteFile(path, append(bytes.Join(lines, []byte("\n")), '\n'), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+func mustVerify(t *testing.T, path, anchorPath string, key []byte) *AuditVerification {
+	t.Helper()
+	v, err := VerifyAuditLog(path, anchorPath, key)
+	if err != nil {
+		t.Fatalf("VerifyAuditLog: %v", err)
+	}
+	return v
+}
+
+func TestAuditChainVerifies(t *testing.T) {
+	path, logger := writeChainedLog(t, 3)
+	logger.Close()
+
+	v := mustVerify(t, path, "", testAuditKey)
+	if v.Break != nil {
+		t.Fatalf("unexpected break: %v", v.Break)
+	}
+	if v.Records != 3 || v.HeadSeq != 3 || !v.Signed || v.HeadHash == "" {
+		t.Errorf("unexpected result: %+v", v)
+	}
+
+	// Reopening continues the chain.
+	logger, err := NewFileAuditLogger(path, WithAuditKey(testAuditKey))
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	logger.Log(context.Background(), domain.AuditEvent{Type: domain.AuditLLMCall})
+	logger.Close()
+	if v := mustVerify(t, path, "", testAuditKey); v.Break != nil || v.HeadSeq != 4 {
+		t.Errorf("after reopen: %+v break=%v", v, v.Break)
+	}
+
+	if v := mustVerify(t, path, "", []byte("wrong key")); v.Break == nil || v.Break.Line != 1 || !strings.Contains(v.Break.Reason, "hmac") {
+		t.Errorf("wrong key: break=%v", v.Break)
+	}
+}
+
+func TestAuditChainDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func([][]byte) [][]byte
+		line   int
+		reason string
+	}{
+		{"edited field", func(l [][]byte) [][]byte {
+			l[1] = bytes.Replace(l[1], []byte(`"index":"1"`), []byte(`"index":"9"`), 1)
+			return l
+		}, 2, "hmac mismatch"},
+		{"removed record", func(l [][]byte) [][]byte {
+			return append(l[:1:1], l[2:]...)
+		}, 2, "expected seq 2"},
+		{"swapped records", func(l [][]byte) [][]byte {
+			l[1], l[2] = l[2], l[1]
+			return l
+		}, 2, "expected seq 2"},
+		{"truncated head", func(l [][]byte) [][]byte {
+			return l[1:]
+		}, 1, "without a checkpoint"},
+		{"stripped signature", func(l [][]byte) [][]byte {
+			body, _, _ := splitSignature(l[3])
+			l[3] = body
+			return l
+		}, 4, "missing hmac"},
+		{"inserted legacy record", func(l [][]byte) [][]byte {
+			return append(l[:2:2], append([][]byte{[]byte(`{"type":"llm_call","detail":null}`)}, l[2:]...)...)
+		}, 3, "without sequence number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, logger := writeChainedLog(t, 4)
+			logger.Close()
+			writeLines(t, path, tt.tamper(readLines(t, path)))
+
+			v := mustVerify(t, path, "", testAuditKey)
+			if v.Break == nil {
+				t.Fatal("expected a break")
+			}
+			if v.Break.Line != tt.line || !strings.Contains(v.Break.Reason, tt.reason) {
+				t.Errorf("break = %v, want line %d %q", v.Break, tt.line, tt.reason)
+			}
+		})
+	}
+}
+
+func TestAuditChainLegacyPrefix(t *testing.T) {
+	legacy := `{"timestamp":"2024-01-01T00:00:00Z","type":"llm_call","detail":null}`
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	os.WriteFile(path, []byte(legacy+"\n"), 0600)
+
+	logger, err := NewFileAuditLogger(path, WithAuditKey(testAuditKey))
+	if err != nil {
+		t.Fatalf("NewFileAuditLogger: %v", err)
+	}
+	logger.Log(context.Background(), domain.AuditEvent{Type: domain.AuditToolExec})
+	logger.Close()
+
+	v := mustVerify(t, path, "", testAuditKey)
+	if v.Break != nil || v.Unchained != 1 || v.Records != 1 {
+		t.Errorf("unexpected result: %+v break=%v", v, v.Break)
+	}
+
+	// The chain commits to the legacy prefix: it can be neither extended
+	// nor edited.
+	original := readLines(t, path)
+	forged := []byte(`{"timestamp":"2023-01-01T00:00:00Z","type":"llm_call","detail":null}`)
+	for name, lines := range map[string][][]byte{
+		"prepended": {forged, original[0], original[1]},
+		"appended":  {original[0], forged, original[1]},
+		"edited":    {bytes.Replace(original[0], []byte("2024"), []byte("2023"), 1), original[1]},
+		"removed":   {original[1]},
+	} {
+		writeLines(t, path, lines)
+		v := mustVerify(t, path, "", testAuditKey)
+		if v.Break == nil || !strings.Contains(v.Break.Reason, "legacy records") {
+			t.Errorf("%s legacy record: break=%v", name, v.Break)
+		}
+	}
+}
+
+func TestAuditRetentionDropsLegacyRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	os.WriteFile(path, []byte(`{"timestamp":"2024-01-01T00:00:00Z","type":"llm_call","detail":null}`+"\n"+
+		`{"timestamp":"2099-01-01T00:00:00Z","type":"llm_call","detail":null}`+"\n"), 0600)
+	logger, err := NewFileAuditLogger(path, WithAuditKey(testAuditKey))
+	if err != nil {
+		t.Fatalf("NewFileAuditLogger: %v", err)
+	}
+	defer logger.Close()
+	ctx := context.Background()
+	logger.Log(ctx, domain.AuditEvent{Type: domain.AuditToolExec})
+
+	logger.SetRetention(RetentionPolicy{MaxAge: time.Hour})
+	if removed, err := logger.EnforceRetention(ctx); err != nil || removed != 1 {
+		t.Fatalf("EnforceRetention: removed=%d err=%v", removed, err)
+	}
+	v := mustVerify(t, path, "", testAuditKey)
+	if v.Break != nil || !v.Checkpoint || v.Unchained != 1 || v.Records != 1 {
+		t.Errorf("unexpected result: %+v break=%v", v, v.Break)
+	}
+}
+
+func TestAuditRetentionCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	logger, err := NewFileAuditLogger(path, WithAuditKey(testAuditKey))
+	if err != nil {
+		t.Fatalf("NewFileAuditLogger: %v", err)
+	}
+	defer logger.Close()
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		logger.Log(ctx, domain.AuditEvent{Timestamp: time.Now().Add(-2 * time.Hour), Type: domain.AuditLLMCall})
+	}
+	logger.Log(ctx, domain.AuditEvent{Type: domain.AuditToolExec})
+
+	logger.SetRetention(RetentionPolicy{MaxAge: time.Hour})
+	if removed, err := logger.EnforceRetention(ctx); err != nil || removed != 3 {
+		t.Fatalf("EnforceRetention: removed=%d err=%v", removed, err)
+	}
+	logger.Log(ctx, domain.AuditEvent{Type: domain.AuditToolExec})
+
+	v := mustVerify(t, path, "", testAuditKey)
+	if v.Break != nil || !v.Checkpoint || v.FirstSeq != 4 || v.HeadSeq != 5 || v.Records != 2 {
+		t.Fatalf("unexpected result: %+v break=%v", v, v.Break)
+	}
+
+	// A second pass replaces the checkpoint.
+	logger.SetRetention(RetentionPolicy{MaxSize: 1})
+	if removed, err := logger.EnforceRetention(ctx); err != nil || removed != 2 {
+		t.Fatalf("second pass: removed=%d err=%v", removed, err)
+	}
+	if lines := readLines(t, path); len(lines) != 1 {
+		t.Fatalf("expected only a checkpoint, got %d lines", len(lines))
+	}
+	logger.Log(ctx, domain.AuditEvent{Type: domain.AuditToolExec})
+	if v := mustVerify(t, path, "", testAuditKey); v.Break != nil || v.FirstSeq != 6 {
+		t.Errorf("after second pass: %+v break=%v", v, v.Break)
+	}
+
+	// A forged checkpoint does not verify.
+	lines := readLines(t, path)
+	lines[0] = bytes.Replace(lines[0], []byte(`"seq":"5"`), []byte(`"seq":"4"`), 1)
+	writeLines(t, path, lines)
+	if v := mustVerify(t, path, "", testAuditKey); v.Break == nil || v.Break.Line != 1 {
+		t.Errorf("forged checkpoint: break=%v", v.Break)
+	}
+}
+
+func TestAuditAnchorDetectsTruncation(t *testing.T) {
+	path, logger := writeChainedLog(t, 3)
+	anchorPath := filepath.Join(t.TempDir(), "audit.anchor")
+	if err := logger.Anchor(anchorPath); err != nil {
+		t.Fatalf("Anchor: %v", err)
+	}
+	// Unchanged head: no new anchor line.
+	logger.Anchor(anchorPath)
+	logger.Log(context.Background(), domain.AuditEvent{Type: domain.AuditLLMCall})
+	logger.Anchor(anchorPath)
+	logger.Close()
+	if n := len(readLines(t, anchorPath)); n != 2 {
+		t.Errorf("anchor lines = %d, want 2", n)
+	}
+
+	v := mustVerify(t, path, anchorPath, testAuditKey)
+	if v.Break != nil || v.Anchors != 2 {
+		t.Fatalf("unexpected result: %+v break=%v", v, v.Break)
+	}
+
+	// Dropping the last record leaves a valid chain, but the anchor notices.
+	lines := readLines(t, path)
+	writeLines(t, path, lines[:len(lines)-1])
+	v = mustVerify(t, path, anchorPath, testAuditKey)
+	if v.Break == nil || !strings.Contains(v.Break.Reason, "truncated") {
+		t.Errorf("truncation: break=%v", v.Break)
+	}
+}
+
+func TestLoadAuditKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.key")
+	if _, err := LoadAuditKey(path, false); err == nil {
+		t.Error("expected error for missing key without create")
+	}
+	key, err := LoadAuditKey(path, true)
+	if err != nil || len(key) != 32 {
+		t.Fatalf("create: len=%d err=%v", len(key), err)
+	}
+	again, err := LoadAuditKey(path, false)
+	if err != nil || !bytes.Equal(key, again) {
+		t.Errorf("reload: err=%v equal=%v", err, bytes.Equal(key, again))
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("key permissions = %o, want 0600", info.Mode().Perm())
+	}
+}
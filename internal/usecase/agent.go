@@ -58,8 +58,30 @@ func NewAgent(deps AgentDeps) *Agent {
 	return &Agent{deps: deps}
 }
 
+// WithTools returns a copy of the agent that may only use the named tools
+// out of its own. A nil list returns the agent itself; an empty, non-nil
+// list leaves it no tools.
+func (a *Agent) WithTools(tools []string) *Agent {
+	if tools == nil {
+		return a
+	}
+	deps := a.deps
+	if len(tools) == 0 {
+		deps.Tools = &scopedToolExecutor{inner: deps.Tools, allowed: map[string]bool{}}
+	} else {
+		deps.Tools = NewScopedToolExecutor(deps.Tools, tools)
+	}
+	return &Agent{deps: deps}
+}
+
 // HandleMessage processes a single user message through the agent loop.
 func (a *Agent) HandleMessage(ctx context.Context, session *Session, userMsg string) (string, error) {
+	return a.handleInner(ctx, session, domain.NewUserMessage(userMsg, nil), nil)
+}
+
+// HandleUserMessage is HandleMessage for a user message that may carry
+// image or file content parts.
+func (a *Agent) HandleUserMessage(ctx context.Context, session *Session, userMsg domain.Message) (string, error) {
 	return a.handleInner(ctx, session, userMsg, nil)
 }
 
@@ -85,11 +107,10 @@ func (a *Agent) executeTool(ctx context.Context, sessionID string, call domain.T
 		}
 	}
 
-	// Tool approval gating
+	// Tool approval gating. The approver publishes its own request/response
+	// events, since only it knows whether a prompt is shown.
 	if a.deps.Approver != nil && a.deps.Approver.NeedsApproval(call) {
-		a.publishEvent(ctx, domain.EventToolApprovalReq, sessionID, nil)
 		approved, approvalErr := a.deps.Approver.RequestApproval(ctx, call)
-		a.publishEvent(ctx, domain.EventToolApprovalResp, sessionID, nil)
 		if approvalErr != nil || !approved {
 			msg := "tool call denied by approval policy"
 			if approvalErr != nil {
@@ -109,7 +130,9 @@ func (a *Agent) executeTool(ctx context.Context, sessionID string, call domain.T
 	}
 
 	a.publishEvent(ctx, domain.EventToolCallStarted, sessionID, map[string]string{"tool": call.Name})
+	start := time.Now()
 	result, err := tool.Execute(ctx, call.Arguments)
+	tracer.RecordToolCall(ctx, call.Name, time.Since(start), err)
 	a.publishEvent(ctx, domain.EventToolCallCompleted, sessionID, map[string]string{
 		"tool":    call.Name,
 		"success": fmt.Sprintf("%v", err == nil),
@@ -178,11 +201,20 @@ func (a *Agent) publishEvent(ctx context.Context, eventType domain.EventType, se
 // does not implement StreamingLLMProvider, it falls back to HandleMessage
 // and emits a single EventStreamCompleted with the full response.
 func (a *Agent) HandleMessageStream(ctx context.Context, session *Session, userMsg string) (string, error) {
+	return a.HandleUserMessageStream(ctx, session, domain.NewUserMessage(userMsg, nil))
+}
+
+// HandleUserMessageStream is HandleMessageStream for a user message that may
+// carry image or file content parts.
+func (a *Agent) HandleUserMessageStream(ctx context.Context, session *Session, userMsg domain.Message) (string, error) {
 	sp, canStream := a.deps.LLM.(domain.StreamingLLMProvider)
 	if !canStream {
 		// Fallback: run synchronous path, emit completed event with full response.
-		result, err := a.HandleMessage(ctx, session, userMsg)
+		result, err := a.HandleUserMessage(ctx, session, userMsg)
 		if err == nil {
+			if sink := domain.StreamSinkFromContext(ctx); sink != nil {
+				sink(domain.StreamDeltaPayload{Content: result, Done: true})
+			}
 			a.publishEvent(ctx, domain.EventStreamCompleted, session.ID, domain.StreamCompletedPayload{
 				Content: result,
 			})
@@ -196,7 +228,7 @@ func (a *Agent) HandleMessageStream(ctx context.Context, session *Session, userM
 // handleInner is the shared agent loop for both sync and streaming modes.
 // When sp is non-nil, it uses streaming via ChatStream; when sp is nil, it
 // uses synchronous Chat.
-func (a *Agent) handleInner(ctx context.Context, session *Session, userMsg string, sp domain.StreamingLLMProvider) (string, error) {
+func (a *Agent) handleInner(ctx context.Context, session *Session, userMsg domain.Message, sp domain.StreamingLLMProvider) (string, error) {
 	streaming := sp != nil
 
 	spanName := "agent.handle_message"
@@ -219,13 +251,23 @@ func (a *Agent) handleInner(ctx context.Context, session *Session, userMsg strin
 	}
 
 	ctx = domain.ContextWithSessionID(ctx, session.ID)
+	ctx = domain.ContextWithSessionKey(ctx, session.ExternalKey)
+	if a.deps.Identity.ID != "" {
+		ctx = domain.ContextWithAgentID(ctx, a.deps.Identity.ID)
+	}
+
+	// Reject images up front so they never enter the session history of a
+	// provider that cannot read them.
+	if userMsg.HasImages() && !domain.SupportsVision(a.deps.LLM) {
+		return "", domain.NewDomainError(opName, domain.ErrVisionUnsupported, a.deps.LLM.Name())
+	}
 
 	// Add user message to session.
-	session.AddMessage(domain.Message{
-		Role:      domain.RoleUser,
-		Content:   userMsg,
-		Timestamp: time.Now(),
-	})
+	userMsg.Role = domain.RoleUser
+	if userMsg.Timestamp.IsZero() {
+		userMsg.Timestamp = time.Now()
+	}
+	session.AddMessage(userMsg)
 
 	// Context guard check point 1: after adding user message.
 	if a.deps.ContextGuard != nil {
@@ -239,7 +281,7 @@ func (a *Agent) handleInner(ctx context.Context, session *Session, userMsg strin
 	if a.deps.Memory != nil && a.deps.Memory.IsAvailable() {
 		memCtx, memSpan := tracer.StartSpan(ctx, "agent.query_memory")
 		var err error
-		memories, err = a.deps.Memory.Query(memCtx, userMsg, 5)
+		memories, err = a.deps.Memory.Query(memCtx, userMsg.Content, 5)
 		memSpan.End()
 		if err != nil {
 			a.deps.Logger.Warn("memory query failed", "error", err)
@@ -397,14 +439,19 @@ func (a *Agent) callLLMWithRetry(
 				callErr = err
 			} else {
 				acc := newStreamAccumulator()
+				sink := domain.StreamSinkFromContext(ctx)
 				for delta := range deltaCh {
 					acc.addDelta(delta)
-					a.publishEvent(ctx, domain.EventStreamDelta, session.ID, domain.StreamDeltaPayload{
+					payload := domain.StreamDeltaPayload{
 						Content:   delta.Content,
 						ToolCalls: delta.ToolCalls,
 						Done:      delta.Done,
 						Iteration: iteration,
-					})
+					}
+					if sink != nil {
+						sink(payload)
+					}
+					a.publishEvent(ctx, domain.EventStreamDelta, session.ID, payload)
 				}
 				msg, usage = acc.build()
 			}
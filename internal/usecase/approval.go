@@ -2,7 +2,13 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
 
 	"alfred-ai/internal/domain"
 )
@@ -15,8 +21,8 @@ import (
 //   - Unknown/unlisted tools: DENIED by default (fail-safe)
 //
 // This implements defense-in-depth: tools must be explicitly allowed to execute.
-// Interactive approval (prompting user) is not yet implemented, so unlisted tools
-// are automatically denied for security reasons.
+// ConfigApprover never prompts, so unlisted tools are automatically denied for
+// security reasons. Use InteractiveApprover to ask the user instead.
 //
 // Example usage:
 //
@@ -65,11 +71,6 @@ func (c *ConfigApprover) NeedsApproval(call domain.ToolCall) bool {
 // Unknown tools are denied by default to prevent unauthorized execution.
 // This is a fail-safe design: better to deny a legitimate tool than to
 // accidentally allow a dangerous operation.
-//
-// Future Enhancement:
-// Interactive approval (prompting user via CLI/UI) is not yet implemented.
-// When implemented, step 3 will become: "prompt user for approval".
-// Until then, tools must be explicitly added to alwaysApprove list.
 func (c *ConfigApprover) RequestApproval(_ context.Context, call domain.ToolCall) (bool, error) {
 	if c.alwaysDeny[call.Name] {
 		return false, domain.ErrToolApprovalDenied
@@ -79,10 +80,258 @@ func (c *ConfigApprover) RequestApproval(_ context.Context, call domain.ToolCall
 		return true, nil
 	}
 
-	// Default: DENY (interactive approval not implemented yet)
+	// Default: DENY (no one to ask)
 	return false, domain.NewDomainError(
 		"ConfigApprover.RequestApproval",
 		domain.ErrToolApprovalDenied,
-		fmt.Sprintf("tool %q requires approval but interactive mode not implemented", call.Name),
+		fmt.Sprintf("tool %q requires approval but interactive mode is disabled", call.Name),
 	)
 }
+
+// DefaultApprovalTimeout is how long InteractiveApprover waits for a decision
+// before denying the call.
+const DefaultApprovalTimeout = 2 * time.Minute
+
+// InteractiveApprover asks the user to approve tool calls that are not on the
+// allow/deny lists.
+//
+// Each request is published as EventToolApprovalReq with an ApprovalRequest
+// payload. The gateway pushes it to RPC clients, and channels that subscribe
+// render a native prompt (buttons, inline keyboard, TUI dialog). Answers come
+// back as EventToolApprovalResp events carrying an ApprovalDecision; the first
+// answer for a pending tool call wins. Unanswered requests are denied after
+// the timeout.
+//
+// A decision with scope "session" is remembered, so later calls to the same
+// tool in the same session are approved without prompting. A grant made for
+// a policy rule covers only calls that the same rule prompts for again.
+type InteractiveApprover struct {
+	lists   *ConfigApprover
+	bus     domain.EventBus
+	audit   domain.AuditLogger // optional
+	timeout time.Duration
+	logger  *slog.Logger
+	unsub   func()
+
+	mu      sync.Mutex
+	pending map[string]chan domain.ApprovalDecision // tool call ID -> waiter
+	granted map[string]map[grantKey]bool            // session ID -> grant -> approved
+}
+
+// grantKey identifies a session grant: the tool, and the policy rule that
+// prompted for it ("" for the allow/deny lists).
+type grantKey struct {
+	tool, rule string
+}
+
+// NewInteractiveApprover creates an InteractiveApprover. The allow/deny lists
+// of lists are applied before anyone is prompted. A non-positive timeout
+// selects DefaultApprovalTimeout. Call Close to stop listening for decisions.
+func NewInteractiveApprover(lists *ConfigApprover, bus domain.EventBus, timeout time.Duration, logger *slog.Logger) *InteractiveApprover {
+	if timeout <= 0 {
+		timeout = DefaultApprovalTimeout
+	}
+	a := &InteractiveApprover{
+		lists:   lists,
+		bus:     bus,
+		timeout: timeout,
+		logger:  logger,
+		pending: make(map[string]chan domain.ApprovalDecision),
+		granted: make(map[string]map[grantKey]bool),
+	}
+	a.unsub = bus.Subscribe(domain.EventToolApprovalResp, a.onDecision)
+	return a
+}
+
+// SetAuditLogger enables auditing of approval decisions.
+func (a *InteractiveApprover) SetAuditLogger(audit domain.AuditLogger) { a.audit = audit }
+
+// Close stops listening for decisions. Pending requests still time out.
+func (a *InteractiveApprover) Close() {
+	if a.unsub != nil {
+		a.unsub()
+	}
+}
+
+// NeedsApproval applies the allow list; everything else is gated.
+func (a *InteractiveApprover) NeedsApproval(call domain.ToolCall) bool {
+	return a.lists.NeedsApproval(call)
+}
+
+// RequestApproval blocks until the user decides, the timeout elapses, or ctx
+// is cancelled. The session is taken from the context.
+func (a *InteractiveApprover) RequestApproval(ctx context.Context, call domain.ToolCall) (bool, error) {
+	if a.lists.alwaysDeny[call.Name] {
+		return false, domain.ErrToolApprovalDenied
+	}
+	if a.lists.alwaysApprove[call.Name] {
+		return true, nil
+	}
+	return a.prompt(ctx, call, "")
+}
+
+// prompt asks the user about call, skipping the allow/deny lists. rule names
+// the policy rule that requested the prompt, if any.
+func (a *InteractiveApprover) prompt(ctx context.Context, call domain.ToolCall, rule string) (bool, error) {
+	sessionID := approvalSession(ctx)
+	key := grantKey{tool: call.Name, rule: rule}
+	if a.isGranted(sessionID, key) {
+		a.auditDecision(ctx, sessionID, call, rule, domain.ApprovalDecision{
+			ToolCallID: call.ID, Approved: true, Scope: domain.ApprovalScopeSession, Reason: "remembered",
+		})
+		return true, nil
+	}
+
+	req, ch := a.register(sessionID, call, rule)
+	defer a.unregister(req.ToolCallID)
+
+	publishEvent(a.bus, ctx, domain.EventToolApprovalReq, sessionID, req)
+
+	timer := time.NewTimer(a.timeout)
+	defer timer.Stop()
+
+	var d domain.ApprovalDecision
+	select {
+	case d = <-ch:
+	case <-timer.C:
+		d = domain.ApprovalDecision{ToolCallID: req.ToolCallID, Reason: "timeout"}
+		// Let channels retire their prompts.
+		publishEvent(a.bus, ctx, domain.EventToolApprovalResp, sessionID, d)
+	case <-ctx.Done():
+		d = domain.ApprovalDecision{ToolCallID: req.ToolCallID, Reason: "cancelled"}
+		publishEvent(a.bus, context.WithoutCancel(ctx), domain.EventToolApprovalResp, sessionID, d)
+		a.auditDecision(context.WithoutCancel(ctx), sessionID, call, rule, d)
+		return false, ctx.Err()
+	}
+	a.auditDecision(ctx, sessionID, call, rule, d)
+
+	if d.Reason == "timeout" {
+		return false, domain.NewDomainError("InteractiveApprover.RequestApproval", domain.ErrToolApprovalTimeout,
+			fmt.Sprintf("no decision for tool %q within %s", call.Name, a.timeout))
+	}
+	if !d.Approved {
+		return false, domain.NewDomainError("InteractiveApprover.RequestApproval", domain.ErrToolApprovalDenied,
+			fmt.Sprintf("tool %q denied by user", call.Name))
+	}
+	if d.Scope == domain.ApprovalScopeSession && sessionID != "" {
+		a.grant(sessionID, key)
+	}
+	return true, nil
+}
+
+// register records a pending request. Calls without a usable ID (some
+// providers omit it, and IDs must be unique while pending) get a fresh one.
+func (a *InteractiveApprover) register(sessionID string, call domain.ToolCall, rule string) (domain.ApprovalRequest, chan domain.ApprovalDecision) {
+	channel, chatID := domain.SplitSessionKey(sessionID)
+	req := domain.ApprovalRequest{
+		ToolCallID: call.ID,
+		Tool:       call.Name,
+		Arguments:  call.Arguments,
+		SessionID:  sessionID,
+		Channel:    channel,
+		ChatID:     chatID,
+		ExpiresAt:  time.Now().Add(a.timeout),
+		Rule:       rule,
+	}
+	ch := make(chan domain.ApprovalDecision, 1)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, dup := a.pending[req.ToolCallID]; dup || req.ToolCallID == "" {
+		req.ToolCallID = ulid.Make().String()
+	}
+	a.pending[req.ToolCallID] = ch
+	return req, ch
+}
+
+func (a *InteractiveApprover) unregister(toolCallID string) {
+	a.mu.Lock()
+	delete(a.pending, toolCallID)
+	a.mu.Unlock()
+}
+
+// onDecision delivers a decision event to the matching pending request.
+// Decisions for unknown or already answered calls are ignored.
+func (a *InteractiveApprover) onDecision(_ context.Context, event domain.Event) {
+	var d domain.ApprovalDecision
+	if len(event.Payload) == 0 || json.Unmarshal(event.Payload, &d) != nil || d.ToolCallID == "" {
+		return
+	}
+	a.mu.Lock()
+	ch, ok := a.pending[d.ToolCallID]
+	if ok {
+		delete(a.pending, d.ToolCallID)
+	}
+	a.mu.Unlock()
+	if ok {
+		ch <- d
+	}
+}
+
+func (a *InteractiveApprover) isGranted(sessionID string, key grantKey) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.granted[sessionID][key]
+}
+
+func (a *InteractiveApprover) grant(sessionID string, key grantKey) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.granted[sessionID] == nil {
+		a.granted[sessionID] = make(map[grantKey]bool)
+	}
+	a.granted[sessionID][key] = true
+}
+
+// ForgetSession drops "approve for this session" grants, e.g. when the
+// session is cleared. sessionID is the session's router key; wire it to
+// SessionManager.SetOnRemove so grants go away with their sessions.
+func (a *InteractiveApprover) ForgetSession(sessionID string) {
+	a.mu.Lock()
+	delete(a.granted, sessionID)
+	a.mu.Unlock()
+}
+
+// approvalSession returns the session approvals are tracked under: its router
+// key ("channel:chatID"), which also names the chat to prompt in, or the
+// session ID when the key is not known.
+func approvalSession(ctx context.Context) string {
+	if key := domain.SessionKeyFromContext(ctx); key != "" {
+		return key
+	}
+	return domain.SessionIDFromContext(ctx)
+}
+
+func (a *InteractiveApprover) auditDecision(ctx context.Context, sessionID string, call domain.ToolCall, rule string, d domain.ApprovalDecision) {
+	outcome := "denied"
+	if d.Approved {
+		outcome = "approved"
+	}
+	a.logger.Info("tool approval decision",
+		"tool", call.Name, "session", sessionID, "outcome", outcome, "decided_by", d.DecidedBy, "reason", d.Reason, "rule", rule)
+	if a.audit == nil {
+		return
+	}
+	scope := d.Scope
+	if scope == "" {
+		scope = domain.ApprovalScopeOnce
+	}
+	detail := map[string]string{
+		"tool":         call.Name,
+		"tool_call_id": d.ToolCallID,
+		"session":      sessionID,
+		"scope":        string(scope),
+		"reason":       d.Reason,
+	}
+	if rule != "" {
+		detail["rule"] = rule
+	}
+	a.audit.Log(ctx, domain.AuditEvent{
+		Type:     domain.AuditToolApproval,
+		Actor:    d.DecidedBy,
+		Resource: call.Name,
+		Action:   "approve",
+		Outcome:  outcome,
+		Detail:   detail,
+	})
+}
@@ -0,0 +1,405 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"alfred-ai/internal/domain"
+)
+
+// PolicyAction is what an approval rule does with a matching tool call.
+type PolicyAction string
+
+const (
+	PolicyAllow  PolicyAction = "allow"
+	PolicyDeny   PolicyAction = "deny"
+	PolicyPrompt PolicyAction = "prompt"
+)
+
+// ArgCondition is a predicate over a tool call's JSON arguments.
+//
+// Path selects values with a dotted path: "action", "options.mode",
+// "files[0].path", or "args[*]" for every element of an array. A leading "$."
+// is accepted. The condition holds when the path selects at least one value
+// and every selected value passes all of the checks that are set. Strings are
+// compared as-is, other scalars by their JSON text.
+type ArgCondition struct {
+	Path    string
+	Equals  string   // exact match
+	OneOf   []string // any of these
+	Matches string   // regular expression; anchor it to match the whole value
+	Under   string   // file path inside this directory, after cleaning ".."
+	Absent  bool     // the path must select nothing; other checks are ignored
+	Not     bool     // negate the result
+}
+
+// ApprovalRule matches tool calls by tool name, caller and arguments. Empty
+// caller lists match everyone; otherwise the caller must be in the list.
+// Roles match when the context carries any of them, so rules with roles never
+// match callers without roles.
+type ApprovalRule struct {
+	Name     string // reported in decisions; defaults to "rules[i]"
+	Tool     string // tool name or glob such as "*" or "github_*"
+	Action   PolicyAction
+	Agents   []string
+	Tenants  []string
+	Channels []string
+	Roles    []domain.AuthRole
+	Args     []ArgCondition
+}
+
+// PolicyDecision is the outcome of evaluating an ApprovalPolicy.
+type PolicyDecision struct {
+	Action PolicyAction
+	Rule   string // name of the rule that fired
+}
+
+// ApprovalPolicy is an ordered list of approval rules. The first matching rule
+// decides.
+type ApprovalPolicy struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	ApprovalRule
+	conds []compiledCondition
+}
+
+type compiledCondition struct {
+	ArgCondition
+	steps []pathStep
+	re    *regexp.Regexp
+}
+
+// pathStep is one element of an argument path: a key, an index, or a wildcard.
+type pathStep struct {
+	key   string
+	index int // -1 for a key step, -2 for a wildcard
+}
+
+const (
+	stepKey      = -1
+	stepWildcard = -2
+)
+
+// NewApprovalPolicy validates and compiles rules.
+func NewApprovalPolicy(rules []ApprovalRule) (*ApprovalPolicy, error) {
+	p := &ApprovalPolicy{rules: make([]compiledRule, 0, len(rules))}
+	for i, r := range rules {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rules[%d]", i)
+		}
+		if r.Tool == "" {
+			return nil, fmt.Errorf("approval rule %q: tool is required", r.Name)
+		}
+		if _, err := path.Match(r.Tool, ""); err != nil {
+			return nil, fmt.Errorf("approval rule %q: tool pattern: %w", r.Name, err)
+		}
+		switch r.Action {
+		case PolicyAllow, PolicyDeny, PolicyPrompt:
+		default:
+			return nil, fmt.Errorf("approval rule %q: invalid action %q (want: allow, deny, prompt)", r.Name, r.Action)
+		}
+
+		cr := compiledRule{ApprovalRule: r}
+		for _, c := range r.Args {
+			cc, err := compileCondition(c)
+			if err != nil {
+				return nil, fmt.Errorf("approval rule %q: %w", r.Name, err)
+			}
+			cr.conds = append(cr.conds, cc)
+		}
+		p.rules = append(p.rules, cr)
+	}
+	return p, nil
+}
+
+func compileCondition(c ArgCondition) (compiledCondition, error) {
+	steps, err := parseArgPath(c.Path)
+	if err != nil {
+		return compiledCondition{}, err
+	}
+	cc := compiledCondition{ArgCondition: c, steps: steps}
+	if c.Matches != "" {
+		if cc.re, err = regexp.Compile(c.Matches); err != nil {
+			return compiledCondition{}, fmt.Errorf("arg %q: %w", c.Path, err)
+		}
+	}
+	if !c.Absent && c.Equals == "" && len(c.OneOf) == 0 && c.Matches == "" && c.Under == "" {
+		return compiledCondition{}, fmt.Errorf("arg %q: no check set (want equals, one_of, matches, under or absent)", c.Path)
+	}
+	return cc, nil
+}
+
+// parseArgPath splits "a.b[0].c[*]" into steps.
+func parseArgPath(p string) ([]pathStep, error) {
+	p = strings.TrimPrefix(strings.TrimPrefix(p, "$"), ".")
+	if p == "" {
+		return nil, fmt.Errorf("arg path is required")
+	}
+	var steps []pathStep
+	for _, seg := range strings.Split(p, ".") {
+		key, rest, indexed := strings.Cut(seg, "[")
+		if key == "" && !indexed {
+			return nil, fmt.Errorf("arg path %q: empty segment", p)
+		}
+		if key != "" {
+			steps = append(steps, pathStep{key: key, index: stepKey})
+		}
+		if indexed {
+			rest = "[" + rest
+		}
+		for rest != "" {
+			end := strings.IndexByte(rest, ']')
+			if rest[0] != '[' || end < 0 {
+				return nil, fmt.Errorf("arg path %q: malformed index", p)
+			}
+			idx := rest[1:end]
+			rest = rest[end+1:]
+			if idx == "*" {
+				steps = append(steps, pathStep{index: stepWildcard})
+				continue
+			}
+			n, err := strconv.Atoi(idx)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("arg path %q: invalid index %q", p, idx)
+			}
+			steps = append(steps, pathStep{index: n})
+		}
+	}
+	return steps, nil
+}
+
+// Covers reports whether any rule could apply to the named tool.
+func (p *ApprovalPolicy) Covers(tool string) bool {
+	for _, r := range p.rules {
+		if ok, _ := path.Match(r.Tool, tool); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate returns the decision of the first rule matching call in ctx. The
+// caller's agent, tenant, channel and roles are read from ctx. ok is false
+// when no rule matches.
+func (p *ApprovalPolicy) Evaluate(ctx context.Context, call domain.ToolCall) (d PolicyDecision, ok bool) {
+	var args any
+	argsParsed := false
+
+	channel, _ := domain.SplitSessionKey(approvalSession(ctx))
+	agentID := domain.AgentIDFromContext(ctx)
+	tenantID := domain.TenantIDFromContext(ctx)
+	roles := domain.RolesFromContext(ctx)
+
+	for _, r := range p.rules {
+		if match, _ := path.Match(r.Tool, call.Name); !match {
+			continue
+		}
+		if !listMatches(r.Agents, agentID) || !listMatches(r.Tenants, tenantID) || !listMatches(r.Channels, channel) {
+			continue
+		}
+		if len(r.Roles) > 0 && !slices.ContainsFunc(roles, func(role domain.AuthRole) bool { return slices.Contains(r.Roles, role) }) {
+			continue
+		}
+		if len(r.conds) > 0 && !argsParsed {
+			args = decodeArgs(call.Arguments)
+			argsParsed = true
+		}
+		if !slices.ContainsFunc(r.conds, func(c compiledCondition) bool { return !c.holds(args) }) {
+			return PolicyDecision{Action: r.Action, Rule: r.Name}, true
+		}
+	}
+	return PolicyDecision{}, false
+}
+
+func listMatches(list []string, v string) bool {
+	return len(list) == 0 || slices.Contains(list, v)
+}
+
+// decodeArgs parses tool call arguments. Unparseable arguments select nothing.
+func decodeArgs(raw json.RawMessage) any {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil
+	}
+	return v
+}
+
+func (c compiledCondition) holds(args any) bool {
+	values := selectArgs(args, c.steps)
+	var ok bool
+	if c.Absent {
+		ok = len(values) == 0
+	} else {
+		ok = len(values) > 0 && !slices.ContainsFunc(values, func(v any) bool { return !c.check(argString(v)) })
+	}
+	return ok != c.Not
+}
+
+func (c compiledCondition) check(s string) bool {
+	if c.Equals != "" && s != c.Equals {
+		return false
+	}
+	if len(c.OneOf) > 0 && !slices.Contains(c.OneOf, s) {
+		return false
+	}
+	if c.re != nil && !c.re.MatchString(s) {
+		return false
+	}
+	if c.Under != "" && !pathUnder(s, c.Under) {
+		return false
+	}
+	return true
+}
+
+// selectArgs returns the values addressed by steps.
+func selectArgs(v any, steps []pathStep) []any {
+	values := []any{v}
+	for _, st := range steps {
+		var next []any
+		for _, cur := range values {
+			switch st.index {
+			case stepKey:
+				if m, ok := cur.(map[string]any); ok {
+					if child, ok := m[st.key]; ok {
+						next = append(next, child)
+					}
+				}
+			case stepWildcard:
+				if arr, ok := cur.([]any); ok {
+					next = append(next, arr...)
+				}
+			default:
+				if arr, ok := cur.([]any); ok && st.index < len(arr) {
+					next = append(next, arr[st.index])
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+func argString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+// pathUnder reports whether p lies inside dir. Both are cleaned first, so
+// "workspace/../etc" is not under "workspace". Symlinks are not resolved.
+func pathUnder(p, dir string) bool {
+	p, dir = filepath.Clean(p), filepath.Clean(dir)
+	if filepath.IsAbs(p) != filepath.IsAbs(dir) {
+		return false
+	}
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// PolicyApprover is a ToolApprover that evaluates an ApprovalPolicy before
+// falling back to another approver (usually the allow/deny lists).
+//
+// Rule actions:
+//   - allow: the call runs without asking
+//   - deny: the call is rejected
+//   - prompt: the user is asked, even for tools on the allow list; without an
+//     InteractiveApprover fallback the call is denied
+//
+// Calls that no rule matches are decided by the fallback. Rule decisions are
+// audited as tool_approval events naming the rule.
+type PolicyApprover struct {
+	policy   *ApprovalPolicy
+	fallback domain.ToolApprover
+	prompter *InteractiveApprover // nil when the fallback does not prompt
+	audit    domain.AuditLogger   // optional
+	logger   *slog.Logger
+}
+
+// NewPolicyApprover creates a PolicyApprover. If fallback is an
+// InteractiveApprover, "prompt" rules use it to ask the user.
+func NewPolicyApprover(policy *ApprovalPolicy, fallback domain.ToolApprover, logger *slog.Logger) *PolicyApprover {
+	prompter, _ := fallback.(*InteractiveApprover)
+	return &PolicyApprover{
+		policy:   policy,
+		fallback: fallback,
+		prompter: prompter,
+		logger:   logger,
+	}
+}
+
+// SetAuditLogger enables auditing of rule decisions.
+func (p *PolicyApprover) SetAuditLogger(audit domain.AuditLogger) { p.audit = audit }
+
+// NeedsApproval gates every tool a rule could apply to, since rules depend on
+// the caller and arguments. Other tools are left to the fallback.
+func (p *PolicyApprover) NeedsApproval(call domain.ToolCall) bool {
+	return p.policy.Covers(call.Name) || p.fallback.NeedsApproval(call)
+}
+
+// RequestApproval applies the first matching rule, or the fallback if none
+// matches.
+func (p *PolicyApprover) RequestApproval(ctx context.Context, call domain.ToolCall) (bool, error) {
+	d, ok := p.policy.Evaluate(ctx, call)
+	if !ok {
+		return p.fallback.RequestApproval(ctx, call)
+	}
+
+	switch d.Action {
+	case PolicyAllow:
+		p.auditDecision(ctx, call, d, "approved")
+		return true, nil
+	case PolicyPrompt:
+		if p.prompter != nil {
+			return p.prompter.prompt(ctx, call, d.Rule)
+		}
+		p.auditDecision(ctx, call, d, "denied")
+		return false, domain.NewDomainError("PolicyApprover.RequestApproval", domain.ErrToolApprovalDenied,
+			fmt.Sprintf("tool %q requires approval (rule %q) but interactive mode is disabled", call.Name, d.Rule))
+	default:
+		p.auditDecision(ctx, call, d, "denied")
+		return false, domain.NewDomainError("PolicyApprover.RequestApproval", domain.ErrToolApprovalDenied,
+			fmt.Sprintf("tool %q denied by rule %q", call.Name, d.Rule))
+	}
+}
+
+func (p *PolicyApprover) auditDecision(ctx context.Context, call domain.ToolCall, d PolicyDecision, outcome string) {
+	sessionID := approvalSession(ctx)
+	p.logger.Info("tool approval decision",
+		"tool", call.Name, "session", sessionID, "outcome", outcome, "decided_by", "policy", "rule", d.Rule)
+	if p.audit == nil {
+		return
+	}
+	p.audit.Log(ctx, domain.AuditEvent{
+		Type:     domain.AuditToolApproval,
+		Actor:    "policy",
+		Resource: call.Name,
+		Action:   "approve",
+		Outcome:  outcome,
+		Detail: map[string]string{
+			"tool":         call.Name,
+			"tool_call_id": call.ID,
+			"session":      sessionID,
+			"rule":         d.Rule,
+			"action":       string(d.Action),
+		},
+	})
+}
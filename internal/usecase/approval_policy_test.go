@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+func mustPolicy(t *testing.T, rules ...ApprovalRule) *ApprovalPolicy {
+	t.Helper()
+	p, err := NewApprovalPolicy(rules)
+	if err != nil {
+		t.Fatalf("NewApprovalPolicy: %v", err)
+	}
+	return p
+}
+
+func TestApprovalPolicyArguments(t *testing.T) {
+	p := mustPolicy(t,
+		ApprovalRule{Name: "fs-read", Tool: "filesystem", Action: PolicyAllow, Args: []ArgCondition{
+			{Path: "action", OneOf: []string{"read", "list"}},
+		}},
+		ApprovalRule{Name: "fs-write-workspace", Tool: "filesystem", Action: PolicyAllow, Args: []ArgCondition{
+			{Path: "action", Equals: "write"},
+			{Path: "path", Under: "./workspace"},
+		}},
+		ApprovalRule{Name: "shell-safe", Tool: "shell", Action: PolicyAllow, Args: []ArgCondition{
+			{Path: "$.command", Matches: `^(ls|cat|grep)$`},
+			{Path: "args[*]", Matches: `^[^;&|]*$`},
+		}},
+		ApprovalRule{Name: "deny-rest", Tool: "*", Action: PolicyDeny},
+	)
+
+	tests := []struct {
+		name string
+		tool string
+		args string
+		want string
+	}{
+		{"read anywhere", "filesystem", `{"action":"read","path":"/etc/hosts"}`, "fs-read"},
+		{"write in workspace", "filesystem", `{"action":"write","path":"workspace/notes.md"}`, "fs-write-workspace"},
+		{"write escaping workspace", "filesystem", `{"action":"write","path":"workspace/../etc/passwd"}`, "deny-rest"},
+		{"write outside", "filesystem", `{"action":"write","path":"/tmp/x"}`, "deny-rest"},
+		{"allowed command", "shell", `{"command":"ls","args":["-la","src"]}`, "shell-safe"},
+		{"injected arg", "shell", `{"command":"ls","args":["x; rm -rf /"]}`, "deny-rest"},
+		{"no args selected", "shell", `{"command":"ls"}`, "deny-rest"},
+		{"other command", "shell", `{"command":"rm","args":["x"]}`, "deny-rest"},
+		{"bad json", "filesystem", `not json`, "deny-rest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := p.Evaluate(context.Background(), domain.ToolCall{Name: tt.tool, Arguments: json.RawMessage(tt.args)})
+			if !ok || d.Rule != tt.want {
+				t.Errorf("got %+v (ok=%v), want rule %q", d, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestApprovalPolicyCaller(t *testing.T) {
+	p := mustPolicy(t,
+		ApprovalRule{Name: "viewer-no-pr", Tool: "github", Action: PolicyDeny,
+			Roles: []domain.AuthRole{domain.AuthRoleViewer},
+			Args:  []ArgCondition{{Path: "action", Equals: "create_pr"}}},
+		ApprovalRule{Tool: "github", Action: PolicyAllow, Agents: []string{"coder"}, Tenants: []string{"acme"}},
+		ApprovalRule{Tool: "github", Action: PolicyPrompt, Channels: []string{"telegram"}},
+	)
+	call := domain.ToolCall{Name: "github", Arguments: json.RawMessage(`{"action":"create_pr"}`)}
+
+	viewer := domain.ContextWithRoles(context.Background(), []domain.AuthRole{domain.AuthRoleViewer})
+	if d, _ := p.Evaluate(viewer, call); d.Action != PolicyDeny || d.Rule != "viewer-no-pr" {
+		t.Errorf("viewer: got %+v", d)
+	}
+
+	ctx := domain.ContextWithAgentID(context.Background(), "coder")
+	if _, ok := p.Evaluate(ctx, call); ok {
+		t.Error("agent without tenant should not match")
+	}
+	ctx = domain.ContextWithTenantID(ctx, "acme")
+	if d, _ := p.Evaluate(ctx, call); d.Action != PolicyAllow || d.Rule != "rules[1]" {
+		t.Errorf("coder@acme: got %+v", d)
+	}
+
+	ctx = domain.ContextWithSessionID(context.Background(), "telegram:42")
+	if d, _ := p.Evaluate(ctx, call); d.Action != PolicyPrompt || d.Rule != "rules[2]" {
+		t.Errorf("telegram: got %+v", d)
+	}
+}
+
+func TestApprovalPolicyConditions(t *testing.T) {
+	args := map[string]any{
+		"opts":  map[string]any{"mode": "fast", "depth": 3},
+		"files": []any{map[string]any{"path": "a"}, map[string]any{"path": "b"}},
+	}
+	raw, _ := json.Marshal(args)
+	call := domain.ToolCall{Name: "t", Arguments: raw}
+
+	tests := []struct {
+		cond ArgCondition
+		want bool
+	}{
+		{ArgCondition{Path: "opts.mode", Equals: "fast"}, true},
+		{ArgCondition{Path: "opts.depth", Equals: "3"}, true},
+		{ArgCondition{Path: "files[1].path", Equals: "b"}, true},
+		{ArgCondition{Path: "files[2].path", Equals: "b"}, false},
+		{ArgCondition{Path: "files[*].path", OneOf: []string{"a", "b"}}, true},
+		{ArgCondition{Path: "files[*].path", Equals: "a"}, false},
+		{ArgCondition{Path: "files[*].path", Equals: "a", Not: true}, true},
+		{ArgCondition{Path: "opts.missing", Absent: true}, true},
+		{ArgCondition{Path: "opts.mode", Absent: true}, false},
+	}
+	for _, tt := range tests {
+		p := mustPolicy(t, ApprovalRule{Tool: "t", Action: PolicyAllow, Args: []ArgCondition{tt.cond}})
+		if _, ok := p.Evaluate(context.Background(), call); ok != tt.want {
+			t.Errorf("%+v: matched=%v, want %v", tt.cond, ok, tt.want)
+		}
+	}
+}
+
+func TestNewApprovalPolicyErrors(t *testing.T) {
+	tests := []struct {
+		rule ApprovalRule
+		want string
+	}{
+		{ApprovalRule{Action: PolicyAllow}, "tool is required"},
+		{ApprovalRule{Tool: "[", Action: PolicyAllow}, "tool pattern"},
+		{ApprovalRule{Tool: "x", Action: "maybe"}, "invalid action"},
+		{ApprovalRule{Tool: "x", Action: PolicyAllow, Args: []ArgCondition{{Path: "a", Matches: "("}}}, "arg \"a\""},
+		{ApprovalRule{Tool: "x", Action: PolicyAllow, Args: []ArgCondition{{Path: "a"}}}, "no check set"},
+		{ApprovalRule{Tool: "x", Action: PolicyAllow, Args: []ArgCondition{{Path: "a..b", Equals: "1"}}}, "empty segment"},
+		{ApprovalRule{Tool: "x", Action: PolicyAllow, Args: []ArgCondition{{Path: "a[x]", Equals: "1"}}}, "invalid index"},
+	}
+	for _, tt := range tests {
+		_, err := NewApprovalPolicy([]ApprovalRule{tt.rule})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%+v: err=%v, want %q", tt.rule, err, tt.want)
+		}
+	}
+}
+
+func TestPathUnder(t *testing.T) {
+	tests := []struct {
+		p, dir string
+		want   bool
+	}{
+		{"workspace/a.txt", "./workspace", true},
+		{"./workspace", "workspace", true},
+		{"workspace2/a", "workspace", false},
+		{"../workspace/a", "workspace", false},
+		{"/srv/data/x", "/srv/data", true},
+		{"/srv/data/../etc", "/srv/data", false},
+		{"/srv/data/x", "srv/data", false},
+		{"a/b", ".", true},
+		{"../b", ".", false},
+	}
+	for _, tt := range tests {
+		if got := pathUnder(tt.p, tt.dir); got != tt.want {
+			t.Errorf("pathUnder(%q, %q) = %v, want %v", tt.p, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyApproverDecisions(t *testing.T) {
+	p := mustPolicy(t,
+		ApprovalRule{Name: "allow-ls", Tool: "shell", Action: PolicyAllow, Args: []ArgCondition{{Path: "command", Equals: "ls"}}},
+		ApprovalRule{Name: "no-rm", Tool: "shell", Action: PolicyDeny, Args: []ArgCondition{{Path: "command", Equals: "rm"}}},
+		ApprovalRule{Name: "ask-fs", Tool: "filesystem", Action: PolicyPrompt},
+	)
+	a := NewPolicyApprover(p, NewConfigApprover([]string{"filesystem", "web"}, nil), newTestLogger())
+	audit := &recordingAuditLogger{}
+	a.SetAuditLogger(audit)
+
+	if !a.NeedsApproval(domain.ToolCall{Name: "filesystem"}) {
+		t.Error("filesystem is covered by a rule and must be gated")
+	}
+	if a.NeedsApproval(domain.ToolCall{Name: "web"}) {
+		t.Error("web is on the allow list and no rule covers it")
+	}
+
+	ctx := context.Background()
+	if ok, err := a.RequestApproval(ctx, domain.ToolCall{Name: "shell", Arguments: json.RawMessage(`{"command":"ls"}`)}); !ok || err != nil {
+		t.Errorf("ls: ok=%v err=%v", ok, err)
+	}
+	_, err := a.RequestApproval(ctx, domain.ToolCall{Name: "shell", Arguments: json.RawMessage(`{"command":"rm"}`)})
+	if !errors.Is(err, domain.ErrToolApprovalDenied) || !strings.Contains(err.Error(), `"no-rm"`) {
+		t.Errorf("rm: err=%v", err)
+	}
+	// Prompt rules override the allow list; without an interactive approver
+	// that means deny.
+	if _, err := a.RequestApproval(ctx, domain.ToolCall{Name: "filesystem"}); !errors.Is(err, domain.ErrToolApprovalDenied) {
+		t.Errorf("filesystem: err=%v", err)
+	}
+	// No rule matches: the lists decide.
+	if _, err := a.RequestApproval(ctx, domain.ToolCall{Name: "shell", Arguments: json.RawMessage(`{"command":"cat"}`)}); !errors.Is(err, domain.ErrToolApprovalDenied) {
+		t.Errorf("cat: err=%v", err)
+	}
+
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+	if len(audit.events) != 3 {
+		t.Fatalf("audit events = %d, want 3", len(audit.events))
+	}
+	for i, want := range []struct{ rule, outcome string }{{"allow-ls", "approved"}, {"no-rm", "denied"}, {"ask-fs", "denied"}} {
+		e := audit.events[i]
+		if e.Type != domain.AuditToolApproval || e.Actor != "policy" || e.Detail["rule"] != want.rule || e.Outcome != want.outcome {
+			t.Errorf("event %d: %+v", i, e)
+		}
+	}
+}
+
+func TestPolicyApproverPromptRule(t *testing.T) {
+	interactive, bus := newInteractiveApprover(t, NewConfigApprover([]string{"filesystem"}, nil), time.Second)
+	audit := &recordingAuditLogger{}
+	interactive.SetAuditLogger(audit)
+	reqs := answerApprovals(bus, func(req domain.ApprovalRequest) domain.ApprovalDecision {
+		return domain.ApprovalDecision{ToolCallID: req.ToolCallID, Approved: true, DecidedBy: "cli"}
+	})
+
+	p := mustPolicy(t, ApprovalRule{Name: "ask-writes", Tool: "filesystem", Action: PolicyPrompt,
+		Args: []ArgCondition{{Path: "action", Equals: "write"}}})
+	a := NewPolicyApprover(p, interactive, newTestLogger())
+
+	ctx := domain.ContextWithSessionID(context.Background(), "cli:default")
+	// Reads fall through to the allow list without a prompt.
+	if ok, err := a.RequestApproval(ctx, domain.ToolCall{ID: "r", Name: "filesystem", Arguments: json.RawMessage(`{"action":"read"}`)}); !ok || err != nil {
+		t.Fatalf("read: ok=%v err=%v", ok, err)
+	}
+	// Writes are prompted even though filesystem is on the allow list.
+	if ok, err := a.RequestApproval(ctx, domain.ToolCall{ID: "w", Name: "filesystem", Arguments: json.RawMessage(`{"action":"write"}`)}); !ok || err != nil {
+		t.Fatalf("write: ok=%v err=%v", ok, err)
+	}
+
+	req := <-reqs
+	if req.ToolCallID != "w" || req.Rule != "ask-writes" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if len(reqs) != 0 {
+		t.Errorf("expected a single prompt, got %d more", len(reqs))
+	}
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+	if len(audit.events) != 1 || audit.events[0].Detail["rule"] != "ask-writes" {
+		t.Errorf("unexpected audit events: %+v", audit.events)
+	}
+}
+
+func TestPolicyApproverSessionGrantPerRule(t *testing.T) {
+	interactive, bus := newInteractiveApprover(t, NewConfigApprover(nil, nil), time.Second)
+	reqs := answerApprovals(bus, func(req domain.ApprovalRequest) domain.ApprovalDecision {
+		return domain.ApprovalDecision{ToolCallID: req.ToolCallID, Approved: true, Scope: domain.ApprovalScopeSession}
+	})
+
+	p := mustPolicy(t,
+		ApprovalRule{Name: "ask-rm", Tool: "shell", Action: PolicyPrompt, Args: []ArgCondition{{Path: "command", Equals: "rm"}}},
+		ApprovalRule{Name: "ask-curl", Tool: "shell", Action: PolicyPrompt, Args: []ArgCondition{{Path: "command", Equals: "curl"}}},
+	)
+	a := NewPolicyApprover(p, interactive, newTestLogger())
+	ctx := domain.ContextWithSessionID(context.Background(), "cli:default")
+
+	calls := []struct{ id, command string }{{"1", "ls"}, {"2", "ls"}, {"3", "rm"}, {"4", "rm"}, {"5", "curl"}}
+	for _, c := range calls {
+		call := domain.ToolCall{ID: c.id, Name: "shell", Arguments: json.RawMessage(`{"command":"` + c.command + `"}`)}
+		if ok, err := a.RequestApproval(ctx, call); !ok || err != nil {
+			t.Fatalf("%s: ok=%v err=%v", c.command, ok, err)
+		}
+	}
+
+	// The first ls grant covers the second ls but neither rule's prompt,
+	// and the rm grant does not cover curl.
+	var prompted []string
+	for len(reqs) > 0 {
+		req := <-reqs
+		prompted = append(prompted, req.ToolCallID+":"+req.Rule)
+	}
+	if strings.Join(prompted, ",") != "1:,3:ask-rm,5:ask-curl" {
+		t.Errorf("prompted = %v", prompted)
+	}
+}
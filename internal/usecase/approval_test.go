@@ -4,9 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"alfred-ai/internal/domain"
+	"alfred-ai/internal/usecase/eventbus"
 )
 
 func TestConfigApproverAlwaysApprove(t *testing.T) {
@@ -90,3 +94,212 @@ func TestConfigApproverMixed(t *testing.T) {
 		t.Errorf("expected ErrToolApprovalDenied for other, got %v", err)
 	}
 }
+
+// recordingAuditLogger keeps audit events for inspection.
+type recordingAuditLogger struct {
+	mu     sync.Mutex
+	events []domain.AuditEvent
+}
+
+func (a *recordingAuditLogger) Log(_ context.Context, e domain.AuditEvent) error {
+	a.mu.Lock()
+	a.events = append(a.events, e)
+	a.mu.Unlock()
+	return nil
+}
+func (a *recordingAuditLogger) Close() error { return nil }
+
+// answerApprovals replies to every approval request with fn's decision and
+// forwards the requests to the returned channel.
+func answerApprovals(bus domain.EventBus, fn func(domain.ApprovalRequest) domain.ApprovalDecision) <-chan domain.ApprovalRequest {
+	reqs := make(chan domain.ApprovalRequest, 10)
+	bus.Subscribe(domain.EventToolApprovalReq, func(ctx context.Context, e domain.Event) {
+		var req domain.ApprovalRequest
+		json.Unmarshal(e.Payload, &req)
+		reqs <- req
+		payload, _ := json.Marshal(fn(req))
+		bus.Publish(ctx, domain.Event{Type: domain.EventToolApprovalResp, Payload: payload})
+	})
+	return reqs
+}
+
+func newInteractiveApprover(t *testing.T, lists *ConfigApprover, timeout time.Duration) (*InteractiveApprover, *eventbus.Bus) {
+	t.Helper()
+	bus := eventbus.New(newTestLogger())
+	a := NewInteractiveApprover(lists, bus, timeout, newTestLogger())
+	t.Cleanup(func() {
+		a.Close()
+		bus.Close()
+	})
+	return a, bus
+}
+
+func TestInteractiveApproverApproveOnce(t *testing.T) {
+	a, bus := newInteractiveApprover(t, NewConfigApprover(nil, nil), time.Second)
+	audit := &recordingAuditLogger{}
+	a.SetAuditLogger(audit)
+	reqs := answerApprovals(bus, func(req domain.ApprovalRequest) domain.ApprovalDecision {
+		return domain.ApprovalDecision{ToolCallID: req.ToolCallID, Approved: true, DecidedBy: "telegram:7"}
+	})
+
+	ctx := domain.ContextWithSessionID(context.Background(), "telegram:42")
+	call := domain.ToolCall{ID: "c1", Name: "shell", Arguments: json.RawMessage(`{"cmd":"ls"}`)}
+	approved, err := a.RequestApproval(ctx, call)
+	if err != nil || !approved {
+		t.Fatalf("approved=%v err=%v", approved, err)
+	}
+
+	req := <-reqs
+	if req.ToolCallID != "c1" || req.Tool != "shell" || req.Channel != "telegram" || req.ChatID != "42" || req.ExpiresAt.IsZero() {
+		t.Errorf("unexpected request: %+v", req)
+	}
+
+	// "once" is not remembered.
+	if _, err := a.RequestApproval(ctx, call); err != nil {
+		t.Fatalf("second request: %v", err)
+	}
+	if len(reqs) != 1 {
+		t.Errorf("expected a second prompt, got %d pending", len(reqs))
+	}
+
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+	if len(audit.events) != 2 {
+		t.Fatalf("audit events = %d, want 2", len(audit.events))
+	}
+	e := audit.events[0]
+	if e.Type != domain.AuditToolApproval || e.Outcome != "approved" || e.Actor != "telegram:7" || e.Detail["session"] != "telegram:42" {
+		t.Errorf("unexpected audit event: %+v", e)
+	}
+}
+
+func TestInteractiveApproverRemembersSession(t *testing.T) {
+	a, bus := newInteractiveApprover(t, NewConfigApprover(nil, nil), time.Second)
+	reqs := answerApprovals(bus, func(req domain.ApprovalRequest) domain.ApprovalDecision {
+		return domain.ApprovalDecision{ToolCallID: req.ToolCallID, Approved: true, Scope: domain.ApprovalScopeSession}
+	})
+
+	ctx := domain.ContextWithSessionID(context.Background(), "slack:C1")
+	for i := 0; i < 3; i++ {
+		if ok, err := a.RequestApproval(ctx, domain.ToolCall{ID: fmt.Sprintf("c%d", i), Name: "shell"}); !ok || err != nil {
+			t.Fatalf("call %d: approved=%v err=%v", i, ok, err)
+		}
+	}
+	if len(reqs) != 1 {
+		t.Errorf("prompts = %d, want 1", len(reqs))
+	}
+
+	// Other sessions and other tools still prompt.
+	other := domain.ContextWithSessionID(context.Background(), "slack:C2")
+	a.RequestApproval(other, domain.ToolCall{ID: "x", Name: "shell"})
+	a.RequestApproval(ctx, domain.ToolCall{ID: "y", Name: "web_fetch"})
+	if len(reqs) != 3 {
+		t.Errorf("prompts = %d, want 3", len(reqs))
+	}
+
+	// Forgetting the session drops the grant.
+	a.ForgetSession("slack:C1")
+	a.RequestApproval(ctx, domain.ToolCall{ID: "z", Name: "shell"})
+	if len(reqs) != 4 {
+		t.Errorf("prompts = %d, want 4", len(reqs))
+	}
+}
+
+func TestInteractiveApproverGrantsEndWithSession(t *testing.T) {
+	a, bus := newInteractiveApprover(t, NewConfigApprover(nil, nil), time.Second)
+	reqs := answerApprovals(bus, func(req domain.ApprovalRequest) domain.ApprovalDecision {
+		return domain.ApprovalDecision{ToolCallID: req.ToolCallID, Approved: true, Scope: domain.ApprovalScopeSession}
+	})
+	sm := NewSessionManager(t.TempDir())
+	sm.SetOnRemove(a.ForgetSession)
+
+	// The agent puts both the session's ULID and its router key in ctx.
+	session := sm.GetOrCreate("slack:C1")
+	ctx := domain.ContextWithSessionID(context.Background(), session.ID)
+	ctx = domain.ContextWithSessionKey(ctx, session.ExternalKey)
+
+	a.RequestApproval(ctx, domain.ToolCall{ID: "a", Name: "shell"})
+	a.RequestApproval(ctx, domain.ToolCall{ID: "b", Name: "shell"})
+	if len(reqs) != 1 {
+		t.Fatalf("prompts = %d, want 1", len(reqs))
+	}
+	if req := <-reqs; req.Channel != "slack" || req.ChatID != "C1" {
+		t.Errorf("prompt routed to %q/%q, want slack/C1", req.Channel, req.ChatID)
+	}
+
+	session.mu.Lock()
+	session.UpdatedAt = time.Now().Add(-2 * time.Hour)
+	session.mu.Unlock()
+	if n := sm.ReapStaleSessions(time.Hour); n != 1 {
+		t.Fatalf("reaped = %d, want 1", n)
+	}
+	a.mu.Lock()
+	left := len(a.granted)
+	a.mu.Unlock()
+	if left != 0 {
+		t.Errorf("grants kept for %d sessions after reaping", left)
+	}
+	a.RequestApproval(ctx, domain.ToolCall{ID: "c", Name: "shell"})
+	if len(reqs) != 1 {
+		t.Errorf("expected a new prompt once the session was reaped")
+	}
+}
+
+func TestInteractiveApproverDeny(t *testing.T) {
+	a, bus := newInteractiveApprover(t, NewConfigApprover(nil, nil), time.Second)
+	answerApprovals(bus, func(req domain.ApprovalRequest) domain.ApprovalDecision {
+		return domain.ApprovalDecision{ToolCallID: req.ToolCallID}
+	})
+
+	approved, err := a.RequestApproval(context.Background(), domain.ToolCall{ID: "c1", Name: "shell"})
+	if approved || !errors.Is(err, domain.ErrToolApprovalDenied) {
+		t.Errorf("approved=%v err=%v, want ErrToolApprovalDenied", approved, err)
+	}
+}
+
+func TestInteractiveApproverTimeout(t *testing.T) {
+	a, bus := newInteractiveApprover(t, NewConfigApprover(nil, nil), 50*time.Millisecond)
+	resolved := make(chan domain.ApprovalDecision, 1)
+	bus.Subscribe(domain.EventToolApprovalResp, func(_ context.Context, e domain.Event) {
+		var d domain.ApprovalDecision
+		json.Unmarshal(e.Payload, &d)
+		resolved <- d
+	})
+
+	approved, err := a.RequestApproval(context.Background(), domain.ToolCall{ID: "c1", Name: "shell"})
+	if approved || !errors.Is(err, domain.ErrToolApprovalTimeout) {
+		t.Fatalf("approved=%v err=%v, want ErrToolApprovalTimeout", approved, err)
+	}
+	select {
+	case d := <-resolved:
+		if d.ToolCallID != "c1" || d.Approved || d.Reason != "timeout" {
+			t.Errorf("unexpected resolution: %+v", d)
+		}
+	case <-time.After(time.Second):
+		t.Error("timeout was not published")
+	}
+
+	// A late answer is ignored.
+	payload, _ := json.Marshal(domain.ApprovalDecision{ToolCallID: "c1", Approved: true})
+	bus.Publish(context.Background(), domain.Event{Type: domain.EventToolApprovalResp, Payload: payload})
+}
+
+func TestInteractiveApproverListsAndCancel(t *testing.T) {
+	a, _ := newInteractiveApprover(t, NewConfigApprover([]string{"read"}, []string{"rm"}), time.Minute)
+
+	if a.NeedsApproval(domain.ToolCall{Name: "read"}) {
+		t.Error("read should not need approval")
+	}
+	if ok, err := a.RequestApproval(context.Background(), domain.ToolCall{Name: "rm"}); ok || !errors.Is(err, domain.ErrToolApprovalDenied) {
+		t.Errorf("rm: approved=%v err=%v", ok, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	if ok, err := a.RequestApproval(ctx, domain.ToolCall{Name: "shell"}); ok || !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: approved=%v err=%v", ok, err)
+	}
+}
@@ -37,7 +37,9 @@ func (cb *ContextBuilder) SetThinkingBudget(budget int) {
 	cb.thinkingBudget = budget
 }
 
-// Build assembles: system prompt + memory context + conversation history.
+// Build assembles: system prompt + skills + memory context + conversation
+// history. The memory context changes from turn to turn, so it goes last in
+// the system prompt to keep the part before it cacheable.
 func (cb *ContextBuilder) Build(
 	history []domain.Message,
 	memoryContext []domain.MemoryEntry,
@@ -47,12 +49,14 @@ func (cb *ContextBuilder) Build(
 
 	// System prompt (always first)
 	systemContent := cb.systemPrompt
-	if len(memoryContext) > 0 {
-		systemContent += "\n\n## Relevant Memory Context\n" + cb.formatMemory(memoryContext)
-	}
 	if len(cb.skills) > 0 {
 		systemContent += "\n\n## Available Skills\n" + cb.formatSkills()
 	}
+	var stableLen int
+	if len(memoryContext) > 0 {
+		stableLen = len(systemContent)
+		systemContent += "\n\n## Relevant Memory Context\n" + cb.formatMemory(memoryContext)
+	}
 	messages = append(messages, domain.Message{
 		Role:      domain.RoleSystem,
 		Content:   systemContent,
@@ -65,10 +69,11 @@ func (cb *ContextBuilder) Build(
 	messages = append(messages, hist...)
 
 	return domain.ChatRequest{
-		Model:          cb.model,
-		Messages:       messages,
-		Tools:          tools,
-		ThinkingBudget: cb.thinkingBudget,
+		Model:           cb.model,
+		Messages:        messages,
+		Tools:           tools,
+		ThinkingBudget:  cb.thinkingBudget,
+		StableSystemLen: stableLen,
 	}
 }
 
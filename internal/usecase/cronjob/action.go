@@ -0,0 +1,287 @@
+package cronjob
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"alfred-ai/internal/domain"
+	"alfred-ai/internal/usecase/workflow"
+)
+
+// WorkflowRunner runs named pipelines for workflow_run actions.
+type WorkflowRunner interface {
+	Run(ctx context.Context, pipelineName string, env map[string]string, opts *workflow.RunOptions) (*domain.WorkflowRun, error)
+}
+
+// ChannelResolver looks up the channels that job results are delivered to.
+type ChannelResolver interface {
+	Get(name string) (domain.Channel, error)
+}
+
+// Retry limits.
+const (
+	maxRetryAttempts    = 10
+	defaultRetryBackoff = time.Second
+	defaultMaxBackoff   = time.Minute
+)
+
+// blockedTools cannot be scheduled as tool_call actions, since a job could
+// then create more jobs or start workflows without anyone in the loop.
+var blockedTools = map[string]bool{"cron": true, "workflow": true}
+
+// maxRunOutput caps the result text kept in the run history.
+const maxRunOutput = 2000
+
+// notifyData is the value notify templates are executed with.
+type notifyData struct {
+	Job domain.CronJob
+	Now time.Time
+}
+
+// validateCronAction checks that action has the fields its kind needs.
+func validateCronAction(a domain.CronAction) error {
+	switch a.Kind {
+	case domain.CronActionAgentRun:
+		if a.Message == "" {
+			return fmt.Errorf("action message is required")
+		}
+	case domain.CronActionWorkflowRun:
+		if a.Workflow == "" {
+			return fmt.Errorf("action kind 'workflow_run' requires 'workflow'")
+		}
+	case domain.CronActionToolCall:
+		if a.Tool == "" {
+			return fmt.Errorf("action kind 'tool_call' requires 'tool'")
+		}
+		if blockedTools[a.Tool] {
+			return fmt.Errorf("tool %q cannot be scheduled", a.Tool)
+		}
+		if len(a.Params) > 0 && !json.Valid(a.Params) {
+			return fmt.Errorf("action params must be valid JSON")
+		}
+	case domain.CronActionNotify:
+		if a.Message == "" {
+			return fmt.Errorf("action message is required")
+		}
+		if a.Channel == "" {
+			return fmt.Errorf("action kind 'notify' requires 'channel'")
+		}
+		if _, err := template.New("notify").Parse(a.Message); err != nil {
+			return fmt.Errorf("invalid message template: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown action kind %q (want: agent_run, workflow_run, tool_call, notify)", a.Kind)
+	}
+	return nil
+}
+
+// validateRetryPolicy checks a job's retry policy, if any.
+func validateRetryPolicy(p *domain.CronRetryPolicy) error {
+	if p == nil {
+		return nil
+	}
+	if p.MaxAttempts < 0 || p.MaxAttempts > maxRetryAttempts {
+		return fmt.Errorf("retry max_attempts must be between 0 and %d", maxRetryAttempts)
+	}
+	if p.BackoffMs < 0 || p.MaxBackoffMs < 0 {
+		return fmt.Errorf("retry backoff must not be negative")
+	}
+	return nil
+}
+
+// retryDelay returns the wait before the attempt after the given one.
+func retryDelay(p *domain.CronRetryPolicy, attempt int) time.Duration {
+	delay := defaultRetryBackoff
+	if p.BackoffMs > 0 {
+		delay = time.Duration(p.BackoffMs) * time.Millisecond
+	}
+	limit := defaultMaxBackoff
+	if p.MaxBackoffMs > 0 {
+		limit = time.Duration(p.MaxBackoffMs) * time.Millisecond
+	}
+	for range attempt - 1 {
+		if delay >= limit {
+			break
+		}
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+// runWithRetry runs the job's action, retrying failures as its policy
+// allows. Returns the result of the last attempt and the attempt count.
+func (m *Manager) runWithRetry(ctx context.Context, job domain.CronJob) (output string, attempts int, err error) {
+	attempts, err = m.retry(ctx, job, "action", func() error {
+		var err error
+		output, err = m.runAction(ctx, job)
+		return err
+	})
+	return output, attempts, err
+}
+
+// deliverResult posts an action's output to the job's channel, if it has
+// one, and reports whether it was sent. Sends are retried on their own, so
+// a failed delivery never runs the action again.
+func (m *Manager) deliverResult(ctx context.Context, job domain.CronJob, output string) (bool, error) {
+	if job.Action.Channel == "" || output == "" {
+		return false, nil
+	}
+	if _, err := m.retry(ctx, job, "delivery", func() error { return m.deliver(ctx, job, output) }); err != nil {
+		return false, fmt.Errorf("deliver to %s: %w", job.Action.Channel, err)
+	}
+	return true, nil
+}
+
+// retry calls fn until it succeeds or the job's retry policy runs out,
+// and returns the number of calls.
+func (m *Manager) retry(ctx context.Context, job domain.CronJob, what string, fn func() error) (attempts int, err error) {
+	maxAttempts := 1
+	if job.Retry != nil {
+		maxAttempts = max(job.Retry.MaxAttempts, 1)
+	}
+	for {
+		attempts++
+		err = fn()
+		if err == nil || attempts >= maxAttempts {
+			return attempts, err
+		}
+
+		delay := retryDelay(job.Retry, attempts)
+		m.logger.Warn("cron job failed, retrying", "id", job.ID, "step", what, "attempt", attempts, "retry_in", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return attempts, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// runAction performs the job's action and returns its result text.
+func (m *Manager) runAction(ctx context.Context, job domain.CronJob) (string, error) {
+	m.mu.Lock()
+	handler, workflows, tools, approver := m.handler, m.workflows, m.tools, m.approver
+	m.mu.Unlock()
+
+	a := job.Action
+	switch a.Kind {
+	case domain.CronActionAgentRun, "": // jobs saved before kinds were checked
+		if handler == nil {
+			return "", fmt.Errorf("no message handler for agent_run")
+		}
+		out, err := handler.Handle(ctx, domain.InboundMessage{
+			SessionID:   "cron:" + job.ID,
+			Content:     a.Message,
+			ChannelName: "cron",
+			AgentID:     a.AgentID,
+		})
+		if err != nil {
+			return "", err
+		}
+		if out.IsError {
+			return "", errors.New(out.Content)
+		}
+		return out.Content, nil
+
+	case domain.CronActionWorkflowRun:
+		if workflows == nil {
+			return "", fmt.Errorf("workflows are not enabled")
+		}
+		run, err := workflows.Run(ctx, a.Workflow, a.Args, nil)
+		if err != nil {
+			return "", err
+		}
+		if run.Status == "failed" || run.Status == "denied" {
+			return "", fmt.Errorf("workflow %s %s: %s", a.Workflow, run.Status, run.Error)
+		}
+		return run.Summary(), nil
+
+	case domain.CronActionToolCall:
+		if tools == nil {
+			return "", fmt.Errorf("no tool registry for tool_call")
+		}
+		if blockedTools[a.Tool] { // jobs saved before the check
+			return "", fmt.Errorf("tool %q cannot be scheduled", a.Tool)
+		}
+		t, err := tools.Get(a.Tool)
+		if err != nil {
+			return "", err
+		}
+		params := a.Params
+		if len(params) == 0 {
+			params = json.RawMessage(`{}`)
+		}
+		call := domain.ToolCall{ID: "cron:" + job.ID, Name: a.Tool, Arguments: params}
+		if approver != nil && approver.NeedsApproval(call) {
+			approved, err := approver.RequestApproval(ctx, call)
+			if err != nil {
+				return "", fmt.Errorf("tool %s: %w", a.Tool, err)
+			}
+			if !approved {
+				return "", fmt.Errorf("tool %s: %w", a.Tool, domain.ErrToolApprovalDenied)
+			}
+		}
+		res, err := t.Execute(ctx, params)
+		if err != nil {
+			return "", err
+		}
+		if res.IsError {
+			return "", fmt.Errorf("tool %s: %s", a.Tool, res.Content)
+		}
+		return res.Content, nil
+
+	case domain.CronActionNotify:
+		return renderNotify(job, time.Now())
+
+	default:
+		return "", fmt.Errorf("unknown action kind %q", a.Kind)
+	}
+}
+
+// deliver sends output to the job's target on its channel.
+func (m *Manager) deliver(ctx context.Context, job domain.CronJob, output string) error {
+	m.mu.Lock()
+	channels := m.channels
+	m.mu.Unlock()
+	if channels == nil {
+		return fmt.Errorf("no channels available")
+	}
+	ch, err := channels.Get(job.Action.Channel)
+	if err != nil {
+		return err
+	}
+	return ch.Send(ctx, domain.OutboundMessage{
+		SessionID: job.Action.Target,
+		Content:   output,
+		Metadata:  map[string]string{"cron_job_id": job.ID},
+	})
+}
+
+// renderNotify executes the notify message template.
+func renderNotify(job domain.CronJob, now time.Time) (string, error) {
+	tmpl, err := template.New("notify").Option("missingkey=error").Parse(job.Action.Message)
+	if err != nil {
+		return "", fmt.Errorf("invalid message template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, notifyData{Job: job, Now: now}); err != nil {
+		return "", fmt.Errorf("render message: %w", err)
+	}
+	if strings.TrimSpace(buf.String()) == "" {
+		return "", fmt.Errorf("message template rendered empty")
+	}
+	return buf.String(), nil
+}
+
+// truncateOutput shortens s to maxRunOutput bytes for the run history.
+func truncateOutput(s string) string {
+	if len(s) <= maxRunOutput {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxRunOutput], "") + "..."
+}
@@ -0,0 +1,351 @@
+package cronjob
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"alfred-ai/internal/domain"
+	"alfred-ai/internal/usecase/workflow"
+)
+
+type stubHandler struct {
+	reply string
+	got   []domain.InboundMessage
+}
+
+func (h *stubHandler) Handle(_ context.Context, msg domain.InboundMessage) (domain.OutboundMessage, error) {
+	h.got = append(h.got, msg)
+	return domain.OutboundMessage{SessionID: msg.SessionID, Content: h.reply}, nil
+}
+
+// flakyTool fails until it has been called failures+1 times.
+type flakyTool struct {
+	failures int
+	calls    int
+	params   json.RawMessage
+}
+
+func (t *flakyTool) Name() string              { return "flaky" }
+func (t *flakyTool) Description() string       { return "fails a few times" }
+func (t *flakyTool) Schema() domain.ToolSchema { return domain.ToolSchema{Name: "flaky"} }
+func (t *flakyTool) Execute(_ context.Context, params json.RawMessage) (*domain.ToolResult, error) {
+	t.calls++
+	t.params = params
+	if t.calls <= t.failures {
+		return &domain.ToolResult{Content: "temporarily unavailable", IsError: true}, nil
+	}
+	return &domain.ToolResult{Content: "ok after " + fmt.Sprint(t.calls)}, nil
+}
+
+type stubTools map[string]domain.Tool
+
+func (s stubTools) Get(name string) (domain.Tool, error) {
+	if t, ok := s[name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("tool %q not found", name)
+}
+
+func (s stubTools) Schemas() []domain.ToolSchema { return nil }
+
+type recordingChannel struct {
+	mu   sync.Mutex
+	sent []domain.OutboundMessage
+}
+
+func (c *recordingChannel) Start(context.Context, domain.MessageHandler) error { return nil }
+func (c *recordingChannel) Stop(context.Context) error                         { return nil }
+func (c *recordingChannel) Name() string                                       { return "slack" }
+func (c *recordingChannel) Send(_ context.Context, msg domain.OutboundMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, msg)
+	return nil
+}
+
+type stubChannels map[string]domain.Channel
+
+func (s stubChannels) Get(name string) (domain.Channel, error) {
+	if ch, ok := s[name]; ok {
+		return ch, nil
+	}
+	return nil, fmt.Errorf("channel %q not found", name)
+}
+
+type stubWorkflows struct {
+	run *domain.WorkflowRun
+	env map[string]string
+}
+
+func (w *stubWorkflows) Run(_ context.Context, name string, env map[string]string, _ *workflow.RunOptions) (*domain.WorkflowRun, error) {
+	if name != w.run.PipelineName {
+		return nil, errors.New("pipeline not found")
+	}
+	w.env = env
+	return w.run, nil
+}
+
+// createAndRun creates job and runs it once, returning the recorded run.
+func createAndRun(t *testing.T, mgr *Manager, job domain.CronJob) domain.CronRun {
+	t.Helper()
+	ctx := context.Background()
+	job.Schedule = domain.CronSchedule{Kind: "every", EveryMs: 3600000}
+	created, err := mgr.Create(ctx, job)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	mgr.executeJob(ctx, created.ID, false) //nolint:errcheck
+
+	runs, err := mgr.ListRuns(ctx, created.ID, 1)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("ListRuns = %v, %v", runs, err)
+	}
+	return runs[0]
+}
+
+func TestManagerCreateActionValidation(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	mgr.SetTools(stubTools{"flaky": &flakyTool{}, "cron": &flakyTool{}, "workflow": &flakyTool{}})
+
+	tests := []struct {
+		name   string
+		action domain.CronAction
+		retry  *domain.CronRetryPolicy
+	}{
+		{"unknown kind", domain.CronAction{Kind: "shell", Message: "ls"}, nil},
+		{"workflow missing name", domain.CronAction{Kind: domain.CronActionWorkflowRun}, nil},
+		{"tool missing name", domain.CronAction{Kind: domain.CronActionToolCall}, nil},
+		{"unknown tool", domain.CronAction{Kind: domain.CronActionToolCall, Tool: "nope"}, nil},
+		{"bad params", domain.CronAction{Kind: domain.CronActionToolCall, Tool: "flaky", Params: json.RawMessage(`{`)}, nil},
+		{"recursive tool", domain.CronAction{Kind: domain.CronActionToolCall, Tool: "cron"}, nil},
+		{"workflow tool", domain.CronAction{Kind: domain.CronActionToolCall, Tool: "workflow"}, nil},
+		{"notify without channel", domain.CronAction{Kind: domain.CronActionNotify, Message: "hi"}, nil},
+		{"notify bad template", domain.CronAction{Kind: domain.CronActionNotify, Channel: "slack", Message: "{{.Now"}, nil},
+		{"too many attempts", domain.CronAction{Kind: domain.CronActionAgentRun, Message: "hi"}, &domain.CronRetryPolicy{MaxAttempts: 11}},
+		{"negative backoff", domain.CronAction{Kind: domain.CronActionAgentRun, Message: "hi"}, &domain.CronRetryPolicy{MaxAttempts: 2, BackoffMs: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mgr.Create(context.Background(), domain.CronJob{
+				Schedule: domain.CronSchedule{Kind: "every", EveryMs: 60000},
+				Action:   tt.action,
+				Retry:    tt.retry,
+			})
+			if err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestExecuteJobAgentRunDelivers(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	handler := &stubHandler{reply: "Today: 3 meetings"}
+	ch := &recordingChannel{}
+	mgr.SetHandler(handler)
+	mgr.SetChannels(stubChannels{"slack": ch})
+
+	run := createAndRun(t, mgr, domain.CronJob{
+		Name: "brief",
+		Action: domain.CronAction{
+			Kind:    domain.CronActionAgentRun,
+			AgentID: "assistant",
+			Channel: "slack",
+			Target:  "C123",
+			Message: "Write my daily brief",
+		},
+	})
+
+	if !run.Success || !run.Delivered || run.Attempts != 1 {
+		t.Fatalf("run = %+v, want delivered success after 1 attempt", run)
+	}
+	if run.Output != "Today: 3 meetings" {
+		t.Errorf("Output = %q", run.Output)
+	}
+	if len(handler.got) != 1 || handler.got[0].AgentID != "assistant" {
+		t.Errorf("handler got %+v, want one message for agent 'assistant'", handler.got)
+	}
+	if len(ch.sent) != 1 || ch.sent[0].SessionID != "C123" || ch.sent[0].Content != "Today: 3 meetings" {
+		t.Errorf("channel sent %+v", ch.sent)
+	}
+}
+
+func TestExecuteJobDeliveryUnknownChannel(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	mgr.SetHandler(&stubHandler{reply: "hi"})
+	mgr.SetChannels(stubChannels{})
+
+	run := createAndRun(t, mgr, domain.CronJob{
+		Action: domain.CronAction{Kind: domain.CronActionAgentRun, Channel: "telegram", Message: "hi"},
+	})
+	if run.Success || run.Delivered {
+		t.Fatalf("run = %+v, want failed delivery", run)
+	}
+	if !strings.Contains(run.Error, "telegram") {
+		t.Errorf("Error = %q, want channel name", run.Error)
+	}
+}
+
+// failingChannel fails every send.
+type failingChannel struct{ recordingChannel }
+
+func (c *failingChannel) Send(context.Context, domain.OutboundMessage) error {
+	return errors.New("rate limited")
+}
+
+func TestExecuteJobDeliveryFailureKeepsAction(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	tool := &flakyTool{}
+	mgr.SetTools(stubTools{"flaky": tool})
+	mgr.SetChannels(stubChannels{"slack": &failingChannel{}})
+
+	run := createAndRun(t, mgr, domain.CronJob{
+		Action: domain.CronAction{Kind: domain.CronActionToolCall, Tool: "flaky", Channel: "slack", Target: "C1"},
+		Retry:  &domain.CronRetryPolicy{MaxAttempts: 3, BackoffMs: 1},
+	})
+	if tool.calls != 1 {
+		t.Errorf("tool ran %d times, want once despite the failed delivery", tool.calls)
+	}
+	if run.Success || run.Delivered || run.Attempts != 1 || !strings.Contains(run.Error, "rate limited") {
+		t.Fatalf("run = %+v, want a failed delivery after one attempt", run)
+	}
+}
+
+func TestExecuteJobToolCallRetries(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	tool := &flakyTool{failures: 2}
+	mgr.SetTools(stubTools{"flaky": tool})
+
+	run := createAndRun(t, mgr, domain.CronJob{
+		Action: domain.CronAction{Kind: domain.CronActionToolCall, Tool: "flaky", Params: json.RawMessage(`{"x":1}`)},
+		Retry:  &domain.CronRetryPolicy{MaxAttempts: 3, BackoffMs: 1},
+	})
+	if !run.Success || run.Attempts != 3 || run.Output != "ok after 3" {
+		t.Fatalf("run = %+v, want success on attempt 3", run)
+	}
+	if string(tool.params) != `{"x":1}` {
+		t.Errorf("params = %s", tool.params)
+	}
+
+	// Without enough attempts the last error is recorded.
+	tool = &flakyTool{failures: 5}
+	mgr.SetTools(stubTools{"flaky": tool})
+	run = createAndRun(t, mgr, domain.CronJob{
+		Action: domain.CronAction{Kind: domain.CronActionToolCall, Tool: "flaky"},
+		Retry:  &domain.CronRetryPolicy{MaxAttempts: 2, BackoffMs: 1},
+	})
+	if run.Success || run.Attempts != 2 || !strings.Contains(run.Error, "temporarily unavailable") {
+		t.Fatalf("run = %+v, want failure after 2 attempts", run)
+	}
+}
+
+// stubApprover approves calls when approve is set and records them.
+type stubApprover struct {
+	approve bool
+	asked   []domain.ToolCall
+}
+
+func (a *stubApprover) NeedsApproval(domain.ToolCall) bool { return true }
+func (a *stubApprover) RequestApproval(_ context.Context, call domain.ToolCall) (bool, error) {
+	a.asked = append(a.asked, call)
+	return a.approve, nil
+}
+
+func TestExecuteJobToolCallApproval(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	tool := &flakyTool{}
+	approver := &stubApprover{}
+	mgr.SetTools(stubTools{"flaky": tool})
+	mgr.SetApprover(approver)
+
+	run := createAndRun(t, mgr, domain.CronJob{
+		Action: domain.CronAction{Kind: domain.CronActionToolCall, Tool: "flaky"},
+	})
+	if run.Success || tool.calls != 0 || !strings.Contains(run.Error, "approval denied") {
+		t.Fatalf("run = %+v after %d calls, want a denial before the tool runs", run, tool.calls)
+	}
+	if len(approver.asked) != 1 || approver.asked[0].Name != "flaky" {
+		t.Errorf("asked = %+v", approver.asked)
+	}
+
+	approver.approve = true
+	run = createAndRun(t, mgr, domain.CronJob{
+		Action: domain.CronAction{Kind: domain.CronActionToolCall, Tool: "flaky"},
+	})
+	if !run.Success || tool.calls != 1 {
+		t.Fatalf("run = %+v, want the approved call to run", run)
+	}
+}
+
+func TestExecuteJobWorkflowRun(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	wf := &stubWorkflows{run: &domain.WorkflowRun{
+		PipelineName: "report",
+		Status:       "completed",
+		Steps: []domain.StepResult{
+			{StepID: "fetch", Status: "completed", Output: json.RawMessage(`{"n":3}`)},
+			{StepID: "format", Status: "completed", Output: json.RawMessage(`"3 new issues"`)},
+		},
+	}}
+	mgr.SetWorkflows(wf)
+
+	run := createAndRun(t, mgr, domain.CronJob{
+		Action: domain.CronAction{Kind: domain.CronActionWorkflowRun, Workflow: "report", Args: map[string]string{"repo": "alfred"}},
+	})
+	if !run.Success || run.Output != "3 new issues" {
+		t.Fatalf("run = %+v, want output of the last step", run)
+	}
+	if wf.env["repo"] != "alfred" {
+		t.Errorf("env = %v", wf.env)
+	}
+
+	wf.run.Status, wf.run.Error = "failed", "step format: boom"
+	run = createAndRun(t, mgr, domain.CronJob{
+		Action: domain.CronAction{Kind: domain.CronActionWorkflowRun, Workflow: "report"},
+	})
+	if run.Success || !strings.Contains(run.Error, "boom") {
+		t.Fatalf("run = %+v, want workflow failure", run)
+	}
+}
+
+func TestExecuteJobNotify(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	ch := &recordingChannel{}
+	mgr.SetChannels(stubChannels{"slack": ch})
+
+	run := createAndRun(t, mgr, domain.CronJob{
+		Name: "standup",
+		Action: domain.CronAction{
+			Kind:    domain.CronActionNotify,
+			Channel: "slack",
+			Target:  "C42",
+			Message: "{{.Job.Name}} on {{.Now.Format \"2006\"}}",
+		},
+	})
+	if !run.Success || !run.Delivered {
+		t.Fatalf("run = %+v", run)
+	}
+	want := fmt.Sprintf("standup on %d", time.Now().Year())
+	if len(ch.sent) != 1 || ch.sent[0].Content != want || ch.sent[0].SessionID != "C42" {
+		t.Errorf("channel sent %+v, want %q to C42", ch.sent, want)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := &domain.CronRetryPolicy{MaxAttempts: 5, BackoffMs: 100, MaxBackoffMs: 300}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, w := range want {
+		if got := retryDelay(p, i+1); got != w {
+			t.Errorf("retryDelay(attempt %d) = %v, want %v", i+1, got, w)
+		}
+	}
+	if got := retryDelay(&domain.CronRetryPolicy{MaxAttempts: 2}, 1); got != time.Second {
+		t.Errorf("default delay = %v, want 1s", got)
+	}
+}
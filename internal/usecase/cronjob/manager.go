@@ -25,10 +25,17 @@ type MessageHandler interface {
 
 // Patch contains optional fields for updating a cron job.
 type Patch struct {
-	Name     *string              `json:"name,omitempty"`
-	Schedule *domain.CronSchedule `json:"schedule,omitempty"`
-	Message  *string              `json:"message,omitempty"`
-	Enabled  *bool                `json:"enabled,omitempty"`
+	Name     *string                 `json:"name,omitempty"`
+	Schedule *domain.CronSchedule    `json:"schedule,omitempty"`
+	Message  *string                 `json:"message,omitempty"`
+	Action   *domain.CronAction      `json:"action,omitempty"` // replaces the whole action
+	Retry    *domain.CronRetryPolicy `json:"retry,omitempty"`
+	Enabled  *bool                   `json:"enabled,omitempty"`
+
+	Misfire     *string `json:"misfire,omitempty"`
+	MaxCatchUp  *int    `json:"max_catch_up,omitempty"`
+	Concurrency *string `json:"concurrency,omitempty"`
+	JitterMs    *int64  `json:"jitter_ms,omitempty"`
 }
 
 // Manager orchestrates cron job CRUD, scheduling, and execution.
@@ -36,9 +43,16 @@ type Manager struct {
 	store     domain.CronStore
 	scheduler *scheduling.Scheduler
 	handler   MessageHandler
+	workflows WorkflowRunner
+	tools     domain.ToolExecutor
+	approver  domain.ToolApprover
+	channels  ChannelResolver
 	bus       domain.EventBus
 	logger    *slog.Logger
 	mu        sync.Mutex
+
+	runMu   sync.Mutex
+	running map[string]*activeRun // job ID → run, for forbid/replace policies
 }
 
 // NewManager creates a Manager. The handler can be set later via SetHandler.
@@ -48,6 +62,7 @@ func NewManager(store domain.CronStore, scheduler *scheduling.Scheduler, bus dom
 		scheduler: scheduler,
 		bus:       bus,
 		logger:    logger,
+		running:   make(map[string]*activeRun),
 	}
 }
 
@@ -59,6 +74,35 @@ func (m *Manager) SetHandler(handler MessageHandler) {
 	m.handler = handler
 }
 
+// SetWorkflows sets the pipeline runner for workflow_run actions.
+func (m *Manager) SetWorkflows(workflows WorkflowRunner) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.workflows = workflows
+}
+
+// SetTools sets the tool registry for tool_call actions.
+func (m *Manager) SetTools(tools domain.ToolExecutor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tools = tools
+}
+
+// SetApprover sets the approver that tool_call actions must pass, the same
+// one that gates the agent's own tool calls.
+func (m *Manager) SetApprover(approver domain.ToolApprover) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.approver = approver
+}
+
+// SetChannels sets the channels that job results are delivered to.
+func (m *Manager) SetChannels(channels ChannelResolver) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.channels = channels
+}
+
 // Create creates and schedules a new cron job.
 func (m *Manager) Create(ctx context.Context, job domain.CronJob) (*domain.CronJob, error) {
 	m.mu.Lock()
@@ -75,8 +119,11 @@ func (m *Manager) Create(ctx context.Context, job domain.CronJob) (*domain.CronJ
 	if err := validateCronSchedule(job.Schedule); err != nil {
 		return nil, domain.WrapOp("cronmanager", err)
 	}
-	if job.Action.Message == "" {
-		return nil, fmt.Errorf("cronmanager: action message is required")
+	if job.Action.Kind == "" {
+		job.Action.Kind = domain.CronActionAgentRun
+	}
+	if err := m.validateJob(job); err != nil {
+		return nil, err
 	}
 
 	if err := m.store.Save(ctx, job); err != nil {
@@ -89,12 +136,11 @@ func (m *Manager) Create(ctx context.Context, job domain.CronJob) (*domain.CronJ
 		return nil, fmt.Errorf("cronmanager: schedule: %w", err)
 	}
 
+	m.recordNextRun(ctx, &job)
+
 	m.emitEvent(ctx, domain.EventCronJobCreated, job)
 	m.logger.Info("cron job created", "id", job.ID, "name", job.Name)
 
-	next := m.scheduler.GetNextRun(job.ID)
-	job.NextRunAt = next
-
 	return &job, nil
 }
 
@@ -105,7 +151,7 @@ func (m *Manager) List(ctx context.Context) ([]domain.CronJob, error) {
 		return nil, err
 	}
 	for i := range jobs {
-		jobs[i].NextRunAt = m.scheduler.GetNextRun(jobs[i].ID)
+		jobs[i].NextRunAt = m.nextRunAt(jobs[i])
 	}
 	return jobs, nil
 }
@@ -116,7 +162,7 @@ func (m *Manager) Get(ctx context.Context, id string) (*domain.CronJob, error) {
 	if err != nil {
 		return nil, err
 	}
-	job.NextRunAt = m.scheduler.GetNextRun(id)
+	job.NextRunAt = m.nextRunAt(*job)
 	return job, nil
 }
 
@@ -135,9 +181,28 @@ func (m *Manager) Update(ctx context.Context, id string, patch Patch) (*domain.C
 	if patch.Name != nil {
 		job.Name = *patch.Name
 	}
+	if patch.Action != nil {
+		job.Action = *patch.Action
+	}
 	if patch.Message != nil {
 		job.Action.Message = *patch.Message
 	}
+	if patch.Retry != nil {
+		job.Retry = patch.Retry
+	}
+	if patch.Misfire != nil {
+		job.Misfire = *patch.Misfire
+	}
+	if patch.MaxCatchUp != nil {
+		job.MaxCatchUp = *patch.MaxCatchUp
+	}
+	if patch.Concurrency != nil {
+		job.Concurrency = *patch.Concurrency
+	}
+	if patch.JitterMs != nil && *patch.JitterMs != job.JitterMs {
+		job.JitterMs = *patch.JitterMs
+		scheduleChanged = true
+	}
 	if patch.Enabled != nil {
 		if *patch.Enabled != job.Enabled {
 			job.Enabled = *patch.Enabled
@@ -152,6 +217,13 @@ func (m *Manager) Update(ctx context.Context, id string, patch Patch) (*domain.C
 		scheduleChanged = true
 	}
 
+	if job.Action.Kind == "" {
+		job.Action.Kind = domain.CronActionAgentRun
+	}
+	if err := m.validateJob(*job); err != nil {
+		return nil, err
+	}
+
 	job.UpdatedAt = time.Now()
 
 	if err := m.store.Save(ctx, *job); err != nil {
@@ -169,10 +241,15 @@ func (m *Manager) Update(ctx context.Context, id string, patch Patch) (*domain.C
 		}
 	}
 
+	if scheduleChanged {
+		m.recordNextRun(ctx, job)
+	} else {
+		job.NextRunAt = m.nextRunAt(*job)
+	}
+
 	m.emitEvent(ctx, domain.EventCronJobUpdated, *job)
 	m.logger.Info("cron job updated", "id", id)
 
-	job.NextRunAt = m.scheduler.GetNextRun(id)
 	return job, nil
 }
 
@@ -183,6 +260,7 @@ func (m *Manager) Delete(ctx context.Context, id string) error {
 
 	// Remove from scheduler (ignore error if not scheduled).
 	m.scheduler.RemoveDynamicTask(id)
+	m.scheduler.RemoveDynamicTask(catchUpTaskID(id))
 
 	if err := m.store.Delete(ctx, id); err != nil {
 		return err
@@ -198,7 +276,9 @@ func (m *Manager) ListRuns(ctx context.Context, jobID string, limit int) ([]doma
 	return m.store.ListRuns(ctx, jobID, limit)
 }
 
-// LoadAndSchedule loads persisted jobs and schedules enabled ones.
+// LoadAndSchedule loads persisted jobs and schedules enabled ones. Runs
+// missed while the process was down are made up for as each job's misfire
+// policy allows, once the scheduler starts.
 // Should be called once during startup after SetHandler.
 func (m *Manager) LoadAndSchedule(ctx context.Context) error {
 	jobs, err := m.store.List(ctx)
@@ -206,19 +286,35 @@ func (m *Manager) LoadAndSchedule(ctx context.Context) error {
 		return fmt.Errorf("cronmanager: load: %w", err)
 	}
 
-	scheduled := 0
+	now := time.Now()
+	scheduled, caughtUp := 0, 0
 	for _, job := range jobs {
 		if !job.Enabled {
 			continue
 		}
 
-		// Skip expired one-shot ("at") jobs — disable them instead of scheduling.
+		catchUp := false
+		if n := catchUpRuns(job, now); n > 0 {
+			if err := m.scheduleCatchUp(job.ID, n); err != nil {
+				m.logger.Warn("failed to schedule missed cron runs", "id", job.ID, "error", err)
+			} else {
+				m.logger.Info("catching up missed cron runs", "id", job.ID, "runs", n)
+				catchUp = true
+				caughtUp++
+			}
+		}
+
+		// Expired one-shot ("at") jobs are not scheduled again. Without a
+		// catch-up run, disable them; the catch-up run disables them otherwise.
 		if job.Schedule.Kind == "at" {
-			if t, err := time.Parse(time.RFC3339, job.Schedule.At); err == nil && t.Before(time.Now()) {
-				job.Enabled = false
-				job.UpdatedAt = time.Now()
-				m.store.Save(ctx, job)
-				m.logger.Info("disabled expired one-shot job", "id", job.ID, "at", job.Schedule.At)
+			if t, err := time.Parse(time.RFC3339, job.Schedule.At); err == nil && t.Before(now) {
+				if !catchUp {
+					job.Enabled = false
+					job.UpdatedAt = now
+					job.NextRunAt = nil
+					m.store.Save(ctx, job)
+					m.logger.Info("disabled expired one-shot job", "id", job.ID, "at", job.Schedule.At)
+				}
 				continue
 			}
 		}
@@ -227,52 +323,109 @@ func (m *Manager) LoadAndSchedule(ctx context.Context) error {
 			m.logger.Warn("failed to schedule persisted job", "id", job.ID, "error", err)
 			continue
 		}
+		m.recordNextRun(ctx, &job)
 		scheduled++
 	}
 
-	m.logger.Info("cron jobs loaded", "total", len(jobs), "scheduled", scheduled)
+	m.logger.Info("cron jobs loaded", "total", len(jobs), "scheduled", scheduled, "catching_up", caughtUp)
 	return nil
 }
 
 // --- internal ---
 
+// validateJob checks the job's action and policies. Tool names are
+// checked against the registry when one is set.
+func (m *Manager) validateJob(job domain.CronJob) error {
+	if err := validateCronAction(job.Action); err != nil {
+		return domain.WrapOp("cronmanager", err)
+	}
+	if err := validateRetryPolicy(job.Retry); err != nil {
+		return domain.WrapOp("cronmanager", err)
+	}
+	if err := validateJobPolicies(job); err != nil {
+		return domain.WrapOp("cronmanager", err)
+	}
+	if job.Action.Kind == domain.CronActionToolCall && m.tools != nil {
+		if _, err := m.tools.Get(job.Action.Tool); err != nil {
+			return domain.WrapOp("cronmanager", err)
+		}
+	}
+	return nil
+}
+
 func (m *Manager) scheduleJob(job domain.CronJob) error {
 	sched, err := buildCronSchedule(job.Schedule)
 	if err != nil {
 		return err
 	}
+	if job.JitterMs > 0 {
+		sched = newJitterSchedule(sched, time.Duration(job.JitterMs)*time.Millisecond)
+	}
 
 	oneShot := job.Schedule.Kind == "at"
 	jobID := job.ID
 
 	return m.scheduler.AddDynamicTask(jobID, sched, func(ctx context.Context) error {
-		return m.executeJob(ctx, jobID)
+		return m.runJob(ctx, jobID, false)
 	}, oneShot)
 }
 
-func (m *Manager) executeJob(ctx context.Context, jobID string) error {
-	job, err := m.store.Get(ctx, jobID)
+// nextRunAt returns when job fires next: the scheduler's time once it is
+// running, else the next time on the job's schedule without jitter.
+func (m *Manager) nextRunAt(job domain.CronJob) *time.Time {
+	if !job.Enabled {
+		return nil
+	}
+	if next := m.scheduler.GetNextRun(job.ID); next != nil {
+		return next
+	}
+	sched, err := buildCronSchedule(job.Schedule)
 	if err != nil {
-		return fmt.Errorf("job %s not found: %w", jobID, err)
+		return nil
+	}
+	next := sched.Next(time.Now())
+	if next.IsZero() {
+		return nil
 	}
+	return &next
+}
 
-	start := time.Now()
-	var runErr error
+// recordNextRun stores when job fires next, so that runs missed while the
+// process is down can be found on the next start.
+func (m *Manager) recordNextRun(ctx context.Context, job *domain.CronJob) {
+	job.NextRunAt = m.nextRunAt(*job)
+	if err := m.store.Save(ctx, *job); err != nil {
+		m.logger.Warn("failed to save cron job next run", "id", job.ID, "error", err)
+	}
+}
 
-	m.mu.Lock()
-	handler := m.handler
-	m.mu.Unlock()
+// runJob runs a job under its concurrency policy.
+func (m *Manager) runJob(ctx context.Context, jobID string, catchUp bool) error {
+	job, err := m.store.Get(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("job %s not found: %w", jobID, err)
+	}
+	runCtx, release, ok := m.acquireRun(ctx, *job)
+	if !ok {
+		m.logger.Info("cron run skipped: previous run still going", "id", jobID)
+		return nil
+	}
+	defer release()
+	return m.executeJob(runCtx, jobID, catchUp)
+}
 
-	if handler == nil {
-		m.logger.Warn("cron job skipped: handler not set", "id", jobID)
-	} else if job.Action.Kind == "agent_run" {
-		_, runErr = handler.Handle(ctx, domain.InboundMessage{
-			SessionID:   "cron:" + jobID,
-			Content:     job.Action.Message,
-			ChannelName: "cron",
-		})
+func (m *Manager) executeJob(ctx context.Context, jobID string, catchUp bool) error {
+	job, err := m.store.Get(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("job %s not found: %w", jobID, err)
 	}
 
+	start := time.Now()
+	output, attempts, runErr := m.runWithRetry(ctx, *job)
+	var delivered bool
+	if runErr == nil {
+		delivered, runErr = m.deliverResult(ctx, *job, output)
+	}
 	duration := time.Since(start)
 
 	// Record the run.
@@ -281,9 +434,14 @@ func (m *Manager) executeJob(ctx context.Context, jobID string) error {
 		StartedAt: start,
 		Duration:  duration.String(),
 		Success:   runErr == nil,
+		Attempts:  attempts,
+		Output:    truncateOutput(output),
+		Delivered: delivered,
+		CatchUp:   catchUp,
 	}
 	if runErr != nil {
 		run.Error = runErr.Error()
+		m.logger.Warn("cron job failed", "id", jobID, "attempts", attempts, "error", runErr)
 	}
 	m.store.SaveRun(ctx, run)
 
@@ -295,6 +453,7 @@ func (m *Manager) executeJob(ctx context.Context, jobID string) error {
 	if job.Schedule.Kind == "at" {
 		job.Enabled = false
 	}
+	job.NextRunAt = m.nextRunAt(*job)
 	m.store.Save(ctx, *job)
 
 	m.emitEvent(ctx, domain.EventCronJobFired, run)
@@ -322,6 +481,9 @@ func (m *Manager) newID() string {
 
 // validateCronSchedule validates a CronSchedule value.
 func validateCronSchedule(s domain.CronSchedule) error {
+	if s.TimeZone != "" && s.Kind != "cron" {
+		return fmt.Errorf("'time_zone' only applies to schedule kind 'cron'")
+	}
 	switch s.Kind {
 	case "at":
 		if s.At == "" {
@@ -338,7 +500,12 @@ func validateCronSchedule(s domain.CronSchedule) error {
 		if s.Expression == "" {
 			return fmt.Errorf("schedule kind 'cron' requires 'expression' field")
 		}
-		if _, err := scheduling.ParseSchedule(s.Expression); err != nil {
+		if s.TimeZone != "" {
+			if _, err := time.LoadLocation(s.TimeZone); err != nil {
+				return fmt.Errorf("invalid time zone: %w", err)
+			}
+		}
+		if _, err := scheduling.ParseSchedule(cronSpec(s)); err != nil {
 			return fmt.Errorf("invalid cron expression: %w", err)
 		}
 	default:
@@ -360,12 +527,20 @@ func buildCronSchedule(s domain.CronSchedule) (cron.Schedule, error) {
 		dur := time.Duration(s.EveryMs) * time.Millisecond
 		return scheduling.NewConstantDelay(dur), nil
 	case "cron":
-		return scheduling.ParseSchedule(s.Expression)
+		return scheduling.ParseSchedule(cronSpec(s))
 	default:
 		return nil, fmt.Errorf("unknown schedule kind: %s", s.Kind)
 	}
 }
 
+// cronSpec returns the cron expression of s, prefixed with its time zone.
+func cronSpec(s domain.CronSchedule) string {
+	if s.TimeZone == "" {
+		return s.Expression
+	}
+	return "CRON_TZ=" + s.TimeZone + " " + s.Expression
+}
+
 // onceSchedule fires once at a specific time. Thread-safe via atomic.
 type onceSchedule struct {
 	at   time.Time
@@ -0,0 +1,201 @@
+package cronjob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"alfred-ai/internal/domain"
+)
+
+// Policy limits.
+const (
+	defaultMaxCatchUp = 10
+	maxCatchUp        = 100
+	maxJitter         = 24 * time.Hour
+)
+
+// validateJobPolicies checks the job's misfire, concurrency and jitter
+// settings.
+func validateJobPolicies(job domain.CronJob) error {
+	switch job.Misfire {
+	case "", domain.CronMisfireSkip, domain.CronMisfireRunOnce, domain.CronMisfireRunAll:
+	default:
+		return fmt.Errorf("unknown misfire policy %q (want: skip, run_once, run_all)", job.Misfire)
+	}
+	if job.MaxCatchUp < 0 || job.MaxCatchUp > maxCatchUp {
+		return fmt.Errorf("max_catch_up must be between 0 and %d", maxCatchUp)
+	}
+	switch job.Concurrency {
+	case "", domain.CronConcurrencyAllow, domain.CronConcurrencyForbid, domain.CronConcurrencyReplace:
+	default:
+		return fmt.Errorf("unknown concurrency policy %q (want: allow, forbid, replace)", job.Concurrency)
+	}
+	if job.JitterMs < 0 || time.Duration(job.JitterMs)*time.Millisecond > maxJitter {
+		return fmt.Errorf("jitter_ms must be between 0 and %d", maxJitter.Milliseconds())
+	}
+	return nil
+}
+
+// catchUpRuns returns how many runs to make up for job's runs missed
+// before now, as its misfire policy allows.
+func catchUpRuns(job domain.CronJob, now time.Time) int {
+	limit := 0
+	switch job.Misfire {
+	case domain.CronMisfireRunOnce:
+		limit = 1
+	case domain.CronMisfireRunAll:
+		limit = job.MaxCatchUp
+		if limit <= 0 {
+			limit = defaultMaxCatchUp
+		}
+	}
+	if limit == 0 {
+		return 0
+	}
+	// A fresh schedule: Next has side effects on one-shot schedules.
+	sched, err := buildCronSchedule(job.Schedule)
+	if err != nil {
+		return 0
+	}
+	return missedRuns(job, sched, now, limit)
+}
+
+// missedRuns counts the times sched was due between job's last expected
+// run and now, up to limit. The stored NextRunAt marks the first run the
+// process was not around for; without it the count starts after the last
+// run or, for jobs that never ran, after creation.
+func missedRuns(job domain.CronJob, sched cron.Schedule, now time.Time, limit int) int {
+	var next time.Time
+	switch {
+	case job.NextRunAt != nil:
+		next = *job.NextRunAt
+	case job.LastRunAt != nil:
+		next = sched.Next(*job.LastRunAt)
+	default:
+		next = sched.Next(job.CreatedAt)
+	}
+	n := 0
+	for n < limit && !next.IsZero() && next.Before(now) {
+		n++
+		next = sched.Next(next)
+	}
+	return n
+}
+
+// catchUpTaskID names the one-shot scheduler task that makes up missed runs.
+func catchUpTaskID(jobID string) string { return jobID + ":catchup" }
+
+// scheduleCatchUp runs job n times, one after another, as soon as the
+// scheduler is running.
+func (m *Manager) scheduleCatchUp(jobID string, n int) error {
+	return m.scheduler.AddDynamicTask(catchUpTaskID(jobID), &immediateSchedule{}, func(ctx context.Context) error {
+		var errs []error
+		for range n {
+			if ctx.Err() != nil {
+				errs = append(errs, ctx.Err())
+				break
+			}
+			if err := m.runJob(ctx, jobID, true); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}, true)
+}
+
+// activeRun is a run of a job whose concurrency policy needs tracking.
+type activeRun struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// acquireRun applies job's concurrency policy before a run. It returns
+// the context to run with and a release func to call when done, or false
+// if the run must be skipped.
+func (m *Manager) acquireRun(ctx context.Context, job domain.CronJob) (context.Context, func(), bool) {
+	if job.Concurrency == "" || job.Concurrency == domain.CronConcurrencyAllow {
+		return ctx, func() {}, true
+	}
+	for {
+		m.runMu.Lock()
+		prev, busy := m.running[job.ID]
+		if !busy {
+			runCtx, cancel := context.WithCancel(ctx)
+			run := &activeRun{cancel: cancel, done: make(chan struct{})}
+			m.running[job.ID] = run
+			m.runMu.Unlock()
+			return runCtx, func() {
+				cancel()
+				m.runMu.Lock()
+				delete(m.running, job.ID)
+				m.runMu.Unlock()
+				close(run.done)
+			}, true
+		}
+		m.runMu.Unlock()
+
+		if job.Concurrency == domain.CronConcurrencyForbid {
+			return ctx, nil, false
+		}
+		prev.cancel()
+		select {
+		case <-prev.done:
+		case <-ctx.Done():
+			return ctx, nil, false
+		}
+	}
+}
+
+// immediateSchedule fires once, as soon as the scheduler asks for it.
+type immediateSchedule struct {
+	done atomic.Bool
+}
+
+func (s *immediateSchedule) Next(t time.Time) time.Time {
+	if s.done.Swap(true) {
+		return time.Time{}
+	}
+	return t
+}
+
+// jitterSchedule delays each time of the wrapped schedule by a random
+// amount up to spread. Times are computed from the undelayed ones, so
+// intervals do not drift.
+type jitterSchedule struct {
+	base   cron.Schedule
+	spread time.Duration
+
+	mu   sync.Mutex
+	last time.Time // last undelayed time handed out
+}
+
+func newJitterSchedule(base cron.Schedule, spread time.Duration) *jitterSchedule {
+	return &jitterSchedule{base: base, spread: spread}
+}
+
+func (s *jitterSchedule) Next(t time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from := t
+	if !s.last.IsZero() && s.last.Before(t) {
+		from = s.last
+	}
+	next := s.base.Next(from)
+	// Skip times the previous delay already overran.
+	for !next.IsZero() && next.Before(t) {
+		next = s.base.Next(next)
+	}
+	if next.IsZero() {
+		return next
+	}
+	s.last = next
+	return next.Add(rand.N(s.spread + 1))
+}
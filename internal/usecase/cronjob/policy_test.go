@@ -0,0 +1,241 @@
+package cronjob
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"alfred-ai/internal/domain"
+	"alfred-ai/internal/usecase/scheduling"
+)
+
+func TestCatchUpRuns(t *testing.T) {
+	now := time.Now()
+	missedSince := now.Add(-3*time.Hour - 30*time.Minute)
+	hourly := domain.CronJob{
+		Schedule:  domain.CronSchedule{Kind: "every", EveryMs: time.Hour.Milliseconds()},
+		NextRunAt: &missedSince, // due at -3h30m, -2h30m, -1h30m and -30m
+	}
+
+	tests := []struct {
+		name       string
+		misfire    string
+		maxCatchUp int
+		want       int
+	}{
+		{"default skips", "", 0, 0},
+		{"skip", domain.CronMisfireSkip, 0, 0},
+		{"run once", domain.CronMisfireRunOnce, 0, 1},
+		{"run all", domain.CronMisfireRunAll, 0, 4},
+		{"run all capped", domain.CronMisfireRunAll, 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := hourly
+			job.Misfire, job.MaxCatchUp = tt.misfire, tt.maxCatchUp
+			if got := catchUpRuns(job, now); got != tt.want {
+				t.Errorf("catchUpRuns = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	// Without a stored next run, counting starts after the last run.
+	lastRun := now.Add(-90 * time.Minute)
+	job := domain.CronJob{
+		Schedule:  hourly.Schedule,
+		Misfire:   domain.CronMisfireRunAll,
+		LastRunAt: &lastRun,
+	}
+	if got := catchUpRuns(job, now); got != 1 {
+		t.Errorf("catchUpRuns after last run = %d, want 1", got)
+	}
+}
+
+func TestLoadAndScheduleCatchUp(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	missedSince := time.Now().Add(-150 * time.Minute)
+	expiredAt := time.Now().Add(-time.Hour)
+	jobs := []domain.CronJob{
+		{
+			ID:        "hourly",
+			Schedule:  domain.CronSchedule{Kind: "every", EveryMs: time.Hour.Milliseconds()},
+			Action:    domain.CronAction{Kind: domain.CronActionAgentRun, Message: "brief"},
+			Misfire:   domain.CronMisfireRunAll,
+			Enabled:   true,
+			NextRunAt: &missedSince,
+		},
+		{
+			ID:       "reminder",
+			Schedule: domain.CronSchedule{Kind: "at", At: expiredAt.Format(time.RFC3339)},
+			Action:   domain.CronAction{Kind: domain.CronActionAgentRun, Message: "call mom"},
+			Misfire:  domain.CronMisfireRunOnce,
+			Enabled:  true,
+		},
+	}
+	for _, job := range jobs {
+		if err := store.Save(ctx, job); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	sched := scheduling.NewScheduler(newTestLogger())
+	mgr := NewManager(store, sched, nil, newTestLogger())
+	mgr.SetHandler(&stubHandler{reply: "done"})
+	if err := mgr.LoadAndSchedule(ctx); err != nil {
+		t.Fatalf("LoadAndSchedule: %v", err)
+	}
+
+	// The next run is recorded before the scheduler starts.
+	job, _ := store.Get(ctx, "hourly")
+	if job.NextRunAt == nil || !job.NextRunAt.After(time.Now()) {
+		t.Errorf("NextRunAt = %v, want a future time", job.NextRunAt)
+	}
+
+	sched.Start(ctx)
+	t.Cleanup(func() { sched.Stop() })
+
+	waitForRuns(t, store, "hourly", 3)
+	waitForRuns(t, store, "reminder", 1)
+
+	runs, _ := store.ListRuns(ctx, "hourly", 0)
+	for _, run := range runs {
+		if !run.CatchUp || !run.Success {
+			t.Errorf("run = %+v, want successful catch-up", run)
+		}
+	}
+	reminder, _ := store.Get(ctx, "reminder")
+	if reminder.Enabled || reminder.NextRunAt != nil {
+		t.Errorf("one-shot job after catch-up = %+v, want disabled", reminder)
+	}
+}
+
+func waitForRuns(t *testing.T, store *FileStore, jobID string, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		runs, _ := store.ListRuns(context.Background(), jobID, 0)
+		if len(runs) >= want {
+			if len(runs) > want {
+				t.Errorf("%s: got %d runs, want %d", jobID, len(runs), want)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: got %d runs, want %d", jobID, len(runs), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAcquireRunConcurrency(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	ctx := context.Background()
+
+	forbid := domain.CronJob{ID: "f", Concurrency: domain.CronConcurrencyForbid}
+	_, release, ok := mgr.acquireRun(ctx, forbid)
+	if !ok {
+		t.Fatal("first run should start")
+	}
+	if _, _, ok := mgr.acquireRun(ctx, forbid); ok {
+		t.Error("forbid: second run should be skipped")
+	}
+	release()
+	if _, release, ok := mgr.acquireRun(ctx, forbid); !ok {
+		t.Error("forbid: run after release should start")
+	} else {
+		release()
+	}
+
+	replace := domain.CronJob{ID: "r", Concurrency: domain.CronConcurrencyReplace}
+	firstCtx, firstRelease, _ := mgr.acquireRun(ctx, replace)
+	go func() {
+		<-firstCtx.Done()
+		firstRelease()
+	}()
+	secondCtx, secondRelease, ok := mgr.acquireRun(ctx, replace)
+	if !ok {
+		t.Fatal("replace: second run should start")
+	}
+	defer secondRelease()
+	if firstCtx.Err() == nil {
+		t.Error("replace: first run should be cancelled")
+	}
+	if secondCtx.Err() != nil {
+		t.Error("replace: second run should not be cancelled")
+	}
+
+	allow := domain.CronJob{ID: "a"}
+	for range 2 {
+		if _, _, ok := mgr.acquireRun(ctx, allow); !ok {
+			t.Error("allow: runs should overlap")
+		}
+	}
+}
+
+func TestJitterSchedule(t *testing.T) {
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	spread := 10 * time.Minute
+	sched := newJitterSchedule(scheduling.NewConstantDelay(time.Hour), spread)
+
+	fire := start
+	for i := 1; i <= 5; i++ {
+		fire = sched.Next(fire)
+		nominal := start.Add(time.Duration(i) * time.Hour)
+		if fire.Before(nominal) || fire.After(nominal.Add(spread)) {
+			t.Fatalf("run %d at %v, want within %v of %v", i, fire, spread, nominal)
+		}
+	}
+}
+
+func TestCronScheduleTimeZone(t *testing.T) {
+	s := domain.CronSchedule{Kind: "cron", Expression: "0 9 * * *", TimeZone: "Asia/Tokyo"}
+	if err := validateCronSchedule(s); err != nil {
+		t.Fatalf("validateCronSchedule: %v", err)
+	}
+	sched, err := buildCronSchedule(s)
+	if err != nil {
+		t.Fatalf("buildCronSchedule: %v", err)
+	}
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	next := sched.Next(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)).In(tokyo)
+	if next.Hour() != 9 || next.Minute() != 0 {
+		t.Errorf("next = %v, want 09:00 Tokyo time", next)
+	}
+
+	for _, bad := range []domain.CronSchedule{
+		{Kind: "cron", Expression: "0 9 * * *", TimeZone: "Mars/Olympus"},
+		{Kind: "every", EveryMs: 60000, TimeZone: "UTC"},
+	} {
+		if err := validateCronSchedule(bad); err == nil {
+			t.Errorf("validateCronSchedule(%+v): expected error", bad)
+		}
+	}
+}
+
+func TestValidateJobPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		job     domain.CronJob
+		wantErr bool
+	}{
+		{"defaults", domain.CronJob{}, false},
+		{"all set", domain.CronJob{Misfire: "run_all", MaxCatchUp: 5, Concurrency: "replace", JitterMs: 30000}, false},
+		{"bad misfire", domain.CronJob{Misfire: "later"}, true},
+		{"catch-up too high", domain.CronJob{MaxCatchUp: 101}, true},
+		{"bad concurrency", domain.CronJob{Concurrency: "queue"}, true},
+		{"negative jitter", domain.CronJob{JitterMs: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateJobPolicies(tt.job); (err != nil) != tt.wantErr {
+				t.Errorf("validateJobPolicies error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -11,16 +12,37 @@ import (
 
 const curateSystemPrompt = `You are a knowledge extraction assistant. Analyze the conversation and extract important facts, preferences, decisions, or technical knowledge worth remembering long-term.
 
-For each piece of knowledge, output exactly in this format:
-POINT: <concise fact or preference>
-TAGS: <comma-separated tags>
+Return each piece of knowledge as a point with its content and tags.
 
 Rules:
 - Only extract genuinely useful, long-term knowledge
 - Skip greetings, small talk, and transient information
-- Each POINT should be self-contained and understandable without context
+- Each point should be self-contained and understandable without context
 - Tags should be lowercase, relevant keywords
-- If the conversation contains nothing worth remembering, respond with exactly: NONE`
+- If the conversation contains nothing worth remembering, return an empty list of points`
+
+// curateResponseFormat is the JSON the curation reply must match.
+var curateResponseFormat = &domain.ResponseFormat{
+	Type: domain.ResponseFormatJSONSchema,
+	Name: "knowledge_points",
+	Schema: json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"points": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {
+						"content": {"type": "string"},
+						"tags": {"type": "array", "items": {"type": "string"}}
+					},
+					"required": ["content", "tags"]
+				}
+			}
+		},
+		"required": ["points"]
+	}`),
+}
 
 // CuratorOption configures a Curator.
 type CuratorOption func(*Curator)
@@ -77,7 +99,8 @@ func (c *Curator) CurateConversation(ctx context.Context, messages []domain.Mess
 			{Role: domain.RoleSystem, Content: curateSystemPrompt},
 			{Role: domain.RoleUser, Content: conversationText},
 		},
-		Temperature: 0.3,
+		Temperature:    0.3,
+		ResponseFormat: curateResponseFormat,
 	}
 
 	resp, err := c.llm.Chat(ctx, req)
@@ -86,7 +109,10 @@ func (c *Curator) CurateConversation(ctx context.Context, messages []domain.Mess
 	}
 
 	// Parse LLM response
-	points := parseCurateResponse(resp.Message.Content)
+	points, err := parseCurateResponse(resp.Message.Content)
+	if err != nil {
+		return nil, domain.NewDomainError("Curator.CurateConversation", domain.ErrCurateFailed, err.Error())
+	}
 	if len(points) == 0 {
 		return &domain.CurateResult{Skipped: 1, Summary: "no knowledge extracted"}, nil
 	}
@@ -130,40 +156,43 @@ type curatePoint struct {
 	tags    []string
 }
 
-// parseCurateResponse parses the LLM's POINT:/TAGS: format output.
-func parseCurateResponse(response string) []curatePoint {
+// parseCurateResponse parses the LLM's curateResponseFormat reply. An
+// empty reply holds no points.
+func parseCurateResponse(response string) ([]curatePoint, error) {
 	response = strings.TrimSpace(response)
-	if response == "NONE" || response == "" {
-		return nil
+	if response == "" {
+		return nil, nil
+	}
+
+	var reply struct {
+		Points []struct {
+			Content string   `json:"content"`
+			Tags    []string `json:"tags"`
+		} `json:"points"`
+	}
+	if err := json.Unmarshal([]byte(response), &reply); err != nil {
+		return nil, fmt.Errorf("parse curation reply: %w", err)
 	}
 
 	var points []curatePoint
-	lines := strings.Split(response, "\n")
-
-	var currentContent string
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "POINT:") {
-			currentContent = strings.TrimSpace(strings.TrimPrefix(line, "POINT:"))
-		} else if strings.HasPrefix(line, "TAGS:") && currentContent != "" {
-			tagsRaw := strings.TrimSpace(strings.TrimPrefix(line, "TAGS:"))
-			tags := parseTags(tagsRaw)
-			points = append(points, curatePoint{
-				content: currentContent,
-				tags:    tags,
-			})
-			currentContent = ""
+	for _, p := range reply.Points {
+		content := strings.TrimSpace(p.Content)
+		if content == "" {
+			continue
 		}
+		points = append(points, curatePoint{
+			content: content,
+			tags:    normalizeTags(p.Tags),
+		})
 	}
 
-	return points
+	return points, nil
 }
 
-// parseTags splits comma-separated tags, trims whitespace, lowercases.
-func parseTags(s string) []string {
-	parts := strings.Split(s, ",")
+// normalizeTags trims whitespace, lowercases, and drops empty tags.
+func normalizeTags(raw []string) []string {
 	var tags []string
-	for _, p := range parts {
+	for _, p := range raw {
 		tag := strings.TrimSpace(strings.ToLower(p))
 		if tag != "" {
 			tags = append(tags, tag)
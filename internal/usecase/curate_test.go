@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -13,9 +14,11 @@ import (
 // stubLLM is a minimal LLM provider for testing curation.
 type stubLLM struct {
 	response string
+	lastReq  domain.ChatRequest
 }
 
-func (s *stubLLM) Chat(_ context.Context, _ domain.ChatRequest) (*domain.ChatResponse, error) {
+func (s *stubLLM) Chat(_ context.Context, req domain.ChatRequest) (*domain.ChatResponse, error) {
+	s.lastReq = req
 	return &domain.ChatResponse{
 		Message: domain.Message{
 			Role:    domain.RoleAssistant,
@@ -57,10 +60,10 @@ func curateTestLogger() *slog.Logger {
 func TestCurator_ExtractKnowledge(t *testing.T) {
 	mem := &stubMemory{}
 	llm := &stubLLM{
-		response: `POINT: User prefers Go with clean architecture pattern
-TAGS: golang, architecture, clean-architecture
-POINT: Project uses PostgreSQL for the main database
-TAGS: postgresql, database`,
+		response: `{"points": [
+			{"content": "User prefers Go with clean architecture pattern", "tags": ["golang", "architecture", "clean-architecture"]},
+			{"content": "Project uses PostgreSQL for the main database", "tags": ["postgresql", "database"]}
+		]}`,
 	}
 
 	curator := NewCurator(mem, llm, curateTestLogger())
@@ -91,9 +94,9 @@ TAGS: postgresql, database`,
 	}
 }
 
-func TestCurator_NONEResponse(t *testing.T) {
+func TestCurator_NoPointsResponse(t *testing.T) {
 	mem := &stubMemory{}
-	llm := &stubLLM{response: "NONE"}
+	llm := &stubLLM{response: `{"points": []}`}
 
 	curator := NewCurator(mem, llm, curateTestLogger())
 	ctx := context.Background()
@@ -138,20 +141,21 @@ func TestParseCurateResponse(t *testing.T) {
 		name     string
 		input    string
 		expected int
+		wantErr  bool
 	}{
 		{
 			name:     "single point",
-			input:    "POINT: User likes Go\nTAGS: golang",
+			input:    `{"points": [{"content": "User likes Go", "tags": ["golang"]}]}`,
 			expected: 1,
 		},
 		{
 			name:     "multiple points",
-			input:    "POINT: Fact one\nTAGS: tag1\nPOINT: Fact two\nTAGS: tag2, tag3",
+			input:    `{"points": [{"content": "Fact one", "tags": ["tag1"]}, {"content": "Fact two", "tags": ["tag2", "tag3"]}]}`,
 			expected: 2,
 		},
 		{
-			name:     "NONE response",
-			input:    "NONE",
+			name:     "no points",
+			input:    `{"points": []}`,
 			expected: 0,
 		},
 		{
@@ -160,15 +164,23 @@ func TestParseCurateResponse(t *testing.T) {
 			expected: 0,
 		},
 		{
-			name:     "malformed - no TAGS",
-			input:    "POINT: Missing tags line\nSomething else",
+			name:     "blank content skipped",
+			input:    `{"points": [{"content": " ", "tags": ["x"]}]}`,
 			expected: 0,
 		},
+		{
+			name:    "not JSON",
+			input:   "POINT: Missing tags line\nSomething else",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			points := parseCurateResponse(tt.input)
+			points, err := parseCurateResponse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
 			if len(points) != tt.expected {
 				t.Errorf("expected %d points, got %d", tt.expected, len(points))
 			}
@@ -176,8 +188,8 @@ func TestParseCurateResponse(t *testing.T) {
 	}
 }
 
-func TestParseTags(t *testing.T) {
-	tags := parseTags("  Golang , Architecture , clean-architecture  ")
+func TestNormalizeTags(t *testing.T) {
+	tags := normalizeTags([]string{"  Golang ", " Architecture", "", "clean-architecture  "})
 	if len(tags) != 3 {
 		t.Fatalf("expected 3 tags, got %d", len(tags))
 	}
@@ -244,9 +256,9 @@ func TestCuratorLLMError(t *testing.T) {
 	}
 }
 
-func TestCuratorNONEResponse(t *testing.T) {
+func TestCuratorNoPointsResponse(t *testing.T) {
 	llm := &mockLLM{responses: []domain.ChatResponse{
-		{Message: domain.Message{Role: domain.RoleAssistant, Content: "NONE"}},
+		{Message: domain.Message{Role: domain.RoleAssistant, Content: `{"points": []}`}},
 	}}
 	c := NewCurator(&mockMemory{}, llm, newTestLogger())
 	msgs := []domain.Message{
@@ -264,7 +276,7 @@ func TestCuratorNONEResponse(t *testing.T) {
 
 func TestCuratorExtractsKnowledge(t *testing.T) {
 	llm := &mockLLM{responses: []domain.ChatResponse{
-		{Message: domain.Message{Role: domain.RoleAssistant, Content: "POINT: User prefers Go\nTAGS: golang, preference\nPOINT: User uses vim\nTAGS: editor, vim"}},
+		{Message: domain.Message{Role: domain.RoleAssistant, Content: `{"points": [{"content": "User prefers Go", "tags": ["golang", "preference"]}, {"content": "User uses vim", "tags": ["editor", "vim"]}]}`}},
 	}}
 	c := NewCurator(&mockMemory{}, llm, newTestLogger())
 	msgs := []domain.Message{
@@ -282,7 +294,7 @@ func TestCuratorExtractsKnowledge(t *testing.T) {
 
 func TestCuratorStoreError(t *testing.T) {
 	llm := &mockLLM{responses: []domain.ChatResponse{
-		{Message: domain.Message{Role: domain.RoleAssistant, Content: "POINT: fact\nTAGS: test"}},
+		{Message: domain.Message{Role: domain.RoleAssistant, Content: `{"points": [{"content": "fact", "tags": ["test"]}]}`}},
 	}}
 	mem := &errorStoreMemory{}
 	c := NewCurator(mem, llm, newTestLogger())
@@ -299,21 +311,26 @@ func TestCuratorStoreError(t *testing.T) {
 }
 
 func TestParseCurateResponseEmpty(t *testing.T) {
-	points := parseCurateResponse("")
-	if len(points) != 0 {
-		t.Errorf("expected 0 points, got %d", len(points))
+	points, err := parseCurateResponse("")
+	if err != nil || len(points) != 0 {
+		t.Errorf("expected 0 points, got %d (err %v)", len(points), err)
 	}
 }
 
-func TestParseCurateResponseNONE(t *testing.T) {
-	points := parseCurateResponse("NONE")
-	if len(points) != 0 {
-		t.Errorf("expected 0 points, got %d", len(points))
+func TestCuratorRequestsStructuredOutput(t *testing.T) {
+	llm := &stubLLM{response: "POINT: fact\nTAGS: test"}
+	c := NewCurator(&mockMemory{}, llm, newTestLogger())
+	_, err := c.CurateConversation(context.Background(), []domain.Message{{Role: domain.RoleUser, Content: "test"}})
+	if !errors.Is(err, domain.ErrCurateFailed) {
+		t.Errorf("err = %v, want ErrCurateFailed for a non-JSON reply", err)
+	}
+	if f := llm.lastReq.ResponseFormat; f == nil || f.Type != domain.ResponseFormatJSONSchema {
+		t.Errorf("ResponseFormat = %+v, want a JSON schema", f)
 	}
 }
 
-func TestParseTagsMultiple(t *testing.T) {
-	tags := parseTags("go, rust, python")
+func TestNormalizeTagsMultiple(t *testing.T) {
+	tags := normalizeTags([]string{"go", "rust", "python"})
 	if len(tags) != 3 {
 		t.Errorf("expected 3 tags, got %d", len(tags))
 	}
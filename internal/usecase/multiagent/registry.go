@@ -1,9 +1,12 @@
 package multiagent
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"sort"
 	"sync"
+	"time"
 
 	"alfred-ai/internal/domain"
 	"alfred-ai/internal/usecase"
@@ -109,3 +112,18 @@ func (r *Registry) Lookup() func(agentID string) (*usecase.Agent, *usecase.Sessi
 		return inst.Agent, inst.Sessions, nil
 	}
 }
+
+// RunAgent sends prompt to agentID (the default agent when empty) in a
+// fresh session and returns the reply. A non-nil tools list narrows the
+// agent's tools to those names for the call; an empty one leaves it none.
+func (r *Registry) RunAgent(ctx context.Context, agentID, prompt string, tools []string) (string, error) {
+	if agentID == "" {
+		agentID = r.defaultID
+	}
+	inst, err := r.Get(agentID)
+	if err != nil {
+		return "", fmt.Errorf("agent %q: %w", agentID, err)
+	}
+	session := usecase.NewSession(fmt.Sprintf("workflow|%s|%d", inst.Identity.ID, time.Now().UnixNano()))
+	return inst.Agent.WithTools(tools).HandleMessage(ctx, session, prompt)
+}
@@ -1,6 +1,8 @@
 package multiagent
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -142,3 +144,25 @@ func TestRegistryConcurrent(t *testing.T) {
 		t.Error("expected some agents after concurrent registration")
 	}
 }
+
+func TestRegistryRunAgent(t *testing.T) {
+	reg := NewRegistry("main", slog.Default())
+	reg.Register(makeAgentInstance("main", "main response"))
+	reg.Register(makeAgentInstance("writer", "a summary"))
+
+	got, err := reg.RunAgent(context.Background(), "writer", "summarize", []string{"web_fetch"})
+	if err != nil {
+		t.Fatalf("RunAgent: %v", err)
+	}
+	if got != "a summary" {
+		t.Errorf("RunAgent(writer) = %q, want %q", got, "a summary")
+	}
+
+	if got, err = reg.RunAgent(context.Background(), "", "hi", nil); err != nil || got != "main response" {
+		t.Errorf("RunAgent(default) = %q, %v; want the default agent's reply", got, err)
+	}
+
+	if _, err := reg.RunAgent(context.Background(), "ghost", "hi", nil); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("RunAgent(ghost) error = %v, want ErrNotFound", err)
+	}
+}
@@ -5,17 +5,45 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"alfred-ai/internal/domain"
 )
 
+// offlineHistoryLimit caps the session messages sent to the local LLM.
+const offlineHistoryLimit = 20
+
+// offlineMaxAttempts is how often a queued message is replayed before it
+// is dead-lettered.
+const offlineMaxAttempts = 5
+
+// offlineDeadLetterDir is the queue subdirectory holding messages that
+// could not be replayed.
+const offlineDeadLetterDir = "dead"
+
+// offlineReplayKey marks a replayed message in InboundMessage.Metadata with
+// its queue ID.
+const offlineReplayKey = "offline_replay_id"
+
+// ReplayHandler runs a message through the agent, as Router.Handle does.
+type ReplayHandler func(ctx context.Context, msg domain.InboundMessage) (domain.OutboundMessage, error)
+
+// ChannelResolver looks up the channels replayed answers are delivered to.
+type ChannelResolver interface {
+	Get(name string) (domain.Channel, error)
+}
+
 // OfflineManager detects network connectivity and provides fallback to a local
-// LLM provider when the primary (cloud) provider is unreachable.
+// LLM provider when the primary (cloud) provider is unreachable. Messages
+// answered offline are queued and replayed through the agent once
+// connectivity returns.
 type OfflineManager struct {
 	localLLM    domain.LLMProvider
 	queue       *MessageQueue
@@ -23,6 +51,9 @@ type OfflineManager struct {
 	checkURL    string
 	checkPeriod time.Duration
 	logger      *slog.Logger
+	replay      ReplayHandler   // optional; nil = queued messages are dropped on sync
+	channels    ChannelResolver // optional; nil = replayed answers are not delivered
+	syncMu      sync.Mutex
 }
 
 // NewOfflineManager creates an OfflineManager.
@@ -39,49 +70,167 @@ func NewOfflineManager(localLLM domain.LLMProvider, queueDir, checkURL string, c
 	return o
 }
 
+// SetReplay sets the handler queued messages are replayed through on sync.
+func (o *OfflineManager) SetReplay(h ReplayHandler) { o.replay = h }
+
+// SetChannels sets the channels replayed answers are delivered to.
+func (o *OfflineManager) SetChannels(c ChannelResolver) { o.channels = c }
+
 // IsOnline returns the current connectivity status.
 func (o *OfflineManager) IsOnline() bool {
 	return o.isOnline.Load()
 }
 
-// HandleOffline processes a message using the local LLM when offline.
-func (o *OfflineManager) HandleOffline(ctx context.Context, sessionID string, msg domain.InboundMessage) (string, error) {
+// HandleOffline answers a message with the local LLM when offline, using
+// the session's history as context, and queues the message for replay. The
+// offline exchange is kept in the session so the agent sees it on replay.
+func (o *OfflineManager) HandleOffline(ctx context.Context, session *Session, msg domain.InboundMessage) (string, error) {
 	// Queue the message for later sync.
 	if err := o.queue.Enqueue(QueuedMessage{
-		SessionID: sessionID,
+		SessionID: session.ExternalKey,
 		Content:   msg.Content,
 		Sender:    msg.SenderName,
 		Channel:   msg.ChannelName,
 		QueuedAt:  time.Now(),
+		Message:   &msg,
 	}); err != nil {
 		o.logger.Warn("failed to queue message", "error", err)
 	}
 
+	// The failed agent call normally recorded the user message already.
+	history := session.Messages()
+	if n := len(history); n == 0 || history[n-1].Role != domain.RoleUser || history[n-1].Content != msg.Content {
+		session.AddMessage(domain.Message{Role: domain.RoleUser, Content: msg.Content})
+		history = session.Messages()
+	}
+
 	// Use local LLM for immediate response.
 	resp, err := o.localLLM.Chat(ctx, domain.ChatRequest{
-		Messages: []domain.Message{
-			{Role: domain.RoleSystem, Content: "You are an AI assistant running in offline mode. Some features may be limited."},
-			{Role: domain.RoleUser, Content: msg.Content},
-		},
+		Messages: offlinePrompt(history),
 	})
 	if err != nil {
 		return "", fmt.Errorf("offline LLM: %w", err)
 	}
+	session.AddMessage(domain.Message{Role: domain.RoleAssistant, Content: resp.Message.Content})
 	return resp.Message.Content, nil
 }
 
-// Sync flushes queued messages. Called when connectivity is restored.
+// offlinePrompt builds the local LLM conversation from the session history.
+// Tool traffic is left out: the local model runs without tools.
+func offlinePrompt(history []domain.Message) []domain.Message {
+	msgs := []domain.Message{
+		{Role: domain.RoleSystem, Content: "You are an AI assistant running in offline mode. Some features may be limited."},
+	}
+	var convo []domain.Message
+	for _, m := range history {
+		if (m.Role == domain.RoleUser || m.Role == domain.RoleAssistant) && m.Content != "" && len(m.ToolCalls) == 0 {
+			convo = append(convo, domain.Message{Role: m.Role, Content: m.Content})
+		}
+	}
+	if len(convo) > offlineHistoryLimit {
+		convo = convo[len(convo)-offlineHistoryLimit:]
+	}
+	return append(msgs, convo...)
+}
+
+// Sync replays queued messages in order through the replay handler into
+// their original sessions and delivers the answers to their channels.
+// Called when connectivity is restored. Each message stays queued until its
+// answer is delivered, and the answer is recorded before delivery, so a
+// crash midway resumes where it stopped without running the agent twice.
+// Sync stops at the first message the agent cannot handle, keeping it and
+// later messages for the next attempt. A message that fails
+// offlineMaxAttempts times is dead-lettered and Sync moves on, so a message
+// that can never succeed does not hold up the rest.
 func (o *OfflineManager) Sync(ctx context.Context) error {
-	msgs, err := o.queue.Drain()
+	o.syncMu.Lock()
+	defer o.syncMu.Unlock()
+
+	if o.replay == nil {
+		msgs, err := o.queue.Drain()
+		if err != nil {
+			return fmt.Errorf("drain queue: %w", err)
+		}
+		if len(msgs) > 0 {
+			o.logger.Info("synced offline messages", "count", len(msgs))
+		}
+		return nil
+	}
+
+	msgs, err := o.queue.Pending()
 	if err != nil {
-		return fmt.Errorf("drain queue: %w", err)
+		return fmt.Errorf("read queue: %w", err)
+	}
+	for _, qm := range msgs {
+		if qm.Reply == nil {
+			out, err := o.replay(ctx, qm.replayMessage())
+			if err != nil {
+				if ctx.Err() != nil {
+					return fmt.Errorf("replay %s: %w", qm.ID, err)
+				}
+				if err := o.replayFailed(qm, err); err != nil {
+					return err
+				}
+				continue
+			}
+			qm.Reply = &out
+			if err := o.queue.Update(qm); err != nil {
+				return fmt.Errorf("record replay %s: %w", qm.ID, err)
+			}
+		}
+		o.deliver(ctx, qm)
+		if err := o.queue.Remove(qm.ID); err != nil {
+			return fmt.Errorf("remove %s: %w", qm.ID, err)
+		}
 	}
 	if len(msgs) > 0 {
-		o.logger.Info("synced offline messages", "count", len(msgs))
+		o.logger.Info("replayed offline messages", "count", len(msgs))
+	}
+	return nil
+}
+
+// replayFailed counts a failed replay of qm. Below offlineMaxAttempts the
+// message stays queued and the error is returned, ending the sync; after
+// that the message is dead-lettered and nil is returned.
+func (o *OfflineManager) replayFailed(qm QueuedMessage, err error) error {
+	qm.Attempts++
+	qm.LastError = err.Error()
+	if qm.Attempts < offlineMaxAttempts {
+		if uerr := o.queue.Update(qm); uerr != nil {
+			return fmt.Errorf("record replay %s: %w", qm.ID, uerr)
+		}
+		return fmt.Errorf("replay %s (attempt %d): %w", qm.ID, qm.Attempts, err)
+	}
+	if derr := o.queue.DeadLetter(qm); derr != nil {
+		return fmt.Errorf("dead-letter %s: %w", qm.ID, derr)
 	}
+	o.logger.Warn("offline message dead-lettered", "id", qm.ID, "attempts", qm.Attempts, "error", err)
 	return nil
 }
 
+// deliver sends a replayed answer back through the message's channel.
+// Failures are logged: the answer is in the session history either way.
+func (o *OfflineManager) deliver(ctx context.Context, qm QueuedMessage) {
+	if o.channels == nil {
+		return
+	}
+	msg := qm.inbound()
+	ch, err := o.channels.Get(msg.ChannelName)
+	if err != nil {
+		o.logger.Warn("cannot deliver replayed message", "id", qm.ID, "channel", msg.ChannelName, "error", err)
+		return
+	}
+	out := *qm.Reply
+	out.SessionID = msg.SessionID
+	if out.ThreadID == "" {
+		out.ThreadID = msg.ThreadID
+	}
+	out.Content = fmt.Sprintf("Following up on your message from %s:\n\n%s", qm.QueuedAt.Format("Jan 2 15:04"), out.Content)
+	if err := ch.Send(ctx, out); err != nil {
+		o.logger.Warn("failed to deliver replayed message", "id", qm.ID, "channel", msg.ChannelName, "error", err)
+	}
+}
+
 // StartMonitor begins a background connectivity check loop.
 func (o *OfflineManager) StartMonitor(ctx context.Context) {
 	go func() {
@@ -99,11 +248,15 @@ func (o *OfflineManager) StartMonitor(ctx context.Context) {
 
 				if !wasOnline && online {
 					o.logger.Info("connectivity restored")
+				} else if wasOnline && !online {
+					o.logger.Warn("connectivity lost, switching to offline mode")
+				}
+				// Messages left by a failed or interrupted sync are
+				// retried on every check while online.
+				if online && o.queue.Len() > 0 {
 					if err := o.Sync(ctx); err != nil {
 						o.logger.Warn("sync failed after reconnect", "error", err)
 					}
-				} else if wasOnline && !online {
-					o.logger.Warn("connectivity lost, switching to offline mode")
 				}
 			}
 		}
@@ -122,11 +275,49 @@ func (o *OfflineManager) checkConnectivity() bool {
 
 // QueuedMessage represents a message saved while offline for later sync.
 type QueuedMessage struct {
-	SessionID string    `json:"session_id"`
+	ID        string    `json:"id,omitempty"` // set by Enqueue
+	SessionID string    `json:"session_id"`   // normalized session key
 	Content   string    `json:"content"`
 	Sender    string    `json:"sender"`
 	Channel   string    `json:"channel"`
 	QueuedAt  time.Time `json:"queued_at"`
+
+	// Message is the original inbound message, replayed on sync.
+	Message *domain.InboundMessage `json:"message,omitempty"`
+	// Reply is the agent's answer, recorded once the replay succeeded.
+	Reply *domain.OutboundMessage `json:"reply,omitempty"`
+	// Attempts counts failed replays; LastError is the latest failure.
+	Attempts  int    `json:"attempts,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// inbound returns the original inbound message, rebuilding it for
+// messages queued before it was stored.
+func (qm QueuedMessage) inbound() domain.InboundMessage {
+	if qm.Message != nil {
+		return *qm.Message
+	}
+	return domain.InboundMessage{
+		SessionID:   strings.TrimPrefix(qm.SessionID, qm.Channel+":"),
+		Content:     qm.Content,
+		ChannelName: qm.Channel,
+		SenderName:  qm.Sender,
+	}
+}
+
+// replayMessage returns the message sent to the agent on replay. It notes
+// that the message was answered offline and carries the queue ID, which
+// lets Router recognize a replay that already reached the agent.
+func (qm QueuedMessage) replayMessage() domain.InboundMessage {
+	msg := qm.inbound()
+	msg.Metadata = maps.Clone(msg.Metadata)
+	if msg.Metadata == nil {
+		msg.Metadata = make(map[string]string, 1)
+	}
+	msg.Metadata[offlineReplayKey] = qm.ID
+	msg.Content = fmt.Sprintf("[Replay %s: sent at %s while you were offline and answered by a local model, see above. Answer it again now that you are back.]\n\n%s",
+		qm.ID, qm.QueuedAt.Format(time.RFC3339), msg.Content)
+	return msg
 }
 
 // MessageQueue is a file-based FIFO queue for offline messages.
@@ -145,13 +336,83 @@ func (q *MessageQueue) Enqueue(msg QueuedMessage) error {
 		return fmt.Errorf("create queue dir: %w", err)
 	}
 
+	msg.ID = fmt.Sprintf("%d_%s", time.Now().UnixNano(), msg.SessionID)
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	return os.WriteFile(filepath.Join(q.dir, msg.ID+".json"), data, 0600)
+}
+
+// Pending returns the queued messages in chronological order without
+// removing them. ReadDir sorts by name, which starts with the enqueue time.
+func (q *MessageQueue) Pending() ([]QueuedMessage, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read queue dir: %w", err)
+	}
+
+	var msgs []QueuedMessage
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(q.dir, name))
+		if err != nil {
+			continue
+		}
+		var msg QueuedMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		msg.ID = strings.TrimSuffix(name, ".json")
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// Update atomically rewrites a queued message.
+func (q *MessageQueue) Update(msg QueuedMessage) error {
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("marshal message: %w", err)
 	}
+	path := filepath.Join(q.dir, msg.ID+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Remove deletes a queued message.
+func (q *MessageQueue) Remove(id string) error {
+	err := os.Remove(filepath.Join(q.dir, id+".json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
 
-	filename := fmt.Sprintf("%d_%s.json", time.Now().UnixNano(), msg.SessionID)
-	return os.WriteFile(filepath.Join(q.dir, filename), data, 0600)
+// DeadLetter moves a message out of the queue into its dead-letter
+// subdirectory, where it is kept for inspection.
+func (q *MessageQueue) DeadLetter(msg QueuedMessage) error {
+	dir := filepath.Join(q.dir, offlineDeadLetterDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("create dead-letter dir: %w", err)
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, msg.ID+".json"), data, 0600); err != nil {
+		return err
+	}
+	return q.Remove(msg.ID)
 }
 
 // Drain reads and removes all queued messages, returning them in chronological order.
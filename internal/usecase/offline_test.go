@@ -6,6 +6,8 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -145,9 +147,11 @@ func TestMessageQueue_MessageRoundtrip(t *testing.T) {
 type mockLLMProvider struct {
 	response string
 	err      error
+	lastReq  domain.ChatRequest
 }
 
-func (m *mockLLMProvider) Chat(_ context.Context, _ domain.ChatRequest) (*domain.ChatResponse, error) {
+func (m *mockLLMProvider) Chat(_ context.Context, req domain.ChatRequest) (*domain.ChatResponse, error) {
+	m.lastReq = req
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -171,12 +175,17 @@ func TestOfflineManager_HandleOffline(t *testing.T) {
 	om := NewOfflineManager(llm, qDir, "https://example.com", time.Minute, slog.Default())
 
 	msg := domain.InboundMessage{
+		SessionID:   "sess-1",
 		Content:     "Hello",
 		SenderName:  "alice",
 		ChannelName: "cli",
 	}
+	session := NewSession("cli:sess-1")
+	session.AddMessage(domain.Message{Role: domain.RoleUser, Content: "My name is Alice"})
+	session.AddMessage(domain.Message{Role: domain.RoleAssistant, Content: "Hi Alice"})
+	session.AddMessage(domain.Message{Role: domain.RoleAssistant, ToolCalls: []domain.ToolCall{{ID: "c1", Name: "web_search"}}})
 
-	resp, err := om.HandleOffline(context.Background(), "sess-1", msg)
+	resp, err := om.HandleOffline(context.Background(), session, msg)
 	if err != nil {
 		t.Fatalf("HandleOffline: %v", err)
 	}
@@ -184,6 +193,22 @@ func TestOfflineManager_HandleOffline(t *testing.T) {
 		t.Errorf("response = %q, want %q", resp, "I'm running locally")
 	}
 
+	// The local LLM sees the history without tool traffic.
+	var got []string
+	for _, m := range llm.lastReq.Messages[1:] {
+		got = append(got, m.Role+": "+m.Content)
+	}
+	want := []string{"user: My name is Alice", "assistant: Hi Alice", "user: Hello"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("prompt = %v, want %v", got, want)
+	}
+
+	// The offline exchange stays in the session.
+	msgs := session.Messages()
+	if last := msgs[len(msgs)-1]; last.Role != domain.RoleAssistant || last.Content != resp {
+		t.Errorf("last session message = %+v", last)
+	}
+
 	// Message should be queued.
 	if om.queue.Len() != 1 {
 		t.Errorf("queue Len = %d, want 1", om.queue.Len())
@@ -195,7 +220,7 @@ func TestOfflineManager_HandleOfflineLLMError(t *testing.T) {
 	llm := &mockLLMProvider{err: errors.New("local model crashed")}
 	om := NewOfflineManager(llm, qDir, "https://example.com", time.Minute, slog.Default())
 
-	_, err := om.HandleOffline(context.Background(), "sess-1", domain.InboundMessage{Content: "test"})
+	_, err := om.HandleOffline(context.Background(), NewSession("cli:sess-1"), domain.InboundMessage{Content: "test"})
 	if err == nil {
 		t.Fatal("expected error from HandleOffline when LLM fails")
 	}
@@ -235,3 +260,150 @@ func TestOfflineManager_SyncEmpty(t *testing.T) {
 		t.Fatalf("Sync empty: %v", err)
 	}
 }
+
+// replayChannel records messages sent to it.
+type replayChannel struct {
+	mu   sync.Mutex
+	sent []domain.OutboundMessage
+}
+
+func (c *replayChannel) Start(context.Context, domain.MessageHandler) error { return nil }
+func (c *replayChannel) Stop(context.Context) error                         { return nil }
+func (c *replayChannel) Name() string                                       { return "telegram" }
+func (c *replayChannel) Send(_ context.Context, msg domain.OutboundMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, msg)
+	return nil
+}
+
+func (c *replayChannel) Get(name string) (domain.Channel, error) {
+	if name != "telegram" {
+		return nil, errors.New("channel not found")
+	}
+	return c, nil
+}
+
+func TestOfflineManager_SyncReplays(t *testing.T) {
+	om := NewOfflineManager(&mockLLMProvider{response: "offline"}, t.TempDir(), "https://example.com", time.Minute, slog.Default())
+	ch := &replayChannel{}
+	om.SetChannels(ch)
+
+	var replayed []domain.InboundMessage
+	fail := true
+	om.SetReplay(func(_ context.Context, msg domain.InboundMessage) (domain.OutboundMessage, error) {
+		if fail && len(replayed) == 1 {
+			return domain.OutboundMessage{}, errors.New("provider unavailable")
+		}
+		replayed = append(replayed, msg)
+		return domain.OutboundMessage{SessionID: msg.SessionID, Content: "answer " + msg.SessionID}, nil
+	})
+
+	for _, sid := range []string{"chat-1", "chat-2"} {
+		msg := domain.InboundMessage{SessionID: sid, Content: "question", ChannelName: "telegram", ThreadID: "t-" + sid}
+		if _, err := om.HandleOffline(context.Background(), NewSession("telegram:"+sid), msg); err != nil {
+			t.Fatalf("HandleOffline: %v", err)
+		}
+	}
+
+	// The second replay fails: the first is delivered, the second kept.
+	if err := om.Sync(context.Background()); err == nil {
+		t.Fatal("expected Sync to report the failed replay")
+	}
+	if om.queue.Len() != 1 || len(ch.sent) != 1 {
+		t.Fatalf("queue Len = %d, sent = %d, want 1 and 1", om.queue.Len(), len(ch.sent))
+	}
+
+	fail = false
+	if err := om.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if om.queue.Len() != 0 {
+		t.Errorf("queue Len after Sync = %d, want 0", om.queue.Len())
+	}
+	if len(replayed) != 2 || replayed[0].SessionID != "chat-1" || replayed[1].SessionID != "chat-2" {
+		t.Fatalf("unexpected replays %+v", replayed)
+	}
+	if replayed[0].Metadata[offlineReplayKey] == "" || !strings.HasSuffix(replayed[0].Content, "\n\nquestion") {
+		t.Errorf("replayed message not marked: %+v", replayed[0])
+	}
+	for i, sid := range []string{"chat-1", "chat-2"} {
+		out := ch.sent[i]
+		if out.SessionID != sid || out.ThreadID != "t-"+sid || !strings.HasSuffix(out.Content, "answer "+sid) {
+			t.Errorf("sent[%d] = %+v", i, out)
+		}
+	}
+}
+
+func TestOfflineManager_SyncDeliversRecordedReply(t *testing.T) {
+	om := NewOfflineManager(&mockLLMProvider{}, t.TempDir(), "https://example.com", time.Minute, slog.Default())
+	ch := &replayChannel{}
+	om.SetChannels(ch)
+	om.SetReplay(func(context.Context, domain.InboundMessage) (domain.OutboundMessage, error) {
+		t.Error("replay handler called for a message that was already replayed")
+		return domain.OutboundMessage{}, nil
+	})
+
+	// A sync crashed after recording the answer but before delivering it.
+	om.queue.Enqueue(QueuedMessage{SessionID: "telegram:chat-1", Content: "q", Channel: "telegram", QueuedAt: time.Now()})
+	pending, _ := om.queue.Pending()
+	pending[0].Reply = &domain.OutboundMessage{Content: "recorded"}
+	if err := om.queue.Update(pending[0]); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if err := om.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(ch.sent) != 1 || ch.sent[0].SessionID != "chat-1" || !strings.HasSuffix(ch.sent[0].Content, "recorded") {
+		t.Errorf("unexpected delivery %+v", ch.sent)
+	}
+	if om.queue.Len() != 0 {
+		t.Errorf("queue Len = %d, want 0", om.queue.Len())
+	}
+}
+
+func TestOfflineManager_SyncDeadLetters(t *testing.T) {
+	dir := t.TempDir()
+	om := NewOfflineManager(&mockLLMProvider{response: "offline"}, dir, "https://example.com", time.Minute, slog.Default())
+
+	var replayed []string
+	om.SetReplay(func(_ context.Context, msg domain.InboundMessage) (domain.OutboundMessage, error) {
+		if msg.SessionID == "chat-1" {
+			return domain.OutboundMessage{}, errors.New("permission denied")
+		}
+		replayed = append(replayed, msg.SessionID)
+		return domain.OutboundMessage{Content: "answer"}, nil
+	})
+	for _, sid := range []string{"chat-1", "chat-2"} {
+		msg := domain.InboundMessage{SessionID: sid, Content: "question", ChannelName: "telegram"}
+		if _, err := om.HandleOffline(context.Background(), NewSession("telegram:"+sid), msg); err != nil {
+			t.Fatalf("HandleOffline: %v", err)
+		}
+	}
+
+	// The first message blocks the queue until it runs out of attempts.
+	for i := 1; i < offlineMaxAttempts; i++ {
+		if err := om.Sync(context.Background()); err == nil {
+			t.Fatalf("attempt %d: expected Sync to report the failed replay", i)
+		}
+	}
+	if len(replayed) != 0 || om.queue.Len() != 2 {
+		t.Fatalf("replayed = %v, queue Len = %d, want nothing replayed yet", replayed, om.queue.Len())
+	}
+
+	if err := om.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(replayed) != 1 || replayed[0] != "chat-2" || om.queue.Len() != 0 {
+		t.Fatalf("replayed = %v, queue Len = %d, want chat-2 replayed and the queue empty", replayed, om.queue.Len())
+	}
+
+	dead, err := NewMessageQueue(filepath.Join(dir, offlineDeadLetterDir)).Pending()
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("dead letters = %v, %v; want 1", dead, err)
+	}
+	if dead[0].SessionID != "telegram:chat-1" || dead[0].Attempts != offlineMaxAttempts || dead[0].LastError != "permission denied" {
+		t.Errorf("dead letter = %+v", dead[0])
+	}
+}
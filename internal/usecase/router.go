@@ -28,6 +28,10 @@ type SecretMatch struct {
 	End         int
 }
 
+// rawChannels receive the agent's response verbatim, without onboarding
+// text: API clients expect model output only.
+var rawChannels = map[string]bool{"openai": true}
+
 // Router dispatches inbound messages from any channel through the agent,
 // normalizing session keys, invoking hooks, publishing events, and
 // auto-curating when configured.
@@ -144,6 +148,16 @@ func (r *Router) handleInner(ctx context.Context, msg domain.InboundMessage, str
 	// 3. Get or create session.
 	session := sessions.GetOrCreate(sessionKey)
 
+	// 3a. A replayed offline message that already reached the agent, in a
+	// sync that stopped before recording the answer, gets that answer back.
+	replayID := msg.Metadata[offlineReplayKey]
+	if replayID != "" {
+		if answer, ok := replayedAnswer(session, msg.Content); ok {
+			r.logger.Info("offline message already replayed", "replay_id", replayID, "session", sessionKey)
+			return domain.OutboundMessage{SessionID: msg.SessionID, Content: answer}, nil
+		}
+	}
+
 	// 4. Invoke OnMessageReceived hooks (pass by value).
 	for _, h := range r.hooks {
 		if err := h.OnMessageReceived(ctx, msg); err != nil {
@@ -156,19 +170,21 @@ func (r *Router) handleInner(ctx context.Context, msg domain.InboundMessage, str
 	r.publishEvent(ctx, domain.EventMessageReceived, session.ID, nil)
 
 	// 6. Call agent (streaming or synchronous).
+	userMsg := domain.NewUserMessage(msg.Content, r.attachmentParts(msg))
 	var response string
 	if stream {
-		response, err = agent.HandleMessageStream(ctx, session, msg.Content)
+		response, err = agent.HandleUserMessageStream(ctx, session, userMsg)
 	} else {
-		response, err = agent.HandleMessage(ctx, session, msg.Content)
+		response, err = agent.HandleUserMessage(ctx, session, userMsg)
 	}
 	if err != nil {
 		// 6a. Offline fallback: if agent call fails and offline manager is
-		// available, try the local LLM instead.
-		if r.offline != nil && !r.offline.IsOnline() {
+		// available, try the local LLM instead. Replays are not queued
+		// again; the sync retries them.
+		if r.offline != nil && !r.offline.IsOnline() && replayID == "" {
 			r.logger.Info("agent call failed while offline, using local LLM fallback",
 				"error", err, "session", sessionKey)
-			offlineResp, offErr := r.offline.HandleOffline(ctx, sessionKey, msg)
+			offlineResp, offErr := r.offline.HandleOffline(ctx, session, msg)
 			if offErr != nil {
 				return domain.OutboundMessage{}, fmt.Errorf("offline fallback: %w", offErr)
 			}
@@ -186,7 +202,7 @@ func (r *Router) handleInner(ctx context.Context, msg domain.InboundMessage, str
 	}
 
 	// 7a. Add welcome message or progressive hints (onboarding UX).
-	if r.onboarding != nil {
+	if r.onboarding != nil && !rawChannels[msg.ChannelName] {
 		msgCount := session.MessageCount()
 
 		// First interaction: prepend welcome message
@@ -256,21 +272,67 @@ func (r *Router) handleInner(ctx context.Context, msg domain.InboundMessage, str
 	return out, nil
 }
 
+// attachmentParts converts inbound image and file attachments into content
+// parts. Attachments the channel could not resolve to data or a URL are
+// logged rather than dropped silently.
+func (r *Router) attachmentParts(msg domain.InboundMessage) []domain.ContentPart {
+	var parts []domain.ContentPart
+	for _, m := range msg.Media {
+		part, ok := m.ContentPart()
+		if !ok {
+			if m.Type == domain.MediaTypeImage || m.Type == domain.MediaTypeFile {
+				r.logger.Warn("attachment has no retrievable content, skipping",
+					"channel", msg.ChannelName, "type", m.Type)
+			}
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// replayedAnswer returns the agent's final answer to the user message with
+// the given content, if the session has one.
+func replayedAnswer(session *Session, content string) (string, bool) {
+	msgs := session.Messages()
+	for i, m := range msgs {
+		if m.Role != domain.RoleUser || m.Content != content {
+			continue
+		}
+		answer, found := "", false
+		for _, next := range msgs[i+1:] {
+			if next.Role == domain.RoleUser {
+				break
+			}
+			if next.Role == domain.RoleAssistant && len(next.ToolCalls) == 0 {
+				answer, found = next.Content, true
+			}
+		}
+		return answer, found
+	}
+	return "", false
+}
+
 // Wait blocks until all background goroutines (auto-curate) complete.
 // Call during shutdown to avoid orphaned goroutines.
 func (r *Router) Wait() { r.wg.Wait() }
 
 // resolveAgent returns the Agent and SessionManager for the given message.
 // In single-agent mode it returns the Router's own agent/sessions.
-// In multi-agent mode it routes via agentRouter then looks up the result.
+// In multi-agent mode it uses msg.AgentID if set, otherwise routes via
+// agentRouter, then looks up the result.
 func (r *Router) resolveAgent(ctx context.Context, msg domain.InboundMessage) (*Agent, *SessionManager, error) {
 	if r.lookup == nil {
 		// Single-agent mode.
 		return r.agent, r.sessions, nil
 	}
-	agentID, err := r.agentRouter.Route(ctx, msg)
-	if err != nil {
-		return nil, nil, fmt.Errorf("agent router: %w", err)
+	agentID := msg.AgentID
+	if agentID == "" {
+		var err error
+		agentID, err = r.agentRouter.Route(ctx, msg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("agent router: %w", err)
+		}
 	}
 	r.publishEvent(ctx, domain.EventAgentRouted, msg.SessionID, map[string]string{"agent_id": agentID})
 	return r.lookup(agentID)
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -284,7 +285,7 @@ func TestRouterAutoCurate(t *testing.T) {
 	bus := &recordingBus{}
 	mem := &routerCountingMemory{}
 	curateLLM := &mockLLM{responses: []domain.ChatResponse{
-		{Message: domain.Message{Role: domain.RoleAssistant, Content: "POINT: test fact\nTAGS: test"}},
+		{Message: domain.Message{Role: domain.RoleAssistant, Content: `{"points": [{"content": "test fact", "tags": ["test"]}]}`}},
 	}}
 
 	agent := NewAgent(AgentDeps{
@@ -478,3 +479,110 @@ func TestMultiRouterRoutingEvent(t *testing.T) {
 		t.Error("missing EventAgentRouted")
 	}
 }
+
+// visionLLM records requests and accepts images.
+type visionLLM struct {
+	mu   sync.Mutex
+	reqs []domain.ChatRequest
+}
+
+func (v *visionLLM) Chat(_ context.Context, req domain.ChatRequest) (*domain.ChatResponse, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.reqs = append(v.reqs, req)
+	return &domain.ChatResponse{Message: domain.Message{Role: domain.RoleAssistant, Content: "a cat"}}, nil
+}
+func (v *visionLLM) Name() string         { return "vision" }
+func (v *visionLLM) SupportsVision() bool { return true }
+
+func newRouterWithLLM(t *testing.T, llm domain.LLMProvider) (*Router, *SessionManager) {
+	t.Helper()
+	agent := NewAgent(AgentDeps{
+		LLM:            llm,
+		Tools:          &mockToolExecutor{tools: map[string]domain.Tool{}},
+		ContextBuilder: NewContextBuilder("test", "model", 50),
+		Logger:         newTestLogger(),
+		MaxIterations:  5,
+	})
+	sessions := NewSessionManager(t.TempDir())
+	return NewRouter(agent, sessions, nil, newTestLogger()), sessions
+}
+
+func TestRouterForwardsImageAttachments(t *testing.T) {
+	llm := &visionLLM{}
+	r, sm := newRouterWithLLM(t, llm)
+
+	img := []byte("\x89PNG\r\n\x1a\nfake")
+	_, err := r.Handle(context.Background(), domain.InboundMessage{
+		SessionID:   "42",
+		Content:     "what is this?",
+		ChannelName: "telegram",
+		Media: []domain.Media{
+			{Type: domain.MediaTypeImage, MIMEType: "image/png", Data: img},
+			{Type: domain.MediaTypeImage, URL: "unresolved-file-id"}, // skipped
+			{Type: domain.MediaTypeLocation},                         // not content
+		},
+	})
+	if err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+
+	if len(llm.reqs) != 1 {
+		t.Fatalf("expected 1 LLM call, got %d", len(llm.reqs))
+	}
+	msgs := llm.reqs[0].Messages
+	user := msgs[len(msgs)-1]
+	if user.Content != "what is this?" || len(user.Parts) != 2 {
+		t.Fatalf("unexpected user message: %+v", user)
+	}
+	if user.Parts[0].Type != domain.ContentPartText || user.Parts[1].Type != domain.ContentPartImage {
+		t.Errorf("unexpected parts: %+v", user.Parts)
+	}
+
+	// Parts survive session persistence.
+	if err := sm.Save("telegram:42"); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := sm.loadFromDisk("telegram:42")
+	if err != nil {
+		t.Fatalf("loadFromDisk: %v", err)
+	}
+	if got := loaded.Msgs[0].Parts; len(got) != 2 || string(got[1].Data) != string(img) {
+		t.Errorf("parts not persisted: %+v", got)
+	}
+}
+
+func TestRouterRejectsImagesWithoutVision(t *testing.T) {
+	llm := &mockLLM{}
+	r, sm := newRouterWithLLM(t, llm)
+
+	_, err := r.Handle(context.Background(), domain.InboundMessage{
+		SessionID:   "42",
+		Content:     "what is this?",
+		ChannelName: "telegram",
+		Media:       []domain.Media{{Type: domain.MediaTypeImage, MIMEType: "image/png", Data: []byte("png")}},
+	})
+	if !errors.Is(err, domain.ErrVisionUnsupported) {
+		t.Fatalf("expected ErrVisionUnsupported, got %v", err)
+	}
+	if n := sm.GetOrCreate("telegram:42").MessageCount(); n != 0 {
+		t.Errorf("rejected image should not enter history, got %d messages", n)
+	}
+}
+
+func TestReplayedAnswer(t *testing.T) {
+	session := NewSession("cli:s1")
+	session.AddMessage(domain.Message{Role: domain.RoleUser, Content: "replay"})
+	if _, ok := replayedAnswer(session, "replay"); ok {
+		t.Error("expected no answer before the agent replied")
+	}
+	session.AddMessage(domain.Message{Role: domain.RoleAssistant, ToolCalls: []domain.ToolCall{{ID: "c1", Name: "search"}}})
+	session.AddMessage(domain.Message{Role: domain.RoleTool, Content: "result"})
+	session.AddMessage(domain.Message{Role: domain.RoleAssistant, Content: "final"})
+	session.AddMessage(domain.Message{Role: domain.RoleUser, Content: "next"})
+	session.AddMessage(domain.Message{Role: domain.RoleAssistant, Content: "other"})
+
+	if answer, ok := replayedAnswer(session, "replay"); !ok || answer != "final" {
+		t.Errorf("replayedAnswer = %q, %v; want final", answer, ok)
+	}
+}
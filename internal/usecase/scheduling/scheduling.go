@@ -134,17 +134,19 @@ func (s *Scheduler) Start(ctx context.Context) error {
 // Stop signals the scheduler to stop and waits for running jobs to finish.
 func (s *Scheduler) Stop() error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if !s.started {
+		s.mu.Unlock()
 		return nil
 	}
 	if s.cancel != nil {
 		s.cancel()
 	}
+	s.started = false
+	s.mu.Unlock()
+
+	// Wait without holding mu: running jobs take it.
 	stopCtx := s.cron.Stop()
 	<-stopCtx.Done()
-	s.started = false
 	return nil
 }
 
@@ -232,7 +234,8 @@ func (s *Scheduler) RemoveDynamicTask(id string) error {
 	return nil
 }
 
-// GetNextRun returns the next scheduled run time for a dynamic task, or nil if not found.
+// GetNextRun returns the next scheduled run time for a dynamic task, or nil
+// if not found, not due again, or the scheduler has not started yet.
 func (s *Scheduler) GetNextRun(id string) *time.Time {
 	s.mu.Lock()
 	entryID, ok := s.dynamicEntries[id]
@@ -242,7 +245,7 @@ func (s *Scheduler) GetNextRun(id string) *time.Time {
 		return nil
 	}
 	entry := s.cron.Entry(entryID)
-	if entry.ID == 0 {
+	if entry.ID == 0 || entry.Next.IsZero() {
 		return nil
 	}
 	t := entry.Next
@@ -98,3 +98,19 @@ func TestScopedToolNilAllowAll(t *testing.T) {
 		t.Errorf("Name = %q, want %q", tool.Name(), "file_read")
 	}
 }
+
+func TestAgentWithToolsEmptyAllowsNone(t *testing.T) {
+	inner := newTestToolExecutor()
+	agent := NewAgent(AgentDeps{Tools: inner})
+
+	if agent.WithTools(nil) != agent {
+		t.Error("nil tools should return the agent itself")
+	}
+	none := agent.WithTools([]string{}).deps.Tools
+	if len(none.Schemas()) != 0 {
+		t.Errorf("Schemas = %v, want none", none.Schemas())
+	}
+	if _, err := none.Get("web_search"); err == nil {
+		t.Error("Get should fail with no tools allowed")
+	}
+}
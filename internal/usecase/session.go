@@ -78,6 +78,7 @@ type SessionManager struct {
 	mu       sync.RWMutex
 	sessions map[string]*Session
 	dataDir  string
+	onRemove func(id string)
 }
 
 // NewSessionManager creates a session manager with a data directory for persistence.
@@ -88,6 +89,27 @@ func NewSessionManager(dataDir string) *SessionManager {
 	}
 }
 
+// SetOnRemove registers fn to be called with the ID of every session that is
+// deleted or reaped, e.g. to drop state kept per session elsewhere.
+func (sm *SessionManager) SetOnRemove(fn func(id string)) {
+	sm.mu.Lock()
+	sm.onRemove = fn
+	sm.mu.Unlock()
+}
+
+// removed reports removed session IDs to the OnRemove callback.
+func (sm *SessionManager) removed(ids ...string) {
+	sm.mu.RLock()
+	fn := sm.onRemove
+	sm.mu.RUnlock()
+	if fn == nil {
+		return
+	}
+	for _, id := range ids {
+		fn(id)
+	}
+}
+
 // validateSessionID checks if a session ID is safe for filesystem use.
 // It rejects path separators, parent directory references, and null bytes.
 func (sm *SessionManager) validateSessionID(id string) error {
@@ -236,6 +258,7 @@ func (sm *SessionManager) DeleteWithTenant(id string, tenantID string) error {
 	if !ok {
 		return domain.NewDomainError("SessionManager.Delete", domain.ErrSessionNotFound, id)
 	}
+	sm.removed(id)
 
 	path := filepath.Join(sm.dataDir, id+".json")
 	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
@@ -292,6 +315,7 @@ func (sm *SessionManager) ReapStaleSessions(maxAge time.Duration) int {
 		delete(sm.sessions, id)
 	}
 	sm.mu.Unlock()
+	sm.removed(staleIDs...)
 
 	// Phase 3: clean up disk files (no lock needed).
 	for _, id := range staleIDs {
@@ -110,6 +110,28 @@ func TestReapStaleSessions(t *testing.T) {
 	}
 }
 
+func TestSessionManagerOnRemove(t *testing.T) {
+	sm := NewSessionManager(t.TempDir())
+	var removed []string
+	sm.SetOnRemove(func(id string) { removed = append(removed, id) })
+
+	sm.GetOrCreate("cli:default")
+	stale := sm.GetOrCreate("slack:C1")
+	stale.mu.Lock()
+	stale.UpdatedAt = time.Now().Add(-2 * time.Hour)
+	stale.mu.Unlock()
+
+	if err := sm.Delete("cli:default"); err != nil {
+		t.Fatal(err)
+	}
+	sm.Delete("cli:default") // already gone: not reported again
+	sm.ReapStaleSessions(time.Hour)
+
+	if len(removed) != 2 || removed[0] != "cli:default" || removed[1] != "slack:C1" {
+		t.Errorf("removed = %v, want [cli:default slack:C1]", removed)
+	}
+}
+
 func TestReapStaleSessionsNone(t *testing.T) {
 	sm := NewSessionManager(t.TempDir())
 	_ = sm.GetOrCreate("s1")
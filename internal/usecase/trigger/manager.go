@@ -0,0 +1,453 @@
+package trigger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"alfred-ai/internal/domain"
+	"alfred-ai/internal/usecase/workflow"
+)
+
+const (
+	// maxTriggerDepth limits chains of runs started by events that earlier
+	// triggered runs published, so rules cannot trigger each other forever.
+	maxTriggerDepth = 3
+
+	// defaultRunTimeout bounds a triggered run.
+	defaultRunTimeout = 5 * time.Minute
+)
+
+// PipelineRunner starts workflow pipelines. *workflow.Manager implements it.
+type PipelineRunner interface {
+	Run(ctx context.Context, name string, env map[string]string, opts *workflow.RunOptions) (*domain.WorkflowRun, error)
+}
+
+// AgentRunner sends a prompt to an agent (the default agent when agentID is
+// empty) in a fresh session and returns the reply.
+type AgentRunner interface {
+	RunAgent(ctx context.Context, agentID, prompt string, tools []string) (string, error)
+}
+
+// Target kinds.
+const (
+	targetPipeline = "pipeline"
+	targetAgent    = "agent"
+)
+
+// rule is a compiled trigger rule with its debounce/throttle state.
+type rule struct {
+	domain.TriggerRule
+	kind    string // targetPipeline or targetAgent
+	target  string // pipeline name or agent ID
+	filter  *template.Template
+	message *template.Template
+
+	mu      sync.Mutex
+	lastRun time.Time
+	timer   *time.Timer
+	pending domain.Event
+	depth   int
+}
+
+// Manager subscribes to the event bus and starts pipeline and agent runs
+// for events matching their trigger rules.
+type Manager struct {
+	bus        domain.EventBus
+	pipelines  PipelineRunner
+	agents     AgentRunner
+	logger     *slog.Logger
+	runTimeout time.Duration
+
+	mu      sync.Mutex
+	rules   []*rule
+	unsub   func()
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewManager creates a trigger Manager. Add rules and runners before Start.
+func NewManager(bus domain.EventBus, logger *slog.Logger) *Manager {
+	return &Manager{
+		bus:        bus,
+		logger:     logger,
+		runTimeout: defaultRunTimeout,
+	}
+}
+
+// SetPipelines sets the runner for pipeline rules.
+func (m *Manager) SetPipelines(pipelines PipelineRunner) {
+	m.pipelines = pipelines
+}
+
+// SetAgents sets the runner for agent rules.
+func (m *Manager) SetAgents(agents AgentRunner) {
+	m.agents = agents
+}
+
+// SetRunTimeout overrides the timeout of triggered runs.
+func (m *Manager) SetRunTimeout(d time.Duration) {
+	if d > 0 {
+		m.runTimeout = d
+	}
+}
+
+// AddPipelineRules registers rules that run the named pipeline with the
+// event payload as args.
+func (m *Manager) AddPipelineRules(pipeline string, rules []domain.TriggerRule) error {
+	return m.addRules(targetPipeline, pipeline, rules)
+}
+
+// AddAgentRules registers rules that send the event to the agent with the
+// given ID (the default agent when empty).
+func (m *Manager) AddAgentRules(agentID string, rules []domain.TriggerRule) error {
+	return m.addRules(targetAgent, agentID, rules)
+}
+
+// RuleCount returns the number of registered rules.
+func (m *Manager) RuleCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.rules)
+}
+
+func (m *Manager) addRules(kind, target string, rules []domain.TriggerRule) error {
+	compiled := make([]*rule, 0, len(rules))
+	for i, tr := range rules {
+		r, err := compileRule(kind, target, tr)
+		if err != nil {
+			return domain.NewSubSystemError("trigger", "Manager.AddRules", domain.ErrInvalidInput,
+				fmt.Sprintf("%s %q: rule %d: %v", kind, target, i+1, err))
+		}
+		compiled = append(compiled, r)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = append(m.rules, compiled...)
+	return nil
+}
+
+// compileRule validates a rule and parses its templates.
+func compileRule(kind, target string, tr domain.TriggerRule) (*rule, error) {
+	if tr.Event == "" {
+		return nil, fmt.Errorf("event is required")
+	}
+	if strings.Contains(strings.TrimSuffix(tr.Event, "*"), "*") {
+		return nil, fmt.Errorf("event %q: only a trailing * is supported", tr.Event)
+	}
+	if tr.Debounce < 0 || tr.Throttle < 0 {
+		return nil, fmt.Errorf("debounce and throttle must not be negative")
+	}
+	if kind == targetPipeline && tr.Message != "" {
+		return nil, fmt.Errorf("message applies to agent rules only")
+	}
+	if kind == targetPipeline && len(tr.Tools) > 0 {
+		return nil, fmt.Errorf("tools applies to agent rules only")
+	}
+	if kind == targetAgent && tr.Tools == nil {
+		tr.Tools = []string{}
+	}
+
+	r := &rule{TriggerRule: tr, kind: kind, target: target}
+	var err error
+	if tr.Filter != "" {
+		if r.filter, err = template.New("filter").Option("missingkey=zero").Parse(tr.Filter); err != nil {
+			return nil, fmt.Errorf("parse filter: %w", err)
+		}
+	}
+	if tr.Message != "" {
+		if r.message, err = template.New("message").Parse(tr.Message); err != nil {
+			return nil, fmt.Errorf("parse message: %w", err)
+		}
+	}
+	return r, nil
+}
+
+// Start subscribes to the event bus. Triggered runs are cancelled by Stop,
+// not by ctx's parent request scope, so ctx should be long-lived.
+func (m *Manager) Start(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.unsub != nil || m.stopped {
+		return
+	}
+	m.ctx, m.cancel = context.WithCancel(ctx)
+	m.unsub = m.bus.SubscribeAll(m.handleEvent)
+	m.logger.Info("event triggers started", "rules", len(m.rules))
+}
+
+// Stop unsubscribes, drops pending debounced events and waits for
+// running triggered runs to be cancelled.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return
+	}
+	m.stopped = true
+	if m.unsub != nil {
+		m.unsub()
+	}
+	rules := m.rules
+	cancel := m.cancel
+	m.mu.Unlock()
+
+	for _, r := range rules {
+		r.mu.Lock()
+		if r.timer != nil {
+			r.timer.Stop()
+			r.timer = nil
+		}
+		r.mu.Unlock()
+	}
+	if cancel != nil {
+		cancel()
+	}
+	m.wg.Wait()
+}
+
+// handleEvent matches an event against every rule.
+func (m *Manager) handleEvent(ctx context.Context, event domain.Event) {
+	if event.Type == domain.EventTriggerFired {
+		return
+	}
+	depth := depthFrom(ctx)
+	if depth >= maxTriggerDepth {
+		m.logger.Warn("trigger chain too deep, ignoring event", "event", event.Type, "depth", depth)
+		return
+	}
+
+	m.mu.Lock()
+	rules := m.rules
+	m.mu.Unlock()
+
+	data := eventData(event)
+	for _, r := range rules {
+		if !matchesType(r.Event, event.Type) {
+			continue
+		}
+		ok, err := r.matches(data)
+		if err != nil {
+			m.logger.Warn("trigger filter failed", "target", r.target, "event", event.Type, "error", err)
+			continue
+		}
+		if ok {
+			m.fire(r, event, depth)
+		}
+	}
+}
+
+// fire starts a run for a matching event, applying the rule's debounce.
+func (m *Manager) fire(r *rule, event domain.Event, depth int) {
+	if r.Debounce <= 0 {
+		m.start(r, event, depth)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending, r.depth = event, depth
+	if r.timer != nil {
+		r.timer.Reset(r.Debounce)
+		return
+	}
+	r.timer = time.AfterFunc(r.Debounce, func() {
+		r.mu.Lock()
+		if r.timer == nil { // stopped
+			r.mu.Unlock()
+			return
+		}
+		r.timer = nil
+		event, depth := r.pending, r.depth
+		r.mu.Unlock()
+		m.start(r, event, depth)
+	})
+}
+
+// start runs the rule's target in the background unless the rule is
+// throttled or the manager is stopped.
+func (m *Manager) start(r *rule, event domain.Event, depth int) {
+	r.mu.Lock()
+	now := time.Now()
+	if r.Throttle > 0 && !r.lastRun.IsZero() && now.Sub(r.lastRun) < r.Throttle {
+		r.mu.Unlock()
+		m.logger.Debug("trigger throttled", "target", r.target, "event", event.Type)
+		return
+	}
+	r.lastRun = now
+	r.mu.Unlock()
+
+	m.mu.Lock()
+	if m.stopped || m.ctx == nil {
+		m.mu.Unlock()
+		return
+	}
+	m.wg.Add(1)
+	parent := m.ctx
+	m.mu.Unlock()
+
+	go func() {
+		defer m.wg.Done()
+		ctx, cancel := context.WithTimeout(withDepth(parent, depth+1), m.runTimeout)
+		defer cancel()
+		m.run(ctx, r, event)
+	}()
+}
+
+// run executes the rule's target and publishes the outcome.
+func (m *Manager) run(ctx context.Context, r *rule, event domain.Event) {
+	m.logger.Info("trigger fired", r.kind, r.target, "event", event.Type)
+
+	fired := map[string]any{
+		r.kind:  r.target,
+		"event": event.Type,
+	}
+	var err error
+	switch r.kind {
+	case targetPipeline:
+		var run *domain.WorkflowRun
+		if run, err = m.runPipeline(ctx, r, event); run != nil {
+			fired["run_id"] = run.ID
+			fired["status"] = run.Status
+		}
+	case targetAgent:
+		err = m.runAgent(ctx, r, event)
+	}
+	if err != nil {
+		fired["error"] = err.Error()
+		m.logger.Warn("triggered run failed", r.kind, r.target, "event", event.Type, "error", err)
+	}
+
+	payload, _ := json.Marshal(fired)
+	m.bus.Publish(ctx, domain.Event{
+		Type:      domain.EventTriggerFired,
+		Timestamp: time.Now(),
+		SessionID: event.SessionID,
+		Payload:   payload,
+	})
+}
+
+func (m *Manager) runPipeline(ctx context.Context, r *rule, event domain.Event) (*domain.WorkflowRun, error) {
+	if m.pipelines == nil {
+		return nil, fmt.Errorf("pipelines are not available to triggers")
+	}
+	run, err := m.pipelines.Run(ctx, r.target, eventArgs(event), nil)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status == "failed" {
+		return run, fmt.Errorf("pipeline %s failed: %s", r.target, run.Error)
+	}
+	return run, nil
+}
+
+func (m *Manager) runAgent(ctx context.Context, r *rule, event domain.Event) error {
+	if m.agents == nil {
+		return fmt.Errorf("agents are not available to triggers")
+	}
+	prompt, err := r.prompt(event)
+	if err != nil {
+		return err
+	}
+	_, err = m.agents.RunAgent(ctx, r.target, prompt, r.Tools)
+	return err
+}
+
+// matches evaluates the rule's filter. Like workflow conditions, an empty,
+// "false", "0" or missing result does not match.
+func (r *rule) matches(data map[string]any) (bool, error) {
+	if r.filter == nil {
+		return true, nil
+	}
+	var buf bytes.Buffer
+	if err := r.filter.Execute(&buf, data); err != nil {
+		return false, err
+	}
+	result := strings.TrimSpace(buf.String())
+	return result != "" && result != "false" && result != "0" && result != "<no value>", nil
+}
+
+// prompt renders the agent message for an event.
+func (r *rule) prompt(event domain.Event) (string, error) {
+	if r.message == nil {
+		prompt := fmt.Sprintf("Event %s occurred.", event.Type)
+		if len(event.Payload) > 0 {
+			prompt += "\n\nPayload:\n" + string(event.Payload)
+		}
+		return prompt, nil
+	}
+	var buf bytes.Buffer
+	if err := r.message.Execute(&buf, eventData(event)); err != nil {
+		return "", fmt.Errorf("render message: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// matchesType reports whether an event type matches a rule's event, which
+// may end in "*" to match a prefix.
+func matchesType(pattern string, eventType domain.EventType) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(string(eventType), prefix)
+	}
+	return pattern == string(eventType)
+}
+
+// eventData is the template data for filters and messages.
+func eventData(event domain.Event) map[string]any {
+	var payload any
+	if len(event.Payload) > 0 {
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			payload = string(event.Payload)
+		}
+	}
+	return map[string]any{
+		"type":       string(event.Type),
+		"payload":    payload,
+		"session_id": event.SessionID,
+		"timestamp":  event.Timestamp,
+	}
+}
+
+// eventArgs turns an event into pipeline args: the payload's top-level
+// fields (strings as-is, other values as JSON) plus event, event_payload
+// and event_session_id.
+func eventArgs(event domain.Event) map[string]string {
+	args := make(map[string]string)
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(event.Payload, &fields) == nil {
+		for k, v := range fields {
+			var s string
+			if json.Unmarshal(v, &s) == nil {
+				args[k] = s
+			} else {
+				args[k] = string(v)
+			}
+		}
+	}
+	args["event"] = string(event.Type)
+	args["event_payload"] = string(event.Payload)
+	args["event_session_id"] = event.SessionID
+	return args
+}
+
+type depthKey struct{}
+
+// withDepth marks ctx as belonging to a run started at the given trigger
+// depth. Events published with it carry the depth to handleEvent.
+func withDepth(ctx context.Context, depth int) context.Context {
+	return context.WithValue(ctx, depthKey{}, depth)
+}
+
+func depthFrom(ctx context.Context) int {
+	depth, _ := ctx.Value(depthKey{}).(int)
+	return depth
+}
@@ -0,0 +1,294 @@
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"alfred-ai/internal/domain"
+	"alfred-ai/internal/usecase/eventbus"
+	"alfred-ai/internal/usecase/workflow"
+)
+
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+type stubPipelines struct {
+	mu   sync.Mutex
+	runs []map[string]string
+}
+
+func (p *stubPipelines) Run(_ context.Context, name string, env map[string]string, _ *workflow.RunOptions) (*domain.WorkflowRun, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.runs = append(p.runs, env)
+	return &domain.WorkflowRun{ID: "run-1", PipelineName: name, Status: "completed"}, nil
+}
+
+func (p *stubPipelines) snapshot() []map[string]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]map[string]string(nil), p.runs...)
+}
+
+type agentCall struct {
+	agentID, prompt string
+	tools           []string
+}
+
+type stubAgents struct {
+	mu    sync.Mutex
+	calls []agentCall
+}
+
+func (a *stubAgents) RunAgent(_ context.Context, agentID, prompt string, tools []string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls = append(a.calls, agentCall{agentID, prompt, tools})
+	return "ok", nil
+}
+
+func (a *stubAgents) snapshot() []agentCall {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]agentCall(nil), a.calls...)
+}
+
+// newTestManager starts a Manager on a real bus and stops both on cleanup.
+func newTestManager(t *testing.T) (*Manager, *eventbus.Bus) {
+	t.Helper()
+	bus := eventbus.New(newTestLogger())
+	mgr := NewManager(bus, newTestLogger())
+	t.Cleanup(func() {
+		mgr.Stop()
+		bus.Close()
+	})
+	return mgr, bus
+}
+
+func publish(bus domain.EventBus, ctx context.Context, eventType domain.EventType, payload any) {
+	data, _ := json.Marshal(payload)
+	bus.Publish(ctx, domain.Event{Type: eventType, Timestamp: time.Now(), SessionID: "s1", Payload: data})
+}
+
+// waitFor polls until cond holds or fails the test after a second.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTriggerPipeline(t *testing.T) {
+	mgr, bus := newTestManager(t)
+	pipelines := &stubPipelines{}
+	mgr.SetPipelines(pipelines)
+	err := mgr.AddPipelineRules("diagnose", []domain.TriggerRule{{
+		Event:  string(domain.EventProcessCompleted),
+		Filter: `{{eq .payload.status "failed"}}`,
+	}})
+	if err != nil {
+		t.Fatalf("AddPipelineRules: %v", err)
+	}
+
+	fired := make(chan domain.Event, 1)
+	bus.Subscribe(domain.EventTriggerFired, func(_ context.Context, e domain.Event) { fired <- e })
+	mgr.Start(context.Background())
+
+	ctx := context.Background()
+	publish(bus, ctx, domain.EventProcessCompleted, map[string]any{"id": "p1", "status": "completed", "exit_code": 0})
+	publish(bus, ctx, domain.EventProcessCompleted, map[string]any{"id": "p2", "status": "failed", "exit_code": 2})
+
+	var event domain.Event
+	select {
+	case event = <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for trigger.fired")
+	}
+	var payload map[string]any
+	json.Unmarshal(event.Payload, &payload)
+	if payload["pipeline"] != "diagnose" || payload["run_id"] != "run-1" || payload["status"] != "completed" {
+		t.Errorf("unexpected trigger.fired payload %v", payload)
+	}
+
+	runs := pipelines.snapshot()
+	if len(runs) != 1 {
+		t.Fatalf("expected only the failed process to trigger, got %d runs", len(runs))
+	}
+	env := runs[0]
+	if env["id"] != "p2" || env["exit_code"] != "2" || env["event"] != "process.completed" || env["event_session_id"] != "s1" {
+		t.Errorf("unexpected args %v", env)
+	}
+}
+
+func TestTriggerAgent(t *testing.T) {
+	mgr, bus := newTestManager(t)
+	agents := &stubAgents{}
+	mgr.SetAgents(agents)
+	if err := mgr.AddAgentRules("ops", []domain.TriggerRule{{
+		Event:   "node.*",
+		Message: "Node {{.payload.node_id}} sent {{.type}}",
+		Tools:   []string{"web_search"},
+	}}); err != nil {
+		t.Fatalf("AddAgentRules: %v", err)
+	}
+	if err := mgr.AddAgentRules("", []domain.TriggerRule{{Event: string(domain.EventNodeUnreachable)}}); err != nil {
+		t.Fatalf("AddAgentRules: %v", err)
+	}
+	mgr.Start(context.Background())
+
+	publish(bus, context.Background(), domain.EventNodeUnreachable, map[string]string{"node_id": "pi"})
+	waitFor(t, "agent runs", func() bool { return len(agents.snapshot()) == 2 })
+
+	for _, call := range agents.snapshot() {
+		switch call.agentID {
+		case "ops":
+			if call.prompt != "Node pi sent node.unreachable" {
+				t.Errorf("ops prompt = %q", call.prompt)
+			}
+			if len(call.tools) != 1 || call.tools[0] != "web_search" {
+				t.Errorf("ops tools = %v, want the listed ones", call.tools)
+			}
+		case "":
+			want := "Event node.unreachable occurred.\n\nPayload:\n{\"node_id\":\"pi\"}"
+			if call.prompt != want {
+				t.Errorf("default prompt = %q, want %q", call.prompt, want)
+			}
+			if call.tools == nil || len(call.tools) != 0 {
+				t.Errorf("default tools = %#v, want none", call.tools)
+			}
+		default:
+			t.Errorf("unexpected agent %q", call.agentID)
+		}
+	}
+}
+
+func TestTriggerDebounce(t *testing.T) {
+	mgr, bus := newTestManager(t)
+	pipelines := &stubPipelines{}
+	mgr.SetPipelines(pipelines)
+	mgr.AddPipelineRules("sync", []domain.TriggerRule{{Event: "memory.stored", Debounce: 50 * time.Millisecond}}) //nolint:errcheck
+	mgr.Start(context.Background())
+
+	for _, id := range []string{"a", "b", "c"} {
+		publish(bus, context.Background(), domain.EventMemoryStored, map[string]string{"id": id})
+		time.Sleep(10 * time.Millisecond)
+	}
+	waitFor(t, "debounced run", func() bool { return len(pipelines.snapshot()) > 0 })
+	time.Sleep(100 * time.Millisecond)
+
+	runs := pipelines.snapshot()
+	if len(runs) != 1 || runs[0]["id"] != "c" {
+		t.Errorf("expected one run with the last event, got %v", runs)
+	}
+}
+
+func TestTriggerThrottle(t *testing.T) {
+	mgr, bus := newTestManager(t)
+	pipelines := &stubPipelines{}
+	mgr.SetPipelines(pipelines)
+	mgr.AddPipelineRules("alert", []domain.TriggerRule{{Event: "node.unreachable", Throttle: time.Hour}}) //nolint:errcheck
+	mgr.Start(context.Background())
+
+	publish(bus, context.Background(), domain.EventNodeUnreachable, map[string]string{"node_id": "a"})
+	waitFor(t, "first run", func() bool { return len(pipelines.snapshot()) == 1 })
+	publish(bus, context.Background(), domain.EventNodeUnreachable, map[string]string{"node_id": "b"})
+	time.Sleep(50 * time.Millisecond)
+
+	if runs := pipelines.snapshot(); len(runs) != 1 {
+		t.Errorf("expected the second event to be throttled, got %d runs", len(runs))
+	}
+}
+
+func TestTriggerDepthLimit(t *testing.T) {
+	mgr, bus := newTestManager(t)
+	pipelines := &stubPipelines{}
+	mgr.SetPipelines(pipelines)
+	mgr.AddPipelineRules("loop", []domain.TriggerRule{{Event: "workflow.*"}}) //nolint:errcheck
+	mgr.Start(context.Background())
+
+	publish(bus, withDepth(context.Background(), maxTriggerDepth), domain.EventWorkflowFailed, nil)
+	publish(bus, withDepth(context.Background(), maxTriggerDepth-1), domain.EventWorkflowFailed, nil)
+	waitFor(t, "run below the depth limit", func() bool { return len(pipelines.snapshot()) == 1 })
+	time.Sleep(50 * time.Millisecond)
+
+	if runs := pipelines.snapshot(); len(runs) != 1 {
+		t.Errorf("expected the event at the depth limit to be ignored, got %d runs", len(runs))
+	}
+}
+
+func TestTriggerStopCancelsPending(t *testing.T) {
+	bus := eventbus.New(newTestLogger())
+	defer bus.Close()
+	mgr := NewManager(bus, newTestLogger())
+	pipelines := &stubPipelines{}
+	mgr.SetPipelines(pipelines)
+	mgr.AddPipelineRules("sync", []domain.TriggerRule{{Event: "memory.stored", Debounce: 20 * time.Millisecond}}) //nolint:errcheck
+	mgr.Start(context.Background())
+
+	publish(bus, context.Background(), domain.EventMemoryStored, nil)
+	time.Sleep(5 * time.Millisecond)
+	mgr.Stop()
+	time.Sleep(50 * time.Millisecond)
+
+	if runs := pipelines.snapshot(); len(runs) != 0 {
+		t.Errorf("expected no run after Stop, got %d", len(runs))
+	}
+}
+
+func TestCompileRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		rule    domain.TriggerRule
+		wantErr bool
+	}{
+		{"exact", targetPipeline, domain.TriggerRule{Event: "process.completed"}, false},
+		{"prefix", targetAgent, domain.TriggerRule{Event: "node.*", Message: "{{.type}}"}, false},
+		{"missing event", targetPipeline, domain.TriggerRule{Filter: "true"}, true},
+		{"inner wildcard", targetPipeline, domain.TriggerRule{Event: "*.failed"}, true},
+		{"negative debounce", targetPipeline, domain.TriggerRule{Event: "x", Debounce: -time.Second}, true},
+		{"bad filter", targetPipeline, domain.TriggerRule{Event: "x", Filter: "{{eq .type"}, true},
+		{"message on pipeline", targetPipeline, domain.TriggerRule{Event: "x", Message: "hi"}, true},
+		{"bad message", targetAgent, domain.TriggerRule{Event: "x", Message: "{{.payload"}, true},
+		{"tools on pipeline", targetPipeline, domain.TriggerRule{Event: "x", Tools: []string{"shell"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := compileRule(tt.kind, "t", tt.rule); (err != nil) != tt.wantErr {
+				t.Errorf("compileRule error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEventArgs(t *testing.T) {
+	event := domain.Event{
+		Type:      domain.EventProcessCompleted,
+		SessionID: "s1",
+		Payload:   json.RawMessage(`{"command":"make","args":["test"],"exit_code":2,"event":"spoofed"}`),
+	}
+	args := eventArgs(event)
+	want := map[string]string{
+		"command":          "make",
+		"args":             `["test"]`,
+		"exit_code":        "2",
+		"event":            "process.completed",
+		"event_payload":    string(event.Payload),
+		"event_session_id": "s1",
+	}
+	for k, v := range want {
+		if args[k] != v {
+			t.Errorf("args[%q] = %q, want %q", k, args[k], v)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package usage prices LLM calls, keeps a persistent cost ledger and
+// enforces spend budgets.
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+// FileLedger implements domain.UsageLedger with one append-only JSONL file
+// per calendar month (usage-2006-01.jsonl), so reading a period only opens
+// the months it covers.
+type FileLedger struct {
+	mu    sync.Mutex
+	dir   string
+	month string
+	file  *os.File
+}
+
+// NewFileLedger creates the ledger directory if needed.
+func NewFileLedger(dir string) (*FileLedger, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("usage ledger: create dir: %w", err)
+	}
+	return &FileLedger{dir: dir}, nil
+}
+
+func ledgerFile(month string) string { return "usage-" + month + ".jsonl" }
+
+// Append writes rec as one JSON line to the file of its month.
+func (l *FileLedger) Append(rec domain.CostRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("usage ledger: marshal: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	month := rec.Time.UTC().Format("2006-01")
+	if l.file == nil || l.month != month {
+		if l.file != nil {
+			l.file.Close()
+		}
+		f, err := os.OpenFile(filepath.Join(l.dir, ledgerFile(month)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			l.file = nil
+			return fmt.Errorf("usage ledger: open file: %w", err)
+		}
+		l.file, l.month = f, month
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("usage ledger: write: %w", err)
+	}
+	return nil
+}
+
+// Records returns the records at or after since, oldest first.
+func (l *FileLedger) Records(since time.Time) ([]domain.CostRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	matches, err := filepath.Glob(filepath.Join(l.dir, "usage-*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("usage ledger: list files: %w", err)
+	}
+	sort.Strings(matches)
+
+	first := since.UTC().Format("2006-01")
+	var records []domain.CostRecord
+	for _, path := range matches {
+		month := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "usage-"), ".jsonl")
+		if !since.IsZero() && month < first {
+			continue
+		}
+		recs, err := readLedgerFile(path, since)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, recs...)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records, nil
+}
+
+func readLedgerFile(path string, since time.Time) ([]domain.CostRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("usage ledger: open for read: %w", err)
+	}
+	defer f.Close()
+
+	var records []domain.CostRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec domain.CostRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// Skip corrupt or torn lines — best effort recovery.
+			continue
+		}
+		if rec.Time.Before(since) {
+			continue
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("usage ledger: scan: %w", err)
+	}
+	return records, nil
+}
+
+// Close closes the file of the current month.
+func (l *FileLedger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+var _ domain.UsageLedger = (*FileLedger)(nil)
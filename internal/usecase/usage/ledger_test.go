@@ -0,0 +1,74 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+func TestFileLedgerMonthlyFiles(t *testing.T) {
+	dir := t.TempDir()
+	ledger, err := NewFileLedger(dir)
+	if err != nil {
+		t.Fatalf("NewFileLedger: %v", err)
+	}
+	defer ledger.Close()
+
+	sep := time.Date(2026, 9, 30, 12, 0, 0, 0, time.UTC)
+	oct := time.Date(2026, 10, 2, 12, 0, 0, 0, time.UTC)
+	for _, rec := range []domain.CostRecord{
+		{Time: sep, Provider: "openai", Model: "gpt-4o", CostUSD: 0.5},
+		{Time: oct, Provider: "anthropic", Model: "claude", CostUSD: 1.5},
+		{Time: oct.Add(time.Hour), Provider: "openai", Model: "gpt-4o", CostUSD: 2},
+	} {
+		if err := ledger.Append(rec); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	for _, name := range []string{"usage-2026-09.jsonl", "usage-2026-10.jsonl"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+
+	all, err := ledger.Records(time.Time{})
+	if err != nil {
+		t.Fatalf("Records: %v", err)
+	}
+	if len(all) != 3 || !all[0].Time.Equal(sep) {
+		t.Fatalf("Records(all) = %+v", all)
+	}
+
+	recent, err := ledger.Records(oct)
+	if err != nil {
+		t.Fatalf("Records: %v", err)
+	}
+	if len(recent) != 2 || recent[0].Provider != "anthropic" {
+		t.Errorf("Records(oct) = %+v", recent)
+	}
+}
+
+func TestFileLedgerSkipsCorruptLines(t *testing.T) {
+	dir := t.TempDir()
+	ledger, _ := NewFileLedger(dir)
+	now := time.Now()
+	ledger.Append(domain.CostRecord{Time: now, Provider: "openai"})
+	ledger.Close()
+
+	path := filepath.Join(dir, ledgerFile(now.UTC().Format("2006-01")))
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	f.WriteString(`{"time":"torn` + "\n")
+	f.Close()
+
+	records, err := ledger.Records(time.Time{})
+	if err != nil {
+		t.Fatalf("Records: %v", err)
+	}
+	if len(records) != 1 {
+		t.Errorf("got %d records, want 1", len(records))
+	}
+}
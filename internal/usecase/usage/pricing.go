@@ -0,0 +1,20 @@
+package usage
+
+import "alfred-ai/internal/domain"
+
+// Pricing maps a price key to a model price. Keys are "provider/model",
+// a bare model name, or a bare provider name, tried in that order.
+type Pricing map[string]domain.ModelPrice
+
+// Lookup returns the price for a call to model on provider.
+func (p Pricing) Lookup(provider, model string) (domain.ModelPrice, bool) {
+	for _, key := range []string{provider + "/" + model, model, provider} {
+		if key == "" || key == "/" {
+			continue
+		}
+		if price, ok := p[key]; ok {
+			return price, true
+		}
+	}
+	return domain.ModelPrice{}, false
+}
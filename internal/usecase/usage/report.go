@@ -0,0 +1,69 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+
+	"alfred-ai/internal/domain"
+)
+
+// ReportGroups lists the dimensions a report can be grouped by.
+var ReportGroups = []string{"day", "provider", "model", "tenant", "agent", "session"}
+
+// ReportRow is the usage of one group in a report.
+type ReportRow struct {
+	Key string `json:"key"`
+	domain.UsageTotals
+}
+
+// Report groups records by one of ReportGroups. Rows grouped by day are in
+// date order, all others are sorted by cost, most expensive first.
+func Report(records []domain.CostRecord, by string) ([]ReportRow, error) {
+	key, err := groupKey(by)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int)
+	var rows []ReportRow
+	for _, rec := range records {
+		k := key(rec)
+		i, ok := index[k]
+		if !ok {
+			i = len(rows)
+			index[k] = i
+			rows = append(rows, ReportRow{Key: k})
+		}
+		rows[i].Add(rec)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if by == "day" {
+			return rows[i].Key < rows[j].Key
+		}
+		return rows[i].CostUSD > rows[j].CostUSD
+	})
+	return rows, nil
+}
+
+func groupKey(by string) (func(domain.CostRecord) string, error) {
+	orNone := func(s string) string {
+		if s == "" {
+			return "(none)"
+		}
+		return s
+	}
+	switch by {
+	case "day":
+		return func(r domain.CostRecord) string { return r.Time.Local().Format("2006-01-02") }, nil
+	case "provider":
+		return func(r domain.CostRecord) string { return r.Provider }, nil
+	case "model":
+		return func(r domain.CostRecord) string { return r.Provider + "/" + r.Model }, nil
+	case "tenant":
+		return func(r domain.CostRecord) string { return orNone(r.TenantID) }, nil
+	case "agent":
+		return func(r domain.CostRecord) string { return orNone(r.AgentID) }, nil
+	case "session":
+		return func(r domain.CostRecord) string { return orNone(r.SessionID) }, nil
+	}
+	return nil, fmt.Errorf("unknown report grouping %q (want one of %v)", by, ReportGroups)
+}
@@ -0,0 +1,297 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+// Decision is the outcome of a budget check before an LLM call. An empty
+// Action lets the call through unchanged.
+type Decision struct {
+	Action domain.BudgetAction
+	Budget domain.BudgetStatus // the budget that triggered the action
+}
+
+// Tracker prices LLM calls, appends them to the ledger and keeps running
+// totals for the current day and month so budget checks never touch disk.
+type Tracker struct {
+	ledger  domain.UsageLedger
+	pricing Pricing
+	budgets []domain.Budget
+	logger  *slog.Logger
+	bus     domain.EventBus // optional
+	now     func() time.Time
+
+	mu       sync.Mutex
+	day      time.Time // start of the current day
+	month    time.Time // start of the current month
+	today    domain.UsageTotals
+	total    domain.UsageTotals // this month
+	spend    map[string]float64 // spendKey → USD in the budget's period
+	unpriced map[string]bool
+	notified map[string]bool // budgets already reported this period
+}
+
+// NewTracker creates a tracker and rebuilds the current month's totals
+// from the ledger.
+func NewTracker(ledger domain.UsageLedger, pricing Pricing, budgets []domain.Budget, logger *slog.Logger) (*Tracker, error) {
+	t := &Tracker{
+		ledger:  ledger,
+		pricing: pricing,
+		budgets: budgets,
+		logger:  logger,
+		now:     time.Now,
+	}
+	t.reset(t.now())
+	records, err := ledger.Records(t.month)
+	if err != nil {
+		return nil, domain.WrapOp("usage.NewTracker", err)
+	}
+	for _, rec := range records {
+		t.add(rec)
+	}
+	return t, nil
+}
+
+// SetBus publishes a usage.budget.exceeded event the first time a budget
+// is spent in each period.
+func (t *Tracker) SetBus(bus domain.EventBus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.bus = bus
+}
+
+// Record prices one call, appends it to the ledger and returns the record.
+// Calls to models without a price are recorded at zero cost. The running
+// totals are updated even when the ledger write fails.
+func (t *Tracker) Record(ctx context.Context, provider, model string, u domain.Usage) (domain.CostRecord, error) {
+	rec := domain.CostRecord{
+		Time:      t.now(),
+		Provider:  provider,
+		Model:     model,
+		SessionID: domain.SessionIDFromContext(ctx),
+		AgentID:   domain.AgentIDFromContext(ctx),
+		TenantID:  domain.TenantIDFromContext(ctx),
+		Usage:     u,
+	}
+	price, ok := t.pricing.Lookup(provider, model)
+	if ok {
+		rec.CostUSD = price.Cost(u)
+	}
+
+	t.mu.Lock()
+	t.rollover(rec.Time)
+	if !ok && u.TotalTokens > 0 && !t.unpriced[provider+"/"+model] {
+		t.logger.Warn("usage: no price configured, recording zero cost", "provider", provider, "model", model)
+	}
+	t.add(rec)
+	t.mu.Unlock()
+
+	if err := t.ledger.Append(rec); err != nil {
+		t.logger.Warn("usage: ledger append failed", "error", err)
+		return rec, domain.WrapOp("usage.Record", err)
+	}
+	return rec, nil
+}
+
+// Check decides what to do with a call made under ctx. Block wins over
+// downgrade, which wins over warn.
+func (t *Tracker) Check(ctx context.Context) Decision {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover(t.now())
+
+	var decision Decision
+	var exceeded []domain.BudgetStatus
+	for _, b := range t.budgets {
+		key, ok := scopeKey(ctx, b)
+		if !ok {
+			continue
+		}
+		status := domain.BudgetStatus{Budget: b, Key: key, Spent: t.spend[t.spendKey(b, key)]}
+		if !status.Exceeded() {
+			continue
+		}
+		exceeded = append(exceeded, status)
+		if actionRank(b.Action) > actionRank(decision.Action) {
+			decision = Decision{Action: b.Action, Budget: status}
+		}
+	}
+	for _, status := range exceeded {
+		t.notify(ctx, status)
+	}
+	return decision
+}
+
+// Summary returns the totals for today and this month and the spend of
+// every budget. Budgets without an ID report their biggest spender.
+func (t *Tracker) Summary() domain.UsageSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover(t.now())
+
+	summary := domain.UsageSummary{Today: t.today, Month: t.total}
+	for _, b := range t.budgets {
+		status := domain.BudgetStatus{Budget: b, Key: b.ID}
+		switch {
+		case b.Scope == domain.BudgetGlobal:
+			status.Key = ""
+			status.Spent = t.spend[t.spendKey(b, "")]
+		case b.ID != "":
+			status.Spent = t.spend[t.spendKey(b, b.ID)]
+		default:
+			prefix := t.spendKey(b, "")
+			for k, v := range t.spend {
+				if len(k) > len(prefix) && k[:len(prefix)] == prefix && v > status.Spent {
+					status.Key, status.Spent = k[len(prefix):], v
+				}
+			}
+		}
+		summary.Budgets = append(summary.Budgets, status)
+	}
+	for model := range t.unpriced {
+		summary.Unpriced = append(summary.Unpriced, model)
+	}
+	slices.Sort(summary.Unpriced)
+	return summary
+}
+
+// add folds rec into the totals. Caller holds mu.
+func (t *Tracker) add(rec domain.CostRecord) {
+	if rec.Time.Before(t.month) {
+		return
+	}
+	t.total.Add(rec)
+	if !rec.Time.Before(t.day) {
+		t.today.Add(rec)
+	}
+	if rec.CostUSD == 0 && rec.Usage.TotalTokens > 0 {
+		if _, ok := t.pricing.Lookup(rec.Provider, rec.Model); !ok {
+			t.unpriced[rec.Provider+"/"+rec.Model] = true
+		}
+	}
+
+	ids := map[domain.BudgetScope]string{
+		domain.BudgetGlobal:  "",
+		domain.BudgetTenant:  rec.TenantID,
+		domain.BudgetAgent:   rec.AgentID,
+		domain.BudgetSession: rec.SessionID,
+	}
+	for scope, id := range ids {
+		if scope != domain.BudgetGlobal && id == "" {
+			continue
+		}
+		t.spend[spendKey(domain.BudgetMonthly, scope, id)] += rec.CostUSD
+		if !rec.Time.Before(t.day) {
+			t.spend[spendKey(domain.BudgetDaily, scope, id)] += rec.CostUSD
+		}
+	}
+}
+
+// rollover starts new periods once now has left the current day or
+// month. Caller holds mu.
+func (t *Tracker) rollover(now time.Time) {
+	y, m, d := now.Date()
+	if day := time.Date(y, m, d, 0, 0, 0, 0, now.Location()); day.Equal(t.day) {
+		return
+	}
+	if month := time.Date(y, m, 1, 0, 0, 0, 0, now.Location()); !month.Equal(t.month) {
+		t.reset(now)
+		return
+	}
+	// New day, same month: drop the daily counters only.
+	t.day = time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	t.today = domain.UsageTotals{}
+	for k := range t.spend {
+		if k[0] == 'd' {
+			delete(t.spend, k)
+		}
+	}
+	for k := range t.notified {
+		if k[0] == 'd' {
+			delete(t.notified, k)
+		}
+	}
+}
+
+// reset clears all totals and starts the periods containing now.
+func (t *Tracker) reset(now time.Time) {
+	y, m, d := now.Date()
+	t.day = time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	t.month = time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+	t.today, t.total = domain.UsageTotals{}, domain.UsageTotals{}
+	t.spend = make(map[string]float64)
+	t.unpriced = make(map[string]bool)
+	t.notified = make(map[string]bool)
+}
+
+// notify logs and publishes a spent budget once per period. Caller holds mu.
+func (t *Tracker) notify(ctx context.Context, status domain.BudgetStatus) {
+	k := t.spendKey(status.Budget, status.Key) + "|" + string(status.Action)
+	if t.notified[k] {
+		return
+	}
+	t.notified[k] = true
+	t.logger.Warn("usage: budget exceeded",
+		"scope", status.Scope, "id", status.Key, "period", status.Period,
+		"limit", status.Limit, "spent", fmt.Sprintf("%.4f", status.Spent), "action", status.Action)
+	if t.bus == nil {
+		return
+	}
+	data, _ := json.Marshal(status)
+	t.bus.Publish(ctx, domain.Event{
+		Type:      domain.EventBudgetExceeded,
+		Timestamp: t.now(),
+		SessionID: domain.SessionIDFromContext(ctx),
+		Payload:   data,
+	})
+}
+
+func (t *Tracker) spendKey(b domain.Budget, id string) string {
+	return spendKey(b.Period, b.Scope, id)
+}
+
+// spendKey identifies a running total. The period comes first so rollover
+// can drop the daily totals by their first byte.
+func spendKey(period domain.BudgetPeriod, scope domain.BudgetScope, id string) string {
+	return string(period) + "|" + string(scope) + "|" + id
+}
+
+// scopeKey returns the ID a budget is summed under for a call made with
+// ctx, and false when the budget does not apply to the call.
+func scopeKey(ctx context.Context, b domain.Budget) (string, bool) {
+	var id string
+	switch b.Scope {
+	case domain.BudgetGlobal:
+		return "", true
+	case domain.BudgetTenant:
+		id = domain.TenantIDFromContext(ctx)
+	case domain.BudgetAgent:
+		id = domain.AgentIDFromContext(ctx)
+	case domain.BudgetSession:
+		id = domain.SessionIDFromContext(ctx)
+	}
+	if id == "" || (b.ID != "" && b.ID != id) {
+		return "", false
+	}
+	return id, true
+}
+
+func actionRank(a domain.BudgetAction) int {
+	switch a {
+	case domain.BudgetBlock:
+		return 3
+	case domain.BudgetDowngrade:
+		return 2
+	case domain.BudgetWarn:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,221 @@
+package usage
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// memLedger is an in-memory domain.UsageLedger.
+type memLedger struct {
+	records []domain.CostRecord
+}
+
+func (l *memLedger) Append(rec domain.CostRecord) error {
+	l.records = append(l.records, rec)
+	return nil
+}
+
+func (l *memLedger) Records(since time.Time) ([]domain.CostRecord, error) {
+	var out []domain.CostRecord
+	for _, r := range l.records {
+		if !r.Time.Before(since) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestModelPriceCost(t *testing.T) {
+	price := domain.ModelPrice{Input: 3, Output: 15, Cached: 0.3}
+	u := domain.Usage{PromptTokens: 1_000_000, CachedTokens: 500_000, CompletionTokens: 100_000, ThinkingTokens: 40_000}
+	// 500k input at $3 + 500k cached at $0.30 + 100k output (thinking at the output price) at $15.
+	if got := price.Cost(u); !approx(got, 1.5+0.15+1.5) {
+		t.Errorf("Cost = %v", got)
+	}
+
+	// Cache writes replace part of the uncached input.
+	price.CacheWrite = 3.75
+	u.CacheWriteTokens = 200_000
+	if got := price.Cost(u); !approx(got, 0.9+0.75+0.15+1.5) {
+		t.Errorf("Cost with cache writes = %v", got)
+	}
+}
+
+func TestPricingLookup(t *testing.T) {
+	pricing := Pricing{
+		"openrouter/openai/gpt-4o": {Input: 5},
+		"gpt-4o":                   {Input: 2.5},
+		"ollama":                   {},
+	}
+	tests := []struct {
+		provider, model string
+		want            float64
+		found           bool
+	}{
+		{"openrouter", "openai/gpt-4o", 5, true},
+		{"openai", "gpt-4o", 2.5, true},
+		{"ollama", "llama3", 0, true},
+		{"anthropic", "claude", 0, false},
+	}
+	for _, tt := range tests {
+		price, ok := pricing.Lookup(tt.provider, tt.model)
+		if ok != tt.found || price.Input != tt.want {
+			t.Errorf("Lookup(%s, %s) = %v, %v", tt.provider, tt.model, price, ok)
+		}
+	}
+}
+
+func TestTrackerRecordAndSummary(t *testing.T) {
+	ledger := &memLedger{}
+	tracker, err := NewTracker(ledger, Pricing{"gpt-4o": {Input: 2, Output: 8}}, nil, newTestLogger())
+	if err != nil {
+		t.Fatalf("NewTracker: %v", err)
+	}
+
+	ctx := domain.ContextWithSessionID(context.Background(), "s1")
+	ctx = domain.ContextWithTenantID(ctx, "acme")
+	rec, err := tracker.Record(ctx, "openai", "gpt-4o", domain.Usage{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500})
+	if err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if !approx(rec.CostUSD, 0.006) || rec.SessionID != "s1" || rec.TenantID != "acme" {
+		t.Errorf("unexpected record %+v", rec)
+	}
+	tracker.Record(ctx, "local", "llama3", domain.Usage{PromptTokens: 10, TotalTokens: 10})
+
+	summary := tracker.Summary()
+	if summary.Today.Calls != 2 || !approx(summary.Month.CostUSD, 0.006) || summary.Month.Usage.TotalTokens != 1510 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	if len(summary.Unpriced) != 1 || summary.Unpriced[0] != "local/llama3" {
+		t.Errorf("Unpriced = %v", summary.Unpriced)
+	}
+	if len(ledger.records) != 2 {
+		t.Errorf("ledger has %d records, want 2", len(ledger.records))
+	}
+
+	// A restarted tracker rebuilds the month from the ledger.
+	restarted, _ := NewTracker(ledger, Pricing{"gpt-4o": {Input: 2, Output: 8}}, nil, newTestLogger())
+	if got := restarted.Summary().Month; got.Calls != 2 || !approx(got.CostUSD, 0.006) {
+		t.Errorf("restarted month = %+v", got)
+	}
+}
+
+func TestTrackerCheck(t *testing.T) {
+	budgets := []domain.Budget{
+		{Scope: domain.BudgetGlobal, Period: domain.BudgetMonthly, Limit: 10, Action: domain.BudgetWarn},
+		{Scope: domain.BudgetSession, Period: domain.BudgetDaily, Limit: 1, Action: domain.BudgetDowngrade, Downgrade: "cheap"},
+		{Scope: domain.BudgetTenant, ID: "acme", Period: domain.BudgetDaily, Limit: 2, Action: domain.BudgetBlock},
+	}
+	tracker, _ := NewTracker(&memLedger{}, Pricing{"m": {Input: 1_000_000}}, budgets, newTestLogger())
+	spend := func(ctx context.Context, usd int) {
+		tracker.Record(ctx, "p", "m", domain.Usage{PromptTokens: usd, TotalTokens: usd})
+	}
+
+	s1 := domain.ContextWithTenantID(domain.ContextWithSessionID(context.Background(), "s1"), "acme")
+	s2 := domain.ContextWithTenantID(domain.ContextWithSessionID(context.Background(), "s2"), "acme")
+	other := domain.ContextWithSessionID(context.Background(), "s3")
+
+	if d := tracker.Check(s1); d.Action != "" {
+		t.Fatalf("fresh tracker decided %q", d.Action)
+	}
+
+	spend(s1, 1)
+	d := tracker.Check(s1)
+	if d.Action != domain.BudgetDowngrade || d.Budget.Downgrade != "cheap" || d.Budget.Key != "s1" {
+		t.Errorf("s1 over its session budget: %+v", d)
+	}
+	if d := tracker.Check(s2); d.Action != "" {
+		t.Errorf("session budgets are per session, s2 got %q", d.Action)
+	}
+
+	spend(s2, 1)
+	if d := tracker.Check(s2); d.Action != domain.BudgetBlock {
+		t.Errorf("acme over its tenant budget: %q", d.Action)
+	}
+	if d := tracker.Check(other); d.Action != "" {
+		t.Errorf("other tenant got %q", d.Action)
+	}
+
+	spend(other, 8)
+	if d := tracker.Check(other); d.Action != domain.BudgetDowngrade {
+		// s3 is over its own session budget, which outranks the global warning.
+		t.Errorf("other got %q", d.Action)
+	}
+
+	summary := tracker.Summary()
+	if len(summary.Budgets) != 3 || !approx(summary.Budgets[0].Spent, 10) || summary.Budgets[1].Key != "s3" {
+		t.Errorf("unexpected budgets %+v", summary.Budgets)
+	}
+}
+
+func TestTrackerRollover(t *testing.T) {
+	budgets := []domain.Budget{{Scope: domain.BudgetGlobal, Period: domain.BudgetDaily, Limit: 1, Action: domain.BudgetBlock}}
+	tracker, _ := NewTracker(&memLedger{}, Pricing{"m": {Input: 1_000_000}}, budgets, newTestLogger())
+	now := time.Date(2026, 10, 15, 23, 0, 0, 0, time.Local)
+	tracker.now = func() time.Time { return now }
+	tracker.reset(now)
+
+	tracker.Record(context.Background(), "p", "m", domain.Usage{PromptTokens: 1, TotalTokens: 1})
+	if d := tracker.Check(context.Background()); d.Action != domain.BudgetBlock {
+		t.Fatalf("expected block, got %q", d.Action)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if d := tracker.Check(context.Background()); d.Action != "" {
+		t.Errorf("daily budget should reset, got %q", d.Action)
+	}
+	summary := tracker.Summary()
+	if summary.Today.Calls != 0 || summary.Month.Calls != 1 {
+		t.Errorf("unexpected totals after day rollover %+v", summary)
+	}
+
+	now = time.Date(2026, 11, 1, 1, 0, 0, 0, time.Local)
+	if got := tracker.Summary().Month.Calls; got != 0 {
+		t.Errorf("month calls after month rollover = %d", got)
+	}
+}
+
+func TestReport(t *testing.T) {
+	day1 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	records := []domain.CostRecord{
+		{Time: day2, Provider: "openai", Model: "gpt-4o", SessionID: "s1", CostUSD: 1},
+		{Time: day1, Provider: "anthropic", Model: "claude", CostUSD: 3},
+		{Time: day1, Provider: "openai", Model: "gpt-4o", SessionID: "s1", CostUSD: 1},
+	}
+
+	rows, err := Report(records, "provider")
+	if err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Key != "anthropic" || rows[1].Calls != 2 || !approx(rows[1].CostUSD, 2) {
+		t.Errorf("by provider = %+v", rows)
+	}
+
+	rows, _ = Report(records, "day")
+	if len(rows) != 2 || rows[0].Key != "2026-10-01" || rows[0].Calls != 2 {
+		t.Errorf("by day = %+v", rows)
+	}
+
+	rows, _ = Report(records, "session")
+	if len(rows) != 2 || rows[0].Key != "(none)" {
+		t.Errorf("by session = %+v", rows)
+	}
+
+	if _, err := Report(records, "color"); err == nil {
+		t.Error("expected error for unknown grouping")
+	}
+}
@@ -168,6 +168,10 @@ func TestContextBuilderWithMemory(t *testing.T) {
 	if !contains(req.Messages[0].Content, "Relevant Memory Context") {
 		t.Error("system prompt should include memory context")
 	}
+	// Memory comes after the stable, cacheable part of the system prompt.
+	if req.StableSystemLen != len("system") {
+		t.Errorf("StableSystemLen = %d, want %d", req.StableSystemLen, len("system"))
+	}
 }
 
 func TestContextBuilderTruncation(t *testing.T) {
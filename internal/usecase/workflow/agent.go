@@ -0,0 +1,201 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kaptinlin/jsonschema"
+
+	"alfred-ai/internal/domain"
+)
+
+// AgentRunner runs agent steps that address an agent. It sends prompt to
+// the named agent (the default agent when empty) in a fresh session and
+// returns the reply. A non-empty tools list narrows the agent's tools to
+// those names for the call.
+type AgentRunner interface {
+	RunAgent(ctx context.Context, agentID, prompt string, tools []string) (string, error)
+}
+
+// SetAgents enables agent steps that address agents. Call before running
+// pipelines.
+func (m *Manager) SetAgents(agents AgentRunner) {
+	m.agents = agents
+}
+
+// SetModelRouter enables agent steps that name a model preference. Call
+// before running pipelines.
+func (m *Manager) SetModelRouter(router domain.ModelRouter) {
+	m.models = router
+}
+
+// structuredOutputPrompt asks for a reply matching a JSON Schema.
+const structuredOutputPrompt = "\n\nRespond with only a JSON value that matches this JSON Schema. " +
+	"Do not wrap it in markdown fences or add commentary.\n"
+
+func (m *Manager) executeAgentStep(ctx context.Context, step domain.Step, scope templateScope, maxOutput int, start time.Time) (*domain.StepResult, error) {
+	var schema *jsonschema.Schema
+	var format *domain.ResponseFormat
+	if len(step.OutputSchema) > 0 {
+		var err error
+		if schema, err = compileOutputSchema(step.OutputSchema); err != nil {
+			return nil, domain.NewSubSystemError("workflow", "Manager.executeAgentStep", domain.ErrInvalidInput, err.Error())
+		}
+		format = &domain.ResponseFormat{Type: domain.ResponseFormatJSONSchema, Schema: mustMarshal(step.OutputSchema)}
+	}
+
+	reply, err := m.askAgent(ctx, step, m.resolveTemplate(step.Prompt, scope), format)
+	if err != nil {
+		return &domain.StepResult{
+			StepID:   step.ID,
+			Status:   "failed",
+			Output:   toJSON(err.Error()),
+			Error:    err.Error(),
+			Duration: time.Since(start),
+		}, domain.NewDomainError("Manager.executeAgentStep", domain.ErrToolFailure, err.Error())
+	}
+
+	if schema == nil {
+		return &domain.StepResult{
+			StepID:   step.ID,
+			Status:   "completed",
+			Output:   toJSON(m.truncateOutput(reply, maxOutput)),
+			Duration: time.Since(start),
+		}, nil
+	}
+
+	parsed, err := parseStructuredOutput(reply, schema)
+	if err != nil {
+		return &domain.StepResult{
+			StepID:   step.ID,
+			Status:   "failed",
+			Output:   toJSON(m.truncateOutput(reply, maxOutput)),
+			Error:    err.Error(),
+			Duration: time.Since(start),
+		}, domain.NewDomainError("Manager.executeAgentStep", domain.ErrToolFailure, err.Error())
+	}
+	return &domain.StepResult{
+		StepID:   step.ID,
+		Status:   "completed",
+		Output:   json.RawMessage(m.truncateOutput(string(mustMarshal(parsed)), maxOutput)),
+		Duration: time.Since(start),
+	}, nil
+}
+
+// askAgent sends prompt to the step's agent or model and returns the reply.
+// A model is asked for format natively; an agent runs its own tool loop, so
+// the schema is spelled out in its prompt instead.
+func (m *Manager) askAgent(ctx context.Context, step domain.Step, prompt string, format *domain.ResponseFormat) (string, error) {
+	if step.Model == "" {
+		if m.agents == nil {
+			return "", fmt.Errorf("agents are not available to workflows")
+		}
+		if format != nil {
+			prompt += structuredOutputPrompt + string(format.Schema)
+		}
+		tools, err := m.agentTools(step)
+		if err != nil {
+			return "", err
+		}
+		return m.agents.RunAgent(ctx, step.Agent, prompt, tools)
+	}
+
+	if m.models == nil {
+		return "", fmt.Errorf("model routing is not available to workflows")
+	}
+	provider, err := m.models.Route(step.Model)
+	if err != nil {
+		return "", err
+	}
+	resp, err := provider.Chat(ctx, domain.ChatRequest{
+		Messages:       []domain.Message{{Role: domain.RoleUser, Content: prompt}},
+		ResponseFormat: format,
+	})
+	if err != nil {
+		return "", fmt.Errorf("model %s: %w", step.Model, err)
+	}
+	return resp.Message.Content, nil
+}
+
+// agentTools returns the tools an agent step may use: its own list, or
+// every registered tool but the blocked ones. An empty list would hand
+// the agent all of its tools, workflow included.
+func (m *Manager) agentTools(step domain.Step) ([]string, error) {
+	if len(step.Tools) > 0 {
+		return step.Tools, nil
+	}
+	var tools []string
+	if m.toolExec != nil {
+		for _, schema := range m.toolExec.Schemas() {
+			if !blockedTools[schema.Name] {
+				tools = append(tools, schema.Name)
+			}
+		}
+	}
+	if len(tools) == 0 {
+		return nil, fmt.Errorf("step %q (agent): no tools to give the agent besides blocked ones", step.ID)
+	}
+	return tools, nil
+}
+
+// compileOutputSchema compiles a step's output_schema.
+func compileOutputSchema(schema map[string]any) (*jsonschema.Schema, error) {
+	compiled, err := jsonschema.NewCompiler().Compile(mustMarshal(schema))
+	if err != nil {
+		return nil, fmt.Errorf("invalid output_schema: %w", err)
+	}
+	return compiled, nil
+}
+
+// codeFenceRe matches a markdown code fence around a reply.
+var codeFenceRe = regexp.MustCompile("(?s)^```[a-zA-Z]*\\s*(.*?)\\s*```$")
+
+// parseStructuredOutput parses a reply as JSON, ignoring a surrounding code
+// fence, and validates it against schema.
+func parseStructuredOutput(reply string, schema *jsonschema.Schema) (any, error) {
+	raw := strings.TrimSpace(reply)
+	if m := codeFenceRe.FindStringSubmatch(raw); m != nil {
+		raw = m[1]
+	}
+	var parsed any
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return nil, fmt.Errorf("reply is not valid JSON: %w", err)
+	}
+	if result := schema.Validate(parsed); !result.IsValid() {
+		return nil, fmt.Errorf("reply does not match output_schema: %s", result.Error())
+	}
+	return parsed, nil
+}
+
+// validateAgentStep checks an agent step's target, tools and schema.
+func validateAgentStep(s domain.Step) error {
+	if s.Prompt == "" {
+		return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+			fmt.Sprintf("step %q (agent) requires prompt", s.ID))
+	}
+	if s.Agent != "" && s.Model != "" {
+		return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+			fmt.Sprintf("step %q (agent) sets both agent and model", s.ID))
+	}
+	if s.Model != "" && len(s.Tools) > 0 {
+		return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+			fmt.Sprintf("step %q (agent): tools need an agent, not a model", s.ID))
+	}
+	for _, name := range s.Tools {
+		if blockedTools[name] {
+			return domain.NewDomainError("validatePipeline", domain.ErrPermissionDenied,
+				fmt.Sprintf("step %q (agent): tool %q is blocked to prevent recursion", s.ID, name))
+		}
+	}
+	if len(s.OutputSchema) > 0 {
+		if _, err := compileOutputSchema(s.OutputSchema); err != nil {
+			return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+				fmt.Sprintf("step %q (agent): %v", s.ID, err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"alfred-ai/internal/domain"
+)
+
+// stubAgents answers agent steps with canned replies.
+type stubAgents struct {
+	replies []string
+	calls   int
+	agentID string
+	prompt  string
+	tools   []string
+}
+
+func (s *stubAgents) RunAgent(_ context.Context, agentID, prompt string, tools []string) (string, error) {
+	s.agentID, s.prompt, s.tools = agentID, prompt, tools
+	reply := s.replies[min(s.calls, len(s.replies)-1)]
+	s.calls++
+	return reply, nil
+}
+
+type stubProvider struct {
+	reply string
+	req   domain.ChatRequest
+}
+
+func (p *stubProvider) Chat(_ context.Context, req domain.ChatRequest) (*domain.ChatResponse, error) {
+	p.req = req
+	return &domain.ChatResponse{Message: domain.Message{Role: domain.RoleAssistant, Content: p.reply}}, nil
+}
+
+func (p *stubProvider) Name() string { return "stub" }
+
+type stubRouter map[string]domain.LLMProvider
+
+func (r stubRouter) Route(preference string) (domain.LLMProvider, error) {
+	if p, ok := r[preference]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("unknown preference %q", preference)
+}
+
+var summarySchema = map[string]any{
+	"type":     "object",
+	"required": []any{"title", "count"},
+	"properties": map[string]any{
+		"title": map[string]any{"type": "string"},
+		"count": map[string]any{"type": "integer"},
+	},
+}
+
+func TestAgentStep(t *testing.T) {
+	mgr := newTestManager(t, &mockCommandExecutor{stdout: "PR #1, PR #2"})
+	agents := &stubAgents{replies: []string{"Two PRs were merged."}}
+	mgr.SetAgents(agents)
+
+	pipeline := simplePipeline(
+		domain.Step{ID: "fetch", Type: "exec", Command: "echo"},
+		domain.Step{ID: "summarize", Type: "agent", Agent: "writer", Tools: []string{"web_fetch"},
+			Prompt: "Summarize: {{.fetch.output}}"},
+	)
+	run, err := mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "completed" {
+		t.Fatalf("expected completed, got %s (error: %s)", run.Status, run.Error)
+	}
+	if agents.agentID != "writer" || agents.prompt != "Summarize: PR #1, PR #2" {
+		t.Errorf("agent got %q / %q", agents.agentID, agents.prompt)
+	}
+	if len(agents.tools) != 1 || agents.tools[0] != "web_fetch" {
+		t.Errorf("tools = %v, want [web_fetch]", agents.tools)
+	}
+	if out := run.Steps[len(run.Steps)-1].Output; string(out) != `"Two PRs were merged."` {
+		t.Errorf("output = %s", out)
+	}
+}
+
+func TestAgentStepStructuredOutput(t *testing.T) {
+	mgr := newTestManager(t, &mockCommandExecutor{})
+	provider := &stubProvider{reply: "```json\n{\"title\": \"Weekly digest\", \"count\": 3}\n```"}
+	mgr.SetModelRouter(stubRouter{"fast": provider})
+
+	pipeline := simplePipeline(
+		domain.Step{ID: "digest", Type: "agent", Model: "fast", Prompt: "Write a digest", OutputSchema: summarySchema},
+		domain.Step{ID: "post", Type: "transform", Template: "{{.digest.output.title}} ({{.digest.output.count}})"},
+	)
+	run, err := mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "completed" {
+		t.Fatalf("expected completed, got %s (error: %s)", run.Status, run.Error)
+	}
+	if f := provider.req.ResponseFormat; f == nil || !strings.Contains(string(f.Schema), `"required":["title","count"]`) {
+		t.Errorf("request should carry the schema as its response format, got %+v", f)
+	}
+	var output string
+	json.Unmarshal(run.Steps[len(run.Steps)-1].Output, &output)
+	if output != "Weekly digest (3)" {
+		t.Errorf("expected the parsed reply in templates, got %q", output)
+	}
+}
+
+func TestAgentStepSchemaMismatchRetries(t *testing.T) {
+	mgr := newTestManager(t, &mockCommandExecutor{})
+	agents := &stubAgents{replies: []string{"not json", `{"title": "x"}`, `{"title": "x", "count": 1}`}}
+	mgr.SetAgents(agents)
+	mgr.toolExec = &mockToolExecutor{tools: map[string]domain.Tool{
+		"search":   &mockTool{name: "search"},
+		"workflow": &mockTool{name: "workflow"},
+	}}
+
+	pipeline := simplePipeline(domain.Step{
+		ID: "digest", Type: "agent", Prompt: "Write a digest", OutputSchema: summarySchema,
+		Retry: &domain.StepRetry{Attempts: 3, Backoff: 1},
+	})
+	run, err := mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "completed" || agents.calls != 3 {
+		t.Fatalf("expected success on the third reply, got %s after %d calls", run.Status, agents.calls)
+	}
+	if len(agents.tools) != 1 || agents.tools[0] != "search" {
+		t.Errorf("tools = %v, want every tool but workflow", agents.tools)
+	}
+	if !strings.Contains(agents.prompt, `"required":["title","count"]`) {
+		t.Errorf("agent prompt should carry the schema, got %q", agents.prompt)
+	}
+	if !strings.Contains(run.Steps[0].Error, "not valid JSON") || !strings.Contains(run.Steps[1].Error, "output_schema") {
+		t.Errorf("unexpected attempt errors: %q, %q", run.Steps[0].Error, run.Steps[1].Error)
+	}
+}
+
+func TestAgentStepNotConfigured(t *testing.T) {
+	mgr := newTestManager(t, &mockCommandExecutor{})
+
+	run, err := mgr.RunInline(context.Background(), simplePipeline(
+		domain.Step{ID: "a", Type: "agent", Prompt: "hi"},
+	), nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "failed" || !strings.Contains(run.Error, "agents are not available") {
+		t.Errorf("expected failure without agents, got %s (%s)", run.Status, run.Error)
+	}
+}
+
+func TestAgentStepValidation(t *testing.T) {
+	mgr := newTestManager(t, &mockCommandExecutor{})
+
+	tests := []struct {
+		name string
+		step domain.Step
+	}{
+		{"no prompt", domain.Step{ID: "a", Type: "agent"}},
+		{"agent and model", domain.Step{ID: "a", Type: "agent", Prompt: "hi", Agent: "x", Model: "fast"}},
+		{"tools with model", domain.Step{ID: "a", Type: "agent", Prompt: "hi", Model: "fast", Tools: []string{"web_fetch"}}},
+		{"blocked tool", domain.Step{ID: "a", Type: "agent", Prompt: "hi", Tools: []string{"workflow"}}},
+		{"bad schema", domain.Step{ID: "a", Type: "agent", Prompt: "hi", OutputSchema: map[string]any{"type": 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := mgr.RunInline(context.Background(), simplePipeline(tt.step), nil, nil); err == nil {
+				t.Errorf("expected validation error for %s", tt.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,464 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+// Control-flow limits.
+const (
+	maxStepAttempts    = 10
+	defaultStepBackoff = time.Second
+	maxStepBackoff     = time.Minute
+	maxForEachItems    = 1000
+	maxPipelineDepth   = 5
+)
+
+// loopBodyTypes are the step types a foreach body may have.
+var loopBodyTypes = map[string]bool{
+	"exec": true, "http": true, "transform": true, "tool_call": true, "agent": true, "pipeline": true,
+}
+
+// runStep runs a step on its own goroutine, applying its retry policy, and
+// returns the outcome for the scheduler.
+func (m *Manager) runStep(ctx context.Context, run *domain.WorkflowRun, step domain.Step, scope templateScope) stepOutcome {
+	var (
+		result  *domain.StepResult
+		details []domain.StepResult
+		err     error
+	)
+	if step.Type == "foreach" {
+		result, details, err = m.executeForEachStep(ctx, run, step, scope)
+	} else {
+		result, details, err = m.runWithRetry(ctx, run, step, scope)
+	}
+	return stepOutcome{step: step, result: result, details: details, err: err}
+}
+
+// runWithRetry executes step until it succeeds or its retry policy gives
+// up. Each failed attempt before the last is returned as a detail result.
+func (m *Manager) runWithRetry(ctx context.Context, run *domain.WorkflowRun, step domain.Step, scope templateScope) (*domain.StepResult, []domain.StepResult, error) {
+	attempts := 1
+	if step.Retry != nil {
+		attempts = max(step.Retry.Attempts, 1)
+	}
+	var details []domain.StepResult
+	for attempt := 1; ; attempt++ {
+		result, err := m.executeStep(ctx, run, step, scope)
+		if err == nil || attempt >= attempts || ctx.Err() != nil || !shouldRetry(step.Retry, result, err) {
+			return result, details, err
+		}
+		failed := failedResult(step, result, err)
+		failed.Attempt = attempt
+		details = append(details, failed)
+
+		delay := retryBackoff(step.Retry, attempt)
+		m.logger.Warn("workflow step failed, retrying", "run_id", run.ID, "step", step.ID,
+			"attempt", attempt, "retry_in", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return result, details, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// shouldRetry reports whether a failure matches the policy's retry_on
+// conditions.
+func shouldRetry(p *domain.StepRetry, result *domain.StepResult, err error) bool {
+	if len(p.On) == 0 {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	status := 0
+	if result != nil {
+		msg += "\n" + strings.ToLower(result.Error)
+		status = httpStatus(result.Output)
+	}
+	for _, on := range p.On {
+		switch on = strings.ToLower(on); on {
+		case "timeout":
+			if strings.Contains(msg, "deadline exceeded") || strings.Contains(msg, "timeout") {
+				return true
+			}
+		case "4xx":
+			if status >= 400 && status < 500 {
+				return true
+			}
+		case "5xx":
+			if status >= 500 && status < 600 {
+				return true
+			}
+		default:
+			if strings.Contains(msg, on) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// httpStatus returns the status code recorded in an http step's output, or
+// 0 for other outputs.
+func httpStatus(output json.RawMessage) int {
+	var resp struct {
+		Status int `json:"status"`
+	}
+	if json.Unmarshal(output, &resp) != nil {
+		return 0
+	}
+	return resp.Status
+}
+
+// retryBackoff returns the wait after the given failed attempt.
+func retryBackoff(p *domain.StepRetry, attempt int) time.Duration {
+	delay := defaultStepBackoff
+	if p.Backoff > 0 {
+		delay = p.Backoff
+	}
+	for range attempt - 1 {
+		if delay >= maxStepBackoff {
+			break
+		}
+		delay *= 2
+	}
+	return min(delay, maxStepBackoff)
+}
+
+// failedResult returns the result to record for a failed step, building
+// one when the step failed before producing any.
+func failedResult(step domain.Step, result *domain.StepResult, err error) domain.StepResult {
+	if result == nil {
+		return domain.StepResult{
+			StepID: step.ID,
+			Status: "failed",
+			Output: toJSON(err.Error()),
+			Error:  err.Error(),
+		}
+	}
+	r := *result
+	r.Status = "failed"
+	if r.Error == "" {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// executeForEachStep runs step.Do once per item, in order. Every iteration
+// and every retried attempt is returned as a detail result; the step's
+// output is the list of iteration outputs. A failed iteration stops the
+// loop unless the body's on_error is "continue".
+func (m *Manager) executeForEachStep(ctx context.Context, run *domain.WorkflowRun, step domain.Step, scope templateScope) (*domain.StepResult, []domain.StepResult, error) {
+	start := time.Now()
+	if step.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, step.Timeout)
+		defer cancel()
+	}
+
+	items, err := resolveItems(step.Items, buildTemplateData(scope))
+	if err != nil {
+		return nil, nil, domain.NewSubSystemError("workflow", "Manager.executeForEachStep", domain.ErrInvalidInput, err.Error())
+	}
+
+	body := *step.Do
+	if body.ID == "" {
+		body.ID = step.ID
+	}
+	var details []domain.StepResult
+	outputs := make([]any, 0, len(items))
+	for i, item := range items {
+		iterScope := scope
+		iterScope.loop = map[string]any{"item": item, "index": i}
+
+		if body.Condition != "" {
+			if ok, _ := m.evaluateCondition(body.Condition, iterScope); !ok {
+				details = append(details, domain.StepResult{
+					StepID: step.ID, Status: "skipped", Output: json.RawMessage(`null`), Iteration: i + 1,
+				})
+				outputs = append(outputs, nil)
+				continue
+			}
+		}
+
+		result, attempts, err := m.runWithRetry(ctx, run, body, iterScope)
+		for _, a := range attempts {
+			a.StepID, a.Iteration = step.ID, i+1
+			details = append(details, a)
+		}
+		var r domain.StepResult
+		if err != nil {
+			r = failedResult(body, result, err)
+		} else {
+			r = *result
+		}
+		r.StepID, r.Iteration = step.ID, i+1
+		details = append(details, r)
+		outputs = append(outputs, decodeOutput(r.Output))
+
+		if err != nil && body.OnError != "continue" {
+			msg := fmt.Sprintf("item %d: %v", i, err)
+			return &domain.StepResult{
+				StepID:   step.ID,
+				Status:   "failed",
+				Output:   json.RawMessage(m.truncateOutput(string(mustMarshal(outputs)), run.EffectiveMaxOutput)),
+				Error:    msg,
+				Duration: time.Since(start),
+			}, details, domain.NewDomainError("Manager.executeForEachStep", domain.ErrToolFailure, msg)
+		}
+	}
+
+	return &domain.StepResult{
+		StepID:   step.ID,
+		Status:   "completed",
+		Output:   json.RawMessage(m.truncateOutput(string(mustMarshal(outputs)), run.EffectiveMaxOutput)),
+		Duration: time.Since(start),
+	}, details, nil
+}
+
+// resolveItems looks up a dotted path in the template data and returns
+// the list it holds. Text that is not a JSON array yields one item per
+// non-empty line.
+func resolveItems(path string, data map[string]any) ([]any, error) {
+	var v any = data
+	for _, key := range strings.Split(path, ".") {
+		var ok bool
+		switch cur := v.(type) {
+		case map[string]any:
+			v, ok = cur[key]
+		case map[string]string:
+			v, ok = cur[key]
+		}
+		if !ok {
+			return nil, fmt.Errorf("items %q: %q not found", path, key)
+		}
+	}
+
+	var items []any
+	switch v := v.(type) {
+	case nil:
+	case []any:
+		items = v
+	case string:
+		if json.Unmarshal([]byte(v), &items) == nil {
+			break
+		}
+		for _, line := range strings.Split(v, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				items = append(items, line)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("items %q is not a list", path)
+	}
+	if len(items) > maxForEachItems {
+		return nil, fmt.Errorf("items %q has %d entries (max %d)", path, len(items), maxForEachItems)
+	}
+	return items, nil
+}
+
+// pipelineCallersKey carries the names of the pipelines a nested run was
+// started from.
+type pipelineCallersKey struct{}
+
+// executePipelineStep runs the named pipeline as a child run and returns
+// the outputs of its steps by step ID. The child does not take a
+// MaxRunning slot, since its parent already holds one, and cannot pause
+// for approval.
+func (m *Manager) executePipelineStep(ctx context.Context, run *domain.WorkflowRun, step domain.Step, scope templateScope, maxOutput int, start time.Time) (*domain.StepResult, error) {
+	pm := m.pipelines.Load().(map[string]domain.Pipeline)
+	child, ok := pm[step.Pipeline]
+	if !ok {
+		return nil, domain.NewSubSystemError("workflow", "Manager.executePipelineStep", domain.ErrNotFound, step.Pipeline)
+	}
+
+	callers, _ := ctx.Value(pipelineCallersKey{}).([]string)
+	callers = append(slices.Clone(callers), run.PipelineName)
+	if slices.Contains(callers, child.Name) || len(callers) > maxPipelineDepth {
+		return nil, domain.NewSubSystemError("workflow", "Manager.executePipelineStep", domain.ErrInvalidInput,
+			fmt.Sprintf("pipeline %q cannot be nested in %s", child.Name, strings.Join(callers, " -> ")))
+	}
+
+	args := make(map[string]string, len(step.PipelineArgs))
+	for k, v := range step.PipelineArgs {
+		args[k] = m.resolveTemplate(v, scope)
+	}
+	childRun, err := m.newRun(ctx, child, args, &RunOptions{MaxOutput: maxOutput})
+	if err != nil {
+		return nil, err
+	}
+	childRun.ParentRunID = run.ID
+	childRun, err = m.continueExecution(context.WithValue(ctx, pipelineCallersKey{}, callers), childRun)
+	if err != nil {
+		return nil, err
+	}
+	if childRun.Status == "paused" {
+		childRun.Status = "failed"
+		childRun.Error = "approval steps are not supported in nested pipelines"
+		childRun.Approvals, childRun.ResumeToken = nil, ""
+		childRun.UpdatedAt = time.Now()
+		m.saveRun(ctx, childRun)
+	}
+
+	if childRun.Status != "completed" {
+		msg := fmt.Sprintf("pipeline %s %s: %s", child.Name, childRun.Status, childRun.Error)
+		return &domain.StepResult{
+			StepID:   step.ID,
+			Status:   "failed",
+			Output:   mustMarshal(map[string]string{"run_id": childRun.ID, "status": childRun.Status, "error": childRun.Error}),
+			Error:    msg,
+			Duration: time.Since(start),
+		}, domain.NewDomainError("Manager.executePipelineStep", domain.ErrToolFailure, msg)
+	}
+
+	outputs := make(map[string]any)
+	for _, r := range childRun.Steps {
+		if r.Status == "completed" && !r.IsDetail() {
+			outputs[r.StepID] = decodeOutput(r.Output)
+		}
+	}
+	output := mustMarshal(map[string]any{"run_id": childRun.ID, "outputs": outputs})
+	return &domain.StepResult{
+		StepID:   step.ID,
+		Status:   "completed",
+		Output:   json.RawMessage(m.truncateOutput(string(output), maxOutput)),
+		Duration: time.Since(start),
+	}, nil
+}
+
+// routeFailure applies step's on_error policy to a failure that survived
+// its retries and reports whether the run goes on.
+func (m *Manager) routeFailure(run *domain.WorkflowRun, step domain.Step, err error) bool {
+	switch step.OnError {
+	case "", "fail":
+		return false
+	case "continue":
+		m.logger.Warn("workflow step failed, continuing", "run_id", run.ID, "step", step.ID, "error", err)
+	default:
+		m.logger.Warn("workflow step failed, compensating", "run_id", run.ID, "step", step.ID,
+			"compensation", step.OnError, "error", err)
+		if run.StepStates[step.OnError] == "standby" {
+			run.StepStates[step.OnError] = "pending"
+		}
+	}
+	return true
+}
+
+// compensationSteps returns the IDs of the steps that on_error routes to.
+func compensationSteps(p domain.Pipeline) map[string]bool {
+	ids := make(map[string]bool)
+	for _, s := range p.Steps {
+		if isCompensationRoute(s.OnError) {
+			ids[s.OnError] = true
+		}
+	}
+	return ids
+}
+
+func isCompensationRoute(onError string) bool {
+	return onError != "" && onError != "fail" && onError != "continue"
+}
+
+// skipStandbySteps marks the compensation steps no failure routed to as
+// skipped once the run is over.
+func skipStandbySteps(run *domain.WorkflowRun) {
+	for id, state := range run.StepStates {
+		if state == "standby" {
+			run.StepStates[id] = "skipped"
+		}
+	}
+}
+
+// validateRetry checks a step's retry policy, if any.
+func validateRetry(s domain.Step) error {
+	if s.Retry == nil {
+		return nil
+	}
+	if s.Retry.Attempts < 0 || s.Retry.Attempts > maxStepAttempts {
+		return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+			fmt.Sprintf("step %q: retry attempts must be between 0 and %d", s.ID, maxStepAttempts))
+	}
+	if s.Retry.Backoff < 0 {
+		return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+			fmt.Sprintf("step %q: retry backoff must not be negative", s.ID))
+	}
+	return nil
+}
+
+// validateForEach checks a foreach step and its body.
+func validateForEach(s domain.Step) error {
+	if s.Items == "" || s.Do == nil {
+		return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+			fmt.Sprintf("step %q (foreach) requires items and do", s.ID))
+	}
+	if s.Retry != nil {
+		return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+			fmt.Sprintf("step %q (foreach): set retry on do", s.ID))
+	}
+	body := *s.Do
+	body.ID = s.ID
+	if !loopBodyTypes[body.Type] {
+		return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+			fmt.Sprintf("step %q (foreach) cannot loop over a %q step", s.ID, body.Type))
+	}
+	if len(body.DependsOn) > 0 || isCompensationRoute(body.OnError) {
+		return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+			fmt.Sprintf("step %q (foreach): do supports neither depends_on nor compensation steps", s.ID))
+	}
+	return validateStepFields(body)
+}
+
+// validateOnError checks that on_error routes to existing steps that no
+// step depends on and that wait for no other step, and that routes do not
+// loop.
+func validateOnError(steps []domain.Step, ids map[string]bool) error {
+	routes := make(map[string]string)
+	for _, s := range steps {
+		if !isCompensationRoute(s.OnError) {
+			continue
+		}
+		if s.OnError == s.ID {
+			return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+				fmt.Sprintf("step %q routes errors to itself", s.ID))
+		}
+		if !ids[s.OnError] {
+			return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+				fmt.Sprintf("step %q routes errors to unknown step %q", s.ID, s.OnError))
+		}
+		routes[s.ID] = s.OnError
+	}
+
+	compensations := compensationSteps(domain.Pipeline{Steps: steps})
+	for _, s := range steps {
+		if compensations[s.ID] && len(s.DependsOn) > 0 {
+			return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+				fmt.Sprintf("compensation step %q must not declare depends_on", s.ID))
+		}
+		for _, d := range s.DependsOn {
+			if compensations[d] {
+				return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+					fmt.Sprintf("step %q depends on compensation step %q", s.ID, d))
+			}
+		}
+	}
+
+	for id := range routes {
+		seen := map[string]bool{id: true}
+		path := []string{id}
+		for next, ok := routes[id]; ok; next, ok = routes[next] {
+			path = append(path, next)
+			if seen[next] {
+				return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+					fmt.Sprintf("on_error cycle: %s", strings.Join(path, " -> ")))
+			}
+			seen[next] = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+// flakyCommandExecutor fails with err until it has been called
+// failures+1 times.
+type flakyCommandExecutor struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (m *flakyCommandExecutor) Execute(_ context.Context, _ string, _ []string, _ string) (string, string, error) {
+	m.calls++
+	if m.calls <= m.failures {
+		return "", "", m.err
+	}
+	return fmt.Sprintf("ok after %d", m.calls), "", nil
+}
+
+func TestStepRetry(t *testing.T) {
+	shell := &flakyCommandExecutor{failures: 2, err: fmt.Errorf("connection reset")}
+	mgr := newTestManager(t, shell)
+
+	pipeline := simplePipeline(domain.Step{
+		ID: "s1", Type: "exec", Command: "echo",
+		Retry: &domain.StepRetry{Attempts: 3, Backoff: time.Millisecond},
+	})
+	run, err := mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "completed" {
+		t.Fatalf("expected completed, got %s (error: %s)", run.Status, run.Error)
+	}
+	if len(run.Steps) != 3 {
+		t.Fatalf("expected 2 failed attempts and the result, got %+v", run.Steps)
+	}
+	for i, r := range run.Steps[:2] {
+		if r.Attempt != i+1 || r.Status != "failed" || !strings.Contains(r.Error, "connection reset") {
+			t.Errorf("attempt %d: unexpected result %+v", i+1, r)
+		}
+	}
+	if last := run.Steps[2]; last.IsDetail() || string(last.Output) != `"ok after 3"` {
+		t.Errorf("unexpected final result %+v", last)
+	}
+}
+
+func TestStepRetryOn(t *testing.T) {
+	shell := &flakyCommandExecutor{failures: 5, err: fmt.Errorf("exit code 1")}
+	mgr := newTestManager(t, shell)
+
+	pipeline := simplePipeline(domain.Step{
+		ID: "s1", Type: "exec", Command: "echo",
+		Retry: &domain.StepRetry{Attempts: 3, Backoff: time.Millisecond, On: []string{"timeout", "5xx"}},
+	})
+	run, err := mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "failed" {
+		t.Fatalf("expected failed, got %s", run.Status)
+	}
+	if shell.calls != 1 {
+		t.Errorf("non-matching failure should not be retried, got %d calls", shell.calls)
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	http503 := &domain.StepResult{Output: json.RawMessage(`{"status":503,"body":""}`), Error: "HTTP 503"}
+	tests := []struct {
+		name   string
+		on     []string
+		result *domain.StepResult
+		err    error
+		want   bool
+	}{
+		{"any", nil, nil, fmt.Errorf("boom"), true},
+		{"timeout", []string{"timeout"}, nil, context.DeadlineExceeded, true},
+		{"5xx", []string{"5xx"}, http503, fmt.Errorf("HTTP 503"), true},
+		{"4xx on 503", []string{"4xx"}, http503, fmt.Errorf("HTTP 503"), false},
+		{"substring", []string{"Rate Limit"}, nil, fmt.Errorf("rate limit exceeded"), true},
+		{"no match", []string{"timeout"}, nil, fmt.Errorf("exit code 1"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldRetry(&domain.StepRetry{Attempts: 2, On: tt.on}, tt.result, tt.err); got != tt.want {
+				t.Errorf("shouldRetry = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := &domain.StepRetry{Attempts: 5, Backoff: 100 * time.Millisecond}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}
+	for i, w := range want {
+		if got := retryBackoff(p, i+1); got != w {
+			t.Errorf("retryBackoff(attempt %d) = %v, want %v", i+1, got, w)
+		}
+	}
+	if got := retryBackoff(&domain.StepRetry{Attempts: 2}, 1); got != time.Second {
+		t.Errorf("default backoff = %v, want 1s", got)
+	}
+	if got := retryBackoff(&domain.StepRetry{Backoff: 40 * time.Second}, 3); got != time.Minute {
+		t.Errorf("backoff = %v, want capped at 1m", got)
+	}
+}
+
+func TestForEachStep(t *testing.T) {
+	mgr := newTestManager(t, &mockCommandExecutor{stdout: "a\nb\n\nc\n"})
+
+	pipeline := simplePipeline(
+		domain.Step{ID: "list", Type: "exec", Command: "echo"},
+		domain.Step{ID: "each", Type: "foreach", Items: "list.output",
+			Do: &domain.Step{Type: "transform", Template: "{{.index}}:{{.item}}"}},
+		domain.Step{ID: "join", Type: "transform", Template: `{{range .each.output}}{{.}} {{end}}`},
+	)
+	run, err := mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "completed" {
+		t.Fatalf("expected completed, got %s (error: %s)", run.Status, run.Error)
+	}
+
+	var iterations []domain.StepResult
+	var each *domain.StepResult
+	for i, r := range run.Steps {
+		if r.StepID != "each" {
+			continue
+		}
+		if r.IsDetail() {
+			iterations = append(iterations, r)
+		} else {
+			each = &run.Steps[i]
+		}
+	}
+	if len(iterations) != 3 {
+		t.Fatalf("expected 3 iterations, got %+v", iterations)
+	}
+	for i, r := range iterations {
+		if r.Iteration != i+1 || r.Status != "completed" {
+			t.Errorf("iteration %d: unexpected result %+v", i+1, r)
+		}
+	}
+	if each == nil || string(each.Output) != `["0:a","1:b","2:c"]` {
+		t.Errorf("unexpected foreach result %+v", each)
+	}
+
+	var joined string
+	json.Unmarshal(run.Steps[len(run.Steps)-1].Output, &joined)
+	if joined != "0:a 1:b 2:c " {
+		t.Errorf("expected the loop output to feed later steps, got %q", joined)
+	}
+}
+
+func TestForEachStepFailure(t *testing.T) {
+	shell := &flakyCommandExecutor{failures: 1, err: fmt.Errorf("exit code 1")}
+	mgr := newTestManager(t, shell)
+
+	items := domain.Step{ID: "items", Type: "transform", Template: `["x","y","z"]`}
+
+	// A failed iteration stops the loop...
+	pipeline := simplePipeline(items, domain.Step{ID: "each", Type: "foreach", Items: "items.output",
+		Do: &domain.Step{Type: "exec", Command: "echo", Args: []string{"{{.item}}"}}})
+	run, err := mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "failed" || !strings.Contains(run.Error, "item 0") || shell.calls != 1 {
+		t.Fatalf("expected failure on the first item, got %s (%s) after %d calls", run.Status, run.Error, shell.calls)
+	}
+
+	// ...unless the body continues on error.
+	shell.calls = 0
+	pipeline.Steps[1].Do.OnError = "continue"
+	run, err = mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "completed" || shell.calls != 3 {
+		t.Fatalf("expected all items to run, got %s after %d calls", run.Status, shell.calls)
+	}
+}
+
+func TestOnErrorContinue(t *testing.T) {
+	mgr := newTestManager(t, &mockCommandExecutor{stdout: "ok"})
+
+	pipeline := simplePipeline(
+		domain.Step{ID: "bad", Type: "exec", Command: "rm", OnError: "continue"},
+		domain.Step{ID: "report", Type: "transform", Template: "bad was {{.bad.status}}"},
+	)
+	run, err := mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "completed" {
+		t.Fatalf("expected completed, got %s (error: %s)", run.Status, run.Error)
+	}
+	if run.StepStates["bad"] != "failed" {
+		t.Errorf("bad: expected failed, got %s", run.StepStates["bad"])
+	}
+	var output string
+	json.Unmarshal(run.Steps[len(run.Steps)-1].Output, &output)
+	if output != "bad was failed" {
+		t.Errorf("expected 'bad was failed', got %q", output)
+	}
+}
+
+func TestOnErrorCompensation(t *testing.T) {
+	mgr := newTestManager(t, &mockCommandExecutor{stdout: "ok"})
+
+	pipeline := simplePipeline(
+		domain.Step{ID: "deploy", Type: "exec", Command: "rm", OnError: "rollback"},
+		domain.Step{ID: "notify", Type: "transform", Template: "deploy {{.deploy.status}}, rollback {{.rollback.status}}"},
+		domain.Step{ID: "rollback", Type: "exec", Command: "echo"},
+	)
+	run, err := mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "completed" {
+		t.Fatalf("expected completed, got %s (error: %s)", run.Status, run.Error)
+	}
+	if run.StepStates["rollback"] != "completed" {
+		t.Errorf("rollback: expected completed, got %s", run.StepStates["rollback"])
+	}
+	var output string
+	json.Unmarshal(run.Steps[len(run.Steps)-1].Output, &output)
+	if output != "deploy failed, rollback completed" {
+		t.Errorf("dependents should wait for the compensation, got %q", output)
+	}
+
+	// Without a failure the compensation step is skipped.
+	pipeline.Steps[0].Command = "echo"
+	run, err = mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "completed" || run.StepStates["rollback"] != "skipped" {
+		t.Errorf("expected completed run with skipped rollback, got %s / %s", run.Status, run.StepStates["rollback"])
+	}
+}
+
+func TestPipelineStep(t *testing.T) {
+	mgr := newTestManager(t, &mockCommandExecutor{})
+
+	greet := `
+name: greet
+args:
+  name:
+    required: true
+steps:
+  - id: hello
+    type: transform
+    template: "hello {{.args.name}}"
+`
+	loop := `
+name: loop
+steps:
+  - id: again
+    type: pipeline
+    pipeline: loop
+`
+	os.WriteFile(filepath.Join(mgr.cfg.PipelineDir, "greet.yaml"), []byte(greet), 0644)
+	os.WriteFile(filepath.Join(mgr.cfg.PipelineDir, "loop.yaml"), []byte(loop), 0644)
+	if err := mgr.LoadPipelines(); err != nil {
+		t.Fatalf("LoadPipelines: %v", err)
+	}
+
+	pipeline := simplePipeline(
+		domain.Step{ID: "sub", Type: "pipeline", Pipeline: "greet",
+			PipelineArgs: map[string]string{"name": "{{.args.who}}"}},
+		domain.Step{ID: "out", Type: "transform", Template: "{{.sub.output.outputs.hello}}!"},
+	)
+	run, err := mgr.RunInline(context.Background(), pipeline, map[string]string{"who": "bob"}, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "completed" {
+		t.Fatalf("expected completed, got %s (error: %s)", run.Status, run.Error)
+	}
+	var output string
+	json.Unmarshal(run.Steps[len(run.Steps)-1].Output, &output)
+	if output != "hello bob!" {
+		t.Errorf("expected 'hello bob!', got %q", output)
+	}
+
+	// A missing required arg fails the step.
+	pipeline.Steps[0].PipelineArgs = nil
+	if run, _ = mgr.RunInline(context.Background(), pipeline, nil, nil); run.Status != "failed" {
+		t.Errorf("expected failed without the required arg, got %s", run.Status)
+	}
+
+	// Pipelines cannot call themselves.
+	run, err = mgr.Run(context.Background(), "loop", nil, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if run.Status != "failed" || !strings.Contains(run.Error, "cannot be nested") {
+		t.Errorf("expected recursion to fail, got %s (%s)", run.Status, run.Error)
+	}
+}
+
+func TestControlStepValidation(t *testing.T) {
+	mgr := newTestManager(t, &mockCommandExecutor{})
+
+	echo := domain.Step{ID: "a", Type: "exec", Command: "echo"}
+	tests := []struct {
+		name  string
+		steps []domain.Step
+	}{
+		{"foreach without do", []domain.Step{{ID: "a", Type: "foreach", Items: "args.x"}}},
+		{"foreach over approval", []domain.Step{{ID: "a", Type: "foreach", Items: "args.x",
+			Do: &domain.Step{Type: "approval"}}}},
+		{"foreach invalid body", []domain.Step{{ID: "a", Type: "foreach", Items: "args.x",
+			Do: &domain.Step{Type: "exec"}}}},
+		{"pipeline without name", []domain.Step{{ID: "a", Type: "pipeline"}}},
+		{"too many attempts", []domain.Step{{ID: "a", Type: "exec", Command: "echo",
+			Retry: &domain.StepRetry{Attempts: 11}}}},
+		{"on_error unknown step", []domain.Step{{ID: "a", Type: "exec", Command: "echo", OnError: "nope"}}},
+		{"on_error itself", []domain.Step{{ID: "a", Type: "exec", Command: "echo", OnError: "a"}}},
+		{"on_error cycle", []domain.Step{
+			{ID: "a", Type: "exec", Command: "echo", OnError: "b"},
+			{ID: "b", Type: "exec", Command: "echo", OnError: "a"},
+		}},
+		{"compensation with depends_on", []domain.Step{
+			{ID: "a", Type: "exec", Command: "echo", OnError: "b"},
+			{ID: "b", Type: "exec", Command: "echo", DependsOn: []string{"a"}},
+		}},
+		{"depends on compensation", []domain.Step{
+			echo,
+			{ID: "b", Type: "exec", Command: "echo", OnError: "c"},
+			{ID: "c", Type: "exec", Command: "echo"},
+			{ID: "d", Type: "exec", Command: "echo", DependsOn: []string{"c"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := mgr.RunInline(context.Background(), simplePipeline(tt.steps...), nil, nil); err == nil {
+				t.Errorf("expected validation error for %s", tt.name)
+			}
+		})
+	}
+}
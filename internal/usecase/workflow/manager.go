@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"text/template"
 	"time"
@@ -58,9 +59,12 @@ type Manager struct {
 	bus        domain.EventBus
 	logger     *slog.Logger
 	toolExec   domain.ToolExecutor // optional; nil = tool_call steps rejected
+	agents     AgentRunner         // optional; nil = agent steps addressing agents fail
+	models     domain.ModelRouter  // optional; nil = agent steps naming a model fail
 
 	pipelines atomic.Value // map[string]domain.Pipeline
 	running   atomic.Int32
+	runLocks  sync.Map // run ID -> *sync.Mutex, serializes Resume; dropped when the run ends
 }
 
 // NewManager creates a new workflow engine.
@@ -169,33 +173,65 @@ func (m *Manager) RunInline(ctx context.Context, pipeline domain.Pipeline, env m
 	return m.executePipeline(ctx, pipeline, env, opts)
 }
 
-// Resume continues a paused workflow.
+// Resume approves or denies one pending approval of a paused workflow.
+// Approving releases the steps that depend on the approval step; the run
+// stays paused while other branches still wait for approval. Denying ends
+// the whole run.
 func (m *Manager) Resume(ctx context.Context, token string, approve bool) (*domain.WorkflowRun, error) {
 	run, err := m.store.GetRunByToken(ctx, token)
 	if err != nil {
 		return nil, domain.NewSubSystemError("workflow", "Manager.Resume", domain.ErrInvalidInput, err.Error())
 	}
 
+	// Serialize resumes of the same run, then reload it so a concurrent
+	// resume of a sibling approval is not lost.
+	mu, _ := m.runLocks.LoadOrStore(run.ID, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+	if run, err = m.store.GetRunByToken(ctx, token); err != nil {
+		return nil, domain.NewSubSystemError("workflow", "Manager.Resume", domain.ErrInvalidInput, err.Error())
+	}
+
+	initStepStates(run)
+	var stepID string
+	if i := run.FindApproval(token); i >= 0 {
+		stepID = run.Approvals[i].StepID
+		run.Approvals = append(run.Approvals[:i], run.Approvals[i+1:]...)
+	}
+
 	if !approve {
+		if stepID != "" {
+			run.StepStates[stepID] = "denied"
+		}
 		run.Status = "denied"
 		run.ResumeToken = ""
+		run.Approvals = nil
 		run.UpdatedAt = time.Now()
 		if err := m.store.SaveRun(ctx, *run); err != nil {
 			m.logger.Warn("failed to save denied run", "run_id", run.ID, "error", err)
 		}
+		m.runLocks.Delete(run.ID)
 		return run, nil
 	}
 
 	// Clear approval state and continue execution.
+	if stepID != "" {
+		run.StepStates[stepID] = "completed"
+	}
 	run.ResumeToken = ""
 	run.ApprovalMessage = ""
 	run.Status = "running"
-	run.CurrentStep++ // move past the approval step
 	run.UpdatedAt = time.Now()
 
 	m.emitEvent(ctx, domain.EventWorkflowResumed, map[string]string{"run_id": run.ID})
 
-	return m.continueExecution(ctx, run)
+	run, err = m.continueExecution(ctx, run)
+	// A finished run has no approvals left, so a resume still waiting on
+	// the lock fails its reload and the entry can go.
+	if run != nil && run.Status != "paused" {
+		m.runLocks.Delete(run.ID)
+	}
+	return run, err
 }
 
 // GetRun returns a workflow run by ID.
@@ -203,9 +239,69 @@ func (m *Manager) GetRun(ctx context.Context, runID string) (*domain.WorkflowRun
 	return m.store.GetRun(ctx, runID)
 }
 
-// ListRuns returns recent workflow runs.
-func (m *Manager) ListRuns(ctx context.Context, limit int) ([]domain.WorkflowRun, error) {
-	return m.store.ListRuns(ctx, limit)
+// ListRuns returns workflow runs matching filter, newest first.
+func (m *Manager) ListRuns(ctx context.Context, filter domain.WorkflowRunFilter) ([]domain.WorkflowRun, error) {
+	return m.store.ListRuns(ctx, filter)
+}
+
+// RecoverRuns handles runs a crash or restart left in the running state.
+// Runs whose pipeline sets on_interrupt: resume continue in the background
+// from their last checkpoint, restarting the steps that were in flight;
+// all others, and nested runs whose parent step is gone, are marked
+// failed. It returns how many runs were resumed.
+func (m *Manager) RecoverRuns(ctx context.Context) (int, error) {
+	runs, err := m.store.ListRuns(ctx, domain.WorkflowRunFilter{Status: "running"})
+	if err != nil {
+		return 0, domain.WrapOp("list interrupted runs", err)
+	}
+
+	resumed := 0
+	for i := range runs {
+		run := &runs[i]
+		initStepStates(run)
+		if run.ParentRunID != "" || run.Pipeline.OnInterrupt != domain.PipelineInterruptResume {
+			m.failInterrupted(ctx, run)
+			continue
+		}
+
+		for id, state := range run.StepStates {
+			if state == "running" {
+				run.StepStates[id] = "pending"
+			}
+		}
+		run.UpdatedAt = time.Now()
+		m.logger.Info("resuming interrupted workflow run", "run_id", run.ID, "pipeline", run.PipelineName)
+		m.emitEvent(ctx, domain.EventWorkflowResumed, map[string]string{"run_id": run.ID})
+		m.running.Add(1)
+		resumed++
+		go func() {
+			defer m.running.Add(-1)
+			m.continueExecution(ctx, run) //nolint:errcheck
+		}()
+	}
+	return resumed, nil
+}
+
+// failInterrupted marks an interrupted run and its in-flight steps failed.
+func (m *Manager) failInterrupted(ctx context.Context, run *domain.WorkflowRun) {
+	const msg = "interrupted by restart"
+	for id, state := range run.StepStates {
+		if state == "running" {
+			run.StepStates[id] = "failed"
+		}
+	}
+	skipStandbySteps(run)
+	run.Status = "failed"
+	run.Error = msg
+	run.UpdatedAt = time.Now()
+	if err := m.store.SaveRun(ctx, *run); err != nil {
+		m.logger.Warn("failed to save interrupted run", "run_id", run.ID, "error", err)
+	}
+	m.logger.Warn("workflow run interrupted", "run_id", run.ID, "pipeline", run.PipelineName)
+	m.emitEvent(ctx, domain.EventWorkflowFailed, map[string]string{
+		"run_id": run.ID,
+		"error":  msg,
+	})
 }
 
 // --- internal execution ---
@@ -218,6 +314,16 @@ func (m *Manager) executePipeline(ctx context.Context, pipeline domain.Pipeline,
 	m.running.Add(1)
 	defer m.running.Add(-1)
 
+	run, err := m.newRun(ctx, pipeline, env, opts)
+	if err != nil {
+		return nil, err
+	}
+	return m.continueExecution(ctx, run)
+}
+
+// newRun prepares a run of pipeline: it merges arg defaults, pipeline env
+// and caller env, checks required args and clamps the per-call overrides.
+func (m *Manager) newRun(ctx context.Context, pipeline domain.Pipeline, env map[string]string, opts *RunOptions) (*domain.WorkflowRun, error) {
 	// Merge pipeline args defaults with caller env.
 	mergedEnv := make(map[string]string)
 	for name, arg := range pipeline.Args {
@@ -267,10 +373,24 @@ func (m *Manager) executePipeline(ctx context.Context, pipeline domain.Pipeline,
 		"run_id":   run.ID,
 		"pipeline": pipeline.Name,
 	})
+	return run, nil
+}
 
-	return m.continueExecution(ctx, run)
+// stepOutcome carries the result of a step run on its own goroutine back
+// to the scheduler.
+type stepOutcome struct {
+	step    domain.Step
+	result  *domain.StepResult
+	details []domain.StepResult // failed attempts and foreach iterations
+	err     error
 }
 
+// continueExecution schedules the run's steps as a dependency graph. Every
+// step whose dependencies have finished starts on its own goroutine; only
+// this goroutine mutates run. Execution stops when nothing is left to
+// start, either because all steps finished or because the remaining ones
+// wait on an approval. A failure ends the run unless the step's on_error
+// handles it.
 func (m *Manager) continueExecution(ctx context.Context, run *domain.WorkflowRun) (*domain.WorkflowRun, error) {
 	timeout := run.EffectiveTimeout
 	if timeout <= 0 {
@@ -279,56 +399,73 @@ func (m *Manager) continueExecution(ctx context.Context, run *domain.WorkflowRun
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	steps := run.Pipeline.Steps
-	for i := run.CurrentStep; i < len(steps); i++ {
-		step := steps[i]
-		run.CurrentStep = i
+	// Cancelled on the first unhandled failure so in-flight siblings stop
+	// early.
+	stepCtx, cancelSteps := context.WithCancel(ctx)
+	defer cancelSteps()
 
-		// Evaluate condition.
-		if step.Condition != "" {
-			ok, err := m.evaluateCondition(step.Condition, run.Steps, run.Env)
-			if err != nil {
-				m.logger.Warn("condition evaluation failed", "step", step.ID, "error", err)
-			}
-			if !ok {
-				run.Steps = append(run.Steps, domain.StepResult{
-					StepID: step.ID,
-					Status: "skipped",
-					Output: json.RawMessage(`null`),
-				})
-				continue
-			}
-		}
+	initStepStates(run)
+	deps := stepDependencies(run.Pipeline)
+	outcomes := make(chan stepOutcome)
+	inFlight := 0
+	var failErr error
 
-		result, err := m.executeStep(ctx, run, step)
-		if err != nil {
-			run.Status = "failed"
-			run.Error = err.Error()
-			run.UpdatedAt = time.Now()
-			m.store.SaveRun(ctx, *run)
-			m.emitEvent(ctx, domain.EventWorkflowFailed, map[string]string{
-				"run_id": run.ID,
-				"error":  run.Error,
-			})
-			return run, nil
+	for {
+		if failErr == nil {
+			inFlight += m.startReadySteps(stepCtx, run, deps, outcomes)
+		}
+		if inFlight == 0 {
+			break
+		}
+		m.checkpoint(ctx, run)
+
+		out := <-outcomes
+		inFlight--
+		run.Steps = append(run.Steps, out.details...)
+		if out.err != nil {
+			run.StepStates[out.step.ID] = "failed"
+			run.Steps = append(run.Steps, failedResult(out.step, out.result, out.err))
+			if failErr == nil && !m.routeFailure(run, out.step, out.err) {
+				failErr = out.err
+				cancelSteps()
+			}
+			continue
 		}
+		run.StepStates[out.step.ID] = out.result.Status
+		run.Steps = append(run.Steps, *out.result)
+	}
 
-		run.Steps = append(run.Steps, *result)
+	if failErr != nil {
+		skipStandbySteps(run)
+		run.Status = "failed"
+		run.Error = failErr.Error()
+		run.UpdatedAt = time.Now()
+		m.saveRun(ctx, run)
+		m.emitEvent(ctx, domain.EventWorkflowFailed, map[string]string{
+			"run_id": run.ID,
+			"error":  run.Error,
+		})
+		return run, nil
+	}
 
-		// If this was an approval step, execution pauses.
-		if run.Status == "paused" {
-			m.store.SaveRun(ctx, *run)
-			m.emitEvent(ctx, domain.EventWorkflowPaused, map[string]string{
-				"run_id":  run.ID,
-				"message": run.ApprovalMessage,
-			})
-			return run, nil
-		}
+	// Branches waiting on an approval pause the run.
+	if len(run.Approvals) > 0 {
+		run.Status = "paused"
+		run.ApprovalMessage = run.Approvals[0].Message
+		run.ResumeToken = run.Approvals[0].ResumeToken
+		run.UpdatedAt = time.Now()
+		m.saveRun(ctx, run)
+		m.emitEvent(ctx, domain.EventWorkflowPaused, map[string]string{
+			"run_id":  run.ID,
+			"message": run.ApprovalMessage,
+		})
+		return run, nil
 	}
 
+	skipStandbySteps(run)
 	run.Status = "completed"
 	run.UpdatedAt = time.Now()
-	m.store.SaveRun(ctx, *run)
+	m.saveRun(ctx, run)
 	m.emitEvent(ctx, domain.EventWorkflowCompleted, map[string]string{
 		"run_id":   run.ID,
 		"pipeline": run.PipelineName,
@@ -336,7 +473,177 @@ func (m *Manager) continueExecution(ctx context.Context, run *domain.WorkflowRun
 	return run, nil
 }
 
-func (m *Manager) executeStep(ctx context.Context, run *domain.WorkflowRun, step domain.Step) (*domain.StepResult, error) {
+// saveRun persists where a run ended up: failed, paused or completed. It
+// ignores ctx's deadline, since a run that timed out must still be stored
+// as failed rather than left running for RecoverRuns to pick up.
+func (m *Manager) saveRun(ctx context.Context, run *domain.WorkflowRun) {
+	if err := m.store.SaveRun(context.WithoutCancel(ctx), *run); err != nil {
+		m.logger.Warn("failed to save workflow run", "run_id", run.ID, "status", run.Status, "error", err)
+	}
+}
+
+// checkpoint saves a running run so RecoverRuns can pick it up after a
+// restart. Failures are logged; the run itself carries on.
+func (m *Manager) checkpoint(ctx context.Context, run *domain.WorkflowRun) {
+	run.UpdatedAt = time.Now()
+	if err := m.store.SaveRun(ctx, *run); err != nil {
+		m.logger.Warn("failed to checkpoint workflow run", "run_id", run.ID, "error", err)
+	}
+}
+
+// startReadySteps starts every pending step whose dependencies have
+// finished and returns how many were launched onto goroutines. Skipped
+// steps and approval steps are settled inline, which may make further
+// steps ready, so it loops until nothing changes.
+func (m *Manager) startReadySteps(ctx context.Context, run *domain.WorkflowRun, deps map[string][]string, outcomes chan<- stepOutcome) int {
+	started := 0
+	for progressed := true; progressed; {
+		progressed = false
+		for i, step := range run.Pipeline.Steps {
+			if run.StepStates[step.ID] != "pending" || !dependenciesFinished(run, deps[step.ID]) {
+				continue
+			}
+			progressed = true
+			if i > run.CurrentStep {
+				run.CurrentStep = i
+			}
+
+			// Evaluate condition.
+			if step.Condition != "" {
+				ok, err := m.evaluateCondition(step.Condition, templateScope{prev: run.Steps, args: run.Env})
+				if err != nil {
+					m.logger.Warn("condition evaluation failed", "step", step.ID, "error", err)
+				}
+				if !ok {
+					run.StepStates[step.ID] = "skipped"
+					run.Steps = append(run.Steps, domain.StepResult{
+						StepID: step.ID,
+						Status: "skipped",
+						Output: json.RawMessage(`null`),
+					})
+					continue
+				}
+			}
+
+			// Approval steps pause their branch until resumed.
+			if step.Type == "approval" {
+				result, approval := m.executeApprovalStep(step, templateScope{prev: run.Steps, args: run.Env}, time.Now())
+				run.StepStates[step.ID] = "awaiting_approval"
+				run.Steps = append(run.Steps, *result)
+				run.Approvals = append(run.Approvals, approval)
+				continue
+			}
+
+			// Steps read a snapshot of the finished results so the
+			// scheduler can keep appending while they run.
+			scope := templateScope{prev: append([]domain.StepResult(nil), run.Steps...), args: run.Env}
+			run.StepStates[step.ID] = "running"
+			started++
+			go func(step domain.Step) {
+				outcomes <- m.runStep(ctx, run, step, scope)
+			}(step)
+		}
+	}
+	return started
+}
+
+// dependenciesFinished reports whether every dependency has finished.
+func dependenciesFinished(run *domain.WorkflowRun, deps []string) bool {
+	for _, id := range deps {
+		if !stepFinished(run, id) {
+			return false
+		}
+	}
+	return true
+}
+
+// stepFinished reports whether step id has completed or been skipped, or
+// failed with its on_error handling the failure: at once for "continue",
+// or once the compensation step it routed to has finished.
+func stepFinished(run *domain.WorkflowRun, id string) bool {
+	switch run.StepStates[id] {
+	case "completed", "skipped":
+		return true
+	case "failed":
+		step, ok := findStep(run.Pipeline, id)
+		if !ok {
+			return false
+		}
+		switch step.OnError {
+		case "", "fail":
+			return false
+		case "continue":
+			return true
+		default:
+			return stepFinished(run, step.OnError)
+		}
+	}
+	return false
+}
+
+// stepDependencies returns each step's dependencies. Pipelines that never
+// declare depends_on run sequentially: each step depends on the one
+// before it. Compensation steps stay out of that chain.
+func stepDependencies(p domain.Pipeline) map[string][]string {
+	deps := make(map[string][]string, len(p.Steps))
+	explicit := false
+	for _, s := range p.Steps {
+		if len(s.DependsOn) > 0 {
+			explicit = true
+			break
+		}
+	}
+	compensations := compensationSteps(p)
+	prevID := ""
+	for _, s := range p.Steps {
+		if compensations[s.ID] {
+			continue
+		}
+		switch {
+		case explicit:
+			deps[s.ID] = s.DependsOn
+		case prevID != "":
+			deps[s.ID] = []string{prevID}
+		}
+		prevID = s.ID
+	}
+	return deps
+}
+
+// findStep returns the step of p with the given ID.
+func findStep(p domain.Pipeline, id string) (domain.Step, bool) {
+	for _, s := range p.Steps {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return domain.Step{}, false
+}
+
+// initStepStates fills in missing step states, deriving them from recorded
+// results for runs saved before per-step state was tracked.
+func initStepStates(run *domain.WorkflowRun) {
+	if run.StepStates == nil {
+		run.StepStates = make(map[string]string, len(run.Pipeline.Steps))
+	}
+	for _, r := range run.Steps {
+		if _, ok := run.StepStates[r.StepID]; !ok && !r.IsDetail() {
+			run.StepStates[r.StepID] = r.Status
+		}
+	}
+	compensations := compensationSteps(run.Pipeline)
+	for _, s := range run.Pipeline.Steps {
+		if _, ok := run.StepStates[s.ID]; !ok {
+			if compensations[s.ID] {
+				run.StepStates[s.ID] = "standby"
+			} else {
+				run.StepStates[s.ID] = "pending"
+			}
+		}
+	}
+}
+
+func (m *Manager) executeStep(ctx context.Context, run *domain.WorkflowRun, step domain.Step, scope templateScope) (*domain.StepResult, error) {
 	stepTimeout := step.Timeout
 	if stepTimeout <= 0 {
 		stepTimeout = run.EffectiveTimeout
@@ -348,27 +655,28 @@ func (m *Manager) executeStep(ctx context.Context, run *domain.WorkflowRun, step
 	defer cancel()
 
 	start := time.Now()
-	args := run.Env
 	maxOutput := run.EffectiveMaxOutput
 
 	switch step.Type {
 	case "exec":
-		return m.executeExecStep(ctx, step, run.Steps, args, maxOutput, start)
+		return m.executeExecStep(ctx, step, scope, maxOutput, start)
 	case "http":
-		return m.executeHTTPStep(ctx, step, run.Steps, args, maxOutput, start)
+		return m.executeHTTPStep(ctx, step, scope, maxOutput, start)
 	case "transform":
-		return m.executeTransformStep(step, run.Steps, args, maxOutput, start)
-	case "approval":
-		return m.executeApprovalStep(run, step, run.Steps, args, start)
+		return m.executeTransformStep(step, scope, maxOutput, start)
 	case "tool_call":
-		return m.executeToolCallStep(ctx, step, run.Steps, args, maxOutput, start)
+		return m.executeToolCallStep(ctx, step, scope, maxOutput, start)
+	case "agent":
+		return m.executeAgentStep(ctx, step, scope, maxOutput, start)
+	case "pipeline":
+		return m.executePipelineStep(ctx, run, step, scope, maxOutput, start)
 	default:
 		return nil, domain.NewSubSystemError("workflow", "Manager.executeStep", domain.ErrInvalidInput,
 			fmt.Sprintf("unknown step type %q", step.Type))
 	}
 }
 
-func (m *Manager) executeExecStep(ctx context.Context, step domain.Step, prev []domain.StepResult, args map[string]string, maxOutput int, start time.Time) (*domain.StepResult, error) {
+func (m *Manager) executeExecStep(ctx context.Context, step domain.Step, scope templateScope, maxOutput int, start time.Time) (*domain.StepResult, error) {
 	if err := m.validateCommand(step.Command); err != nil {
 		return nil, err
 	}
@@ -385,7 +693,7 @@ func (m *Manager) executeExecStep(ctx context.Context, step domain.Step, prev []
 	// Resolve template references in args.
 	resolvedArgs := make([]string, len(step.Args))
 	for i, a := range step.Args {
-		resolvedArgs[i] = m.resolveTemplate(a, prev, args)
+		resolvedArgs[i] = m.resolveTemplate(a, scope)
 	}
 
 	stdout, stderr, err := m.shell.Execute(ctx, step.Command, resolvedArgs, workDir)
@@ -415,13 +723,13 @@ func (m *Manager) executeExecStep(ctx context.Context, step domain.Step, prev []
 	}, nil
 }
 
-func (m *Manager) executeHTTPStep(ctx context.Context, step domain.Step, prev []domain.StepResult, args map[string]string, maxOutput int, start time.Time) (*domain.StepResult, error) {
+func (m *Manager) executeHTTPStep(ctx context.Context, step domain.Step, scope templateScope, maxOutput int, start time.Time) (*domain.StepResult, error) {
 	// Resolve templates in URL, Body, and Headers.
-	url := m.resolveTemplate(step.URL, prev, args)
-	body := m.resolveTemplate(step.Body, prev, args)
+	url := m.resolveTemplate(step.URL, scope)
+	body := m.resolveTemplate(step.Body, scope)
 	headers := make(map[string]string, len(step.Headers))
 	for k, v := range step.Headers {
-		headers[k] = m.resolveTemplate(v, prev, args)
+		headers[k] = m.resolveTemplate(v, scope)
 	}
 
 	if url == "" {
@@ -507,7 +815,7 @@ func (m *Manager) executeHTTPStep(ctx context.Context, step domain.Step, prev []
 	}, nil
 }
 
-func (m *Manager) executeTransformStep(step domain.Step, prev []domain.StepResult, args map[string]string, maxOutput int, start time.Time) (*domain.StepResult, error) {
+func (m *Manager) executeTransformStep(step domain.Step, scope templateScope, maxOutput int, start time.Time) (*domain.StepResult, error) {
 	if step.Template == "" {
 		return nil, domain.NewSubSystemError("workflow", "Manager.executeTransformStep", domain.ErrInvalidInput, "template is required")
 	}
@@ -518,7 +826,7 @@ func (m *Manager) executeTransformStep(step domain.Step, prev []domain.StepResul
 			fmt.Sprintf("invalid template: %v", err))
 	}
 
-	data := buildTemplateData(prev, args)
+	data := buildTemplateData(scope)
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return &domain.StepResult{
@@ -539,25 +847,24 @@ func (m *Manager) executeTransformStep(step domain.Step, prev []domain.StepResul
 	}, nil
 }
 
-func (m *Manager) executeApprovalStep(run *domain.WorkflowRun, step domain.Step, prev []domain.StepResult, args map[string]string, start time.Time) (*domain.StepResult, error) {
+func (m *Manager) executeApprovalStep(step domain.Step, scope templateScope, start time.Time) (*domain.StepResult, domain.PendingApproval) {
 	// Resolve template in approval message.
-	message := m.resolveTemplate(step.Message, prev, args)
+	message := m.resolveTemplate(step.Message, scope)
 
 	token := generateWorkflowID(time.Now())
-	run.Status = "paused"
-	run.ApprovalMessage = message
-	run.ResumeToken = token
-	run.UpdatedAt = time.Now()
-
 	return &domain.StepResult{
 		StepID:   step.ID,
 		Status:   "completed",
 		Output:   mustMarshal(map[string]string{"resume_token": token, "message": message}),
 		Duration: time.Since(start),
-	}, nil
+	}, domain.PendingApproval{
+		StepID:      step.ID,
+		Message:     message,
+		ResumeToken: token,
+	}
 }
 
-func (m *Manager) executeToolCallStep(ctx context.Context, step domain.Step, prev []domain.StepResult, args map[string]string, maxOutput int, start time.Time) (*domain.StepResult, error) {
+func (m *Manager) executeToolCallStep(ctx context.Context, step domain.Step, scope templateScope, maxOutput int, start time.Time) (*domain.StepResult, error) {
 	if m.toolExec == nil {
 		return nil, domain.NewDomainError("Manager.executeToolCallStep",
 			domain.ErrToolFailure, "tool executor not configured")
@@ -580,7 +887,7 @@ func (m *Manager) executeToolCallStep(ctx context.Context, step domain.Step, pre
 	// Resolve templates in tool params.
 	params := json.RawMessage(step.ToolParams)
 	if len(params) > 0 {
-		resolved := m.resolveTemplate(string(params), prev, args)
+		resolved := m.resolveTemplate(string(params), scope)
 		params = json.RawMessage(resolved)
 	}
 	if len(params) == 0 {
@@ -638,12 +945,12 @@ func (m *Manager) validateCommand(command string) error {
 		fmt.Sprintf("command %q (base: %q) not in allowlist", command, base))
 }
 
-func (m *Manager) evaluateCondition(condition string, prev []domain.StepResult, args map[string]string) (bool, error) {
+func (m *Manager) evaluateCondition(condition string, scope templateScope) (bool, error) {
 	tmpl, err := template.New("cond").Parse(condition)
 	if err != nil {
 		return false, fmt.Errorf("parse condition: %w", err)
 	}
-	data := buildTemplateData(prev, args)
+	data := buildTemplateData(scope)
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return false, fmt.Errorf("evaluate condition: %w", err)
@@ -652,7 +959,7 @@ func (m *Manager) evaluateCondition(condition string, prev []domain.StepResult,
 	return result != "" && result != "false" && result != "0" && result != "<no value>", nil
 }
 
-func (m *Manager) resolveTemplate(input string, prev []domain.StepResult, args map[string]string) string {
+func (m *Manager) resolveTemplate(input string, scope templateScope) string {
 	if !strings.Contains(input, "{{") {
 		return input
 	}
@@ -661,7 +968,7 @@ func (m *Manager) resolveTemplate(input string, prev []domain.StepResult, args m
 		m.logger.Warn("template parse error, using raw input", "error", err)
 		return input
 	}
-	data := buildTemplateData(prev, args)
+	data := buildTemplateData(scope)
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
 		m.logger.Warn("template execution error, using raw input", "error", err)
@@ -713,35 +1020,61 @@ func (m *Manager) emitEvent(ctx context.Context, eventType domain.EventType, pay
 	})
 }
 
-func buildTemplateData(steps []domain.StepResult, args map[string]string) map[string]any {
-	data := make(map[string]any, len(steps)+1)
-	for _, s := range steps {
-		entry := map[string]any{
+// templateScope is what step templates can reference: the finished step
+// results, the pipeline args and, inside a foreach body, the loop
+// variables.
+type templateScope struct {
+	prev []domain.StepResult
+	args map[string]string
+	loop map[string]any // "item" and "index"; nil outside foreach
+}
+
+func buildTemplateData(scope templateScope) map[string]any {
+	data := make(map[string]any, len(scope.prev)+len(scope.loop)+1)
+	for _, s := range scope.prev {
+		if s.IsDetail() {
+			continue
+		}
+		data[s.StepID] = map[string]any{
 			"status": s.Status,
 			"error":  s.Error,
+			"output": decodeOutput(s.Output),
 		}
-		// Try to unmarshal output as a Go value.
-		var v any
-		if json.Unmarshal(s.Output, &v) == nil {
-			entry["output"] = v
-		} else {
-			entry["output"] = string(s.Output)
-		}
-		data[s.StepID] = entry
 	}
-	if len(args) > 0 {
-		data["args"] = args
+	if len(scope.args) > 0 {
+		data["args"] = scope.args
+	}
+	for k, v := range scope.loop {
+		data[k] = v
 	}
 	return data
 }
 
+// decodeOutput unmarshals a step output as a Go value, falling back to the
+// raw text.
+func decodeOutput(output json.RawMessage) any {
+	var v any
+	if json.Unmarshal(output, &v) == nil {
+		return v
+	}
+	return string(output)
+}
+
 func validatePipeline(p domain.Pipeline) error {
 	if len(p.Steps) == 0 {
 		return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput, "pipeline has no steps")
 	}
+	switch p.OnInterrupt {
+	case "", domain.PipelineInterruptFail, domain.PipelineInterruptResume:
+	default:
+		return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+			fmt.Sprintf("invalid on_interrupt %q (want %q or %q)", p.OnInterrupt,
+				domain.PipelineInterruptFail, domain.PipelineInterruptResume))
+	}
 	seen := make(map[string]bool, len(p.Steps))
 	validTypes := map[string]bool{
 		"exec": true, "http": true, "transform": true, "approval": true, "tool_call": true,
+		"agent": true, "foreach": true, "pipeline": true,
 	}
 	for i, s := range p.Steps {
 		if s.ID == "" {
@@ -759,29 +1092,113 @@ func validatePipeline(p domain.Pipeline) error {
 				fmt.Sprintf("step %q has invalid type %q", s.ID, s.Type))
 		}
 
-		// Semantic validation per step type.
-		switch s.Type {
-		case "exec":
-			if s.Command == "" {
+		if err := validateStepFields(s); err != nil {
+			return err
+		}
+	}
+	if err := validateOnError(p.Steps, seen); err != nil {
+		return err
+	}
+	return validateDependencies(p.Steps, seen)
+}
+
+// validateStepFields checks that s has the fields its type needs and a
+// sane retry policy.
+func validateStepFields(s domain.Step) error {
+	switch s.Type {
+	case "exec":
+		if s.Command == "" {
+			return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+				fmt.Sprintf("step %q (exec) requires command", s.ID))
+		}
+	case "http":
+		if s.URL == "" {
+			return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+				fmt.Sprintf("step %q (http) requires url", s.ID))
+		}
+	case "transform":
+		if s.Template == "" {
+			return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+				fmt.Sprintf("step %q (transform) requires template", s.ID))
+		}
+	case "tool_call":
+		if s.ToolName == "" {
+			return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+				fmt.Sprintf("step %q (tool_call) requires tool_name", s.ID))
+		}
+	case "agent":
+		if err := validateAgentStep(s); err != nil {
+			return err
+		}
+	case "pipeline":
+		if s.Pipeline == "" {
+			return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+				fmt.Sprintf("step %q (pipeline) requires pipeline", s.ID))
+		}
+	case "foreach":
+		if err := validateForEach(s); err != nil {
+			return err
+		}
+	}
+	return validateRetry(s)
+}
+
+// validateDependencies checks that depends_on references existing steps
+// and that the steps form an acyclic graph.
+func validateDependencies(steps []domain.Step, ids map[string]bool) error {
+	deps := make(map[string][]string, len(steps))
+	for _, s := range steps {
+		for _, d := range s.DependsOn {
+			if d == s.ID {
 				return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
-					fmt.Sprintf("step %q (exec) requires command", s.ID))
+					fmt.Sprintf("step %q depends on itself", s.ID))
 			}
-		case "http":
-			if s.URL == "" {
+			if !ids[d] {
 				return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
-					fmt.Sprintf("step %q (http) requires url", s.ID))
+					fmt.Sprintf("step %q depends on unknown step %q", s.ID, d))
 			}
-		case "transform":
-			if s.Template == "" {
-				return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
-					fmt.Sprintf("step %q (transform) requires template", s.ID))
+		}
+		deps[s.ID] = s.DependsOn
+	}
+
+	// Depth-first search; reaching a step that is still on the path
+	// closes a cycle.
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := make(map[string]int, len(steps))
+	var path []string
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case onPath:
+			for i, p := range path {
+				if p == id {
+					cycle := strings.Join(append(path[i:], id), " -> ")
+					return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
+						fmt.Sprintf("dependency cycle: %s", cycle))
+				}
 			}
-		case "tool_call":
-			if s.ToolName == "" {
-				return domain.NewSubSystemError("workflow", "validatePipeline", domain.ErrInvalidInput,
-					fmt.Sprintf("step %q (tool_call) requires tool_name", s.ID))
+		case done:
+			return nil
+		}
+		state[id] = onPath
+		path = append(path, id)
+		for _, d := range deps[id] {
+			if err := visit(d); err != nil {
+				return err
 			}
 		}
+		path = path[:len(path)-1]
+		state[id] = done
+		return nil
+	}
+	for _, s := range steps {
+		if err := visit(s.ID); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -297,6 +298,9 @@ func TestManagerResumeApprove(t *testing.T) {
 	if len(resumed.Steps) != 3 {
 		t.Errorf("expected 3 steps, got %d", len(resumed.Steps))
 	}
+	if _, ok := mgr.runLocks.Load(run.ID); ok {
+		t.Error("run lock should be dropped once the run completes")
+	}
 }
 
 func TestManagerResumeDeny(t *testing.T) {
@@ -320,6 +324,9 @@ func TestManagerResumeDeny(t *testing.T) {
 	if denied.Status != "denied" {
 		t.Errorf("expected denied, got %s", denied.Status)
 	}
+	if _, ok := mgr.runLocks.Load(run.ID); ok {
+		t.Error("run lock should be dropped once the run is denied")
+	}
 }
 
 func TestManagerResumeInvalidToken(t *testing.T) {
@@ -482,7 +489,7 @@ func TestManagerListRuns(t *testing.T) {
 	mgr.RunInline(context.Background(), pipeline, nil, nil)
 	mgr.RunInline(context.Background(), pipeline, nil, nil)
 
-	runs, err := mgr.ListRuns(context.Background(), 10)
+	runs, err := mgr.ListRuns(context.Background(), domain.WorkflowRunFilter{Limit: 10})
 	if err != nil {
 		t.Fatalf("ListRuns: %v", err)
 	}
@@ -1160,3 +1167,418 @@ func TestArgsInTemplate(t *testing.T) {
 		t.Errorf("expected 'hello world', got %q", output)
 	}
 }
+
+// slowCommandExecutor echoes its first argument after a delay and tracks
+// the peak number of concurrent executions.
+type slowCommandExecutor struct {
+	delay   time.Duration
+	mu      sync.Mutex
+	active  int
+	maxSeen int
+}
+
+func (m *slowCommandExecutor) Execute(ctx context.Context, _ string, args []string, _ string) (string, string, error) {
+	m.mu.Lock()
+	m.active++
+	if m.active > m.maxSeen {
+		m.maxSeen = m.active
+	}
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.active--
+		m.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(m.delay):
+	case <-ctx.Done():
+		return "", "", ctx.Err()
+	}
+	if len(args) > 0 {
+		return args[0], "", nil
+	}
+	return "", "", nil
+}
+
+func TestDAGParallelFanIn(t *testing.T) {
+	shell := &slowCommandExecutor{delay: 200 * time.Millisecond}
+	mgr := newTestManager(t, shell)
+
+	pipeline := simplePipeline(
+		domain.Step{ID: "a", Type: "exec", Command: "echo", Args: []string{"A"}},
+		domain.Step{ID: "b", Type: "exec", Command: "echo", Args: []string{"B"}},
+		domain.Step{ID: "c", Type: "exec", Command: "echo", Args: []string{"C"}},
+		domain.Step{ID: "join", Type: "transform", DependsOn: []string{"a", "b", "c"},
+			Template: `{{index (index . "a") "output"}}{{index (index . "b") "output"}}{{index (index . "c") "output"}}`},
+	)
+
+	start := time.Now()
+	run, err := mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if run.Status != "completed" {
+		t.Fatalf("expected completed, got %s (error: %s)", run.Status, run.Error)
+	}
+	if shell.maxSeen != 3 {
+		t.Errorf("expected 3 concurrent steps, got %d", shell.maxSeen)
+	}
+	if elapsed >= 500*time.Millisecond {
+		t.Errorf("independent steps should overlap, took %v", elapsed)
+	}
+
+	last := run.Steps[len(run.Steps)-1]
+	if last.StepID != "join" {
+		t.Fatalf("expected join to finish last, got %q", last.StepID)
+	}
+	var output string
+	json.Unmarshal(last.Output, &output)
+	if output != "ABC" {
+		t.Errorf("expected 'ABC', got %q", output)
+	}
+	for id, state := range run.StepStates {
+		if state != "completed" {
+			t.Errorf("step %q: expected completed, got %s", id, state)
+		}
+	}
+}
+
+func TestDAGFailureStopsRun(t *testing.T) {
+	mgr := newTestManager(t, &slowCommandExecutor{delay: 5 * time.Second})
+
+	pipeline := simplePipeline(
+		domain.Step{ID: "slow", Type: "exec", Command: "echo"},
+		domain.Step{ID: "bad", Type: "exec", Command: "rm"},
+		domain.Step{ID: "after", Type: "transform", Template: "x", DependsOn: []string{"slow", "bad"}},
+	)
+
+	start := time.Now()
+	run, err := mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "failed" {
+		t.Fatalf("expected failed, got %s", run.Status)
+	}
+	if time.Since(start) >= 5*time.Second {
+		t.Error("in-flight steps should be cancelled after a failure")
+	}
+	if run.StepStates["bad"] != "failed" {
+		t.Errorf("bad: expected failed, got %s", run.StepStates["bad"])
+	}
+	if run.StepStates["after"] != "pending" {
+		t.Errorf("after: expected pending, got %s", run.StepStates["after"])
+	}
+}
+
+func TestDAGParallelApprovals(t *testing.T) {
+	mgr := newTestManager(t, &mockCommandExecutor{stdout: "ok"})
+
+	pipeline := simplePipeline(
+		domain.Step{ID: "fetch", Type: "exec", Command: "echo"},
+		domain.Step{ID: "ok-a", Type: "approval", Message: "deploy A?", DependsOn: []string{"fetch"}},
+		domain.Step{ID: "ok-b", Type: "approval", Message: "deploy B?", DependsOn: []string{"fetch"}},
+		domain.Step{ID: "deploy-a", Type: "exec", Command: "echo", DependsOn: []string{"ok-a"}},
+		domain.Step{ID: "deploy-b", Type: "exec", Command: "echo", DependsOn: []string{"ok-b"}},
+		domain.Step{ID: "report", Type: "transform", Template: "done", DependsOn: []string{"deploy-a", "deploy-b"}},
+	)
+
+	run, err := mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "paused" {
+		t.Fatalf("expected paused, got %s", run.Status)
+	}
+	if len(run.Approvals) != 2 {
+		t.Fatalf("expected 2 pending approvals, got %d", len(run.Approvals))
+	}
+	if run.ResumeToken != run.Approvals[0].ResumeToken {
+		t.Error("ResumeToken should mirror the first pending approval")
+	}
+
+	// Approve B first: its branch runs, the run stays paused on A.
+	tokenA := run.Approvals[0].ResumeToken
+	tokenB := run.Approvals[1].ResumeToken
+	run, err = mgr.Resume(context.Background(), tokenB, true)
+	if err != nil {
+		t.Fatalf("Resume B: %v", err)
+	}
+	if run.Status != "paused" {
+		t.Fatalf("expected paused after first approval, got %s", run.Status)
+	}
+	if _, ok := mgr.runLocks.Load(run.ID); !ok {
+		t.Error("run lock should be kept while the run is paused")
+	}
+	if run.StepStates["deploy-b"] != "completed" || run.StepStates["deploy-a"] != "pending" {
+		t.Errorf("unexpected states: %v", run.StepStates)
+	}
+	if run.ResumeToken != tokenA {
+		t.Error("expected the remaining approval's token")
+	}
+
+	if _, err := mgr.Resume(context.Background(), tokenB, true); err == nil {
+		t.Error("expected error reusing a consumed token")
+	}
+
+	run, err = mgr.Resume(context.Background(), tokenA, true)
+	if err != nil {
+		t.Fatalf("Resume A: %v", err)
+	}
+	if run.Status != "completed" {
+		t.Fatalf("expected completed, got %s (error: %s)", run.Status, run.Error)
+	}
+	if run.StepStates["report"] != "completed" {
+		t.Errorf("report: expected completed, got %s", run.StepStates["report"])
+	}
+}
+
+func TestDAGDenyOneApproval(t *testing.T) {
+	mgr := newTestManager(t, &mockCommandExecutor{stdout: "ok"})
+
+	pipeline := simplePipeline(
+		domain.Step{ID: "ok-a", Type: "approval", Message: "A?", DependsOn: []string{}},
+		domain.Step{ID: "ok-b", Type: "approval", Message: "B?", DependsOn: []string{}},
+		domain.Step{ID: "join", Type: "transform", Template: "x", DependsOn: []string{"ok-a", "ok-b"}},
+	)
+
+	run, _ := mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if len(run.Approvals) != 2 {
+		t.Fatalf("expected 2 pending approvals, got %d", len(run.Approvals))
+	}
+	tokenB := run.Approvals[1].ResumeToken
+
+	denied, err := mgr.Resume(context.Background(), run.Approvals[0].ResumeToken, false)
+	if err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if denied.Status != "denied" || denied.StepStates["ok-a"] != "denied" {
+		t.Errorf("expected denied run and step, got %s / %s", denied.Status, denied.StepStates["ok-a"])
+	}
+	if _, err := mgr.Resume(context.Background(), tokenB, true); err == nil {
+		t.Error("expected error resuming a denied run")
+	}
+}
+
+func TestDAGSkippedDependency(t *testing.T) {
+	mgr := newTestManager(t, &mockCommandExecutor{stdout: "ok"})
+
+	pipeline := simplePipeline(
+		domain.Step{ID: "a", Type: "exec", Command: "echo", Condition: "false"},
+		domain.Step{ID: "b", Type: "transform", DependsOn: []string{"a"},
+			Template: `{{index (index . "a") "status"}}`},
+	)
+
+	run, err := mgr.RunInline(context.Background(), pipeline, nil, nil)
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "completed" {
+		t.Fatalf("expected completed, got %s (error: %s)", run.Status, run.Error)
+	}
+	var output string
+	json.Unmarshal(run.Steps[1].Output, &output)
+	if output != "skipped" {
+		t.Errorf("expected 'skipped', got %q", output)
+	}
+}
+
+func TestValidateDependencies(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []domain.Step
+		want  string
+	}{
+		{"unknown", []domain.Step{
+			{ID: "a", Type: "transform", Template: "x", DependsOn: []string{"nope"}},
+		}, `depends on unknown step "nope"`},
+		{"self", []domain.Step{
+			{ID: "a", Type: "transform", Template: "x", DependsOn: []string{"a"}},
+		}, `depends on itself`},
+		{"cycle", []domain.Step{
+			{ID: "a", Type: "transform", Template: "x", DependsOn: []string{"c"}},
+			{ID: "b", Type: "transform", Template: "x", DependsOn: []string{"a"}},
+			{ID: "c", Type: "transform", Template: "x", DependsOn: []string{"b"}},
+		}, `dependency cycle: a -> c -> b -> a`},
+		{"valid", []domain.Step{
+			{ID: "a", Type: "transform", Template: "x"},
+			{ID: "b", Type: "transform", Template: "x", DependsOn: []string{"a"}},
+			{ID: "c", Type: "transform", Template: "x", DependsOn: []string{"a", "b"}},
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePipeline(simplePipeline(tt.steps...))
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// blockingExecutor blocks every command until release is closed.
+type blockingExecutor struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingExecutor) Execute(ctx context.Context, _ string, _ []string, _ string) (string, string, error) {
+	b.started <- struct{}{}
+	select {
+	case <-b.release:
+		return "ok", "", nil
+	case <-ctx.Done():
+		return "", "", ctx.Err()
+	}
+}
+
+func TestManagerCheckpointsRunningSteps(t *testing.T) {
+	shell := &blockingExecutor{started: make(chan struct{}, 1), release: make(chan struct{})}
+	mgr := newTestManager(t, shell)
+
+	pipeline := simplePipeline(
+		domain.Step{ID: "s1", Type: "transform", Template: "first"},
+		domain.Step{ID: "s2", Type: "exec", Command: "echo"},
+	)
+	done := make(chan *domain.WorkflowRun, 1)
+	go func() {
+		run, _ := mgr.RunInline(context.Background(), pipeline, nil, nil)
+		done <- run
+	}()
+	<-shell.started
+
+	runs, err := mgr.ListRuns(context.Background(), domain.WorkflowRunFilter{Status: "running"})
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("expected one running run checkpointed, got %d (%v)", len(runs), err)
+	}
+	if runs[0].StepStates["s1"] != "completed" || runs[0].StepStates["s2"] != "running" || len(runs[0].Steps) != 1 {
+		t.Errorf("unexpected checkpoint: states %v, %d steps", runs[0].StepStates, len(runs[0].Steps))
+	}
+
+	close(shell.release)
+	if run := <-done; run.Status != "completed" {
+		t.Errorf("expected completed, got %s (error: %s)", run.Status, run.Error)
+	}
+}
+
+func TestManagerTimedOutRunIsStored(t *testing.T) {
+	shell := &blockingExecutor{started: make(chan struct{}, 1), release: make(chan struct{})}
+	mgr := newTestManager(t, shell)
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "workflow_runs.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	mgr.store = store
+
+	pipeline := simplePipeline(domain.Step{ID: "s1", Type: "exec", Command: "echo"})
+	run, err := mgr.RunInline(context.Background(), pipeline, nil, &RunOptions{Timeout: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("RunInline: %v", err)
+	}
+	if run.Status != "failed" {
+		t.Fatalf("expected failed, got %s", run.Status)
+	}
+
+	stored, err := store.GetRun(context.Background(), run.ID)
+	if err != nil {
+		t.Fatalf("GetRun: %v", err)
+	}
+	if stored.Status != "failed" {
+		t.Errorf("stored status = %s, want failed", stored.Status)
+	}
+}
+
+// interruptedRun returns a run that stopped while its second step ran.
+func interruptedRun(id, policy string) domain.WorkflowRun {
+	pipeline := simplePipeline(
+		domain.Step{ID: "s1", Type: "transform", Template: "first"},
+		domain.Step{ID: "s2", Type: "exec", Command: "echo"},
+		domain.Step{ID: "s3", Type: "transform", Template: "{{.s2.output}}"},
+	)
+	pipeline.OnInterrupt = policy
+	now := time.Now()
+	return domain.WorkflowRun{
+		ID:           id,
+		PipelineName: pipeline.Name,
+		Status:       "running",
+		Steps:        []domain.StepResult{{StepID: "s1", Status: "completed", Output: json.RawMessage(`"first"`)}},
+		StepStates:   map[string]string{"s1": "completed", "s2": "running", "s3": "pending"},
+		CurrentStep:  1,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Pipeline:     pipeline,
+	}
+}
+
+func TestManagerRecoverRuns(t *testing.T) {
+	mgr := newTestManager(t, &mockCommandExecutor{stdout: "again"})
+	ctx := context.Background()
+
+	child := interruptedRun("child", domain.PipelineInterruptResume)
+	child.ParentRunID = "parent"
+	for _, run := range []domain.WorkflowRun{
+		interruptedRun("resume", domain.PipelineInterruptResume),
+		interruptedRun("fail", ""),
+		child,
+	} {
+		if err := mgr.store.SaveRun(ctx, run); err != nil {
+			t.Fatalf("SaveRun: %v", err)
+		}
+	}
+
+	resumed, err := mgr.RecoverRuns(ctx)
+	if err != nil {
+		t.Fatalf("RecoverRuns: %v", err)
+	}
+	if resumed != 1 {
+		t.Errorf("expected 1 resumed run, got %d", resumed)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	var run *domain.WorkflowRun
+	for {
+		run, _ = mgr.GetRun(ctx, "resume")
+		if run.Status != "running" || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if run.Status != "completed" {
+		t.Fatalf("expected resumed run to complete, got %s (error: %s)", run.Status, run.Error)
+	}
+	if len(run.Steps) != 3 || run.Steps[2].Status != "completed" {
+		t.Errorf("expected s2 rerun and s3 to follow, got %+v", run.Steps)
+	}
+
+	for _, id := range []string{"fail", "child"} {
+		run, _ := mgr.GetRun(ctx, id)
+		if run.Status != "failed" || run.Error != "interrupted by restart" {
+			t.Errorf("%s: expected failed run, got %s (%s)", id, run.Status, run.Error)
+		}
+		if run.StepStates["s2"] != "failed" || run.StepStates["s3"] != "pending" {
+			t.Errorf("%s: unexpected step states %v", id, run.StepStates)
+		}
+	}
+}
+
+func TestValidatePipelineOnInterrupt(t *testing.T) {
+	p := simplePipeline(domain.Step{ID: "a", Type: "transform", Template: "x"})
+	p.OnInterrupt = "retry"
+	if err := validatePipeline(p); err == nil || !strings.Contains(err.Error(), "on_interrupt") {
+		t.Errorf("expected on_interrupt error, got %v", err)
+	}
+	p.OnInterrupt = domain.PipelineInterruptResume
+	if err := validatePipeline(p); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,320 @@
+package workflow
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+
+	"alfred-ai/internal/domain"
+)
+
+// maxSQLiteRuns bounds the finished runs kept by SQLiteStore.
+const maxSQLiteRuns = 10000
+
+// SQLiteStore implements domain.WorkflowStore with SQLite. Runs are indexed
+// by status, pipeline and resume token. Step results live in their own
+// table and are appended as the run progresses, so a checkpoint writes
+// only the steps finished since the last one.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (or creates) a SQLite database at dbPath and runs
+// the schema migration.
+func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("workflowstore: open db: %w", err)
+	}
+	// A single connection serializes writers; WAL keeps readers cheap.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("workflowstore: set WAL mode: %w", err)
+	}
+	if err := migrateSQLiteStore(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("workflowstore: migrate: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func migrateSQLiteStore(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS workflow_runs (
+			id         TEXT PRIMARY KEY,
+			pipeline   TEXT NOT NULL,
+			status     TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL,
+			run        TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_workflow_runs_status ON workflow_runs (status, created_at);
+		CREATE INDEX IF NOT EXISTS idx_workflow_runs_pipeline ON workflow_runs (pipeline, created_at);
+		CREATE INDEX IF NOT EXISTS idx_workflow_runs_created ON workflow_runs (created_at);
+
+		CREATE TABLE IF NOT EXISTS workflow_steps (
+			run_id TEXT NOT NULL,
+			seq    INTEGER NOT NULL,
+			result TEXT NOT NULL,
+			PRIMARY KEY (run_id, seq)
+		);
+
+		CREATE TABLE IF NOT EXISTS workflow_tokens (
+			token  TEXT PRIMARY KEY,
+			run_id TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_workflow_tokens_run ON workflow_tokens (run_id);
+	`)
+	return err
+}
+
+// Close closes the underlying database connection.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) SaveRun(ctx context.Context, run domain.WorkflowRun) error {
+	steps := run.Steps
+	run.Steps = nil
+	data, err := json.Marshal(run)
+	if err != nil {
+		return domain.WrapOp("marshal", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("workflowstore: begin: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO workflow_runs (id, pipeline, status, created_at, updated_at, run)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			pipeline = excluded.pipeline, status = excluded.status,
+			updated_at = excluded.updated_at, run = excluded.run`,
+		run.ID, run.PipelineName, run.Status, run.CreatedAt.UnixNano(), run.UpdatedAt.UnixNano(), string(data))
+	if err != nil {
+		return fmt.Errorf("workflowstore: save run: %w", err)
+	}
+
+	// Steps only grow, so append the ones not stored yet.
+	var stored int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM workflow_steps WHERE run_id = ?", run.ID).Scan(&stored); err != nil {
+		return fmt.Errorf("workflowstore: count steps: %w", err)
+	}
+	if stored > len(steps) {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM workflow_steps WHERE run_id = ? AND seq >= ?", run.ID, len(steps)); err != nil {
+			return fmt.Errorf("workflowstore: trim steps: %w", err)
+		}
+		stored = len(steps)
+	}
+	for i := stored; i < len(steps); i++ {
+		result, err := json.Marshal(steps[i])
+		if err != nil {
+			return domain.WrapOp("marshal", err)
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO workflow_steps (run_id, seq, result) VALUES (?, ?, ?)",
+			run.ID, i, string(result)); err != nil {
+			return fmt.Errorf("workflowstore: save step: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM workflow_tokens WHERE run_id = ?", run.ID); err != nil {
+		return fmt.Errorf("workflowstore: clear tokens: %w", err)
+	}
+	if run.Status == "paused" {
+		tokens := []string{run.ResumeToken}
+		for _, a := range run.Approvals {
+			tokens = append(tokens, a.ResumeToken)
+		}
+		for _, token := range tokens {
+			if token == "" {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, "INSERT OR REPLACE INTO workflow_tokens (token, run_id) VALUES (?, ?)",
+				token, run.ID); err != nil {
+				return fmt.Errorf("workflowstore: save token: %w", err)
+			}
+		}
+	}
+
+	// New runs may push finished ones past the cap.
+	if n, _ := res.RowsAffected(); n > 0 && len(steps) == 0 {
+		if err := evictSQLiteRuns(ctx, tx); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// evictSQLiteRuns deletes the oldest finished runs beyond maxSQLiteRuns.
+func evictSQLiteRuns(ctx context.Context, tx *sql.Tx) error {
+	const finished = `status IN ('completed', 'failed', 'denied')`
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM workflow_runs WHERE `+finished+`
+		ORDER BY created_at DESC LIMIT -1 OFFSET ?`, maxSQLiteRuns)
+	if err != nil {
+		return fmt.Errorf("workflowstore: evict: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("workflowstore: evict: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	for _, id := range ids {
+		if err := deleteSQLiteRun(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetRun(ctx context.Context, id string) (*domain.WorkflowRun, error) {
+	run, err := s.loadRun(ctx, s.db.QueryRowContext(ctx, "SELECT run FROM workflow_runs WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("workflowstore: run %q not found", id)
+	}
+	return run, err
+}
+
+func (s *SQLiteStore) ListRuns(ctx context.Context, filter domain.WorkflowRunFilter) ([]domain.WorkflowRun, error) {
+	query := "SELECT run FROM workflow_runs WHERE 1 = 1"
+	var args []any
+	if filter.Status != "" {
+		query += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.Pipeline != "" {
+		query += " AND pipeline = ?"
+		args = append(args, filter.Pipeline)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+	query += " ORDER BY created_at DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, max(filter.Offset, 0))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("workflowstore: list runs: %w", err)
+	}
+	var raws []string
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("workflowstore: list runs: %w", err)
+		}
+		raws = append(raws, raw)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("workflowstore: list runs: %w", err)
+	}
+
+	runs := make([]domain.WorkflowRun, 0, len(raws))
+	for _, raw := range raws {
+		var run domain.WorkflowRun
+		if err := json.Unmarshal([]byte(raw), &run); err != nil {
+			return nil, fmt.Errorf("workflowstore: decode run: %w", err)
+		}
+		if run.Steps, err = s.loadSteps(ctx, run.ID); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+func (s *SQLiteStore) DeleteRun(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("workflowstore: begin: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var exists int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM workflow_runs WHERE id = ?", id).Scan(&exists); err != nil {
+		return fmt.Errorf("workflowstore: delete run: %w", err)
+	}
+	if exists == 0 {
+		return fmt.Errorf("workflowstore: run %q not found", id)
+	}
+	if err := deleteSQLiteRun(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func deleteSQLiteRun(ctx context.Context, tx *sql.Tx, id string) error {
+	for _, table := range []string{"workflow_steps", "workflow_tokens"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE run_id = ?", id); err != nil {
+			return fmt.Errorf("workflowstore: delete run: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM workflow_runs WHERE id = ?", id); err != nil {
+		return fmt.Errorf("workflowstore: delete run: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetRunByToken(ctx context.Context, token string) (*domain.WorkflowRun, error) {
+	run, err := s.loadRun(ctx, s.db.QueryRowContext(ctx, `
+		SELECT r.run FROM workflow_tokens t JOIN workflow_runs r ON r.id = t.run_id
+		WHERE t.token = ? AND r.status = 'paused'`, token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("workflowstore: no paused run with token %q", token)
+	}
+	return run, err
+}
+
+// loadRun decodes the run selected by row and attaches its steps.
+func (s *SQLiteStore) loadRun(ctx context.Context, row *sql.Row) (*domain.WorkflowRun, error) {
+	var raw string
+	if err := row.Scan(&raw); err != nil {
+		return nil, err
+	}
+	var run domain.WorkflowRun
+	if err := json.Unmarshal([]byte(raw), &run); err != nil {
+		return nil, fmt.Errorf("workflowstore: decode run: %w", err)
+	}
+	steps, err := s.loadSteps(ctx, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	run.Steps = steps
+	return &run, nil
+}
+
+func (s *SQLiteStore) loadSteps(ctx context.Context, runID string) ([]domain.StepResult, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT result FROM workflow_steps WHERE run_id = ? ORDER BY seq", runID)
+	if err != nil {
+		return nil, fmt.Errorf("workflowstore: load steps: %w", err)
+	}
+	defer rows.Close()
+
+	steps := []domain.StepResult{}
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("workflowstore: load steps: %w", err)
+		}
+		var step domain.StepResult
+		if err := json.Unmarshal([]byte(raw), &step); err != nil {
+			return nil, fmt.Errorf("workflowstore: decode step: %w", err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, rows.Err()
+}
@@ -0,0 +1,162 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"alfred-ai/internal/domain"
+)
+
+func newTestSQLiteStore(t *testing.T) (*SQLiteStore, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "workflow_runs.db")
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store, path
+}
+
+func TestSQLiteStoreCheckpoints(t *testing.T) {
+	store, path := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	run := newTestRun("run-1", "running")
+	run.Steps = nil
+	run.StepStates = map[string]string{"s1": "running"}
+	if err := store.SaveRun(ctx, run); err != nil {
+		t.Fatalf("SaveRun: %v", err)
+	}
+
+	run.Steps = append(run.Steps, domain.StepResult{StepID: "s1", Status: "completed", Output: json.RawMessage(`"ok"`)})
+	run.StepStates["s1"] = "completed"
+	store.SaveRun(ctx, run)
+	run.Steps = append(run.Steps, domain.StepResult{StepID: "s2", Status: "completed", Output: json.RawMessage(`2`)})
+	store.SaveRun(ctx, run)
+
+	// Reopen to read back what is on disk.
+	store.Close()
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer store.Close()
+
+	got, err := store.GetRun(ctx, "run-1")
+	if err != nil {
+		t.Fatalf("GetRun: %v", err)
+	}
+	if len(got.Steps) != 2 || got.Steps[0].StepID != "s1" || string(got.Steps[1].Output) != "2" {
+		t.Errorf("unexpected steps %+v", got.Steps)
+	}
+	if got.StepStates["s1"] != "completed" || got.Pipeline.Name != "test-pipeline" {
+		t.Errorf("unexpected run %+v", got)
+	}
+	if !got.CreatedAt.Equal(run.CreatedAt) {
+		t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, run.CreatedAt)
+	}
+}
+
+func TestSQLiteStoreList(t *testing.T) {
+	store, _ := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	for i, spec := range []struct{ id, status, pipeline string }{
+		{"run-1", "completed", "deploy"},
+		{"run-2", "failed", "deploy"},
+		{"run-3", "completed", "backup"},
+		{"run-4", "running", "deploy"},
+	} {
+		run := newTestRun(spec.id, spec.status)
+		run.PipelineName = spec.pipeline
+		run.CreatedAt = now.Add(time.Duration(i) * time.Minute)
+		store.SaveRun(ctx, run)
+	}
+
+	ids := func(filter domain.WorkflowRunFilter) []string {
+		runs, err := store.ListRuns(ctx, filter)
+		if err != nil {
+			t.Fatalf("ListRuns: %v", err)
+		}
+		var out []string
+		for _, r := range runs {
+			out = append(out, r.ID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		filter domain.WorkflowRunFilter
+		want   []string
+	}{
+		{"all newest first", domain.WorkflowRunFilter{}, []string{"run-4", "run-3", "run-2", "run-1"}},
+		{"status", domain.WorkflowRunFilter{Status: "completed"}, []string{"run-3", "run-1"}},
+		{"pipeline", domain.WorkflowRunFilter{Pipeline: "deploy"}, []string{"run-4", "run-2", "run-1"}},
+		{"both", domain.WorkflowRunFilter{Status: "completed", Pipeline: "deploy"}, []string{"run-1"}},
+		{"page", domain.WorkflowRunFilter{Limit: 2, Offset: 1}, []string{"run-3", "run-2"}},
+		{"past the end", domain.WorkflowRunFilter{Offset: 10}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(tt.filter)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSQLiteStoreGetByToken(t *testing.T) {
+	store, _ := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	run := newTestRun("run-1", "paused")
+	run.ResumeToken = "tok-a"
+	run.Approvals = []domain.PendingApproval{{StepID: "a", ResumeToken: "tok-a"}, {StepID: "b", ResumeToken: "tok-b"}}
+	store.SaveRun(ctx, run)
+
+	for _, token := range []string{"tok-a", "tok-b"} {
+		got, err := store.GetRunByToken(ctx, token)
+		if err != nil {
+			t.Fatalf("GetRunByToken(%s): %v", token, err)
+		}
+		if got.ID != "run-1" {
+			t.Errorf("GetRunByToken(%s) = %s", token, got.ID)
+		}
+	}
+
+	// Once the run moves on, its tokens no longer resolve.
+	run.Status = "completed"
+	run.ResumeToken, run.Approvals = "", nil
+	store.SaveRun(ctx, run)
+	if _, err := store.GetRunByToken(ctx, "tok-b"); err == nil {
+		t.Error("expected error for token of a finished run")
+	}
+}
+
+func TestSQLiteStoreDelete(t *testing.T) {
+	store, _ := newTestSQLiteStore(t)
+	ctx := context.Background()
+	store.SaveRun(ctx, newTestRun("run-1", "completed"))
+
+	if err := store.DeleteRun(ctx, "run-1"); err != nil {
+		t.Fatalf("DeleteRun: %v", err)
+	}
+	if _, err := store.GetRun(ctx, "run-1"); err == nil {
+		t.Error("expected error after delete")
+	}
+	if err := store.DeleteRun(ctx, "run-1"); err == nil {
+		t.Error("expected error deleting a missing run")
+	}
+}
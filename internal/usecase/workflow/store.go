@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"sync"
 
@@ -43,7 +45,7 @@ func (s *FileStore) SaveRun(_ context.Context, run domain.WorkflowRun) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.runs[run.ID] = run
+	s.runs[run.ID] = cloneRun(run)
 
 	// Evict oldest runs if over limit.
 	if len(s.runs) > maxWorkflowRuns {
@@ -61,23 +63,27 @@ func (s *FileStore) GetRun(_ context.Context, id string) (*domain.WorkflowRun, e
 	if !ok {
 		return nil, fmt.Errorf("workflowstore: run %q not found", id)
 	}
+	run = cloneRun(run)
 	return &run, nil
 }
 
-func (s *FileStore) ListRuns(_ context.Context, limit int) ([]domain.WorkflowRun, error) {
+func (s *FileStore) ListRuns(_ context.Context, filter domain.WorkflowRunFilter) ([]domain.WorkflowRun, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	runs := make([]domain.WorkflowRun, 0, len(s.runs))
 	for _, r := range s.runs {
-		runs = append(runs, r)
+		if (filter.Status == "" || r.Status == filter.Status) && (filter.Pipeline == "" || r.PipelineName == filter.Pipeline) {
+			runs = append(runs, cloneRun(r))
+		}
 	}
 	sort.Slice(runs, func(i, j int) bool {
 		return runs[i].CreatedAt.After(runs[j].CreatedAt) // newest first
 	})
 
-	if limit > 0 && limit < len(runs) {
-		runs = runs[:limit]
+	runs = runs[min(max(filter.Offset, 0), len(runs)):]
+	if filter.Limit > 0 && filter.Limit < len(runs) {
+		runs = runs[:filter.Limit]
 	}
 	return runs, nil
 }
@@ -98,7 +104,8 @@ func (s *FileStore) GetRunByToken(_ context.Context, token string) (*domain.Work
 	defer s.mu.RUnlock()
 
 	for _, r := range s.runs {
-		if r.ResumeToken == token && r.Status == "paused" {
+		if r.Status == "paused" && (r.ResumeToken == token || r.FindApproval(token) >= 0) {
+			r = cloneRun(r)
 			return &r, nil
 		}
 	}
@@ -161,6 +168,16 @@ func (s *FileStore) evictOldest() {
 	}
 }
 
+// cloneRun copies the slices and maps a run shares with its caller, so a
+// stored run does not change while the manager keeps executing it.
+func cloneRun(run domain.WorkflowRun) domain.WorkflowRun {
+	run.Steps = slices.Clone(run.Steps)
+	run.StepStates = maps.Clone(run.StepStates)
+	run.Approvals = slices.Clone(run.Approvals)
+	run.Env = maps.Clone(run.Env)
+	return run
+}
+
 // writeJSON atomically writes v as indented JSON to path.
 func writeJSON(path string, v any) error {
 	data, err := json.MarshalIndent(v, "", "  ")
@@ -67,7 +67,7 @@ func TestFileStoreList(t *testing.T) {
 	store.SaveRun(ctx, r2)
 	store.SaveRun(ctx, r3)
 
-	runs, err := store.ListRuns(ctx, 2)
+	runs, err := store.ListRuns(ctx, domain.WorkflowRunFilter{Limit: 2})
 	if err != nil {
 		t.Fatalf("ListRuns: %v", err)
 	}
@@ -78,6 +78,40 @@ func TestFileStoreList(t *testing.T) {
 	if runs[0].ID != "run-3" {
 		t.Errorf("expected run-3 first, got %s", runs[0].ID)
 	}
+
+	runs, _ = store.ListRuns(ctx, domain.WorkflowRunFilter{Status: "completed", Offset: 1})
+	if len(runs) != 1 || runs[0].ID != "run-1" {
+		t.Errorf("expected only run-1 on the second page of completed runs, got %v", runs)
+	}
+	runs, _ = store.ListRuns(ctx, domain.WorkflowRunFilter{Pipeline: "other"})
+	if len(runs) != 0 {
+		t.Errorf("expected no runs for another pipeline, got %d", len(runs))
+	}
+	runs, _ = store.ListRuns(ctx, domain.WorkflowRunFilter{Offset: 10})
+	if len(runs) != 0 {
+		t.Errorf("expected no runs past the end, got %d", len(runs))
+	}
+}
+
+func TestFileStoreIsolatesSavedRuns(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	ctx := context.Background()
+	run := newTestRun("run-1", "running")
+	run.StepStates = map[string]string{"s1": "completed"}
+	store.SaveRun(ctx, run)
+
+	// The manager keeps mutating a run after checkpointing it.
+	run.StepStates["s1"] = "failed"
+	run.Steps[0].Status = "failed"
+
+	got, _ := store.GetRun(ctx, "run-1")
+	if got.StepStates["s1"] != "completed" || got.Steps[0].Status != "completed" {
+		t.Errorf("stored run changed with the caller's copy: %+v", got)
+	}
 }
 
 func TestFileStoreDelete(t *testing.T) {